@@ -0,0 +1,198 @@
+// Package audit keeps an append-only JSONL log of sync operations so every
+// push, pull, merge and restore can be traced after the fact.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"dotsync/internal/config"
+)
+
+// FileName is the audit log file inside the config directory
+const FileName = "audit.jsonl"
+
+// Actions
+const (
+	ActionPush    = "push"
+	ActionPull    = "pull"
+	ActionMerge   = "merge"
+	ActionRestore = "restore"
+	ActionBackup  = "backup" // Quick backup
+	ActionResolve = "resolve"
+	ActionDelete  = "delete"
+)
+
+// Results
+const (
+	ResultOK       = "ok"
+	ResultFailed   = "failed"
+	ResultConflict = "conflict"
+	ResultSkipped  = "skipped"
+)
+
+// Entry is one logged file operation
+type Entry struct {
+	Time         time.Time `json:"time"`
+	Action       string    `json:"action"`
+	AppID        string    `json:"app_id,omitempty"`
+	File         string    `json:"file,omitempty"`
+	LocalHash    string    `json:"local_hash,omitempty"`
+	DotfilesHash string    `json:"dotfiles_hash,omitempty"`
+	Result       string    `json:"result"`
+	Error        string    `json:"error,omitempty"`
+	Detail       string    `json:"detail,omitempty"` // e.g. the conflict policy or commit
+	Machine      string    `json:"machine,omitempty"`
+}
+
+// ResultFor returns ResultOK or ResultFailed with the error text
+func ResultFor(err error) (result, message string) {
+	if err != nil {
+		return ResultFailed, err.Error()
+	}
+	return ResultOK, ""
+}
+
+// Log is an audit log file
+type Log struct {
+	path    string
+	machine string
+}
+
+// DefaultPath returns the audit log path for the active profile
+func DefaultPath() string {
+	return filepath.Join(config.ConfigDir(), FileName)
+}
+
+// New creates a Log writing to path
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// WithMachine stamps entries that have no machine with the given name
+func (l *Log) WithMachine(machine string) *Log {
+	l.machine = machine
+	return l
+}
+
+// Path returns the log file path
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes entries to the end of the log. Entries without a
+// timestamp get the current time.
+func (l *Log) Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	now := time.Now()
+	for _, e := range entries {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		if e.Machine == "" {
+			e.Machine = l.machine
+		}
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter selects log entries; zero fields match everything
+type Filter struct {
+	Action string
+	AppID  string
+	File   string // Substring of the file path
+	Since  time.Time
+	Failed bool // Only failed or conflicted entries
+	Limit  int  // Keep only the newest Limit entries
+}
+
+// ParseSince turns a --since value into a cutoff before now: a duration
+// ("12h"), a number of days ("7d") or a date ("2006-01-02")
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use 12h, 7d or 2006-01-02)", value)
+}
+
+// Matches reports whether e passes the filter
+func (f Filter) Matches(e Entry) bool {
+	if f.Action != "" && e.Action != f.Action {
+		return false
+	}
+	if f.AppID != "" && e.AppID != f.AppID {
+		return false
+	}
+	if f.File != "" && !strings.Contains(e.File, f.File) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Failed && e.Result != ResultFailed && e.Result != ResultConflict {
+		return false
+	}
+	return true
+}
+
+// Read returns the entries matching f, oldest first. A missing log is empty;
+// malformed lines are skipped.
+func (l *Log) Read(f Filter) ([]Entry, error) {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	lines := bufio.NewScanner(file)
+	lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lines.Scan() {
+		var e Entry
+		if err := json.Unmarshal(lines.Bytes(), &e); err != nil {
+			continue
+		}
+		if f.Matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[len(entries)-f.Limit:]
+	}
+	return entries, lines.Err()
+}
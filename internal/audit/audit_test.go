@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "logs", FileName)).WithMachine("laptop")
+
+	old := time.Now().Add(-48 * time.Hour)
+	result, msg := ResultFor(errors.New("permission denied"))
+	err := log.Append(
+		Entry{Time: old, Action: ActionPush, AppID: "zsh", File: ".zshrc", Result: ResultOK},
+		Entry{Action: ActionPull, AppID: "nvim", File: "nvim/init.lua", Result: result, Error: msg},
+		Entry{Action: ActionMerge, AppID: "zsh", File: ".zshrc", Result: ResultOK, Machine: "desktop"},
+	)
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	all, err := log.Read(Filter{})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(all))
+	}
+	if all[1].Time.IsZero() || all[1].Machine != "laptop" || all[2].Machine != "desktop" {
+		t.Errorf("Unexpected stamping: %+v", all)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"by action", Filter{Action: ActionPush}, 1},
+		{"by app", Filter{AppID: "zsh"}, 2},
+		{"by file", Filter{File: "init"}, 1},
+		{"since", Filter{Since: time.Now().Add(-time.Hour)}, 2},
+		{"failed", Filter{Failed: true}, 1},
+		{"limit keeps newest", Filter{Limit: 1}, 1},
+	}
+	for _, tt := range tests {
+		got, err := log.Read(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: Read failed: %v", tt.name, err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: got %d entries, want %d", tt.name, len(got), tt.want)
+		}
+	}
+
+	newest, _ := log.Read(Filter{Limit: 1})
+	if newest[0].Action != ActionMerge {
+		t.Errorf("Limit should keep the newest entry, got %+v", newest[0])
+	}
+}
+
+func TestReadMissingAndMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	log := New(path)
+
+	entries, err := log.Read(Filter{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Missing log should be empty, got (%v, %v)", entries, err)
+	}
+
+	os.WriteFile(path, []byte("not json\n{\"action\":\"push\",\"result\":\"ok\"}\n"), 0644)
+	entries, err = log.Read(Filter{})
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected malformed line to be skipped, got (%v, %v)", entries, err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSince(tt.input, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSince(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"dotsync/internal/clone"
 	"dotsync/internal/config"
 	"dotsync/internal/modes"
 	"dotsync/internal/models"
+	"dotsync/internal/vfs"
 )
 
 // BackupManager handles backup operations for machine-specific files
@@ -143,7 +146,7 @@ func (b *BackupManager) ListMachineFiles(machineName string) ([]string, error) {
 	var files []string
 
 	// Walk through dotfiles directory looking for machine folders
-	err := filepath.Walk(b.config.DotfilesPath, func(path string, info os.FileInfo, err error) error {
+	err := vfs.Walk(b.config.DotfilesPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
@@ -174,6 +177,35 @@ func (b *BackupManager) ListMachineFiles(machineName string) ([]string, error) {
 	return files, nil
 }
 
+// AppMachines returns, per app, the machines that have a backup of it
+func (b *BackupManager) AppMachines() (map[string][]string, error) {
+	machines, err := b.ListMachines()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := vfs.ReadDir(b.config.DotfilesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]string{}, nil
+		}
+		return nil, err
+	}
+
+	apps := make(map[string][]string)
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		for _, m := range machines {
+			info, err := vfs.Stat(b.GetMachineBackupPath(e.Name(), m.Name, ""))
+			if err == nil && info.IsDir() {
+				apps[e.Name()] = append(apps[e.Name()], m.Name)
+			}
+		}
+	}
+	return apps, nil
+}
+
 // GetMachineBackupPath returns the backup path for a machine
 func (b *BackupManager) GetMachineBackupPath(appID, machineName, fileName string) string {
 	return filepath.Join(b.config.DotfilesPath, appID, machineName, fileName)
@@ -187,17 +219,37 @@ func (b *BackupManager) getBackupDestPath(appID, relPath string) string {
 // copyFile copies a file from src to dst, creating directories as needed
 func (b *BackupManager) copyFile(src, dst string) error {
 	// Create destination directory
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+	if err := vfs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	srcFile, err := os.Open(src)
+	srcInfo, err := vfs.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source: %w", err)
+	}
+
+	// Clone large files where the filesystem can, copy otherwise
+	if !vfs.Real() || srcInfo.Size() < clone.MinSize || clone.File(src, dst) != nil {
+		if err := copyContent(src, dst); err != nil {
+			return err
+		}
+	}
+
+	// Preserve permissions
+	vfs.Chmod(dst, srcInfo.Mode())
+
+	return nil
+}
+
+// copyContent copies src's bytes into dst
+func copyContent(src, dst string) error {
+	srcFile, err := vfs.Open(src)
 	if err != nil {
 		return fmt.Errorf("failed to open source: %w", err)
 	}
 	defer srcFile.Close()
 
-	dstFile, err := os.Create(dst)
+	dstFile, err := vfs.Create(dst)
 	if err != nil {
 		return fmt.Errorf("failed to create destination: %w", err)
 	}
@@ -206,13 +258,6 @@ func (b *BackupManager) copyFile(src, dst string) error {
 	if _, err := io.Copy(dstFile, srcFile); err != nil {
 		return fmt.Errorf("failed to copy: %w", err)
 	}
-
-	// Preserve permissions
-	srcInfo, err := os.Stat(src)
-	if err == nil {
-		os.Chmod(dst, srcInfo.Mode())
-	}
-
 	return nil
 }
 
@@ -223,7 +268,7 @@ func (b *BackupManager) machinesFilePath() string {
 
 // loadMachinesFile loads the machines.json file
 func (b *BackupManager) loadMachinesFile() (*MachinesFile, error) {
-	data, err := os.ReadFile(b.machinesFilePath())
+	data, err := vfs.ReadFile(b.machinesFilePath())
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +285,7 @@ func (b *BackupManager) loadMachinesFile() (*MachinesFile, error) {
 func (b *BackupManager) saveMachinesFile(mf *MachinesFile) error {
 	// Create .dotsync directory
 	dotsyncDir := filepath.Join(b.config.DotfilesPath, ".dotsync")
-	if err := os.MkdirAll(dotsyncDir, 0755); err != nil {
+	if err := vfs.MkdirAll(dotsyncDir, 0755); err != nil {
 		return err
 	}
 
@@ -249,7 +294,7 @@ func (b *BackupManager) saveMachinesFile(mf *MachinesFile) error {
 		return err
 	}
 
-	return os.WriteFile(b.machinesFilePath(), data, 0644)
+	return vfs.WriteFile(b.machinesFilePath(), data, 0644)
 }
 
 // updateMachinesFile updates the machines.json with current machine
@@ -175,6 +175,25 @@ func TestListMachines(t *testing.T) {
 	}
 }
 
+func TestAppMachines(t *testing.T) {
+	_, bm, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	bm.saveMachinesFile(&MachinesFile{Machines: []Machine{{Name: "laptop"}, {Name: "desktop"}}})
+	os.MkdirAll(filepath.Join(bm.config.DotfilesPath, "zsh", "laptop"), 0755)
+	os.MkdirAll(filepath.Join(bm.config.DotfilesPath, "zsh", "desktop"), 0755)
+	os.MkdirAll(filepath.Join(bm.config.DotfilesPath, "git", "laptop"), 0755)
+	os.MkdirAll(filepath.Join(bm.config.DotfilesPath, "nvim", "lua"), 0755)
+
+	apps, err := bm.AppMachines()
+	if err != nil {
+		t.Fatalf("app machines failed: %v", err)
+	}
+	if len(apps) != 2 || len(apps["zsh"]) != 2 || len(apps["git"]) != 1 {
+		t.Errorf("unexpected app machines %v", apps)
+	}
+}
+
 func TestUpdateMachinesFile(t *testing.T) {
 	_, bm, cleanup := setupTestEnv(t)
 	defer cleanup()
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"dotsync/internal/vfs"
 )
 
 // RestoreResult contains the result of a restore operation
@@ -81,7 +83,7 @@ func (b *BackupManager) Restore(opts RestoreOptions) (*RestoreResult, error) {
 		sourcePath := b.GetMachineBackupPath(appID, opts.SourceMachine, fileName)
 
 		// Check source exists
-		sourceInfo, err := os.Stat(sourcePath)
+		sourceInfo, err := vfs.Stat(sourcePath)
 		if err != nil {
 			result.Errors = append(result.Errors, RestoreError{
 				AppID:    appID,
@@ -104,7 +106,7 @@ func (b *BackupManager) Restore(opts RestoreOptions) (*RestoreResult, error) {
 
 		// Backup current file if requested and exists
 		if opts.BackupCurrent {
-			if _, err := os.Stat(destPath); err == nil {
+			if _, err := vfs.Stat(destPath); err == nil {
 				backupPath := b.getRestoreBackupPath(appID, fileName)
 				if err := b.copyFile(destPath, backupPath); err != nil {
 					result.Errors = append(result.Errors, RestoreError{
@@ -170,7 +172,7 @@ func (b *BackupManager) GetRestorableFiles(sourceMachine string) ([]RestorableFi
 	var files []RestorableFile
 
 	// Walk through dotfiles looking for source machine's files
-	err := filepath.Walk(b.config.DotfilesPath, func(path string, info os.FileInfo, err error) error {
+	err := vfs.Walk(b.config.DotfilesPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
@@ -271,7 +273,7 @@ func (b *BackupManager) getLocalConfigPath(appID, fileName string) string {
 
 	// Check if any existing path matches
 	for _, path := range commonPaths {
-		if _, err := os.Stat(path); err == nil {
+		if _, err := vfs.Stat(path); err == nil {
 			return path
 		}
 	}
@@ -301,14 +303,14 @@ func (b *BackupManager) CompareWithLocal(machineName string, appID, fileName str
 	}
 
 	// Check source
-	if info, err := os.Stat(sourcePath); err == nil {
+	if info, err := vfs.Stat(sourcePath); err == nil {
 		comparison.SourceExists = true
 		comparison.SourceSize = info.Size()
 		comparison.SourceModTime = info.ModTime()
 	}
 
 	// Check local
-	if info, err := os.Stat(localPath); err == nil {
+	if info, err := vfs.Stat(localPath); err == nil {
 		comparison.LocalExists = true
 		comparison.LocalSize = info.Size()
 		comparison.LocalModTime = info.ModTime()
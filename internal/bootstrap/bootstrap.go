@@ -0,0 +1,232 @@
+// Package bootstrap runs the optional install script kept in an app's
+// dotfiles directory (install.sh or bootstrap) the first time the app is
+// pulled onto a machine, e.g. to install tpm or zinit.
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dotsync/internal/layout"
+)
+
+// ScriptNames are the script files looked for in an app's dotfiles
+// directory, in order of preference
+var ScriptNames = []string{"install.sh", "bootstrap"}
+
+// Script is the bootstrap script of one app
+type Script struct {
+	AppID string
+	Path  string
+}
+
+// Find returns the bootstrap script in appDir, or nil when there is none
+func Find(appID, appDir string) *Script {
+	for _, name := range ScriptNames {
+		path := filepath.Join(appDir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return &Script{AppID: appID, Path: path}
+		}
+	}
+	return nil
+}
+
+// AppDir returns the app's dotfiles directory, where its script is kept.
+// Only the default layout has per-app directories; in the others apps have
+// no script.
+func AppDir(dotfilesPath, appID string) (string, bool) {
+	if !layout.IsDefault(layout.For(dotfilesPath)) {
+		return "", false
+	}
+	return filepath.Join(dotfilesPath, appID), true
+}
+
+// Command builds the command for a script. Executable scripts run directly
+// so their shebang is honored; others run with sh. Scripts run in their
+// app's dotfiles directory with DOTSYNC_APP set.
+func Command(s Script) *exec.Cmd {
+	var cmd *exec.Cmd
+	if info, err := os.Stat(s.Path); err == nil && info.Mode()&0111 != 0 {
+		cmd = exec.Command(s.Path)
+	} else {
+		cmd = exec.Command("sh", s.Path)
+	}
+	cmd.Dir = filepath.Dir(s.Path)
+	cmd.Env = append(os.Environ(), "DOTSYNC_APP="+s.AppID)
+	return cmd
+}
+
+// RecordFileName is the file in the config dir listing apps already
+// bootstrapped on this machine
+const RecordFileName = "bootstrap.json"
+
+// Record tracks which apps have run their script on this machine
+type Record struct {
+	Ran map[string]time.Time `json:"ran"` // App ID -> when its script last succeeded
+
+	path string
+}
+
+// LoadRecord loads the record from the config dir; a missing file is an
+// empty record
+func LoadRecord(configDir string) (*Record, error) {
+	r := &Record{Ran: make(map[string]time.Time), path: filepath.Join(configDir, RecordFileName)}
+	data, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return r, err
+	}
+	if r.Ran == nil {
+		r.Ran = make(map[string]time.Time)
+	}
+	return r, nil
+}
+
+// Save writes the record to the config dir
+func (r *Record) Save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0644)
+}
+
+// Done reports whether the app's script already ran on this machine
+func (r *Record) Done(appID string) bool {
+	_, ok := r.Ran[appID]
+	return ok
+}
+
+// Pending returns the scripts of the given apps that haven't run on this
+// machine yet, each app once
+func (r *Record) Pending(dotfilesPath string, appIDs []string) []Script {
+	var scripts []Script
+	seen := make(map[string]bool)
+	for _, appID := range appIDs {
+		if seen[appID] || r.Done(appID) {
+			continue
+		}
+		seen[appID] = true
+		dir, ok := AppDir(dotfilesPath, appID)
+		if !ok {
+			continue
+		}
+		if s := Find(appID, dir); s != nil {
+			scripts = append(scripts, *s)
+		}
+	}
+	return scripts
+}
+
+// Result holds the outcome of one script
+type Result struct {
+	Script   Script
+	Error    error
+	Duration time.Duration
+}
+
+// Session runs scripts one after another with their output on the
+// terminal. It satisfies tea.ExecCommand so the TUI can hand the terminal
+// over while the scripts run.
+type Session struct {
+	Scripts []Script
+	Record  *Record // Successful scripts are marked done and saved, if set
+	Pause   bool    // Wait for Enter after the last script
+	Results []Result
+
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// NewSession creates a session for scripts
+func NewSession(scripts []Script, record *Record) *Session {
+	return &Session{Scripts: scripts, Record: record, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+// WithPause waits for Enter after the last script so its output can be read
+func (s *Session) WithPause() *Session {
+	s.Pause = true
+	return s
+}
+
+// SetStdin sets the input scripts read from
+func (s *Session) SetStdin(r io.Reader) { s.stdin = r }
+
+// SetStdout sets where script output goes
+func (s *Session) SetStdout(w io.Writer) { s.stdout = w }
+
+// SetStderr sets where script errors go
+func (s *Session) SetStderr(w io.Writer) { s.stderr = w }
+
+// Run runs every script, continuing past failures. Failed scripts are not
+// marked done, so they run again after the next pull.
+func (s *Session) Run() error {
+	s.Results = nil
+	for _, script := range s.Scripts {
+		fmt.Fprintf(s.stdout, "==> %s: %s\n", script.AppID, filepath.Base(script.Path))
+		cmd := Command(script)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = s.stdin, s.stdout, s.stderr
+
+		start := time.Now()
+		err := cmd.Run()
+		s.Results = append(s.Results, Result{Script: script, Error: err, Duration: time.Since(start)})
+		if err != nil {
+			fmt.Fprintf(s.stderr, "==> %s failed: %v\n", script.AppID, err)
+		} else if s.Record != nil {
+			s.Record.Ran[script.AppID] = time.Now()
+		}
+		fmt.Fprintln(s.stdout)
+	}
+
+	var err error
+	if s.Record != nil {
+		err = s.Record.Save()
+	}
+	if s.Pause && s.stdin != nil {
+		fmt.Fprint(s.stdout, "Press Enter to return to dotsync...")
+		_, _ = fmt.Fscanln(s.stdin)
+	}
+	return err
+}
+
+// Summary returns a short summary like "Bootstrap: 1/2 scripts ran (tmux failed)"
+func Summary(results []Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var failed []string
+	for _, r := range results {
+		if r.Error != nil {
+			failed = append(failed, r.Script.AppID)
+		}
+	}
+	ran := len(results) - len(failed)
+	if len(failed) == 0 {
+		return fmt.Sprintf("Bootstrap: %d/%d scripts ran", ran, len(results))
+	}
+	return fmt.Sprintf("Bootstrap: %d/%d scripts ran (%s failed)", ran, len(results), strings.Join(failed, ", "))
+}
+
+// HasFailures returns true if any script failed
+func HasFailures(results []Result) bool {
+	for _, r := range results {
+		if r.Error != nil {
+			return true
+		}
+	}
+	return false
+}
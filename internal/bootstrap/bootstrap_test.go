@@ -0,0 +1,142 @@
+package bootstrap
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dotsync/internal/layout"
+)
+
+func writeScript(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"bootstrap only", []string{"bootstrap"}, "bootstrap"},
+		{"install.sh preferred", []string{"bootstrap", "install.sh"}, "install.sh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appDir := filepath.Join(dir, tt.name)
+			os.MkdirAll(appDir, 0755)
+			for _, f := range tt.files {
+				writeScript(t, filepath.Join(appDir, f), "true\n", 0644)
+			}
+
+			s := Find("tmux", appDir)
+			if tt.want == "" {
+				if s != nil {
+					t.Errorf("Expected no script, got %s", s.Path)
+				}
+				return
+			}
+			if s == nil || filepath.Base(s.Path) != tt.want || s.AppID != "tmux" {
+				t.Errorf("Expected %s, got %+v", tt.want, s)
+			}
+		})
+	}
+
+	// A directory named like a script doesn't count
+	os.MkdirAll(filepath.Join(dir, "dir", "install.sh"), 0755)
+	if s := Find("x", filepath.Join(dir, "dir")); s != nil {
+		t.Errorf("Expected a directory to be ignored, got %s", s.Path)
+	}
+}
+
+func TestRecordPending(t *testing.T) {
+	dotfiles := t.TempDir()
+	configDir := t.TempDir()
+	writeScript(t, filepath.Join(dotfiles, "tmux", "install.sh"), "true\n", 0644)
+	writeScript(t, filepath.Join(dotfiles, "zsh", "bootstrap"), "true\n", 0644)
+	os.MkdirAll(filepath.Join(dotfiles, "git"), 0755)
+
+	record, err := LoadRecord(configDir)
+	if err != nil {
+		t.Fatalf("LoadRecord failed: %v", err)
+	}
+	pending := record.Pending(dotfiles, []string{"tmux", "git", "zsh", "tmux"})
+	if len(pending) != 2 || pending[0].AppID != "tmux" || pending[1].AppID != "zsh" {
+		t.Fatalf("Expected tmux and zsh, got %+v", pending)
+	}
+
+	record.Ran["tmux"] = time.Now()
+	if err := record.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadRecord(configDir)
+	if err != nil {
+		t.Fatalf("LoadRecord failed: %v", err)
+	}
+	if pending := loaded.Pending(dotfiles, []string{"tmux", "zsh"}); len(pending) != 1 || pending[0].AppID != "zsh" {
+		t.Errorf("Expected only zsh after tmux ran, got %+v", pending)
+	}
+}
+
+func TestRecordPending_HomeLayout(t *testing.T) {
+	dotfiles := t.TempDir()
+	// A home-layout repo mirrors $HOME, so dotfiles/tmux isn't tmux's
+	writeScript(t, filepath.Join(dotfiles, "tmux", "install.sh"), "true\n", 0644)
+	home, _ := layout.New(layout.Home)
+	if err := layout.Save(dotfiles, home); err != nil {
+		t.Fatal(err)
+	}
+
+	record, _ := LoadRecord(t.TempDir())
+	if pending := record.Pending(dotfiles, []string{"tmux"}); len(pending) != 0 {
+		t.Errorf("Expected no scripts in the home layout, got %+v", pending)
+	}
+}
+
+func TestSessionRun(t *testing.T) {
+	dotfiles := t.TempDir()
+	configDir := t.TempDir()
+	writeScript(t, filepath.Join(dotfiles, "tmux", "install.sh"), "echo \"installing $DOTSYNC_APP\"\ntouch ran\n", 0644)
+	writeScript(t, filepath.Join(dotfiles, "zsh", "bootstrap"), "#!/bin/sh\necho broken >&2\nexit 3\n", 0755)
+
+	record, _ := LoadRecord(configDir)
+	scripts := record.Pending(dotfiles, []string{"tmux", "zsh"})
+
+	var out bytes.Buffer
+	session := NewSession(scripts, record)
+	session.SetStdin(strings.NewReader(""))
+	session.SetStdout(&out)
+	session.SetStderr(&out)
+	if err := session.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "==> tmux: install.sh\ninstalling tmux") || !strings.Contains(out.String(), "broken") {
+		t.Errorf("Expected script output to be shown, got:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(dotfiles, "tmux", "ran")); err != nil {
+		t.Errorf("Expected the script to run in the app directory: %v", err)
+	}
+	if !HasFailures(session.Results) || Summary(session.Results) != "Bootstrap: 1/2 scripts ran (zsh failed)" {
+		t.Errorf("Unexpected summary %q", Summary(session.Results))
+	}
+
+	// Only the successful script is remembered
+	loaded, _ := LoadRecord(configDir)
+	if !loaded.Done("tmux") || loaded.Done("zsh") {
+		t.Errorf("Expected only tmux to be done, got %v", loaded.Ran)
+	}
+}
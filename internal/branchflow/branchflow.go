@@ -0,0 +1,250 @@
+// Package branchflow implements the branch-per-machine workflow of the
+// dotfiles repo: each machine commits and pushes to a branch of its own,
+// and a main branch holds the merged configs every machine pulls from.
+package branchflow
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"dotsync/internal/git"
+)
+
+// Prefix starts the name of every machine branch, e.g. machine/laptop
+const Prefix = "machine/"
+
+// DefaultMain is the merged branch when none is configured
+const DefaultMain = "main"
+
+const remoteName = "origin"
+
+// BranchFor returns the branch of a machine, from its hostname. Characters
+// git refuses in branch names become dashes.
+func BranchFor(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, ".local"))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, host)
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		name = "unknown"
+	}
+	return Prefix + name
+}
+
+// Flow switches, merges and compares the branches of one machine's repo
+type Flow struct {
+	Repo   *git.Repo
+	Main   string // Merged source of truth
+	Branch string // This machine's branch
+}
+
+// New creates the flow of the machine named host. An empty main means
+// DefaultMain.
+func New(repo *git.Repo, main, host string) *Flow {
+	if main == "" {
+		main = DefaultMain
+	}
+	return &Flow{Repo: repo, Main: main, Branch: BranchFor(host)}
+}
+
+// Drift compares a machine branch with main
+type Drift struct {
+	Branch  string
+	Ahead   int  // Commits not merged into main yet
+	Behind  int  // Commits of main the branch hasn't merged
+	Current bool // This machine's branch
+}
+
+// Enter switches the repo to this machine's branch: the local one, the
+// one pushed from an earlier install, or a new one started from main. The
+// branch tracks its namesake on the remote, so pushes publish it.
+func (f *Flow) Enter() error {
+	if !f.Repo.IsRepo() {
+		return fmt.Errorf("not a git repository")
+	}
+	if f.Repo.CurrentBranch() != f.Branch {
+		var err error
+		switch {
+		case slices.Contains(f.Repo.Branches(), f.Branch):
+			err = f.Repo.Checkout(f.Branch)
+		case slices.Contains(f.Repo.RemoteBranches(), remoteName+"/"+f.Branch):
+			err = f.Repo.CreateBranch(f.Branch, remoteName+"/"+f.Branch)
+		default:
+			err = f.Repo.CreateBranch(f.Branch, f.mainRev())
+		}
+		if err != nil {
+			return fmt.Errorf("switch to %s: %w", f.Branch, err)
+		}
+	}
+	if f.Repo.HasRemote() {
+		return f.Repo.SetUpstream(f.Branch, remoteName)
+	}
+	return nil
+}
+
+// Publish pushes this machine's branch
+func (f *Flow) Publish() error {
+	if err := f.Enter(); err != nil {
+		return err
+	}
+	if !f.Repo.HasRemote() {
+		return nil
+	}
+	return f.Repo.PushWithUpstream(remoteName, f.Branch)
+}
+
+// Update brings what other machines merged into main onto this machine's
+// branch. A merge with conflicts is aborted and returned as an error.
+func (f *Flow) Update() error {
+	if f.Repo.HasRemote() {
+		if err := f.Repo.Fetch(); err != nil {
+			return fmt.Errorf("fetch: %w", err)
+		}
+	}
+	if err := f.Enter(); err != nil {
+		return err
+	}
+	rev := f.mainRev()
+	if rev == "" {
+		return nil // No main yet: nothing to bring in
+	}
+	return f.Repo.Merge(rev)
+}
+
+// MergeToMain merges this machine's branch into main and pushes main,
+// then fast-forwards the machine branch to it. The repo ends up back on
+// the machine branch, also when the merge fails.
+func (f *Flow) MergeToMain() error {
+	if err := f.Enter(); err != nil {
+		return err
+	}
+	status, err := f.Repo.GetStatus()
+	if err != nil {
+		return err
+	}
+	if !status.IsClean {
+		return fmt.Errorf("commit or stash the changes in the dotfiles repo first")
+	}
+	hasRemote := f.Repo.HasRemote()
+	if hasRemote {
+		if err := f.Repo.Fetch(); err != nil {
+			return fmt.Errorf("fetch: %w", err)
+		}
+	}
+
+	if err := f.mergeIntoMain(hasRemote); err != nil {
+		_ = f.Repo.Checkout(f.Branch)
+		return err
+	}
+	if err := f.Repo.Checkout(f.Branch); err != nil {
+		return err
+	}
+	if err := f.Repo.Merge(f.Main); err != nil {
+		return err
+	}
+	if hasRemote {
+		return f.Repo.PushWithUpstream(remoteName, f.Branch)
+	}
+	return nil
+}
+
+// mergeIntoMain checks out main, catches it up with the remote and merges
+// the machine branch into it
+func (f *Flow) mergeIntoMain(hasRemote bool) error {
+	remoteMain := remoteName + "/" + f.Main
+	hasRemoteMain := slices.Contains(f.Repo.RemoteBranches(), remoteMain)
+	switch {
+	case slices.Contains(f.Repo.Branches(), f.Main):
+		if err := f.Repo.Checkout(f.Main); err != nil {
+			return fmt.Errorf("switch to %s: %w", f.Main, err)
+		}
+		if hasRemoteMain {
+			if err := f.Repo.Merge(remoteMain); err != nil {
+				return err
+			}
+		}
+	case hasRemoteMain:
+		if err := f.Repo.CreateBranch(f.Main, remoteMain); err != nil {
+			return err
+		}
+	default:
+		// The first merge starts main
+		return f.startMain(hasRemote)
+	}
+
+	if err := f.Repo.Merge(f.Branch); err != nil {
+		return err
+	}
+	if hasRemote {
+		return f.Repo.PushWithUpstream(remoteName, f.Main)
+	}
+	return nil
+}
+
+// startMain creates main at the machine branch
+func (f *Flow) startMain(hasRemote bool) error {
+	if err := f.Repo.CreateBranch(f.Main, f.Branch); err != nil {
+		return err
+	}
+	if hasRemote {
+		return f.Repo.PushWithUpstream(remoteName, f.Main)
+	}
+	return nil
+}
+
+// Drift compares every machine branch, local or pushed by another
+// machine, with main, sorted by branch
+func (f *Flow) Drift() ([]Drift, error) {
+	base := f.mainRev()
+	if base == "" {
+		return nil, fmt.Errorf("no %s branch yet: merge a machine branch to start it", f.Main)
+	}
+
+	refs := make(map[string]string) // Branch -> the ref compared
+	for _, b := range f.Repo.RemoteBranches() {
+		if name, ok := strings.CutPrefix(b, remoteName+"/"); ok && strings.HasPrefix(name, Prefix) {
+			refs[name] = b
+		}
+	}
+	// Local branches hold this machine's unpushed commits
+	for _, b := range f.Repo.Branches() {
+		if strings.HasPrefix(b, Prefix) {
+			refs[b] = b
+		}
+	}
+
+	drift := make([]Drift, 0, len(refs))
+	for name, ref := range refs {
+		ahead, behind, err := f.Repo.AheadBehind(ref, base)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, Drift{Branch: name, Ahead: ahead, Behind: behind, Current: name == f.Branch})
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Branch < drift[j].Branch })
+	return drift, nil
+}
+
+// Upstream returns the revision Update merges into the machine branch,
+// "" before main exists
+func (f *Flow) Upstream() string {
+	return f.mainRev()
+}
+
+// mainRev returns the freshest main: the remote's when it has one, the
+// local branch, or "" before main exists
+func (f *Flow) mainRev() string {
+	if remoteMain := remoteName + "/" + f.Main; slices.Contains(f.Repo.RemoteBranches(), remoteMain) {
+		return remoteMain
+	}
+	if slices.Contains(f.Repo.Branches(), f.Main) {
+		return f.Main
+	}
+	return ""
+}
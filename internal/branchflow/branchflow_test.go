@@ -0,0 +1,161 @@
+package branchflow
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/git"
+)
+
+func TestBranchFor(t *testing.T) {
+	tests := map[string]string{
+		"laptop":          "machine/laptop",
+		"Mac-Mini.local":  "machine/mac-mini",
+		"work box (2)":    "machine/work-box--2",
+		"":                "machine/unknown",
+		"build_01.lan.io": "machine/build_01.lan.io",
+	}
+	for host, want := range tests {
+		if got := BranchFor(host); got != want {
+			t.Errorf("BranchFor(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+// gitEnv isolates git from the user's config and gives commits an author
+func gitEnv(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@test.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@test.com")
+}
+
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	run(t, dir, "add", name)
+	run(t, dir, "commit", "-m", "update "+name)
+}
+
+// machine clones the shared remote for a machine named host
+func machine(t *testing.T, origin, host string) (*Flow, string) {
+	t.Helper()
+	dir := filepath.Join(filepath.Dir(origin), host)
+	run(t, filepath.Dir(origin), "clone", "-q", origin, dir)
+	return New(git.NewRepo(dir), "", host), dir
+}
+
+func TestFlow(t *testing.T) {
+	gitEnv(t)
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	run(t, root, "init", "-q", "--bare", "-b", "main", origin)
+	seed := filepath.Join(root, "seed")
+	run(t, root, "clone", "-q", origin, seed)
+	run(t, seed, "checkout", "-q", "-b", "main")
+	commitFile(t, seed, ".zshrc", "base")
+	run(t, seed, "push", "-q", "origin", "main")
+
+	laptop, laptopDir := machine(t, origin, "laptop")
+	if err := laptop.Enter(); err != nil {
+		t.Fatal(err)
+	}
+	if got := laptop.Repo.CurrentBranch(); got != "machine/laptop" {
+		t.Fatalf("Expected machine/laptop checked out, got %s", got)
+	}
+	commitFile(t, laptopDir, ".vimrc", "laptop")
+	// A plain push publishes the machine branch, as sync pushes do
+	if err := laptop.Repo.Push(); err != nil {
+		t.Fatalf("Push of the machine branch failed: %v", err)
+	}
+
+	desktop, desktopDir := machine(t, origin, "desktop")
+	if err := desktop.Enter(); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, desktopDir, ".tmux.conf", "desktop")
+	if err := desktop.Publish(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := laptop.Repo.Fetch(); err != nil {
+		t.Fatal(err)
+	}
+	drift, err := laptop.Drift()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 2 {
+		t.Fatalf("Expected 2 machine branches, got %+v", drift)
+	}
+	if d := drift[0]; d.Branch != "machine/desktop" || d.Ahead != 1 || d.Behind != 0 || d.Current {
+		t.Errorf("Unexpected desktop drift %+v", d)
+	}
+	if d := drift[1]; d.Branch != "machine/laptop" || d.Ahead != 1 || !d.Current {
+		t.Errorf("Unexpected laptop drift %+v", d)
+	}
+
+	if err := desktop.MergeToMain(); err != nil {
+		t.Fatal(err)
+	}
+	if got := desktop.Repo.CurrentBranch(); got != "machine/desktop" {
+		t.Errorf("Expected to be back on machine/desktop, got %s", got)
+	}
+	if err := laptop.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(laptopDir, ".tmux.conf")); err != nil {
+		t.Error("Expected the desktop's file on the laptop after update")
+	}
+
+	drift, err = laptop.Drift()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := drift[0]; d.Branch != "machine/desktop" || d.Ahead != 0 {
+		t.Errorf("Expected desktop merged into main, got %+v", d)
+	}
+}
+
+func TestMergeToMain_Conflict(t *testing.T) {
+	gitEnv(t)
+	dir := t.TempDir()
+	run(t, dir, "init", "-q", "-b", "main")
+	commitFile(t, dir, ".zshrc", "base")
+
+	flow := New(git.NewRepo(dir), "", "laptop")
+	if err := flow.Enter(); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, ".zshrc", "laptop")
+	run(t, dir, "checkout", "-q", "main")
+	commitFile(t, dir, ".zshrc", "main")
+	run(t, dir, "checkout", "-q", "machine/laptop")
+
+	if err := flow.MergeToMain(); err == nil {
+		t.Fatal("Expected the conflicting merge to fail")
+	}
+	if got := flow.Repo.CurrentBranch(); got != "machine/laptop" {
+		t.Errorf("Expected to be back on machine/laptop, got %s", got)
+	}
+	status, err := flow.Repo.GetStatus()
+	if err != nil || !status.IsClean {
+		t.Errorf("Expected the aborted merge to leave a clean tree, got %+v, %v", status, err)
+	}
+}
@@ -2,6 +2,7 @@ package brew
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -135,3 +136,23 @@ func ExportBrewfile(dir string) (string, error) {
 func (b *BrewInfo) Stats() (formulae, casks, taps int) {
 	return len(b.Formulae), len(b.Casks), len(b.Taps)
 }
+
+// BrewfilePath returns where the Brewfile lives in a dotfiles directory
+func BrewfilePath(dotfilesPath string) string {
+	return filepath.Join(dotfilesPath, "homebrew", "Brewfile")
+}
+
+// ApplyBrewfile installs everything in a Brewfile with brew bundle,
+// streaming brew's output to out
+func ApplyBrewfile(path string, out io.Writer) error {
+	if _, err := exec.LookPath("brew"); err != nil {
+		return fmt.Errorf("homebrew not found")
+	}
+
+	cmd := exec.Command("brew", "bundle", "--file", path)
+	cmd.Stdout, cmd.Stderr = out, out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("brew bundle failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package browser finds the default Firefox and Chromium-family profiles
+// and syncs only their hand-edited files (user.js, chrome/userChrome.css,
+// extension preferences, bookmarks). Caches, cookies, logins and keys are
+// never part of a browser app.
+package browser
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dotsync/internal/models"
+)
+
+// Kind is the profile layout of a browser
+type Kind int
+
+const (
+	KindFirefox Kind = iota
+	KindChromium
+)
+
+// Browser is a browser whose default profile dotsync can sync
+type Browser struct {
+	ID    string
+	Name  string
+	Icon  string
+	Kind  Kind
+	Roots []string // Profile roots relative to home, first existing wins
+}
+
+// Browsers are the supported browsers. IDs match the builtin definitions
+// they replace, which used to sync whole profile roots.
+var Browsers = []Browser{
+	{ID: "firefox", Name: "Firefox", Icon: "🦊", Kind: KindFirefox, Roots: []string{
+		".mozilla/firefox",
+		"snap/firefox/common/.mozilla/firefox",
+		".var/app/org.mozilla.firefox/.mozilla/firefox",
+		"Library/Application Support/Firefox",
+	}},
+	{ID: "chromium", Name: "Chromium", Icon: "🌐", Kind: KindChromium, Roots: []string{
+		".config/chromium",
+		"snap/chromium/common/chromium",
+		"Library/Application Support/Chromium",
+	}},
+	{ID: "google-chrome", Name: "Google Chrome", Icon: "🌐", Kind: KindChromium, Roots: []string{
+		".config/google-chrome",
+		"Library/Application Support/Google/Chrome",
+	}},
+	{ID: "brave", Name: "Brave", Icon: "🦁", Kind: KindChromium, Roots: []string{
+		".config/BraveSoftware/Brave-Browser",
+		"Library/Application Support/BraveSoftware/Brave-Browser",
+	}},
+}
+
+// FirefoxFiles are the synced files of a Firefox profile
+var FirefoxFiles = []string{
+	"user.js",
+	"chrome", // userChrome.css, userContent.css
+	"extension-preferences.json",
+	"extension-settings.json",
+	"containers.json",
+	"handlers.json",
+}
+
+// ChromiumFiles are the synced files of a Chromium profile. Preferences is
+// left out: it mixes settings with account and machine state.
+var ChromiumFiles = []string{
+	"Bookmarks",
+	"Custom Dictionary.txt",
+}
+
+// sensitiveNames are profile files that must never leave the machine:
+// cookies, saved logins, keys, history and session state
+var sensitiveNames = map[string]bool{
+	"cookies.sqlite":         true,
+	"logins.json":            true,
+	"logins-backup.json":     true,
+	"key3.db":                true,
+	"key4.db":                true,
+	"cert9.db":               true,
+	"signedInUser.json":      true,
+	"places.sqlite":          true,
+	"formhistory.sqlite":     true,
+	"sessionstore.jsonlz4":   true,
+	"sessionstore-backups":   true,
+	"cache2":                 true,
+	"Cookies":                true,
+	"Login Data":             true,
+	"Login Data For Account": true,
+	"Web Data":               true,
+	"History":                true,
+	"Sessions":               true,
+	"Code Cache":             true,
+	"GPUCache":               true,
+	"Service Worker":         true,
+}
+
+// IsSensitive reports whether a file name is browser state that is never
+// synced, wherever it appears
+func IsSensitive(name string) bool {
+	return sensitiveNames[name]
+}
+
+// FirefoxDefaultProfile returns the default profile directory under a
+// Firefox root from profiles.ini: the [Install…] default, else the
+// profile marked Default=1, else the only profile
+func FirefoxDefaultProfile(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "profiles.ini"))
+	if err != nil {
+		return ""
+	}
+
+	type profile struct {
+		path     string
+		relative bool
+		isDef    bool
+	}
+	var (
+		installDefault string
+		profiles       []profile
+		section        string
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			if strings.HasPrefix(section, "Profile") {
+				profiles = append(profiles, profile{relative: true})
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(section, "Install") && key == "Default" && installDefault == "":
+			installDefault = value
+		case strings.HasPrefix(section, "Profile") && len(profiles) > 0:
+			p := &profiles[len(profiles)-1]
+			switch key {
+			case "Path":
+				p.path = value
+			case "IsRelative":
+				p.relative = value != "0"
+			case "Default":
+				p.isDef = value == "1"
+			}
+		}
+	}
+
+	resolve := func(path string, relative bool) string {
+		if relative {
+			return filepath.Join(root, filepath.FromSlash(path))
+		}
+		return path
+	}
+	if installDefault != "" {
+		return resolve(installDefault, !filepath.IsAbs(installDefault))
+	}
+	for _, p := range profiles {
+		if p.isDef && p.path != "" {
+			return resolve(p.path, p.relative)
+		}
+	}
+	if len(profiles) == 1 && profiles[0].path != "" {
+		return resolve(profiles[0].path, profiles[0].relative)
+	}
+	return ""
+}
+
+// ChromiumDefaultProfile returns the last used profile directory under a
+// Chromium user data dir, from Local State, falling back to Default
+func ChromiumDefaultProfile(root string) string {
+	if _, err := os.Stat(root); err != nil {
+		return ""
+	}
+	var state struct {
+		Profile struct {
+			LastUsed string `json:"last_used"`
+		} `json:"profile"`
+	}
+	name := "Default"
+	if data, err := os.ReadFile(filepath.Join(root, "Local State")); err == nil {
+		if json.Unmarshal(data, &state) == nil && state.Profile.LastUsed != "" {
+			name = filepath.Base(state.Profile.LastUsed)
+		}
+	}
+	return filepath.Join(root, name)
+}
+
+// DefaultProfile returns the default profile directory of b under home,
+// or "" when the browser has no profile here
+func (b Browser) DefaultProfile(home string) string {
+	for _, root := range b.Roots {
+		root = filepath.Join(home, filepath.FromSlash(root))
+		var profile string
+		if b.Kind == KindFirefox {
+			profile = FirefoxDefaultProfile(root)
+		} else {
+			profile = ChromiumDefaultProfile(root)
+		}
+		if profile != "" {
+			return profile
+		}
+	}
+	return ""
+}
+
+// Files returns the synced files of b's profile layout
+func (b Browser) Files() []string {
+	if b.Kind == KindFirefox {
+		return FirefoxFiles
+	}
+	return ChromiumFiles
+}
+
+// Definitions returns app definitions for the browsers with a default
+// profile under home, listing only the safe files of that profile
+func Definitions(home string) []models.AppDefinition {
+	var defs []models.AppDefinition
+	for _, b := range Browsers {
+		profile := b.DefaultProfile(home)
+		if profile == "" {
+			continue
+		}
+		def := models.AppDefinition{ID: b.ID, Name: b.Name, Category: "browser", Icon: b.Icon}
+		for _, name := range b.Files() {
+			def.ConfigPaths = append(def.ConfigPaths, filepath.Join(profile, name))
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
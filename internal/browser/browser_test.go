@@ -0,0 +1,128 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestFirefoxDefaultProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		ini  string
+		want string
+	}{
+		{
+			name: "install default wins",
+			ini: `[Profile1]
+Name=default
+IsRelative=1
+Path=abc.default
+Default=1
+
+[Profile0]
+Name=default-release
+IsRelative=1
+Path=xyz.default-release
+
+[Install4F96D1932A9F858E]
+Default=xyz.default-release
+Locked=1
+`,
+			want: "xyz.default-release",
+		},
+		{
+			name: "profile marked default",
+			ini:  "[Profile0]\nPath=a.one\n\n[Profile1]\nPath=b.two\nDefault=1\n",
+			want: "b.two",
+		},
+		{
+			name: "single profile",
+			ini:  "[General]\nStartWithLastProfile=1\n\n[Profile0]\nPath=only.profile\n",
+			want: "only.profile",
+		},
+		{
+			name: "ambiguous",
+			ini:  "[Profile0]\nPath=a\n\n[Profile1]\nPath=b\n",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, filepath.Join(root, "profiles.ini"), tt.ini)
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(root, tt.want)
+			}
+			if got := FirefoxDefaultProfile(root); got != want {
+				t.Errorf("FirefoxDefaultProfile() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if got := FirefoxDefaultProfile(t.TempDir()); got != "" {
+		t.Errorf("Expected no profile without profiles.ini, got %q", got)
+	}
+}
+
+func TestChromiumDefaultProfile(t *testing.T) {
+	root := t.TempDir()
+	if got := ChromiumDefaultProfile(root); got != filepath.Join(root, "Default") {
+		t.Errorf("Expected Default without Local State, got %q", got)
+	}
+	writeFile(t, filepath.Join(root, "Local State"), `{"profile":{"last_used":"Profile 2"}}`)
+	if got := ChromiumDefaultProfile(root); got != filepath.Join(root, "Profile 2") {
+		t.Errorf("Expected the last used profile, got %q", got)
+	}
+	if got := ChromiumDefaultProfile(filepath.Join(root, "missing")); got != "" {
+		t.Errorf("Expected no profile for a missing root, got %q", got)
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".mozilla/firefox/profiles.ini"), "[Profile0]\nPath=p.default\nDefault=1\n")
+	os.MkdirAll(filepath.Join(home, ".config/BraveSoftware/Brave-Browser/Default"), 0755)
+
+	defs := Definitions(home)
+	if len(defs) != 2 || defs[0].ID != "firefox" || defs[1].ID != "brave" {
+		t.Fatalf("Expected firefox and brave, got %+v", defs)
+	}
+
+	profile := filepath.Join(home, ".mozilla/firefox/p.default")
+	if defs[0].ConfigPaths[0] != filepath.Join(profile, "user.js") {
+		t.Errorf("Expected profile files, got %v", defs[0].ConfigPaths)
+	}
+	for _, def := range defs {
+		for _, path := range def.ConfigPaths {
+			if IsSensitive(filepath.Base(path)) {
+				t.Errorf("%s syncs sensitive file %s", def.ID, path)
+			}
+		}
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	for _, name := range []string{"cookies.sqlite", "logins.json", "key4.db", "Cookies", "Login Data", "cache2"} {
+		if !IsSensitive(name) {
+			t.Errorf("Expected %s to be sensitive", name)
+		}
+	}
+	for _, name := range []string{"user.js", "userChrome.css", "Bookmarks"} {
+		if IsSensitive(name) {
+			t.Errorf("Expected %s to sync", name)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package changelog diffs the dotfiles repo between two dates or
+// revisions and summarizes the changes per app, e.g. for a weekly
+// "config changelog" kept as markdown.
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dotsync/internal/git"
+)
+
+// File is a changed file of an app
+type File struct {
+	Path    string // Relative to the app's directory
+	OldPath string // Previous path of a renamed file, relative to its app's directory
+	Change  git.Change
+	Added   int
+	Removed int
+	Binary  bool
+}
+
+// App is an app with its changed files
+type App struct {
+	ID      string
+	Files   []File
+	Added   int
+	Removed int
+}
+
+// Changelog is the set of changes between two snapshots of the repo
+type Changelog struct {
+	From    git.Snapshot
+	To      git.Snapshot
+	Commits int
+	Apps    []App // Sorted by app ID
+}
+
+// Files returns the number of changed files
+func (c *Changelog) Files() int {
+	n := 0
+	for _, app := range c.Apps {
+		n += len(app.Files)
+	}
+	return n
+}
+
+// Lines returns the total lines added and removed
+func (c *Changelog) Lines() (added, removed int) {
+	for _, app := range c.Apps {
+		added += app.Added
+		removed += app.Removed
+	}
+	return added, removed
+}
+
+// IsEmpty reports whether nothing changed
+func (c *Changelog) IsEmpty() bool {
+	return len(c.Apps) == 0
+}
+
+// Build diffs the dotfiles repo between from and to. Each is a date
+// (2006-01-02), an age (12h, 7d) or a git revision (hash, branch, tag,
+// HEAD~3); an empty to means HEAD. A date as to includes that whole day.
+// Files outside app directories, such as dotsync's own metadata, are left
+// out.
+func Build(dotfilesPath, from, to string, now time.Time) (*Changelog, error) {
+	repo := git.NewRepo(dotfilesPath)
+	if !repo.IsRepo() {
+		return nil, fmt.Errorf("%s is not a git repository", dotfilesPath)
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	fromSnap, err := resolve(repo, from, now, false)
+	if err != nil {
+		return nil, err
+	}
+	toSnap, err := resolve(repo, to, now, true)
+	if err != nil {
+		return nil, err
+	}
+	return build(repo, fromSnap, toSnap)
+}
+
+// BuildWindow diffs the dotfiles repo between the given times
+func BuildWindow(dotfilesPath string, since, until time.Time) (*Changelog, error) {
+	repo := git.NewRepo(dotfilesPath)
+	if !repo.IsRepo() {
+		return nil, fmt.Errorf("%s is not a git repository", dotfilesPath)
+	}
+	fromSnap, err := repo.SnapshotAt(since)
+	if err != nil {
+		return nil, err
+	}
+	toSnap, err := repo.SnapshotAt(until)
+	if err != nil {
+		return nil, err
+	}
+	return build(repo, fromSnap, toSnap)
+}
+
+// Incoming diffs the dotfiles repo between HEAD and upstream, a revision
+// such as origin/main, counting only what upstream added since the two
+// split: the changes a pull would bring in. It returns nil when upstream
+// has no commits HEAD lacks.
+func Incoming(dotfilesPath, upstream string) (*Changelog, error) {
+	repo := git.NewRepo(dotfilesPath)
+	if !repo.IsRepo() {
+		return nil, fmt.Errorf("%s is not a git repository", dotfilesPath)
+	}
+	head, err := repo.SnapshotOf("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	toSnap, err := repo.SnapshotOf(upstream)
+	if err != nil {
+		return nil, err
+	}
+	fromSnap, err := repo.MergeBase(head, toSnap)
+	if err != nil {
+		return nil, err
+	}
+	if fromSnap.Hash == toSnap.Hash {
+		return nil, nil
+	}
+	return build(repo, fromSnap, toSnap)
+}
+
+func build(repo *git.Repo, from, to git.Snapshot) (*Changelog, error) {
+	c := &Changelog{From: from, To: to}
+	changes, err := repo.Diff(from, to)
+	if err != nil {
+		return nil, err
+	}
+	c.Commits, _ = repo.CountCommits(from, to)
+
+	apps := make(map[string]*App)
+	for _, fc := range changes {
+		appID, rel, ok := splitAppPath(fc.Path)
+		if !ok {
+			continue
+		}
+		app := apps[appID]
+		if app == nil {
+			app = &App{ID: appID}
+			apps[appID] = app
+		}
+		f := File{Path: rel, Change: fc.Change, Added: fc.Added, Removed: fc.Removed, Binary: fc.Binary}
+		if fc.OldPath != "" {
+			f.OldPath = fc.OldPath
+			if oldApp, oldRel, ok := splitAppPath(fc.OldPath); ok && oldApp == appID {
+				f.OldPath = oldRel
+			}
+		}
+		app.Files = append(app.Files, f)
+		app.Added += fc.Added
+		app.Removed += fc.Removed
+	}
+
+	for _, app := range apps {
+		c.Apps = append(c.Apps, *app)
+	}
+	sort.Slice(c.Apps, func(i, j int) bool { return c.Apps[i].ID < c.Apps[j].ID })
+	return c, nil
+}
+
+// splitAppPath splits a repo path into its app ID and the path inside the
+// app's directory
+func splitAppPath(path string) (appID, rel string, ok bool) {
+	appID, rel, ok = strings.Cut(path, "/")
+	if !ok || strings.HasPrefix(appID, ".") {
+		return "", "", false
+	}
+	return appID, rel, true
+}
+
+// resolve turns a date, age or revision into a snapshot. A date counts
+// from its start, or from its end when end is set.
+func resolve(repo *git.Repo, ref string, now time.Time, end bool) (git.Snapshot, error) {
+	if t, ok := parseWhen(ref, now); ok {
+		if end && len(ref) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return repo.SnapshotAt(t)
+	}
+	return repo.SnapshotOf(ref)
+}
+
+// parseWhen parses a date (2006-01-02) or an age (12h, 7d) relative to now
+func parseWhen(value string, now time.Time) (time.Time, bool) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), true
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// Range describes the compared snapshots, e.g. "Thu 2026-10-08 (3f2a1c9) → Thu 2026-10-15 (a81d0e2)"
+func (c *Changelog) Range() string {
+	return describe(c.From) + " → " + describe(c.To)
+}
+
+func describe(s git.Snapshot) string {
+	if s.Hash == "" {
+		return "(empty)"
+	}
+	return fmt.Sprintf("%s (%s)", s.When.Local().Format("Mon 2006-01-02"), s.Hash[:7])
+}
+
+// Lines describes a file's line counts, e.g. "+12 -3" or "binary"
+func (f File) Lines() string {
+	if f.Binary {
+		return "binary"
+	}
+	return fmt.Sprintf("+%d -%d", f.Added, f.Removed)
+}
+
+// Name returns the file's path, with where it came from if it was renamed
+func (f File) Name() string {
+	if f.Change == git.ChangeRenamed {
+		return f.OldPath + " → " + f.Path
+	}
+	return f.Path
+}
+
+// Markdown renders the changelog as a markdown document
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Config changelog\n\n")
+	fmt.Fprintf(&b, "%s\n\n", c.Range())
+
+	if c.IsEmpty() {
+		b.WriteString("No changes.\n")
+		return b.String()
+	}
+
+	added, removed := c.Lines()
+	fmt.Fprintf(&b, "%d commits, %d files changed in %d apps (+%d -%d)\n", c.Commits, c.Files(), len(c.Apps), added, removed)
+	for _, app := range c.Apps {
+		fmt.Fprintf(&b, "\n## %s\n\n", app.ID)
+		for _, f := range app.Files {
+			fmt.Fprintf(&b, "- %s `%s` (%s)\n", f.Change, f.Name(), f.Lines())
+		}
+	}
+	return b.String()
+}
+
+// WriteFile writes the markdown changelog to path, creating parent
+// directories as needed
+func (c *Changelog) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(c.Markdown()), 0644)
+}
@@ -0,0 +1,206 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dotsync/internal/git"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func commit(t *testing.T, wt *gogit.Worktree, dir string, files map[string]string, message string, when time.Time) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		if content == "" {
+			if _, err := wt.Remove(path); err != nil {
+				t.Fatalf("Remove failed: %v", err)
+			}
+			continue
+		}
+		os.MkdirAll(filepath.Dir(full), 0755)
+		os.WriteFile(full, []byte(content), 0644)
+		if _, err := wt.Add(path); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	sig := &object.Signature{Name: "Tester", Email: "t@example.com", When: when}
+	if _, err := wt.Commit(message, &gogit.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+}
+
+func testRepo(t *testing.T, now time.Time) string {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit failed: %v", err)
+	}
+	wt, _ := repo.Worktree()
+
+	commit(t, wt, dir, map[string]string{
+		"zsh/.zshrc":            "a\nb\n",
+		"git/.gitconfig":        "[user]\n",
+		"tmux/.tmux.conf":       "set -g mouse on\n",
+		".dotsync/sources.json": "{}\n",
+	}, "initial", now.Add(-30*24*time.Hour))
+	commit(t, wt, dir, map[string]string{
+		"zsh/.zshrc":       "a\nc\nd\n",
+		"git/.gitconfig":   "",
+		"nvim/init.lua":    "vim.o.number = true\n",
+		".dotsync/x.json":  "{}\n",
+		"tmux/.tmux.conf":  "set -g mouse on\nset -g base-index 1\n",
+		"tmux/laptop/.tmp": "backup\n",
+	}, "weekly changes", now.Add(-2*24*time.Hour))
+	commit(t, wt, dir, map[string]string{"nvim/init.lua": "vim.o.number = false\n"}, "tweak nvim", now.Add(-time.Hour))
+	return dir
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Now()
+	dir := testRepo(t, now)
+
+	c, err := Build(dir, "7d", "", now)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if c.Commits != 2 {
+		t.Errorf("Expected 2 commits, got %d", c.Commits)
+	}
+
+	var ids []string
+	for _, app := range c.Apps {
+		ids = append(ids, app.ID)
+	}
+	if strings.Join(ids, ",") != "git,nvim,tmux,zsh" {
+		t.Fatalf("Expected app directories only, got %v", ids)
+	}
+
+	tests := []struct {
+		app, path string
+		change    git.Change
+		added     int
+		removed   int
+	}{
+		{"git", ".gitconfig", git.ChangeDeleted, 0, 1},
+		{"nvim", "init.lua", git.ChangeAdded, 1, 0},
+		{"zsh", ".zshrc", git.ChangeModified, 2, 1},
+	}
+	for _, tt := range tests {
+		var found *File
+		for _, app := range c.Apps {
+			for i, f := range app.Files {
+				if app.ID == tt.app && f.Path == tt.path {
+					found = &app.Files[i]
+				}
+			}
+		}
+		if found == nil {
+			t.Errorf("%s/%s missing from changelog", tt.app, tt.path)
+			continue
+		}
+		if found.Change != tt.change || found.Added != tt.added || found.Removed != tt.removed {
+			t.Errorf("%s/%s = %s %s, want %s +%d -%d", tt.app, tt.path, found.Change, found.Lines(), tt.change, tt.added, tt.removed)
+		}
+	}
+	if c.Files() != 5 {
+		t.Errorf("Expected 5 files, got %d", c.Files())
+	}
+}
+
+func TestBuild_Revisions(t *testing.T) {
+	now := time.Now()
+	dir := testRepo(t, now)
+
+	c, err := Build(dir, "HEAD~1", "HEAD", now)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if c.Commits != 1 || len(c.Apps) != 1 || c.Apps[0].ID != "nvim" {
+		t.Errorf("Expected only the nvim tweak, got %d commits %+v", c.Commits, c.Apps)
+	}
+
+	// A window older than the repo compares against the empty tree
+	c, err = Build(dir, "60d", now.Add(-20*24*time.Hour).Format("2006-01-02"), now)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if c.From.Hash != "" || c.Commits != 1 || len(c.Apps) != 3 {
+		t.Errorf("Expected the initial commit only, got %d commits %+v", c.Commits, c.Apps)
+	}
+
+	if _, err := Build(dir, "no-such-branch", "", now); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+	if _, err := Build(t.TempDir(), "7d", "", now); err == nil {
+		t.Error("Expected an error outside a git repository")
+	}
+}
+
+func TestIncoming(t *testing.T) {
+	now := time.Now()
+	dir := testRepo(t, now)
+	repo, _ := gogit.PlainOpen(dir)
+	wt, _ := repo.Worktree()
+	head, _ := repo.Head()
+
+	// Upstream got the nvim tweak, this machine went on from the commit
+	// before it with a zsh change
+	upstream := plumbing.NewRemoteReferenceName("origin", head.Name().Short())
+	repo.Storer.SetReference(plumbing.NewHashReference(upstream, head.Hash()))
+	parent, _ := repo.CommitObject(head.Hash())
+	base, _ := parent.Parent(0)
+	if err := wt.Reset(&gogit.ResetOptions{Commit: base.Hash, Mode: gogit.HardReset}); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	commit(t, wt, dir, map[string]string{"zsh/.zshrc": "local\n"}, "local zsh", now)
+
+	c, err := Incoming(dir, upstream.Short())
+	if err != nil {
+		t.Fatalf("Incoming failed: %v", err)
+	}
+	if c == nil || c.Commits != 1 || len(c.Apps) != 1 || c.Apps[0].ID != "nvim" {
+		t.Fatalf("Expected the upstream nvim commit only, got %+v", c)
+	}
+
+	// Nothing comes in once HEAD has it
+	commit(t, wt, dir, map[string]string{"nvim/init.lua": "vim.o.number = false\n"}, "merge", now)
+	head, _ = repo.Head()
+	repo.Storer.SetReference(plumbing.NewHashReference(upstream, head.Hash()))
+	if c, err := Incoming(dir, upstream.Short()); err != nil || c != nil {
+		t.Errorf("Expected nothing incoming, got %+v, %v", c, err)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	now := time.Now()
+	c, err := Build(testRepo(t, now), "7d", "", now)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	md := c.Markdown()
+	for _, want := range []string{"# Config changelog", "## zsh", "- modified `.zshrc` (+2 -1)", "- deleted `.gitconfig`", "2 commits, 5 files changed in 4 apps"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "notes", "changelog.md")
+	if err := c.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != md {
+		t.Errorf("WriteFile wrote %q", data)
+	}
+
+	empty := &Changelog{}
+	if !strings.Contains(empty.Markdown(), "No changes.") {
+		t.Errorf("Expected an empty changelog to say so, got %q", empty.Markdown())
+	}
+}
@@ -0,0 +1,21 @@
+// Package clone copies files by cloning them where the filesystem can
+// share blocks between files (APFS, btrfs, XFS): the copy is instant and
+// takes no space until one side changes. Hard links are never used, since
+// an edit to one side would show through the other.
+package clone
+
+import "errors"
+
+// MinSize is the smallest file worth cloning; below it a buffered copy is
+// as fast and the saved blocks don't matter
+const MinSize = 64 << 10
+
+// ErrUnsupported is returned where the platform cannot clone files
+var ErrUnsupported = errors.New("file cloning not supported on this platform")
+
+// File clones src to dst, replacing dst. It fails when the filesystem
+// cannot clone, or src and dst are on different filesystems; callers fall
+// back to copying. A dst that exists keeps its mode, a new one gets src's.
+func File(src, dst string) error {
+	return cloneFile(src, dst)
+}
@@ -0,0 +1,30 @@
+//go:build darwin
+
+package clone
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile clones src next to dst with clonefile(2), which only creates
+// new files, then renames the clone over dst
+func cloneFile(src, dst string) error {
+	tmp := dst + ".dotsync-clone"
+	os.Remove(tmp)
+	if err := unix.Clonefile(src, tmp, unix.CLONE_NOFOLLOW); err != nil {
+		return err
+	}
+	if info, err := os.Stat(dst); err == nil {
+		if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
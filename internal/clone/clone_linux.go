@@ -0,0 +1,49 @@
+//go:build linux
+
+package clone
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile shares src's blocks with a temp file next to dst with the
+// FICLONE ioctl, then renames it over dst. Filesystems without reflinks
+// fail the ioctl, and dst is left as it was for the fallback copy.
+func cloneFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	perm := info.Mode().Perm()
+	if dstInfo, err := os.Stat(dst); err == nil {
+		perm = dstInfo.Mode().Perm()
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".dotsync-clone-*")
+	if err != nil {
+		return err
+	}
+	tmp := out.Name()
+	err = unix.IoctlFileClone(int(out.Fd()), int(in.Fd()))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
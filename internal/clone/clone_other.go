@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package clone
+
+// cloneFile is unsupported on this platform
+func cloneFile(src, dst string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,52 @@
+package clone
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	data := bytes.Repeat([]byte("dotsync "), MinSize/8)
+	os.WriteFile(src, data, 0600)
+	os.WriteFile(dst, []byte("old"), 0644)
+
+	if err := File(src, dst); err != nil {
+		// ext4, tmpfs and most CI disks cannot clone
+		t.Skipf("filesystem cannot clone: %v", err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Errorf("Expected the clone to match the source, got %d bytes", len(got))
+	}
+	if info, _ := os.Stat(dst); info.Mode().Perm() != 0644 {
+		t.Errorf("Expected the existing mode kept, got %v", info.Mode())
+	}
+
+	// The clone is a copy, not a link
+	os.WriteFile(dst, []byte("changed"), 0644)
+	if got, _ := os.ReadFile(src); !bytes.Equal(got, data) {
+		t.Error("Expected the source unchanged by a write to the clone")
+	}
+}
+
+func TestFile_FailureKeepsDst(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	os.WriteFile(src, bytes.Repeat([]byte("dotsync "), MinSize/8), 0600)
+	os.WriteFile(dst, []byte("old"), 0644)
+
+	if err := File(src, dst); err == nil {
+		t.Skip("filesystem can clone")
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "old" {
+		t.Errorf("Expected a failed clone to leave dst as it was, got %q", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("Expected no temp file left behind, got %v", entries)
+	}
+}
@@ -1,23 +1,204 @@
 package config
 
 import (
-	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"dotsync/internal/policy"
+	"dotsync/internal/subtree"
+	"dotsync/internal/symlink"
 
 	"github.com/go-git/go-git/v5"
 )
 
 // Config holds the application configuration
 type Config struct {
-	DotfilesPath string `json:"dotfiles_path"` // Path to dotfiles directory
-	BackupPath   string `json:"backup_path"`   // Path for backups
-	AppsConfig   string `json:"apps_config"`   // Path to apps.yaml (optional)
-	FirstRun     bool   `json:"-"`             // Is this the first run?
+	DotfilesPath     string                   `json:"dotfiles_path"`                // Path to dotfiles directory
+	BackupPath       string                   `json:"backup_path"`                  // Path for backups
+	AppsConfig       string                   `json:"apps_config"`                  // Path to apps.yaml (optional)
+	HealthChecks     bool                     `json:"health_checks"`                // Run app health probes after pull
+	Bootstrap        bool                     `json:"bootstrap"`                    // Run each app's install.sh/bootstrap from dotfiles after its first pull here
+	GitUserName      string                   `json:"git_user_name"`                // Commit identity for the dotfiles repo
+	GitUserEmail     string                   `json:"git_user_email"`               // Commit email for the dotfiles repo
+	GitSigningKey    string                   `json:"git_signing_key"`              // Signing key for dotfiles commits (optional)
+	RemoteBackend    string                   `json:"remote_backend"`               // Where the store is published: git, rclone, s3, git+rclone, git+s3, gist
+	RemoteTarget     string                   `json:"remote_target"`                // rclone remote, s3:// URL or gist ID for non-git backends
+	MachineBranches  bool                     `json:"machine_branches,omitempty"`   // Push to this machine's own branch and merge into MainBranch
+	MainBranch       string                   `json:"main_branch,omitempty"`        // Merged branch every machine pulls from (empty = main)
+	NestedRepos      string                   `json:"nested_repos"`                 // How to sync nested git repos: copy (default), manifest, submodule
+	Symlinks         string                   `json:"symlinks"`                     // How to sync symlinked configs: follow, copy-target, skip, preserve-as-link
+	SymlinkPolicies  map[string]string        `json:"symlink_policies,omitempty"`   // Per-app symlink policy overriding Symlinks
+	Xattrs           string                   `json:"xattrs"`                       // Extended attributes: strip (quarantine/metadata), preserve (also carry the rest), off
+	IconSet          string                   `json:"icon_set"`                     // Status icons: default, shapes, labels
+	Theme            string                   `json:"theme"`                        // UI colors: dark, light, solarized, catppuccin, custom
+	Language         string                   `json:"language"`                     // UI language: en, vi (empty = from $LANG)
+	ReadOnly         bool                     `json:"read_only"`                    // Disable push, pull and git changes; scans, diffs and previews still work
+	FlatAppList      bool                     `json:"flat_app_list"`                // List apps without category headers
+	Dashboard        bool                     `json:"dashboard"`                    // Open the dashboard after the startup scan
+	NotificationsOff bool                     `json:"notifications_off"`            // No desktop notifications for quick backups, conflicts and failed syncs
+	DiffTool         string                   `json:"diff_tool"`                    // External diff tool for d (empty = built-in view)
+	MergeTool        string                   `json:"merge_tool"`                   // External merge tool for m (empty = built-in view)
+	ReportFile       string                   `json:"report_file"`                  // Where `dotsync report` writes the drift summary
+	ReportEmail      string                   `json:"report_email"`                 // Email the drift summary via sendmail/msmtp
+	RegistryURL      string                   `json:"registry_url"`                 // Community app-definition catalog fetched by `dotsync registry`
+	RegistryKey      string                   `json:"registry_key"`                 // Base64 ed25519 public key the catalog must be signed with
+	PublicRepo       string                   `json:"public_repo,omitempty"`        // Repo `dotsync share` writes sanitized configs to
+	DisabledApps     []string                 `json:"disabled_apps,omitempty"`      // App IDs ignored by the scanner
+	AppAliases       map[string]string        `json:"app_aliases,omitempty"`        // Alias app ID -> canonical app ID
+	SubtreeRules     map[string]subtree.Rules `json:"subtree_rules,omitempty"`      // Per-app include/exclude within config dirs
+	VolatileKeys     map[string][]string      `json:"volatile_keys,omitempty"`      // Per-app JSONPath rules for keys kept out of dotfiles
+	ShellBlocks      []string                 `json:"shell_blocks,omitempty"`       // Shell rc blocks (kind:name) synced via the managed include
+	SSHLocalHosts    []string                 `json:"ssh_local_hosts,omitempty"`    // ~/.ssh/config Host patterns kept machine-only
+	GitProtectedKeys []string                 `json:"git_protected_keys,omitempty"` // Extra ~/.gitconfig keys kept per machine (identity and credentials always are)
+	GitIdentity      map[string]string        `json:"git_identity,omitempty"`       // This machine's values for protected gitconfig keys, e.g. user.email
+	DconfPaths       []string                 `json:"dconf_paths,omitempty"`        // dconf directories captured as GNOME Settings (empty = defaults)
+	KDEGroups        []string                 `json:"kde_groups,omitempty"`         // KDE rc groups captured as KDE Settings, "rcfile:Group" or "rcfile"
+	Conflicts        policy.Rules             `json:"conflict_policy"`              // Auto-resolution for files changed on both sides
+	ScanMaxDepth     int                      `json:"scan_max_depth,omitempty"`     // Directory levels scanned below a config dir (0 = default)
+	ScanMaxFiles     int                      `json:"scan_max_files,omitempty"`     // Files collected per app (0 = default)
+	ScanIgnore       []string                 `json:"scan_ignore,omitempty"`        // Glob patterns of files and dirs the scan skips
+	SystemConfigs    bool                     `json:"system_configs,omitempty"`     // Sync /etc configs separately, under dotfiles/system with sudo
+	NoColor          bool                     `json:"no_color,omitempty"`           // Plain output without colors
+	Plain            bool                     `json:"plain,omitempty"`              // ASCII only: no colors, emoji or box-drawing glyphs
+	Accessible       bool                     `json:"accessible,omitempty"`         // Screen-reader mode: linear panels, status changes printed as lines
+	PanelSplit       int                      `json:"panel_split,omitempty"`        // Apps panel width in percent of the window (0 = half)
+	LogLevel         string                   `json:"log_level,omitempty"`          // Least severe records written to the log file: debug, info, warn, error (empty = info)
+	FirstRun         bool                     `json:"-"`                            // Is this the first run?
+
+	savedDotfilesPath string      // DotfilesPath from the config file while an override is active
+	saved             map[int]any // Values from the config file of fields overridden by env or flags
+}
+
+// Environment variables that override the saved config for one invocation
+const (
+	ProfileEnv      = "DOTSYNC_PROFILE"
+	DotfilesPathEnv = "DOTSYNC_DOTFILES_PATH"
+	ReadOnlyEnv     = "DOTSYNC_READ_ONLY"
+)
+
+var (
+	activeProfile        string // Named profile with its own config and sync state
+	dotfilesPathOverride string // Dotfiles path used instead of the saved one
+	forceReadOnly        bool   // Read-only for this process, whatever the config says
+)
+
+// ErrReadOnly is returned for writes attempted in read-only mode
+var ErrReadOnly = errors.New("read-only mode: push, pull and git changes are disabled")
+
+// SetProfile selects a named profile for this process. Each profile keeps
+// its own dotsync.yaml and sync state under ~/.config/dotsync/profiles/NAME.
+func SetProfile(name string) {
+	activeProfile = filepath.Base(strings.TrimSpace(name))
+	if activeProfile == "." || activeProfile == string(filepath.Separator) {
+		activeProfile = ""
+	}
+}
+
+// Profile returns the active profile name, or "" for the default config
+func Profile() string {
+	return activeProfile
+}
+
+// Profiles lists the named profiles that have a config dir, sorted. The
+// default config is not included.
+func Profiles() []string {
+	homeDir, _ := os.UserHomeDir()
+	entries, err := os.ReadDir(filepath.Join(homeDir, ".config", "dotsync", "profiles"))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// SetDotfilesPathOverride makes Load use path as the dotfiles path without
+// saving it, unless the path is then changed explicitly
+func SetDotfilesPathOverride(path string) {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "~/") {
+		homeDir, _ := os.UserHomeDir()
+		path = filepath.Join(homeDir, path[2:])
+	}
+	dotfilesPathOverride = path
+}
+
+// SetReadOnly forces read-only mode for this process without saving it
+func SetReadOnly() {
+	forceReadOnly = true
+}
+
+// ApplyEnv fills the profile, dotfiles path and other setting overrides
+// from the environment when they were not set explicitly (flags win over
+// env). Invalid values are returned and ignored.
+func ApplyEnv() error {
+	if activeProfile == "" {
+		SetProfile(os.Getenv(ProfileEnv))
+	}
+	if dotfilesPathOverride == "" {
+		SetDotfilesPathOverride(os.Getenv(DotfilesPathEnv))
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(ReadOnlyEnv))) {
+	case "1", "true", "yes", "on":
+		SetReadOnly()
+	}
+	return errors.Join(applyEnvOverrides()...)
+}
+
+// IsReadOnly returns true if writes are disabled, by the config or for
+// this process
+func (c *Config) IsReadOnly() bool {
+	return forceReadOnly || (c != nil && c.ReadOnly)
+}
+
+// IsReadOnlyForced returns true if read-only mode comes from a flag or env
+// var rather than the config
+func IsReadOnlyForced() bool {
+	return forceReadOnly
+}
+
+// CheckWritable returns ErrReadOnly in read-only mode
+func (c *Config) CheckWritable() error {
+	if c.IsReadOnly() {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// applyOverride swaps in the dotfiles path override, remembering the saved one
+func (c *Config) applyOverride() {
+	if dotfilesPathOverride == "" {
+		return
+	}
+	c.savedDotfilesPath = c.DotfilesPath
+	c.DotfilesPath = dotfilesPathOverride
+}
+
+// SymlinkPolicy returns how symlinked configs of an app sync
+func (c *Config) SymlinkPolicy(appID string) symlink.Policy {
+	if p, ok := c.SymlinkPolicies[appID]; ok {
+		return symlink.ParsePolicy(p)
+	}
+	return symlink.ParsePolicy(c.Symlinks)
+}
+
+// IsOverridden returns true if the dotfiles path comes from a flag or env var
+func (c *Config) IsOverridden() bool {
+	return dotfilesPathOverride != "" && c.DotfilesPath == dotfilesPathOverride
 }
 
 // configFileName is the name of the config file
-const configFileName = "dotsync.json"
+const configFileName = "dotsync.yaml"
+
+// legacyConfigFileName is the JSON config of earlier versions, read until
+// the first save replaces it with dotsync.yaml
+const legacyConfigFileName = "dotsync.json"
 
 // Default returns the default configuration
 func Default() *Config {
@@ -33,32 +214,45 @@ func Default() *Config {
 
 // ConfigPath returns the path to the config file
 func ConfigPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".config", "dotsync", configFileName)
+	return filepath.Join(ConfigDir(), configFileName)
 }
 
-// Load loads the configuration from file
+// ThemePath returns the custom theme file used by the "custom" theme
+func ThemePath() string {
+	return filepath.Join(ConfigDir(), "theme.json")
+}
+
+// Load loads the configuration from file. A file with unknown keys or
+// invalid values fails with ParseErrors naming the lines.
 func Load() (*Config, error) {
 	configPath := ConfigPath()
 
 	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		configPath = filepath.Join(ConfigDir(), legacyConfigFileName)
+		data, err = os.ReadFile(configPath)
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			// First run - return default config
 			cfg := Default()
 			cfg.FirstRun = true
+			cfg.applyOverride()
+			cfg.applyOverrides()
 			return cfg, nil
 		}
 		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	cfg, err := decode(data, configPath)
+	if err != nil {
 		return nil, err
 	}
 
 	cfg.FirstRun = false
-	return &cfg, nil
+	cfg.applyOverride()
+	cfg.applyOverrides()
+	return cfg, nil
 }
 
 // Save saves the configuration to file
@@ -70,12 +264,57 @@ func (c *Config) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	// Keep the saved settings unless they were changed on purpose
+	out := *c
+	if c.IsOverridden() {
+		out.DotfilesPath = c.savedDotfilesPath
+	}
+	out.restoreSaved()
+
+	// Comments and order added by hand are kept
+	prev, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	data, err := encodeOver(prev, &out)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	if err := writeFileAtomic(configPath, data, 0644); err != nil {
+		return err
+	}
+	// Migrated: the YAML file now holds everything the JSON one did
+	if err := os.Remove(filepath.Join(ConfigDir(), legacyConfigFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// into place, so an interrupted save leaves the old file as it was
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 // EnsureDirectories creates necessary directories and initializes git repo if needed
@@ -143,10 +382,15 @@ func SuggestedPaths() []string {
 	}
 }
 
-// ConfigDir returns the directory containing dotsync config files
+// ConfigDir returns the directory containing dotsync config files for the
+// active profile
 func ConfigDir() string {
 	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".config", "dotsync")
+	dir := filepath.Join(homeDir, ".config", "dotsync")
+	if activeProfile != "" {
+		dir = filepath.Join(dir, "profiles", activeProfile)
+	}
+	return dir
 }
 
 // StatePath returns the path to the sync state file
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -32,8 +33,8 @@ func TestConfigPath(t *testing.T) {
 	if !filepath.IsAbs(path) {
 		t.Error("ConfigPath should return absolute path")
 	}
-	if filepath.Base(path) != "dotsync.json" {
-		t.Errorf("Expected config file name 'dotsync.json', got %s", filepath.Base(path))
+	if filepath.Base(path) != "dotsync.yaml" {
+		t.Errorf("Expected config file name 'dotsync.yaml', got %s", filepath.Base(path))
 	}
 }
 
@@ -162,26 +163,44 @@ func TestEnsureDirectories(t *testing.T) {
 func TestSaveAndLoad(t *testing.T) {
 	// Create temp config directory
 	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
 	configDir := filepath.Join(tempDir, ".config", "dotsync")
 	os.MkdirAll(configDir, 0755)
 
-	// Create a config and save it
-	cfg := &Config{
-		DotfilesPath: "/test/dotfiles",
-		BackupPath:   "/test/backup",
-		AppsConfig:   "/test/apps.yaml",
-	}
-
 	// Write directly to temp location for testing
-	configPath := filepath.Join(configDir, "dotsync.json")
-	data := []byte(`{"dotfiles_path": "/test/dotfiles", "backup_path": "/test/backup", "apps_config": "/test/apps.yaml"}`)
+	configPath := filepath.Join(configDir, "dotsync.yaml")
+	data := []byte("dotfiles_path: /test/dotfiles\nbackup_path: /test/backup\napps_config: /test/apps.yaml\n")
 	if err := os.WriteFile(configPath, data, 0644); err != nil {
 		t.Fatalf("Failed to write test config: %v", err)
 	}
 
-	// Verify we can read the format
-	if cfg.DotfilesPath != "/test/dotfiles" {
-		t.Errorf("Expected /test/dotfiles, got %s", cfg.DotfilesPath)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.DotfilesPath != "/test/dotfiles" || cfg.BackupPath != "/test/backup" || cfg.AppsConfig != "/test/apps.yaml" {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+}
+
+func TestLoadLegacyJSON(t *testing.T) {
+	// Configs of earlier versions are dotsync.json, read until the next save
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	configDir := filepath.Join(tempDir, ".config", "dotsync")
+	os.MkdirAll(configDir, 0755)
+
+	data := []byte(`{"dotfiles_path": "/test/dotfiles", "backup_path": "/test/backup"}`)
+	if err := os.WriteFile(filepath.Join(configDir, "dotsync.json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.FirstRun || cfg.DotfilesPath != "/test/dotfiles" {
+		t.Errorf("Expected the legacy JSON config loaded, got %+v", cfg)
 	}
 }
 
@@ -243,7 +262,7 @@ func TestSave(t *testing.T) {
 	}
 
 	// Verify file was written to temp dir
-	data, err := os.ReadFile(filepath.Join(configDir, "dotsync.json"))
+	data, err := os.ReadFile(filepath.Join(configDir, "dotsync.yaml"))
 	if err != nil {
 		t.Fatalf("Failed to read saved config: %v", err)
 	}
@@ -265,20 +284,22 @@ func TestLoadWithExistingConfig(t *testing.T) {
 	}
 }
 
-func TestLoadWithInvalidJSON(t *testing.T) {
-	// Create temp config with invalid JSON
+func TestLoadWithInvalidYAML(t *testing.T) {
+	// Create temp config with invalid YAML
 	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
 	configDir := filepath.Join(tempDir, ".config", "dotsync")
 	os.MkdirAll(configDir, 0755)
 
-	// Write invalid JSON
-	configPath := filepath.Join(configDir, "dotsync.json")
-	if err := os.WriteFile(configPath, []byte(`{invalid json}`), 0644); err != nil {
+	configPath := filepath.Join(configDir, "dotsync.yaml")
+	if err := os.WriteFile(configPath, []byte("dotfiles_path: [unclosed\n"), 0644); err != nil {
 		t.Fatalf("Failed to write test config: %v", err)
 	}
 
-	// Load should fail gracefully with invalid JSON
-	// But since Load uses real ConfigPath(), we test error handling differently
+	// Load should fail gracefully with invalid YAML
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
 }
 
 func TestEnsureDirectories_Error(t *testing.T) {
@@ -371,9 +392,9 @@ func TestConfigPath_Contains(t *testing.T) {
 		// Check if it's under .config
 	}
 
-	// Path should end with dotsync.json
-	if filepath.Base(path) != "dotsync.json" {
-		t.Errorf("Expected dotsync.json, got %s", filepath.Base(path))
+	// Path should end with dotsync.yaml
+	if filepath.Base(path) != "dotsync.yaml" {
+		t.Errorf("Expected dotsync.yaml, got %s", filepath.Base(path))
 	}
 }
 
@@ -421,7 +442,7 @@ func TestSave_CreatesDirectory(t *testing.T) {
 	}
 
 	// Verify config was saved under temp HOME
-	configPath := filepath.Join(tempDir, ".config", "dotsync", "dotsync.json")
+	configPath := filepath.Join(tempDir, ".config", "dotsync", "dotsync.yaml")
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		t.Error("Save should have created the config file")
 	}
@@ -526,3 +547,130 @@ func TestStatePath_IsAbsolute(t *testing.T) {
 		t.Errorf("StatePath should return absolute path, got %s", path)
 	}
 }
+
+func TestProfileConfigDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(func() { SetProfile("") })
+
+	SetProfile("server")
+	if Profile() != "server" {
+		t.Errorf("Expected profile server, got %q", Profile())
+	}
+	want := filepath.Join(home, ".config", "dotsync", "profiles", "server")
+	if ConfigDir() != want {
+		t.Errorf("ConfigDir() = %s, want %s", ConfigDir(), want)
+	}
+	if ConfigPath() != filepath.Join(want, "dotsync.yaml") {
+		t.Errorf("ConfigPath() should live in the profile dir, got %s", ConfigPath())
+	}
+
+	SetProfile("../escape")
+	if Profile() != "escape" {
+		t.Errorf("Profile names should not contain path elements, got %q", Profile())
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if got := Profiles(); len(got) != 0 {
+		t.Errorf("Expected no profiles, got %v", got)
+	}
+
+	dir := filepath.Join(home, ".config", "dotsync", "profiles")
+	os.MkdirAll(filepath.Join(dir, "work"), 0755)
+	os.MkdirAll(filepath.Join(dir, "server"), 0755)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+	if got := Profiles(); len(got) != 2 || got[0] != "server" || got[1] != "work" {
+		t.Errorf("Expected the profile dirs sorted, got %v", got)
+	}
+}
+
+func TestDotfilesPathOverride(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(func() { SetDotfilesPathOverride("") })
+
+	saved := &Config{DotfilesPath: "/saved/dotfiles", BackupPath: "/saved/backup"}
+	if err := saved.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	SetDotfilesPathOverride("~/server-dotfiles")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.DotfilesPath != filepath.Join(home, "server-dotfiles") || !cfg.IsOverridden() {
+		t.Fatalf("Expected overridden path, got %s", cfg.DotfilesPath)
+	}
+
+	// Saving other settings keeps the saved dotfiles path
+	cfg.BackupPath = "/new/backup"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	SetDotfilesPathOverride("")
+	reloaded, _ := Load()
+	if reloaded.DotfilesPath != "/saved/dotfiles" || reloaded.BackupPath != "/new/backup" {
+		t.Errorf("Override should not be persisted, got %+v", reloaded)
+	}
+
+	// Changing the path explicitly is persisted
+	SetDotfilesPathOverride("/tmp/override")
+	cfg, _ = Load()
+	cfg.DotfilesPath = "/chosen/dotfiles"
+	cfg.Save()
+	SetDotfilesPathOverride("")
+	reloaded, _ = Load()
+	if reloaded.DotfilesPath != "/chosen/dotfiles" {
+		t.Errorf("Explicit change should be saved, got %s", reloaded.DotfilesPath)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv(ProfileEnv, "work")
+	t.Setenv(DotfilesPathEnv, "/env/dotfiles")
+	t.Cleanup(func() {
+		SetProfile("")
+		SetDotfilesPathOverride("")
+	})
+
+	SetProfile("flag")
+	ApplyEnv()
+
+	if Profile() != "flag" {
+		t.Errorf("Flag should win over env, got %q", Profile())
+	}
+	if dotfilesPathOverride != "/env/dotfiles" {
+		t.Errorf("Env should fill unset override, got %q", dotfilesPathOverride)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { forceReadOnly = false })
+
+	cfg := Default()
+	if cfg.IsReadOnly() || cfg.CheckWritable() != nil {
+		t.Fatal("Expected writes to be allowed by default")
+	}
+	cfg.ReadOnly = true
+	if !errors.Is(cfg.CheckWritable(), ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from the config flag, got %v", cfg.CheckWritable())
+	}
+
+	cfg.ReadOnly = false
+	t.Setenv(ReadOnlyEnv, "1")
+	ApplyEnv()
+	if !cfg.IsReadOnly() || !IsReadOnlyForced() {
+		t.Error("Expected the env var to force read-only mode")
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if reloaded, _ := Load(); reloaded.ReadOnly {
+		t.Error("Forced read-only mode should not be persisted")
+	}
+}
@@ -0,0 +1,378 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// field documents a key of dotsync.yaml and, for settings with a fixed set
+// of choices, the values it accepts
+type field struct {
+	Key    string
+	Doc    string
+	Values []string // Accepted values besides empty (the default); nil = any
+}
+
+// schema lists every key of the config file. Keys follow the json tags of
+// Config, which stay the single naming source for both formats.
+var schema = []field{
+	{Key: "dotfiles_path", Doc: "Path to the dotfiles directory"},
+	{Key: "backup_path", Doc: "Where backups are written before files are overwritten"},
+	{Key: "apps_config", Doc: "Path to apps.yaml with custom app definitions (empty = built-in only)"},
+	{Key: "health_checks", Doc: "Run app health probes after pull"},
+	{Key: "bootstrap", Doc: "Run each app's install.sh/bootstrap from dotfiles after its first pull here"},
+	{Key: "git_user_name", Doc: "Commit identity for the dotfiles repo"},
+	{Key: "git_user_email", Doc: "Commit email for the dotfiles repo"},
+	{Key: "git_signing_key", Doc: "Signing key for dotfiles commits (optional)"},
+	{Key: "remote_backend", Doc: "Where the store is published", Values: []string{"git", "rclone", "s3", "git+rclone", "git+s3", "gist"}},
+	{Key: "remote_target", Doc: "rclone remote, s3:// URL or gist ID for non-git backends"},
+	{Key: "machine_branches", Doc: "Push to a machine/<host> branch of this machine's own; main_branch holds the merged configs"},
+	{Key: "main_branch", Doc: "Branch the machine branches merge into and pull from (empty = main)"},
+	{Key: "nested_repos", Doc: "How to sync git repos inside config dirs (empty = copy)", Values: []string{"copy", "manifest", "submodule"}},
+	{Key: "symlinks", Doc: "How to sync symlinked configs", Values: []string{"follow", "copy-target", "skip", "preserve-as-link"}},
+	{Key: "symlink_policies", Doc: "Per-app symlink policy overriding symlinks, e.g. nvim: preserve-as-link"},
+	{Key: "xattrs", Doc: "Extended attributes: strip quarantine/metadata, preserve the rest too, or leave them alone", Values: []string{"strip", "preserve", "off"}},
+	{Key: "icon_set", Doc: "Status icons", Values: []string{"default", "shapes", "labels"}},
+	{Key: "theme", Doc: "UI colors; custom reads theme.json next to this file", Values: []string{"dark", "light", "solarized", "catppuccin", "custom"}},
+	{Key: "language", Doc: "UI language, en or vi (empty = from $LANG)"},
+	{Key: "read_only", Doc: "Disable push, pull and git changes; scans, diffs and previews still work"},
+	{Key: "flat_app_list", Doc: "List apps without category headers"},
+	{Key: "dashboard", Doc: "Open the dashboard after the startup scan"},
+	{Key: "notifications_off", Doc: "No desktop notifications for quick backups, conflicts and failed syncs"},
+	{Key: "diff_tool", Doc: "External diff tool for d (empty = built-in view)"},
+	{Key: "merge_tool", Doc: "External merge tool for m (empty = built-in view)"},
+	{Key: "report_file", Doc: "Where `dotsync report` writes the drift summary"},
+	{Key: "report_email", Doc: "Email the drift summary via sendmail/msmtp"},
+	{Key: "registry_url", Doc: "Community app-definition catalog fetched by `dotsync registry`"},
+	{Key: "registry_key", Doc: "Base64 ed25519 public key the catalog must be signed with"},
+	{Key: "public_repo", Doc: "Repo `dotsync share` writes configs to with secrets redacted and emails templated, for sharing"},
+	{Key: "disabled_apps", Doc: "App IDs ignored by the scanner"},
+	{Key: "app_aliases", Doc: "Alias app ID -> canonical app ID"},
+	{Key: "subtree_rules", Doc: "Per-app include/exclude lists of paths within config dirs"},
+	{Key: "volatile_keys", Doc: "Per-app JSONPath rules for keys kept out of dotfiles"},
+	{Key: "shell_blocks", Doc: "Shell rc blocks (kind:name) synced via the managed include"},
+	{Key: "ssh_local_hosts", Doc: "~/.ssh/config Host patterns kept machine-only"},
+	{Key: "git_protected_keys", Doc: "Extra ~/.gitconfig keys kept per machine (identity and credentials always are)"},
+	{Key: "git_identity", Doc: "This machine's values for protected gitconfig keys, e.g. user.email"},
+	{Key: "dconf_paths", Doc: "dconf directories captured as GNOME Settings (empty = defaults)"},
+	{Key: "kde_groups", Doc: `KDE rc groups captured as KDE Settings, "rcfile:Group" or "rcfile"`},
+	{Key: "conflict_policy", Doc: "Auto-resolution for files changed on both sides: default, then apps and files overrides"},
+	{Key: "scan_max_depth", Doc: "Directory levels scanned below a config dir (0 = default)"},
+	{Key: "scan_max_files", Doc: "Files collected per app (0 = default)"},
+	{Key: "scan_ignore", Doc: "Glob patterns of files and dirs the scan skips"},
+	{Key: "system_configs", Doc: "Sync configs outside $HOME (/etc) separately, under dotfiles/system with sudo"},
+	{Key: "no_color", Doc: "Plain output without colors (also set by $NO_COLOR)"},
+	{Key: "plain", Doc: "ASCII only: no colors, emoji, nerd-font icons or box drawing, for minimal terminals and screen readers"},
+	{Key: "accessible", Doc: "Screen-reader mode: panels one below the other without boxes, status changes printed as plain lines, no full-screen redraws"},
+	{Key: "panel_split", Doc: "Width of the apps panel in percent of the window, 20 to 80 (0 = half); < and > resize it"},
+	{Key: "log_level", Doc: "Least severe records written to ~/.local/state/dotsync/logs/dotsync.log (empty = info)", Values: []string{"debug", "info", "warn", "error"}},
+}
+
+const fileHeader = "dotsync configuration. Edit with `dotsync config edit`, which checks it\n" +
+	"when the editor closes. Unknown keys and invalid values are errors."
+
+// ParseError points at the line of the config file holding a bad setting
+type ParseError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Msg)
+}
+
+// encode writes c as commented YAML, each key preceded by its doc
+func encode(c *Config) ([]byte, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is YAML, so the keys keep the order and names of the json tags
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+	blockStyle(root)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if f, ok := schemaField(key.Value); ok {
+			key.HeadComment = f.comment()
+		}
+		if i > 0 {
+			key.HeadComment = "\n" + key.HeadComment
+		}
+	}
+	doc.HeadComment = fileHeader
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeOver writes c over prev, the config file as it is on disk, so the
+// comments and key order added with `dotsync config edit` survive: changed
+// values are replaced in place, keys no longer saved are dropped and new
+// ones go at the end with their docs. A prev that isn't a YAML mapping is
+// replaced by encode.
+func encodeOver(prev []byte, c *Config) ([]byte, error) {
+	var old yaml.Node
+	if err := yaml.Unmarshal(prev, &old); err != nil || len(old.Content) == 0 || old.Content[0].Kind != yaml.MappingNode {
+		return encode(c)
+	}
+	data, err := encode(c)
+	if err != nil {
+		return nil, err
+	}
+	var fresh yaml.Node
+	if err := yaml.Unmarshal(data, &fresh); err != nil {
+		return nil, err
+	}
+
+	root, values := old.Content[0], fresh.Content[0]
+	want := make(map[string]*yaml.Node, len(values.Content)/2)
+	for i := 0; i+1 < len(values.Content); i += 2 {
+		want[values.Content[i].Value] = values.Content[i+1]
+	}
+	kept := root.Content[:0]
+	have := make(map[string]bool)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		next, ok := want[key.Value]
+		if !ok || have[key.Value] {
+			continue
+		}
+		have[key.Value] = true
+		if !sameValue(value, next) {
+			next.LineComment = value.LineComment
+			value = next
+		}
+		kept = append(kept, key, value)
+	}
+	root.Content = kept
+	for i := 0; i+1 < len(values.Content); i += 2 {
+		if key := values.Content[i]; !have[key.Value] {
+			if len(root.Content) > 0 && !strings.HasPrefix(key.HeadComment, "\n") {
+				key.HeadComment = "\n" + key.HeadComment
+			}
+			root.Content = append(root.Content, key, values.Content[i+1])
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&old); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sameValue reports whether two value nodes hold the same setting, however
+// they are written
+func sameValue(a, b *yaml.Node) bool {
+	var va, vb any
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// comment returns the doc written above the key, with its choices
+func (f field) comment() string {
+	if len(f.Values) == 0 {
+		return f.Doc
+	}
+	return f.Doc + "\none of: " + strings.Join(f.Values, ", ")
+}
+
+// blockStyle drops the flow style and quoting JSON input parses with
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// decode reads a config file in YAML, or the JSON dotsync.json used to
+// be, validating every key. All problems are returned, each as a
+// ParseError naming the line.
+func decode(data []byte, file string) (*Config, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		line, msg := syntaxLine(err)
+		return nil, &ParseError{File: file, Line: line, Msg: msg}
+	}
+	var cfg Config
+	if len(doc.Content) == 0 {
+		return &cfg, nil // Empty file
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &ParseError{File: file, Line: root.Line, Msg: "expected `key: value` settings"}
+	}
+
+	fields := jsonFields()
+	v := reflect.ValueOf(&cfg).Elem()
+	var errs []error
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		fail := func(format string, args ...any) {
+			errs = append(errs, &ParseError{File: file, Line: key.Line, Msg: fmt.Sprintf(format, args...)})
+		}
+
+		index, ok := fields[key.Value]
+		if !ok {
+			if s := suggestKey(key.Value); s != "" {
+				fail("unknown key %q (did you mean %q?)", key.Value, s)
+			} else {
+				fail("unknown key %q", key.Value)
+			}
+			continue
+		}
+		if seen[key.Value] {
+			fail("%s is set twice", key.Value)
+			continue
+		}
+		seen[key.Value] = true
+
+		setting, err := decodeSetting(key.Value, v.Field(index).Type(), value)
+		if err != nil {
+			fail("%s: %v", key.Value, err)
+			continue
+		}
+		v.Field(index).Set(setting)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &cfg, nil
+}
+
+// decodeSetting converts the value of key to the field type t, checking
+// it against the key's choices. It goes through JSON, so nested settings
+// use their json tags too.
+func decodeSetting(key string, t reflect.Type, value *yaml.Node) (reflect.Value, error) {
+	var raw any
+	if err := value.Decode(&raw); err != nil {
+		return reflect.Value{}, err
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	target := reflect.New(t)
+	if err := json.Unmarshal(b, target.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("expected %s", describeType(t))
+	}
+	if f, ok := schemaField(key); ok && len(f.Values) > 0 {
+		if s, _ := raw.(string); s != "" && !slices.Contains(f.Values, s) {
+			return reflect.Value{}, fmt.Errorf("%q is not one of %s", s, strings.Join(f.Values, ", "))
+		}
+	}
+	return target.Elem(), nil
+}
+
+// jsonFields maps the saved keys of Config to their field index
+func jsonFields() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+// schemaField returns the schema entry of key
+func schemaField(key string) (field, bool) {
+	for _, f := range schema {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// describeType names a setting's type for error messages
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "text"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int:
+		return "a whole number"
+	case reflect.Slice:
+		return "a list"
+	default:
+		return "a mapping of keys to values"
+	}
+}
+
+// suggestKey returns the known key closest to a misspelled one
+func suggestKey(key string) string {
+	best, bestDist := "", 3 // At most two edits away
+	for _, f := range schema {
+		if d := editDistance(key, f.Key); d < bestDist {
+			best, bestDist = f.Key, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// syntaxLine splits a yaml syntax error into its line and message
+func syntaxLine(err error) (int, string) {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	var line int
+	if _, scanErr := fmt.Sscanf(msg, "line %d:", &line); scanErr != nil {
+		return 0, msg
+	}
+	_, msg, _ = strings.Cut(msg, ": ")
+	return line, msg
+}
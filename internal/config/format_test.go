@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/subtree"
+)
+
+func TestSchemaCoversEveryKey(t *testing.T) {
+	for key := range jsonFields() {
+		if _, ok := schemaField(key); !ok {
+			t.Errorf("Config key %s has no schema entry", key)
+		}
+	}
+	for _, f := range schema {
+		if _, ok := jsonFields()[f.Key]; !ok {
+			t.Errorf("Schema entry %s is not a Config key", f.Key)
+		}
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	cfg := &Config{
+		DotfilesPath:    "/test/dotfiles",
+		Symlinks:        "skip",
+		SymlinkPolicies: map[string]string{"nvim": "preserve-as-link"},
+		SubtreeRules:    map[string]subtree.Rules{"nvim": {Exclude: []string{"spell"}}},
+		ScanIgnore:      []string{"*.log"},
+		ScanMaxDepth:    3,
+	}
+	data, err := encode(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Path to the dotfiles directory\ndotfiles_path: /test/dotfiles", "# one of: follow, copy-target, skip, preserve-as-link\nsymlinks: skip"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %q in:\n%s", want, data)
+		}
+	}
+
+	got, err := decode(data, ConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Symlinks != "skip" || got.ScanMaxDepth != 3 || got.SymlinkPolicies["nvim"] != "preserve-as-link" ||
+		len(got.SubtreeRules["nvim"].Exclude) != 1 || len(got.ScanIgnore) != 1 {
+		t.Errorf("Round trip lost settings: %+v", got)
+	}
+}
+
+func TestDecodeErrorsNameTheLine(t *testing.T) {
+	data := "dotfiles_path: /x\nsymlink: skip\ntheme: neon\nscan_max_depth: deep\ndotfiles_path: /y\n"
+	_, err := decode([]byte(data), "dotsync.yaml")
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, want := range []string{
+		`dotsync.yaml:2: unknown key "symlink" (did you mean "symlinks"?)`,
+		`dotsync.yaml:3: theme: "neon" is not one of`,
+		`dotsync.yaml:4: scan_max_depth: expected a whole number`,
+		`dotsync.yaml:5: dotfiles_path is set twice`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in:\n%v", want, err)
+		}
+	}
+
+	_, err = decode([]byte("dotfiles_path: [\n"), "dotsync.yaml")
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line == 0 {
+		t.Errorf("Expected a syntax error with a line, got %v", err)
+	}
+}
+
+func TestLoadMigratesLegacyJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	configDir := filepath.Join(tempDir, ".config", "dotsync")
+	os.MkdirAll(configDir, 0755)
+	legacy := filepath.Join(configDir, legacyConfigFileName)
+	os.WriteFile(legacy, []byte(`{"dotfiles_path": "/test/dotfiles", "read_only": true}`), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DotfilesPath != "/test/dotfiles" || !cfg.ReadOnly || cfg.FirstRun {
+		t.Errorf("Expected the JSON config to load, got %+v", cfg)
+	}
+
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("Expected the JSON config to be replaced on save")
+	}
+	if cfg, err := Load(); err != nil || cfg.DotfilesPath != "/test/dotfiles" {
+		t.Errorf("Expected the YAML config to load, got %+v, %v", cfg, err)
+	}
+}
+
+func TestSaveKeepsComments(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	configDir := filepath.Join(tempDir, ".config", "dotsync")
+	os.MkdirAll(configDir, 0755)
+	data := "# my settings\ntheme: light # easier on the eyes\n\n# wide apps panel\npanel_split: 60 # resized often\ndotfiles_path: /test/dotfiles\n"
+	os.WriteFile(ConfigPath(), []byte(data), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.PanelSplit = 40
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, _ := os.ReadFile(ConfigPath())
+	text := string(saved)
+	for _, want := range []string{"# my settings\ntheme: light # easier on the eyes\n", "# wide apps panel\npanel_split: 40 # resized often\ndotfiles_path: /test/dotfiles\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+	if !strings.Contains(text, "# Where backups are written before files are overwritten\nbackup_path:") {
+		t.Errorf("Expected keys missing from the file added with their docs:\n%s", text)
+	}
+	if got, err := Load(); err != nil || got.PanelSplit != 40 || got.Theme != "light" {
+		t.Errorf("Expected the saved config to load, got %+v, %v", got, err)
+	}
+	if entries, _ := os.ReadDir(configDir); len(entries) != 1 {
+		t.Errorf("Expected only the config file left, got %v", entries)
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source tells where an override of a saved setting comes from. Flags win
+// over environment variables, which win over the config file.
+type Source int
+
+const (
+	SourceEnv Source = iota + 1
+	SourceFlag
+)
+
+// override is a setting replaced for this process without being saved
+type override struct {
+	value  reflect.Value
+	source Source
+}
+
+// overrides holds the env and flag values of settings by key. The dotfiles
+// path and read-only mode keep their own state: dotfilesPathOverride and
+// forceReadOnly.
+var overrides = make(map[string]override)
+
+// EnvName returns the environment variable overriding a setting, e.g.
+// DOTSYNC_SCAN_MAX_DEPTH for scan_max_depth
+func EnvName(key string) string {
+	return "DOTSYNC_" + strings.ToUpper(key)
+}
+
+// FlagName returns the command-line flag overriding a setting, e.g.
+// --scan-max-depth for scan_max_depth
+func FlagName(key string) string {
+	return "--" + strings.ReplaceAll(key, "_", "-")
+}
+
+// SettingForFlag returns the setting a flag like --theme overrides, and
+// whether the setting is a switch that needs no value
+func SettingForFlag(flag string) (key string, isSwitch bool, ok bool) {
+	key = strings.ReplaceAll(strings.TrimPrefix(flag, "--"), "-", "_")
+	index, ok := jsonFields()[key]
+	if !ok || !strings.HasPrefix(flag, "--") {
+		return "", false, false
+	}
+	return key, reflect.TypeOf(Config{}).Field(index).Type.Kind() == reflect.Bool, true
+}
+
+// SetOverride replaces a saved setting for this process. Values are read
+// like the config file's, so lists and maps use YAML flow syntax
+// ("[a, b]", "{nvim: skip}"); switches also take 1/0, yes/no and on/off.
+// An env value never replaces a flag's.
+func SetOverride(key, value string, source Source) error {
+	index, ok := jsonFields()[key]
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if existing, ok := overrides[key]; ok && existing.source > source {
+		return nil
+	}
+	t := reflect.TypeOf(Config{}).Field(index).Type
+	setting, err := decodeSetting(key, t, overrideNode(t, value))
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case "dotfiles_path":
+		if source == SourceFlag || dotfilesPathOverride == "" {
+			SetDotfilesPathOverride(value)
+		}
+	case "read_only":
+		// Forcing writes back on would defeat a read-only config
+		if setting.Bool() {
+			SetReadOnly()
+		}
+	default:
+		overrides[key] = override{value: setting, source: source}
+	}
+	return nil
+}
+
+// overrideNode turns an env or flag value into the YAML node a config file
+// line would hold
+func overrideNode(t reflect.Type, value string) *yaml.Node {
+	switch t.Kind() {
+	case reflect.String:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	case reflect.Bool:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "1", "true", "yes", "on":
+			value = "true"
+		case "0", "false", "no", "off":
+			value = "false"
+		}
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+	return doc.Content[0]
+}
+
+// Overrides lists the settings replaced by env vars or flags, sorted, as
+// "key (source)"
+func Overrides() []string {
+	var list []string
+	for key, o := range overrides {
+		source := "env " + EnvName(key)
+		if o.source == SourceFlag {
+			source = "flag " + FlagName(key)
+		}
+		list = append(list, fmt.Sprintf("%s (%s)", key, source))
+	}
+	if dotfilesPathOverride != "" {
+		list = append(list, "dotfiles_path (flag or env)")
+	}
+	if forceReadOnly {
+		list = append(list, "read_only (flag or env)")
+	}
+	sort.Strings(list)
+	return list
+}
+
+// applyEnvOverrides reads DOTSYNC_<KEY> for every setting without its own
+// handling in ApplyEnv, and the NO_COLOR convention
+func applyEnvOverrides() []error {
+	var errs []error
+	for _, f := range schema {
+		if f.Key == "dotfiles_path" || f.Key == "read_only" {
+			continue
+		}
+		if value, ok := os.LookupEnv(EnvName(f.Key)); ok {
+			if err := SetOverride(f.Key, value, SourceEnv); err != nil {
+				errs = append(errs, fmt.Errorf("$%s: %w", EnvName(f.Key), err))
+			}
+		}
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		SetOverride("no_color", "true", SourceEnv)
+	}
+	return errs
+}
+
+// applyOverrides swaps in the env and flag values, remembering the saved
+// ones so Save keeps them
+func (c *Config) applyOverrides() {
+	v := reflect.ValueOf(c).Elem()
+	fields := jsonFields()
+	for key, o := range overrides {
+		index := fields[key]
+		if c.saved == nil {
+			c.saved = make(map[int]any)
+		}
+		c.saved[index] = v.Field(index).Interface()
+		v.Field(index).Set(o.value)
+	}
+}
+
+// restoreSaved puts back the saved values of overridden settings that were
+// not changed since, so overrides are never persisted
+func (c *Config) restoreSaved() {
+	v := reflect.ValueOf(c).Elem()
+	fields := jsonFields()
+	for key, o := range overrides {
+		index := fields[key]
+		saved, ok := c.saved[index]
+		if ok && reflect.DeepEqual(v.Field(index).Interface(), o.value.Interface()) {
+			v.Field(index).Set(reflect.ValueOf(saved))
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestOverridesAreNotSaved(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { overrides = make(map[string]override) })
+
+	saved := &Config{DotfilesPath: "/saved/dotfiles", Theme: "dark", ScanIgnore: []string{"*.log"}}
+	if err := saved.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	t.Setenv(EnvName("theme"), "light")
+	t.Setenv(EnvName("scan_max_depth"), "3")
+	if err := ApplyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOverride("theme", "solarized", SourceFlag); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOverride("scan_ignore", "[cache, '*.db']", SourceFlag); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOverride("no_color", "yes", SourceFlag); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme != "solarized" || cfg.ScanMaxDepth != 3 || len(cfg.ScanIgnore) != 2 || !cfg.NoColor {
+		t.Errorf("Expected flags over env over the file, got %+v", cfg)
+	}
+
+	// Settings changed on purpose are saved, overridden ones keep the file's value
+	cfg.ScanMaxDepth = 7
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	overrides = make(map[string]override)
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Theme != "dark" || reloaded.ScanMaxDepth != 7 || len(reloaded.ScanIgnore) != 1 || reloaded.NoColor {
+		t.Errorf("Expected only the explicit change to be saved, got %+v", reloaded)
+	}
+}
+
+func TestSetOverrideValidates(t *testing.T) {
+	t.Cleanup(func() { overrides = make(map[string]override) })
+
+	for key, value := range map[string]string{
+		"theme":          "neon",
+		"scan_max_depth": "deep",
+		"no_such":        "x",
+	} {
+		if err := SetOverride(key, value, SourceFlag); err == nil {
+			t.Errorf("Expected %s=%s to be rejected", key, value)
+		}
+	}
+	if len(overrides) != 0 {
+		t.Errorf("Rejected values should not be kept, got %v", overrides)
+	}
+
+	// Text settings take values that would read as other types
+	if err := SetOverride("git_user_name", "true", SourceEnv); err != nil {
+		t.Errorf("Expected any text for git_user_name, got %v", err)
+	}
+}
+
+func TestSettingForFlag(t *testing.T) {
+	tests := []struct {
+		flag     string
+		key      string
+		isSwitch bool
+		ok       bool
+	}{
+		{"--scan-max-depth", "scan_max_depth", false, true},
+		{"--read-only", "read_only", true, true},
+		{"--dotfiles-path", "dotfiles_path", false, true},
+		{"--dry-run", "", false, false},
+		{"theme", "", false, false},
+	}
+	for _, tt := range tests {
+		key, isSwitch, ok := SettingForFlag(tt.flag)
+		if key != tt.key || isSwitch != tt.isSwitch || ok != tt.ok {
+			t.Errorf("SettingForFlag(%s) = %s, %v, %v", tt.flag, key, isSwitch, ok)
+		}
+	}
+	if EnvName("dotfiles_path") != DotfilesPathEnv || EnvName("read_only") != ReadOnlyEnv {
+		t.Error("Expected the generic env names to match the existing ones")
+	}
+	if FlagName("scan_max_depth") != "--scan-max-depth" {
+		t.Errorf("Unexpected flag name %s", FlagName("scan_max_depth"))
+	}
+}
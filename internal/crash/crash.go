@@ -0,0 +1,104 @@
+// Package crash records panics in the TUI and writes them to a crash
+// report next to the log file, so a crash leaves more behind than a
+// stack trace scrolled off a restored terminal.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ReportPrefix starts the file name of every crash report
+const ReportPrefix = "crash-"
+
+// Panic is a recorded panic
+type Panic struct {
+	Value any
+	Stack []byte
+	Time  time.Time
+}
+
+var (
+	mu       sync.Mutex
+	recorded *Panic
+)
+
+// Guard records a panic and panics again, so Bubble Tea still restores
+// the terminal. Defer it directly: defer crash.Guard().
+func Guard() {
+	if r := recover(); r != nil {
+		record(r, debug.Stack())
+		panic(r)
+	}
+}
+
+// record keeps the first panic; later ones are usually fallout
+func record(value any, stack []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	if recorded == nil {
+		recorded = &Panic{Value: value, Stack: stack, Time: time.Now()}
+	}
+}
+
+// Recovered returns the recorded panic, nil if there was none
+func Recovered() *Panic {
+	mu.Lock()
+	defer mu.Unlock()
+	return recorded
+}
+
+// Cmd guards a command, which Bubble Tea runs in a goroutine of its own.
+// The commands of a batch it returns are guarded too.
+func Cmd(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		defer Guard()
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for i := range batch {
+				batch[i] = Cmd(batch[i])
+			}
+		}
+		return msg
+	}
+}
+
+// WriteReport writes p to a new report in dir with the version, the stack
+// and the given lines of the log, and returns its path
+func WriteReport(dir, version string, p *Panic, logLines []string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "dotsync %s crashed at %s\n\n", version, p.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "panic: %v\n\n%s\n", p.Value, p.Stack)
+	if len(logLines) > 0 {
+		b.WriteString("\nLast log lines:\n")
+		for _, line := range logLines {
+			b.WriteString(line + "\n")
+		}
+	}
+	path := filepath.Join(dir, ReportPrefix+p.Time.Format("20060102-150405")+".txt")
+	return path, os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// Latest returns the newest crash report in dir, "" if there is none
+func Latest(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, ReportPrefix+"*.txt"))
+	if len(matches) == 0 {
+		return ""
+	}
+	// Names sort by time
+	return slices.Max(matches)
+}
@@ -0,0 +1,103 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reset forgets the recorded panic after the test
+func reset(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		recorded = nil
+		mu.Unlock()
+	})
+}
+
+func TestGuard(t *testing.T) {
+	reset(t)
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the panic to go on, got %v", r)
+			}
+		}()
+		defer Guard()
+		panic("boom")
+	}()
+
+	p := Recovered()
+	if p == nil || p.Value != "boom" || !strings.Contains(string(p.Stack), "TestGuard") {
+		t.Fatalf("Expected the panic recorded with its stack, got %+v", p)
+	}
+
+	// Only the first panic is kept
+	func() {
+		defer func() { recover() }()
+		defer Guard()
+		panic("later")
+	}()
+	if Recovered().Value != "boom" {
+		t.Errorf("Expected the first panic kept, got %v", Recovered().Value)
+	}
+}
+
+func TestCmd(t *testing.T) {
+	reset(t)
+	if Cmd(nil) != nil {
+		t.Error("Expected a nil command to stay nil")
+	}
+
+	ok := func() tea.Msg { return "ok" }
+	bad := func() tea.Msg { panic("in batch") }
+	msg := Cmd(tea.Batch(ok, bad))()
+	batch, isBatch := msg.(tea.BatchMsg)
+	if !isBatch || len(batch) != 2 {
+		t.Fatalf("Expected the batch passed through, got %#v", msg)
+	}
+	if batch[0]() != "ok" {
+		t.Error("Expected the guarded command to return its message")
+	}
+	func() {
+		defer func() { recover() }()
+		batch[1]()
+	}()
+	if p := Recovered(); p == nil || p.Value != "in batch" {
+		t.Errorf("Expected the panic of a batched command recorded, got %+v", p)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if Latest(dir) != "" {
+		t.Error("Expected no report in an empty dir")
+	}
+
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	p := &Panic{Value: "index out of range", Stack: []byte("goroutine 1 [running]:\nmain.main()"), Time: at}
+	path, err := WriteReport(dir, "1.2.0", p, []string{"level=INFO msg=pull app=nvim"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "crash-20260304-050607.txt" || Latest(dir) != path {
+		t.Errorf("Unexpected report path %s (latest %s)", path, Latest(dir))
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"dotsync 1.2.0 crashed", "panic: index out of range", "main.main()", "Last log lines:\nlevel=INFO msg=pull app=nvim"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %q in the report:\n%s", want, data)
+		}
+	}
+
+	p.Time = at.Add(time.Hour)
+	newer, _ := WriteReport(dir, "1.2.0", p, nil)
+	if Latest(dir) != newer {
+		t.Errorf("Expected the newer report latest, got %s", Latest(dir))
+	}
+}
@@ -0,0 +1,129 @@
+// Package dashboard summarizes sync health for the home screen: what is
+// tracked, what needs pushing or pulling, when this machine last synced and
+// how fresh every machine's backups are.
+package dashboard
+
+import (
+	"sort"
+	"time"
+
+	"dotsync/internal/audit"
+	"dotsync/internal/backup"
+	"dotsync/internal/git"
+	"dotsync/internal/models"
+)
+
+// Backup freshness levels
+const (
+	FreshnessFresh = "fresh" // Backed up within a day
+	FreshnessStale = "stale" // Within a week
+	FreshnessOld   = "old"   // Longer ago
+	FreshnessNever = "never" // No backup recorded
+)
+
+// Summary is the data shown on the dashboard
+type Summary struct {
+	Apps      int
+	Files     int
+	Modified  int // Local changes to push
+	Outdated  int // Dotfiles changes to pull
+	Conflicts int // Changed on both sides
+
+	LastPush   time.Time
+	LastPull   time.Time
+	LastBackup time.Time
+
+	HasRepo bool
+	Branch  string
+	Ahead   int
+	Behind  int
+
+	Machines []backup.Machine // Most recently backed up first
+}
+
+// Build counts tracked apps and files by sync state
+func Build(apps []*models.App) *Summary {
+	s := &Summary{Apps: len(apps)}
+	for _, app := range apps {
+		for _, file := range app.Files {
+			if file.Excluded {
+				continue
+			}
+			s.Files++
+			switch file.ConflictType {
+			case models.ConflictLocalModified, models.ConflictLocalNew:
+				s.Modified++
+			case models.ConflictDotfilesModified, models.ConflictDotfilesNew:
+				s.Outdated++
+			case models.ConflictBothModified:
+				s.Conflicts++
+			}
+		}
+	}
+	return s
+}
+
+// WithAudit fills in the last successful push, pull and quick backup
+func (s *Summary) WithAudit(entries []audit.Entry) *Summary {
+	for _, e := range entries {
+		if e.Result != audit.ResultOK {
+			continue
+		}
+		var last *time.Time
+		switch e.Action {
+		case audit.ActionPush:
+			last = &s.LastPush
+		case audit.ActionPull:
+			last = &s.LastPull
+		case audit.ActionBackup:
+			last = &s.LastBackup
+		default:
+			continue
+		}
+		if e.Time.After(*last) {
+			*last = e.Time
+		}
+	}
+	return s
+}
+
+// WithGit fills in the branch and how far it is ahead of or behind its
+// upstream. A nil status means the dotfiles are not a git repo.
+func (s *Summary) WithGit(status *git.Status) *Summary {
+	if status == nil {
+		return s
+	}
+	s.HasRepo = true
+	s.Branch = status.Branch
+	s.Ahead = status.Ahead
+	s.Behind = status.Behind
+	return s
+}
+
+// WithMachines lists the machines with backups, newest first
+func (s *Summary) WithMachines(machines []backup.Machine) *Summary {
+	s.Machines = append([]backup.Machine(nil), machines...)
+	sort.SliceStable(s.Machines, func(i, j int) bool {
+		return s.Machines[i].LastSync.After(s.Machines[j].LastSync)
+	})
+	return s
+}
+
+// Healthy reports whether nothing needs pushing, pulling or resolving
+func (s *Summary) Healthy() bool {
+	return s.Modified == 0 && s.Outdated == 0 && s.Conflicts == 0 && s.Ahead == 0 && s.Behind == 0
+}
+
+// Freshness classifies a backup time relative to now
+func Freshness(t, now time.Time) string {
+	switch age := now.Sub(t); {
+	case t.IsZero():
+		return FreshnessNever
+	case age < 24*time.Hour:
+		return FreshnessFresh
+	case age < 7*24*time.Hour:
+		return FreshnessStale
+	default:
+		return FreshnessOld
+	}
+}
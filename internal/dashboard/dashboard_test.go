@@ -0,0 +1,101 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"dotsync/internal/audit"
+	"dotsync/internal/backup"
+	"dotsync/internal/git"
+	"dotsync/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	apps := []*models.App{
+		{ID: "zsh", Files: []models.File{
+			{RelPath: ".zshrc", ConflictType: models.ConflictLocalModified},
+			{RelPath: ".zprofile", ConflictType: models.ConflictNone},
+		}},
+		{ID: "git", Files: []models.File{
+			{RelPath: "config", ConflictType: models.ConflictDotfilesNew},
+			{RelPath: "ignore", ConflictType: models.ConflictBothModified},
+			{RelPath: "hooks", Excluded: true, ConflictType: models.ConflictLocalNew},
+		}},
+	}
+
+	s := Build(apps)
+	if s.Apps != 2 || s.Files != 4 {
+		t.Errorf("Expected 2 apps and 4 files, got %d and %d", s.Apps, s.Files)
+	}
+	if s.Modified != 1 || s.Outdated != 1 || s.Conflicts != 1 {
+		t.Errorf("Unexpected counts: %+v", s)
+	}
+	if s.Healthy() {
+		t.Error("Summary with pending changes should not be healthy")
+	}
+	if !Build(nil).Healthy() {
+		t.Error("Empty summary should be healthy")
+	}
+}
+
+func TestWithAudit(t *testing.T) {
+	t1 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	entries := []audit.Entry{
+		{Time: t1, Action: audit.ActionPush, Result: audit.ResultOK},
+		{Time: t2, Action: audit.ActionPush, Result: audit.ResultOK},
+		{Time: t2.Add(time.Hour), Action: audit.ActionPush, Result: audit.ResultFailed},
+		{Time: t1, Action: audit.ActionPull, Result: audit.ResultOK},
+		{Time: t2, Action: audit.ActionBackup, Result: audit.ResultOK},
+	}
+
+	s := Build(nil).WithAudit(entries)
+	if !s.LastPush.Equal(t2) {
+		t.Errorf("Last push should ignore failures, got %v", s.LastPush)
+	}
+	if !s.LastPull.Equal(t1) || !s.LastBackup.Equal(t2) {
+		t.Errorf("Unexpected last pull/backup: %v, %v", s.LastPull, s.LastBackup)
+	}
+}
+
+func TestWithGitAndMachines(t *testing.T) {
+	now := time.Now()
+	s := Build(nil).
+		WithGit(&git.Status{Branch: "main", Ahead: 2}).
+		WithMachines([]backup.Machine{
+			{Name: "old", LastSync: now.Add(-48 * time.Hour)},
+			{Name: "new", LastSync: now},
+		})
+
+	if !s.HasRepo || s.Branch != "main" || s.Ahead != 2 {
+		t.Errorf("Unexpected git info: %+v", s)
+	}
+	if s.Healthy() {
+		t.Error("Unpushed commits should not be healthy")
+	}
+	if s.Machines[0].Name != "new" {
+		t.Errorf("Machines should be newest first, got %q", s.Machines[0].Name)
+	}
+	if Build(nil).WithGit(nil).HasRepo {
+		t.Error("Nil status means no repo")
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Time{}, FreshnessNever},
+		{now.Add(-time.Hour), FreshnessFresh},
+		{now.Add(-72 * time.Hour), FreshnessStale},
+		{now.Add(-30 * 24 * time.Hour), FreshnessOld},
+	}
+
+	for _, tt := range tests {
+		if got := Freshness(tt.t, now); got != tt.want {
+			t.Errorf("Freshness(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
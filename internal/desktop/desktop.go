@@ -0,0 +1,427 @@
+// Package desktop captures GNOME (dconf) and KDE settings as plain files so
+// they sync like any other config. Selected dconf paths are dumped and
+// selected KDE config groups are copied into a staging directory, which the
+// scanner sees as the "GNOME Settings" and "KDE Settings" pseudo-apps; after
+// a pull the staged files are loaded back into dconf and the KDE rc files.
+package desktop
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"dotsync/internal/models"
+)
+
+// Pseudo-app IDs
+const (
+	GnomeAppID = "gnome-settings"
+	KDEAppID   = "kde-settings"
+)
+
+// DirName is the staging directory inside the config dir
+const DirName = "desktop"
+
+// pathHeader starts every dconf dump so the path survives the file name
+const pathHeader = "# dconf path: "
+
+// DefaultDconfPaths are dumped when no paths are configured
+var DefaultDconfPaths = []string{
+	"/org/gnome/desktop/interface/",
+	"/org/gnome/desktop/wm/keybindings/",
+	"/org/gnome/desktop/wm/preferences/",
+	"/org/gnome/settings-daemon/plugins/media-keys/",
+	"/org/gnome/shell/keybindings/",
+}
+
+// DefaultKDEGroups are copied when no groups are configured. Each entry is
+// "rcfile:Group", or just "rcfile" for every group in the file.
+var DefaultKDEGroups = []string{
+	"kdeglobals:General",
+	"kdeglobals:KDE",
+	"kdeglobals:Icons",
+	"kwinrc:Windows",
+	"kglobalshortcutsrc",
+}
+
+// RunFunc runs a command with optional stdin and returns its stdout
+type RunFunc func(stdin []byte, name string, args ...string) ([]byte, error)
+
+// run executes a command on this machine
+func run(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	return cmd.Output()
+}
+
+// Capturer dumps and restores desktop settings
+type Capturer struct {
+	Dir         string   // Staging directory
+	ConfigHome  string   // Where KDE rc files live (~/.config)
+	DconfPaths  []string // dconf directories to capture
+	KDEGroups   []string // KDE groups to capture
+	run         RunFunc
+	lookPath    func(string) (string, error)
+	isSupported bool
+}
+
+// New creates a capturer staging into configDir/desktop. Empty path and
+// group lists fall back to the defaults.
+func New(configDir string, dconfPaths, kdeGroups []string) *Capturer {
+	home, _ := os.UserHomeDir()
+	if len(dconfPaths) == 0 {
+		dconfPaths = DefaultDconfPaths
+	}
+	if len(kdeGroups) == 0 {
+		kdeGroups = DefaultKDEGroups
+	}
+	return &Capturer{
+		Dir:         filepath.Join(configDir, DirName),
+		ConfigHome:  filepath.Join(home, ".config"),
+		DconfPaths:  dconfPaths,
+		KDEGroups:   kdeGroups,
+		run:         run,
+		lookPath:    exec.LookPath,
+		isSupported: runtime.GOOS == "linux",
+	}
+}
+
+// WithRunner replaces how commands run, e.g. to fake dconf in tests
+func (c *Capturer) WithRunner(run RunFunc, lookPath func(string) (string, error)) *Capturer {
+	c.run = run
+	c.lookPath = lookPath
+	c.isSupported = true
+	return c
+}
+
+// GnomeDir is where dconf dumps are staged
+func (c *Capturer) GnomeDir() string { return filepath.Join(c.Dir, "gnome") }
+
+// KDEDir is where KDE groups are staged
+func (c *Capturer) KDEDir() string { return filepath.Join(c.Dir, "kde") }
+
+// hasDconf reports whether dconf is usable here
+func (c *Capturer) hasDconf() bool {
+	if !c.isSupported {
+		return false
+	}
+	_, err := c.lookPath("dconf")
+	return err == nil
+}
+
+// hasKDE reports whether any configured KDE rc file exists
+func (c *Capturer) hasKDE() bool {
+	if !c.isSupported {
+		return false
+	}
+	for _, spec := range c.KDEGroups {
+		file, _ := splitGroup(spec)
+		if _, err := os.Stat(filepath.Join(c.ConfigHome, file)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Definitions returns the pseudo-app definitions for the desktops present
+// on this machine
+func (c *Capturer) Definitions() []models.AppDefinition {
+	var defs []models.AppDefinition
+	if c.hasDconf() {
+		defs = append(defs, models.AppDefinition{
+			ID:          GnomeAppID,
+			Name:        "GNOME Settings",
+			Category:    "system",
+			Icon:        "🖥️",
+			ConfigPaths: []string{c.GnomeDir()},
+		})
+	}
+	if c.hasKDE() {
+		defs = append(defs, models.AppDefinition{
+			ID:          KDEAppID,
+			Name:        "KDE Settings",
+			Category:    "system",
+			Icon:        "⚙️",
+			ConfigPaths: []string{c.KDEDir()},
+		})
+	}
+	return defs
+}
+
+// Capture refreshes the staged files from dconf and the KDE rc files
+func (c *Capturer) Capture() error {
+	var errs []string
+	if c.hasDconf() {
+		if err := c.captureDconf(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if c.hasKDE() {
+		if err := c.captureKDE(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("capturing desktop settings: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// dumpName is the staged file name for a dconf path
+func dumpName(path string) string {
+	return strings.ReplaceAll(strings.Trim(path, "/"), "/", ".") + ".ini"
+}
+
+func (c *Capturer) captureDconf() error {
+	if err := os.MkdirAll(c.GnomeDir(), 0755); err != nil {
+		return err
+	}
+	for _, path := range c.DconfPaths {
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		out, err := c.run(nil, "dconf", "dump", path)
+		if err != nil {
+			return fmt.Errorf("dconf dump %s: %w", path, err)
+		}
+		data := append([]byte(pathHeader+path+"\n"), out...)
+		if err := writeIfChanged(filepath.Join(c.GnomeDir(), dumpName(path)), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Capturer) captureKDE() error {
+	if err := os.MkdirAll(c.KDEDir(), 0755); err != nil {
+		return err
+	}
+	for file, groups := range groupsByFile(c.KDEGroups) {
+		data, err := os.ReadFile(filepath.Join(c.ConfigHome, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		picked := PickGroups(string(data), groups)
+		if err := writeIfChanged(filepath.Join(c.KDEDir(), file), []byte(picked)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply loads the staged files back after a pull: dconf dumps with
+// `dconf load` and KDE groups merged into their rc files. appID selects
+// which pseudo-app was pulled.
+func (c *Capturer) Apply(appID string) error {
+	switch appID {
+	case GnomeAppID:
+		return c.applyDconf()
+	case KDEAppID:
+		return c.applyKDE()
+	}
+	return nil
+}
+
+func (c *Capturer) applyDconf() error {
+	if !c.hasDconf() {
+		return nil
+	}
+	entries, err := os.ReadDir(c.GnomeDir())
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".ini") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.GnomeDir(), e.Name()))
+		if err != nil {
+			return err
+		}
+		path, body := splitHeader(data)
+		if path == "" {
+			path = "/" + strings.ReplaceAll(strings.TrimSuffix(e.Name(), ".ini"), ".", "/") + "/"
+		}
+		if _, err := c.run(body, "dconf", "load", path); err != nil {
+			return fmt.Errorf("dconf load %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func (c *Capturer) applyKDE() error {
+	entries, err := os.ReadDir(c.KDEDir())
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		staged, err := os.ReadFile(filepath.Join(c.KDEDir(), e.Name()))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(c.ConfigHome, e.Name())
+		local, err := os.ReadFile(target)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		merged := MergeGroups(string(local), string(staged))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(merged), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitHeader separates the dconf path comment from a staged dump
+func splitHeader(data []byte) (path string, body []byte) {
+	first, rest, _ := bytes.Cut(data, []byte("\n"))
+	if !bytes.HasPrefix(first, []byte(pathHeader)) {
+		return "", data
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(first), pathHeader)), rest
+}
+
+// writeIfChanged writes data unless the file already holds it, so
+// unchanged settings keep their modification time
+func writeIfChanged(path string, data []byte) error {
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// splitGroup splits "rcfile:Group" into its parts; group is "" for the
+// whole file
+func splitGroup(spec string) (file, group string) {
+	file, group, _ = strings.Cut(spec, ":")
+	return strings.TrimSpace(file), strings.TrimSpace(group)
+}
+
+// groupsByFile maps each rc file to its groups; a nil list means all
+func groupsByFile(specs []string) map[string][]string {
+	byFile := make(map[string][]string)
+	all := make(map[string]bool)
+	for _, spec := range specs {
+		file, group := splitGroup(spec)
+		if file == "" {
+			continue
+		}
+		if group == "" {
+			all[file] = true
+			byFile[file] = nil
+			continue
+		}
+		if !all[file] {
+			byFile[file] = append(byFile[file], group)
+		}
+	}
+	return byFile
+}
+
+// section is one [Group] of an ini file with its lines
+type section struct {
+	name  string // Header without brackets; "" for lines before any header
+	lines []string
+}
+
+// parseSections splits an ini file into sections
+func parseSections(src string) []section {
+	sections := []section{{}}
+	for _, line := range strings.Split(strings.TrimRight(src, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			sections = append(sections, section{name: trimmed[1 : len(trimmed)-1]})
+		}
+		sections[len(sections)-1].lines = append(sections[len(sections)-1].lines, line)
+	}
+	return sections
+}
+
+// matchesGroup reports whether a section header belongs to a group,
+// including its nested groups such as [Windows][Rules]
+func matchesGroup(name string, groups []string) bool {
+	if groups == nil {
+		return name != ""
+	}
+	for _, g := range groups {
+		if name == g || strings.HasPrefix(name, g+"][") {
+			return true
+		}
+	}
+	return false
+}
+
+// render joins sections back into ini text, one blank line between them
+func render(sections []section) string {
+	var blocks []string
+	for _, s := range sections {
+		lines := s.lines
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+		}
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// PickGroups returns only the given groups of a KDE rc file (nil groups
+// keeps every group)
+func PickGroups(src string, groups []string) string {
+	var picked []section
+	for _, s := range parseSections(src) {
+		if matchesGroup(s.name, groups) {
+			picked = append(picked, s)
+		}
+	}
+	return render(picked)
+}
+
+// MergeGroups replaces the groups of local that staged contains and
+// appends the ones local lacks; other groups are kept as they are
+func MergeGroups(local, staged string) string {
+	incoming := make(map[string]section)
+	var order []string
+	for _, s := range parseSections(staged) {
+		if s.name == "" {
+			continue
+		}
+		incoming[s.name] = s
+		order = append(order, s.name)
+	}
+
+	var merged []section
+	done := make(map[string]bool)
+	for _, s := range parseSections(local) {
+		if in, ok := incoming[s.name]; ok && s.name != "" {
+			s = in
+			done[s.name] = true
+		}
+		merged = append(merged, s)
+	}
+	for _, name := range order {
+		if !done[name] {
+			merged = append(merged, incoming[name])
+		}
+	}
+	return render(merged)
+}
@@ -0,0 +1,124 @@
+package desktop
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeDconf records loads and answers dumps from a map of path -> dump
+type fakeDconf struct {
+	dumps  map[string]string
+	loaded map[string]string
+}
+
+func (f *fakeDconf) run(stdin []byte, name string, args ...string) ([]byte, error) {
+	if name != "dconf" || len(args) != 2 {
+		return nil, errors.New("unexpected command")
+	}
+	switch args[0] {
+	case "dump":
+		return []byte(f.dumps[args[1]]), nil
+	case "load":
+		f.loaded[args[1]] = string(stdin)
+		return nil, nil
+	}
+	return nil, errors.New("unexpected dconf command")
+}
+
+func newTestCapturer(t *testing.T, f *fakeDconf, hasDconf bool) *Capturer {
+	t.Helper()
+	lookPath := func(string) (string, error) {
+		if hasDconf {
+			return "/usr/bin/dconf", nil
+		}
+		return "", errors.New("not found")
+	}
+	c := New(t.TempDir(), []string{"/org/gnome/desktop/interface"}, []string{"kdeglobals:General", "kwinrc"})
+	c.ConfigHome = t.TempDir()
+	return c.WithRunner(f.run, lookPath)
+}
+
+func TestDconfCaptureAndApply(t *testing.T) {
+	f := &fakeDconf{
+		dumps:  map[string]string{"/org/gnome/desktop/interface/": "[/]\ncolor-scheme='prefer-dark'\n"},
+		loaded: make(map[string]string),
+	}
+	c := newTestCapturer(t, f, true)
+
+	defs := c.Definitions()
+	if len(defs) != 1 || defs[0].ID != GnomeAppID || defs[0].ConfigPaths[0] != c.GnomeDir() {
+		t.Fatalf("Expected only the GNOME pseudo-app, got %+v", defs)
+	}
+	if err := c.Capture(); err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	staged := filepath.Join(c.GnomeDir(), "org.gnome.desktop.interface.ini")
+	data, err := os.ReadFile(staged)
+	if err != nil {
+		t.Fatalf("Expected a staged dump: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# dconf path: /org/gnome/desktop/interface/\n[/]") {
+		t.Errorf("Unexpected dump %q", data)
+	}
+
+	if err := c.Apply(GnomeAppID); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if got := f.loaded["/org/gnome/desktop/interface/"]; got != "[/]\ncolor-scheme='prefer-dark'\n" {
+		t.Errorf("Expected the dump loaded without its header, got %q", got)
+	}
+}
+
+func TestKDECaptureAndApply(t *testing.T) {
+	c := newTestCapturer(t, &fakeDconf{}, false)
+	os.WriteFile(filepath.Join(c.ConfigHome, "kdeglobals"), []byte(
+		"[General]\nColorScheme=BreezeDark\n\n[KDE]\nSingleClick=false\n\n[General][Nested]\nx=1\n"), 0644)
+	os.WriteFile(filepath.Join(c.ConfigHome, "kwinrc"), []byte("[Windows]\nFocusPolicy=ClickToFocus\n"), 0644)
+
+	defs := c.Definitions()
+	if len(defs) != 1 || defs[0].ID != KDEAppID {
+		t.Fatalf("Expected only the KDE pseudo-app, got %+v", defs)
+	}
+	if err := c.Capture(); err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(c.KDEDir(), "kdeglobals"))
+	if want := "[General]\nColorScheme=BreezeDark\n\n[General][Nested]\nx=1\n"; string(data) != want {
+		t.Errorf("Expected only the General groups, got %q", data)
+	}
+
+	// Another machine with its own KDE group and an older color scheme
+	os.WriteFile(filepath.Join(c.ConfigHome, "kdeglobals"), []byte("[KDE]\nSingleClick=true\n\n[General]\nColorScheme=Breeze\n"), 0644)
+	if err := c.Apply(KDEAppID); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(c.ConfigHome, "kdeglobals"))
+	want := "[KDE]\nSingleClick=true\n\n[General]\nColorScheme=BreezeDark\n\n[General][Nested]\nx=1\n"
+	if string(data) != want {
+		t.Errorf("Unexpected merged kdeglobals:\n%s", data)
+	}
+}
+
+func TestNoDesktop(t *testing.T) {
+	c := newTestCapturer(t, &fakeDconf{}, false)
+	if defs := c.Definitions(); len(defs) != 0 {
+		t.Errorf("Expected no pseudo-apps without dconf or KDE, got %+v", defs)
+	}
+	if err := c.Capture(); err != nil {
+		t.Errorf("Capture without a desktop should be a no-op, got %v", err)
+	}
+}
+
+func TestMergeGroupsKeepsPreamble(t *testing.T) {
+	local := "# comment\n[A]\na=1\n"
+	got := MergeGroups(local, "[B]\nb=2\n")
+	if got != "# comment\n\n[A]\na=1\n\n[B]\nb=2\n" {
+		t.Errorf("Unexpected merge %q", got)
+	}
+	if got := MergeGroups("", "[B]\nb=2\n"); got != "[B]\nb=2\n" {
+		t.Errorf("Expected a new file to hold only the staged group, got %q", got)
+	}
+}
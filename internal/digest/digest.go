@@ -0,0 +1,161 @@
+// Package digest summarizes recent dotfiles activity from git history and
+// sync state, for a quick overview when returning to the tool.
+package digest
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dotsync/internal/git"
+	"dotsync/internal/sync"
+)
+
+// Week is the default digest window
+const Week = 7 * 24 * time.Hour
+
+// Count is a name with a number of changes
+type Count struct {
+	Name    string
+	Changes int
+}
+
+// Digest summarizes activity since a point in time
+type Digest struct {
+	Since      time.Time
+	Commits    int
+	Files      int      // Distinct files changed
+	Apps       []Count  // Apps by number of changes, most active first
+	Machines   []string // Machines whose backup copies changed
+	Authors    []string // Commit authors
+	Conflicts  int      // Merge commits and commits resolving conflicts
+	Synced     int      // Files synced on this machine
+	SizeBefore int64    // Repo size at the start of the window
+	SizeAfter  int64    // Repo size now
+	HasRepo    bool     // False when the dotfiles directory is not a git repo
+}
+
+// Growth returns how much the repo grew during the window
+func (d *Digest) Growth() int64 {
+	return d.SizeAfter - d.SizeBefore
+}
+
+// Build computes the digest for the dotfiles repo at dotfilesPath.
+// machine is this machine's name, used to recognize per-machine backup
+// directories (dotfiles/{app}/{machine}/...).
+func Build(dotfilesPath, machine string, stateManager *sync.StateManager, since time.Time) (*Digest, error) {
+	d := &Digest{Since: since}
+
+	if stateManager != nil {
+		for _, f := range stateManager.Files() {
+			if f.SyncedAt.After(since) {
+				d.Synced++
+			}
+		}
+	}
+
+	repo := git.NewRepo(dotfilesPath)
+	if !repo.IsRepo() {
+		return d, nil
+	}
+	d.HasRepo = true
+
+	commits, err := repo.History(since)
+	if err != nil {
+		return d, err
+	}
+
+	machines := knownMachines(dotfilesPath, machine)
+	files := make(map[string]bool)
+	apps := make(map[string]int)
+	active := make(map[string]bool)
+	authors := make(map[string]bool)
+
+	for _, c := range commits {
+		d.Commits++
+		authors[c.Author] = true
+		if c.Merge || isConflictCommit(c.Message) {
+			d.Conflicts++
+		}
+		for _, path := range c.Files {
+			files[path] = true
+			parts := strings.Split(path, "/")
+			if len(parts) < 2 {
+				continue
+			}
+			apps[parts[0]]++
+			if len(parts) > 2 && machines[parts[1]] {
+				active[parts[1]] = true
+			}
+		}
+	}
+
+	d.Files = len(files)
+	d.Apps = sortedCounts(apps)
+	d.Machines = sortedKeys(active)
+	d.Authors = sortedKeys(authors)
+
+	d.SizeBefore, _ = repo.TreeSize(since)
+	d.SizeAfter, _ = repo.TreeSize(time.Now())
+	return d, nil
+}
+
+// knownMachines returns the names used as per-machine backup directories:
+// this machine, plus any directory name that appears under two or more apps
+func knownMachines(dotfilesPath, machine string) map[string]bool {
+	seen := make(map[string]int)
+	apps, _ := os.ReadDir(dotfilesPath)
+	for _, app := range apps {
+		if !app.IsDir() || strings.HasPrefix(app.Name(), ".") {
+			continue
+		}
+		entries, _ := os.ReadDir(filepath.Join(dotfilesPath, app.Name()))
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				seen[e.Name()]++
+			}
+		}
+	}
+
+	machines := make(map[string]bool)
+	if machine != "" {
+		machines[machine] = true
+	}
+	for name, n := range seen {
+		if n >= 2 {
+			machines[name] = true
+		}
+	}
+	return machines
+}
+
+// isConflictCommit reports whether a commit message records a conflict resolution
+func isConflictCommit(message string) bool {
+	lower := strings.ToLower(message)
+	return strings.Contains(lower, "conflict") || strings.HasPrefix(lower, "merge")
+}
+
+func sortedCounts(m map[string]int) []Count {
+	counts := make([]Count, 0, len(m))
+	for name, n := range m {
+		counts = append(counts, Count{Name: name, Changes: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Changes != counts[j].Changes {
+			return counts[i].Changes > counts[j].Changes
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
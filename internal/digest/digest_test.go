@@ -0,0 +1,82 @@
+package digest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dotsync/internal/sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func commitFile(t *testing.T, wt *gogit.Worktree, dir, path, content, message string, when time.Time) {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	os.MkdirAll(filepath.Dir(full), 0755)
+	os.WriteFile(full, []byte(content), 0644)
+	if _, err := wt.Add(path); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	sig := &object.Signature{Name: "Tester", Email: "t@example.com", When: when}
+	if _, err := wt.Commit(message, &gogit.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit failed: %v", err)
+	}
+	wt, _ := repo.Worktree()
+
+	now := time.Now()
+	commitFile(t, wt, dir, "zsh/.zshrc", "old", "initial", now.Add(-30*24*time.Hour))
+	commitFile(t, wt, dir, "zsh/laptop/.zshrc", "backup", "sync: update zsh (1 files)", now.Add(-2*24*time.Hour))
+	commitFile(t, wt, dir, "git/laptop/.gitconfig", "backup", "sync: update git (1 files)", now.Add(-24*time.Hour))
+	commitFile(t, wt, dir, "zsh/.zshrc", "resolved and longer", "Resolve conflict in zsh", now.Add(-time.Hour))
+
+	sm := sync.NewStateManager(t.TempDir())
+	sm.SetFileState("zsh", ".zshrc", "a", "a")
+
+	d, err := Build(dir, "desktop", sm, now.Add(-Week))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !d.HasRepo || d.Commits != 3 {
+		t.Errorf("Expected 3 commits in window, got %d", d.Commits)
+	}
+	if d.Files != 3 {
+		t.Errorf("Expected 3 distinct files, got %d", d.Files)
+	}
+	if len(d.Apps) != 2 || d.Apps[0].Name != "zsh" || d.Apps[0].Changes != 2 {
+		t.Errorf("Unexpected app activity: %+v", d.Apps)
+	}
+	if len(d.Machines) != 1 || d.Machines[0] != "laptop" {
+		t.Errorf("Expected laptop to be active, got %v", d.Machines)
+	}
+	if d.Conflicts != 1 {
+		t.Errorf("Expected 1 conflict resolution, got %d", d.Conflicts)
+	}
+	if d.Synced != 1 {
+		t.Errorf("Expected 1 synced file, got %d", d.Synced)
+	}
+	if d.SizeBefore != 3 || d.Growth() <= 0 {
+		t.Errorf("Unexpected sizes: before=%d after=%d", d.SizeBefore, d.SizeAfter)
+	}
+}
+
+func TestBuild_NotARepo(t *testing.T) {
+	d, err := Build(t.TempDir(), "desktop", nil, time.Now().Add(-Week))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if d.HasRepo || d.Commits != 0 {
+		t.Errorf("Expected empty digest, got %+v", d)
+	}
+}
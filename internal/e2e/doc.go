@@ -0,0 +1,4 @@
+// Package e2e holds end-to-end tests of the push, pull and conflict
+// pipeline. Each test runs scanner, sync and backup against an in-memory
+// filesystem on which several simulated machines share a dotfiles remote.
+package e2e
@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+
+	"dotsync/internal/sync"
+	"dotsync/internal/vfs"
+)
+
+func TestPushPull_TwoMachines(t *testing.T) {
+	w := newWorld(t)
+	laptop, desktop := w.machine("laptop"), w.machine("desktop")
+
+	laptop.write(".tmux.conf", "set -g mouse on\n")
+	laptop.write(".config/nvim/init.lua", "vim.o.number = true\n")
+	laptop.write(".config/nvim/lua/keys.lua", "-- keys\n")
+	for _, r := range laptop.push("tmux", "nvim") {
+		if !r.Success {
+			t.Fatalf("push %s/%s: %v", r.App.ID, r.File.RelPath, r.Error)
+		}
+	}
+
+	// A fresh machine receives everything the laptop pushed
+	for _, r := range desktop.pull("tmux", "nvim") {
+		if !r.Success {
+			t.Fatalf("pull %s/%s: %v", r.App.ID, r.File.RelPath, r.Error)
+		}
+	}
+	for _, rel := range []string{".tmux.conf", ".config/nvim/init.lua", ".config/nvim/lua/keys.lua"} {
+		if got, want := desktop.read(rel), laptop.read(rel); got != want {
+			t.Errorf("desktop %s = %q, want %q", rel, got, want)
+		}
+	}
+
+	// Later edits on one machine reach the other
+	laptop.write(".tmux.conf", "set -g mouse off\n")
+	laptop.push("tmux")
+	desktop.pull("tmux")
+	if got := desktop.read(".tmux.conf"); got != "set -g mouse off\n" {
+		t.Errorf("Expected the laptop's edit on the desktop, got %q", got)
+	}
+}
+
+func TestPull_Conflict(t *testing.T) {
+	w := newWorld(t)
+	laptop, desktop := w.machine("laptop"), w.machine("desktop")
+
+	laptop.write(".tmux.conf", "set -g mouse on\n")
+	laptop.push("tmux")
+	desktop.pull("tmux")
+
+	// Both machines change the file before the desktop pulls again
+	laptop.write(".tmux.conf", "set -g mouse off\n")
+	laptop.push("tmux")
+	desktop.write(".tmux.conf", "set -g status off\n")
+
+	results := desktop.pull("tmux")
+	if len(results) != 1 || !results[0].Conflict || !errors.Is(results[0].Error, sync.ErrConflict) {
+		t.Fatalf("Expected one conflict, got %+v", results)
+	}
+	if got := desktop.read(".tmux.conf"); got != "set -g status off\n" {
+		t.Errorf("Expected the desktop's change kept, got %q", got)
+	}
+
+	// Pushing the resolution makes the laptop's next pull a plain update
+	desktop.write(".tmux.conf", "set -g mouse off\nset -g status off\n")
+	desktop.push("tmux")
+	results = laptop.pull("tmux")
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("Expected the resolution pulled, got %+v", results)
+	}
+	if got := laptop.read(".tmux.conf"); got != "set -g mouse off\nset -g status off\n" {
+		t.Errorf("Expected the merged file on the laptop, got %q", got)
+	}
+	if results[0].BackupPath == "" {
+		t.Error("Expected the replaced file backed up")
+	} else if data, _ := vfs.ReadFile(results[0].BackupPath); string(data) != "set -g mouse off\n" {
+		t.Errorf("Expected the laptop's old file in the backup, got %q", data)
+	}
+}
+
+func TestBackupRestore_AcrossMachines(t *testing.T) {
+	w := newWorld(t)
+	laptop, desktop := w.machine("laptop"), w.machine("desktop")
+
+	laptop.write(".tmux.conf", "set -g prefix C-a\n")
+	if result := laptop.backup("tmux"); len(result.BackedUp) != 1 {
+		t.Fatalf("Expected one file backed up, got %+v", result)
+	}
+
+	desktop.write(".tmux.conf", "set -g prefix C-b\n")
+	if err := desktop.restore("laptop", "tmux", ".tmux.conf"); err != nil {
+		t.Fatal(err)
+	}
+	if got := desktop.read(".tmux.conf"); got != "set -g prefix C-a\n" {
+		t.Errorf("Expected the laptop's backup restored, got %q", got)
+	}
+}
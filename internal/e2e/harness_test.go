@@ -0,0 +1,230 @@
+package e2e
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"dotsync/internal/backup"
+	"dotsync/internal/config"
+	"dotsync/internal/models"
+	"dotsync/internal/modes"
+	"dotsync/internal/scanner"
+	"dotsync/internal/sync"
+	"dotsync/internal/vfs"
+)
+
+// world is an in-memory filesystem holding every simulated machine and
+// the remote their dotfiles clones push to
+type world struct {
+	t      *testing.T
+	fs     *vfs.Mem
+	remote string // Stands in for the git remote
+}
+
+// newWorld swaps the in-memory filesystem in for the test
+func newWorld(t *testing.T) *world {
+	t.Helper()
+	mem := vfs.NewMem()
+	t.Cleanup(vfs.Use(mem))
+	w := &world{t: t, fs: mem, remote: "/remote/dotfiles.git"}
+	if err := vfs.MkdirAll(w.remote, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// machine is one simulated computer: a home directory with a dotfiles
+// clone and sync state of its own
+type machine struct {
+	w     *world
+	name  string
+	home  string
+	cfg   *config.Config
+	modes *modes.ModesConfig
+	state *sync.StateManager
+}
+
+// machine creates a computer with an empty home and a fresh clone
+func (w *world) machine(name string) *machine {
+	w.t.Helper()
+	home := filepath.Join("/machines", name, "home")
+	m := &machine{
+		w:    w,
+		name: name,
+		home: home,
+		cfg: &config.Config{
+			DotfilesPath: filepath.Join(home, "dotfiles"),
+			BackupPath:   filepath.Join(home, ".dotfiles-backup"),
+			Xattrs:       "off", // Extended attributes live on the real filesystem
+		},
+		modes: &modes.ModesConfig{
+			Version:     2,
+			MachineName: name,
+			SyncedApps:  make(map[string]bool),
+			SyncedFiles: make(map[string]bool),
+		},
+		state: sync.NewStateManager(filepath.Join(home, ".config", "dotsync")),
+	}
+	if err := vfs.MkdirAll(m.cfg.DotfilesPath, 0755); err != nil {
+		w.t.Fatal(err)
+	}
+	return m
+}
+
+// path returns where a home-relative path lives on this machine
+func (m *machine) path(rel string) string {
+	return filepath.Join(m.home, rel)
+}
+
+// write creates or replaces a file under the home directory
+func (m *machine) write(rel, content string) {
+	m.w.t.Helper()
+	path := m.path(rel)
+	if err := vfs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		m.w.t.Fatal(err)
+	}
+	if err := vfs.WriteFile(path, []byte(content), 0644); err != nil {
+		m.w.t.Fatal(err)
+	}
+}
+
+// read returns a file under the home directory, "" if it is missing
+func (m *machine) read(rel string) string {
+	data, _ := vfs.ReadFile(m.path(rel))
+	return string(data)
+}
+
+// enter makes this machine the one scanner and sync see as home
+func (m *machine) enter() {
+	m.w.t.Setenv("HOME", m.home)
+}
+
+// scan returns the installed apps among ids, selected for sync
+func (m *machine) scan(ids ...string) []*models.App {
+	m.w.t.Helper()
+	m.enter()
+	apps, err := scanner.New("").Scan()
+	if err != nil {
+		m.w.t.Fatalf("%s: scan: %v", m.name, err)
+	}
+	var selected []*models.App
+	for _, app := range apps {
+		if slices.Contains(ids, app.ID) {
+			app.Selected = true
+			selected = append(selected, app)
+		}
+	}
+	return selected
+}
+
+// push exports the apps into the clone, records the sync state and
+// publishes the clone to the remote
+func (m *machine) push(ids ...string) []sync.ExportResult {
+	m.w.t.Helper()
+	apps := m.scan(ids...)
+	results, err := sync.NewExporter(m.cfg).ExportAll(apps)
+	if err != nil {
+		m.w.t.Fatalf("%s: push: %v", m.name, err)
+	}
+	for _, r := range results {
+		if r.Success {
+			m.record(r.App.ID, r.File, true)
+		}
+	}
+	m.w.mirror(m.cfg.DotfilesPath, m.w.remote)
+	return results
+}
+
+// pull fetches the remote into the clone and imports the apps. A machine
+// without the apps yet receives what the clone holds for them.
+func (m *machine) pull(ids ...string) []sync.ImportResult {
+	m.w.t.Helper()
+	m.w.mirror(m.w.remote, m.cfg.DotfilesPath)
+	apps := m.scan(ids...)
+	if len(apps) == 0 {
+		restored, _ := scanner.New("").ScanDotfiles(m.cfg.DotfilesPath)
+		for _, app := range restored {
+			if slices.Contains(ids, app.ID) {
+				apps = append(apps, app)
+			}
+		}
+	}
+	results, err := sync.NewImporter(m.cfg).WithStateManager(m.state).ImportAll(apps)
+	if err != nil {
+		m.w.t.Fatalf("%s: pull: %v", m.name, err)
+	}
+	for _, r := range results {
+		if r.Success {
+			m.record(r.App.ID, r.File, false)
+		}
+	}
+	return results
+}
+
+// record notes both sides of a synced file in the state, as the TUI does
+func (m *machine) record(appID string, file models.File, pushed bool) {
+	if file.IsDir {
+		return
+	}
+	localHash, _ := sync.ComputeFileHashNoCache(file.Path)
+	dotfilesHash, _ := sync.ComputeFileHashNoCache(sync.DotfilePath(m.cfg.DotfilesPath, appID, file))
+	m.state.SetFileState(appID, file.RelPath, localHash, dotfilesHash)
+	if pushed {
+		m.state.RecordPush(appID, file.RelPath)
+	} else {
+		m.state.RecordPull(appID, file.RelPath)
+	}
+}
+
+// backup copies the apps into this machine's backup folder of the clone
+// and publishes it
+func (m *machine) backup(ids ...string) *backup.BackupResult {
+	m.w.t.Helper()
+	result, err := backup.New(m.cfg, m.modes).Backup(m.scan(ids...))
+	if err != nil {
+		m.w.t.Fatalf("%s: backup: %v", m.name, err)
+	}
+	m.w.mirror(m.cfg.DotfilesPath, m.w.remote)
+	return result
+}
+
+// restore fetches the remote and restores a file from another machine's
+// backup
+func (m *machine) restore(from, appID, fileName string) error {
+	m.w.mirror(m.w.remote, m.cfg.DotfilesPath)
+	m.enter()
+	return backup.New(m.cfg, m.modes).RestoreFile(from, appID, fileName, true)
+}
+
+// mirror replaces dst with a copy of src, like a push or a fast-forward
+// pull of the whole repo
+func (w *world) mirror(src, dst string) {
+	w.t.Helper()
+	if err := vfs.RemoveAll(dst); err != nil {
+		w.t.Fatal(err)
+	}
+	err := vfs.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return vfs.MkdirAll(target, 0755)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := vfs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return vfs.WriteFile(target, data, info.Mode().Perm())
+	})
+	if err != nil {
+		w.t.Fatalf("mirror %s to %s: %v", src, dst, err)
+	}
+}
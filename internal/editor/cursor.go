@@ -33,3 +33,10 @@ func (e *Cursor) OpenDiff(file1, file2 string) error {
 	e.cmd = exec.Command(e.command, "--wait", "--diff", file1, file2)
 	return e.cmd.Start()
 }
+
+// OpenFile opens a file in Cursor
+// Command: cursor --wait FILE
+func (e *Cursor) OpenFile(path string) error {
+	e.cmd = exec.Command(e.command, "--wait", path)
+	return e.cmd.Start()
+}
@@ -23,6 +23,9 @@ type Editor interface {
 	// OpenDiff opens a diff view between two files
 	OpenDiff(file1, file2 string) error
 
+	// OpenFile opens a single file for editing
+	OpenFile(path string) error
+
 	// Wait blocks until the editor is closed
 	Wait() error
 }
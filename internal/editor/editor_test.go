@@ -140,3 +140,74 @@ func TestDetectWithNotInstalledEditor(t *testing.T) {
 	_, _ = Detect(cfg)
 	// No assertion needed - we're just checking it doesn't panic
 }
+
+func TestParseTool(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantErr   bool
+		wantMerge bool
+	}{
+		{"delta", false, false},
+		{"vimdiff", false, true},
+		{"code", false, true},
+		{"kdiff3 {local} {dotfiles} -o {merged}", false, true},
+		{"difft {local} {dotfiles}", false, false},
+		{"", true, false},
+		{"no-such-tool", true, false},
+	}
+
+	for _, tt := range tests {
+		tool, err := ParseTool(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTool(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (len(tool.Merge) > 0) != tt.wantMerge {
+			t.Errorf("ParseTool(%q) merge = %v, want %v", tt.spec, len(tool.Merge) > 0, tt.wantMerge)
+		}
+	}
+}
+
+func TestToolCommands(t *testing.T) {
+	tool, err := ParseTool("kdiff3 {local} {dotfiles} -o {merged}")
+	if err != nil {
+		t.Fatalf("ParseTool failed: %v", err)
+	}
+
+	cmd := tool.DiffCmd("/home/a", "/dots/a")
+	want := []string{"kdiff3", "/home/a", "/dots/a", "-o", "/home/a"}
+	if len(cmd.Args) != len(want) {
+		t.Fatalf("DiffCmd args = %v, want %v", cmd.Args, want)
+	}
+	for i := range want {
+		if cmd.Args[i] != want[i] {
+			t.Errorf("DiffCmd args = %v, want %v", cmd.Args, want)
+			break
+		}
+	}
+
+	cmd, err = tool.MergeCmd("/home/a", "/dots/a", "/tmp/out")
+	if err != nil {
+		t.Fatalf("MergeCmd failed: %v", err)
+	}
+	if cmd.Args[len(cmd.Args)-1] != "/tmp/out" {
+		t.Errorf("Expected merged path last, got %v", cmd.Args)
+	}
+
+	if _, err := Tools["delta"].MergeCmd("a", "b", "a"); err == nil {
+		t.Error("Expected error merging with a diff-only tool")
+	}
+}
+
+func TestNextToolCycles(t *testing.T) {
+	name := ""
+	for range ToolNames {
+		name = NextTool(name)
+	}
+	if name != "" {
+		t.Errorf("Expected cycle back to built-in, got %q", name)
+	}
+	if got := NextTool("difft {local} {dotfiles}"); got != "" {
+		t.Errorf("Custom command should cycle to built-in, got %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package editor
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Placeholders in tool arguments
+const (
+	ArgLocal    = "{local}"    // The local config file
+	ArgDotfiles = "{dotfiles}" // The copy in the dotfiles repo
+	ArgMerged   = "{merged}"   // Where the merge result is written
+)
+
+// Tool is an external diff/merge program. Diff and Merge are argument
+// templates with placeholders; a tool without Merge can only diff.
+type Tool struct {
+	Name  string
+	Diff  []string
+	Merge []string
+}
+
+// Tools are the built-in external tools
+var Tools = map[string]Tool{
+	"delta": {
+		Name: "delta",
+		Diff: []string{"delta", "--paging=always", ArgLocal, ArgDotfiles},
+	},
+	"vimdiff": {
+		Name:  "vimdiff",
+		Diff:  []string{"vimdiff", ArgLocal, ArgDotfiles},
+		Merge: []string{"vimdiff", ArgMerged, ArgDotfiles},
+	},
+	"nvimdiff": {
+		Name:  "nvimdiff",
+		Diff:  []string{"nvim", "-d", ArgLocal, ArgDotfiles},
+		Merge: []string{"nvim", "-d", ArgMerged, ArgDotfiles},
+	},
+	"meld": {
+		Name:  "meld",
+		Diff:  []string{"meld", ArgLocal, ArgDotfiles},
+		Merge: []string{"meld", ArgLocal, ArgMerged, ArgDotfiles, "--output", ArgMerged},
+	},
+	"code": {
+		Name:  "VS Code",
+		Diff:  []string{"code", "--wait", "--diff", ArgLocal, ArgDotfiles},
+		Merge: []string{"code", "--wait", "--merge", ArgLocal, ArgDotfiles, ArgLocal, ArgMerged},
+	},
+}
+
+// ToolNames lists the built-in tools in settings order; "" is the
+// built-in diff/merge view
+var ToolNames = []string{"", "delta", "vimdiff", "nvimdiff", "meld", "code"}
+
+// NextTool returns the tool name after name in ToolNames. Custom commands
+// cycle back to the built-in view.
+func NextTool(name string) string {
+	for i, n := range ToolNames {
+		if n == name {
+			return ToolNames[(i+1)%len(ToolNames)]
+		}
+	}
+	return ToolNames[0]
+}
+
+// ParseTool resolves a configured tool: a built-in name, or a custom
+// command line using the {local}, {dotfiles} and {merged} placeholders,
+// e.g. "kdiff3 {local} {dotfiles} -o {merged}"
+func ParseTool(spec string) (Tool, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Tool{}, fmt.Errorf("no external tool configured")
+	}
+	if t, ok := Tools[spec]; ok {
+		return t, nil
+	}
+	if !strings.Contains(spec, ArgLocal) && !strings.Contains(spec, ArgDotfiles) {
+		return Tool{}, fmt.Errorf("unknown tool %q (use %s or a command with %s and %s)",
+			spec, strings.Join(ToolNames[1:], ", "), ArgLocal, ArgDotfiles)
+	}
+
+	args := strings.Fields(spec)
+	t := Tool{Name: args[0], Diff: args}
+	if strings.Contains(spec, ArgMerged) {
+		t.Merge = args
+	}
+	return t, nil
+}
+
+// IsInstalled reports whether the tool's program is on $PATH
+func (t Tool) IsInstalled() bool {
+	return len(t.Diff) > 0 && isCommandAvailable(t.Diff[0])
+}
+
+// DiffCmd returns the command comparing local with dotfiles
+func (t Tool) DiffCmd(local, dotfiles string) *exec.Cmd {
+	args := expandArgs(t.Diff, local, dotfiles, local)
+	return exec.Command(args[0], args[1:]...)
+}
+
+// MergeCmd returns the command merging dotfiles into merged
+func (t Tool) MergeCmd(local, dotfiles, merged string) (*exec.Cmd, error) {
+	if len(t.Merge) == 0 {
+		return nil, fmt.Errorf("%s cannot merge", t.Name)
+	}
+	args := expandArgs(t.Merge, local, dotfiles, merged)
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// expandArgs fills the placeholders in args
+func expandArgs(args []string, local, dotfiles, merged string) []string {
+	r := strings.NewReplacer(ArgLocal, local, ArgDotfiles, dotfiles, ArgMerged, merged)
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = r.Replace(a)
+	}
+	return out
+}
@@ -34,3 +34,10 @@ func (e *VSCode) OpenDiff(file1, file2 string) error {
 	e.cmd = exec.Command(e.command, "--wait", "--diff", file1, file2)
 	return e.cmd.Start()
 }
+
+// OpenFile opens a file in VS Code
+// Command: code --wait FILE
+func (e *VSCode) OpenFile(path string) error {
+	e.cmd = exec.Command(e.command, "--wait", path)
+	return e.cmd.Start()
+}
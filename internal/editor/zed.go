@@ -28,8 +28,15 @@ func (e *Zed) OpenMerge(local, remote, merged string) error {
 }
 
 // OpenDiff opens Zed's diff view between two files
-// Command: zed --wait FILE1 FILE2
+// Command: zed --wait --diff FILE1 FILE2
 func (e *Zed) OpenDiff(file1, file2 string) error {
-	e.cmd = exec.Command(e.command, "--wait", file1, file2)
+	e.cmd = exec.Command(e.command, "--wait", "--diff", file1, file2)
+	return e.cmd.Start()
+}
+
+// OpenFile opens a file in Zed
+// Command: zed --wait FILE
+func (e *Zed) OpenFile(path string) error {
+	e.cmd = exec.Command(e.command, "--wait", path)
 	return e.cmd.Start()
 }
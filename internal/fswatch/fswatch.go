@@ -0,0 +1,166 @@
+// Package fswatch reports changes to a set of files from filesystem events
+// (inotify on Linux, kqueue on macOS and the BSDs), batched so a burst of
+// writes from an editor save or a git checkout becomes one refresh.
+package fswatch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// MaxDirs caps the directories one watcher holds. kqueue takes a file
+// descriptor per watched file, so past this callers should poll instead.
+const MaxDirs = 1000
+
+// ErrTooMany is returned by Set when the files span more than MaxDirs
+// directories
+var ErrTooMany = errors.New("too many directories to watch")
+
+// Watcher watches a set of files. Their parent directories are what is
+// watched, so files replaced by a rename (as most editors save) or created
+// later are still seen.
+type Watcher struct {
+	fsw      *fsnotify.Watcher
+	debounce time.Duration
+	events   chan []string
+	done     chan struct{}
+	closed   sync.Once
+
+	mu    sync.Mutex
+	files map[string]bool // Reported on change
+	dirs  map[string]bool // Watched
+}
+
+// New starts a watcher that batches the changes of each debounce window
+func New(debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		fsw:      fsw,
+		debounce: debounce,
+		events:   make(chan []string),
+		done:     make(chan struct{}),
+		files:    make(map[string]bool),
+		dirs:     make(map[string]bool),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Set replaces the watched files. Directories that don't exist yet are
+// skipped; a file created in one shows up after the next Set.
+func (w *Watcher) Set(paths []string) error {
+	files := make(map[string]bool, len(paths))
+	dirs := make(map[string]bool)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		files[p] = true
+		dirs[filepath.Dir(p)] = true
+	}
+	if len(dirs) > MaxDirs {
+		return fmt.Errorf("%w: %d (at most %d)", ErrTooMany, len(dirs), MaxDirs)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			_ = w.fsw.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+	var errs []error
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.fsw.Add(dir); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		w.dirs[dir] = true
+	}
+	w.files = files
+	return errors.Join(errs...)
+}
+
+// Events delivers the changed files of each debounce window, sorted
+func (w *Watcher) Events() <-chan []string {
+	return w.events
+}
+
+// Close stops watching and closes Events. Later calls do nothing.
+func (w *Watcher) Close() error {
+	var err error
+	w.closed.Do(func() {
+		close(w.done)
+		err = w.fsw.Close()
+	})
+	return err
+}
+
+// run collects events into batches. The window opens at the first change
+// and isn't extended by later ones, so a file written continuously still
+// refreshes once per window.
+func (w *Watcher) run() {
+	defer close(w.events)
+	var pending map[string]bool
+	var flush <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			// Attribute changes come with every touch and atime update
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			path := filepath.Clean(ev.Name)
+			w.mu.Lock()
+			tracked := w.files[path]
+			w.mu.Unlock()
+			if !tracked {
+				continue
+			}
+			if pending == nil {
+				pending = make(map[string]bool)
+				flush = time.After(w.debounce)
+			}
+			pending[path] = true
+
+		case _, ok := <-w.fsw.Errors:
+			// Queue overflows lose events; the next ones still come
+			if !ok {
+				return
+			}
+
+		case <-flush:
+			batch := make([]string, 0, len(pending))
+			for p := range pending {
+				batch = append(batch, p)
+			}
+			sort.Strings(batch)
+			pending, flush = nil, nil
+			select {
+			case w.events <- batch:
+			case <-w.done:
+				return
+			}
+
+		case <-w.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// next waits for the next batch of changes
+func next(t *testing.T, w *Watcher) []string {
+	t.Helper()
+	select {
+	case batch := <-w.Events():
+		return batch
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a batch of changes")
+		return nil
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	tracked := filepath.Join(dir, "config", "app.conf")
+	later := filepath.Join(dir, "config", "later.conf")
+	other := filepath.Join(dir, "config", "other.conf")
+	os.MkdirAll(filepath.Dir(tracked), 0755)
+	os.WriteFile(tracked, []byte("a"), 0644)
+
+	w, err := New(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	missing := filepath.Join(dir, "not-yet", "x.conf")
+	if err := w.Set([]string{tracked, later, missing}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// A burst of writes is one batch; untracked files are left out
+	for i := 0; i < 20; i++ {
+		os.WriteFile(tracked, []byte(strings.Repeat("a", i)), 0644)
+	}
+	os.WriteFile(other, []byte("x"), 0644)
+	if batch := next(t, w); len(batch) != 1 || batch[0] != tracked {
+		t.Errorf("Expected one batch with app.conf, got %v", batch)
+	}
+
+	// Saving by rename, as editors do, and creating a tracked file
+	tmp := tracked + ".swp"
+	os.WriteFile(tmp, []byte("b"), 0644)
+	os.Rename(tmp, tracked)
+	os.WriteFile(later, []byte("c"), 0644)
+	if batch := next(t, w); strings.Join(batch, ",") != tracked+","+later {
+		t.Errorf("Expected app.conf and later.conf, got %v", batch)
+	}
+
+	// Untracked after Set drops it
+	w.Set([]string{later})
+	os.WriteFile(tracked, []byte("d"), 0644)
+	os.WriteFile(later, []byte("e"), 0644)
+	if batch := next(t, w); len(batch) != 1 || batch[0] != later {
+		t.Errorf("Expected only later.conf, got %v", batch)
+	}
+}
+
+func TestWatcher_TooMany(t *testing.T) {
+	w, err := New(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var paths []string
+	for i := 0; i <= MaxDirs; i++ {
+		paths = append(paths, filepath.Join("/nonexistent", strconv.Itoa(i), "file"))
+	}
+	if err := w.Set(paths); err == nil {
+		t.Error("Expected an error past MaxDirs")
+	}
+}
+
+func TestWatcher_Close(t *testing.T) {
+	w, err := New(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	w.Close() // A second close, e.g. on quit after the polling fallback, is a no-op
+
+	select {
+	case _, ok := <-w.Events():
+		if ok {
+			t.Error("Expected Events closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Events closed")
+	}
+}
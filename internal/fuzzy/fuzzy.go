@@ -0,0 +1,115 @@
+// Package fuzzy scores fzf-style fuzzy matches: every character of the
+// pattern must appear in the text in order, and matches on word boundaries
+// and in consecutive runs score higher.
+package fuzzy
+
+import (
+	"unicode"
+)
+
+// Scoring weights
+const (
+	scoreMatch       = 16 // Every matched character
+	bonusBoundary    = 10 // Match at the start of a word or path segment
+	bonusConsecutive = 8  // Match right after the previous matched character
+	penaltyGap       = 1  // Every unmatched character inside the match
+	penaltyLeading   = 1  // Every character before the match, up to maxLeading
+	maxLeading       = 8
+)
+
+// Score matches pattern against text, ignoring case. It reports whether
+// pattern matched and how well; higher scores are better matches. An empty
+// pattern matches everything with a score of 0.
+func Score(pattern, text string) (int, bool) {
+	p := []rune(toLower(pattern))
+	t := []rune(text)
+	lower := []rune(toLower(text))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	// Find the end of the first complete match scanning forward, then walk
+	// back from there to the latest possible start: the shortest window
+	pi := 0
+	end := -1
+	for i := range lower {
+		if lower[i] == p[pi] {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, false
+	}
+	pi = len(p) - 1
+	start := end
+	for i := end; i >= 0; i-- {
+		if lower[i] == p[pi] {
+			if pi == 0 {
+				start = i
+				break
+			}
+			pi--
+		}
+	}
+
+	// Score the window left to right
+	score := -min(start, maxLeading) * penaltyLeading
+	pi = 0
+	consecutive := 0
+	for i := start; i <= end; i++ {
+		if pi < len(p) && lower[i] == p[pi] {
+			score += scoreMatch
+			if isBoundary(t, i) {
+				score += bonusBoundary
+			}
+			if consecutive > 0 {
+				score += bonusConsecutive * consecutive
+			}
+			consecutive++
+			pi++
+		} else {
+			score -= penaltyGap
+			consecutive = 0
+		}
+	}
+	return score, true
+}
+
+// Best returns the highest score of pattern against any of texts
+func Best(pattern string, texts ...string) (int, bool) {
+	best, found := 0, false
+	for _, text := range texts {
+		if score, ok := Score(pattern, text); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// isBoundary reports whether t[i] starts a word: the first character, one
+// after a separator, or an upper-case letter after a lower-case one
+func isBoundary(t []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := t[i-1]
+	switch prev {
+	case '/', '.', '_', '-', ' ', '\\':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(t[i])
+}
+
+// toLower lower-cases s rune by rune, keeping one rune per input rune so
+// positions line up with the original text
+func toLower(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
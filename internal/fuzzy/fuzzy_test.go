@@ -0,0 +1,60 @@
+package fuzzy
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		match   bool
+	}{
+		{"", "anything", true},
+		{"nvim", "Neovim", true},
+		{"vn", "Neovim", false},
+		{"zrc", ".zshrc", true},
+		{"initlua", "lua/init.lua", true},
+		{"luainit", "lua/init.lua", true},
+		{"ZSH", "zsh", true},
+		{"abc", "ab", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := Score(tt.pattern, tt.text); ok != tt.match {
+			t.Errorf("Score(%q, %q) matched = %v, want %v", tt.pattern, tt.text, ok, tt.match)
+		}
+	}
+}
+
+func TestScoreRanking(t *testing.T) {
+	tests := []struct {
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"zsh", "zsh", "fuzzy-shell"},                              // Consecutive beats scattered
+		{"init", "nvim/init.lua", "nvim/lua/plugin/settings-init"}, // Less leading text
+		{"gc", "git/config", "magic"},                              // Word boundaries
+		{"kc", "kitty/kitty.conf", "kitty/mock"},                   // Boundary after separator
+	}
+
+	for _, tt := range tests {
+		b, ok1 := Score(tt.pattern, tt.better)
+		w, ok2 := Score(tt.pattern, tt.worse)
+		if !ok1 || !ok2 {
+			t.Fatalf("%q should match both %q and %q", tt.pattern, tt.better, tt.worse)
+		}
+		if b <= w {
+			t.Errorf("%q: expected %q (%d) to beat %q (%d)", tt.pattern, tt.better, b, tt.worse, w)
+		}
+	}
+}
+
+func TestBest(t *testing.T) {
+	if _, ok := Best("xyz", "abc", "def"); ok {
+		t.Error("Best should fail when nothing matches")
+	}
+	exact, _ := Score("git", "git")
+	if got, ok := Best("git", "digit", "git"); !ok || got != exact {
+		t.Errorf("Best should return the top score %d, got %d", exact, got)
+	}
+}
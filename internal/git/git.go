@@ -1,14 +1,20 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
 )
@@ -29,6 +35,36 @@ func NewRepo(path string) *Repo {
 	return r
 }
 
+// IsURL reports whether s looks like a git remote rather than a local
+// path: an https, ssh or git URL, or scp-like user@host:path
+func IsURL(s string) bool {
+	s = strings.TrimSpace(s)
+	for _, scheme := range []string{"https://", "http://", "ssh://", "git://", "file://"} {
+		if strings.HasPrefix(s, scheme) {
+			return true
+		}
+	}
+	at := strings.Index(s, "@")
+	colon := strings.Index(s, ":")
+	return at > 0 && colon > at && !strings.ContainsAny(s[:at], "/ ")
+}
+
+// Clone clones url into path and opens it. An existing repo at path is
+// opened as is, so provisioning can be re-run.
+func Clone(url, path string) (*Repo, error) {
+	if r := NewRepo(path); r.IsRepo() {
+		return r, nil
+	}
+
+	// Use exec for clone so the user's credential helpers and ssh keys apply
+	cmd := exec.Command("git", "clone", "--quiet", url, path)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("clone failed: %s", strings.TrimSpace(string(output)))
+	}
+	return NewRepo(path), nil
+}
+
 // IsRepo checks if the path is a git repository
 func (r *Repo) IsRepo() bool {
 	return r.repo != nil
@@ -144,11 +180,50 @@ func (r *Repo) calculateAheadBehind(status *Status) {
 		return
 	}
 
-	localHash := head.Hash()
-	remoteHeadHash := remoteHash.Hash()
+	status.Ahead, status.Behind = r.countAheadBehind(head.Hash(), remoteHash.Hash())
+}
 
-	if localHash == remoteHeadHash {
-		return
+// Upstream returns the remote branch the current branch tracks, e.g.
+// origin/main, "" if it has none yet
+func (r *Repo) Upstream() string {
+	if r.repo == nil {
+		return ""
+	}
+	head, err := r.repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return ""
+	}
+	ref := plumbing.NewRemoteReferenceName("origin", head.Name().Short())
+	if _, err := r.repo.Reference(ref, true); err != nil {
+		return ""
+	}
+	return ref.Short()
+}
+
+// AheadBehind returns how many commits rev has that base lacks (ahead),
+// and the other way round (behind). Both are revisions such as a branch,
+// origin/main or a commit.
+func (r *Repo) AheadBehind(rev, base string) (ahead, behind int, err error) {
+	if r.repo == nil {
+		return 0, 0, fmt.Errorf("not a git repository")
+	}
+	revHash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return 0, 0, fmt.Errorf("unknown revision %q: %w", rev, err)
+	}
+	baseHash, err := r.repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return 0, 0, fmt.Errorf("unknown revision %q: %w", base, err)
+	}
+	ahead, behind = r.countAheadBehind(*revHash, *baseHash)
+	return ahead, behind, nil
+}
+
+// countAheadBehind counts the commits reachable from only one of local
+// and remote
+func (r *Repo) countAheadBehind(localHash, remoteHash plumbing.Hash) (ahead, behind int) {
+	if localHash == remoteHash {
+		return 0, 0
 	}
 
 	// Count commits ahead/behind
@@ -165,7 +240,7 @@ func (r *Repo) calculateAheadBehind(status *Status) {
 	}
 
 	// Get remote commits
-	remoteIter, err := r.repo.Log(&git.LogOptions{From: remoteHeadHash})
+	remoteIter, err := r.repo.Log(&git.LogOptions{From: remoteHash})
 	if err == nil {
 		_ = remoteIter.ForEach(func(c *object.Commit) error {
 			remoteCommits[c.Hash] = true
@@ -176,16 +251,17 @@ func (r *Repo) calculateAheadBehind(status *Status) {
 	// Count ahead (local commits not in remote)
 	for hash := range localCommits {
 		if !remoteCommits[hash] {
-			status.Ahead++
+			ahead++
 		}
 	}
 
 	// Count behind (remote commits not in local)
 	for hash := range remoteCommits {
 		if !localCommits[hash] {
-			status.Behind++
+			behind++
 		}
 	}
+	return ahead, behind
 }
 
 // Add stages files for commit
@@ -250,16 +326,99 @@ func (r *Repo) Commit(message string) error {
 		return err
 	}
 
+	identity := r.Identity()
+
+	// go-git can't use the user's gpg/ssh agent, so signed commits go through git
+	if identity.SigningKey != "" {
+		cmd := exec.Command("git", "-C", r.Path, "commit", "-S", "-m", message)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("commit failed: %s", string(output))
+		}
+		return nil
+	}
+
+	name, email := identity.Name, identity.Email
+	if name == "" {
+		name = "dotsync"
+	}
+	if email == "" {
+		email = "dotsync@local"
+	}
+
 	_, err = worktree.Commit(message, &git.CommitOptions{
 		Author: &object.Signature{
-			Name:  "dotsync",
-			Email: "dotsync@local",
+			Name:  name,
+			Email: email,
 			When:  time.Now(),
 		},
 	})
 	return err
 }
 
+// Identity holds the commit identity stored in the repo's local config
+type Identity struct {
+	Name       string
+	Email      string
+	SigningKey string
+}
+
+// Identity returns the identity from the repo's local .git/config.
+// Global/system config is intentionally ignored so each dotfiles repo
+// commits with its own identity.
+func (r *Repo) Identity() Identity {
+	if r.repo == nil {
+		return Identity{}
+	}
+
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return Identity{}
+	}
+
+	return Identity{
+		Name:       cfg.User.Name,
+		Email:      cfg.User.Email,
+		SigningKey: cfg.Raw.Section("user").Option("signingkey"),
+	}
+}
+
+// SetIdentity writes user.name, user.email and user.signingkey to the
+// repo's local config. Empty values remove the setting.
+func (r *Repo) SetIdentity(id Identity) error {
+	if r.repo == nil {
+		return fmt.Errorf("not a git repository")
+	}
+
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return err
+	}
+
+	cfg.User.Name = id.Name
+	cfg.User.Email = id.Email
+
+	// go-git only writes non-empty values, so clear removed ones explicitly
+	user := cfg.Raw.Section("user")
+	if id.Name == "" {
+		user.RemoveOption("name")
+	}
+	if id.Email == "" {
+		user.RemoveOption("email")
+	}
+
+	commit := cfg.Raw.Section("commit")
+	if id.SigningKey != "" {
+		user.SetOption("signingkey", id.SigningKey)
+		commit.SetOption("gpgsign", "true")
+	} else {
+		user.RemoveOption("signingkey")
+		commit.RemoveOption("gpgsign")
+	}
+
+	return r.repo.SetConfig(cfg)
+}
+
 // CommitAmend amends the last commit
 func (r *Repo) CommitAmend(message string) error {
 	if r.repo == nil {
@@ -330,6 +489,25 @@ func (r *Repo) Fetch() error {
 	return cmd.Run()
 }
 
+// FetchQuiet fetches from the remote without asking for credentials,
+// for fetches that run in the background. Remotes that need a password
+// or a passphrase fail instead.
+func (r *Repo) FetchQuiet() error {
+	if r.repo == nil {
+		return fmt.Errorf("not a git repository")
+	}
+
+	cmd := exec.Command("git", "-C", r.Path, "fetch", "--quiet")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("fetch failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // Stash stashes current changes
 func (r *Repo) Stash() error {
 	if r.repo == nil {
@@ -384,6 +562,55 @@ func (r *Repo) Branches() []string {
 	return branches
 }
 
+// RemoteBranches returns the remote-tracking branches, such as origin/main
+func (r *Repo) RemoteBranches() []string {
+	if r.repo == nil {
+		return nil
+	}
+
+	refs, err := r.repo.References()
+	if err != nil {
+		return nil
+	}
+
+	var branches []string
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() && ref.Type() == plumbing.HashReference {
+			branches = append(branches, ref.Name().Short())
+		}
+		return nil
+	})
+
+	sort.Strings(branches)
+	return branches
+}
+
+// ReadFile returns a file's content at a revision such as HEAD, a branch
+// or a commit. path is relative to the repo root. A file missing at that
+// revision is reported with os.ErrNotExist.
+func (r *Repo) ReadFile(rev, path string) ([]byte, error) {
+	if r.repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q: %w", rev, err)
+	}
+	commit, err := r.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(filepath.ToSlash(path))
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, fmt.Errorf("%s at %s: %w", path, rev, os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.Contents()
+	return []byte(content), err
+}
+
 // Checkout switches to a branch
 func (r *Repo) Checkout(branch string) error {
 	if r.repo == nil {
@@ -400,6 +627,61 @@ func (r *Repo) Checkout(branch string) error {
 	})
 }
 
+// CreateBranch creates branch at rev, or at HEAD when rev is empty, and
+// switches to it, carrying over uncommitted changes like git checkout -b
+func (r *Repo) CreateBranch(branch, rev string) error {
+	if r.repo == nil {
+		return fmt.Errorf("not a git repository")
+	}
+
+	args := []string{"-C", r.Path, "checkout", "-b", branch}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("create branch failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SetUpstream makes branch track the same-named branch of remote, so a
+// plain push publishes it even before it exists there
+func (r *Repo) SetUpstream(branch, remote string) error {
+	if r.repo == nil {
+		return fmt.Errorf("not a git repository")
+	}
+
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Branches[branch] = &config.Branch{
+		Name:   branch,
+		Remote: remote,
+		Merge:  plumbing.NewBranchReferenceName(branch),
+	}
+	return r.repo.SetConfig(cfg)
+}
+
+// Merge merges rev into the current branch. A merge that stops on
+// conflicts is aborted, leaving the branch as it was.
+func (r *Repo) Merge(rev string) error {
+	if r.repo == nil {
+		return fmt.Errorf("not a git repository")
+	}
+
+	// Use exec for merge as go-git only fast-forwards
+	cmd := exec.Command("git", "-C", r.Path, "merge", "--no-edit", rev)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		_ = exec.Command("git", "-C", r.Path, "merge", "--abort").Run()
+		return fmt.Errorf("merge of %s failed: %s", rev, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // Log returns recent commit logs
 func (r *Repo) Log(count int) ([]CommitInfo, error) {
 	if r.repo == nil {
@@ -435,6 +717,39 @@ func (r *Repo) Log(count int) ([]CommitInfo, error) {
 	return commits, nil
 }
 
+// PathLog returns the latest commits on HEAD changing a path that match
+// accepts, newest first
+func (r *Repo) PathLog(match func(path string) bool, count int) ([]CommitInfo, error) {
+	if r.repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+
+	head, err := r.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commitIter, err := r.repo.Log(&git.LogOptions{From: head.Hash(), PathFilter: match})
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []CommitInfo
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if len(commits) >= count {
+			return storer.ErrStop
+		}
+		commits = append(commits, CommitInfo{
+			Hash:    c.Hash.String()[:7],
+			Message: strings.Split(c.Message, "\n")[0],
+			Author:  c.Author.Name,
+			Date:    c.Author.When.Format("2006-01-02 15:04"),
+		})
+		return nil
+	})
+	return commits, err
+}
+
 // CommitInfo holds commit information
 type CommitInfo struct {
 	Hash    string
@@ -443,6 +758,276 @@ type CommitInfo struct {
 	Date    string
 }
 
+// CommitStat is a commit with the paths it changed
+type CommitStat struct {
+	Hash    string
+	Message string
+	Author  string
+	When    time.Time
+	Merge   bool     // Has more than one parent
+	Files   []string // Paths changed relative to the first parent
+}
+
+// History returns the commits on HEAD authored since the given time,
+// newest first
+func (r *Repo) History(since time.Time) ([]CommitStat, error) {
+	if r.repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+
+	head, err := r.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commitIter, err := r.repo.Log(&git.LogOptions{From: head.Hash(), Since: &since})
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []CommitStat
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		stat := CommitStat{
+			Hash:    c.Hash.String()[:7],
+			Message: strings.Split(c.Message, "\n")[0],
+			Author:  c.Author.Name,
+			When:    c.Author.When,
+			Merge:   c.NumParents() > 1,
+		}
+		if stats, err := c.Stats(); err == nil {
+			for _, fs := range stats {
+				stat.Files = append(stat.Files, fs.Name)
+			}
+		}
+		commits = append(commits, stat)
+		return nil
+	})
+	return commits, err
+}
+
+// TreeSize returns the total size of the files tracked by the newest
+// commit made at or before t, or 0 if there is none
+func (r *Repo) TreeSize(t time.Time) (int64, error) {
+	if r.repo == nil {
+		return 0, fmt.Errorf("not a git repository")
+	}
+
+	commit, err := r.commitAt(t)
+	if err != nil || commit == nil {
+		return 0, err
+	}
+
+	files, err := commit.Files()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	err = files.ForEach(func(f *object.File) error {
+		size += f.Size
+		return nil
+	})
+	return size, err
+}
+
+// commitAt returns the newest commit on HEAD made at or before t, or nil
+// if there is none
+func (r *Repo) commitAt(t time.Time) (*object.Commit, error) {
+	head, err := r.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commitIter, err := r.repo.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+
+	var commit *object.Commit
+	_ = commitIter.ForEach(func(c *object.Commit) error {
+		if !c.Committer.When.After(t) {
+			commit = c
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return commit, nil
+}
+
+// Change is how a file differs between two revisions
+type Change string
+
+const (
+	ChangeAdded    Change = "added"
+	ChangeModified Change = "modified"
+	ChangeDeleted  Change = "deleted"
+	ChangeRenamed  Change = "renamed"
+)
+
+// FileChange is a file that differs between two revisions
+type FileChange struct {
+	Path    string // Path in the newer revision, or the old path if deleted
+	OldPath string // Previous path of a renamed file
+	Change  Change
+	Added   int // Lines added
+	Removed int // Lines removed
+	Binary  bool
+}
+
+// Snapshot is a commit standing for the state of the repo at some point
+type Snapshot struct {
+	Hash string // Full hash, empty before the first commit
+	When time.Time
+}
+
+// SnapshotAt returns the newest commit on HEAD made at or before t. The
+// snapshot has no hash when the repo has no commit that old.
+func (r *Repo) SnapshotAt(t time.Time) (Snapshot, error) {
+	if r.repo == nil {
+		return Snapshot{}, fmt.Errorf("not a git repository")
+	}
+	commit, err := r.commitAt(t)
+	if err != nil || commit == nil {
+		return Snapshot{When: t}, err
+	}
+	return Snapshot{Hash: commit.Hash.String(), When: commit.Committer.When}, nil
+}
+
+// SnapshotOf resolves a revision such as a hash, branch, tag or HEAD~3
+func (r *Repo) SnapshotOf(rev string) (Snapshot, error) {
+	if r.repo == nil {
+		return Snapshot{}, fmt.Errorf("not a git repository")
+	}
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("unknown revision %q: %w", rev, err)
+	}
+	commit, err := r.repo.CommitObject(*hash)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Hash: commit.Hash.String(), When: commit.Committer.When}, nil
+}
+
+// MergeBase returns the newest commit both snapshots descend from, a
+// snapshot without a hash if they share none
+func (r *Repo) MergeBase(a, b Snapshot) (Snapshot, error) {
+	if r.repo == nil {
+		return Snapshot{}, fmt.Errorf("not a git repository")
+	}
+	ca, err := r.repo.CommitObject(plumbing.NewHash(a.Hash))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	cb, err := r.repo.CommitObject(plumbing.NewHash(b.Hash))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	bases, err := ca.MergeBase(cb)
+	if err != nil || len(bases) == 0 {
+		return Snapshot{}, err
+	}
+	return Snapshot{Hash: bases[0].Hash.String(), When: bases[0].Committer.When}, nil
+}
+
+// CountCommits returns how many commits are reachable from to but not
+// from from, following first parents
+func (r *Repo) CountCommits(from, to Snapshot) (int, error) {
+	if r.repo == nil {
+		return 0, fmt.Errorf("not a git repository")
+	}
+	if to.Hash == "" || to.Hash == from.Hash {
+		return 0, nil
+	}
+	commit, err := r.repo.CommitObject(plumbing.NewHash(to.Hash))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for commit != nil && commit.Hash.String() != from.Hash {
+		n++
+		if commit.NumParents() == 0 {
+			break
+		}
+		if commit, err = commit.Parent(0); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Diff returns the files that differ between two snapshots, sorted by path.
+// A snapshot without a hash stands for the empty repo.
+func (r *Repo) Diff(from, to Snapshot) ([]FileChange, error) {
+	if r.repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	fromTree, err := r.treeOf(from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := r.treeOf(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := changes.Patch()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []FileChange
+	for _, fp := range patch.FilePatches() {
+		src, dst := fp.Files()
+		fc := FileChange{Binary: fp.IsBinary()}
+		switch {
+		case src == nil:
+			fc.Path, fc.Change = dst.Path(), ChangeAdded
+		case dst == nil:
+			fc.Path, fc.Change = src.Path(), ChangeDeleted
+		case src.Path() != dst.Path():
+			fc.Path, fc.OldPath, fc.Change = dst.Path(), src.Path(), ChangeRenamed
+		default:
+			fc.Path, fc.Change = dst.Path(), ChangeModified
+		}
+		for _, chunk := range fp.Chunks() {
+			lines := countLines(chunk.Content())
+			switch chunk.Type() {
+			case diff.Add:
+				fc.Added += lines
+			case diff.Delete:
+				fc.Removed += lines
+			}
+		}
+		files = append(files, fc)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// treeOf returns the tree of a snapshot, nil for the empty repo
+func (r *Repo) treeOf(s Snapshot) (*object.Tree, error) {
+	if s.Hash == "" {
+		return nil, nil
+	}
+	commit, err := r.repo.CommitObject(plumbing.NewHash(s.Hash))
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+func countLines(s string) int {
+	n := strings.Count(s, "\n")
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
 // HasRemote checks if a remote is configured
 func (r *Repo) HasRemote() bool {
 	if r.repo == nil {
@@ -453,6 +1038,18 @@ func (r *Repo) HasRemote() bool {
 	return err == nil && len(remotes) > 0
 }
 
+// SetRemote points the named remote at url, creating it if needed
+func (r *Repo) SetRemote(name, url string) error {
+	if r.repo == nil {
+		return fmt.Errorf("not a git repository")
+	}
+	if err := r.repo.DeleteRemote(name); err != nil && err != git.ErrRemoteNotFound {
+		return err
+	}
+	_, err := r.repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+	return err
+}
+
 // RemoteURL returns the remote URL
 func (r *Repo) RemoteURL() string {
 	if r.repo == nil {
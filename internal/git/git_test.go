@@ -1,9 +1,12 @@
 package git
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
@@ -422,6 +425,106 @@ func TestLog_RealRepo(t *testing.T) {
 	}
 }
 
+func TestPathLog_RealRepo(t *testing.T) {
+	tempDir := t.TempDir()
+	gitRepo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+	worktree, _ := gitRepo.Worktree()
+	commit := func(path, message string) {
+		full := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(full), 0755)
+		os.WriteFile(full, []byte(message), 0644)
+		worktree.Add(path)
+		worktree.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+		})
+	}
+	commit("nvim/init.lua", "nvim: init")
+	commit("zsh/.zshrc", "zsh: rc")
+	commit("nvim/lua/plugins.lua", "nvim: plugins")
+
+	repo := NewRepo(tempDir)
+	commits, err := repo.PathLog(func(path string) bool { return strings.HasPrefix(path, "nvim/") }, 5)
+	if err != nil {
+		t.Fatalf("PathLog failed: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Message != "nvim: plugins" || commits[1].Message != "nvim: init" {
+		t.Errorf("Expected the two nvim commits, newest first, got %+v", commits)
+	}
+	if commits, _ := repo.PathLog(func(string) bool { return true }, 1); len(commits) != 1 {
+		t.Errorf("Expected the count to limit the log, got %+v", commits)
+	}
+}
+
+func TestReadFile_RealRepo(t *testing.T) {
+	tempDir := t.TempDir()
+	gitRepo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+	worktree, _ := gitRepo.Worktree()
+	commit := func(content string) {
+		os.MkdirAll(filepath.Join(tempDir, "zsh"), 0755)
+		os.WriteFile(filepath.Join(tempDir, "zsh", ".zshrc"), []byte(content), 0644)
+		worktree.Add("zsh/.zshrc")
+		worktree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+		})
+	}
+	commit("v1")
+	commit("v2")
+
+	repo := NewRepo(tempDir)
+	for rev, want := range map[string]string{"HEAD": "v2", "HEAD~1": "v1"} {
+		content, err := repo.ReadFile(rev, filepath.Join("zsh", ".zshrc"))
+		if err != nil || string(content) != want {
+			t.Errorf("ReadFile(%s) = %q, %v; want %q", rev, content, err, want)
+		}
+	}
+	if _, err := repo.ReadFile("HEAD", "missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected ErrNotExist for a missing file, got %v", err)
+	}
+	if _, err := repo.ReadFile("no-such-branch", "zsh/.zshrc"); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
+
+func TestAheadBehind_RealRepo(t *testing.T) {
+	tempDir := t.TempDir()
+	gitRepo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+	worktree, _ := gitRepo.Worktree()
+	commit := func(content string) plumbing.Hash {
+		os.WriteFile(filepath.Join(tempDir, ".zshrc"), []byte(content), 0644)
+		worktree.Add(".zshrc")
+		hash, _ := worktree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+		})
+		return hash
+	}
+	base := commit("v1")
+	gitRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), base))
+	commit("v2")
+	commit("v3")
+
+	repo := NewRepo(tempDir)
+	ahead, behind, err := repo.AheadBehind("HEAD", "main")
+	if err != nil || ahead != 2 || behind != 0 {
+		t.Errorf("AheadBehind(HEAD, main) = %d, %d, %v; want 2, 0", ahead, behind, err)
+	}
+	ahead, behind, _ = repo.AheadBehind("main", "HEAD")
+	if ahead != 0 || behind != 2 {
+		t.Errorf("AheadBehind(main, HEAD) = %d, %d; want 0, 2", ahead, behind)
+	}
+	if _, _, err := repo.AheadBehind("no-such-branch", "main"); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
+
 func TestCommit_RealRepo(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -478,6 +581,27 @@ func TestRemoteURL_RealRepo(t *testing.T) {
 	}
 }
 
+func TestSetRemote_RealRepo(t *testing.T) {
+	tempDir := t.TempDir()
+	if _, err := git.PlainInit(tempDir, false); err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+
+	repo := NewRepo(tempDir)
+	if err := repo.SetRemote("origin", "git@example.com:me/dotfiles.git"); err != nil {
+		t.Fatalf("SetRemote failed: %v", err)
+	}
+	if err := repo.SetRemote("origin", "https://example.com/me/dotfiles.git"); err != nil {
+		t.Fatalf("SetRemote should replace an existing remote: %v", err)
+	}
+	if !repo.HasRemote() || repo.RemoteURL() != "https://example.com/me/dotfiles.git" {
+		t.Errorf("Expected the new URL, got %q", repo.RemoteURL())
+	}
+	if err := NewRepo(t.TempDir()).SetRemote("origin", "x"); err == nil {
+		t.Error("SetRemote should fail outside a repo")
+	}
+}
+
 func TestCheckout_RealRepo(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -1620,3 +1744,174 @@ func TestGetStatus_WithStagedAndModified(t *testing.T) {
 		t.Error("Should have staged files")
 	}
 }
+
+func TestIdentity_RealRepo(t *testing.T) {
+	tempDir := t.TempDir()
+	if _, err := git.PlainInit(tempDir, false); err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+
+	repo := NewRepo(tempDir)
+	if id := repo.Identity(); id.Name != "" || id.Email != "" {
+		t.Errorf("Fresh repo should have no local identity, got %+v", id)
+	}
+
+	want := Identity{Name: "Work Me", Email: "me@work.example", SigningKey: "ABC123"}
+	if err := repo.SetIdentity(want); err != nil {
+		t.Fatalf("SetIdentity failed: %v", err)
+	}
+
+	// Reopen to make sure it was persisted
+	got := NewRepo(tempDir).Identity()
+	if got != want {
+		t.Errorf("Identity() = %+v, want %+v", got, want)
+	}
+
+	// Clearing values removes them
+	if err := repo.SetIdentity(Identity{}); err != nil {
+		t.Fatalf("SetIdentity failed: %v", err)
+	}
+	if got := NewRepo(tempDir).Identity(); got != (Identity{}) {
+		t.Errorf("Identity should be cleared, got %+v", got)
+	}
+}
+
+func TestCommit_UsesRepoIdentity(t *testing.T) {
+	tempDir := t.TempDir()
+	gitRepo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+
+	repo := NewRepo(tempDir)
+	if err := repo.SetIdentity(Identity{Name: "Personal", Email: "me@home.example"}); err != nil {
+		t.Fatalf("SetIdentity failed: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte("hello"), 0644)
+	worktree, _ := gitRepo.Worktree()
+	worktree.Add("test.txt")
+
+	if err := repo.Commit("test commit"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	commits, err := repo.Log(1)
+	if err != nil || len(commits) != 1 {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if commits[0].Author != "Personal" {
+		t.Errorf("Commit author = %q, want Personal", commits[0].Author)
+	}
+}
+
+func TestSetIdentity_NotARepo(t *testing.T) {
+	repo := NewRepo(t.TempDir())
+	if err := repo.SetIdentity(Identity{Name: "x"}); err == nil {
+		t.Error("SetIdentity should fail outside a repo")
+	}
+}
+
+func TestClone(t *testing.T) {
+	src := t.TempDir()
+	repo, err := git.PlainInit(src, false)
+	if err != nil {
+		t.Fatalf("PlainInit failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(src, ".zshrc"), []byte("alias gs='git status'\n"), 0644)
+	wt, _ := repo.Worktree()
+	wt.Add(".zshrc")
+	if _, err := wt.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "t", Email: "t@t"}}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "dotfiles")
+	cloned, err := Clone(src, dest)
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	if !cloned.IsRepo() || cloned.RemoteURL() != src {
+		t.Errorf("Expected a repo with origin %s, got %q", src, cloned.RemoteURL())
+	}
+	if _, err := os.Stat(filepath.Join(dest, ".zshrc")); err != nil {
+		t.Errorf("Expected the work tree to be checked out: %v", err)
+	}
+
+	// Cloning again opens the existing repo
+	if _, err := Clone("/nonexistent/repo", dest); err != nil {
+		t.Errorf("Expected the existing clone to be reused, got %v", err)
+	}
+	if _, err := Clone("/nonexistent/repo", filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("Expected an error for a missing remote")
+	}
+}
+
+func TestDiff_RealRepo(t *testing.T) {
+	tempDir := t.TempDir()
+	gitRepo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+	worktree, _ := gitRepo.Worktree()
+	sig := &object.Signature{Name: "Test", Email: "test@test.com"}
+
+	os.WriteFile(filepath.Join(tempDir, "keep.txt"), []byte("one\ntwo\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "gone.txt"), []byte("bye\n"), 0644)
+	worktree.Add(".")
+	worktree.Commit("first", &git.CommitOptions{Author: sig})
+
+	os.WriteFile(filepath.Join(tempDir, "keep.txt"), []byte("one\nthree\nfour\n"), 0644)
+	worktree.Remove("gone.txt")
+	worktree.Add("keep.txt")
+	worktree.Commit("second", &git.CommitOptions{Author: sig})
+
+	repo := NewRepo(tempDir)
+	from, err := repo.SnapshotOf("HEAD~1")
+	if err != nil {
+		t.Fatalf("SnapshotOf failed: %v", err)
+	}
+	to, err := repo.SnapshotOf("HEAD")
+	if err != nil {
+		t.Fatalf("SnapshotOf failed: %v", err)
+	}
+	if n, _ := repo.CountCommits(from, to); n != 1 {
+		t.Errorf("Expected 1 commit between snapshots, got %d", n)
+	}
+
+	changes, err := repo.Diff(from, to)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Path != "gone.txt" || changes[0].Change != ChangeDeleted || changes[0].Removed != 1 {
+		t.Errorf("Unexpected change: %+v", changes[0])
+	}
+	if changes[1].Path != "keep.txt" || changes[1].Change != ChangeModified || changes[1].Added != 2 || changes[1].Removed != 1 {
+		t.Errorf("Unexpected change: %+v", changes[1])
+	}
+
+	// The empty snapshot stands for the repo before its first commit
+	changes, err = repo.Diff(Snapshot{}, from)
+	if err != nil || len(changes) != 2 || changes[0].Change != ChangeAdded {
+		t.Errorf("Expected everything added, got %+v (%v)", changes, err)
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	tests := map[string]bool{
+		"git@github.com:me/dotfiles.git":      true,
+		"https://github.com/me/dotfiles":      true,
+		"ssh://git@host.example/dotfiles.git": true,
+		"~/dotfiles":                          false,
+		"/Users/me/dotfiles":                  false,
+		"/tmp/a@b:c":                          false,
+		"":                                    false,
+	}
+	for in, want := range tests {
+		if got := IsURL(in); got != want {
+			t.Errorf("IsURL(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
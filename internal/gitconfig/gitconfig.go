@@ -0,0 +1,243 @@
+// Package gitconfig keeps machine identities out of a synced ~/.gitconfig.
+// Protected keys (user.name, user.email, signing keys, credential helpers)
+// are replaced by placeholders in the dotfiles copy and filled in from the
+// machine's own values on pull, so a work identity never lands on a
+// personal machine.
+package gitconfig
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultProtected are the keys that are always kept per machine
+var DefaultProtected = []string{"user.name", "user.email", "user.signingkey", "credential.*"}
+
+// IsConfig reports whether path is a global git config (~/.gitconfig or
+// ~/.config/git/config)
+func IsConfig(path string) bool {
+	if filepath.Base(path) == ".gitconfig" {
+		return true
+	}
+	dir := filepath.Dir(path)
+	return filepath.Base(path) == "config" && filepath.Base(dir) == "git" && filepath.Base(filepath.Dir(dir)) == ".config"
+}
+
+// Placeholder stands for a machine value in the dotfiles copy
+func Placeholder(key string) string {
+	return "{{machine:" + key + "}}"
+}
+
+var (
+	sectionRe = regexp.MustCompile(`^\s*\[\s*([A-Za-z0-9.-]+)(?:\s+"((?:[^"\\]|\\.)*)")?\s*\]`)
+	entryRe   = regexp.MustCompile(`^(\s*)([A-Za-z][A-Za-z0-9-]*)(\s*=\s*|\s*$)`)
+)
+
+// line is one line of a git config with the key it sets, if any
+type line struct {
+	text    string
+	header  string // Section header line the entry is under
+	key     string // Canonical key, e.g. user.email or credential.https://host.helper
+	section string // Canonical section of a header or entry, e.g. credential.https://host
+	prefix  string // Text up to the value, e.g. "\temail = "
+	value   string
+}
+
+func parse(src string) []line {
+	var lines []line
+	var header, section string
+	for _, text := range strings.Split(src, "\n") {
+		l := line{text: text}
+		if m := sectionRe.FindStringSubmatch(text); m != nil {
+			name, sub := strings.ToLower(m[1]), m[2]
+			if sub == "" {
+				// Legacy [section.subsection] syntax
+				if i := strings.Index(name, "."); i >= 0 {
+					name, sub = name[:i], name[i+1:]
+				}
+			}
+			section = name
+			if sub != "" {
+				section += "." + sub
+			}
+			header = strings.TrimSpace(text)
+			l.section = section
+		} else if m := entryRe.FindStringSubmatch(text); m != nil && section != "" && !isComment(text) {
+			l.key = section + "." + strings.ToLower(m[2])
+			l.section = section
+			l.header = header
+			l.prefix = m[0]
+			l.value = text[len(m[0]):]
+			if strings.TrimSpace(m[3]) == "" {
+				// A bare key is a boolean true
+				l.prefix = strings.TrimRight(m[0], " \t") + " = "
+				l.value = "true"
+			}
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func isComment(text string) bool {
+	t := strings.TrimSpace(text)
+	return strings.HasPrefix(t, "#") || strings.HasPrefix(t, ";")
+}
+
+// Protected reports whether key is kept per machine. Patterns ending in
+// ".*" match every key under that section.
+func Protected(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, p := range append(DefaultProtected, patterns...) {
+		p = strings.ToLower(p)
+		if strings.HasSuffix(p, ".*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if p == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Templatize replaces the values of protected keys with placeholders, for
+// the dotfiles copy
+func Templatize(src string, patterns []string) string {
+	lines := parse(src)
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = l.text
+		if l.key != "" && Protected(l.key, patterns) {
+			out[i] = l.prefix + Placeholder(l.key)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// value is a protected setting of the local machine
+type value struct {
+	header string // Section header to recreate the setting under
+	line   string // Whole line, used when the setting is added back
+	value  string
+}
+
+// Render builds the local config from incoming dotfiles content. Protected
+// keys take this machine's values, from overrides first and then from the
+// current local config; protected keys with no local value are dropped,
+// whatever incoming says. Local protected settings missing from incoming
+// are added back under their section.
+func Render(incoming, local string, patterns []string, overrides map[string]string) string {
+	// Local values per key, in file order
+	values := make(map[string][]value)
+	var order []string
+	for _, l := range parse(local) {
+		if l.key == "" || !Protected(l.key, patterns) {
+			continue
+		}
+		if _, ok := values[l.key]; !ok {
+			order = append(order, l.key)
+		}
+		values[l.key] = append(values[l.key], value{header: l.header, line: l.text, value: l.value})
+	}
+	for key, v := range overrides {
+		key = strings.ToLower(key)
+		if _, ok := values[key]; !ok {
+			order = append(order, key)
+		}
+		name := key[strings.LastIndex(key, ".")+1:]
+		values[key] = []value{{header: headerFor(key), line: "\t" + name + " = " + v, value: v}}
+	}
+
+	var out []string
+	used := make(map[string]int)
+	emptied := make(map[int]bool) // Headers of sections that lost an entry
+	header := -1
+	for _, l := range parse(incoming) {
+		if l.key == "" && l.section != "" {
+			header = len(out)
+		}
+		if l.key != "" && Protected(l.key, patterns) {
+			n := used[l.key]
+			if n >= len(values[l.key]) {
+				emptied[header] = true
+				continue // No value on this machine
+			}
+			used[l.key]++
+			out = append(out, l.prefix+values[l.key][n].value)
+		} else {
+			out = append(out, l.text)
+		}
+	}
+	trailing := strings.HasSuffix(incoming, "\n") || incoming == ""
+	if trailing {
+		out = out[:len(out)-1]
+	}
+	out = dropEmptySections(out, emptied)
+
+	// Add back local settings that incoming doesn't have
+	sectionEnd := make(map[string]int) // Index in out after a section's last line
+	for i, l := range parse(strings.Join(out, "\n")) {
+		if l.section != "" {
+			sectionEnd[l.section] = i + 1
+		}
+	}
+	for _, key := range order {
+		for _, v := range values[key][used[key]:] {
+			section := key[:strings.LastIndex(key, ".")]
+			if at, ok := sectionEnd[section]; ok {
+				out = append(out[:at], append([]string{v.line}, out[at:]...)...)
+				for s, end := range sectionEnd {
+					if end >= at {
+						sectionEnd[s] = end + 1
+					}
+				}
+				continue
+			}
+			out = append(out, v.header, v.line)
+			sectionEnd[section] = len(out)
+		}
+	}
+	result := strings.Join(out, "\n")
+	if trailing {
+		result += "\n"
+	}
+	return result
+}
+
+// dropEmptySections removes the given section headers when nothing but
+// blank lines and comments is left under them
+func dropEmptySections(lines []string, headers map[int]bool) []string {
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		if headers[i] {
+			end := i + 1
+			for end < len(lines) && sectionRe.FindStringSubmatch(lines[end]) == nil {
+				end++
+			}
+			empty := true
+			for _, l := range lines[i+1 : end] {
+				if strings.TrimSpace(l) != "" && !isComment(l) {
+					empty = false
+				}
+			}
+			if empty {
+				i = end - 1
+				continue
+			}
+		}
+		out = append(out, lines[i])
+	}
+	return out
+}
+
+// headerFor builds a section header for a canonical key
+func headerFor(key string) string {
+	section := key[:strings.LastIndex(key, ".")]
+	name, sub, ok := strings.Cut(section, ".")
+	if !ok {
+		return "[" + name + "]"
+	}
+	return "[" + name + " \"" + sub + "\"]"
+}
@@ -0,0 +1,105 @@
+package gitconfig
+
+import "testing"
+
+const workConfig = `[user]
+	name = Work Me
+	email = me@corp.example
+[core]
+	editor = nvim
+[credential "https://git.corp.example"]
+	helper = corp-helper
+[alias]
+	st = status
+`
+
+func TestTemplatize(t *testing.T) {
+	got := Templatize(workConfig, []string{"alias.st"})
+	want := `[user]
+	name = {{machine:user.name}}
+	email = {{machine:user.email}}
+[core]
+	editor = nvim
+[credential "https://git.corp.example"]
+	helper = {{machine:credential.https://git.corp.example.helper}}
+[alias]
+	st = {{machine:alias.st}}
+`
+	if got != want {
+		t.Errorf("Templatize =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	incoming := Templatize(workConfig, nil)
+
+	tests := []struct {
+		name      string
+		local     string
+		overrides map[string]string
+		want      string
+	}{
+		{
+			name:  "personal machine keeps its identity",
+			local: "[user]\n\tname = Me\n\temail = me@home.example\n[credential]\n\thelper = osxkeychain\n",
+			want: `[user]
+	name = Me
+	email = me@home.example
+[core]
+	editor = nvim
+[alias]
+	st = status
+[credential]
+	helper = osxkeychain
+`,
+		},
+		{
+			name:      "overrides win and unknown values are dropped",
+			local:     "",
+			overrides: map[string]string{"user.email": "me@override.example"},
+			want: `[user]
+	email = me@override.example
+[core]
+	editor = nvim
+[alias]
+	st = status
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(incoming, tt.local, nil, tt.overrides); got != tt.want {
+				t.Errorf("Render =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// Literal identities in an old dotfiles copy never reach the machine
+	got := Render(workConfig, "[user]\n\tname = Me\n", nil, nil)
+	want := "[user]\n\tname = Me\n[core]\n\teditor = nvim\n[alias]\n\tst = status\n"
+	if got != want {
+		t.Errorf("Render of literal values =\n%s\nwant\n%s", got, want)
+	}
+
+	// A local-only setting goes back under its existing section
+	got = Render("[user]\n\tname = {{machine:user.name}}\n[core]\n\tpager = less\n", "[user]\n\tname = Me\n\tsigningkey = ABC\n", nil, nil)
+	want = "[user]\n\tname = Me\n\tsigningkey = ABC\n[core]\n\tpager = less\n"
+	if got != want {
+		t.Errorf("Render with local-only key =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestIsConfig(t *testing.T) {
+	tests := map[string]bool{
+		"/home/me/.gitconfig":         true,
+		"/home/me/.config/git/config": true,
+		"/home/me/repo/.git/config":   false,
+		"/home/me/.config/git/ignore": false,
+	}
+	for path, want := range tests {
+		if got := IsConfig(path); got != want {
+			t.Errorf("IsConfig(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package gitignore honors the .gitignore files config directories carry
+// themselves (nvim, doom emacs), so their build artifacts and plugin
+// caches are neither scanned nor exported.
+package gitignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gi "github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// FileName is the ignore file read in each directory
+const FileName = ".gitignore"
+
+// Matcher matches paths below a config directory against the .gitignore
+// files loaded from it and its subdirectories
+type Matcher struct {
+	root     string
+	patterns []gi.Pattern
+}
+
+// New returns a matcher for the config directory root, with root's own
+// .gitignore loaded
+func New(root string) *Matcher {
+	m := &Matcher{root: filepath.Clean(root)}
+	m.Load(m.root)
+	return m
+}
+
+// ForDir returns a matcher for root with the .gitignore files of every
+// directory from root down to dir loaded, for walks starting below root
+func ForDir(root, dir string) *Matcher {
+	m := New(root)
+	rel := m.components(dir)
+	for i := range rel {
+		m.Load(filepath.Join(m.root, filepath.Join(rel[:i+1]...)))
+	}
+	return m
+}
+
+// Load adds the patterns of dir's .gitignore, which apply below dir. A
+// missing or unreadable file adds none.
+func (m *Matcher) Load(dir string) {
+	if m == nil {
+		return
+	}
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	domain := m.components(dir)
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		line := strings.TrimRight(lines.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m.patterns = append(m.patterns, gi.ParsePattern(line, domain))
+	}
+}
+
+// Ignored reports whether path is ignored; the last matching pattern wins,
+// so later and deeper negations re-include
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+	parts := m.components(path)
+	if len(parts) == 0 {
+		return false
+	}
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		if result := m.patterns[i].Match(parts, isDir); result != gi.NoMatch {
+			return result == gi.Exclude
+		}
+	}
+	return false
+}
+
+// components splits path relative to the root; paths outside it have none
+func (m *Matcher) components(path string) []string {
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
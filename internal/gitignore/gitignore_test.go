@@ -0,0 +1,49 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "nvim")
+	os.MkdirAll(filepath.Join(root, "lua", "cache"), 0755)
+	os.WriteFile(filepath.Join(root, FileName), []byte("# build output\nplugin/\n*.log\n!keep.log\n"), 0644)
+	os.WriteFile(filepath.Join(root, "lua", FileName), []byte("cache\n"), 0644)
+
+	m := New(root)
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"init.lua", false, false},
+		{"plugin", true, true},
+		{"plugin", false, false}, // Directory-only pattern
+		{"debug.log", false, true},
+		{"keep.log", false, false},
+		{"lua/debug.log", false, true},
+		{"lua/cache", true, false}, // lua/.gitignore not loaded yet
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(filepath.Join(root, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%s, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	m.Load(filepath.Join(root, "lua"))
+	if !m.Ignored(filepath.Join(root, "lua", "cache"), true) {
+		t.Error("Expected lua/.gitignore to apply below lua")
+	}
+	if m.Ignored(filepath.Join(root, "cache"), true) {
+		t.Error("Expected lua/.gitignore not to apply outside lua")
+	}
+
+	if !ForDir(root, filepath.Join(root, "lua")).Ignored(filepath.Join(root, "lua", "cache"), true) {
+		t.Error("Expected ForDir to load the .gitignore files down to the walked dir")
+	}
+	if (*Matcher)(nil).Ignored(root, true) {
+		t.Error("Expected a nil matcher to ignore nothing")
+	}
+}
@@ -0,0 +1,200 @@
+// Package health runs post-restore probes that verify a restored config still loads.
+package health
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the maximum time a single probe may run
+const DefaultTimeout = 5 * time.Second
+
+// Probe is a command that exits 0 when an app's config is healthy
+type Probe struct {
+	AppID   string   // App the probe belongs to
+	Name    string   // Short description shown in the summary
+	Command []string // Command and arguments to run
+}
+
+// Status represents the outcome of a probe
+type Status int
+
+const (
+	StatusPassed Status = iota
+	StatusFailed
+	StatusSkipped // Probe binary not installed
+)
+
+// String returns a string representation of the status
+func (s Status) String() string {
+	switch s {
+	case StatusPassed:
+		return "passed"
+	case StatusFailed:
+		return "failed"
+	case StatusSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+// Result holds the outcome of a single probe
+type Result struct {
+	Probe    Probe
+	Status   Status
+	Output   string // Combined output (trimmed), useful when failed
+	Error    error
+	Duration time.Duration
+}
+
+// defaultProbes are the built-in probes for key apps
+var defaultProbes = []Probe{
+	{AppID: "zsh", Name: "zsh -ic exit", Command: []string{"zsh", "-ic", "exit"}},
+	{AppID: "bash", Name: "bash -ic exit", Command: []string{"bash", "-ic", "exit"}},
+	{AppID: "fish", Name: "fish -c exit", Command: []string{"fish", "-c", "exit"}},
+	{AppID: "tmux", Name: "tmux start-server", Command: []string{"tmux", "start-server"}},
+	{AppID: "git", Name: "git config --get user.email", Command: []string{"git", "config", "--get", "user.email"}},
+	{AppID: "nvim", Name: "nvim --headless +qa", Command: []string{"nvim", "--headless", "+qa"}},
+}
+
+// DefaultProbes returns a copy of the built-in probes
+func DefaultProbes() []Probe {
+	probes := make([]Probe, len(defaultProbes))
+	copy(probes, defaultProbes)
+	return probes
+}
+
+// Checker runs probes for restored apps
+type Checker struct {
+	probes  []Probe
+	timeout time.Duration
+}
+
+// NewChecker creates a Checker with the built-in probes
+func NewChecker() *Checker {
+	return &Checker{
+		probes:  DefaultProbes(),
+		timeout: DefaultTimeout,
+	}
+}
+
+// WithProbes replaces the probe set
+func (c *Checker) WithProbes(probes []Probe) *Checker {
+	c.probes = probes
+	return c
+}
+
+// WithTimeout sets the per-probe timeout
+func (c *Checker) WithTimeout(timeout time.Duration) *Checker {
+	c.timeout = timeout
+	return c
+}
+
+// ProbesFor returns the probes registered for an app
+func (c *Checker) ProbesFor(appID string) []Probe {
+	var probes []Probe
+	for _, p := range c.probes {
+		if p.AppID == appID {
+			probes = append(probes, p)
+		}
+	}
+	return probes
+}
+
+// Run runs all probes registered for the given app IDs
+func (c *Checker) Run(ctx context.Context, appIDs []string) []Result {
+	var results []Result
+	seen := make(map[string]bool)
+
+	for _, appID := range appIDs {
+		if seen[appID] {
+			continue
+		}
+		seen[appID] = true
+
+		for _, probe := range c.ProbesFor(appID) {
+			results = append(results, c.runProbe(ctx, probe))
+		}
+	}
+
+	return results
+}
+
+// runProbe runs a single probe with the configured timeout
+func (c *Checker) runProbe(ctx context.Context, probe Probe) Result {
+	result := Result{Probe: probe}
+
+	if len(probe.Command) == 0 {
+		result.Status = StatusSkipped
+		return result
+	}
+
+	if _, err := exec.LookPath(probe.Command[0]); err != nil {
+		result.Status = StatusSkipped
+		return result
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	cmd := exec.CommandContext(probeCtx, probe.Command[0], probe.Command[1:]...)
+	output, err := cmd.CombinedOutput()
+	result.Duration = time.Since(start)
+	result.Output = strings.TrimSpace(string(output))
+
+	if probeCtx.Err() == context.DeadlineExceeded {
+		result.Status = StatusFailed
+		result.Error = fmt.Errorf("timed out after %v", c.timeout)
+		return result
+	}
+
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err
+		return result
+	}
+
+	result.Status = StatusPassed
+	return result
+}
+
+// Summary returns a short summary like "Health: 3/4 passed (zsh failed)"
+func Summary(results []Result) string {
+	passed, failed := 0, 0
+	var failedNames []string
+
+	for _, r := range results {
+		switch r.Status {
+		case StatusPassed:
+			passed++
+		case StatusFailed:
+			failed++
+			failedNames = append(failedNames, r.Probe.AppID)
+		}
+	}
+
+	total := passed + failed
+	if total == 0 {
+		return ""
+	}
+
+	if failed == 0 {
+		return fmt.Sprintf("Health: %d/%d passed", passed, total)
+	}
+	return fmt.Sprintf("Health: %d/%d passed (%s failed)", passed, total, strings.Join(failedNames, ", "))
+}
+
+// HasFailures returns true if any probe failed
+func HasFailures(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunPassAndFail(t *testing.T) {
+	checker := NewChecker().WithProbes([]Probe{
+		{AppID: "ok", Name: "true", Command: []string{"true"}},
+		{AppID: "broken", Name: "false", Command: []string{"false"}},
+	})
+
+	results := checker.Run(context.Background(), []string{"ok", "broken"})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Status != StatusPassed {
+		t.Errorf("Expected ok probe to pass, got %s", results[0].Status)
+	}
+	if results[1].Status != StatusFailed {
+		t.Errorf("Expected broken probe to fail, got %s", results[1].Status)
+	}
+	if !HasFailures(results) {
+		t.Error("HasFailures should be true")
+	}
+}
+
+func TestRunSkipsMissingBinary(t *testing.T) {
+	checker := NewChecker().WithProbes([]Probe{
+		{AppID: "ghost", Name: "missing", Command: []string{"dotsync-no-such-binary-xyz"}},
+	})
+
+	results := checker.Run(context.Background(), []string{"ghost"})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != StatusSkipped {
+		t.Errorf("Expected skipped, got %s", results[0].Status)
+	}
+	if Summary(results) != "" {
+		t.Errorf("Summary should be empty when all probes skipped, got %q", Summary(results))
+	}
+}
+
+func TestRunOnlySelectedAppsOnce(t *testing.T) {
+	checker := NewChecker().WithProbes([]Probe{
+		{AppID: "a", Name: "true", Command: []string{"true"}},
+		{AppID: "b", Name: "true", Command: []string{"true"}},
+	})
+
+	results := checker.Run(context.Background(), []string{"a", "a", "c"})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].Probe.AppID != "a" {
+		t.Errorf("Expected probe for app a, got %s", results[0].Probe.AppID)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	checker := NewChecker().
+		WithProbes([]Probe{{AppID: "slow", Name: "sleep", Command: []string{"sleep", "5"}}}).
+		WithTimeout(100 * time.Millisecond)
+
+	results := checker.Run(context.Background(), []string{"slow"})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != StatusFailed {
+		t.Errorf("Expected timeout to fail, got %s", results[0].Status)
+	}
+	if results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got %v", results[0].Error)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	results := []Result{
+		{Probe: Probe{AppID: "zsh"}, Status: StatusPassed},
+		{Probe: Probe{AppID: "tmux"}, Status: StatusFailed},
+		{Probe: Probe{AppID: "fish"}, Status: StatusSkipped},
+	}
+
+	got := Summary(results)
+	want := "Health: 1/2 passed (tmux failed)"
+	if got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultProbesCoverKeyApps(t *testing.T) {
+	checker := NewChecker()
+	for _, appID := range []string{"zsh", "tmux", "git"} {
+		if len(checker.ProbesFor(appID)) == 0 {
+			t.Errorf("Expected default probe for %s", appID)
+		}
+	}
+}
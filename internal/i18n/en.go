@@ -0,0 +1,535 @@
+package i18n
+
+// en is the English catalog; every key must exist here
+var en = map[string]string{
+	"key.scroll":         "scroll",
+	"key.page":           "page",
+	"key.close":          "close",
+	"key.navigate":       "navigate",
+	"key.confirm":        "confirm",
+	"key.cancel":         "cancel",
+	"key.clear":          "clear",
+	"key.select":         "select",
+	"key.backup":         "backup",
+	"key.push":           "push",
+	"key.pull":           "pull",
+	"key.help":           "help",
+	"key.mode":           "mode",
+	"key.to_files":       "→files",
+	"key.to_apps":        "→apps",
+	"key.all":            "all",
+	"key.modified":       "mod",
+	"key.outdated":       "outdated",
+	"key.add_custom":     "add custom",
+	"key.search":         "search",
+	"key.filter":         "filter",
+	"key.diff":           "diff",
+	"key.edit":           "edit",
+	"key.cherry_pick":    "push 1-hunks only",
+	"key.preview":        "preview",
+	"key.quit":           "quit",
+	"key.next_save":      "next/save",
+	"key.top_bottom":     "top/bottom",
+	"key.back":           "back",
+	"key.next_prev_hunk": "next/prev hunk",
+	"key.keep_local":     "keep local",
+	"key.use_dotfiles":   "use dotfiles",
+	"key.merge":          "merge",
+	"key.highlight":      "highlight",
+	"key.split":          "split",
+	"key.structural":     "structural",
+	"key.diff_base":      "compare with",
+	"key.save_merge":     "save merge",
+	"key.checkout":       "checkout",
+	"key.back_to_status": "back to status",
+	"key.add_all":        "add all",
+	"key.commit":         "commit",
+	"key.push_ahead":     "push ↑%d",
+	"key.fetch":          "fetch",
+	"key.stash":          "stash",
+	"key.branches":       "branches",
+	"key.lazygit":        "lazygit",
+	"key.merge_main":     "merge into %s",
+	"key.refresh":        "refresh",
+
+	"setup.welcome.title":     "🔄 Welcome to Dotsync!",
+	"setup.welcome.intro":     "Dotsync helps you sync your dotfiles between machines.",
+	"setup.welcome.features":  "Features:",
+	"setup.feature.detect":    "Auto-detect installed apps and their configs",
+	"setup.feature.selective": "Selective sync - choose which files to sync",
+	"setup.feature.apps":      "Support for 960+ apps out of the box",
+	"setup.feature.git":       "Built-in git operations and branch switching",
+	"setup.feature.discover":  "Discovers unknown apps in ~/.config",
+	"setup.welcome.help":      "Press ENTER to continue • q to quit",
+	"setup.path.title":        "📁 Choose Dotfiles Location",
+	"setup.path.question":     "Where do you want to store your dotfiles?",
+	"setup.path.exists":       " (exists)",
+	"setup.path.custom":       "Or enter custom path:",
+	"setup.path.help":         "1-3 quick select • ENTER confirm • ESC back",
+	"setup.confirm.title":     "✓ Confirm Setup",
+	"setup.confirm.location":  "Dotfiles will be stored at:",
+	"setup.confirm.exists":    "✓ Directory exists",
+	"setup.confirm.create":    "  Directory will be created",
+	"setup.confirm.help":      "y/ENTER confirm • n/ESC go back • q quit",
+	"setup.path.clone":        "…or paste a git URL to clone an existing dotfiles repo",
+	"setup.confirm.clone":     "Dotfiles repo to clone:",
+	"setup.confirm.into":      "into:",
+	"setup.confirm.restore":   "  Its apps will be matched to this machine for a first pull",
+
+	"onboard.title":             "📦 First Backup",
+	"onboard.desc":              "Your dotfiles repo is empty. Pick the apps to back up first - recommended ones are already checked.",
+	"onboard.recommended":       "recommended",
+	"onboard.none":              "No app configs found to back up",
+	"onboard.help":              "SPACE toggle • ENTER push & commit • ESC skip",
+	"onboard.remote.title":      "🌐 Add a Remote",
+	"onboard.remote.desc":       "Backed up and committed. Paste a git remote URL to push there too, or leave it empty to stay local.",
+	"onboard.remote.help":       "ENTER add & push (empty: finish) • ESC finish",
+	"onboard.restore.title":     "📥 Restore Dotfiles",
+	"onboard.restore.desc":      "Cloned a dotsync repo. Apps installed here are checked - pick what to pull onto this machine.",
+	"onboard.restore.installed": "installed here",
+	"onboard.restore.none":      "No app in the cloned repo",
+	"onboard.restore.help":      "SPACE toggle • ENTER review pull • ESC skip",
+
+	"mappath.title":  "📍 Repo Path",
+	"mappath.local":  "Local file:",
+	"mappath.repo":   "Stored in the dotfiles repo at:",
+	"mappath.mapped": "Explicitly mapped - clear the path to follow the repo layout again",
+	"mappath.help":   "ENTER save • ctrl+r reset to layout • ESC cancel",
+
+	"confirm.push.title":         "📤 Push to Dotfiles",
+	"confirm.push.desc":          "This will copy your local configs to your dotfiles repository.",
+	"confirm.push.files":         "Files to push:",
+	"confirm.pull.title":         "⚠️  Pull from Dotfiles",
+	"confirm.pull.desc":          "This will replace your local configs with versions from dotfiles.",
+	"confirm.pull.files":         "Files to pull:",
+	"confirm.more_files":         "  ... and %d more files",
+	"confirm.more":               "  ... and %d more",
+	"confirm.total":              "Total: %d files, %s",
+	"confirm.deleted_local":      "Deleted locally (still in dotfiles):",
+	"confirm.deleted_remote":     "Deleted on another machine (still here):",
+	"confirm.deleted_by":         " (by %s)",
+	"confirm.choose":             "Choose action:",
+	"confirm.push":               "Push",
+	"confirm.push.option":        "Copy local configs to dotfiles repository",
+	"confirm.cancel":             "Cancel",
+	"confirm.cancel.option":      "Go back without changes",
+	"confirm.push_delete":        "Push + propagate deletions",
+	"confirm.push_delete.option": "Also remove %d deleted configs from dotfiles (tombstoned for other machines)",
+	"confirm.pull":               "Pull",
+	"confirm.pull.option":        "Backup current configs and pull from dotfiles",
+	"confirm.pull_delete":        "Pull + propagate deletions",
+	"confirm.pull_delete.option": "Also back up and remove %d configs deleted on other machines",
+	"confirm.help":               "↑↓ navigate • ENTER select • ESC cancel",
+
+	"diffstatus.new (will create)": "new (will create)",
+	"diffstatus.different":         "different",
+	"diffstatus.will overwrite":    "will overwrite",
+	"diffstatus.not in dotfiles":   "not in dotfiles",
+	"diffstatus.missing locally":   "missing locally",
+	"diffstatus.same":              "same",
+
+	"watch.changed": "↻ %d files changed outside dotsync, e.g. %s",
+
+	"upstream.banner":     "⬇ %d apps updated upstream (%s) — U: review & pull",
+	"upstream.title":      "⬇ Upstream Changes",
+	"upstream.help":       "↑/↓: scroll  •  l: pull into dotfiles  •  r: fetch again  •  Esc: back",
+	"upstream.none":       "Dotfiles are up to date with upstream",
+	"upstream.fetching":   "Fetching upstream...",
+	"upstream.rescanning": "Pulled from upstream, rescanning...",
+	"upstream.pulled":     "✓ Pulled from upstream: %s • O selects outdated files, l applies them here",
+
+	"scan.title":        "Scanning for apps...",
+	"scan.looking":      "Looking for configurations in:",
+	"scan.hashing":      "Hashing %s (%s of %s)...",
+	"scan.large_files":  "⚠ %d tracked files over %s, e.g. %s",
+	"scan.home":         "Home directory dotfiles",
+	"scan.tip.search":   "💡 Use / to search apps by name",
+	"scan.tip.category": "💡 Press 1-9 to filter by category",
+	"scan.tip.select":   "💡 Press M to select modified, O for outdated",
+	"scan.tip.diff":     "💡 Press d to view file differences",
+	"scan.tip.git":      "💡 Press g to access git operations",
+	"scan.tip.rescan":   "💡 Press s to rescan at any time",
+
+	"sync.pushing":  "%s Pushing files...",
+	"sync.pulling":  "%s Pulling files...",
+	"sync.progress": "  %d / %d files",
+
+	"header.override":  " (override)",
+	"header.read_only": " [read-only]",
+
+	"stats.apps":      "Apps: %d/%d",
+	"stats.files":     "Files: %d",
+	"stats.modified":  "Modified: %d",
+	"stats.conflicts": "⚡Conflicts: %d",
+
+	"helpbar.scanning":   "⏳ Scanning... ",
+	"helpbar.syncing":    "🔄 Syncing... ",
+	"helpbar.add_custom": "➕ Add custom source  ",
+	"helpbar.visual":     "-- VISUAL -- ",
+
+	"help.title":            "⌨️  Keyboard Shortcuts Guide",
+	"help.section.quick":    "  ─── ⚡ Quick Actions ───",
+	"help.quick.Q":          "Quick Backup: auto-backup files to dotfiles",
+	"help.quick.P":          "Push + Commit: push selected + git commit",
+	"help.quick.p":          "Push: copy local → dotfiles (manual)",
+	"help.quick.l":          "Pull: copy dotfiles → local",
+	"help.quick.c":          "Check conflicts",
+	"help.quick.C":          "Conflict queue: resolve files pull skipped",
+	"help.quick.A":          "Review queue: step through selected changes, then apply",
+	"help.quick.U":          "Upstream changes: review what other machines pushed, then pull",
+	"help.quick.W":          "Weekly digest: recent activity overview",
+	"help.quick.I":          "App details: paths, size, sync times, history",
+	"help.quick.H":          "Audit log: history of sync operations",
+	"help.quick.o":          "Dashboard: sync health overview",
+	"help.quick.T":          "Suggestions: recommended actions, Enter to act",
+	"help.quick.L":          "Map the current file to an explicit path in the dotfiles repo",
+	"help.quick.X":          "Archive an uninstalled app's dotfiles into _archived/",
+	"help.quick.w":          "Write a JSON/Markdown report of the last push, pull or quick sync",
+	"key.save":              "save",
+	"editor.line":           "line",
+	"editor.modified":       "● modified",
+	"editor.markers":        "%d conflict markers",
+	"help.quick.i":          "Edit in dotsync (from preview or merge)",
+	"help.quick.e":          "Compare local vs dotfiles in editor (VS Code/Cursor/Zed)",
+	"help.quick.E":          "Edit the local file in editor",
+	"help.section.modes":    "  ─── 💾 Backup vs Sync ───",
+	"help.mode.backup":      "Per-machine copy → Q pushes automatically",
+	"help.mode.sync":        "Same on every machine → p/l manually",
+	"help.mode.t":           "Toggle sync for the selected app/file",
+	"help.mode.R":           "Restore configs from another machine",
+	"help.section.nav":      "  ─── 🧭 Navigation ───",
+	"help.nav.search":       "Fuzzy search apps and file paths",
+	"help.nav.category":     "Filter by category",
+	"help.nav.clear":        "Clear category filter",
+	"help.nav.stale":        "Sort apps and files by last push/pull, stalest first",
+	"help.nav.group":        "Group apps by category / flat list",
+	"help.nav.split":        "Narrow / widen the apps panel, even split (or drag the divider)",
+	"help.nav.zen":          "Zen mode: only the focused panel",
+	"help.nav.fold":         "Collapse/expand category (on a header)",
+	"help.nav.move":         "Move cursor up/down",
+	"help.nav.panel":        "Switch Apps ↔ Files panel",
+	"help.nav.page":         "Scroll page",
+	"help.nav.jump":         "Jump to first/last",
+	"help.section.select":   "  ─── ✅ Selection ───",
+	"help.select.toggle":    "Toggle selection",
+	"help.select.all":       "Select all",
+	"help.select.none":      "Deselect all",
+	"help.select.visual":    "Visual mode: mark a range, V/Space to select it",
+	"help.select.shown":     "Toggle all shown (respects search/category filter)",
+	"help.select.modified":  "Select all modified (need push)",
+	"help.select.outdated":  "Select all outdated (need pull)",
+	"help.select.status":    "Status filter: conflicts only → changed only → all",
+	"help.select.custom":    "Add custom folder/app source",
+	"help.select.undo":      "Undo last selection",
+	"help.select.exclude":   "Exclude/re-include subtree (Files panel)",
+	"help.select.include":   "Include-only subtree (Files panel)",
+	"help.section.file":     "  ─── 📄 File Actions ───",
+	"help.file.preview":     "Preview file content",
+	"help.file.diffstat":    "Changes since last push: lines added/removed in the selected apps",
+	"help.file.diff":        "View diff (local vs dotfiles)",
+	"help.file.diffbase":    "In the diff: compare with HEAD, a branch (b) or any commit (B)",
+	"help.file.merge":       "Merge conflicts",
+	"help.file.rescan":      "Rescan all apps",
+	"help.file.brewfile":    "Export Brewfile",
+	"help.file.refresh":     "Refresh current view",
+	"help.section.git":      "  ─── 🔀 Git (press 'g') ───",
+	"help.git.autoinit":     "Creates a git repo automatically if there is none",
+	"help.git.open":         "Open git panel (auto git init)",
+	"help.git.stage":        "Stage all",
+	"help.git.commit":       "Commit",
+	"help.git.push":         "Push",
+	"help.git.fetch":        "Fetch",
+	"help.git.pull":         "Pull",
+	"help.git.branch":       "Switch branch",
+	"help.git.merge_main":   "Branch per machine: merge this machine's branch into main",
+	"help.git.lazygit":      "Open lazygit (if installed)",
+	"help.section.general":  "  ─── ⚙️ General ───",
+	"help.general.settings": "Settings (dotfiles path, backup path)",
+	"help.general.help":     "Toggle this help",
+	"help.general.back":     "Go back / Cancel",
+	"help.general.quit":     "Quit",
+	"help.icons.title":      "📊 Status Icons",
+	"help.icons.synced":     "Synced - Files are identical",
+	"help.icons.modified":   "Modified - Local has changes (push)",
+	"help.icons.outdated":   "Outdated - Dotfiles has updates (pull)",
+	"help.icons.conflict":   "Conflict - Both sides changed",
+	"help.icons.backup":     "Backup only - Per-machine storage",
+	"help.icons.sync":       "Backup + Sync - Same on all machines",
+	"help.how.title":        "💡 How it works",
+	"help.how.backup1":      "Each machine has its own folder: dotfiles/app/{machine}/",
+	"help.how.backup2":      "Press Q → pushes to this machine's folder automatically",
+	"help.how.backup3":      "Use R to restore configs from another machine",
+	"help.how.sync1":        "A single copy: dotfiles/app/file",
+	"help.how.sync2":        "Press p to push, l to pull (manual)",
+	"help.how.sync3":        "Identical on every machine",
+	"help.close":            "  Press any key to close",
+
+	"settings.title":            "⚙️  Settings",
+	"settings.dotfiles_path":    "Dotfiles Path",
+	"settings.backup_path":      "Backup Path",
+	"settings.git_name":         "Git Name",
+	"settings.git_email":        "Git Email",
+	"settings.signing_key":      "Signing Key",
+	"settings.remote":           "Remote",
+	"settings.remote_target":    "Remote Target",
+	"settings.health_checks":    "Health Checks",
+	"settings.bootstrap":        "Bootstrap Scripts",
+	"settings.machine_branches": "Machine Branch",
+	"settings.read_only":        "Read-only Mode",
+	"settings.auto_push":        "Auto Push (Q)",
+	"settings.icons":            "Status Icons",
+	"settings.theme":            "Theme",
+	"settings.language":         "Language",
+	"settings.nested_repos":     "Nested Repos",
+	"settings.symlinks":         "Symlinks",
+	"settings.xattrs":           "Xattrs",
+	"settings.conflicts":        "Conflicts",
+	"settings.definitions":      "Definitions",
+	"settings.orphans":          "Orphaned Dotfiles",
+	"settings.orphans_scan":     "scan (Enter)",
+	"settings.dashboard":        "Dashboard",
+	"settings.notifications":    "Notifications",
+	"settings.diff_tool":        "Diff tool",
+	"settings.merge_tool":       "Merge tool",
+	"settings.builtin":          "built-in",
+	"settings.on":               "on",
+	"settings.off":              "off",
+	"settings.help.editing":     "Enter: save  •  Esc: cancel",
+	"settings.help":             "↑/↓: navigate  •  Enter: edit/toggle  •  Esc/q: back",
+	"settings.config_file":      "Config file: %s",
+	"settings.language_set":     "✓ Language: %s",
+
+	"conflicts.title": "⚠️  Conflict Queue (%d)",
+	"conflicts.desc":  "Changed locally and in dotfiles since last sync. Pull left these untouched.",
+	"conflicts.none":  "No conflicts 🎉",
+	"conflicts.help":  "↑/↓: navigate  •  Enter/d: diff (1 keep local, 2 use dotfiles, m merge)  •  Esc: back",
+
+	"review.title":    "🔎 Review Queue",
+	"review.progress": "Review %d/%d: %s/%s",
+	"review.keys":     "1 push  •  2 pull  •  m merge  •  x skip  •  b back  •  Esc cancel",
+	"review.desc":     "Decisions so far: %s. Enter applies them in one batch.",
+	"review.help":     "Enter: apply  •  b: back to last file  •  Esc: cancel",
+
+	"suggestions.title":   "💡 Suggestions",
+	"suggestions.desc":    "Things worth doing, most pressing first. Enter acts on the highlighted one.",
+	"suggestions.loading": "Looking for suggestions...",
+	"suggestions.none":    "Nothing to suggest - all good 🎉",
+	"suggestions.help":    "%d/%d  •  Enter: act  •  r: refresh  •  Esc: back",
+
+	// Sync result
+	"syncresult.title":           "Sync result: %s",
+	"syncresult.error":           "✗ %v",
+	"syncresult.done":            "✓ %d/%d files synced",
+	"syncresult.failed":          "✗ %d failed",
+	"syncresult.help":            "g: git panel  •  Enter/Esc: back",
+	"syncresult.help_failed":     "↑↓: failed files  •  Enter: details  •  r: retry file  •  S: retry with sudo  •  R: retry all failed  •  s: skip file from now on  •  g: git panel  •  y: copy errors  •  Esc: back",
+	"syncresult.detail_help":     "%d/%d  •  ↑↓: other failures  •  r: retry  •  S: sudo  •  s: skip from now on  •  y: copy errors  •  Esc: back to list",
+	"syncresult.app":             "App",
+	"syncresult.local":           "Local",
+	"syncresult.dotfiles":        "Dotfiles",
+	"syncresult.kind.permission": "Permission denied: check the owner and mode of the file and its directory (ls -l), then retry",
+	"syncresult.kind.missing":    "Path missing: the file or its directory was moved or deleted since the scan; rescan, or skip it",
+	"syncresult.kind.no-space":   "Disk full: free space on the destination, then retry",
+	"syncresult.sudo_hint":       "S: copy it with sudo (asks for your password)  •  s: skip it from now on",
+
+	"quicksync.title":     "⚡ Quick Backup Results (%d files)",
+	"quicksync.committed": "Committed: %s",
+	"quicksync.pushed":    "✓ Pushed to remote",
+	"quicksync.more":      "  ... %d more",
+	"quicksync.help":      "↑/↓: navigate  •  Enter/d: diff  •  m: merge  •  Esc: back",
+
+	"definitions.title":    "🧹 Definition Anomalies",
+	"definitions.desc":     "Duplicate IDs are merged automatically. Shared paths scan the same files twice - disable or alias extras.",
+	"definitions.none":     "No anomalies detected",
+	"definitions.active":   "active",
+	"definitions.disabled": "disabled",
+	"definitions.alias":    "alias of %s",
+	"definitions.help":     "%d/%d  •  d: disable/enable  •  a: alias to first  •  Esc: back (rescans if changed)",
+
+	"orphans.title":      "🗑 Orphaned Dotfiles",
+	"orphans.desc":       "App directories in dotfiles that no app definition maps, or whose app isn't installed and has nothing tracked.",
+	"orphans.none":       "No orphaned directories found",
+	"orphans.help":       "%d/%d  •  a: archive to backup dir  •  D: delete  •  Esc: back",
+	"orphans.help_empty": "Esc: back",
+
+	"custom.title":       "➕ Add Custom Source",
+	"custom.folder":      "[Folder]",
+	"custom.app":         "[App]",
+	"custom.mode":        "Mode: ",
+	"custom.switch":      "(Tab to switch)",
+	"custom.name":        "Name: ",
+	"custom.paths":       "Path(s): ",
+	"custom.notes":       "Notes:",
+	"custom.note.folder": "• Folder mode expects exactly 1 path",
+	"custom.note.app":    "• App mode supports 1 or more comma-separated paths",
+	"custom.help":        "Enter: next/save  •  Tab: switch mode  •  Esc: cancel",
+
+	"commit.title":       "📝 Commit Changes",
+	"commit.files":       "Files to commit: %d",
+	"commit.message":     "Commit message:",
+	"commit.placeholder": "Enter commit message...",
+	"commit.help":        "Ctrl+S to commit • ESC to cancel",
+
+	"notify.backup_done":      "dotsync: quick backup done",
+	"notify.backup_failed":    "dotsync: quick backup failed",
+	"notify.push_failed":      "dotsync: backed up but push failed",
+	"notify.conflicts":        "dotsync: conflicts detected",
+	"notify.conflicts_pull":   "%d files changed on both sides were skipped by pull",
+	"notify.conflicts_backup": "%d sync files changed on both sides need a merge",
+
+	"digest.title":         "📅 Weekly Digest",
+	"digest.loading":       " Reading history...",
+	"digest.since":         "Since %s",
+	"digest.no_repo":       "Dotfiles directory is not a git repository - only local sync activity is shown",
+	"digest.commits":       "Commits",
+	"digest.files":         "Files changed",
+	"digest.conflicts":     "Conflicts resolved",
+	"digest.machines":      "Machines active",
+	"digest.none":          "none",
+	"digest.authors":       "Authors",
+	"digest.size":          "Repo size",
+	"digest.synced":        "Synced here",
+	"digest.synced_files":  "%d files",
+	"digest.apps":          "Most active apps",
+	"digest.more":          "  … %d more",
+	"digest.changes":       "%d changes",
+	"digest.help":          "r: refresh  •  c: changelog  •  Esc: back",
+	"changelog.title":      "📝 Config Changelog",
+	"changelog.loading":    " Comparing snapshots...",
+	"changelog.empty":      "No changes in this window",
+	"changelog.summary":    "%d commits, %d files changed in %d apps (+%d -%d)",
+	"changelog.help":       "↑/↓: scroll  •  [/]: previous/next week  •  e: export markdown  •  r: refresh  •  Esc: back",
+	"dashboard.title":      "🏠 Dashboard",
+	"dashboard.loading":    " Checking sync health...",
+	"dashboard.healthy":    "✓ Everything is in sync",
+	"dashboard.attention":  "Needs attention",
+	"dashboard.tracked":    "Tracked",
+	"dashboard.tracked_n":  "%d apps • %d files",
+	"dashboard.modified":   "To push",
+	"dashboard.outdated":   "To pull",
+	"dashboard.conflicts":  "Conflicts",
+	"dashboard.last_push":  "Last push",
+	"dashboard.last_pull":  "Last pull",
+	"dashboard.last_qb":    "Last backup",
+	"dashboard.git":        "Git",
+	"dashboard.git_status": "%s • %d ahead • %d behind",
+	"dashboard.no_repo":    "not a git repository",
+	"dashboard.machines":   "Machine backups",
+	"dashboard.no_backups": "No machine backups yet - press Q in the apps view",
+	"dashboard.fresh":      "fresh",
+	"dashboard.stale":      "stale",
+	"dashboard.old":        "old",
+	"dashboard.never":      "never",
+	"dashboard.actions":    "Quick actions",
+	"dashboard.help":       "p: push modified  •  l: pull outdated  •  C: conflicts  •  g: git  •  s: rescan  •  r: refresh  •  Enter/Esc: apps",
+
+	"audit.title":       "📜 Audit Log",
+	"audit.action":      "action: %s",
+	"audit.all":         "all",
+	"audit.failed_only": "  •  failures only",
+	"audit.none":        "No logged operations",
+	"audit.help.empty":  "f: action filter  •  x: failures only  •  l: log file  •  Esc: back",
+	"audit.help":        "%d/%d  •  f: action filter  •  x: failures only  •  l: log file  •  r: reload  •  Esc: back",
+
+	// Resuming a sync interrupted by a crash
+	"resume.title":     "⚠ Interrupted Sync",
+	"resume.body":      "dotsync stopped in the middle of a %s started %s (pid %d). Some files of these apps may not have been synced:",
+	"resume.report":    "Crash report: %s",
+	"resume.help":      "Enter/y: %s them again (confirm first)  •  n/Esc: dismiss",
+	"resume.no_apps":   "None of the interrupted apps were found by the scan",
+	"resume.dismissed": "Interrupted sync dismissed",
+
+	// Log file tail
+	"logs.title":      "🪵 Log File",
+	"logs.none":       "Nothing logged yet",
+	"logs.error":      "Error reading log file: %v",
+	"logs.help.empty": "r: reload  •  Esc: audit log  •  --log-level debug logs more",
+	"logs.help":       "%d/%d  •  ↑↓: scroll  •  r: reload  •  Esc: audit log  •  q: close",
+
+	"appinfo.title":       "%s %s",
+	"appinfo.no_app":      "No app under the cursor",
+	"appinfo.id":          "ID",
+	"appinfo.category":    "Category",
+	"appinfo.paths":       "Config paths",
+	"appinfo.files":       "Files",
+	"appinfo.files_value": "%d files, %s",
+	"appinfo.mode":        "Sync mode",
+	"appinfo.mode.backup": "backup: stored per machine",
+	"appinfo.mode.sync":   "sync: shared by all machines",
+	"appinfo.last_push":   "Last push",
+	"appinfo.last_pull":   "Last pull",
+	"appinfo.history":     "Recent commits",
+	"appinfo.no_history":  "No commits touch this app's dotfiles",
+	"appinfo.help":        "Esc/I: close",
+
+	"diffstat.title":         "📊 Changes since last push",
+	"diffstat.computing":     "Counting changed lines...",
+	"diffstat.summary":       "%d files changed in %d apps,",
+	"diffstat.none":          "✓ The selected files match the dotfiles repo",
+	"diffstat.local_only":    "(not in dotfiles)",
+	"diffstat.dotfiles_only": "(deleted locally)",
+	"diffstat.help":          "%d/%d  •  Enter/d: diff  •  Esc: back",
+	"diffstat.help.empty":    "Esc: back",
+	"diffstat.back":          "Esc: back to the changes",
+
+	"apps.none":        "No apps found",
+	"apps.uninstalled": "uninstalled",
+
+	"files.select_app":    "Select an app to see files",
+	"files.excluded":      "excluded",
+	"files.none_filtered": "No files match the status filter",
+	"files.truncated":     "truncated by scan limits",
+	"panel.focused":       "[focused]",
+	"breadcrumb.apps":     "Apps",
+	"breadcrumb.to_files": "Enter/Tab: files",
+	"breadcrumb.to_apps":  "Esc/Tab: apps",
+
+	"filter.conflicts": "conflicts only",
+	"filter.changed":   "changed only",
+
+	"diff.none":          "No diff to display",
+	"diff.identical":     "No differences found",
+	"diff.no_semantic":   "No key changes (only formatting, comments or key order differ)",
+	"diff.local":         "Local",
+	"diff.dotfiles":      "Dotfiles",
+	"diff.vs":            "vs %s",
+	"diff.base":          "Comparing with the dotfiles at %s",
+	"diff.base.working":  "Comparing with the working dotfiles copy",
+	"diff.base.prompt":   "Branch, tag or commit, e.g. HEAD~3",
+	"diff.base.input":    "Revision to compare with, Enter to apply, Esc to cancel",
+	"diff.base.none":     "The dotfiles directory is not a git repository",
+	"diff.base.readonly": "Comparing with %s: press b until the working copy is back to keep or merge",
+
+	"merge.none":     "No merge in progress",
+	"merge.no_hunks": "No hunks to display",
+
+	"git.no_repo":         "No repository configured",
+	"git.changes":         "Changes",
+	"git.commits":         "Recent Commits",
+	"git.branches":        "Branches",
+	"git.machines":        "Machine branches vs %s",
+	"git.machines.none":   "No %s branch yet: M merges this machine's branch to start it",
+	"git.machines.merged": "✓ merged",
+	"git.machines.drift":  "%d to merge, %d behind",
+
+	"restore.title":          "Restore from another machine",
+	"restore.select_machine": "Select source machine:",
+	"restore.no_machines":    "  No other machines found",
+	"restore.files_from":     "Files from %s:",
+	"restore.no_files":       "  No files available",
+	"restore.selected":       "Selected: %d/%d files",
+
+	"time.short.now":     "now",
+	"time.short.minutes": "%dm",
+	"time.short.hours":   "%dh",
+	"time.short.days":    "%dd",
+	"time.short.never":   "never",
+	"time.never":         "never",
+	"time.just_now":      "just now",
+	"time.minute_ago":    "1 minute ago",
+	"time.minutes_ago":   "%d minutes ago",
+	"time.hour_ago":      "1 hour ago",
+	"time.hours_ago":     "%d hours ago",
+	"time.day_ago":       "1 day ago",
+	"time.days_ago":      "%d days ago",
+}
@@ -0,0 +1,106 @@
+// Package i18n holds the UI message catalogs. Screens look strings up by
+// key with T; a key missing from the active language falls back to English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lang is a UI language
+type Lang string
+
+const (
+	English    Lang = "en"
+	Vietnamese Lang = "vi"
+)
+
+// Langs lists the supported languages in settings order
+var Langs = []Lang{English, Vietnamese}
+
+// catalogs maps each language to its messages
+var catalogs = map[Lang]map[string]string{
+	English:    en,
+	Vietnamese: vi,
+}
+
+// active is the language used by T
+var active = English
+
+// ParseLang parses a language code such as "vi" or "vi_VN.UTF-8";
+// anything unsupported is English
+func ParseLang(s string) Lang {
+	code := strings.ToLower(s)
+	if i := strings.IndexAny(code, "_-."); i >= 0 {
+		code = code[:i]
+	}
+	for _, l := range Langs {
+		if string(l) == code {
+			return l
+		}
+	}
+	return English
+}
+
+// Next returns the language after l in Langs
+func (l Lang) Next() Lang {
+	for i, lang := range Langs {
+		if lang == l {
+			return Langs[(i+1)%len(Langs)]
+		}
+	}
+	return English
+}
+
+// Name returns the language's own name
+func (l Lang) Name() string {
+	switch l {
+	case Vietnamese:
+		return "Tiếng Việt"
+	default:
+		return "English"
+	}
+}
+
+// Detect picks the language from the configured value, or from the
+// environment ($LC_ALL, $LC_MESSAGES, $LANG) when none is configured
+func Detect(configured string) Lang {
+	if configured != "" {
+		return ParseLang(configured)
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			return ParseLang(v)
+		}
+	}
+	return English
+}
+
+// SetLanguage switches the language used by T
+func SetLanguage(l Lang) {
+	if _, ok := catalogs[l]; !ok {
+		l = English
+	}
+	active = l
+}
+
+// Language returns the active language
+func Language() Lang {
+	return active
+}
+
+// T returns the message for key in the active language, formatted with
+// args when given. Unknown keys are returned as-is.
+func T(key string, args ...any) string {
+	msg, ok := catalogs[active][key]
+	if !ok {
+		if msg, ok = en[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
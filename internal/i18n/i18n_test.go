@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, msg := range en {
+			translated, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing key %q", lang, key)
+				continue
+			}
+			want := verbs.FindAllString(msg, -1)
+			got := verbs.FindAllString(translated, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s: %q has verbs %v, English has %v", lang, key, got, want)
+				continue
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("%s: %q has verbs %v, English has %v", lang, key, got, want)
+					break
+				}
+			}
+		}
+		for key := range catalog {
+			if _, ok := en[key]; !ok {
+				t.Errorf("%s: key %q is not in the English catalog", lang, key)
+			}
+		}
+	}
+}
+
+func TestParseLang(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Lang
+	}{
+		{"", English},
+		{"en", English},
+		{"vi", Vietnamese},
+		{"vi_VN.UTF-8", Vietnamese},
+		{"VI", Vietnamese},
+		{"fr_FR.UTF-8", English},
+		{"C", English},
+	}
+
+	for _, tt := range tests {
+		if got := ParseLang(tt.input); got != tt.want {
+			t.Errorf("ParseLang(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "vi_VN.UTF-8")
+
+	if got := Detect(""); got != Vietnamese {
+		t.Errorf("Expected language from $LANG, got %q", got)
+	}
+	if got := Detect("en"); got != English {
+		t.Errorf("Configured language should win, got %q", got)
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLanguage(English)
+
+	if got := T("stats.files", 3); got != "Files: 3" {
+		t.Errorf("Unexpected English message %q", got)
+	}
+
+	SetLanguage(Vietnamese)
+	if got := T("stats.files", 3); got != "Tệp: 3" {
+		t.Errorf("Unexpected Vietnamese message %q", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("Unknown keys should be returned as-is, got %q", got)
+	}
+
+	SetLanguage("fr")
+	if Language() != English {
+		t.Errorf("Unsupported language should fall back to English, got %q", Language())
+	}
+}
+
+func TestLangNextCycles(t *testing.T) {
+	l := English
+	for range Langs {
+		l = l.Next()
+	}
+	if l != English {
+		t.Errorf("Expected cycle back to English, got %q", l)
+	}
+}
@@ -0,0 +1,535 @@
+package i18n
+
+// vi is the Vietnamese catalog
+var vi = map[string]string{
+	"key.scroll":         "cuộn",
+	"key.page":           "trang",
+	"key.close":          "đóng",
+	"key.navigate":       "di chuyển",
+	"key.confirm":        "xác nhận",
+	"key.cancel":         "hủy",
+	"key.clear":          "bỏ lọc",
+	"key.select":         "chọn",
+	"key.backup":         "sao lưu",
+	"key.push":           "push",
+	"key.pull":           "pull",
+	"key.help":           "trợ giúp",
+	"key.mode":           "chế độ",
+	"key.to_files":       "→tệp",
+	"key.to_apps":        "→ứng dụng",
+	"key.all":            "tất cả",
+	"key.modified":       "đã sửa",
+	"key.outdated":       "cũ hơn",
+	"key.add_custom":     "thêm nguồn",
+	"key.search":         "tìm",
+	"key.filter":         "lọc",
+	"key.diff":           "so sánh",
+	"key.edit":           "sửa",
+	"key.cherry_pick":    "chỉ push các đoạn 1",
+	"key.preview":        "xem",
+	"key.quit":           "thoát",
+	"key.next_save":      "tiếp/lưu",
+	"key.top_bottom":     "đầu/cuối",
+	"key.back":           "quay lại",
+	"key.next_prev_hunk": "đoạn sau/trước",
+	"key.keep_local":     "giữ bản máy",
+	"key.use_dotfiles":   "dùng dotfiles",
+	"key.merge":          "gộp",
+	"key.highlight":      "tô màu",
+	"key.split":          "song song",
+	"key.structural":     "cấu trúc",
+	"key.diff_base":      "so sánh với",
+	"key.save_merge":     "lưu bản gộp",
+	"key.checkout":       "checkout",
+	"key.back_to_status": "về trạng thái",
+	"key.add_all":        "add tất cả",
+	"key.commit":         "commit",
+	"key.push_ahead":     "push ↑%d",
+	"key.fetch":          "fetch",
+	"key.stash":          "stash",
+	"key.branches":       "nhánh",
+	"key.lazygit":        "lazygit",
+	"key.merge_main":     "gộp vào %s",
+	"key.refresh":        "làm mới",
+
+	"setup.welcome.title":     "🔄 Chào mừng đến với Dotsync!",
+	"setup.welcome.intro":     "Dotsync giúp bạn đồng bộ dotfiles giữa các máy.",
+	"setup.welcome.features":  "Tính năng:",
+	"setup.feature.detect":    "Tự động phát hiện ứng dụng đã cài và cấu hình của chúng",
+	"setup.feature.selective": "Đồng bộ có chọn lọc - tự chọn tệp cần đồng bộ",
+	"setup.feature.apps":      "Hỗ trợ sẵn hơn 960 ứng dụng",
+	"setup.feature.git":       "Tích hợp thao tác git và chuyển nhánh",
+	"setup.feature.discover":  "Phát hiện ứng dụng lạ trong ~/.config",
+	"setup.welcome.help":      "Nhấn ENTER để tiếp tục • q để thoát",
+	"setup.path.title":        "📁 Chọn nơi lưu dotfiles",
+	"setup.path.question":     "Bạn muốn lưu dotfiles ở đâu?",
+	"setup.path.exists":       " (đã có)",
+	"setup.path.custom":       "Hoặc nhập đường dẫn khác:",
+	"setup.path.help":         "1-3 chọn nhanh • ENTER xác nhận • ESC quay lại",
+	"setup.confirm.title":     "✓ Xác nhận thiết lập",
+	"setup.confirm.location":  "Dotfiles sẽ được lưu tại:",
+	"setup.confirm.exists":    "✓ Thư mục đã tồn tại",
+	"setup.confirm.create":    "  Thư mục sẽ được tạo",
+	"setup.confirm.help":      "y/ENTER xác nhận • n/ESC quay lại • q thoát",
+	"setup.path.clone":        "…hoặc dán URL git để clone kho dotfiles có sẵn",
+	"setup.confirm.clone":     "Kho dotfiles sẽ clone:",
+	"setup.confirm.into":      "vào:",
+	"setup.confirm.restore":   "  Các ứng dụng trong kho sẽ được đối chiếu với máy này để pull lần đầu",
+
+	"onboard.title":             "📦 Sao lưu lần đầu",
+	"onboard.desc":              "Kho dotfiles đang trống. Chọn các ứng dụng để sao lưu trước - các mục nên dùng đã được chọn sẵn.",
+	"onboard.recommended":       "nên dùng",
+	"onboard.none":              "Không tìm thấy cấu hình nào để sao lưu",
+	"onboard.help":              "SPACE chọn • ENTER push & commit • ESC bỏ qua",
+	"onboard.remote.title":      "🌐 Thêm remote",
+	"onboard.remote.desc":       "Đã sao lưu và commit. Dán URL git remote để push lên đó, hoặc để trống để chỉ lưu trên máy.",
+	"onboard.remote.help":       "ENTER thêm & push (trống: kết thúc) • ESC kết thúc",
+	"onboard.restore.title":     "📥 Khôi phục dotfiles",
+	"onboard.restore.desc":      "Đã clone kho dotsync. Các ứng dụng đã cài trên máy này được chọn sẵn - chọn những gì cần pull về.",
+	"onboard.restore.installed": "đã cài",
+	"onboard.restore.none":      "Kho vừa clone không có ứng dụng nào",
+	"onboard.restore.help":      "SPACE chọn • ENTER xem trước pull • ESC bỏ qua",
+
+	"mappath.title":  "📍 Đường dẫn trong kho",
+	"mappath.local":  "Tệp trên máy:",
+	"mappath.repo":   "Lưu trong kho dotfiles tại:",
+	"mappath.mapped": "Đã ánh xạ riêng - xóa đường dẫn để theo bố cục kho",
+	"mappath.help":   "ENTER lưu • ctrl+r về theo bố cục • ESC hủy",
+
+	"confirm.push.title":         "📤 Push lên dotfiles",
+	"confirm.push.desc":          "Cấu hình trên máy này sẽ được sao chép vào kho dotfiles.",
+	"confirm.push.files":         "Tệp sẽ push:",
+	"confirm.pull.title":         "⚠️  Pull từ dotfiles",
+	"confirm.pull.desc":          "Cấu hình trên máy này sẽ bị thay bằng bản trong dotfiles.",
+	"confirm.pull.files":         "Tệp sẽ pull:",
+	"confirm.more_files":         "  ... và %d tệp khác",
+	"confirm.more":               "  ... và %d mục khác",
+	"confirm.total":              "Tổng: %d tệp, %s",
+	"confirm.deleted_local":      "Đã xóa trên máy (vẫn còn trong dotfiles):",
+	"confirm.deleted_remote":     "Đã xóa trên máy khác (vẫn còn ở đây):",
+	"confirm.deleted_by":         " (bởi %s)",
+	"confirm.choose":             "Chọn thao tác:",
+	"confirm.push":               "Push",
+	"confirm.push.option":        "Sao chép cấu hình trên máy vào kho dotfiles",
+	"confirm.cancel":             "Hủy",
+	"confirm.cancel.option":      "Quay lại, không thay đổi gì",
+	"confirm.push_delete":        "Push + lan truyền thao tác xóa",
+	"confirm.push_delete.option": "Xóa luôn %d cấu hình đã bị xóa khỏi dotfiles (đánh dấu cho các máy khác)",
+	"confirm.pull":               "Pull",
+	"confirm.pull.option":        "Sao lưu cấu hình hiện tại rồi pull từ dotfiles",
+	"confirm.pull_delete":        "Pull + lan truyền thao tác xóa",
+	"confirm.pull_delete.option": "Sao lưu rồi xóa %d cấu hình đã bị xóa trên máy khác",
+	"confirm.help":               "↑↓ di chuyển • ENTER chọn • ESC hủy",
+
+	"diffstatus.new (will create)": "mới (sẽ tạo)",
+	"diffstatus.different":         "khác nhau",
+	"diffstatus.will overwrite":    "sẽ ghi đè",
+	"diffstatus.not in dotfiles":   "chưa có trong dotfiles",
+	"diffstatus.missing locally":   "không có trên máy",
+	"diffstatus.same":              "giống nhau",
+
+	"watch.changed": "↻ %d tệp đã thay đổi bên ngoài dotsync, ví dụ %s",
+
+	"upstream.banner":     "⬇ %d ứng dụng được cập nhật trên remote (%s) — U: xem & kéo về",
+	"upstream.title":      "⬇ Thay đổi trên remote",
+	"upstream.help":       "↑/↓: cuộn  •  l: kéo vào dotfiles  •  r: fetch lại  •  Esc: quay lại",
+	"upstream.none":       "Dotfiles đã khớp với remote",
+	"upstream.fetching":   "Đang fetch từ remote...",
+	"upstream.rescanning": "Đã kéo từ remote, đang quét lại...",
+	"upstream.pulled":     "✓ Đã kéo từ remote: %s • O chọn các tệp cũ, l áp dụng chúng tại đây",
+
+	"scan.title":        "Đang quét ứng dụng...",
+	"scan.looking":      "Đang tìm cấu hình trong:",
+	"scan.hashing":      "Đang băm %s (%s / %s)...",
+	"scan.large_files":  "⚠ %d tệp được theo dõi lớn hơn %s, ví dụ %s",
+	"scan.home":         "Dotfiles trong thư mục home",
+	"scan.tip.search":   "💡 Dùng / để tìm ứng dụng theo tên",
+	"scan.tip.category": "💡 Nhấn 1-9 để lọc theo nhóm",
+	"scan.tip.select":   "💡 Nhấn M để chọn tệp đã sửa, O cho tệp cũ hơn",
+	"scan.tip.diff":     "💡 Nhấn d để xem khác biệt của tệp",
+	"scan.tip.git":      "💡 Nhấn g để mở các thao tác git",
+	"scan.tip.rescan":   "💡 Nhấn s để quét lại bất cứ lúc nào",
+
+	"sync.pushing":  "%s Đang push tệp...",
+	"sync.pulling":  "%s Đang pull tệp...",
+	"sync.progress": "  %d / %d tệp",
+
+	"header.override":  " (ghi đè)",
+	"header.read_only": " [chỉ đọc]",
+
+	"stats.apps":      "Ứng dụng: %d/%d",
+	"stats.files":     "Tệp: %d",
+	"stats.modified":  "Đã sửa: %d",
+	"stats.conflicts": "⚡Xung đột: %d",
+
+	"helpbar.scanning":   "⏳ Đang quét... ",
+	"helpbar.syncing":    "🔄 Đang đồng bộ... ",
+	"helpbar.add_custom": "➕ Thêm nguồn tùy chỉnh  ",
+	"helpbar.visual":     "-- CHỌN VÙNG -- ",
+
+	"help.title":            "⌨️  Hướng dẫn phím tắt",
+	"help.section.quick":    "  ─── ⚡ Thao tác nhanh ───",
+	"help.quick.Q":          "Sao lưu nhanh: tự động sao lưu tệp vào dotfiles",
+	"help.quick.P":          "Push + Commit: push mục đã chọn + git commit",
+	"help.quick.p":          "Push: chép máy → dotfiles (thủ công)",
+	"help.quick.l":          "Pull: chép dotfiles → máy",
+	"help.quick.c":          "Kiểm tra xung đột",
+	"help.quick.C":          "Hàng đợi xung đột: xử lý các tệp pull đã bỏ qua",
+	"help.quick.A":          "Hàng đợi duyệt: xem lần lượt các thay đổi đã chọn rồi áp dụng",
+	"help.quick.U":          "Thay đổi từ remote: xem những gì máy khác đã đẩy lên rồi kéo về",
+	"help.quick.W":          "Tổng kết tuần: tổng quan hoạt động gần đây",
+	"help.quick.I":          "Chi tiết ứng dụng: đường dẫn, dung lượng, lần đồng bộ, lịch sử",
+	"help.quick.H":          "Nhật ký: lịch sử các thao tác đồng bộ",
+	"help.quick.o":          "Tổng quan: tình trạng đồng bộ",
+	"help.quick.T":          "Gợi ý: các việc nên làm, Enter để thực hiện",
+	"help.quick.L":          "Đặt đường dẫn riêng trong kho dotfiles cho tệp hiện tại",
+	"help.quick.X":          "Lưu trữ dotfiles của ứng dụng đã gỡ vào _archived/",
+	"help.quick.w":          "Ghi báo cáo JSON/Markdown của lần push, pull hoặc quick sync gần nhất",
+	"key.save":              "lưu",
+	"editor.line":           "dòng",
+	"editor.modified":       "● đã sửa",
+	"editor.markers":        "%d dấu xung đột",
+	"help.quick.i":          "Sửa ngay trong dotsync (từ xem trước hoặc merge)",
+	"help.quick.e":          "So sánh bản cục bộ với dotfiles trong trình soạn thảo (VS Code/Cursor/Zed)",
+	"help.quick.E":          "Sửa tệp cục bộ trong trình soạn thảo",
+	"help.section.modes":    "  ─── 💾 Sao lưu và Đồng bộ ───",
+	"help.mode.backup":      "Lưu riêng theo máy → Q tự động push",
+	"help.mode.sync":        "Giống nhau mọi máy → p/l thủ công",
+	"help.mode.t":           "Bật/tắt đồng bộ cho app/tệp đang chọn",
+	"help.mode.R":           "Khôi phục cấu hình từ máy khác",
+	"help.section.nav":      "  ─── 🧭 Di chuyển ───",
+	"help.nav.search":       "Tìm gần đúng ứng dụng và đường dẫn tệp",
+	"help.nav.category":     "Lọc theo nhóm",
+	"help.nav.clear":        "Bỏ lọc nhóm",
+	"help.nav.stale":        "Sắp xếp ứng dụng và tệp theo lần push/pull cuối, cũ nhất trước",
+	"help.nav.group":        "Nhóm ứng dụng theo loại / danh sách phẳng",
+	"help.nav.split":        "Thu hẹp / mở rộng khung ứng dụng, chia đều (hoặc kéo đường phân cách)",
+	"help.nav.zen":          "Chế độ zen: chỉ hiện khung đang chọn",
+	"help.nav.fold":         "Thu gọn/mở rộng nhóm (trên tiêu đề)",
+	"help.nav.move":         "Di chuyển con trỏ lên/xuống",
+	"help.nav.panel":        "Chuyển khung Ứng dụng ↔ Tệp",
+	"help.nav.page":         "Cuộn trang",
+	"help.nav.jump":         "Nhảy tới đầu/cuối",
+	"help.section.select":   "  ─── ✅ Chọn ───",
+	"help.select.toggle":    "Chọn/bỏ chọn",
+	"help.select.all":       "Chọn tất cả",
+	"help.select.none":      "Bỏ chọn tất cả",
+	"help.select.visual":    "Chế độ chọn vùng: đánh dấu một dải, V/Space để chọn",
+	"help.select.shown":     "Chọn/bỏ chọn mọi mục đang hiện (theo bộ lọc)",
+	"help.select.modified":  "Chọn mọi tệp đã sửa (cần push)",
+	"help.select.outdated":  "Chọn mọi tệp cũ hơn (cần pull)",
+	"help.select.status":    "Bộ lọc trạng thái: chỉ xung đột → chỉ thay đổi → tất cả",
+	"help.select.custom":    "Thêm thư mục/ứng dụng tùy chỉnh",
+	"help.select.undo":      "Hoàn tác lần chọn trước",
+	"help.select.exclude":   "Loại trừ/bỏ loại trừ nhánh (khung Tệp)",
+	"help.select.include":   "Chỉ giữ nhánh này (khung Tệp)",
+	"help.section.file":     "  ─── 📄 Thao tác với tệp ───",
+	"help.file.preview":     "Xem nội dung tệp",
+	"help.file.diffstat":    "Thay đổi từ lần push cuối: số dòng thêm/xóa trong các ứng dụng đã chọn",
+	"help.file.diff":        "Xem khác biệt (máy và dotfiles)",
+	"help.file.diffbase":    "Trong diff: so sánh với HEAD, một nhánh (b) hoặc commit bất kỳ (B)",
+	"help.file.merge":       "Gộp xung đột",
+	"help.file.rescan":      "Quét lại mọi ứng dụng",
+	"help.file.brewfile":    "Xuất Brewfile",
+	"help.file.refresh":     "Làm mới màn hình",
+	"help.section.git":      "  ─── 🔀 Git (nhấn 'g') ───",
+	"help.git.autoinit":     "Tự động tạo git nếu chưa có",
+	"help.git.open":         "Mở khung git (tự git init)",
+	"help.git.stage":        "Stage tất cả",
+	"help.git.commit":       "Commit",
+	"help.git.push":         "Push",
+	"help.git.fetch":        "Fetch",
+	"help.git.pull":         "Pull",
+	"help.git.branch":       "Chuyển nhánh",
+	"help.git.merge_main":   "Nhánh theo máy: gộp nhánh của máy này vào main",
+	"help.git.lazygit":      "Mở lazygit (nếu đã cài)",
+	"help.section.general":  "  ─── ⚙️ Chung ───",
+	"help.general.settings": "Cài đặt (đường dẫn dotfiles, sao lưu)",
+	"help.general.help":     "Bật/tắt trợ giúp này",
+	"help.general.back":     "Quay lại / Hủy",
+	"help.general.quit":     "Thoát",
+	"help.icons.title":      "📊 Biểu tượng trạng thái",
+	"help.icons.synced":     "Đã đồng bộ - Tệp giống nhau",
+	"help.icons.modified":   "Đã sửa - Máy có thay đổi (push)",
+	"help.icons.outdated":   "Cũ hơn - Dotfiles có cập nhật (pull)",
+	"help.icons.conflict":   "Xung đột - Cả hai bên đều thay đổi",
+	"help.icons.backup":     "Chỉ sao lưu - Lưu riêng theo máy",
+	"help.icons.sync":       "Sao lưu + Đồng bộ - Giống nhau mọi máy",
+	"help.how.title":        "💡 Cách hoạt động",
+	"help.how.backup1":      "Mỗi máy có folder riêng: dotfiles/app/{machine}/",
+	"help.how.backup2":      "Nhấn Q → tự động push lên folder của máy này",
+	"help.how.backup3":      "Dùng R để restore config từ máy khác",
+	"help.how.sync1":        "Một bản duy nhất: dotfiles/app/file",
+	"help.how.sync2":        "Nhấn p để push, l để pull (thủ công)",
+	"help.how.sync3":        "Giống nhau trên mọi máy",
+	"help.close":            "  Nhấn phím bất kỳ để đóng",
+
+	"settings.title":            "⚙️  Cài đặt",
+	"settings.dotfiles_path":    "Thư mục dotfiles",
+	"settings.backup_path":      "Thư mục sao lưu",
+	"settings.git_name":         "Tên Git",
+	"settings.git_email":        "Email Git",
+	"settings.signing_key":      "Khóa ký",
+	"settings.remote":           "Remote",
+	"settings.remote_target":    "Đích remote",
+	"settings.health_checks":    "Kiểm tra app",
+	"settings.bootstrap":        "Chạy script cài đặt",
+	"settings.machine_branches": "Nhánh theo máy",
+	"settings.read_only":        "Chế độ chỉ đọc",
+	"settings.auto_push":        "Tự push (Q)",
+	"settings.icons":            "Biểu tượng",
+	"settings.theme":            "Giao diện",
+	"settings.language":         "Ngôn ngữ",
+	"settings.nested_repos":     "Repo lồng nhau",
+	"settings.symlinks":         "Liên kết tượng trưng",
+	"settings.xattrs":           "Thuộc tính mở rộng",
+	"settings.conflicts":        "Xung đột",
+	"settings.definitions":      "Định nghĩa",
+	"settings.orphans":          "Dotfiles mồ côi",
+	"settings.orphans_scan":     "quét (Enter)",
+	"settings.dashboard":        "Tổng quan",
+	"settings.notifications":    "Thông báo",
+	"settings.diff_tool":        "Công cụ diff",
+	"settings.merge_tool":       "Công cụ merge",
+	"settings.builtin":          "tích hợp sẵn",
+	"settings.on":               "bật",
+	"settings.off":              "tắt",
+	"settings.help.editing":     "Enter: lưu  •  Esc: hủy",
+	"settings.help":             "↑/↓: di chuyển  •  Enter: sửa/bật tắt  •  Esc/q: quay lại",
+	"settings.config_file":      "Tệp cấu hình: %s",
+	"settings.language_set":     "✓ Ngôn ngữ: %s",
+
+	"conflicts.title": "⚠️  Hàng đợi xung đột (%d)",
+	"conflicts.desc":  "Đã thay đổi cả trên máy lẫn trong dotfiles từ lần đồng bộ trước. Pull đã giữ nguyên các tệp này.",
+	"conflicts.none":  "Không có xung đột 🎉",
+	"conflicts.help":  "↑/↓: di chuyển  •  Enter/d: so sánh (1 giữ bản máy, 2 dùng dotfiles, m gộp)  •  Esc: quay lại",
+
+	"review.title":    "🔎 Hàng đợi duyệt",
+	"review.progress": "Duyệt %d/%d: %s/%s",
+	"review.keys":     "1 push  •  2 pull  •  m gộp  •  x bỏ qua  •  b quay lại  •  Esc hủy",
+	"review.desc":     "Các quyết định: %s. Enter áp dụng tất cả một lần.",
+	"review.help":     "Enter: áp dụng  •  b: quay lại tệp trước  •  Esc: hủy",
+
+	"suggestions.title":   "💡 Gợi ý",
+	"suggestions.desc":    "Những việc nên làm, quan trọng nhất trước. Enter để thực hiện mục đang chọn.",
+	"suggestions.loading": "Đang tìm gợi ý...",
+	"suggestions.none":    "Không có gợi ý nào - mọi thứ đều ổn 🎉",
+	"suggestions.help":    "%d/%d  •  Enter: thực hiện  •  r: làm mới  •  Esc: quay lại",
+
+	// Sync result
+	"syncresult.title":           "Kết quả đồng bộ: %s",
+	"syncresult.error":           "✗ %v",
+	"syncresult.done":            "✓ Đã đồng bộ %d/%d tệp",
+	"syncresult.failed":          "✗ %d lỗi",
+	"syncresult.help":            "g: bảng git  •  Enter/Esc: quay lại",
+	"syncresult.help_failed":     "↑↓: tệp lỗi  •  Enter: chi tiết  •  r: thử lại tệp  •  S: thử lại bằng sudo  •  R: thử lại tất cả  •  s: bỏ qua tệp từ nay  •  g: bảng git  •  y: sao chép lỗi  •  Esc: quay lại",
+	"syncresult.detail_help":     "%d/%d  •  ↑↓: lỗi khác  •  r: thử lại  •  S: sudo  •  s: bỏ qua từ nay  •  y: sao chép lỗi  •  Esc: về danh sách",
+	"syncresult.app":             "Ứng dụng",
+	"syncresult.local":           "Máy này",
+	"syncresult.dotfiles":        "Dotfiles",
+	"syncresult.kind.permission": "Không có quyền: kiểm tra chủ sở hữu và quyền của tệp và thư mục (ls -l), rồi thử lại",
+	"syncresult.kind.missing":    "Thiếu đường dẫn: tệp hoặc thư mục đã bị di chuyển hoặc xóa từ lần quét trước; quét lại hoặc bỏ qua",
+	"syncresult.kind.no-space":   "Hết dung lượng: giải phóng chỗ trống ở đích rồi thử lại",
+	"syncresult.sudo_hint":       "S: sao chép bằng sudo (hỏi mật khẩu)  •  s: bỏ qua từ nay",
+
+	"quicksync.title":     "⚡ Kết quả sao lưu nhanh (%d tệp)",
+	"quicksync.committed": "Đã commit: %s",
+	"quicksync.pushed":    "✓ Đã push lên remote",
+	"quicksync.more":      "  ... còn %d mục",
+	"quicksync.help":      "↑/↓: di chuyển  •  Enter/d: so sánh  •  m: gộp  •  Esc: quay lại",
+
+	"definitions.title":    "🧹 Định nghĩa bất thường",
+	"definitions.desc":     "ID trùng được gộp tự động. Đường dẫn dùng chung sẽ bị quét hai lần - hãy tắt hoặc đặt bí danh cho mục thừa.",
+	"definitions.none":     "Không phát hiện bất thường",
+	"definitions.active":   "đang dùng",
+	"definitions.disabled": "đã tắt",
+	"definitions.alias":    "bí danh của %s",
+	"definitions.help":     "%d/%d  •  d: tắt/bật  •  a: bí danh của mục đầu  •  Esc: quay lại (quét lại nếu có thay đổi)",
+
+	"orphans.title":      "🗑 Dotfiles mồ côi",
+	"orphans.desc":       "Thư mục app trong dotfiles không còn định nghĩa nào ánh xạ tới, hoặc app chưa cài và không có gì được theo dõi.",
+	"orphans.none":       "Không tìm thấy thư mục mồ côi",
+	"orphans.help":       "%d/%d  •  a: lưu trữ vào thư mục backup  •  D: xoá  •  Esc: quay lại",
+	"orphans.help_empty": "Esc: quay lại",
+
+	"custom.title":       "➕ Thêm nguồn tùy chỉnh",
+	"custom.folder":      "[Thư mục]",
+	"custom.app":         "[Ứng dụng]",
+	"custom.mode":        "Chế độ: ",
+	"custom.switch":      "(Tab để đổi)",
+	"custom.name":        "Tên: ",
+	"custom.paths":       "Đường dẫn: ",
+	"custom.notes":       "Ghi chú:",
+	"custom.note.folder": "• Chế độ thư mục cần đúng 1 đường dẫn",
+	"custom.note.app":    "• Chế độ ứng dụng nhận 1 hoặc nhiều đường dẫn, cách nhau bởi dấu phẩy",
+	"custom.help":        "Enter: tiếp/lưu  •  Tab: đổi chế độ  •  Esc: hủy",
+
+	"commit.title":       "📝 Commit thay đổi",
+	"commit.files":       "Tệp sẽ commit: %d",
+	"commit.message":     "Nội dung commit:",
+	"commit.placeholder": "Nhập nội dung commit...",
+	"commit.help":        "Ctrl+S để commit • ESC để hủy",
+
+	"notify.backup_done":      "dotsync: đã sao lưu nhanh",
+	"notify.backup_failed":    "dotsync: sao lưu nhanh thất bại",
+	"notify.push_failed":      "dotsync: đã sao lưu nhưng push thất bại",
+	"notify.conflicts":        "dotsync: phát hiện xung đột",
+	"notify.conflicts_pull":   "Pull đã bỏ qua %d tệp thay đổi ở cả hai phía",
+	"notify.conflicts_backup": "%d tệp đồng bộ thay đổi ở cả hai phía cần hợp nhất",
+
+	"digest.title":         "📅 Tổng kết tuần",
+	"digest.loading":       " Đang đọc lịch sử...",
+	"digest.since":         "Từ %s",
+	"digest.no_repo":       "Thư mục dotfiles không phải repo git - chỉ hiển thị hoạt động đồng bộ trên máy",
+	"digest.commits":       "Commit",
+	"digest.files":         "Tệp thay đổi",
+	"digest.conflicts":     "Xung đột đã xử lý",
+	"digest.machines":      "Máy hoạt động",
+	"digest.none":          "không có",
+	"digest.authors":       "Tác giả",
+	"digest.size":          "Dung lượng repo",
+	"digest.synced":        "Đồng bộ tại máy",
+	"digest.synced_files":  "%d tệp",
+	"digest.apps":          "Ứng dụng thay đổi nhiều nhất",
+	"digest.more":          "  … còn %d",
+	"digest.changes":       "%d thay đổi",
+	"digest.help":          "r: làm mới  •  c: nhật ký thay đổi  •  Esc: quay lại",
+	"changelog.title":      "📝 Nhật ký thay đổi cấu hình",
+	"changelog.loading":    " Đang so sánh ảnh chụp...",
+	"changelog.empty":      "Không có thay đổi trong khoảng này",
+	"changelog.summary":    "%d commit, %d tệp thay đổi trong %d ứng dụng (+%d -%d)",
+	"changelog.help":       "↑/↓: cuộn  •  [/]: tuần trước/sau  •  e: xuất markdown  •  r: làm mới  •  Esc: quay lại",
+	"dashboard.title":      "🏠 Tổng quan",
+	"dashboard.loading":    " Đang kiểm tra tình trạng đồng bộ...",
+	"dashboard.healthy":    "✓ Mọi thứ đã đồng bộ",
+	"dashboard.attention":  "Cần xử lý",
+	"dashboard.tracked":    "Theo dõi",
+	"dashboard.tracked_n":  "%d ứng dụng • %d tệp",
+	"dashboard.modified":   "Cần push",
+	"dashboard.outdated":   "Cần pull",
+	"dashboard.conflicts":  "Xung đột",
+	"dashboard.last_push":  "Push gần nhất",
+	"dashboard.last_pull":  "Pull gần nhất",
+	"dashboard.last_qb":    "Sao lưu gần nhất",
+	"dashboard.git":        "Git",
+	"dashboard.git_status": "%s • đi trước %d • đi sau %d",
+	"dashboard.no_repo":    "không phải kho git",
+	"dashboard.machines":   "Sao lưu theo máy",
+	"dashboard.no_backups": "Chưa có bản sao lưu nào - nhấn Q trong màn hình ứng dụng",
+	"dashboard.fresh":      "mới",
+	"dashboard.stale":      "hơi cũ",
+	"dashboard.old":        "cũ",
+	"dashboard.never":      "chưa có",
+	"dashboard.actions":    "Thao tác nhanh",
+	"dashboard.help":       "p: push tệp đã sửa  •  l: pull tệp cũ  •  C: xung đột  •  g: git  •  s: quét lại  •  r: làm mới  •  Enter/Esc: ứng dụng",
+
+	"audit.title":       "📜 Nhật ký thao tác",
+	"audit.action":      "thao tác: %s",
+	"audit.all":         "tất cả",
+	"audit.failed_only": "  •  chỉ lỗi",
+	"audit.none":        "Chưa có thao tác nào được ghi",
+	"audit.help.empty":  "f: lọc thao tác  •  x: chỉ lỗi  •  l: tệp log  •  Esc: quay lại",
+	"audit.help":        "%d/%d  •  f: lọc thao tác  •  x: chỉ lỗi  •  l: tệp log  •  r: tải lại  •  Esc: quay lại",
+
+	// Resuming a sync interrupted by a crash
+	"resume.title":     "⚠ Đồng bộ bị gián đoạn",
+	"resume.body":      "dotsync đã dừng giữa chừng khi %s bắt đầu lúc %s (pid %d). Một số tệp của các ứng dụng sau có thể chưa được đồng bộ:",
+	"resume.report":    "Báo cáo lỗi: %s",
+	"resume.help":      "Enter/y: %s lại (xác nhận trước)  •  n/Esc: bỏ qua",
+	"resume.no_apps":   "Quét không tìm thấy ứng dụng nào bị gián đoạn",
+	"resume.dismissed": "Đã bỏ qua lần đồng bộ bị gián đoạn",
+
+	// Log file tail
+	"logs.title":      "🪵 Tệp log",
+	"logs.none":       "Chưa có gì được ghi",
+	"logs.error":      "Lỗi đọc tệp log: %v",
+	"logs.help.empty": "r: tải lại  •  Esc: nhật ký thao tác  •  --log-level debug để ghi chi tiết hơn",
+	"logs.help":       "%d/%d  •  ↑↓: cuộn  •  r: tải lại  •  Esc: nhật ký thao tác  •  q: đóng",
+
+	"appinfo.title":       "%s %s",
+	"appinfo.no_app":      "Không có ứng dụng nào tại con trỏ",
+	"appinfo.id":          "ID",
+	"appinfo.category":    "Danh mục",
+	"appinfo.paths":       "Đường dẫn",
+	"appinfo.files":       "Tệp",
+	"appinfo.files_value": "%d tệp, %s",
+	"appinfo.mode":        "Chế độ",
+	"appinfo.mode.backup": "sao lưu: lưu riêng từng máy",
+	"appinfo.mode.sync":   "đồng bộ: dùng chung mọi máy",
+	"appinfo.last_push":   "Push gần nhất",
+	"appinfo.last_pull":   "Pull gần nhất",
+	"appinfo.history":     "Commit gần đây",
+	"appinfo.no_history":  "Chưa có commit nào chạm tới dotfiles của ứng dụng này",
+	"appinfo.help":        "Esc/I: đóng",
+
+	"diffstat.title":         "📊 Thay đổi từ lần push cuối",
+	"diffstat.computing":     "Đang đếm các dòng thay đổi...",
+	"diffstat.summary":       "%d tệp thay đổi trong %d ứng dụng,",
+	"diffstat.none":          "✓ Các tệp đã chọn khớp với kho dotfiles",
+	"diffstat.local_only":    "(chưa có trong dotfiles)",
+	"diffstat.dotfiles_only": "(đã xóa trên máy)",
+	"diffstat.help":          "%d/%d  •  Enter/d: xem diff  •  Esc: quay lại",
+	"diffstat.help.empty":    "Esc: quay lại",
+	"diffstat.back":          "Esc: quay lại danh sách thay đổi",
+
+	"apps.none":        "Không tìm thấy ứng dụng",
+	"apps.uninstalled": "đã gỡ cài đặt",
+
+	"files.select_app":    "Chọn một ứng dụng để xem tệp",
+	"files.excluded":      "đã loại trừ",
+	"files.none_filtered": "Không có tệp nào khớp bộ lọc trạng thái",
+	"files.truncated":     "bị cắt bớt do giới hạn quét",
+	"panel.focused":       "[đang chọn]",
+	"breadcrumb.apps":     "Ứng dụng",
+	"breadcrumb.to_files": "Enter/Tab: tệp",
+	"breadcrumb.to_apps":  "Esc/Tab: ứng dụng",
+
+	"filter.conflicts": "chỉ xung đột",
+	"filter.changed":   "chỉ thay đổi",
+
+	"diff.none":          "Không có khác biệt để hiển thị",
+	"diff.identical":     "Không tìm thấy khác biệt",
+	"diff.no_semantic":   "Không có khóa thay đổi (chỉ khác định dạng, chú thích hoặc thứ tự khóa)",
+	"diff.local":         "Máy này",
+	"diff.dotfiles":      "Dotfiles",
+	"diff.vs":            "so với %s",
+	"diff.base":          "Đang so sánh với dotfiles tại %s",
+	"diff.base.working":  "Đang so sánh với bản dotfiles hiện tại",
+	"diff.base.prompt":   "Nhánh, tag hoặc commit, vd. HEAD~3",
+	"diff.base.input":    "Phiên bản để so sánh, Enter để áp dụng, Esc để hủy",
+	"diff.base.none":     "Thư mục dotfiles không phải kho git",
+	"diff.base.readonly": "Đang so sánh với %s: nhấn b đến khi về bản hiện tại để giữ hoặc gộp",
+
+	"merge.none":     "Không có phiên gộp nào",
+	"merge.no_hunks": "Không có đoạn nào để hiển thị",
+
+	"git.no_repo":         "Chưa cấu hình repository",
+	"git.changes":         "Thay đổi",
+	"git.commits":         "Commit gần đây",
+	"git.branches":        "Nhánh",
+	"git.machines":        "Nhánh các máy so với %s",
+	"git.machines.none":   "Chưa có nhánh %s: M gộp nhánh của máy này để tạo",
+	"git.machines.merged": "✓ đã gộp",
+	"git.machines.drift":  "%d chưa gộp, thiếu %d",
+
+	"restore.title":          "Khôi phục từ máy khác",
+	"restore.select_machine": "Chọn máy nguồn:",
+	"restore.no_machines":    "  Không tìm thấy máy nào khác",
+	"restore.files_from":     "Tệp từ %s:",
+	"restore.no_files":       "  Không có tệp nào",
+	"restore.selected":       "Đã chọn: %d/%d tệp",
+
+	"time.short.now":     "vừa xong",
+	"time.short.minutes": "%dp",
+	"time.short.hours":   "%dg",
+	"time.short.days":    "%dng",
+	"time.short.never":   "chưa",
+	"time.never":         "chưa bao giờ",
+	"time.just_now":      "vừa xong",
+	"time.minute_ago":    "1 phút trước",
+	"time.minutes_ago":   "%d phút trước",
+	"time.hour_ago":      "1 giờ trước",
+	"time.hours_ago":     "%d giờ trước",
+	"time.day_ago":       "1 ngày trước",
+	"time.days_ago":      "%d ngày trước",
+}
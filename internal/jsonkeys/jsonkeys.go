@@ -0,0 +1,423 @@
+// Package jsonkeys keeps volatile keys (window state, recent files, tokens)
+// out of synced JSON configs. Keys are picked with per-app JSONPath rules:
+// they are stripped from the dotfiles copy on push and the local values are
+// kept on pull. Files are edited in place, so comments and formatting of
+// everything else survive.
+package jsonkeys
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Rule selects keys in an app's JSON files: an optional file glob, then a
+// JSONPath. Examples:
+//
+//	$.window.zoomLevel               every JSON file of the app
+//	storage.json:$.windowsState      only files named storage.json
+//	$..token                         token keys at any depth
+//	$['workbench.colorTheme']        keys containing dots
+//	$.recentFiles[*]                 every element of an array
+type Rule struct {
+	File string // Glob matched against the relative path or base name; empty = any JSON file
+	Path Path
+}
+
+// Path is a parsed JSONPath
+type Path []step
+
+type step struct {
+	key       string
+	index     int  // Array index when key is empty and !wildcard
+	wildcard  bool // * or [*]
+	recursive bool // Preceded by ..
+}
+
+// ParseRule parses "[glob:]$.json.path"
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	var r Rule
+	if i := strings.Index(s, ":$"); i >= 0 {
+		r.File = filepath.ToSlash(s[:i])
+		s = s[i+1:]
+	}
+	path, err := ParsePath(s)
+	if err != nil {
+		return Rule{}, err
+	}
+	r.Path = path
+	return r, nil
+}
+
+// ParsePath parses a JSONPath subset: $, .key, ['key'], [n], * and ..
+func ParsePath(s string) (Path, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("json path %q must start with $", s)
+	}
+
+	var path Path
+	rest := s[1:]
+	for rest != "" {
+		var st step
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			st.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(rest, "."):
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "" {
+				return nil, fmt.Errorf("json path %q: empty key", s)
+			}
+			if name == "*" {
+				st.wildcard = true
+			} else {
+				st.key = name
+			}
+			path = append(path, st)
+			continue
+		case !strings.HasPrefix(rest, "["):
+			return nil, fmt.Errorf("json path %q: unexpected %q", s, rest[0])
+		}
+
+		// Bracket step
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("json path %q: missing ]", s)
+		}
+		inner := strings.TrimSpace(rest[1:end])
+		rest = rest[end+1:]
+		switch {
+		case inner == "*":
+			st.wildcard = true
+		case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+			st.key = inner[1 : len(inner)-1]
+		default:
+			n, err := strconv.Atoi(inner)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("json path %q: invalid index %q", s, inner)
+			}
+			st.index = n
+		}
+		if st.key == "" && !st.wildcard && st.index == 0 && inner != "0" {
+			return nil, fmt.Errorf("json path %q: empty key", s)
+		}
+		path = append(path, st)
+	}
+
+	if len(path) == 0 {
+		return nil, fmt.Errorf("json path %q selects the whole document", s)
+	}
+	return path, nil
+}
+
+// Validate returns the first invalid rule in a list
+func Validate(rules []string) error {
+	for _, s := range rules {
+		if _, err := ParseRule(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// For returns the rules that apply to the file at relPath. Invalid rules
+// are skipped; use Validate to report them.
+func For(rules []string, relPath string) []Rule {
+	var out []Rule
+	for _, s := range rules {
+		r, err := ParseRule(s)
+		if err == nil && r.Applies(relPath) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Applies reports whether the rule covers the file at relPath
+func (r Rule) Applies(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if r.File == "" {
+		return IsJSON(relPath)
+	}
+	if ok, _ := filepath.Match(r.File, relPath); ok {
+		return true
+	}
+	ok, _ := filepath.Match(r.File, filepath.Base(relPath))
+	return ok
+}
+
+// IsJSON reports whether a file is JSON by its extension
+func IsJSON(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonc", ".code-workspace":
+		return true
+	}
+	return false
+}
+
+// Strip removes every key the rules select from src
+func Strip(src []byte, rules []Rule) ([]byte, error) {
+	for {
+		tree, err := parse(src)
+		if err != nil {
+			return nil, err
+		}
+		target := firstMatch(tree, rules)
+		if target == nil {
+			return src, nil
+		}
+		src = remove(src, tree.lookup(target[:len(target)-1]), target[len(target)-1])
+	}
+}
+
+// Restore strips the selected keys from src (incoming dotfiles content)
+// and puts back the values they have in local, so a pull never changes
+// them. A nil local, or one that doesn't parse, only strips.
+func Restore(src, local []byte, rules []Rule) ([]byte, error) {
+	out, err := Strip(src, rules)
+	if err != nil {
+		return nil, err
+	}
+	if local == nil {
+		return out, nil
+	}
+	localTree, err := parse(local)
+	if err != nil {
+		return out, nil
+	}
+
+	for _, r := range rules {
+		for _, target := range matches(localTree, r.Path) {
+			n := localTree.lookup(target)
+			if out, err = set(out, target, local[n.start:n.end]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// StripFile strips the selected keys from a file in place
+func StripFile(path string, rules []Rule) error {
+	return rewrite(path, func(data []byte) ([]byte, error) {
+		return Strip(data, rules)
+	})
+}
+
+// RestoreFile applies Restore to a file in place
+func RestoreFile(path string, local []byte, rules []Rule) error {
+	return rewrite(path, func(data []byte) ([]byte, error) {
+		return Restore(data, local, rules)
+	})
+}
+
+func rewrite(path string, fn func([]byte) ([]byte, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, err := fn(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if bytes.Equal(out, data) {
+		return nil
+	}
+	return os.WriteFile(path, out, info.Mode().Perm())
+}
+
+// firstMatch returns the first concrete path any rule selects
+func firstMatch(tree *node, rules []Rule) []any {
+	for _, r := range rules {
+		if found := matches(tree, r.Path); len(found) > 0 {
+			return found[0]
+		}
+	}
+	return nil
+}
+
+// matches expands a JSONPath into the concrete key/index paths it selects
+func matches(tree *node, path Path) [][]any {
+	var out [][]any
+	seen := make(map[string]bool)
+	var walk func(n *node, steps Path, prefix []any)
+	walk = func(n *node, steps Path, prefix []any) {
+		if len(steps) == 0 {
+			if id := fmt.Sprint(prefix); !seen[id] {
+				seen[id] = true
+				out = append(out, append([]any(nil), prefix...))
+			}
+			return
+		}
+		st := steps[0]
+		for _, c := range children(n) {
+			if st.selects(c.step) {
+				walk(c.value, steps[1:], append(prefix, c.step))
+			}
+			if st.recursive {
+				walk(c.value, steps, append(prefix, c.step))
+			}
+		}
+	}
+	walk(tree, path, nil)
+	return out
+}
+
+type childRef struct {
+	step  any // string key or int index
+	value *node
+}
+
+func children(n *node) []childRef {
+	var out []childRef
+	for _, m := range n.members {
+		out = append(out, childRef{m.key, m.value})
+	}
+	for i, e := range n.elems {
+		out = append(out, childRef{i, e.value})
+	}
+	return out
+}
+
+func (s step) selects(child any) bool {
+	if s.wildcard {
+		return true
+	}
+	switch c := child.(type) {
+	case string:
+		return s.key != "" && s.key == c
+	case int:
+		return s.key == "" && s.index == c
+	}
+	return false
+}
+
+// remove deletes a member or element of parent, with its comma
+func remove(src []byte, parent *node, target any) []byte {
+	type item struct{ start, end, comma int }
+	var items []item
+	idx := -1
+	if key, ok := target.(string); ok {
+		for i, m := range parent.members {
+			items = append(items, item{m.keyStart, m.value.end, m.comma})
+			if m.key == key {
+				idx = i
+			}
+		}
+	} else {
+		for _, e := range parent.elems {
+			items = append(items, item{e.value.start, e.value.end, e.comma})
+		}
+		idx = target.(int)
+	}
+
+	it := items[idx]
+	start, end := it.start, it.end
+	switch {
+	case it.comma >= 0:
+		end = it.comma + 1
+	case idx > 0:
+		// Last item: take the comma before it instead
+		return splice(src, items[idx-1].comma, end, nil)
+	}
+
+	// Drop the whole line when the item had it to itself
+	lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+	lineEnd := bytes.IndexByte(src[end:], '\n')
+	if lineEnd >= 0 && isBlank(src[lineStart:start]) && isBlank(src[end:end+lineEnd]) {
+		start, end = lineStart, end+lineEnd+1
+	} else {
+		for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+			end++
+		}
+	}
+	return splice(src, start, end, nil)
+}
+
+// set writes value at a concrete path in src, creating missing object keys
+func set(src []byte, path []any, value []byte) ([]byte, error) {
+	tree, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if n := tree.lookup(path); n != nil {
+		return splice(src, n.start, n.end, value), nil
+	}
+
+	// Find the deepest existing object on the way and add the rest there
+	depth := len(path) - 1
+	parent := tree.lookup(path[:depth])
+	for parent == nil {
+		depth--
+		parent = tree.lookup(path[:depth])
+	}
+	if !parent.object {
+		return src, nil
+	}
+	for i := len(path) - 1; i > depth; i-- {
+		key, ok := path[i].(string)
+		if !ok {
+			return src, nil // Can't invent array positions
+		}
+		value = append(append([]byte("{"+quote(key)+": "), value...), '}')
+	}
+	key, ok := path[depth].(string)
+	if !ok {
+		return src, nil
+	}
+	entry := append([]byte(quote(key)+": "), value...)
+
+	if len(parent.members) == 0 {
+		return splice(src, parent.start+1, parent.start+1, entry), nil
+	}
+	last := parent.members[len(parent.members)-1]
+	sep := []byte(" ")
+	lineStart := bytes.LastIndexByte(src[:last.keyStart], '\n') + 1
+	if indent := src[lineStart:last.keyStart]; isBlank(indent) && lineStart > 0 {
+		sep = append([]byte("\n"), indent...)
+	}
+	if last.comma >= 0 {
+		insert := append(append(sep, entry...), ',')
+		return splice(src, last.comma+1, last.comma+1, insert), nil
+	}
+	insert := append(append([]byte(","), sep...), entry...)
+	return splice(src, last.value.end, last.value.end, insert), nil
+}
+
+func splice(src []byte, start, end int, insert []byte) []byte {
+	out := make([]byte, 0, len(src)-(end-start)+len(insert))
+	out = append(out, src[:start]...)
+	out = append(out, insert...)
+	return append(out, src[end:]...)
+}
+
+func isBlank(b []byte) bool {
+	return len(bytes.TrimLeft(b, " \t\r")) == 0
+}
+
+func quote(key string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(key)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,179 @@
+package jsonkeys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		file    string
+		steps   int
+		wantErr bool
+	}{
+		{"$.window.zoomLevel", "", 2, false},
+		{"storage.json:$.windowsState", "storage.json", 1, false},
+		{"$..token", "", 1, false},
+		{"$['workbench.colorTheme']", "", 1, false},
+		{"$.recent[*]", "", 2, false},
+		{"$.list[0].name", "", 3, false},
+		{"window.zoomLevel", "", 0, true},
+		{"$", "", 0, true},
+		{"$.a[", "", 0, true},
+		{"$.a[x]", "", 0, true},
+		{"$..", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRule(tt.rule)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRule(%q) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (r.File != tt.file || len(r.Path) != tt.steps) {
+			t.Errorf("ParseRule(%q) = %+v, want file %q and %d steps", tt.rule, r, tt.file, tt.steps)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	rules := []string{"$.a", "storage.json:$.b", "User/*.json:$.c", "bad"}
+
+	tests := []struct {
+		relPath string
+		want    int
+	}{
+		{"Code/User/settings.json", 1},
+		{"Code/User/storage.json", 2},
+		{"User/keybindings.json", 2},
+		{"init.lua", 0},
+	}
+	for _, tt := range tests {
+		if got := For(rules, tt.relPath); len(got) != tt.want {
+			t.Errorf("For(%q) returned %d rules, want %d", tt.relPath, len(got), tt.want)
+		}
+	}
+	if err := Validate(rules); err == nil {
+		t.Error("Expected Validate to report the invalid rule")
+	}
+}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		src   string
+		want  string
+	}{
+		{
+			name:  "middle key keeps comments",
+			rules: []string{"$.window"},
+			src:   "{\n  // Font\n  \"font\": 12,\n  \"window\": {\"x\": 1},\n  \"theme\": \"dark\" // keep\n}\n",
+			want:  "{\n  // Font\n  \"font\": 12,\n  \"theme\": \"dark\" // keep\n}\n",
+		},
+		{
+			name:  "last key",
+			rules: []string{"$.window"},
+			src:   "{\n  \"font\": 12,\n  \"window\": 3\n}",
+			want:  "{\n  \"font\": 12\n}",
+		},
+		{
+			name:  "only key",
+			rules: []string{"$.window"},
+			src:   `{"window": 3}`,
+			want:  `{}`,
+		},
+		{
+			name:  "dotted key and nested path",
+			rules: []string{"$['editor.fontSize']", "$.a.b"},
+			src:   `{"editor.fontSize": 14, "a": {"b": 1, "c": 2}}`,
+			want:  `{"a": {"c": 2}}`,
+		},
+		{
+			name:  "recursive descent",
+			rules: []string{"$..token"},
+			src:   `{"token": "x", "accounts": [{"name": "a", "token": "y"}, {"token": "z"}]}`,
+			want:  `{"accounts": [{"name": "a"}, {}]}`,
+		},
+		{
+			name:  "array wildcard and trailing comma",
+			rules: []string{"$.recent[*]"},
+			src:   "{\"recent\": [\"a\", \"b\",], \"x\": 1,}",
+			want:  "{\"recent\": [], \"x\": 1,}",
+		},
+		{
+			name:  "no match",
+			rules: []string{"$.missing"},
+			src:   `{"a": 1}`,
+			want:  `{"a": 1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Strip([]byte(tt.src), For(tt.rules, "settings.json"))
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Strip =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Strip([]byte(`{"a": }`), For([]string{"$.a"}, "x.json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	rules := For([]string{"$.window.state", "$.recent", "$.token"}, "settings.json")
+	local := []byte("{\n  \"font\": 11,\n  \"window\": {\"state\": \"max\"},\n  \"recent\": [\"/tmp\"]\n}\n")
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "adds local values",
+			src:  "{\n  \"font\": 12,\n  \"window\": {}\n}\n",
+			want: "{\n  \"font\": 12,\n  \"window\": {\"state\": \"max\"},\n  \"recent\": [\"/tmp\"]\n}\n",
+		},
+		{
+			name: "replaces incoming values and drops ones missing locally",
+			src:  "{\n  \"font\": 12,\n  \"recent\": [],\n  \"token\": \"leaked\",\n}\n",
+			want: "{\n  \"font\": 12,\n  \"window\": {\"state\": \"max\"},\n  \"recent\": [\"/tmp\"],\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Restore([]byte(tt.src), local, rules)
+			if err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Restore =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	os.WriteFile(path, []byte(`{"a": 1, "b": 2}`), 0600)
+
+	if err := StripFile(path, For([]string{"$.b"}, "settings.json")); err != nil {
+		t.Fatalf("StripFile failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != `{"a": 1}` {
+		t.Errorf("Unexpected content %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("StripFile should keep permissions, got %v", info.Mode().Perm())
+	}
+}
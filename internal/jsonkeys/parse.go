@@ -0,0 +1,237 @@
+package jsonkeys
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// node is a parsed JSON value with its byte range in the source, so keys can
+// be removed or replaced without reformatting the rest of the file
+type node struct {
+	start, end int // Value range: src[start:end]
+	object     bool
+	array      bool
+	members    []member // Object members in file order
+	elems      []elem   // Array elements
+}
+
+type member struct {
+	key      string
+	keyStart int // Offset of the opening quote of the key
+	value    *node
+	comma    int // Offset of the comma after the value, or -1
+}
+
+type elem struct {
+	value *node
+	comma int
+}
+
+// parser reads JSON with comments and trailing commas (JSONC)
+type parser struct {
+	src []byte
+	pos int
+}
+
+func parse(src []byte) (*node, error) {
+	p := &parser{src: src}
+	p.skip()
+	n, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q after value", p.src[p.pos])
+	}
+	return n, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("json offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// skip skips whitespace and comments
+func (p *parser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '/':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '*':
+			p.pos += 2
+			for p.pos+1 < len(p.src) && !(p.src[p.pos] == '*' && p.src[p.pos+1] == '/') {
+				p.pos++
+			}
+			p.pos = min(p.pos+2, len(p.src))
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) value() (*node, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch p.src[p.pos] {
+	case '{':
+		return p.object()
+	case '[':
+		return p.array()
+	case '"':
+		start := p.pos
+		if _, err := p.str(); err != nil {
+			return nil, err
+		}
+		return &node{start: start, end: p.pos}, nil
+	}
+
+	// Number, true, false or null
+	start := p.pos
+	for p.pos < len(p.src) && !isDelim(p.src[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos || !json.Valid(p.src[start:p.pos]) {
+		return nil, p.errorf("invalid value %q", p.src[start:max(p.pos, start+1)])
+	}
+	return &node{start: start, end: p.pos}, nil
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', ',', ':', ']', '}', '/':
+		return true
+	}
+	return false
+}
+
+// str reads a string literal and returns its decoded value
+func (p *parser) str() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal(p.src[start:p.pos], &s); err != nil {
+				return "", p.errorf("invalid string: %v", err)
+			}
+			return s, nil
+		case '\n':
+			return "", p.errorf("unterminated string")
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) object() (*node, error) {
+	n := &node{start: p.pos, object: true}
+	p.pos++ // {
+	for {
+		p.skip()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			n.end = p.pos
+			return n, nil
+		}
+		if p.src[p.pos] != '"' {
+			return nil, p.errorf("expected key")
+		}
+
+		m := member{keyStart: p.pos, comma: -1}
+		key, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		m.key = key
+		p.skip()
+		if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after key")
+		}
+		p.pos++
+		p.skip()
+		if m.value, err = p.value(); err != nil {
+			return nil, err
+		}
+		p.skip()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			m.comma = p.pos
+			p.pos++
+		} else if p.pos < len(p.src) && p.src[p.pos] != '}' {
+			return nil, p.errorf("expected ',' or '}'")
+		}
+		n.members = append(n.members, m)
+	}
+}
+
+func (p *parser) array() (*node, error) {
+	n := &node{start: p.pos, array: true}
+	p.pos++ // [
+	for {
+		p.skip()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			n.end = p.pos
+			return n, nil
+		}
+
+		e := elem{comma: -1}
+		var err error
+		if e.value, err = p.value(); err != nil {
+			return nil, err
+		}
+		p.skip()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			e.comma = p.pos
+			p.pos++
+		} else if p.pos < len(p.src) && p.src[p.pos] != ']' {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+		n.elems = append(n.elems, e)
+	}
+}
+
+// child returns the value at one step of a concrete path
+func (n *node) child(step any) *node {
+	switch s := step.(type) {
+	case string:
+		if n.object {
+			// The last duplicate wins, like encoding/json
+			for i := len(n.members) - 1; i >= 0; i-- {
+				if n.members[i].key == s {
+					return n.members[i].value
+				}
+			}
+		}
+	case int:
+		if n.array && s >= 0 && s < len(n.elems) {
+			return n.elems[s].value
+		}
+	}
+	return nil
+}
+
+// lookup follows a concrete path of keys and indexes
+func (n *node) lookup(path []any) *node {
+	for _, step := range path {
+		if n = n.child(step); n == nil {
+			return nil
+		}
+	}
+	return n
+}
@@ -0,0 +1,256 @@
+// Package layout maps app files to their place in a dotfiles repo. The
+// default keeps one directory per app (dotfiles/nvim/nvim/init.lua); repos
+// started with stow, a bare repo or by hand usually mirror $HOME instead
+// (dotfiles/.config/nvim/init.lua) or keep configs at the top level
+// (dotfiles/nvim/init.lua). The layout is a property of the repo, stored
+// in it so every machine cloning the repo uses the same one, together with
+// explicit mappings of single local paths for files the layout places
+// badly, such as VS Code's settings deep in ~/Library.
+package layout
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Layout names
+const (
+	ByApp = "by-app" // dotfiles/<app>/<rel path>
+	Home  = "home"   // dotfiles/<path relative to $HOME>, _root/<path> outside it
+	Flat  = "flat"   // dotfiles/<rel path>, apps sharing one namespace
+)
+
+// RootDir holds files outside the home directory in the home layout
+const RootDir = "_root"
+
+// Layout decides where an app's file is stored in the dotfiles repo
+type Layout interface {
+	// Name returns the layout name saved in the repo
+	Name() string
+	// Path returns the dotfiles path of the file with RelPath relPath of
+	// app appID, whose local path is localPath
+	Path(dotfilesPath, appID, relPath, localPath string) string
+}
+
+// Names lists the supported layouts, the default first
+func Names() []string {
+	return []string{ByApp, Home, Flat}
+}
+
+// New returns the layout with the given name; "" is the default
+func New(name string) (Layout, error) {
+	switch strings.TrimSpace(name) {
+	case "", ByApp:
+		return byApp{}, nil
+	case Home:
+		return home{}, nil
+	case Flat:
+		return flat{}, nil
+	default:
+		return nil, fmt.Errorf("unknown layout %q (want %s)", name, strings.Join(Names(), ", "))
+	}
+}
+
+// Default returns the one directory per app layout
+func Default() Layout {
+	return byApp{}
+}
+
+// IsDefault reports whether l stores files in per-app directories
+func IsDefault(l Layout) bool {
+	return l == nil || l.Name() == ByApp
+}
+
+type byApp struct{}
+
+func (byApp) Name() string { return ByApp }
+
+func (byApp) Path(dotfilesPath, appID, relPath, _ string) string {
+	return filepath.Join(dotfilesPath, appID, relPath)
+}
+
+type home struct{}
+
+func (home) Name() string { return Home }
+
+func (home) Path(dotfilesPath, _, _, localPath string) string {
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(homeDir, localPath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join(dotfilesPath, rel)
+		}
+	}
+	return filepath.Join(dotfilesPath, RootDir, localPath)
+}
+
+type flat struct{}
+
+func (flat) Name() string { return Flat }
+
+func (flat) Path(dotfilesPath, _, relPath, _ string) string {
+	return filepath.Join(dotfilesPath, relPath)
+}
+
+// FilePath is where a repo's layout is saved
+func FilePath(dotfilesPath string) string {
+	return filepath.Join(dotfilesPath, ".dotsync", "layout.json")
+}
+
+type file struct {
+	Layout   string            `json:"layout"`
+	Mappings map[string]string `json:"mappings,omitempty"` // ~/local/path -> path in the repo
+}
+
+// readFile reads the saved layout file; a missing one is empty
+func readFile(dotfilesPath string) (file, error) {
+	var f file
+	data, err := os.ReadFile(FilePath(dotfilesPath))
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("parse %s: %w", FilePath(dotfilesPath), err)
+	}
+	return f, nil
+}
+
+// writeFile saves the layout file
+func writeFile(dotfilesPath string, f file) error {
+	path := FilePath(dotfilesPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// mapped is a layout with explicit mappings taking precedence
+type mapped struct {
+	Layout
+	mappings map[string]string
+}
+
+func (m mapped) Path(dotfilesPath, appID, relPath, localPath string) string {
+	if repoPath, ok := lookup(m.mappings, localPath); ok {
+		return filepath.Join(dotfilesPath, repoPath)
+	}
+	return m.Layout.Path(dotfilesPath, appID, relPath, localPath)
+}
+
+// lookup finds the mapping of localPath, or of a mapped directory holding it
+func lookup(mappings map[string]string, localPath string) (string, bool) {
+	key := tildePath(localPath)
+	if repoPath, ok := mappings[key]; ok {
+		return filepath.FromSlash(repoPath), true
+	}
+	for dir := filepath.Dir(key); dir != "." && dir != "/" && dir != "~"; dir = filepath.Dir(dir) {
+		if repoPath, ok := mappings[dir]; ok {
+			return filepath.Join(filepath.FromSlash(repoPath), strings.TrimPrefix(key, dir+string(filepath.Separator))), true
+		}
+	}
+	return "", false
+}
+
+// tildePath writes a path under the home directory as ~/..., so mappings
+// hold on machines with other home directories
+func tildePath(path string) string {
+	path = filepath.Clean(path)
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(homeDir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
+
+// Load returns the layout saved in the repo at dotfilesPath with its path
+// mappings; repos without one use the default
+func Load(dotfilesPath string) (Layout, error) {
+	f, err := readFile(dotfilesPath)
+	if err != nil {
+		return Default(), err
+	}
+	l, err := New(f.Layout)
+	if err != nil {
+		return Default(), err
+	}
+	if len(f.Mappings) > 0 {
+		return mapped{Layout: l, mappings: f.Mappings}, nil
+	}
+	return l, nil
+}
+
+// For is Load for callers that can't report errors: an unreadable layout
+// file falls back to the default
+func For(dotfilesPath string) Layout {
+	l, _ := Load(dotfilesPath)
+	return l
+}
+
+// Save records the layout in the repo at dotfilesPath, keeping its path
+// mappings
+func Save(dotfilesPath string, l Layout) error {
+	f, err := readFile(dotfilesPath)
+	if err != nil {
+		return err
+	}
+	f.Layout = l.Name()
+	return writeFile(dotfilesPath, f)
+}
+
+// Mapping returns the repo path localPath is explicitly mapped to, if any
+func Mapping(dotfilesPath, localPath string) (string, bool) {
+	f, err := readFile(dotfilesPath)
+	if err != nil {
+		return "", false
+	}
+	repoPath, ok := f.Mappings[tildePath(localPath)]
+	return repoPath, ok
+}
+
+// SetMapping stores localPath at repoPath, relative to the repo root,
+// whatever the layout says. An empty repoPath removes the mapping.
+func SetMapping(dotfilesPath, localPath, repoPath string) error {
+	f, err := readFile(dotfilesPath)
+	if err != nil {
+		return err
+	}
+	key := tildePath(localPath)
+	if repoPath == "" {
+		delete(f.Mappings, key)
+		return writeFile(dotfilesPath, f)
+	}
+	repoPath, err = cleanRepoPath(repoPath)
+	if err != nil {
+		return err
+	}
+	if f.Mappings == nil {
+		f.Mappings = make(map[string]string)
+	}
+	f.Mappings[key] = repoPath
+	return writeFile(dotfilesPath, f)
+}
+
+// cleanRepoPath checks that a mapped path stays inside the repo and out of
+// git's and dotsync's own directories
+func cleanRepoPath(repoPath string) (string, error) {
+	clean := filepath.ToSlash(filepath.Clean(strings.TrimSpace(repoPath)))
+	first, _, _ := strings.Cut(clean, "/")
+	switch {
+	case filepath.IsAbs(clean) || strings.HasPrefix(clean, "~"):
+		return "", fmt.Errorf("repo path %q must be relative to the dotfiles repo", repoPath)
+	case clean == "." || clean == ".." || strings.HasPrefix(clean, "../"):
+		return "", fmt.Errorf("repo path %q is outside the dotfiles repo", repoPath)
+	case first == ".git" || first == ".dotsync":
+		return "", fmt.Errorf("repo path %q is reserved", repoPath)
+	}
+	return clean, nil
+}
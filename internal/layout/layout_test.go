@@ -0,0 +1,118 @@
+package layout
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	local := filepath.Join(homeDir, ".config", "nvim", "init.lua")
+	tests := []struct {
+		layout string
+		local  string
+		want   string
+	}{
+		{ByApp, local, "/df/nvim/nvim/init.lua"},
+		{Home, local, "/df/.config/nvim/init.lua"},
+		{Home, "/etc/hosts", "/df/_root/etc/hosts"},
+		{Flat, local, "/df/nvim/init.lua"},
+	}
+	for _, tt := range tests {
+		l, err := New(tt.layout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := l.Path("/df", "nvim", filepath.Join("nvim", "init.lua"), tt.local); got != tt.want {
+			t.Errorf("%s: Path() = %s, want %s", tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestNew_Unknown(t *testing.T) {
+	if _, err := New("stow"); err == nil {
+		t.Error("Expected an unknown layout to be rejected")
+	}
+	if l, err := New(""); err != nil || !IsDefault(l) {
+		t.Error("Expected an empty name to give the default layout")
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	dir := t.TempDir()
+	if l, err := Load(dir); err != nil || !IsDefault(l) {
+		t.Fatalf("Expected the default layout without a layout file, got %v, %v", l, err)
+	}
+
+	l, _ := New(Home)
+	if err := Save(dir, l); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(dir)
+	if err != nil || got.Name() != Home {
+		t.Fatalf("Expected the saved home layout back, got %v, %v", got, err)
+	}
+
+	os.WriteFile(FilePath(dir), []byte(`{"layout": "nested"}`), 0644)
+	if _, err := Load(dir); err == nil {
+		t.Error("Expected an unknown saved layout to be an error")
+	}
+	if !IsDefault(For(dir)) {
+		t.Error("Expected For to fall back to the default")
+	}
+}
+
+func TestSetMapping(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	dir := t.TempDir()
+	settings := filepath.Join(homeDir, "Library", "Application Support", "Code", "User", "settings.json")
+	if err := SetMapping(dir, settings, "vscode/settings.json"); err != nil {
+		t.Fatal(err)
+	}
+	snippets := filepath.Join(homeDir, ".config", "snippets")
+	if err := SetMapping(dir, snippets, "editor/snippets"); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"../outside", "/abs", ".git/config", "."} {
+		if err := SetMapping(dir, settings, bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+
+	l, err := Load(dir)
+	if err != nil || !IsDefault(l) {
+		t.Fatalf("Expected the default layout with mappings, got %v, %v", l, err)
+	}
+	if got := l.Path("/df", "vscode", "User/settings.json", settings); got != "/df/vscode/settings.json" {
+		t.Errorf("Mapped file: got %s", got)
+	}
+	if got := l.Path("/df", "snip", "snippets/go.json", filepath.Join(snippets, "go.json")); got != "/df/editor/snippets/go.json" {
+		t.Errorf("File in a mapped directory: got %s", got)
+	}
+	if got := l.Path("/df", "zsh", ".zshrc", filepath.Join(homeDir, ".zshrc")); got != "/df/zsh/.zshrc" {
+		t.Errorf("Unmapped file should follow the layout, got %s", got)
+	}
+	if got, ok := Mapping(dir, settings); !ok || got != "vscode/settings.json" {
+		t.Errorf("Mapping() = %q, %v", got, ok)
+	}
+
+	// Changing the layout keeps the mappings
+	home, _ := New(Home)
+	Save(dir, home)
+	if err := SetMapping(dir, settings, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Mapping(dir, settings); ok {
+		t.Error("Expected the mapping removed")
+	}
+	if _, ok := Mapping(dir, snippets); !ok || For(dir).Name() != Home {
+		t.Error("Expected the other mapping and the layout kept")
+	}
+}
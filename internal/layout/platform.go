@@ -0,0 +1,78 @@
+package layout
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Cross-platform apps keep their config in ~/Library/Application Support
+// on macOS and in ~/.config on Linux, with the same tree below
+const (
+	appSupportDir = "Library/Application Support"
+	xdgConfigDir  = ".config"
+)
+
+// Translate returns where path lives on goos: an ~/.config path becomes
+// its ~/Library/Application Support equivalent on macOS and the other way
+// around elsewhere. Other paths are returned unchanged.
+func Translate(path, goos string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil {
+		return path
+	}
+	rel = filepath.ToSlash(rel)
+
+	from, to := appSupportDir, xdgConfigDir
+	if goos == "darwin" {
+		from, to = xdgConfigDir, appSupportDir
+	}
+	if rest, ok := strings.CutPrefix(rel, from+"/"); ok {
+		return filepath.Join(homeDir, filepath.FromSlash(to), filepath.FromSlash(rest))
+	}
+	return path
+}
+
+// NativePaths drops the config paths of another platform whose equivalent
+// on goos is listed too, so a definition naming both the macOS and the
+// Linux location of a file restores it once, in the right place
+func NativePaths(paths []string, goos string) []string {
+	listed := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		listed[filepath.Clean(p)] = true
+	}
+	var native []string
+	for _, p := range paths {
+		if t := Translate(p, goos); t != p && listed[filepath.Clean(t)] {
+			continue
+		}
+		native = append(native, p)
+	}
+	return native
+}
+
+// Find returns the dotfiles path of a file like l.Path, falling back to
+// where another platform's push stored it when only that copy exists, as
+// in layouts that mirror the home directory
+func Find(l Layout, dotfilesPath, appID, relPath, localPath string) string {
+	path := l.Path(dotfilesPath, appID, relPath, localPath)
+	if _, err := os.Lstat(path); err == nil {
+		return path
+	}
+	foreign := "darwin"
+	if runtime.GOOS == "darwin" {
+		foreign = "linux"
+	}
+	if alt := Translate(localPath, foreign); alt != localPath {
+		altPath := l.Path(dotfilesPath, appID, relPath, alt)
+		if _, err := os.Lstat(altPath); err == nil {
+			return altPath
+		}
+	}
+	return path
+}
@@ -0,0 +1,81 @@
+package layout
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	mac := filepath.Join(homeDir, "Library", "Application Support", "Code", "User", "settings.json")
+	linux := filepath.Join(homeDir, ".config", "Code", "User", "settings.json")
+
+	if got := Translate(linux, "darwin"); got != mac {
+		t.Errorf("Translate(linux, darwin) = %s", got)
+	}
+	if got := Translate(mac, "linux"); got != linux {
+		t.Errorf("Translate(mac, linux) = %s", got)
+	}
+	if got := Translate(mac, "darwin"); got != mac {
+		t.Errorf("Native paths should stay, got %s", got)
+	}
+	if got := Translate("/etc/hosts", "darwin"); got != "/etc/hosts" {
+		t.Errorf("Paths outside home should stay, got %s", got)
+	}
+}
+
+func TestNativePaths(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	join := func(parts ...string) string { return filepath.Join(append([]string{homeDir}, parts...)...) }
+	macSettings := join("Library", "Application Support", "Code", "User", "settings.json")
+	macKeys := join("Library", "Application Support", "Code", "User", "keybindings.json")
+	linuxSettings := join(".config", "Code", "User", "settings.json")
+	paths := []string{macSettings, macKeys, linuxSettings}
+
+	got := NativePaths(paths, "darwin")
+	if len(got) != 2 || got[0] != macSettings || got[1] != macKeys {
+		t.Errorf("darwin: NativePaths() = %v", got)
+	}
+	// keybindings.json has no Linux entry, so it stays
+	got = NativePaths(paths, "linux")
+	if len(got) != 2 || got[0] != macKeys || got[1] != linuxSettings {
+		t.Errorf("linux: NativePaths() = %v", got)
+	}
+}
+
+func TestFind_ForeignCopy(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	dir := t.TempDir()
+	l, _ := New(Home)
+	native := filepath.Join(homeDir, ".config", "Code", "User", "settings.json")
+	if runtime.GOOS == "darwin" {
+		native = filepath.Join(homeDir, "Library", "Application Support", "Code", "User", "settings.json")
+	}
+	want := l.Path(dir, "vscode", "settings.json", native)
+	if got := Find(l, dir, "vscode", "settings.json", native); got != want {
+		t.Errorf("Without any copy Find should give the native path, got %s", got)
+	}
+
+	// Pushed from the other platform
+	foreign := "darwin"
+	if runtime.GOOS == "darwin" {
+		foreign = "linux"
+	}
+	stored := l.Path(dir, "vscode", "settings.json", Translate(native, foreign))
+	os.MkdirAll(filepath.Dir(stored), 0755)
+	os.WriteFile(stored, []byte("{}"), 0644)
+	if got := Find(l, dir, "vscode", "settings.json", native); got != stored {
+		t.Errorf("Find should fall back to the other platform's copy, got %s", got)
+	}
+}
@@ -0,0 +1,200 @@
+// Package localsection handles machine-local sections of shared files:
+// lines between a "dotsync:local-begin" and a "dotsync:local-end" marker,
+// written in whatever comment syntax the file uses. A push leaves the
+// section body out of dotfiles, keeping the markers, and a pull puts this
+// machine's body back between them. One shared .zshrc can so end with a
+// tail that differs per machine.
+package localsection
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+)
+
+// Markers, found anywhere in a line so any comment syntax works
+const (
+	BeginMarker = "dotsync:local-begin"
+	EndMarker   = "dotsync:local-end"
+)
+
+// maxFileSize is the largest file searched for markers
+const maxFileSize = 1 << 20
+
+// Section is one machine-local section
+type Section struct {
+	Name string // Optional name after the begin marker
+	Body []string
+}
+
+// name returns the section name after the begin marker on line
+func name(line string) string {
+	_, after, _ := strings.Cut(line, BeginMarker)
+	fields := strings.Fields(after)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// Has reports whether data contains a begin marker
+func Has(data []byte) bool {
+	return bytes.Contains(data, []byte(BeginMarker))
+}
+
+// FileHas reports whether the file at path is small enough to be a config
+// and contains a begin marker
+func FileHas(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > maxFileSize {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxFileSize)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), BeginMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// split returns the lines of data outside sections, with the marker lines,
+// and the sections in order. A section without an end marker runs to the
+// end of the file, so an unterminated section never leaks.
+func split(data []byte) (lines []string, sections []Section) {
+	var current *Section
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case current == nil && strings.Contains(line, BeginMarker):
+			lines = append(lines, line)
+			current = &Section{Name: name(line)}
+		case current != nil && strings.Contains(line, EndMarker):
+			sections = append(sections, *current)
+			current = nil
+			lines = append(lines, line)
+		case current != nil:
+			current.Body = append(current.Body, line)
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if current != nil {
+		sections = append(sections, *current)
+	}
+	return lines, sections
+}
+
+// Sections returns the machine-local sections of data
+func Sections(data []byte) []Section {
+	_, sections := split(data)
+	return sections
+}
+
+// Strip returns data with every section body removed, keeping the markers
+// so a pull knows where to put the local bodies back
+func Strip(data []byte) []byte {
+	if !Has(data) {
+		return data
+	}
+	lines, _ := split(data)
+	return []byte(strings.Join(lines, ""))
+}
+
+// Restore fills the sections of incoming with the bodies of local's
+// sections, matched by name, else by position. Local sections incoming has
+// no place for are appended with their markers so nothing is lost.
+func Restore(incoming, local []byte) []byte {
+	localSections := Sections(local)
+	if len(localSections) == 0 {
+		return incoming
+	}
+	used := make([]bool, len(localSections))
+	take := func(name string, index int) []string {
+		for i, s := range localSections {
+			if !used[i] && name != "" && s.Name == name {
+				used[i] = true
+				return s.Body
+			}
+		}
+		if index < len(localSections) && !used[index] && localSections[index].Name == name {
+			used[index] = true
+			return localSections[index].Body
+		}
+		return nil
+	}
+
+	var out strings.Builder
+	index := 0
+	inSection := false
+	for _, line := range strings.SplitAfter(string(incoming), "\n") {
+		switch {
+		case !inSection && strings.Contains(line, BeginMarker):
+			out.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				out.WriteString("\n")
+			}
+			body := take(name(line), index)
+			for _, l := range body {
+				out.WriteString(l)
+			}
+			if len(body) > 0 && !strings.HasSuffix(body[len(body)-1], "\n") {
+				out.WriteString("\n")
+			}
+			index++
+			inSection = true
+		case inSection && strings.Contains(line, EndMarker):
+			out.WriteString(line)
+			inSection = false
+		case inSection:
+			// Incoming bodies belong to another machine
+		default:
+			out.WriteString(line)
+		}
+	}
+
+	for i, s := range localSections {
+		if used[i] {
+			continue
+		}
+		if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+			out.WriteString("\n")
+		}
+		begin := "# " + BeginMarker
+		if s.Name != "" {
+			begin += " " + s.Name
+		}
+		out.WriteString(begin + "\n")
+		for _, l := range s.Body {
+			out.WriteString(l)
+		}
+		if len(s.Body) > 0 && !strings.HasSuffix(s.Body[len(s.Body)-1], "\n") {
+			out.WriteString("\n")
+		}
+		out.WriteString("# " + EndMarker + "\n")
+	}
+	return []byte(out.String())
+}
+
+// RestoreFile puts the saved local file's sections back into the freshly
+// pulled file at path
+func RestoreFile(path string, local []byte) error {
+	incoming, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, Restore(incoming, local), info.Mode().Perm())
+}
@@ -0,0 +1,73 @@
+package localsection
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const laptopRC = `export EDITOR=nvim
+# dotsync:local-begin
+export PATH=/opt/work/bin:$PATH
+alias vpn='sudo wg-quick up work'
+# dotsync:local-end
+alias ll='ls -l'
+`
+
+func TestStrip(t *testing.T) {
+	want := "export EDITOR=nvim\n# dotsync:local-begin\n# dotsync:local-end\nalias ll='ls -l'\n"
+	if got := string(Strip([]byte(laptopRC))); got != want {
+		t.Errorf("Strip() = %q, want %q", got, want)
+	}
+
+	// An unterminated section is local up to the end of the file
+	if got := string(Strip([]byte("a\n// dotsync:local-begin\nsecret\n"))); got != "a\n// dotsync:local-begin\n" {
+		t.Errorf("Expected the unterminated section stripped, got %q", got)
+	}
+	if got := string(Strip([]byte("no markers"))); got != "no markers" {
+		t.Errorf("Expected a file without markers untouched, got %q", got)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	// The shared copy changed outside the section since the laptop pushed
+	incoming := "export EDITOR=hx\n# dotsync:local-begin\nexport DESKTOP=1\n# dotsync:local-end\nalias ll='ls -la'\n"
+	want := "export EDITOR=hx\n# dotsync:local-begin\nexport PATH=/opt/work/bin:$PATH\nalias vpn='sudo wg-quick up work'\n# dotsync:local-end\nalias ll='ls -la'\n"
+	if got := string(Restore([]byte(incoming), []byte(laptopRC))); got != want {
+		t.Errorf("Restore() = %q, want %q", got, want)
+	}
+}
+
+func TestRestoreByNameAndOrphans(t *testing.T) {
+	local := "-- dotsync:local-begin fonts\nsize=12\n-- dotsync:local-end\n-- dotsync:local-begin keys\nmod=alt\n-- dotsync:local-end\n"
+	incoming := "x=1\n-- dotsync:local-begin keys\n-- dotsync:local-end"
+	want := "x=1\n-- dotsync:local-begin keys\nmod=alt\n-- dotsync:local-end" +
+		"\n# dotsync:local-begin fonts\nsize=12\n# dotsync:local-end\n"
+	if got := string(Restore([]byte(incoming), []byte(local))); got != want {
+		t.Errorf("Restore() = %q, want %q", got, want)
+	}
+}
+
+func TestFileHasAndRestoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zshrc")
+	os.WriteFile(path, []byte(laptopRC), 0600)
+	if !FileHas(path) {
+		t.Fatal("Expected markers to be found")
+	}
+	local, _ := os.ReadFile(path)
+
+	os.WriteFile(path, Strip(local), 0600)
+	if err := RestoreFile(path, local); err != nil {
+		t.Fatalf("RestoreFile failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != laptopRC {
+		t.Errorf("Expected the local section back, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the mode kept, got %v", info.Mode().Perm())
+	}
+	if FileHas(filepath.Dir(path)) {
+		t.Error("Expected directories to have no markers")
+	}
+}
@@ -0,0 +1,185 @@
+// Package lock keeps two dotsync processes (say the TUI and a scheduled
+// run) from syncing the dotfiles repo and state file at the same time.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// FileName is the lock file in the config dir
+const FileName = "sync.lock"
+
+// StaleAfter is how old a lock can get before it is taken over even if its
+// process seems alive, in case the PID was reused
+const StaleAfter = 6 * time.Hour
+
+// Path returns the lock file path in the config dir
+func Path(configDir string) string {
+	return filepath.Join(configDir, FileName)
+}
+
+// Holder describes the process holding a lock
+type Holder struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"` // e.g. "pull" or "quick backup"
+	Started time.Time `json:"started"`
+	Apps    []string  `json:"apps,omitempty"` // App IDs being synced, to resume after a crash
+}
+
+// LockedError is returned when another live process holds the lock
+type LockedError struct {
+	Holder Holder
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder.PID == 0 {
+		return "another sync is in progress - try again when it finishes"
+	}
+	return fmt.Sprintf("another sync is in progress (dotsync %s, pid %d, started %s ago) - try again when it finishes",
+		e.Holder.Command, e.Holder.PID, time.Since(e.Holder.Started).Round(time.Second))
+}
+
+// Lock is a held lock file
+type Lock struct {
+	path   string
+	holder Holder
+}
+
+// Acquire takes the lock at path for command on apps. A lock left behind
+// by a process that no longer runs is taken over.
+func Acquire(path, command string, apps ...string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	l := &Lock{path: path, holder: Holder{PID: os.Getpid(), Host: host, Command: command, Started: time.Now(), Apps: apps}}
+	data, err := json.Marshal(l.holder)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return l, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		holder, readErr := Read(path)
+		switch {
+		case os.IsNotExist(readErr):
+			continue // Released meanwhile
+		case readErr != nil:
+			// Probably being written right now, unless it has been like this for a while
+			if !isStaleFile(path) {
+				return nil, &LockedError{}
+			}
+		case !Stale(holder):
+			return nil, &LockedError{Holder: holder}
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("could not take the sync lock at %s", path)
+}
+
+// Release removes the lock file if this lock still owns it
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	holder, err := Read(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err == nil && (holder.PID != l.holder.PID || !holder.Started.Equal(l.holder.Started)) {
+		return nil // Taken over as stale
+	}
+	return os.Remove(l.path)
+}
+
+// Read returns the holder recorded in a lock file
+func Read(path string) (Holder, error) {
+	var holder Holder
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return holder, err
+	}
+	err = json.Unmarshal(data, &holder)
+	return holder, err
+}
+
+// Stale reports whether a lock's holder is gone: its process no longer
+// runs on this host, or it is older than StaleAfter
+func Stale(h Holder) bool {
+	if time.Since(h.Started) > StaleAfter {
+		return true
+	}
+	host, _ := os.Hostname()
+	if h.Host != "" && h.Host != host {
+		return false // Can't check another machine's processes
+	}
+	return !processAlive(h.PID)
+}
+
+// Abandoned returns the holder of a lock at path left behind by a process
+// of this host that died mid-sync, e.g. in a crash
+func Abandoned(path string) (Holder, bool) {
+	holder, err := Read(path)
+	if err != nil {
+		return holder, false
+	}
+	host, _ := os.Hostname()
+	return holder, (holder.Host == "" || holder.Host == host) && Stale(holder)
+}
+
+// ClearAbandoned removes the lock at path if it is still abandoned
+func ClearAbandoned(path string) error {
+	if _, ok := Abandoned(path); !ok {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// isStaleFile reports whether an unreadable lock file is old enough that
+// its writer must have died
+func isStaleFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) > time.Minute
+}
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	// EPERM means it exists but belongs to someone else
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,115 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	path := Path(t.TempDir())
+
+	l, err := Acquire(path, "pull")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// Held by this (live) process
+	_, err = Acquire(path, "push")
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Holder.Command != "pull" || locked.Holder.PID != os.Getpid() {
+		t.Fatalf("Expected a LockedError from the pull, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "another sync is in progress (dotsync pull") {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the lock file to be removed")
+	}
+	l2, err := Acquire(path, "push")
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	l2.Release()
+}
+
+func TestAcquireStale(t *testing.T) {
+	host, _ := os.Hostname()
+	tests := []struct {
+		name   string
+		holder Holder
+		stale  bool
+	}{
+		{"dead process", Holder{PID: 1 << 30, Host: host, Command: "pull", Started: time.Now()}, true},
+		{"too old", Holder{PID: os.Getpid(), Host: host, Command: "pull", Started: time.Now().Add(-2 * StaleAfter)}, true},
+		{"other host", Holder{PID: 1 << 30, Host: "elsewhere", Command: "pull", Started: time.Now()}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := Path(t.TempDir())
+			data, _ := json.Marshal(tt.holder)
+			os.WriteFile(path, data, 0644)
+
+			l, err := Acquire(path, "push")
+			if tt.stale && err != nil {
+				t.Fatalf("Expected the stale lock to be taken over, got %v", err)
+			}
+			if !tt.stale && err == nil {
+				t.Fatal("Expected the lock to be held")
+			}
+			l.Release()
+		})
+	}
+}
+
+func TestReleaseAfterTakeover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	l, err := Acquire(path, "pull")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// Another process took the lock over meanwhile
+	data, _ := json.Marshal(Holder{PID: os.Getpid() + 1, Command: "push", Started: time.Now()})
+	os.WriteFile(path, data, 0644)
+	l.Release()
+	if _, err := os.Stat(path); err != nil {
+		t.Error("Release must not remove a lock it no longer owns")
+	}
+}
+
+func TestAbandoned(t *testing.T) {
+	path := Path(t.TempDir())
+	if _, ok := Abandoned(path); ok {
+		t.Error("Expected no abandoned lock without a lock file")
+	}
+
+	l, _ := Acquire(path, "pull", "nvim", "tmux")
+	if _, ok := Abandoned(path); ok {
+		t.Error("Expected a lock of this live process not to count as abandoned")
+	}
+	l.Release()
+
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(Holder{PID: 1 << 30, Host: host, Command: "pull", Started: time.Now(), Apps: []string{"nvim", "tmux"}})
+	os.WriteFile(path, data, 0644)
+	holder, ok := Abandoned(path)
+	if !ok || holder.Command != "pull" || strings.Join(holder.Apps, ",") != "nvim,tmux" {
+		t.Fatalf("Expected the dead pull of nvim and tmux, got %+v, %v", holder, ok)
+	}
+	if err := ClearAbandoned(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the abandoned lock removed, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Package logging writes dotsync's diagnostic log: leveled slog records in
+// a size-rotated file under the XDG state directory, kept so sync problems
+// can be looked into after the fact.
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileName is the current log file inside Dir
+const FileName = "dotsync.log"
+
+// Rotation defaults: the file is rotated past MaxSize bytes and the newest
+// Keep rotated files (dotsync.log.1 is the newest) are kept
+const (
+	MaxSize = 1 << 20
+	Keep    = 3
+)
+
+// Dir returns $XDG_STATE_HOME/dotsync/logs, or ~/.local/state/dotsync/logs
+func Dir() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "dotsync", "logs")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "state", "dotsync", "logs")
+}
+
+// Path returns the current log file
+func Path() string {
+	return filepath.Join(Dir(), FileName)
+}
+
+// ParseLevel parses debug, info, warn (or warning) and error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+}
+
+// Setup makes the default slog logger write records at level and above to
+// the log file. Close the returned writer on exit.
+func Setup(level slog.Level) (io.Closer, error) {
+	w, err := NewRotatingWriter(Path(), MaxSize, Keep)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
+	return w, nil
+}
+
+// RotatingWriter appends to a file, moving it to path.1 (and path.1 to
+// path.2 and so on) once a write would take it past maxSize
+type RotatingWriter struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	keep    int
+	file    *os.File
+	size    int64
+}
+
+// NewRotatingWriter opens path for appending, creating its directory
+func NewRotatingWriter(path string, maxSize int64, keep int) (*RotatingWriter, error) {
+	w := &RotatingWriter{path: path, maxSize: maxSize, keep: keep}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if it would not fit. A record larger
+// than maxSize goes into a file of its own.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the rotated files up by one, dropping the oldest
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	os.Remove(fmt.Sprintf("%s.%d", w.path, w.keep))
+	for i := w.keep - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if w.keep > 0 {
+		if err := os.Rename(w.path, w.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(w.path); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Close closes the file
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// Tail returns the last n lines of the log at path, reaching into path.1
+// when the current file is shorter. A missing log has no lines.
+func Tail(path string, n int) ([]string, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) < n {
+		older, err := readLines(path + ".1")
+		if err != nil {
+			return nil, err
+		}
+		lines = append(older, lines...)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(data), "\n"), nil
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	if got := Dir(); got != "/tmp/state/dotsync/logs" {
+		t.Errorf("Dir() = %s with XDG_STATE_HOME set", got)
+	}
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/me")
+	if got := Dir(); got != "/home/me/.local/state/dotsync/logs" {
+		t.Errorf("Dir() = %s without XDG_STATE_HOME", got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"", slog.LevelInfo},
+		{"warning", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+	for _, tt := range tests {
+		if got, err := ParseLevel(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", FileName)
+	w, err := NewRotatingWriter(path, 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 8; i++ {
+		fmt.Fprintf(w, "line %d\n", i) // 7 bytes, two fit in a file
+	}
+	w.Close()
+
+	want := map[string]string{
+		path:        "line 7\nline 8\n",
+		path + ".1": "line 5\nline 6\n",
+		path + ".2": "line 3\nline 4\n",
+	}
+	for p, content := range want {
+		if data, _ := os.ReadFile(p); string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(p), data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only two rotated files kept, got %v", err)
+	}
+
+	// Reopening continues the current file
+	w, _ = NewRotatingWriter(path, 20, 2)
+	fmt.Fprintf(w, "line 9\n")
+	w.Close()
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "line 7\nline 8\n" {
+		t.Errorf("Expected a rotation on reopen past the size, got %q", data)
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if lines, err := Tail(path, 5); err != nil || len(lines) != 0 {
+		t.Errorf("Expected no lines for a missing log, got %v, %v", lines, err)
+	}
+
+	os.WriteFile(path+".1", []byte("a\nb\nc\n"), 0644)
+	os.WriteFile(path, []byte("d\ne\n"), 0644)
+	if lines, _ := Tail(path, 2); strings.Join(lines, ",") != "d,e" {
+		t.Errorf("Tail(2) = %v", lines)
+	}
+	if lines, _ := Tail(path, 4); strings.Join(lines, ",") != "b,c,d,e" {
+		t.Errorf("Expected the rotated file to fill the tail, got %v", lines)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	defer slog.SetDefault(slog.Default())
+
+	closer, err := Setup(slog.LevelWarn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.Info("hidden")
+	slog.Warn("push failed", "app", "nvim")
+	closer.Close()
+
+	lines, _ := Tail(Path(), 10)
+	if len(lines) != 1 || !strings.Contains(lines[0], `msg="push failed" app=nvim`) {
+		t.Errorf("Expected only the warning logged, got %v", lines)
+	}
+}
@@ -0,0 +1,257 @@
+// Package metrics keeps a machine-readable summary of dotfiles drift (last
+// sync, pending pushes and pulls, conflicts) in a status file for status
+// bars like xbar or waybar and monitoring scripts, and serves it over HTTP
+// as JSON or in the Prometheus text format.
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"dotsync/internal/config"
+	"dotsync/internal/models"
+	"dotsync/internal/report"
+	"dotsync/internal/sync"
+)
+
+// FileName is the status file inside the config directory
+const FileName = "status.json"
+
+// DefaultPath returns the status file of the current profile
+func DefaultPath() string {
+	return filepath.Join(config.ConfigDir(), FileName)
+}
+
+// Status is the drift summary of one machine
+type Status struct {
+	Machine     string    `json:"machine,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ScannedAt   time.Time `json:"scanned_at"` // When the counts below were computed
+	LastSync    time.Time `json:"last_sync"`  // Newest push or pull of any file; zero if never
+	Apps        int       `json:"apps"`
+	Files       int       `json:"files"`
+	PendingPush int       `json:"pending_push"`
+	PendingPull int       `json:"pending_pull"`
+	Conflicts   int       `json:"conflicts"`
+	InSync      bool      `json:"in_sync"`
+}
+
+// Build summarizes apps as of their last scan at scannedAt. Files synced
+// since that scan no longer count as pending.
+func Build(machine string, apps []*models.App, stateManager *sync.StateManager, scannedAt time.Time) *Status {
+	s := &Status{
+		Machine:   machine,
+		UpdatedAt: time.Now(),
+		ScannedAt: scannedAt,
+		Apps:      len(apps),
+	}
+	for _, app := range apps {
+		s.Files += len(app.Files)
+	}
+
+	if stateManager != nil {
+		for _, f := range stateManager.Files() {
+			if f.SyncedAt.After(s.LastSync) {
+				s.LastSync = f.SyncedAt
+			}
+		}
+	}
+
+	for _, e := range report.Build(machine, apps).Entries {
+		if stateManager != nil {
+			if state, ok := stateManager.GetFileState(e.AppID, e.RelPath); ok && state.SyncedAt.After(scannedAt) {
+				continue
+			}
+		}
+		switch e.Kind {
+		case report.KindPush:
+			s.PendingPush++
+		case report.KindPull:
+			s.PendingPull++
+		case report.KindConflict:
+			s.Conflicts++
+		}
+	}
+	s.InSync = s.PendingPush+s.PendingPull+s.Conflicts == 0
+	return s
+}
+
+// Load reads a status file
+func Load(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Status
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Write saves the status file. It is replaced in one step so a status bar
+// polling it never reads half a file.
+func (s *Status) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Short returns a one-line glyph summary for status bars such as xbar,
+// SketchyBar and waybar, e.g. "✓ synced" or "● 3 to push / ⚡1 conflict"
+func (s *Status) Short() string {
+	if s.InSync {
+		return "✓ synced"
+	}
+	var parts []string
+	if s.PendingPush > 0 {
+		parts = append(parts, fmt.Sprintf("● %d to push", s.PendingPush))
+	}
+	if s.PendingPull > 0 {
+		parts = append(parts, fmt.Sprintf("↓ %d to pull", s.PendingPull))
+	}
+	if s.Conflicts == 1 {
+		parts = append(parts, "⚡1 conflict")
+	} else if s.Conflicts > 1 {
+		parts = append(parts, fmt.Sprintf("⚡%d conflicts", s.Conflicts))
+	}
+	return strings.Join(parts, " / ")
+}
+
+// Class names the state for status bar styling: synced, pending or conflict
+func (s *Status) Class() string {
+	switch {
+	case s.Conflicts > 0:
+		return "conflict"
+	case !s.InSync:
+		return "pending"
+	}
+	return "synced"
+}
+
+// Tooltip describes when the status was computed and the last sync
+func (s *Status) Tooltip() string {
+	lastSync := "never"
+	if !s.LastSync.IsZero() {
+		lastSync = s.LastSync.Local().Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf("%d apps, %d files\nScanned %s\nLast sync %s",
+		s.Apps, s.Files, s.ScannedAt.Local().Format("2006-01-02 15:04"), lastSync)
+}
+
+// Waybar returns the status as a waybar custom module JSON line
+func (s *Status) Waybar() ([]byte, error) {
+	return json.Marshal(struct {
+		Text    string `json:"text"`
+		Tooltip string `json:"tooltip"`
+		Class   string `json:"class"`
+	}{s.Short(), s.Tooltip(), s.Class()})
+}
+
+// Prometheus renders the status in the Prometheus text exposition format
+func (s *Status) Prometheus() string {
+	var b strings.Builder
+	labels := ""
+	if s.Machine != "" {
+		labels = fmt.Sprintf("{machine=%q}", s.Machine)
+	}
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP dotsync_%s %s\n", name, help)
+		fmt.Fprintf(&b, "# TYPE dotsync_%s gauge\n", name)
+		fmt.Fprintf(&b, "dotsync_%s%s %g\n", name, labels, value)
+	}
+	inSync := 0.0
+	if s.InSync {
+		inSync = 1
+	}
+	var lastSync float64
+	if !s.LastSync.IsZero() {
+		lastSync = float64(s.LastSync.Unix())
+	}
+	gauge("pending_pushes", "Files changed locally and not pushed.", float64(s.PendingPush))
+	gauge("pending_pulls", "Files changed in dotfiles and not pulled.", float64(s.PendingPull))
+	gauge("conflicts", "Files changed on both sides.", float64(s.Conflicts))
+	gauge("in_sync", "1 when nothing is pending.", inSync)
+	gauge("apps", "Apps with configs found.", float64(s.Apps))
+	gauge("files", "Config files tracked.", float64(s.Files))
+	gauge("last_sync_timestamp_seconds", "Unix time of the last push or pull, 0 if never.", lastSync)
+	gauge("last_scan_timestamp_seconds", "Unix time the counts were computed.", float64(s.ScannedAt.Unix()))
+	return b.String()
+}
+
+// Handler serves /metrics in the Prometheus format and /status.json (also
+// /) as JSON. get is called for every request.
+func Handler(get func() (*Status, error)) http.Handler {
+	mux := http.NewServeMux()
+	serve := func(w http.ResponseWriter, prometheus bool) {
+		s, err := get()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if prometheus {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			fmt.Fprint(w, s.Prometheus())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s)
+	}
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) { serve(w, true) })
+	mux.HandleFunc("/status.json", func(w http.ResponseWriter, r *http.Request) { serve(w, false) })
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		serve(w, false)
+	})
+	return mux
+}
+
+// Cache rebuilds a status at most once per interval, so frequent scrapes
+// don't rescan the disk every time
+type Cache struct {
+	build    func() (*Status, error)
+	interval time.Duration
+
+	mu     gosync.Mutex
+	status *Status
+	at     time.Time
+}
+
+// NewCache creates a cache around build
+func NewCache(build func() (*Status, error), interval time.Duration) *Cache {
+	return &Cache{build: build, interval: interval}
+}
+
+// Get returns the cached status, rebuilding it when it is older than the
+// interval
+func (c *Cache) Get() (*Status, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.status != nil && time.Since(c.at) < c.interval {
+		return c.status, nil
+	}
+	s, err := c.build()
+	if err != nil {
+		return nil, err
+	}
+	c.status, c.at = s, time.Now()
+	return s, nil
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dotsync/internal/models"
+	"dotsync/internal/sync"
+)
+
+func testApps() []*models.App {
+	return []*models.App{
+		{ID: "zsh", Files: []models.File{
+			{RelPath: ".zshrc", ConflictType: models.ConflictLocalModified},
+			{RelPath: ".zprofile", ConflictType: models.ConflictLocalModified},
+		}},
+		{ID: "git", Files: []models.File{
+			{RelPath: ".gitconfig", ConflictType: models.ConflictBothModified},
+			{RelPath: ".gitignore", ConflictType: models.ConflictDotfilesModified},
+			{RelPath: ".gitattributes", ConflictType: models.ConflictNone},
+		}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	scannedAt := time.Now()
+	sm := sync.NewStateManager(t.TempDir())
+	// Pushed after the scan, so no longer pending
+	sm.SetFileState("zsh", ".zprofile", "a", "a")
+
+	s := Build("laptop", testApps(), sm, scannedAt)
+
+	if s.Apps != 2 || s.Files != 5 {
+		t.Errorf("Expected 2 apps and 5 files, got %d and %d", s.Apps, s.Files)
+	}
+	if s.PendingPush != 1 || s.PendingPull != 1 || s.Conflicts != 1 || s.InSync {
+		t.Errorf("Unexpected counts: %+v", s)
+	}
+	if s.LastSync.IsZero() {
+		t.Error("Expected the last sync time from state")
+	}
+
+	clean := Build("laptop", nil, nil, scannedAt)
+	if !clean.InSync || !clean.LastSync.IsZero() {
+		t.Errorf("Expected an empty machine to be in sync, got %+v", clean)
+	}
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dotsync", FileName)
+	s := Build("laptop", testApps(), nil, time.Now())
+	if err := s.Write(path); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.PendingPush != 2 || loaded.Machine != "laptop" {
+		t.Errorf("Unexpected loaded status: %+v", loaded)
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	s := Build("laptop", testApps(), nil, time.Now())
+	text := s.Prometheus()
+	for _, want := range []string{
+		"# TYPE dotsync_pending_pushes gauge",
+		`dotsync_pending_pushes{machine="laptop"} 2`,
+		`dotsync_conflicts{machine="laptop"} 1`,
+		`dotsync_in_sync{machine="laptop"} 0`,
+		`dotsync_last_sync_timestamp_seconds{machine="laptop"} 0`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Prometheus output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := Build("laptop", testApps(), nil, time.Now())
+	server := httptest.NewServer(Handler(func() (*Status, error) { return s, nil }))
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want string
+		code int
+	}{
+		{"/metrics", "dotsync_pending_pulls", http.StatusOK},
+		{"/status.json", `"pending_push":2`, http.StatusOK},
+		{"/", `"conflicts":1`, http.StatusOK},
+		{"/nope", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.code || !strings.Contains(string(body), tt.want) {
+			t.Errorf("GET %s = %d %q, want %d containing %q", tt.path, resp.StatusCode, body, tt.code, tt.want)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	calls := 0
+	fail := false
+	cache := NewCache(func() (*Status, error) {
+		calls++
+		if fail {
+			return nil, errors.New("scan failed")
+		}
+		return &Status{}, nil
+	}, time.Hour)
+
+	cache.Get()
+	cache.Get()
+	if calls != 1 {
+		t.Errorf("Expected one build within the interval, got %d", calls)
+	}
+
+	cache.interval = 0
+	fail = true
+	if _, err := cache.Get(); err == nil || calls != 2 {
+		t.Errorf("Expected a rebuild error after the interval, got %v (%d calls)", err, calls)
+	}
+}
+
+func TestShort(t *testing.T) {
+	tests := []struct {
+		status Status
+		want   string
+		class  string
+	}{
+		{Status{InSync: true}, "✓ synced", "synced"},
+		{Status{PendingPush: 3}, "● 3 to push", "pending"},
+		{Status{PendingPush: 3, Conflicts: 1}, "● 3 to push / ⚡1 conflict", "conflict"},
+		{Status{PendingPull: 2, Conflicts: 2}, "↓ 2 to pull / ⚡2 conflicts", "conflict"},
+	}
+	for _, tt := range tests {
+		if got := tt.status.Short(); got != tt.want {
+			t.Errorf("Short() = %q, want %q", got, tt.want)
+		}
+		if got := tt.status.Class(); got != tt.class {
+			t.Errorf("Class() = %q, want %q", got, tt.class)
+		}
+	}
+
+	data, err := (&Status{PendingPush: 1}).Waybar()
+	if err != nil || !strings.Contains(string(data), `"text":"● 1 to push"`) || !strings.Contains(string(data), `"class":"pending"`) {
+		t.Errorf("Unexpected waybar output %s (%v)", data, err)
+	}
+}
@@ -10,6 +10,8 @@ type App struct {
 	Files       []File   // Detected config files
 	Selected    bool     // Whether app is selected for sync
 	Installed   bool     // Whether app is detected on system
+	Uninstalled bool     // Synced here before, but its configs are gone locally
+	Truncated   bool     // The scan's depth or file limit left files out
 }
 
 // Category represents a group of apps
@@ -79,3 +81,16 @@ func (a *App) SelectedFiles() []File {
 	}
 	return selected
 }
+
+// HasFilesMatching reports whether any file of the app passes the filter
+func (a *App) HasFilesMatching(f StatusFilter) bool {
+	if f == FilterNone {
+		return true
+	}
+	for i := range a.Files {
+		if f.Matches(&a.Files[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,10 @@
 package models
 
 import (
-	"os"
 	"path/filepath"
 	"time"
+
+	"dotsync/internal/vfs"
 )
 
 // File represents a config file that can be synced
@@ -20,6 +21,9 @@ type File struct {
 	LocalHash    string       // SHA256 hash of local file
 	DotfilesHash string       // SHA256 hash of dotfiles version
 	ConflictType ConflictType // Conflict status based on hash comparison
+	NestedRepo   bool         // Directory is a nested git repo (pinned instead of copied)
+	Excluded     bool         // Excluded by per-app subtree rules; shown but never synced
+	LinkTarget   string       // Target of the symlink at Path, if it is one
 }
 
 // ConflictType represents the type of sync conflict
@@ -40,21 +44,21 @@ const (
 func (c ConflictType) ConflictIcon() string {
 	switch c {
 	case ConflictNone:
-		return "✓"
+		return icon(iconSynced)
 	case ConflictLocalModified:
-		return "●"
+		return icon(iconModified)
 	case ConflictDotfilesModified:
-		return "○"
+		return icon(iconOutdated)
 	case ConflictBothModified:
-		return "⚡"
+		return icon(iconConflict)
 	case ConflictLocalNew:
-		return "+"
+		return icon(iconNew)
 	case ConflictDotfilesNew:
-		return "↓"
+		return icon(iconIncoming)
 	case ConflictLocalDeleted, ConflictDotfilesDeleted:
-		return "✗"
+		return icon(iconDeleted)
 	default:
-		return "?"
+		return icon(iconUnknown)
 	}
 }
 
@@ -98,17 +102,17 @@ const (
 func (s SyncStatus) StatusIcon() string {
 	switch s {
 	case StatusSynced:
-		return "✓"
+		return icon(iconSynced)
 	case StatusModified:
-		return "●"
+		return icon(iconModified)
 	case StatusOutdated:
-		return "○"
+		return icon(iconOutdated)
 	case StatusNew:
-		return "+"
+		return icon(iconNew)
 	case StatusMissing:
-		return "✗"
+		return icon(iconMissing)
 	default:
-		return "?"
+		return icon(iconUnknown)
 	}
 }
 
@@ -130,9 +134,51 @@ func (s SyncStatus) String() string {
 	}
 }
 
+// StatusFilter narrows the app and file lists down to files that need
+// attention
+type StatusFilter int
+
+const (
+	FilterNone      StatusFilter = iota // Show everything
+	FilterConflicts                     // Only files changed on both sides
+	FilterChanged                       // Only files out of sync either way
+)
+
+// Matches reports whether a file is shown under the filter. Directory
+// entries and excluded placeholders only pass when nothing is filtered.
+func (f StatusFilter) Matches(file *File) bool {
+	if f == FilterNone {
+		return true
+	}
+	if file.IsDir || file.Excluded {
+		return false
+	}
+	if f == FilterConflicts {
+		return file.ConflictType == ConflictBothModified
+	}
+	return file.ConflictType != ConflictNone
+}
+
+// Next cycles through the filters: none, conflicts, changed
+func (f StatusFilter) Next() StatusFilter {
+	return (f + 1) % 3
+}
+
+// String returns a short name of the filter
+func (f StatusFilter) String() string {
+	switch f {
+	case FilterConflicts:
+		return "conflicts"
+	case FilterChanged:
+		return "changed"
+	default:
+		return "all"
+	}
+}
+
 // NewFile creates a File from a path
 func NewFile(path string, basePath string) (*File, error) {
-	info, err := os.Stat(path)
+	info, err := vfs.Stat(path)
 	if err != nil {
 		return nil, err
 	}
@@ -159,6 +205,15 @@ func (f *File) ToggleSelected() {
 	f.Selected = !f.Selected
 }
 
+// LargeFileSize is the size from which a tracked file is flagged as
+// suspiciously large, and hashing it shows progress
+const LargeFileSize = 100 << 20
+
+// Large reports whether the file is at least LargeFileSize
+func (f *File) Large() bool {
+	return !f.IsDir && f.Size >= LargeFileSize
+}
+
 // SizeHuman returns human-readable file size
 func (f *File) SizeHuman() string {
 	const unit = 1024
@@ -182,6 +237,9 @@ func formatSize(size int64, unit string) string {
 
 // Icon returns an icon based on file type
 func (f *File) Icon() string {
+	if f.NestedRepo {
+		return "🔗"
+	}
 	if f.IsDir {
 		return "📁"
 	}
@@ -0,0 +1,71 @@
+package models
+
+// IconSet selects how sync status is drawn. The default set tells modified
+// (●) from outdated (○) by fill alone, which is hard to read without color,
+// so the alternatives use distinct shapes or text labels instead
+type IconSet string
+
+const (
+	IconSetDefault IconSet = "default" // Colored glyphs
+	IconSetShapes  IconSet = "shapes"  // Glyphs that differ in shape, not fill
+	IconSetLabels  IconSet = "labels"  // Text labels such as [MOD]/[OUT]/[CONF]
+)
+
+// IconSets lists the icon sets in cycle order
+var IconSets = []IconSet{IconSetDefault, IconSetShapes, IconSetLabels}
+
+// activeIconSet is the icon set used by ConflictIcon and StatusIcon
+var activeIconSet = IconSetDefault
+
+// ParseIconSet returns the icon set named s, or the default
+func ParseIconSet(s string) IconSet {
+	for _, set := range IconSets {
+		if string(set) == s {
+			return set
+		}
+	}
+	return IconSetDefault
+}
+
+// Next returns the icon set after s, wrapping around
+func (s IconSet) Next() IconSet {
+	for i, set := range IconSets {
+		if set == s {
+			return IconSets[(i+1)%len(IconSets)]
+		}
+	}
+	return IconSetDefault
+}
+
+// SetIconSet changes the icon set used for status icons
+func SetIconSet(s IconSet) {
+	activeIconSet = ParseIconSet(string(s))
+}
+
+// ActiveIconSet returns the icon set used for status icons
+func ActiveIconSet() IconSet {
+	return activeIconSet
+}
+
+// Icon kinds shared by conflict types and sync statuses
+const (
+	iconSynced = iota
+	iconModified
+	iconOutdated
+	iconConflict
+	iconNew
+	iconIncoming
+	iconDeleted
+	iconMissing
+	iconUnknown
+)
+
+var iconTable = map[IconSet][]string{
+	IconSetDefault: {"✓", "●", "○", "⚡", "+", "↓", "✗", "✗", "?"},
+	IconSetShapes:  {"✓", "▲", "▼", "‼", "+", "↓", "✗", "∅", "?"},
+	IconSetLabels:  {"[OK]", "[MOD]", "[OUT]", "[CONF]", "[NEW]", "[IN]", "[DEL]", "[MISS]", "[?]"},
+}
+
+func icon(kind int) string {
+	return iconTable[activeIconSet][kind]
+}
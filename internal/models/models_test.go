@@ -349,6 +349,30 @@ func TestAppSelectedFiles_None(t *testing.T) {
 	}
 }
 
+func TestStatusFilter(t *testing.T) {
+	app := &App{
+		Files: []File{
+			{Name: "conf", IsDir: true},
+			{Name: "a", ConflictType: ConflictNone},
+			{Name: "b", ConflictType: ConflictDotfilesModified},
+			{Name: "c", ConflictType: ConflictBothModified, Excluded: true},
+		},
+	}
+
+	if !app.HasFilesMatching(FilterNone) || !app.HasFilesMatching(FilterChanged) {
+		t.Error("Expected the outdated file to pass the changed filter")
+	}
+	if app.HasFilesMatching(FilterConflicts) {
+		t.Error("Expected an excluded conflict to be filtered out")
+	}
+	if FilterChanged.Matches(&app.Files[0]) || FilterChanged.Matches(&app.Files[1]) {
+		t.Error("Expected directories and synced files to be filtered out")
+	}
+	if FilterNone.Next() != FilterConflicts || FilterChanged.Next() != FilterNone {
+		t.Error("Expected Next to cycle none, conflicts, changed")
+	}
+}
+
 // ============ Category Tests ============
 
 func TestCategory(t *testing.T) {
@@ -441,6 +465,51 @@ func TestFileStruct(t *testing.T) {
 	}
 }
 
+func TestIconSets(t *testing.T) {
+	defer SetIconSet(IconSetDefault)
+
+	tests := []struct {
+		set      IconSet
+		modified string
+		outdated string
+		conflict string
+	}{
+		{IconSetDefault, "●", "○", "⚡"},
+		{IconSetShapes, "▲", "▼", "‼"},
+		{IconSetLabels, "[MOD]", "[OUT]", "[CONF]"},
+	}
+
+	for _, tt := range tests {
+		SetIconSet(tt.set)
+		if got := StatusModified.StatusIcon(); got != tt.modified {
+			t.Errorf("%s: StatusModified = %s, want %s", tt.set, got, tt.modified)
+		}
+		if got := ConflictDotfilesModified.ConflictIcon(); got != tt.outdated {
+			t.Errorf("%s: ConflictDotfilesModified = %s, want %s", tt.set, got, tt.outdated)
+		}
+		if got := ConflictBothModified.ConflictIcon(); got != tt.conflict {
+			t.Errorf("%s: ConflictBothModified = %s, want %s", tt.set, got, tt.conflict)
+		}
+	}
+}
+
+func TestParseIconSet(t *testing.T) {
+	if got := ParseIconSet("labels"); got != IconSetLabels {
+		t.Errorf("ParseIconSet(labels) = %s", got)
+	}
+	if got := ParseIconSet("bogus"); got != IconSetDefault {
+		t.Errorf("ParseIconSet(bogus) = %s, want default", got)
+	}
+	if got := IconSetLabels.Next(); got != IconSetDefault {
+		t.Errorf("Next should wrap to default, got %s", got)
+	}
+
+	SetIconSet("bogus")
+	if ActiveIconSet() != IconSetDefault {
+		t.Errorf("Unknown icon set should fall back to default, got %s", ActiveIconSet())
+	}
+}
+
 func TestConflictIcon_Default(t *testing.T) {
 	// Test unknown conflict type (default case)
 	unknownConflict := ConflictType(99)
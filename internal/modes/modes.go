@@ -14,6 +14,7 @@ type ModesConfig struct {
 	MachineName string          `json:"machine_name"`
 	SyncedApps  map[string]bool `json:"synced_apps"`  // appID -> true = sync ON
 	SyncedFiles map[string]bool `json:"synced_files"` // "appID/file" -> true
+	AutoPush    bool            `json:"auto_push"`    // Push to the git remote after Quick Backup commits
 }
 
 // configFileName is the name of the modes config file
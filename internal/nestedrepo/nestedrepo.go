@@ -0,0 +1,202 @@
+// Package nestedrepo detects git repositories nested inside config directories
+// (nvim plugin managers, oh-my-zsh custom plugins) and pins them by remote+commit
+// so they can be re-cloned instead of copying thousands of files.
+package nestedrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// Mode controls how nested repos are synced
+type Mode string
+
+const (
+	ModeCopy      Mode = "copy"      // Copy the working tree like any other directory (default)
+	ModeManifest  Mode = "manifest"  // Pin remote+commit in a manifest
+	ModeSubmodule Mode = "submodule" // Record as a git submodule of the dotfiles repo
+)
+
+// Modes lists all modes in the order they cycle in settings
+var Modes = []Mode{ModeCopy, ModeManifest, ModeSubmodule}
+
+// ParseMode converts a config string to a Mode, defaulting to ModeCopy so
+// pinning nested repos stays opt-in
+func ParseMode(s string) Mode {
+	for _, m := range Modes {
+		if string(m) == s {
+			return m
+		}
+	}
+	return ModeCopy
+}
+
+// Next returns the next mode in the cycle
+func (m Mode) Next() Mode {
+	for i, mode := range Modes {
+		if mode == m {
+			return Modes[(i+1)%len(Modes)]
+		}
+	}
+	return ModeCopy
+}
+
+// ManifestFileName is the manifest file stored in each app's dotfiles directory
+const ManifestFileName = ".dotsync-repos.json"
+
+// Repo describes a pinned nested repository
+type Repo struct {
+	Path     string    `json:"path"` // Path relative to the app's dotfiles directory
+	Remote   string    `json:"remote"`
+	Commit   string    `json:"commit"`
+	Branch   string    `json:"branch,omitempty"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// Manifest lists the pinned repos for one app
+type Manifest struct {
+	Version int    `json:"version"`
+	Repos   []Repo `json:"repos"`
+}
+
+// IsRepo returns true if dir is the root of a git working tree.
+// Both .git directories and .git files (worktrees, submodules) count.
+func IsRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// Inspect reads the origin remote, HEAD commit and branch of a repo
+func Inspect(dir string) (*Repo, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", dir, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("read HEAD of %s: %w", dir, err)
+	}
+
+	info := &Repo{
+		Commit:   head.Hash().String(),
+		PinnedAt: time.Now(),
+	}
+	if head.Name().IsBranch() {
+		info.Branch = head.Name().Short()
+	}
+
+	if remote, err := repo.Remote("origin"); err == nil {
+		if urls := remote.Config().URLs; len(urls) > 0 {
+			info.Remote = urls[0]
+		}
+	}
+	if info.Remote == "" {
+		return nil, fmt.Errorf("%s has no origin remote", dir)
+	}
+
+	return info, nil
+}
+
+// LoadManifest loads the manifest from an app's dotfiles directory.
+// A missing manifest returns an empty one.
+func LoadManifest(appDir string) (*Manifest, error) {
+	m := &Manifest{Version: 1}
+
+	data, err := os.ReadFile(filepath.Join(appDir, ManifestFileName))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Save writes the manifest to an app's dotfiles directory
+func (m *Manifest) Save(appDir string) error {
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		return err
+	}
+
+	sort.Slice(m.Repos, func(i, j int) bool { return m.Repos[i].Path < m.Repos[j].Path })
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(appDir, ManifestFileName), data, 0644)
+}
+
+// Upsert adds or replaces the entry for repo.Path
+func (m *Manifest) Upsert(repo Repo) {
+	for i := range m.Repos {
+		if m.Repos[i].Path == repo.Path {
+			m.Repos[i] = repo
+			return
+		}
+	}
+	m.Repos = append(m.Repos, repo)
+}
+
+// Find returns the entry for a relative path, or nil
+func (m *Manifest) Find(path string) *Repo {
+	for i := range m.Repos {
+		if m.Repos[i].Path == path {
+			return &m.Repos[i]
+		}
+	}
+	return nil
+}
+
+// Clone restores a pinned repo to dest by cloning its remote and checking
+// out the pinned commit. An existing repo at dest is left untouched.
+func Clone(repo Repo, dest string) error {
+	if IsRepo(dest) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	if output, err := exec.Command("git", "clone", "--quiet", repo.Remote, dest).CombinedOutput(); err != nil {
+		return fmt.Errorf("clone failed: %s", output)
+	}
+
+	if repo.Commit != "" {
+		if output, err := exec.Command("git", "-C", dest, "checkout", "--quiet", repo.Commit).CombinedOutput(); err != nil {
+			return fmt.Errorf("checkout failed: %s", output)
+		}
+	}
+	return nil
+}
+
+// AddSubmodule records repo as a submodule of the dotfiles repo at relPath
+// (relative to dotfilesRoot), pinned to the repo's commit.
+func AddSubmodule(dotfilesRoot, relPath string, repo Repo) error {
+	dest := filepath.Join(dotfilesRoot, relPath)
+	if !IsRepo(dest) {
+		if output, err := exec.Command("git", "-C", dotfilesRoot, "submodule", "add", "--force", repo.Remote, relPath).CombinedOutput(); err != nil {
+			return fmt.Errorf("submodule add failed: %s", output)
+		}
+	}
+
+	if repo.Commit != "" {
+		if output, err := exec.Command("git", "-C", dest, "checkout", "--quiet", repo.Commit).CombinedOutput(); err != nil {
+			return fmt.Errorf("checkout failed: %s", output)
+		}
+	}
+	return nil
+}
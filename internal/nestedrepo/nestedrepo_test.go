@@ -0,0 +1,167 @@
+package nestedrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// initRepo creates a repo with one commit and an origin remote
+func initRepo(t *testing.T, dir string) string {
+	t.Helper()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "init.lua"), []byte("return {}"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree failed: %v", err)
+	}
+	if _, err := wt.Add("init.lua"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	hash, err := wt.Commit("init", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@local", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"https://github.com/example/plugin.git"},
+	})
+	if err != nil {
+		t.Fatalf("CreateRemote failed: %v", err)
+	}
+
+	return hash.String()
+}
+
+func TestIsRepo(t *testing.T) {
+	dir := t.TempDir()
+	if IsRepo(dir) {
+		t.Error("Empty dir should not be a repo")
+	}
+
+	initRepo(t, dir)
+	if !IsRepo(dir) {
+		t.Error("Initialized dir should be a repo")
+	}
+
+	// .git files (worktrees/submodules) also count
+	wt := t.TempDir()
+	os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: /elsewhere"), 0644)
+	if !IsRepo(wt) {
+		t.Error("Dir with .git file should be a repo")
+	}
+}
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	commit := initRepo(t, dir)
+
+	repo, err := Inspect(dir)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if repo.Commit != commit {
+		t.Errorf("Commit = %s, want %s", repo.Commit, commit)
+	}
+	if repo.Remote != "https://github.com/example/plugin.git" {
+		t.Errorf("Remote = %s", repo.Remote)
+	}
+	if repo.Branch == "" {
+		t.Error("Branch should be set")
+	}
+}
+
+func TestInspect_NoRemote(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("PlainInit failed: %v", err)
+	}
+
+	if _, err := Inspect(dir); err == nil {
+		t.Error("Inspect should fail for repo without commits or remote")
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(m.Repos) != 0 {
+		t.Errorf("Expected empty manifest, got %d repos", len(m.Repos))
+	}
+
+	m.Upsert(Repo{Path: "nvim/pack/b", Remote: "r1", Commit: "c1"})
+	m.Upsert(Repo{Path: "nvim/pack/a", Remote: "r2", Commit: "c2"})
+	m.Upsert(Repo{Path: "nvim/pack/b", Remote: "r1", Commit: "c3"})
+
+	if err := m.Save(dir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(loaded.Repos) != 2 {
+		t.Fatalf("Expected 2 repos, got %d", len(loaded.Repos))
+	}
+	if loaded.Repos[0].Path != "nvim/pack/a" {
+		t.Errorf("Repos should be sorted by path, got %s first", loaded.Repos[0].Path)
+	}
+	if r := loaded.Find("nvim/pack/b"); r == nil || r.Commit != "c3" {
+		t.Errorf("Upsert should replace existing entry, got %+v", r)
+	}
+	if loaded.Find("missing") != nil {
+		t.Error("Find should return nil for unknown path")
+	}
+}
+
+func TestParseModeAndNext(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Mode
+	}{
+		{"", ModeCopy},
+		{"manifest", ModeManifest},
+		{"submodule", ModeSubmodule},
+		{"copy", ModeCopy},
+		{"bogus", ModeCopy},
+	}
+	for _, tt := range tests {
+		if got := ParseMode(tt.input); got != tt.want {
+			t.Errorf("ParseMode(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+
+	if ModeSubmodule.Next() != ModeCopy {
+		t.Errorf("ModeSubmodule.Next() = %s, want copy", ModeSubmodule.Next())
+	}
+}
+
+func TestClone_ExistingRepoUntouched(t *testing.T) {
+	dir := t.TempDir()
+	initRepo(t, dir)
+
+	// Remote is unreachable, so this only passes if Clone skips existing repos
+	if err := Clone(Repo{Remote: "https://invalid.invalid/x.git", Commit: "abc"}, dir); err != nil {
+		t.Errorf("Clone should be a no-op for existing repo: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Package notify shows native desktop notifications for sync events:
+// osascript on macOS and notify-send on Linux.
+package notify
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+)
+
+// AppName is shown as the sender of notifications where supported
+const AppName = "dotsync"
+
+// ErrUnsupported is returned when there is no notification tool
+var ErrUnsupported = errors.New("desktop notifications are not supported on this system")
+
+// Command builds the notification command for goos, or nil when the OS
+// has no supported tool. Title and message are passed as arguments, never
+// interpolated into a script.
+func Command(goos, title, message string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", "--app-name="+AppName, title, message)
+	}
+	return nil
+}
+
+// Send shows a notification on this machine
+func Send(title, message string) error {
+	cmd := Command(runtime.GOOS, title, message)
+	if cmd == nil {
+		return ErrUnsupported
+	}
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		return ErrUnsupported
+	}
+	return cmd.Run()
+}
+
+// Notifier sends notifications unless they are turned off
+type Notifier struct {
+	Enabled bool
+	send    func(title, message string) error
+}
+
+// New creates a notifier that sends when enabled
+func New(enabled bool) *Notifier {
+	return &Notifier{Enabled: enabled, send: Send}
+}
+
+// SetSender replaces how notifications are shown, e.g. to capture them
+func (n *Notifier) SetSender(send func(title, message string) error) { n.send = send }
+
+// Notify shows a notification if enabled. Failures are returned but are
+// never worth interrupting a sync for.
+func (n *Notifier) Notify(title, message string) error {
+	if n == nil || !n.Enabled {
+		return nil
+	}
+	return n.send(title, message)
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		tool string
+		last string
+	}{
+		{"darwin", "osascript", `it's "done"`},
+		{"linux", "notify-send", `it's "done"`},
+		{"windows", "", ""},
+	}
+	for _, tt := range tests {
+		cmd := Command(tt.goos, "dotsync", `it's "done"`)
+		if tt.tool == "" {
+			if cmd != nil {
+				t.Errorf("%s: expected no command, got %v", tt.goos, cmd.Args)
+			}
+			continue
+		}
+		if cmd == nil {
+			t.Fatalf("%s: expected a command", tt.goos)
+		}
+		if filepath.Base(cmd.Args[0]) != tt.tool || cmd.Args[len(cmd.Args)-1] != tt.last {
+			t.Errorf("%s: unexpected args %q", tt.goos, cmd.Args)
+		}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	var sent []string
+	n := New(false)
+	n.SetSender(func(title, message string) error {
+		sent = append(sent, title+": "+message)
+		return nil
+	})
+
+	n.Notify("Quick backup", "3 files")
+	if len(sent) != 0 {
+		t.Errorf("Disabled notifier sent %v", sent)
+	}
+	n.Enabled = true
+	n.Notify("Quick backup", "3 files")
+	if len(sent) != 1 || sent[0] != "Quick backup: 3 files" {
+		t.Errorf("Unexpected notifications: %v", sent)
+	}
+
+	var nilNotifier *Notifier
+	if err := nilNotifier.Notify("x", "y"); err != nil {
+		t.Errorf("Nil notifier failed: %v", err)
+	}
+}
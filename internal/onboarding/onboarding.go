@@ -0,0 +1,101 @@
+// Package onboarding drives a new machine's first sync: the first backup
+// into an empty dotfiles repo, or the first pull from a cloned one. It
+// decides which apps to offer and which of them to pick by default.
+package onboarding
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"dotsync/internal/models"
+)
+
+// recommendedCategories are the categories picked by default: the configs
+// almost everyone wants on a new machine
+var recommendedCategories = []string{"shell", "git", "editor", "terminal"}
+
+// MaxRecommendedSize is the largest app picked by default; bigger ones
+// usually carry caches or plugin checkouts
+const MaxRecommendedSize = 20 << 20
+
+// Choice is an installed app offered for the first backup
+type Choice struct {
+	App         *models.App
+	Recommended bool
+	Chosen      bool
+}
+
+// IsEmpty reports whether the dotfiles directory holds nothing to sync
+// yet. Hidden entries such as .git don't count; a missing directory is
+// empty.
+func IsEmpty(dotfilesPath string) bool {
+	entries, err := os.ReadDir(dotfilesPath)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// Choices lists the apps with config files here, recommended ones first
+// and already chosen, the rest by name
+func Choices(apps []*models.App) []Choice {
+	var choices []Choice
+	for _, app := range apps {
+		if app.Uninstalled || !hasFiles(app) {
+			continue
+		}
+		rec := isRecommended(app)
+		choices = append(choices, Choice{App: app, Recommended: rec, Chosen: rec})
+	}
+	sort.SliceStable(choices, func(i, j int) bool {
+		if choices[i].Recommended != choices[j].Recommended {
+			return choices[i].Recommended
+		}
+		return strings.ToLower(choices[i].App.Name) < strings.ToLower(choices[j].App.Name)
+	})
+	return choices
+}
+
+// Chosen returns the apps of the chosen choices
+func Chosen(choices []Choice) []*models.App {
+	var apps []*models.App
+	for _, c := range choices {
+		if c.Chosen {
+			apps = append(apps, c.App)
+		}
+	}
+	return apps
+}
+
+func hasFiles(app *models.App) bool {
+	for _, f := range app.Files {
+		if !f.Excluded {
+			return true
+		}
+	}
+	return false
+}
+
+func isRecommended(app *models.App) bool {
+	category := strings.ToLower(app.Category)
+	recommended := false
+	for _, c := range recommendedCategories {
+		if category == c {
+			recommended = true
+		}
+	}
+	if !recommended {
+		return false
+	}
+	var size int64
+	for _, f := range app.Files {
+		size += f.Size
+	}
+	return size <= MaxRecommendedSize
+}
@@ -0,0 +1,106 @@
+package onboarding
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/models"
+)
+
+func TestIsEmpty(t *testing.T) {
+	dir := t.TempDir()
+	if !IsEmpty(filepath.Join(dir, "missing")) {
+		t.Error("Expected a missing directory to be empty")
+	}
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	if !IsEmpty(dir) {
+		t.Error("Expected hidden entries not to count")
+	}
+	os.MkdirAll(filepath.Join(dir, "zsh"), 0755)
+	if IsEmpty(dir) {
+		t.Error("Expected an app directory to make it non-empty")
+	}
+}
+
+func TestChoices(t *testing.T) {
+	file := func(size int64) []models.File { return []models.File{{Path: "/x", Size: size}} }
+	apps := []*models.App{
+		{ID: "vscode", Name: "VS Code", Category: "editor", Files: file(MaxRecommendedSize + 1)},
+		{ID: "btop", Name: "btop", Category: "other", Files: file(10)},
+		{ID: "zsh", Name: "Zsh", Category: "shell", Files: file(10)},
+		{ID: "git", Name: "Git", Category: "git", Files: file(10)},
+		{ID: "fish", Name: "Fish", Category: "shell"},
+		{ID: "tmux", Name: "tmux", Category: "terminal", Files: file(10), Uninstalled: true},
+	}
+
+	choices := Choices(apps)
+	var ids []string
+	for _, c := range choices {
+		ids = append(ids, c.App.ID)
+	}
+	want := []string{"git", "zsh", "btop", "vscode"}
+	if len(ids) != len(want) {
+		t.Fatalf("Choices() = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("Choices()[%d] = %s, want %s", i, ids[i], want[i])
+		}
+	}
+
+	chosen := Chosen(choices)
+	if len(chosen) != 2 || chosen[0].ID != "git" || chosen[1].ID != "zsh" {
+		t.Errorf("Expected the small recommended apps chosen, got %d", len(chosen))
+	}
+}
+
+func TestDetectLayout(t *testing.T) {
+	dir := t.TempDir()
+	if got := DetectLayout(dir, 0); got != LayoutEmpty {
+		t.Errorf("Expected an empty repo, got %s", got)
+	}
+	if got := DetectLayout(dir, 2); got != LayoutDotsync {
+		t.Errorf("Expected mapped app directories to mean dotsync, got %s", got)
+	}
+
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("dotfiles"), 0644)
+	if got := DetectLayout(dir, 0); got != LayoutUnknown {
+		t.Errorf("Expected an unknown layout, got %s", got)
+	}
+	os.WriteFile(filepath.Join(dir, ".zshrc"), []byte("export A=1"), 0644)
+	if got := DetectLayout(dir, 0); got != LayoutHome {
+		t.Errorf("Expected a home directory mirror, got %s", got)
+	}
+}
+
+func TestRestorePlan(t *testing.T) {
+	dotfiles := []*models.App{
+		{ID: "zsh", Name: "Zsh"},
+		{ID: "alacritty", Name: "Alacritty"},
+		{ID: "git", Name: "Git"},
+		{ID: "nvim", Name: "Neovim"},
+	}
+	local := []*models.App{
+		{ID: "git", Installed: true},
+		{ID: "alacritty", Installed: true, Uninstalled: true},
+	}
+	installed := func(id string) bool { return id == "nvim" }
+
+	choices := RestorePlan(dotfiles, local, installed)
+	var ids []string
+	for _, c := range choices {
+		ids = append(ids, c.App.ID)
+	}
+	want := []string{"git", "nvim", "alacritty", "zsh"}
+	for i := range want {
+		if i >= len(ids) || ids[i] != want[i] {
+			t.Fatalf("RestorePlan() = %v, want %v", ids, want)
+		}
+	}
+	chosen := Chosen(choices)
+	if len(chosen) != 2 || !chosen[0].Installed || !chosen[1].Installed {
+		t.Errorf("Expected only the apps installed here chosen, got %d", len(chosen))
+	}
+}
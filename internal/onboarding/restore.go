@@ -0,0 +1,98 @@
+package onboarding
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"dotsync/internal/models"
+)
+
+// Layout is how a cloned dotfiles repo is organized
+type Layout int
+
+const (
+	LayoutEmpty   Layout = iota // Nothing to restore yet
+	LayoutDotsync               // One directory per app, as dotsync pushes
+	LayoutHome                  // Mirrors the home directory (.zshrc, .config/...), as stow or a bare repo keeps it
+	LayoutUnknown               // Directories no app definition maps back
+)
+
+// String returns a short description of the layout
+func (l Layout) String() string {
+	switch l {
+	case LayoutEmpty:
+		return "empty"
+	case LayoutDotsync:
+		return "dotsync"
+	case LayoutHome:
+		return "home directory mirror"
+	default:
+		return "unknown"
+	}
+}
+
+// homeOnlyEntries are hidden entries of a repo that say nothing about its
+// layout
+var homeOnlyEntries = map[string]bool{
+	".git": true, ".github": true, ".gitignore": true, ".gitattributes": true,
+	".gitmodules": true, ".dotsync": true, ".DS_Store": true,
+}
+
+// DetectLayout tells how the repo at dotfilesPath is organized, given how
+// many of its directories map to a known app
+func DetectLayout(dotfilesPath string, mapped int) Layout {
+	if mapped > 0 {
+		return LayoutDotsync
+	}
+	entries, err := os.ReadDir(dotfilesPath)
+	if err != nil {
+		return LayoutEmpty
+	}
+	hidden, visible := 0, 0
+	for _, e := range entries {
+		switch {
+		case homeOnlyEntries[e.Name()]:
+		case strings.HasPrefix(e.Name(), "."):
+			hidden++
+		default:
+			visible++
+		}
+	}
+	switch {
+	case hidden > 0:
+		return LayoutHome
+	case visible > 0:
+		return LayoutUnknown
+	default:
+		return LayoutEmpty
+	}
+}
+
+// RestorePlan offers the apps of a cloned repo (as the scanner's
+// ScanDotfiles returns them) for pulling onto this machine. Apps installed
+// here, either found by the local scan or reported by installed, are
+// recommended and chosen; the rest are offered unchosen. Recommended apps
+// come first, then by name.
+func RestorePlan(dotfilesApps, localApps []*models.App, installed func(id string) bool) []Choice {
+	local := make(map[string]bool, len(localApps))
+	for _, app := range localApps {
+		if app.Installed && !app.Uninstalled {
+			local[app.ID] = true
+		}
+	}
+
+	var choices []Choice
+	for _, app := range dotfilesApps {
+		here := local[app.ID] || (installed != nil && installed(app.ID))
+		app.Installed = here
+		choices = append(choices, Choice{App: app, Recommended: here, Chosen: here})
+	}
+	sort.SliceStable(choices, func(i, j int) bool {
+		if choices[i].Recommended != choices[j].Recommended {
+			return choices[i].Recommended
+		}
+		return strings.ToLower(choices[i].App.Name) < strings.ToLower(choices[j].App.Name)
+	})
+	return choices
+}
@@ -0,0 +1,225 @@
+// Package peer pushes app configs directly to another machine over SSH.
+// The remote machine must have dotsync installed: files are copied into its
+// dotfiles store with scp, each to where the remote's own repo layout keeps
+// it, and then applied by the remote dotsync in --porcelain mode, so its
+// conflict detection decides what gets overwritten.
+package peer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"dotsync/internal/models"
+	"dotsync/internal/porcelain"
+	"dotsync/internal/sync"
+)
+
+// runner executes a command with stdin and returns its stdout
+type runner func(stdin string, name string, args ...string) ([]byte, error)
+
+func execRunner(stdin string, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return out, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
+
+// Peer is a remote machine reachable over SSH, e.g. "user@host"
+type Peer struct {
+	Host   string
+	Binary string // dotsync executable on the remote (default "dotsync")
+	run    runner
+}
+
+// New creates a Peer for host
+func New(host string) *Peer {
+	return &Peer{Host: host, Binary: "dotsync", run: execRunner}
+}
+
+// WithBinary sets the dotsync executable used on the remote
+func (p *Peer) WithBinary(binary string) *Peer {
+	if binary != "" {
+		p.Binary = binary
+	}
+	return p
+}
+
+// Result summarizes a push to a peer
+type Result struct {
+	Applied   []porcelain.Event // Files written on the remote
+	Conflicts []porcelain.Event // Files skipped because they were modified on the remote
+	Failed    []porcelain.Event // Files that could not be applied
+}
+
+// Push copies the dotfiles copies of the selected files of apps to the peer
+// and applies them. Files whose copy on the peer changed since the peer last
+// synced are left alone and reported as conflicts, so a push never throws
+// away what the peer hasn't pulled yet.
+func (p *Peer) Push(dotfilesPath string, apps []*models.App) (*Result, error) {
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("no apps to push")
+	}
+
+	remote, err := p.remoteFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var appIDs []string
+	for _, app := range apps {
+		uploaded := false
+		for _, file := range app.Files {
+			if !file.Selected || file.Excluded {
+				continue
+			}
+			ev := porcelain.Event{Type: porcelain.EventResult, Command: "push", AppID: app.ID, File: file.RelPath}
+			info, ok := remote[app.ID+"/"+file.RelPath]
+			switch {
+			case !ok:
+				ev.Message = "not tracked on " + p.Host
+				result.Failed = append(result.Failed, ev)
+				continue
+			case changedOnPeer(info.Conflict):
+				ev.Type, ev.Message = porcelain.EventConflict, "dotfiles copy changed on "+p.Host
+				result.Conflicts = append(result.Conflicts, ev)
+				continue
+			}
+
+			local := sync.DotfilePath(dotfilesPath, app.ID, file)
+			if _, err := os.Stat(local); err != nil {
+				ev.Message = err.Error()
+				result.Failed = append(result.Failed, ev)
+				continue
+			}
+			if err := p.upload(local, info.DotfilesPath); err != nil {
+				return nil, fmt.Errorf("upload %s/%s: %w", app.ID, file.RelPath, err)
+			}
+			uploaded = true
+		}
+		if uploaded {
+			appIDs = append(appIDs, app.ID)
+		}
+	}
+	if len(appIDs) == 0 {
+		return result, nil
+	}
+
+	events, err := p.session(porcelain.Command{Cmd: "pull", Apps: appIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ev := range events {
+		switch ev.Type {
+		case porcelain.EventResult:
+			if ev.Success {
+				result.Applied = append(result.Applied, ev)
+			} else {
+				result.Failed = append(result.Failed, ev)
+			}
+		case porcelain.EventConflict:
+			result.Conflicts = append(result.Conflicts, ev)
+		case porcelain.EventError:
+			return result, fmt.Errorf("remote: %s", ev.Message)
+		}
+	}
+	return result, nil
+}
+
+// changedOnPeer reports whether the peer's conflict status says its dotfiles
+// copy has changes it hasn't applied locally
+func changedOnPeer(conflict string) bool {
+	return conflict == models.ConflictDotfilesModified.ConflictString() ||
+		conflict == models.ConflictBothModified.ConflictString()
+}
+
+// remoteFiles asks the remote dotsync for its tracked files, by app ID and
+// RelPath, with where its dotfiles store keeps each
+func (p *Peer) remoteFiles() (map[string]porcelain.FileInfo, error) {
+	events, err := p.session(porcelain.Command{Cmd: "status"})
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]porcelain.FileInfo)
+	for _, ev := range events {
+		switch {
+		case ev.Type == porcelain.EventError:
+			return nil, fmt.Errorf("remote: %s", ev.Message)
+		case ev.Type != porcelain.EventApp || ev.App == nil:
+			continue
+		}
+		for _, f := range ev.App.Files {
+			if f.DotfilesPath == "" {
+				return nil, fmt.Errorf("remote dotsync did not report its dotfiles paths (is it up to date?)")
+			}
+			files[ev.App.ID+"/"+f.RelPath] = f
+		}
+	}
+	return files, nil
+}
+
+// upload replaces remotePath, a file or directory on the peer, with
+// localPath. The copy goes to a temp path next to it first and is swapped
+// in only once the transfer succeeded, so a dropped connection leaves
+// remotePath as it was.
+func (p *Peer) upload(localPath, remotePath string) error {
+	dir, name := path.Dir(remotePath), path.Base(remotePath)
+	tmp := path.Join(dir, ".dotsync-upload-"+name)
+	old := path.Join(dir, ".dotsync-old-"+name)
+
+	if _, err := p.run("", "ssh", p.Host, "rm -rf "+shellQuote(tmp)+" && mkdir -p "+shellQuote(dir)); err != nil {
+		return err
+	}
+	// scp (SFTP mode) takes the remote path literally, so it is not shell-quoted
+	if _, err := p.run("", "scp", "-r", "-q", localPath, p.Host+":"+tmp); err != nil {
+		_, _ = p.run("", "ssh", p.Host, "rm -rf "+shellQuote(tmp))
+		return err
+	}
+
+	swap := fmt.Sprintf("rm -rf %[1]s && { [ ! -e %[2]s ] || mv %[2]s %[1]s; } && mv %[3]s %[2]s && rm -rf %[1]s",
+		shellQuote(old), shellQuote(remotePath), shellQuote(tmp))
+	_, err := p.run("", "ssh", p.Host, swap)
+	return err
+}
+
+// session runs one porcelain command on the peer and returns its events
+func (p *Peer) session(cmd porcelain.Command) ([]porcelain.Event, error) {
+	line, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	stdin := string(line) + "\n" + `{"cmd":"quit"}` + "\n"
+
+	out, err := p.run(stdin, "ssh", p.Host, p.Binary+" --porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", p.Host, err)
+	}
+
+	var events []porcelain.Event
+	lines := bufio.NewScanner(bytes.NewReader(out))
+	for lines.Scan() {
+		var ev porcelain.Event
+		if err := json.Unmarshal(lines.Bytes(), &ev); err != nil {
+			continue // Ignore stray output such as shell banners
+		}
+		events = append(events, ev)
+	}
+	return events, lines.Err()
+}
+
+// shellQuote quotes s for the remote shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,194 @@
+package peer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/models"
+)
+
+type fakeRemote struct {
+	calls  []string
+	status string
+	pull   string
+	err    error
+	failOn string // Commands starting with this fail
+}
+
+// remoteStatus is the peer's scan: zsh and git tracked, its home layout
+// keeping them at the top of the repo, and its .gitignore copy not pulled yet
+const remoteStatus = "Welcome!\n" + `{"type":"ready"}
+{"type":"app","app":{"id":"zsh","name":"Zsh","files":[{"rel_path":".zshrc","path":"/home/bob/.zshrc","dotfiles_path":"/home/bob/dotfiles/.zshrc","status":"Synced","conflict":"Synced"}]}}
+{"type":"app","app":{"id":"git","name":"Git","files":[{"rel_path":".gitconfig","path":"/home/bob/.gitconfig","dotfiles_path":"/home/bob/dotfiles/.gitconfig","status":"Modified","conflict":"Modified (push)"},{"rel_path":".gitignore","path":"/home/bob/.gitignore","dotfiles_path":"/home/bob/dotfiles/.gitignore","status":"Outdated","conflict":"Outdated (pull)"}]}}
+`
+
+func (f *fakeRemote) run(stdin string, name string, args ...string) ([]byte, error) {
+	call := name + " " + strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.failOn != "" && strings.HasPrefix(call, f.failOn) {
+		return nil, errors.New("lost connection")
+	}
+	switch {
+	case strings.Contains(stdin, `"cmd":"status"`):
+		if f.status != "" {
+			return []byte(f.status), nil
+		}
+		return []byte(remoteStatus), nil
+	case strings.Contains(stdin, `"cmd":"pull"`):
+		return []byte(f.pull), nil
+	}
+	return nil, nil
+}
+
+// testStore writes a local dotfiles store in the default layout with the
+// zsh and git files, and returns it with the apps to push
+func testStore(t *testing.T) (string, []*models.App) {
+	t.Helper()
+	dotfiles := t.TempDir()
+	for _, rel := range []string{"zsh/.zshrc", "git/.gitconfig", "git/.gitignore"} {
+		path := filepath.Join(dotfiles, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dotfiles, []*models.App{
+		{ID: "zsh", Files: []models.File{{RelPath: ".zshrc", Path: "/home/alice/.zshrc", Selected: true}}},
+		{ID: "git", Files: []models.File{
+			{RelPath: ".gitconfig", Path: "/home/alice/.gitconfig", Selected: true},
+			{RelPath: ".gitignore", Path: "/home/alice/.gitignore", Selected: true},
+		}},
+	}
+}
+
+func TestPush(t *testing.T) {
+	fake := &fakeRemote{pull: strings.Join([]string{
+		`{"type":"ready"}`,
+		`{"type":"result","command":"pull","app_id":"zsh","file":".zshrc","success":true}`,
+		`{"type":"conflict","command":"pull","app_id":"git","file":".gitconfig","message":"changed"}`,
+		`{"type":"done","command":"pull","count":1}`,
+	}, "\n")}
+	p := New("bob@laptop")
+	p.run = fake.run
+	dotfiles, apps := testStore(t)
+
+	result, err := p.Push(dotfiles, apps)
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	if len(result.Applied) != 1 || result.Applied[0].File != ".zshrc" {
+		t.Errorf("Applied = %+v", result.Applied)
+	}
+	if len(result.Conflicts) != 2 || result.Conflicts[0].File != ".gitignore" || result.Conflicts[1].File != ".gitconfig" {
+		t.Errorf("Conflicts = %+v", result.Conflicts)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Failed = %+v", result.Failed)
+	}
+
+	wantCalls := []string{
+		"ssh bob@laptop dotsync --porcelain",
+		"ssh bob@laptop rm -rf '/home/bob/dotfiles/.dotsync-upload-.zshrc' && mkdir -p '/home/bob/dotfiles'",
+		"scp -r -q " + filepath.Join(dotfiles, "zsh", ".zshrc") + " bob@laptop:/home/bob/dotfiles/.dotsync-upload-.zshrc",
+		"ssh bob@laptop rm -rf '/home/bob/dotfiles/.dotsync-old-.zshrc' && { [ ! -e '/home/bob/dotfiles/.zshrc' ] || mv '/home/bob/dotfiles/.zshrc' '/home/bob/dotfiles/.dotsync-old-.zshrc'; } && mv '/home/bob/dotfiles/.dotsync-upload-.zshrc' '/home/bob/dotfiles/.zshrc' && rm -rf '/home/bob/dotfiles/.dotsync-old-.zshrc'",
+		"ssh bob@laptop rm -rf '/home/bob/dotfiles/.dotsync-upload-.gitconfig' && mkdir -p '/home/bob/dotfiles'",
+		"scp -r -q " + filepath.Join(dotfiles, "git", ".gitconfig") + " bob@laptop:/home/bob/dotfiles/.dotsync-upload-.gitconfig",
+		"ssh bob@laptop rm -rf '/home/bob/dotfiles/.dotsync-old-.gitconfig' && { [ ! -e '/home/bob/dotfiles/.gitconfig' ] || mv '/home/bob/dotfiles/.gitconfig' '/home/bob/dotfiles/.dotsync-old-.gitconfig'; } && mv '/home/bob/dotfiles/.dotsync-upload-.gitconfig' '/home/bob/dotfiles/.gitconfig' && rm -rf '/home/bob/dotfiles/.dotsync-old-.gitconfig'",
+		"ssh bob@laptop dotsync --porcelain",
+	}
+	if len(fake.calls) != len(wantCalls) {
+		t.Fatalf("calls = %v", fake.calls)
+	}
+	for i, want := range wantCalls {
+		if fake.calls[i] != want {
+			t.Errorf("call %d = %q, want %q", i, fake.calls[i], want)
+		}
+	}
+}
+
+func TestPush_UntrackedOnPeer(t *testing.T) {
+	fake := &fakeRemote{status: `{"type":"ready"}` + "\n"}
+	p := New("bob@laptop")
+	p.run = fake.run
+	dotfiles, apps := testStore(t)
+
+	result, err := p.Push(dotfiles, apps[:1])
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if len(result.Failed) != 1 || !strings.Contains(result.Failed[0].Message, "not tracked") {
+		t.Errorf("Failed = %+v", result.Failed)
+	}
+	if len(fake.calls) != 1 {
+		t.Errorf("Expected nothing uploaded or pulled, got %v", fake.calls)
+	}
+}
+
+func TestPush_OutdatedRemote(t *testing.T) {
+	fake := &fakeRemote{status: `{"type":"app","app":{"id":"zsh","files":[{"rel_path":".zshrc","path":"/home/bob/.zshrc"}]}}` + "\n"}
+	p := New("host")
+	p.run = fake.run
+	dotfiles, apps := testStore(t)
+
+	if _, err := p.Push(dotfiles, apps); err == nil || !strings.Contains(err.Error(), "up to date") {
+		t.Errorf("Expected an error for a remote without dotfiles paths, got %v", err)
+	}
+}
+
+func TestPush_RemoteError(t *testing.T) {
+	fake := &fakeRemote{pull: `{"type":"error","command":"pull","message":"unknown app in [zsh]"}`}
+	p := New("host")
+	p.run = fake.run
+	dotfiles, apps := testStore(t)
+
+	if _, err := p.Push(dotfiles, apps[:1]); err == nil || !strings.Contains(err.Error(), "unknown app") {
+		t.Errorf("Expected remote error, got %v", err)
+	}
+}
+
+func TestPush_SSHFailure(t *testing.T) {
+	p := New("host")
+	p.run = (&fakeRemote{err: errors.New("connection refused")}).run
+	dotfiles, apps := testStore(t)
+
+	if _, err := p.Push(dotfiles, apps); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected ssh error, got %v", err)
+	}
+	if _, err := p.Push(dotfiles, nil); err == nil {
+		t.Error("Expected error when no apps are given")
+	}
+}
+
+func TestPush_FailedUploadKeepsRemote(t *testing.T) {
+	fake := &fakeRemote{failOn: "scp"}
+	p := New("bob@laptop")
+	p.run = fake.run
+	dotfiles, apps := testStore(t)
+
+	if _, err := p.Push(dotfiles, apps[:1]); err == nil || !strings.Contains(err.Error(), "lost connection") {
+		t.Fatalf("Expected the upload error, got %v", err)
+	}
+	for _, call := range fake.calls {
+		if strings.Contains(call, "mv ") || strings.Contains(call, "rm -rf '/home/bob/dotfiles/.zshrc'") {
+			t.Errorf("A failed upload should not touch the remote copy, got %q", call)
+		}
+	}
+	if last := fake.calls[len(fake.calls)-1]; last != "ssh bob@laptop rm -rf '/home/bob/dotfiles/.dotsync-upload-.zshrc'" {
+		t.Errorf("Expected the partial upload cleaned up, got %q", last)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("/a b/it's"); got != `'/a b/it'\''s'` {
+		t.Errorf("shellQuote = %s", got)
+	}
+}
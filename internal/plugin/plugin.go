@@ -0,0 +1,271 @@
+// Package plugin runs third-party extensions: executables in the plugins
+// directory that speak JSON over stdio. Each plugin is invoked once per
+// hook with the hook name as its only argument and a Request on stdin, and
+// answers with a Response on stdout. Plugins can add app detectors, export
+// extra data into the dotfiles repo on push (e.g. a dconf dump) and run
+// actions after a sync, without forking the built-in definitions.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dotsync/internal/models"
+)
+
+// DirName is the directory in the config dir holding plugin executables
+const DirName = "plugins"
+
+// ExportDirName is the dotfiles directory holding plugin exports
+const ExportDirName = "_plugins"
+
+// DefaultTimeout is the maximum time a plugin may take to answer one hook
+const DefaultTimeout = 30 * time.Second
+
+// Hook names passed to plugins
+const (
+	HookDetect   = "detect"    // Return extra app definitions
+	HookExport   = "export"    // Write extra files into Request.Dir on push
+	HookPostSync = "post-sync" // React to a finished push or pull
+)
+
+// Request is written as JSON to a plugin's stdin
+type Request struct {
+	Hook         string   `json:"hook"`
+	Home         string   `json:"home"`
+	DotfilesPath string   `json:"dotfiles_path,omitempty"`
+	Dir          string   `json:"dir,omitempty"`    // Export: where the plugin writes its files
+	Action       string   `json:"action,omitempty"` // Post-sync: push or pull
+	Apps         []string `json:"apps,omitempty"`   // Post-sync: IDs of the apps synced
+}
+
+// App is an app definition contributed by a detect hook
+type App struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Category       string   `json:"category,omitempty"`
+	Icon           string   `json:"icon,omitempty"`
+	ConfigPaths    []string `json:"config_paths"`
+	EncryptedFiles []string `json:"encrypted_files,omitempty"`
+}
+
+// Response is read as JSON from a plugin's stdout. Empty output means the
+// plugin has nothing to do for the hook.
+type Response struct {
+	Apps    []App    `json:"apps,omitempty"`    // Detect
+	Files   []string `json:"files,omitempty"`   // Export: files written, relative to Dir
+	Message string   `json:"message,omitempty"` // Shown in summaries
+	Error   string   `json:"error,omitempty"`
+}
+
+// Plugin is one executable in the plugins directory
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Result holds the outcome of one plugin for one hook
+type Result struct {
+	Plugin   Plugin
+	Hook     string
+	Response Response
+	Error    error
+}
+
+// Dir returns the plugins directory inside configDir
+func Dir(configDir string) string {
+	return filepath.Join(configDir, DirName)
+}
+
+// Discover lists the executable files in dir, sorted by name. A missing
+// directory means no plugins.
+func Discover(dir string) ([]Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var plugins []Plugin
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		plugins = append(plugins, Plugin{Name: name, Path: path})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// Runner calls hooks on a set of plugins
+type Runner struct {
+	Plugins []Plugin
+	Home    string
+	timeout time.Duration
+}
+
+// NewRunner creates a runner for the plugins in configDir
+func NewRunner(configDir string) (*Runner, error) {
+	plugins, err := Discover(Dir(configDir))
+	home, _ := os.UserHomeDir()
+	return &Runner{Plugins: plugins, Home: home, timeout: DefaultTimeout}, err
+}
+
+// WithTimeout sets the per-plugin timeout
+func (r *Runner) WithTimeout(d time.Duration) *Runner {
+	r.timeout = d
+	return r
+}
+
+// Call runs one hook on one plugin
+func (r *Runner) Call(ctx context.Context, p Plugin, req Request) (Response, error) {
+	var resp Response
+	req.Home = r.Home
+	input, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.Path, req.Hook)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = time.Second // Don't hang on children holding stdout open
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return resp, fmt.Errorf("%s: timed out after %v", p.Name, r.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return resp, fmt.Errorf("%s: %w: %s", p.Name, err, msg)
+		}
+		return resp, fmt.Errorf("%s: %w", p.Name, err)
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return resp, nil
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return resp, fmt.Errorf("%s: invalid response: %w", p.Name, err)
+	}
+	if resp.Error != "" {
+		return resp, fmt.Errorf("%s: %s", p.Name, resp.Error)
+	}
+	return resp, nil
+}
+
+// run calls a hook on every plugin in order; build fills in the request
+// for each plugin
+func (r *Runner) run(ctx context.Context, hook string, build func(Plugin) Request) []Result {
+	var results []Result
+	for _, p := range r.Plugins {
+		req := build(p)
+		req.Hook = hook
+		resp, err := r.Call(ctx, p, req)
+		results = append(results, Result{Plugin: p, Hook: hook, Response: resp, Error: err})
+	}
+	return results
+}
+
+// Detect asks every plugin for extra app definitions. Definitions without an
+// ID or config paths are dropped; plugins default the category to "other".
+func (r *Runner) Detect(ctx context.Context) ([]models.AppDefinition, []Result) {
+	results := r.run(ctx, HookDetect, func(Plugin) Request { return Request{} })
+	var defs []models.AppDefinition
+	for _, res := range results {
+		for _, a := range res.Response.Apps {
+			if a.ID == "" || len(a.ConfigPaths) == 0 {
+				continue
+			}
+			def := models.AppDefinition{
+				ID:             a.ID,
+				Name:           a.Name,
+				Category:       a.Category,
+				Icon:           a.Icon,
+				ConfigPaths:    a.ConfigPaths,
+				EncryptedFiles: a.EncryptedFiles,
+			}
+			if def.Name == "" {
+				def.Name = a.ID
+			}
+			if def.Category == "" {
+				def.Category = "other"
+			}
+			defs = append(defs, def)
+		}
+	}
+	return defs, results
+}
+
+// Export lets every plugin write its files into its own directory of the
+// dotfiles repo, <dotfiles>/_plugins/<name>
+func (r *Runner) Export(ctx context.Context, dotfilesPath string) []Result {
+	return r.run(ctx, HookExport, func(p Plugin) Request {
+		dir := ExportDir(dotfilesPath, p.Name)
+		_ = os.MkdirAll(dir, 0755)
+		return Request{DotfilesPath: dotfilesPath, Dir: dir}
+	})
+}
+
+// PostSync tells every plugin that a push or pull of apps finished
+func (r *Runner) PostSync(ctx context.Context, dotfilesPath, action string, apps []string) []Result {
+	return r.run(ctx, HookPostSync, func(Plugin) Request {
+		return Request{DotfilesPath: dotfilesPath, Action: action, Apps: apps}
+	})
+}
+
+// ExportDir is where a plugin's export hook writes inside the dotfiles repo
+func ExportDir(dotfilesPath, name string) string {
+	return filepath.Join(dotfilesPath, ExportDirName, name)
+}
+
+// Errors joins the errors of failed results, or returns nil
+func Errors(results []Result) error {
+	var errs []error
+	for _, res := range results {
+		if res.Error != nil {
+			errs = append(errs, res.Error)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Summary returns a short summary of plugin results for the status bar, or
+// "" when no plugin did anything
+func Summary(results []Result) string {
+	ran, failed := 0, 0
+	for _, res := range results {
+		if res.Error != nil {
+			failed++
+			continue
+		}
+		if len(res.Response.Files) > 0 || len(res.Response.Apps) > 0 || res.Response.Message != "" {
+			ran++
+		}
+	}
+	switch {
+	case failed > 0:
+		return fmt.Sprintf("%d plugin(s) ran, %d failed", ran, failed)
+	case ran > 0:
+		return fmt.Sprintf("%d plugin(s) ran", ran)
+	}
+	return ""
+}
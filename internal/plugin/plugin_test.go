@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "zeta.sh", "true\n")
+	writePlugin(t, dir, "alpha", "true\n")
+	writePlugin(t, dir, ".hidden", "true\n")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a plugin"), 0644)
+	os.MkdirAll(filepath.Join(dir, "subdir"), 0755)
+
+	plugins, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(plugins) != 2 || plugins[0].Name != "alpha" || plugins[1].Name != "zeta" {
+		t.Errorf("Expected alpha and zeta, got %+v", plugins)
+	}
+
+	if plugins, err := Discover(filepath.Join(dir, "missing")); err != nil || plugins != nil {
+		t.Errorf("Expected no plugins for a missing dir, got %+v, %v", plugins, err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	configDir := t.TempDir()
+	writePlugin(t, Dir(configDir), "gnome", `[ "$1" = detect ] || exit 0
+cat >/dev/null
+echo '{"apps":[{"id":"dconf","config_paths":["~/.config/dconf/user"]},{"id":"broken"}]}'
+`)
+	writePlugin(t, Dir(configDir), "silent", "cat >/dev/null\n")
+
+	runner, err := NewRunner(configDir)
+	if err != nil {
+		t.Fatalf("NewRunner failed: %v", err)
+	}
+	defs, results := runner.Detect(context.Background())
+	if len(results) != 2 || Errors(results) != nil {
+		t.Fatalf("Expected 2 successful results, got %+v", results)
+	}
+	if len(defs) != 1 {
+		t.Fatalf("Expected the incomplete definition to be dropped, got %+v", defs)
+	}
+	if defs[0].ID != "dconf" || defs[0].Name != "dconf" || defs[0].Category != "other" {
+		t.Errorf("Expected defaults filled in, got %+v", defs[0])
+	}
+}
+
+func TestExportAndPostSyncRequests(t *testing.T) {
+	configDir := t.TempDir()
+	dotfiles := t.TempDir()
+	out := filepath.Join(t.TempDir(), "requests")
+	writePlugin(t, Dir(configDir), "recorder", `cat >>`+out+`
+echo >>`+out+`
+if [ "$1" = export ]; then echo '{"files":["dump.ini"]}'; fi
+`)
+
+	runner, _ := NewRunner(configDir)
+	results := runner.Export(context.Background(), dotfiles)
+	if len(results) != 1 || len(results[0].Response.Files) != 1 {
+		t.Fatalf("Expected one export with a file, got %+v", results)
+	}
+	if info, err := os.Stat(ExportDir(dotfiles, "recorder")); err != nil || !info.IsDir() {
+		t.Errorf("Expected the export dir to be created, got %v", err)
+	}
+	runner.PostSync(context.Background(), dotfiles, "pull", []string{"zsh", "git"})
+
+	data, _ := os.ReadFile(out)
+	got := string(data)
+	if !strings.Contains(got, `"hook":"export"`) || !strings.Contains(got, `"dir":"`+ExportDir(dotfiles, "recorder")+`"`) {
+		t.Errorf("Expected the export request with its dir, got %s", got)
+	}
+	if !strings.Contains(got, `"hook":"post-sync"`) || !strings.Contains(got, `"action":"pull","apps":["zsh","git"]`) {
+		t.Errorf("Expected the post-sync request with the apps, got %s", got)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "fails", "echo boom >&2; exit 1\n")
+	writePlugin(t, dir, "reports", `echo '{"error":"no dconf"}'`+"\n")
+	writePlugin(t, dir, "garbage", "echo not json\n")
+	writePlugin(t, dir, "slow", "sleep 5\n")
+
+	plugins, _ := Discover(dir)
+	runner := &Runner{Plugins: plugins, timeout: 200 * time.Millisecond}
+	want := map[string]string{
+		"fails":   "boom",
+		"reports": "no dconf",
+		"garbage": "invalid response",
+		"slow":    "timed out",
+	}
+	for _, p := range plugins {
+		_, err := runner.Call(context.Background(), p, Request{Hook: HookPostSync})
+		if err == nil || !strings.Contains(err.Error(), want[p.Name]) {
+			t.Errorf("%s: expected error containing %q, got %v", p.Name, want[p.Name], err)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	if got := Summary([]Result{{}}); got != "" {
+		t.Errorf("Expected no summary when nothing ran, got %q", got)
+	}
+	results := []Result{
+		{Response: Response{Files: []string{"a"}}},
+		{Response: Response{Message: "reloaded"}},
+		{Error: os.ErrNotExist},
+	}
+	if got := Summary(results); got != "2 plugin(s) ran, 1 failed" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+}
@@ -0,0 +1,101 @@
+// Package policy decides how files changed both locally and in dotfiles
+// since the last sync are resolved without asking.
+package policy
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Policy is a conflict resolution policy
+type Policy string
+
+const (
+	AlwaysAsk    Policy = "always-ask"    // Leave the conflict for diff/merge (default)
+	PreferLocal  Policy = "prefer-local"  // Keep the local file
+	PreferRemote Policy = "prefer-remote" // Take the dotfiles copy
+	PreferNewest Policy = "prefer-newest" // Keep whichever side was modified last
+)
+
+// Policies lists the policies in cycle order
+var Policies = []Policy{AlwaysAsk, PreferLocal, PreferRemote, PreferNewest}
+
+// Parse returns the policy named s, or AlwaysAsk
+func Parse(s string) Policy {
+	for _, p := range Policies {
+		if string(p) == s {
+			return p
+		}
+	}
+	return AlwaysAsk
+}
+
+// Next returns the policy after p, wrapping around
+func (p Policy) Next() Policy {
+	for i, candidate := range Policies {
+		if candidate == p {
+			return Policies[(i+1)%len(Policies)]
+		}
+	}
+	return AlwaysAsk
+}
+
+// Side is the version a policy keeps
+type Side int
+
+const (
+	SideAsk    Side = iota // No automatic choice
+	SideLocal              // Keep the local file
+	SideRemote             // Take the dotfiles copy
+)
+
+// Resolve picks a side for a conflicted file. PreferNewest compares
+// modification times and asks when they are equal or unreadable.
+func (p Policy) Resolve(localPath, dotfilesPath string) Side {
+	switch p {
+	case PreferLocal:
+		return SideLocal
+	case PreferRemote:
+		return SideRemote
+	case PreferNewest:
+		local, err := os.Stat(localPath)
+		if err != nil {
+			return SideAsk
+		}
+		remote, err := os.Stat(dotfilesPath)
+		if err != nil {
+			return SideAsk
+		}
+		switch {
+		case local.ModTime().After(remote.ModTime()):
+			return SideLocal
+		case remote.ModTime().After(local.ModTime()):
+			return SideRemote
+		}
+	}
+	return SideAsk
+}
+
+// Rules holds the global policy and its per-app and per-file overrides.
+// File keys are "appID/relPath", using the file RelPath.
+type Rules struct {
+	Default Policy            `json:"default,omitempty"`
+	Apps    map[string]Policy `json:"apps,omitempty"`
+	Files   map[string]Policy `json:"files,omitempty"`
+}
+
+// For returns the policy for a file: file override, then app, then default
+func (r Rules) For(appID, relPath string) Policy {
+	if p, ok := r.Files[FileKey(appID, relPath)]; ok {
+		return Parse(string(p))
+	}
+	if p, ok := r.Apps[appID]; ok {
+		return Parse(string(p))
+	}
+	return Parse(string(r.Default))
+}
+
+// FileKey returns the key used for per-file overrides
+func FileKey(appID, relPath string) string {
+	return appID + "/" + filepath.ToSlash(relPath)
+}
@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAndNext(t *testing.T) {
+	if got := Parse("prefer-newest"); got != PreferNewest {
+		t.Errorf("Parse(prefer-newest) = %s", got)
+	}
+	if got := Parse("bogus"); got != AlwaysAsk {
+		t.Errorf("Parse(bogus) = %s, want always-ask", got)
+	}
+
+	p := AlwaysAsk
+	for range Policies {
+		p = p.Next()
+	}
+	if p != AlwaysAsk {
+		t.Errorf("Expected cycle back to always-ask, got %s", p)
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	rules := Rules{
+		Default: PreferRemote,
+		Apps:    map[string]Policy{"nvim": PreferLocal},
+		Files:   map[string]Policy{"nvim/nvim/lazy-lock.json": PreferNewest},
+	}
+
+	tests := []struct {
+		appID   string
+		relPath string
+		want    Policy
+	}{
+		{"nvim", "nvim/lazy-lock.json", PreferNewest},
+		{"nvim", "nvim/init.lua", PreferLocal},
+		{"zsh", ".zshrc", PreferRemote},
+	}
+
+	for _, tt := range tests {
+		if got := rules.For(tt.appID, tt.relPath); got != tt.want {
+			t.Errorf("For(%s, %s) = %s, want %s", tt.appID, tt.relPath, got, tt.want)
+		}
+	}
+
+	if got := (Rules{}).For("zsh", ".zshrc"); got != AlwaysAsk {
+		t.Errorf("Empty rules should ask, got %s", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	remote := filepath.Join(dir, "remote")
+	os.WriteFile(local, []byte("a"), 0644)
+	os.WriteFile(remote, []byte("b"), 0644)
+
+	now := time.Now()
+	os.Chtimes(local, now, now)
+	os.Chtimes(remote, now.Add(-time.Hour), now.Add(-time.Hour))
+
+	tests := []struct {
+		policy Policy
+		want   Side
+	}{
+		{AlwaysAsk, SideAsk},
+		{PreferLocal, SideLocal},
+		{PreferRemote, SideRemote},
+		{PreferNewest, SideLocal},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Resolve(local, remote); got != tt.want {
+			t.Errorf("%s.Resolve() = %d, want %d", tt.policy, got, tt.want)
+		}
+	}
+
+	os.Chtimes(remote, now, now)
+	if got := PreferNewest.Resolve(local, remote); got != SideAsk {
+		t.Errorf("Equal mtimes should ask, got %d", got)
+	}
+	if got := PreferNewest.Resolve(local, filepath.Join(dir, "missing")); got != SideAsk {
+		t.Errorf("Missing file should ask, got %d", got)
+	}
+}
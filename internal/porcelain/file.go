@@ -0,0 +1,156 @@
+package porcelain
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dotsync/internal/config"
+	"dotsync/internal/models"
+	"dotsync/internal/scanner"
+	"dotsync/internal/sync"
+)
+
+// FileReport describes the sync state of a single config file, for editor
+// plugins that act on the buffer being edited
+type FileReport struct {
+	Path         string `json:"path"`
+	AppID        string `json:"app_id"`
+	RelPath      string `json:"rel_path"`
+	DotfilesPath string `json:"dotfiles_path"`
+	Status       string `json:"status"`
+	Conflict     string `json:"conflict"`
+	Excluded     bool   `json:"excluded,omitempty"` // Filtered out by subtree rules
+}
+
+// DiffReport is a line diff between the local file and its dotfiles copy
+type DiffReport struct {
+	FileReport
+	Identical bool       `json:"identical"`
+	Added     int        `json:"added"`
+	Removed   int        `json:"removed"`
+	Hunks     []HunkInfo `json:"hunks"`
+}
+
+// HunkInfo is one hunk; lines are prefixed with "+", "-" or " "
+type HunkInfo struct {
+	StartOld int      `json:"start_old"`
+	StartNew int      `json:"start_new"`
+	Lines    []string `json:"lines"`
+}
+
+// ResolveFile maps a local path to its app and reports its sync state
+func ResolveFile(cfg *config.Config, s *scanner.Scanner, stateManager *sync.StateManager, path string) (*FileReport, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	appID, relPath, ok := s.ResolvePath(abs)
+	if !ok {
+		return nil, fmt.Errorf("%s is not part of any known app config", path)
+	}
+
+	report := &FileReport{
+		Path:         abs,
+		AppID:        appID,
+		RelPath:      relPath,
+		DotfilesPath: sync.DotfilePath(cfg.DotfilesPath, appID, models.File{RelPath: relPath, Path: abs}),
+		Excluded:     !cfg.SubtreeRules[appID].Allows(relPath),
+	}
+
+	// Content decides when both sides exist; mtimes are only a fallback
+	status := sync.CompareFiles(abs, report.DotfilesPath)
+	conflict := models.ConflictNone
+	localHash, localErr := sync.ComputeFileHash(abs)
+	dotfilesHash, dotfilesErr := sync.ComputeFileHash(report.DotfilesPath)
+	if localErr == nil && dotfilesErr == nil {
+		if localHash == dotfilesHash {
+			status = models.StatusSynced
+		} else if stateManager != nil {
+			conflict = stateManager.DetectConflict(appID, relPath, localHash, dotfilesHash)
+			switch conflict {
+			case models.ConflictNone:
+				// Unchanged since the last sync, which filtered the copy
+				status = models.StatusSynced
+			case models.ConflictLocalModified:
+				status = models.StatusModified
+			case models.ConflictDotfilesModified:
+				status = models.StatusOutdated
+			}
+		}
+	}
+
+	report.Status = status.String()
+	report.Conflict = conflict.ConflictString()
+	return report, nil
+}
+
+// PushFile exports the local file into the dotfiles store, through the same
+// filters as a push from the TUI, and records the sync
+func PushFile(cfg *config.Config, stateManager *sync.StateManager, report *FileReport) error {
+	if report.Excluded {
+		return fmt.Errorf("%s is excluded by subtree rules", report.RelPath)
+	}
+	info, err := os.Stat(report.Path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory; push it from the TUI", report.Path)
+	}
+
+	if err := sync.NewExporter(cfg).ExportFile(report.AppID, report.RelPath, report.Path, report.DotfilesPath); err != nil {
+		return err
+	}
+	sync.GetHashCache().InvalidatePath(report.DotfilesPath)
+
+	if stateManager != nil {
+		// Filters can leave the copy different from the local file
+		localHash, err := sync.ComputeFileHash(report.Path)
+		dotfilesHash, err2 := sync.ComputeFileHash(report.DotfilesPath)
+		if err == nil && err2 == nil {
+			stateManager.SetFileState(report.AppID, report.RelPath, localHash, dotfilesHash)
+			stateManager.RecordPush(report.AppID, report.RelPath)
+			if err := stateManager.Save(); err != nil {
+				return fmt.Errorf("save sync state: %w", err)
+			}
+		}
+	}
+
+	report.Status = models.StatusSynced.String()
+	report.Conflict = models.ConflictNone.ConflictString()
+	return nil
+}
+
+// DiffFile diffs the local file (old) against its dotfiles copy (new),
+// matching the orientation of the TUI diff view
+func DiffFile(report *FileReport) (*DiffReport, error) {
+	result, err := sync.ComputeDiff(report.Path, report.DotfilesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &DiffReport{
+		FileReport: *report,
+		Identical:  result.Identical,
+		Added:      result.LinesAdded,
+		Removed:    result.LinesRemoved,
+		Hunks:      []HunkInfo{},
+	}
+	for _, h := range result.Hunks {
+		hunk := HunkInfo{StartOld: h.StartOld, StartNew: h.StartNew}
+		for _, line := range h.DiffLines {
+			prefix := " "
+			switch line.Type {
+			case sync.DiffInsert:
+				prefix = "+"
+			case sync.DiffDelete:
+				prefix = "-"
+			}
+			hunk.Lines = append(hunk.Lines, prefix+line.Content)
+		}
+		diff.Hunks = append(diff.Hunks, hunk)
+	}
+	return diff, nil
+}
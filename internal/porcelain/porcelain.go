@@ -0,0 +1,417 @@
+// Package porcelain drives the sync engine over newline-delimited JSON so
+// other frontends (editor plugins, extensions) can use dotsync without the TUI.
+// Commands are read one per line from stdin; events are written one per line
+// to stdout.
+package porcelain
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"dotsync/internal/audit"
+	"dotsync/internal/config"
+	"dotsync/internal/layout"
+	"dotsync/internal/lock"
+	"dotsync/internal/models"
+	"dotsync/internal/notify"
+	"dotsync/internal/report"
+	"dotsync/internal/sync"
+)
+
+// Event types
+const (
+	EventReady        = "ready"         // Engine is ready for commands
+	EventInfo         = "info"          // Engine settings, e.g. the dotfiles path
+	EventScanStarted  = "scan_started"  // Scan began
+	EventApp          = "app"           // One scanned app with file statuses
+	EventScanComplete = "scan_complete" // Scan finished; Count is the number of apps
+	EventResult       = "result"        // One file pushed or pulled
+	EventConflict     = "conflict"      // File skipped by pull because both sides changed
+	EventDone         = "done"          // Command finished; Count is successful files
+	EventError        = "error"         // Command failed or was not understood
+)
+
+// Event is a single line written to the output
+type Event struct {
+	Type    string   `json:"type"`
+	Command string   `json:"command,omitempty"`
+	App     *AppInfo `json:"app,omitempty"`
+	AppID   string   `json:"app_id,omitempty"`
+	File    string   `json:"file,omitempty"`
+	Success bool     `json:"success,omitempty"`
+	Message string   `json:"message,omitempty"`
+	Count   int      `json:"count,omitempty"`
+
+	DotfilesPath string `json:"dotfiles_path,omitempty"` // EventInfo only
+	Policy       string `json:"policy,omitempty"`        // EventResult: conflict policy that decided the file
+}
+
+// AppInfo describes a scanned app
+type AppInfo struct {
+	ID    string     `json:"id"`
+	Name  string     `json:"name"`
+	Files []FileInfo `json:"files"`
+}
+
+// FileInfo describes one file of an app
+type FileInfo struct {
+	RelPath      string `json:"rel_path"`
+	Path         string `json:"path"`
+	DotfilesPath string `json:"dotfiles_path"` // Where the repo's layout keeps it
+	IsDir        bool   `json:"is_dir,omitempty"`
+	Status       string `json:"status"`
+	Conflict     string `json:"conflict"`
+}
+
+// Command is a single line read from the input
+type Command struct {
+	Cmd  string   `json:"cmd"`            // info, scan, status, push, pull, quit
+	Apps []string `json:"apps,omitempty"` // App IDs to act on; empty = all scanned apps
+}
+
+// ScanFunc scans apps and fills in their sync status
+type ScanFunc func() ([]*models.App, error)
+
+// Server executes commands against the sync engine
+type Server struct {
+	config       *config.Config
+	scan         ScanFunc
+	stateManager *sync.StateManager
+	out          *json.Encoder
+	apps         []*models.App
+	audit        *audit.Log
+	lockPath     string // Sync lock taken around push and pull, if set
+	reportPath   string // Report written after each push and pull, if set
+	notifier     *notify.Notifier
+}
+
+// New creates a Server writing events to w
+func New(cfg *config.Config, scan ScanFunc, stateManager *sync.StateManager, w io.Writer) *Server {
+	return &Server{
+		config:       cfg,
+		scan:         scan,
+		stateManager: stateManager,
+		out:          json.NewEncoder(w),
+	}
+}
+
+// WithAudit records every pushed or pulled file in log
+func (s *Server) WithAudit(log *audit.Log) *Server {
+	s.audit = log
+	return s
+}
+
+// WithLock takes the sync lock at path while a push or pull runs, so
+// another dotsync can't sync at the same time
+func (s *Server) WithLock(path string) *Server {
+	s.lockPath = path
+	return s
+}
+
+// WithReport writes a report of each push or pull to path, as JSON when it
+// ends in .json and as markdown otherwise
+func (s *Server) WithReport(path string) *Server {
+	s.reportPath = path
+	return s
+}
+
+// WithNotifier shows a desktop notification when a push or pull fails or
+// pull skips conflicts, for syncs run from cron or launchd
+func (s *Server) WithNotifier(n *notify.Notifier) *Server {
+	s.notifier = n
+	return s
+}
+
+// Run processes commands from r until EOF or a quit command
+func (s *Server) Run(r io.Reader) error {
+	if err := s.emit(Event{Type: EventReady}); err != nil {
+		return err
+	}
+
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" {
+			continue
+		}
+
+		var cmd Command
+		if err := json.Unmarshal([]byte(line), &cmd); err != nil {
+			s.emit(Event{Type: EventError, Message: fmt.Sprintf("invalid command: %v", err)})
+			continue
+		}
+		if cmd.Cmd == "quit" {
+			return nil
+		}
+		if err := s.Handle(cmd); err != nil {
+			return err
+		}
+	}
+	return lines.Err()
+}
+
+// Handle executes one command. The returned error is an output failure;
+// command failures are reported as error events.
+func (s *Server) Handle(cmd Command) error {
+	switch cmd.Cmd {
+	case "info":
+		return s.emit(Event{Type: EventInfo, Command: cmd.Cmd, DotfilesPath: s.config.DotfilesPath})
+	case "scan":
+		return s.handleScan()
+	case "status":
+		if s.apps == nil {
+			return s.handleScan()
+		}
+		return s.emitApps()
+	case "push", "pull":
+		if err := s.config.CheckWritable(); err != nil {
+			return s.emit(Event{Type: EventError, Command: cmd.Cmd, Message: err.Error()})
+		}
+		if s.lockPath != "" {
+			l, err := lock.Acquire(s.lockPath, cmd.Cmd)
+			if err != nil {
+				return s.emit(Event{Type: EventError, Command: cmd.Cmd, Message: err.Error()})
+			}
+			defer l.Release()
+		}
+		if cmd.Cmd == "push" {
+			return s.handlePush(cmd)
+		}
+		return s.handlePull(cmd)
+	default:
+		return s.emit(Event{Type: EventError, Command: cmd.Cmd, Message: "unknown command"})
+	}
+}
+
+func (s *Server) handleScan() error {
+	if err := s.emit(Event{Type: EventScanStarted, Command: "scan"}); err != nil {
+		return err
+	}
+
+	apps, err := s.scan()
+	if err != nil {
+		return s.emit(Event{Type: EventError, Command: "scan", Message: err.Error()})
+	}
+	s.apps = apps
+
+	if err := s.emitApps(); err != nil {
+		return err
+	}
+	return s.emit(Event{Type: EventScanComplete, Command: "scan", Count: len(apps)})
+}
+
+func (s *Server) emitApps() error {
+	lay := layout.For(s.config.DotfilesPath)
+	for _, app := range s.apps {
+		if err := s.emit(Event{Type: EventApp, App: appInfo(app, lay, s.config.DotfilesPath)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) handlePush(cmd Command) error {
+	if err := s.selectApps(cmd); err != nil {
+		return s.emit(Event{Type: EventError, Command: cmd.Cmd, Message: err.Error()})
+	}
+
+	results, err := sync.NewExporter(s.config).ExportAll(s.apps)
+	entries := make([]audit.Entry, 0, len(results))
+	success := 0
+	for _, r := range results {
+		if r.Success {
+			success++
+			s.recordSync(audit.ActionPush, r.App.ID, r.File, r.File.LocalHash)
+		}
+		entry := auditEntry(audit.ActionPush, r.App.ID, r.File, r.Error)
+		if !r.Success && r.Error == nil {
+			entry.Result = audit.ResultSkipped
+		}
+		entries = append(entries, entry)
+		if err := s.emit(resultEvent(cmd.Cmd, r.App.ID, r.File.RelPath, r.Success, r.Error)); err != nil {
+			return err
+		}
+	}
+	s.logAudit(entries)
+	if err := s.writeReport(cmd.Cmd, entries, err); err != nil {
+		return err
+	}
+	s.notifyProblems(cmd.Cmd, entries, err)
+	return s.finish(cmd.Cmd, success, err)
+}
+
+func (s *Server) handlePull(cmd Command) error {
+	if err := s.selectApps(cmd); err != nil {
+		return s.emit(Event{Type: EventError, Command: cmd.Cmd, Message: err.Error()})
+	}
+
+	results, err := sync.NewImporter(s.config).WithStateManager(s.stateManager).ImportAll(s.apps)
+	entries := make([]audit.Entry, 0, len(results))
+	success := 0
+	for _, r := range results {
+		ev := resultEvent(cmd.Cmd, r.App.ID, r.File.RelPath, r.Success, r.Error)
+		if r.Conflict {
+			ev.Type = EventConflict
+		}
+		ev.Policy = string(r.Policy)
+		if r.Success {
+			success++
+			s.recordSync(audit.ActionPull, r.App.ID, r.File, r.File.DotfilesHash)
+		}
+		entry := auditEntry(audit.ActionPull, r.App.ID, r.File, r.Error)
+		entry.Detail = string(r.Policy)
+		if r.Conflict {
+			entry.Result = audit.ResultConflict
+		} else if !r.Success && r.Error == nil {
+			entry.Result = audit.ResultSkipped
+		}
+		entries = append(entries, entry)
+		if err := s.emit(ev); err != nil {
+			return err
+		}
+	}
+	s.logAudit(entries)
+	if err := s.writeReport(cmd.Cmd, entries, err); err != nil {
+		return err
+	}
+	s.notifyProblems(cmd.Cmd, entries, err)
+	return s.finish(cmd.Cmd, success, err)
+}
+
+// finish saves sync state and emits the final event of a command
+func (s *Server) finish(command string, success int, err error) error {
+	if s.stateManager != nil {
+		_ = s.stateManager.Save()
+	}
+	if err != nil {
+		return s.emit(Event{Type: EventError, Command: command, Message: err.Error()})
+	}
+	return s.emit(Event{Type: EventDone, Command: command, Count: success})
+}
+
+// logAudit appends entries to the audit log, if one is attached. A failed
+// write is not a command failure.
+func (s *Server) logAudit(entries []audit.Entry) {
+	if s.audit != nil {
+		_ = s.audit.Append(entries...)
+	}
+}
+
+// writeReport writes the report of a push or pull, if a report path is
+// set. A failed write is reported as an error event.
+func (s *Server) writeReport(command string, entries []audit.Entry, syncErr error) error {
+	if s.reportPath == "" {
+		return nil
+	}
+	if err := report.NewSyncReport(command, "", entries, syncErr).WriteFile(s.reportPath); err != nil {
+		return s.emit(Event{Type: EventError, Command: command, Message: fmt.Sprintf("write report: %v", err)})
+	}
+	return nil
+}
+
+// notifyProblems sends a notification about failed files and conflicts
+func (s *Server) notifyProblems(command string, entries []audit.Entry, syncErr error) {
+	failed, conflicts := 0, 0
+	for _, e := range entries {
+		switch e.Result {
+		case audit.ResultFailed:
+			failed++
+		case audit.ResultConflict:
+			conflicts++
+		}
+	}
+	switch {
+	case syncErr != nil:
+		_ = s.notifier.Notify("dotsync: "+command+" failed", syncErr.Error())
+	case failed > 0:
+		_ = s.notifier.Notify("dotsync: "+command+" failed", fmt.Sprintf("%d files could not be synced", failed))
+	case conflicts > 0:
+		_ = s.notifier.Notify("dotsync: conflicts detected", fmt.Sprintf("%d files changed on both sides were skipped", conflicts))
+	}
+}
+
+// recordSync stores the synced hash for both sides and the time of the
+// push or pull, like the TUI does
+func (s *Server) recordSync(action, appID string, file models.File, hash string) {
+	if s.stateManager == nil || hash == "" {
+		return
+	}
+	s.stateManager.SetFileState(appID, file.RelPath, hash, hash)
+	if action == audit.ActionPush {
+		s.stateManager.RecordPush(appID, file.RelPath)
+	} else {
+		s.stateManager.RecordPull(appID, file.RelPath)
+	}
+}
+
+// selectApps marks the apps named in cmd (or all apps) as selected,
+// scanning first if needed
+func (s *Server) selectApps(cmd Command) error {
+	if s.apps == nil {
+		apps, err := s.scan()
+		if err != nil {
+			return err
+		}
+		s.apps = apps
+	}
+
+	wanted := make(map[string]bool)
+	for _, id := range cmd.Apps {
+		wanted[id] = true
+	}
+
+	found := 0
+	for _, app := range s.apps {
+		app.Selected = len(wanted) == 0 || wanted[app.ID]
+		if app.Selected {
+			found++
+		}
+	}
+	if len(wanted) > 0 && found < len(wanted) {
+		return fmt.Errorf("unknown app in %v", cmd.Apps)
+	}
+	return nil
+}
+
+func (s *Server) emit(ev Event) error {
+	return s.out.Encode(ev)
+}
+
+func resultEvent(command, appID, relPath string, success bool, err error) Event {
+	ev := Event{Type: EventResult, Command: command, AppID: appID, File: relPath, Success: success}
+	if err != nil {
+		ev.Message = err.Error()
+	}
+	return ev
+}
+
+func auditEntry(action, appID string, file models.File, err error) audit.Entry {
+	result, message := audit.ResultFor(err)
+	return audit.Entry{
+		Action:       action,
+		AppID:        appID,
+		File:         file.RelPath,
+		LocalHash:    file.LocalHash,
+		DotfilesHash: file.DotfilesHash,
+		Result:       result,
+		Error:        message,
+	}
+}
+
+func appInfo(app *models.App, lay layout.Layout, dotfilesPath string) *AppInfo {
+	info := &AppInfo{ID: app.ID, Name: app.Name, Files: []FileInfo{}}
+	for _, f := range app.Files {
+		info.Files = append(info.Files, FileInfo{
+			RelPath:      f.RelPath,
+			Path:         f.Path,
+			DotfilesPath: layout.Find(lay, dotfilesPath, app.ID, f.RelPath, f.Path),
+			IsDir:        f.IsDir,
+			Status:       f.SyncStatus.String(),
+			Conflict:     f.ConflictType.ConflictString(),
+		})
+	}
+	return info
+}
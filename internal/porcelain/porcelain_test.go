@@ -0,0 +1,412 @@
+package porcelain
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/audit"
+	"dotsync/internal/config"
+	"dotsync/internal/layout"
+	"dotsync/internal/lock"
+	"dotsync/internal/models"
+	"dotsync/internal/notify"
+	"dotsync/internal/report"
+	"dotsync/internal/scanner"
+	"dotsync/internal/sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decodeEvents(t *testing.T, out *bytes.Buffer) []Event {
+	t.Helper()
+	var events []Event
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("invalid event line %q: %v", line, err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func newTestServer(t *testing.T, out *bytes.Buffer) (*Server, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	localPath := filepath.Join(tempDir, "local", "app.conf")
+	os.MkdirAll(filepath.Dir(localPath), 0755)
+	os.WriteFile(localPath, []byte("local"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+
+	scan := func() ([]*models.App, error) {
+		return []*models.App{{
+			ID:   "test",
+			Name: "Test",
+			Files: []models.File{
+				{Name: "app.conf", Path: localPath, RelPath: "app.conf", Selected: true, SyncStatus: models.StatusModified},
+			},
+		}}, nil
+	}
+
+	return New(cfg, scan, sync.NewStateManager(tempDir), out), cfg.DotfilesPath
+}
+
+func TestRun_ScanAndPush(t *testing.T) {
+	var out bytes.Buffer
+	server, dotfiles := newTestServer(t, &out)
+
+	input := `{"cmd":"scan"}
+{"cmd":"push","apps":["test"]}
+{"cmd":"quit"}
+{"cmd":"scan"}
+`
+	if err := server.Run(strings.NewReader(input)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	events := decodeEvents(t, &out)
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	want := []string{EventReady, EventScanStarted, EventApp, EventScanComplete, EventResult, EventDone}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("Event types = %v, want %v", types, want)
+	}
+
+	app := events[2].App
+	if app == nil || app.ID != "test" || len(app.Files) != 1 || app.Files[0].Status != "Modified" {
+		t.Errorf("Unexpected app event: %+v", app)
+	}
+	if app != nil && len(app.Files) == 1 && app.Files[0].DotfilesPath != filepath.Join(dotfiles, "test", "app.conf") {
+		t.Errorf("Expected the file's dotfiles path reported, got %q", app.Files[0].DotfilesPath)
+	}
+	if !events[4].Success || events[4].AppID != "test" || events[4].File != "app.conf" {
+		t.Errorf("Unexpected result event: %+v", events[4])
+	}
+	if events[5].Count != 1 {
+		t.Errorf("Expected 1 pushed file, got %d", events[5].Count)
+	}
+
+	if _, err := os.Stat(filepath.Join(dotfiles, "test", "app.conf")); err != nil {
+		t.Errorf("Push should export the file: %v", err)
+	}
+}
+
+func TestRun_PushWritesAuditLog(t *testing.T) {
+	var out bytes.Buffer
+	server, _ := newTestServer(t, &out)
+	log := audit.New(filepath.Join(t.TempDir(), audit.FileName))
+	server.WithAudit(log)
+
+	if err := server.Handle(Command{Cmd: "push", Apps: []string{"test"}}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+
+	entries, err := log.Read(audit.Filter{})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != audit.ActionPush || entries[0].File != "app.conf" || entries[0].Result != audit.ResultOK {
+		t.Errorf("Unexpected audit entries: %+v", entries)
+	}
+}
+
+func TestHandle_PushWritesReport(t *testing.T) {
+	var out bytes.Buffer
+	server, _ := newTestServer(t, &out)
+	path := filepath.Join(t.TempDir(), "reports", "push.json")
+	server.WithReport(path)
+
+	if err := server.Handle(Command{Cmd: "push", Apps: []string{"test"}}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var r report.SyncReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if r.Action != "push" || len(r.Files) != 1 || r.Files[0].RelPath != "app.conf" || r.Files[0].Result != audit.ResultOK {
+		t.Errorf("Unexpected report: %+v", r)
+	}
+}
+
+func TestHandle_NotifiesFailures(t *testing.T) {
+	var out bytes.Buffer
+	server, dotfiles := newTestServer(t, &out)
+	var sent []string
+	n := notify.New(true)
+	n.SetSender(func(title, message string) error {
+		sent = append(sent, title)
+		return nil
+	})
+	server.WithNotifier(n)
+
+	if err := server.Handle(Command{Cmd: "push", Apps: []string{"test"}}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("Expected no notification for a clean push, got %v", sent)
+	}
+
+	// A file where the dotfiles directory should be makes the push fail
+	os.RemoveAll(dotfiles)
+	os.WriteFile(dotfiles, []byte("not a dir"), 0644)
+	if err := server.Handle(Command{Cmd: "push", Apps: []string{"test"}}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "dotsync: push failed" {
+		t.Errorf("Expected a failure notification, got %v", sent)
+	}
+}
+
+func TestHandle_PushWhileLocked(t *testing.T) {
+	var out bytes.Buffer
+	server, dotfiles := newTestServer(t, &out)
+	lockPath := lock.Path(t.TempDir())
+	server.WithLock(lockPath)
+
+	held, err := lock.Acquire(lockPath, "pull")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if err := server.Handle(Command{Cmd: "push", Apps: []string{"test"}}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	events := decodeEvents(t, &out)
+	if len(events) != 1 || events[0].Type != EventError || !strings.Contains(events[0].Message, "another sync is in progress") {
+		t.Fatalf("Expected a lock error, got %+v", events)
+	}
+	if _, err := os.Stat(filepath.Join(dotfiles, "test", "app.conf")); err == nil {
+		t.Error("Push must not run while the lock is held")
+	}
+
+	held.Release()
+	out.Reset()
+	if err := server.Handle(Command{Cmd: "push", Apps: []string{"test"}}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("Expected the lock to be released after the push")
+	}
+}
+
+func TestHandle_ReadOnly(t *testing.T) {
+	var out bytes.Buffer
+	server, dotfiles := newTestServer(t, &out)
+	server.config.ReadOnly = true
+
+	if err := server.Handle(Command{Cmd: "push", Apps: []string{"test"}}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	events := decodeEvents(t, &out)
+	if len(events) != 1 || events[0].Type != EventError || events[0].Message != config.ErrReadOnly.Error() {
+		t.Fatalf("Expected a read-only error, got %+v", events)
+	}
+	if _, err := os.Stat(filepath.Join(dotfiles, "test", "app.conf")); err == nil {
+		t.Error("Push must not run in read-only mode")
+	}
+}
+
+func TestRun_InvalidAndUnknownCommands(t *testing.T) {
+	var out bytes.Buffer
+	server, _ := newTestServer(t, &out)
+
+	input := "not json\n{\"cmd\":\"explode\"}\n{\"cmd\":\"pull\",\"apps\":[\"missing\"]}\n"
+	if err := server.Run(strings.NewReader(input)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	events := decodeEvents(t, &out)
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d: %+v", len(events), events)
+	}
+	for _, ev := range events[1:] {
+		if ev.Type != EventError {
+			t.Errorf("Expected error event, got %+v", ev)
+		}
+	}
+}
+
+func TestRun_StatusScansOnce(t *testing.T) {
+	var out bytes.Buffer
+	server, _ := newTestServer(t, &out)
+
+	calls := 0
+	scan := server.scan
+	server.scan = func() ([]*models.App, error) {
+		calls++
+		return scan()
+	}
+
+	if err := server.Run(strings.NewReader("{\"cmd\":\"status\"}\n{\"cmd\":\"status\"}\n")); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected status to reuse the first scan, got %d scans", calls)
+	}
+}
+
+func TestHandle_Info(t *testing.T) {
+	var out bytes.Buffer
+	server, dotfiles := newTestServer(t, &out)
+
+	if err := server.Handle(Command{Cmd: "info"}); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+
+	events := decodeEvents(t, &out)
+	if len(events) != 1 || events[0].Type != EventInfo || events[0].DotfilesPath != dotfiles {
+		t.Errorf("Unexpected info events: %+v", events)
+	}
+}
+
+func TestFileCommands(t *testing.T) {
+	tempDir := t.TempDir()
+	appDir := filepath.Join(tempDir, "home", "myeditor")
+	localPath := filepath.Join(appDir, "init.lua")
+	os.MkdirAll(appDir, 0755)
+	os.WriteFile(localPath, []byte("a\nb\n"), 0644)
+
+	defsPath := filepath.Join(tempDir, "apps.yaml")
+	data, _ := yaml.Marshal(models.AppConfig{Apps: []models.AppDefinition{
+		{ID: "myeditor", Name: "My Editor", ConfigPaths: []string{appDir}},
+	}})
+	os.WriteFile(defsPath, data, 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	sm := sync.NewStateManager(tempDir)
+
+	file, err := ResolveFile(cfg, scanner.New(defsPath), sm, localPath)
+	if err != nil {
+		t.Fatalf("ResolveFile failed: %v", err)
+	}
+	if file.AppID != "myeditor" || file.RelPath != filepath.Join("myeditor", "init.lua") {
+		t.Errorf("Unexpected resolution: %+v", file)
+	}
+	if file.Status != models.StatusNew.String() {
+		t.Errorf("Expected New before first push, got %s", file.Status)
+	}
+
+	if err := PushFile(cfg, sm, file); err != nil {
+		t.Fatalf("PushFile failed: %v", err)
+	}
+	if _, ok := sm.GetFileState("myeditor", file.RelPath); !ok {
+		t.Error("PushFile should record sync state")
+	}
+
+	os.WriteFile(localPath, []byte("a\nc\n"), 0644)
+	sync.GetHashCache().InvalidatePath(localPath)
+
+	file, err = ResolveFile(cfg, scanner.New(defsPath), sm, localPath)
+	if err != nil {
+		t.Fatalf("ResolveFile failed: %v", err)
+	}
+	if file.Status != models.StatusModified.String() {
+		t.Errorf("Expected Modified after local edit, got %s", file.Status)
+	}
+
+	diff, err := DiffFile(file)
+	if err != nil {
+		t.Fatalf("DiffFile failed: %v", err)
+	}
+	if diff.Identical || diff.Added == 0 || diff.Removed == 0 || len(diff.Hunks) == 0 {
+		t.Errorf("Expected a diff, got %+v", diff)
+	}
+
+	if _, err := ResolveFile(cfg, scanner.New(defsPath), sm, filepath.Join(tempDir, "elsewhere.txt")); err == nil {
+		t.Error("Expected error for a path outside any app")
+	}
+}
+
+func TestResolveFile_HomeLayout(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	appDir := filepath.Join(tempDir, ".config", "myeditor")
+	localPath := filepath.Join(appDir, "init.lua")
+	os.MkdirAll(appDir, 0755)
+	os.WriteFile(localPath, []byte("a\n"), 0644)
+
+	defsPath := filepath.Join(tempDir, "apps.yaml")
+	data, _ := yaml.Marshal(models.AppConfig{Apps: []models.AppDefinition{
+		{ID: "myeditor", Name: "My Editor", ConfigPaths: []string{appDir}},
+	}})
+	os.WriteFile(defsPath, data, 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	home, _ := layout.New(layout.Home)
+	if err := layout.Save(cfg.DotfilesPath, home); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ResolveFile(cfg, scanner.New(defsPath), nil, localPath)
+	if err != nil {
+		t.Fatalf("ResolveFile failed: %v", err)
+	}
+	want := filepath.Join(cfg.DotfilesPath, ".config", "myeditor", "init.lua")
+	if file.DotfilesPath != want {
+		t.Errorf("DotfilesPath = %s, want %s", file.DotfilesPath, want)
+	}
+	if err := PushFile(cfg, nil, file); err != nil {
+		t.Fatalf("PushFile failed: %v", err)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("Expected the push at the home layout path: %v", err)
+	}
+}
+
+func TestPushFile_StripsLocalSections(t *testing.T) {
+	tempDir := t.TempDir()
+	appDir := filepath.Join(tempDir, "home", "myeditor")
+	localPath := filepath.Join(appDir, "init.lua")
+	os.MkdirAll(appDir, 0755)
+	local := "a\n-- dotsync:local-begin\nsecret\n-- dotsync:local-end\nb\n"
+	os.WriteFile(localPath, []byte(local), 0644)
+
+	defsPath := filepath.Join(tempDir, "apps.yaml")
+	data, _ := yaml.Marshal(models.AppConfig{Apps: []models.AppDefinition{
+		{ID: "myeditor", Name: "My Editor", ConfigPaths: []string{appDir}},
+	}})
+	os.WriteFile(defsPath, data, 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	sm := sync.NewStateManager(tempDir)
+
+	file, err := ResolveFile(cfg, scanner.New(defsPath), sm, localPath)
+	if err != nil {
+		t.Fatalf("ResolveFile failed: %v", err)
+	}
+	if err := PushFile(cfg, sm, file); err != nil {
+		t.Fatalf("PushFile failed: %v", err)
+	}
+
+	pushed, _ := os.ReadFile(file.DotfilesPath)
+	if strings.Contains(string(pushed), "secret") {
+		t.Errorf("PushFile should strip machine-local sections, got %q", pushed)
+	}
+	if got, _ := os.ReadFile(localPath); string(got) != local {
+		t.Errorf("PushFile should leave the local file alone, got %q", got)
+	}
+
+	file, err = ResolveFile(cfg, scanner.New(defsPath), sm, localPath)
+	if err != nil {
+		t.Fatalf("ResolveFile failed: %v", err)
+	}
+	if file.Status != models.StatusSynced.String() {
+		t.Errorf("Expected Synced after the push, got %s", file.Status)
+	}
+}
@@ -0,0 +1,94 @@
+// Package provision holds the pieces of `dotsync provision` that set up a
+// new machine beyond restoring configs, such as system package lists.
+package provision
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Manager is a system package manager a package list can be installed with
+type Manager struct {
+	Name    string   // List file name without .txt, e.g. apt
+	Binary  string   // Binary that must be in $PATH
+	Install []string // Install command; package names are appended
+}
+
+// Managers are the package managers with list support
+var Managers = []Manager{
+	{Name: "apt", Binary: "apt-get", Install: []string{"sudo", "apt-get", "install", "-y"}},
+	{Name: "dnf", Binary: "dnf", Install: []string{"sudo", "dnf", "install", "-y"}},
+	{Name: "pacman", Binary: "pacman", Install: []string{"sudo", "pacman", "-S", "--needed", "--noconfirm"}},
+	{Name: "npm", Binary: "npm", Install: []string{"npm", "install", "-g"}},
+	{Name: "cargo", Binary: "cargo", Install: []string{"cargo", "install"}},
+}
+
+// PackagesDir is where package lists live in the dotfiles repo, one
+// <manager>.txt file per package manager
+func PackagesDir(dotfilesPath string) string {
+	return filepath.Join(dotfilesPath, "packages")
+}
+
+// PackageList is one manager's list of packages
+type PackageList struct {
+	Manager  Manager
+	Path     string
+	Packages []string
+}
+
+// Command returns the command that installs the list
+func (l PackageList) Command() []string {
+	return append(append([]string(nil), l.Manager.Install...), l.Packages...)
+}
+
+// FindPackageLists returns the non-empty package lists in the dotfiles
+// repo, split into lists whose manager is installed here and lists whose
+// manager isn't
+func FindPackageLists(dotfilesPath string) (usable, unavailable []PackageList, err error) {
+	for _, m := range Managers {
+		path := filepath.Join(PackagesDir(dotfilesPath), m.Name+".txt")
+		pkgs, err := ReadPackageList(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(pkgs) == 0 {
+			continue
+		}
+		list := PackageList{Manager: m, Path: path, Packages: pkgs}
+		if _, err := exec.LookPath(m.Binary); err == nil {
+			usable = append(usable, list)
+		} else {
+			unavailable = append(unavailable, list)
+		}
+	}
+	return usable, unavailable, nil
+}
+
+// ReadPackageList reads one package per line; blank lines and # comments
+// are ignored
+func ReadPackageList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkgs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			pkgs = append(pkgs, line)
+		}
+	}
+	return pkgs, scanner.Err()
+}
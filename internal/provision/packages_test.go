@@ -0,0 +1,50 @@
+package provision
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadPackageList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apt.txt")
+	os.WriteFile(path, []byte("# CLI tools\nripgrep\n\n  fd-find  # fd on Debian\ntmux\n"), 0644)
+
+	pkgs, err := ReadPackageList(path)
+	if err != nil {
+		t.Fatalf("ReadPackageList failed: %v", err)
+	}
+	if strings.Join(pkgs, ",") != "ripgrep,fd-find,tmux" {
+		t.Errorf("Unexpected packages %v", pkgs)
+	}
+}
+
+func TestFindPackageLists(t *testing.T) {
+	dotfiles := t.TempDir()
+	dir := PackagesDir(dotfiles)
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "apt.txt"), []byte("ripgrep\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "dnf.txt"), []byte("# nothing yet\n"), 0644)
+
+	// No manager binaries are found with an empty PATH
+	t.Setenv("PATH", t.TempDir())
+	usable, unavailable, err := FindPackageLists(dotfiles)
+	if err != nil {
+		t.Fatalf("FindPackageLists failed: %v", err)
+	}
+	if len(usable) != 0 || len(unavailable) != 1 || unavailable[0].Manager.Name != "apt" {
+		t.Fatalf("Expected apt to be unavailable and the empty dnf list skipped, got %+v %+v", usable, unavailable)
+	}
+
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "apt-get"), []byte("#!/bin/sh\n"), 0755)
+	t.Setenv("PATH", bin)
+	usable, _, _ = FindPackageLists(dotfiles)
+	if len(usable) != 1 {
+		t.Fatalf("Expected apt to be usable, got %+v", usable)
+	}
+	if cmd := strings.Join(usable[0].Command(), " "); cmd != "sudo apt-get install -y ripgrep" {
+		t.Errorf("Unexpected command %q", cmd)
+	}
+}
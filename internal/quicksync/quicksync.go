@@ -11,6 +11,7 @@ import (
 	"dotsync/internal/git"
 	"dotsync/internal/modes"
 	"dotsync/internal/models"
+	"dotsync/internal/policy"
 )
 
 // ActionType represents the overall action taken
@@ -51,6 +52,72 @@ func (a ActionType) String() string {
 	}
 }
 
+// Outcome is what happened to a single file during a Quick Sync
+type Outcome int
+
+const (
+	// OutcomeBackedUp - local file was pushed to dotfiles
+	OutcomeBackedUp Outcome = iota
+	// OutcomeFailed - push was attempted but failed
+	OutcomeFailed
+	// OutcomeSkipped - file was left alone
+	OutcomeSkipped
+	// OutcomeConflict - both sides changed, needs diff/merge
+	OutcomeConflict
+	// OutcomePending - sync file needs a manual push or pull
+	OutcomePending
+	// OutcomePulled - dotfiles copy was restored over the local file
+	OutcomePulled
+)
+
+// String returns a human-readable string for the outcome
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeBackedUp:
+		return "backed up"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeSkipped:
+		return "skipped"
+	case OutcomeConflict:
+		return "conflict"
+	case OutcomePending:
+		return "pending"
+	case OutcomePulled:
+		return "pulled"
+	default:
+		return "unknown"
+	}
+}
+
+// Icon returns an icon for the outcome
+func (o Outcome) Icon() string {
+	switch o {
+	case OutcomeBackedUp:
+		return "✓"
+	case OutcomeFailed:
+		return "✗"
+	case OutcomeSkipped:
+		return "–"
+	case OutcomeConflict:
+		return "⚡"
+	case OutcomePending:
+		return "•"
+	case OutcomePulled:
+		return "↓"
+	default:
+		return "?"
+	}
+}
+
+// Item is the outcome for one file, for the result screen
+type Item struct {
+	File    FileInfo
+	Outcome Outcome
+	Error   error
+	Policy  policy.Policy // Conflict policy that decided the outcome, if any
+}
+
 // Result contains the result of a Quick Sync operation
 type Result struct {
 	// Overall action taken
@@ -60,6 +127,9 @@ type Result struct {
 	BackedUpCount int
 	BackupFiles   []FileInfo
 
+	// Conflicts decided by a conflict policy
+	PolicyResolved int
+
 	// Sync mode status (for manual action)
 	SyncLocalMod  int
 	SyncRemoteMod int
@@ -70,12 +140,17 @@ type Result struct {
 	Fetched       bool
 	Committed     bool
 	CommitMessage string
+	Pushed        bool
+	PushError     error // Auto-push failure; the backup itself still succeeded
 
 	// Error if any
 	Error error
 
 	// Detection result for detailed info
 	Detection *DetectionResult
+
+	// Every file touched or reported, in order: backup results, then sync files
+	Items []Item
 }
 
 // Summary returns a human-readable summary of the result
@@ -84,7 +159,14 @@ func (r *Result) Summary() string {
 	case ActionSynced:
 		return "All files are in sync"
 	case ActionBackedUp:
-		return fmt.Sprintf("Backed up %d files", r.BackedUpCount)
+		msg := fmt.Sprintf("Backed up %d files", r.BackedUpCount)
+		if r.Pushed {
+			msg = fmt.Sprintf("Backed up and pushed %d files", r.BackedUpCount)
+		}
+		if r.PolicyResolved > 0 {
+			msg += fmt.Sprintf(" • %d conflicts resolved by policy", r.PolicyResolved)
+		}
+		return msg
 	case ActionPending:
 		return r.formatPendingMessage()
 	case ActionFailed:
@@ -201,16 +283,37 @@ func (q *QuickSync) Run(apps []*models.App) *Result {
 	resolveResult := q.resolver.ResolveAuto(detection)
 
 	// Count successful backups
+	listed := make(map[string]bool)
 	for _, res := range resolveResult.BackupResults {
 		if res.Action == ActionPush && res.Error == nil {
 			result.BackedUpCount++
 			result.BackupFiles = append(result.BackupFiles, res.File)
 		}
+		result.Items = append(result.Items, Item{File: res.File, Outcome: backupOutcome(res), Error: res.Error})
+		listed[res.File.AppID+"/"+res.File.RelPath] = true
+	}
+
+	// Conflicts decided by policy are listed with the policy that chose the side
+	for _, res := range resolveResult.PolicyResults {
+		if res.Error == nil {
+			result.PolicyResolved++
+			if res.Action == ActionPush {
+				result.BackedUpCount++
+				result.BackupFiles = append(result.BackupFiles, res.File)
+			}
+		}
+		result.Items = append(result.Items, Item{File: res.File, Outcome: backupOutcome(res), Error: res.Error, Policy: res.Policy})
+		listed[res.File.AppID+"/"+res.File.RelPath] = true
 	}
 
 	result.Committed = resolveResult.Committed
 	result.CommitMessage = resolveResult.CommitMessage
 
+	// Step 4: Auto-push the backup commit (modes config "auto_push")
+	if result.Committed && q.modesConfig != nil && q.modesConfig.AutoPush {
+		result.Pushed, result.PushError = q.autoPush()
+	}
+
 	// 3b. Collect SYNC files status
 	result.SyncFiles = resolveResult.SyncFiles
 
@@ -223,13 +326,21 @@ func (q *QuickSync) Run(apps []*models.App) *Result {
 		case StateConflict:
 			result.SyncConflicts++
 		}
+
+		if !listed[f.AppID+"/"+f.RelPath] {
+			outcome := OutcomePending
+			if f.State == StateConflict {
+				outcome = OutcomeConflict
+			}
+			result.Items = append(result.Items, Item{File: f, Outcome: outcome})
+		}
 	}
 
 	// Determine overall action
 	if resolveResult.Error != nil {
 		result.Action = ActionFailed
 		result.Error = resolveResult.Error
-	} else if result.BackedUpCount > 0 && !result.HasSyncPending() {
+	} else if (result.BackedUpCount > 0 || result.PolicyResolved > 0) && !result.HasSyncPending() {
 		result.Action = ActionBackedUp
 	} else if result.HasSyncPending() {
 		result.Action = ActionPending
@@ -240,6 +351,43 @@ func (q *QuickSync) Run(apps []*models.App) *Result {
 	return result
 }
 
+// autoPush pushes the backup commit when the repo has a remote and nothing
+// was left uncommitted. Returns whether a push happened.
+func (q *QuickSync) autoPush() (bool, error) {
+	if q.gitRepo == nil || !q.gitRepo.IsRepo() || !q.gitRepo.HasRemote() {
+		return false, nil
+	}
+
+	status, err := q.gitRepo.GetStatus()
+	if err != nil {
+		return false, fmt.Errorf("git status: %w", err)
+	}
+	if !status.IsClean {
+		return false, fmt.Errorf("push skipped: dotfiles repo has uncommitted changes")
+	}
+
+	if err := q.gitRepo.Push(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// backupOutcome maps a backup resolve result to its outcome
+func backupOutcome(res ResolveResult) Outcome {
+	switch {
+	case res.Error != nil:
+		return OutcomeFailed
+	case res.Action == ActionPush:
+		return OutcomeBackedUp
+	case res.Action == ActionPull:
+		return OutcomePulled
+	case res.Action == ActionMerge:
+		return OutcomeConflict
+	default:
+		return OutcomeSkipped
+	}
+}
+
 // GetDetector returns the conflict detector
 func (q *QuickSync) GetDetector() *ConflictDetector {
 	return q.detector
@@ -295,7 +443,7 @@ func (q *QuickSync) PushFile(file FileInfo) error {
 	}
 
 	// Update sync state
-	if err := q.resolver.UpdateSyncState(file); err != nil {
+	if err := q.resolver.recordSync(file, ActionPush); err != nil {
 		return err
 	}
 
@@ -311,7 +459,7 @@ func (q *QuickSync) PullFile(file FileInfo) error {
 	}
 
 	// Update sync state
-	if err := q.resolver.UpdateSyncState(file); err != nil {
+	if err := q.resolver.recordSync(file, ActionPull); err != nil {
 		return err
 	}
 
@@ -3,11 +3,13 @@ package quicksync
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"dotsync/internal/config"
 	"dotsync/internal/modes"
 	"dotsync/internal/models"
+	"dotsync/internal/policy"
 )
 
 func TestFileStateString(t *testing.T) {
@@ -189,6 +191,48 @@ func containsHelper(s, substr string) bool {
 	return false
 }
 
+func TestOutcomeString(t *testing.T) {
+	tests := []struct {
+		outcome  Outcome
+		expected string
+	}{
+		{OutcomeBackedUp, "backed up"},
+		{OutcomeFailed, "failed"},
+		{OutcomeSkipped, "skipped"},
+		{OutcomeConflict, "conflict"},
+		{OutcomePending, "pending"},
+		{OutcomePulled, "pulled"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.outcome.String(); got != tt.expected {
+			t.Errorf("Outcome(%d).String() = %q, want %q", tt.outcome, got, tt.expected)
+		}
+	}
+}
+
+func TestBackupOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   ResolveResult
+		expected Outcome
+	}{
+		{"pushed", ResolveResult{Action: ActionPush}, OutcomeBackedUp},
+		{"push failed", ResolveResult{Action: ActionPush, Error: os.ErrPermission}, OutcomeFailed},
+		{"needs merge", ResolveResult{Action: ActionMerge}, OutcomeConflict},
+		{"pulled by policy", ResolveResult{Action: ActionPull, Policy: policy.PreferRemote}, OutcomePulled},
+		{"nothing to do", ResolveResult{Action: ActionNone}, OutcomeSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backupOutcome(tt.result); got != tt.expected {
+				t.Errorf("backupOutcome() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestQuickSyncNew(t *testing.T) {
 	// Create temp directory
 	tmpDir := t.TempDir()
@@ -374,3 +418,154 @@ func TestGetAppIDs(t *testing.T) {
 		t.Errorf("expected 3 unique app IDs, got %d: %v", len(appIDs), appIDs)
 	}
 }
+
+func TestResultSummaryPushed(t *testing.T) {
+	r := &Result{Action: ActionBackedUp, BackedUpCount: 3, Pushed: true}
+	if got := r.Summary(); got != "Backed up and pushed 3 files" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestAutoPushSkipsWithoutRemote(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{DotfilesPath: tmpDir, BackupPath: filepath.Join(tmpDir, "backup")}
+	if err := cfg.InitGitRepo(); err != nil {
+		t.Fatalf("InitGitRepo failed: %v", err)
+	}
+
+	modesCfg := modes.Default()
+	modesCfg.AutoPush = true
+	qs := New(cfg, modesCfg)
+
+	pushed, err := qs.autoPush()
+	if pushed || err != nil {
+		t.Errorf("autoPush() without remote = (%v, %v), want (false, nil)", pushed, err)
+	}
+}
+
+func TestResolveConflictsByPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	cfg := &config.Config{
+		DotfilesPath: filepath.Join(tmpDir, "dotfiles"),
+		BackupPath:   filepath.Join(tmpDir, "backup"),
+		Conflicts: policy.Rules{
+			Default: policy.PreferLocal,
+			Apps:    map[string]policy.Policy{"remote": policy.PreferRemote, "ask": policy.AlwaysAsk},
+		},
+	}
+	modesCfg := modes.Default()
+	resolver := NewResolver(cfg, modesCfg, nil, NewConflictDetector(cfg, modesCfg))
+
+	var files []FileInfo
+	for _, appID := range []string{"local", "remote", "ask"} {
+		local := filepath.Join(tmpDir, appID+".conf")
+		dotfiles := filepath.Join(cfg.DotfilesPath, appID, appID+".conf")
+		os.MkdirAll(filepath.Dir(dotfiles), 0755)
+		os.WriteFile(local, []byte("local"), 0644)
+		os.WriteFile(dotfiles, []byte("dotfiles"), 0644)
+		files = append(files, FileInfo{AppID: appID, FilePath: local, RelPath: appID + ".conf", DotfilesPath: dotfiles, State: StateConflict})
+	}
+
+	results := resolver.ResolveConflicts(files)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 resolved conflicts, got %d: %+v", len(results), results)
+	}
+	if results[0].Action != ActionPush || results[0].Policy != policy.PreferLocal || results[0].Error != nil {
+		t.Errorf("Unexpected prefer-local result: %+v", results[0])
+	}
+	if results[1].Action != ActionPull || results[1].Policy != policy.PreferRemote || results[1].Error != nil {
+		t.Errorf("Unexpected prefer-remote result: %+v", results[1])
+	}
+
+	if content, _ := os.ReadFile(files[0].DotfilesPath); string(content) != "local" {
+		t.Errorf("prefer-local should push, dotfiles has %q", content)
+	}
+	if content, _ := os.ReadFile(files[1].FilePath); string(content) != "dotfiles" {
+		t.Errorf("prefer-remote should pull, local has %q", content)
+	}
+	if content, _ := os.ReadFile(files[2].FilePath); string(content) != "local" {
+		t.Errorf("always-ask should leave the file alone, local has %q", content)
+	}
+}
+
+func TestResolveBackupFilesStripsLocalSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	cfg := &config.Config{
+		DotfilesPath: filepath.Join(tmpDir, "dotfiles"),
+		BackupPath:   filepath.Join(tmpDir, "backup"),
+	}
+	modesCfg := modes.Default()
+	resolver := NewResolver(cfg, modesCfg, nil, NewConflictDetector(cfg, modesCfg))
+
+	local := filepath.Join(tmpDir, "app.conf")
+	content := "a\n# dotsync:local-begin\nsecret\n# dotsync:local-end\nb\n"
+	os.WriteFile(local, []byte(content), 0644)
+	file := FileInfo{
+		AppID:        "app",
+		FilePath:     local,
+		RelPath:      "app.conf",
+		DotfilesPath: filepath.Join(cfg.DotfilesPath, "app", "laptop", "app.conf"),
+		SyncPath:     filepath.Join(cfg.DotfilesPath, "app", "app.conf"),
+		Synced:       true,
+		State:        StateLocalModified,
+	}
+
+	results := resolver.ResolveBackupFiles([]FileInfo{file})
+	if len(results) != 1 || results[0].Action != ActionPush || results[0].Error != nil {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	for _, path := range []string{file.DotfilesPath, file.SyncPath} {
+		pushed, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Expected a copy at %s: %v", path, err)
+		}
+		if strings.Contains(string(pushed), "secret") {
+			t.Errorf("Quick backup should strip machine-local sections, %s has %q", path, pushed)
+		}
+	}
+	if got, _ := os.ReadFile(local); string(got) != content {
+		t.Errorf("Quick backup should leave the local file alone, got %q", got)
+	}
+}
+
+func TestResolveConflictsFiltersVolatileKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	cfg := &config.Config{
+		DotfilesPath: filepath.Join(tmpDir, "dotfiles"),
+		BackupPath:   filepath.Join(tmpDir, "backup"),
+		VolatileKeys: map[string][]string{"push": {"$.token"}, "pull": {"$.token"}},
+		Conflicts: policy.Rules{
+			Default: policy.PreferLocal,
+			Apps:    map[string]policy.Policy{"pull": policy.PreferRemote},
+		},
+	}
+	modesCfg := modes.Default()
+	resolver := NewResolver(cfg, modesCfg, nil, NewConflictDetector(cfg, modesCfg))
+
+	var files []FileInfo
+	for _, appID := range []string{"push", "pull"} {
+		local := filepath.Join(tmpDir, appID+".json")
+		dotfiles := filepath.Join(cfg.DotfilesPath, appID, appID+".json")
+		os.MkdirAll(filepath.Dir(dotfiles), 0755)
+		os.WriteFile(local, []byte(`{"font": 12, "token": "abc"}`), 0644)
+		os.WriteFile(dotfiles, []byte(`{"font": 14}`), 0644)
+		files = append(files, FileInfo{AppID: appID, FilePath: local, RelPath: appID + ".json", DotfilesPath: dotfiles, State: StateConflict})
+	}
+
+	results := resolver.ResolveConflicts(files)
+	if len(results) != 2 || results[0].Error != nil || results[1].Error != nil {
+		t.Fatalf("Expected both conflicts resolved, got %+v", results)
+	}
+	if content, _ := os.ReadFile(files[0].DotfilesPath); string(content) != `{"font": 12}` {
+		t.Errorf("A push should leave the token out of dotfiles, got %q", content)
+	}
+	if content, _ := os.ReadFile(files[1].FilePath); string(content) != `{"font": 14, "token": "abc"}` {
+		t.Errorf("A pull should keep the local token, got %q", content)
+	}
+}
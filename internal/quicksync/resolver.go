@@ -2,13 +2,12 @@ package quicksync
 
 import (
 	"fmt"
-	"io"
-	"os"
 	"path/filepath"
 
 	"dotsync/internal/config"
 	"dotsync/internal/git"
 	"dotsync/internal/modes"
+	"dotsync/internal/policy"
 	"dotsync/internal/sync"
 )
 
@@ -51,6 +50,7 @@ type ResolveResult struct {
 	File   FileInfo
 	Action ResolveAction
 	Error  error
+	Policy policy.Policy // Conflict policy that chose the action, if any
 }
 
 // Resolver handles auto-resolution of file states
@@ -122,98 +122,54 @@ func (r *Resolver) ResolveBackupFiles(files []FileInfo) []ResolveResult {
 	return results
 }
 
-// pushFile copies a file from local to dotfiles
-func (r *Resolver) pushFile(file FileInfo) error {
-	// Ensure destination directory exists
-	destDir := filepath.Dir(file.DotfilesPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	// Check if source is a directory
-	srcInfo, err := os.Stat(file.FilePath)
-	if err != nil {
-		return fmt.Errorf("failed to stat source: %w", err)
-	}
-
-	if srcInfo.IsDir() {
-		return r.copyDir(file.FilePath, file.DotfilesPath)
-	}
+// ResolveConflicts applies the configured conflict policies to files changed
+// on both sides. Files whose policy asks are left out of the results.
+func (r *Resolver) ResolveConflicts(files []FileInfo) []ResolveResult {
+	var results []ResolveResult
 
-	return r.copyFile(file.FilePath, file.DotfilesPath)
-}
+	for _, file := range files {
+		if file.State != StateConflict {
+			continue
+		}
 
-// pullFile copies a file from dotfiles to local
-func (r *Resolver) pullFile(file FileInfo) error {
-	// Ensure destination directory exists
-	destDir := filepath.Dir(file.FilePath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
+		p := r.config.Conflicts.For(file.AppID, file.RelPath)
+		result := ResolveResult{File: file, Policy: p}
 
-	// Check if source is a directory
-	srcInfo, err := os.Stat(file.DotfilesPath)
-	if err != nil {
-		return fmt.Errorf("failed to stat source: %w", err)
-	}
+		switch p.Resolve(file.FilePath, file.DotfilesPath) {
+		case policy.SideLocal:
+			result.Action = ActionPush
+			result.Error = r.pushFile(file)
+			if result.Error == nil && file.Synced && file.SyncPath != "" {
+				syncFile := file
+				syncFile.DotfilesPath = file.SyncPath
+				result.Error = r.pushFile(syncFile)
+			}
+		case policy.SideRemote:
+			result.Action = ActionPull
+			result.Error = r.pullFile(file)
+		default:
+			continue
+		}
 
-	if srcInfo.IsDir() {
-		return r.copyDir(file.DotfilesPath, file.FilePath)
+		if result.Error == nil {
+			_ = r.recordSync(file, result.Action)
+		}
+		results = append(results, result)
 	}
 
-	return r.copyFile(file.DotfilesPath, file.FilePath)
+	return results
 }
 
-// copyFile copies a single file
-func (r *Resolver) copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open source: %w", err)
-	}
-	defer srcFile.Close()
-
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("failed to create destination: %w", err)
-	}
-	defer dstFile.Close()
-
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("failed to copy: %w", err)
-	}
-
-	// Preserve permissions
-	srcInfo, err := os.Stat(src)
-	if err == nil {
-		os.Chmod(dst, srcInfo.Mode())
-	}
-
-	return nil
+// pushFile copies a file from local to dotfiles the way a push does,
+// leaving out machine-specific parts
+func (r *Resolver) pushFile(file FileInfo) error {
+	return sync.NewExporter(r.config).ExportFile(file.AppID, file.RelPath, file.FilePath, file.DotfilesPath)
 }
 
-// copyDir copies a directory recursively
-func (r *Resolver) copyDir(src, dst string) error {
-	// Remove destination directory first
-	os.RemoveAll(dst)
-
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Calculate destination path
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-		dstPath := filepath.Join(dst, relPath)
-
-		if info.IsDir() {
-			return os.MkdirAll(dstPath, info.Mode())
-		}
-
-		return r.copyFile(path, dstPath)
-	})
+// pullFile copies a file from dotfiles to local the way a pull does,
+// keeping machine-specific parts
+func (r *Resolver) pullFile(file FileInfo) error {
+	return sync.NewImporter(r.config).ImportFile(file.AppID, file.RelPath, file.DotfilesPath, file.FilePath)
 }
 
 // UpdateSyncState updates the sync state after resolving
@@ -228,6 +184,21 @@ func (r *Resolver) UpdateSyncState(file FileInfo) error {
 	return nil
 }
 
+// recordSync updates the sync state after file was pushed or pulled, and
+// stamps the time of that push or pull
+func (r *Resolver) recordSync(file FileInfo, action ResolveAction) error {
+	if err := r.UpdateSyncState(file); err != nil {
+		return err
+	}
+	switch action {
+	case ActionPush:
+		r.detector.GetStateManager().RecordPush(file.AppID, file.RelPath)
+	case ActionPull:
+		r.detector.GetStateManager().RecordPull(file.AppID, file.RelPath)
+	}
+	return nil
+}
+
 // CommitChanges creates a git commit for the changes
 func (r *Resolver) CommitChanges(message string, files []FileInfo) error {
 	if r.gitRepo == nil || !r.gitRepo.IsRepo() {
@@ -286,13 +257,15 @@ func GenerateCommitMessage(files []FileInfo) string {
 // ResolveAutoResult contains the result of auto-resolve
 type ResolveAutoResult struct {
 	BackupResults []ResolveResult
-	SyncFiles     []FileInfo // Files that need manual action
+	PolicyResults []ResolveResult // Conflicts decided by a conflict policy
+	SyncFiles     []FileInfo      // Files that need manual action
 	Committed     bool
 	CommitMessage string
 	Error         error
 }
 
-// ResolveAuto automatically resolves backup files and reports sync files
+// ResolveAuto automatically resolves backup files and policy-covered
+// conflicts, and reports sync files
 func (r *Resolver) ResolveAuto(detection *DetectionResult) *ResolveAutoResult {
 	result := &ResolveAutoResult{
 		BackupResults: []ResolveResult{},
@@ -303,39 +276,58 @@ func (r *Resolver) ResolveAuto(detection *DetectionResult) *ResolveAutoResult {
 	backupFiles := detection.GetBackupFilesWithChanges()
 
 	// Auto-resolve backup files
+	successfulPushes := []FileInfo{}
 	if len(backupFiles) > 0 {
 		result.BackupResults = r.ResolveBackupFiles(backupFiles)
 
 		// Count successful pushes
-		successfulPushes := []FileInfo{}
 		for _, res := range result.BackupResults {
 			if res.Action == ActionPush && res.Error == nil {
 				successfulPushes = append(successfulPushes, res.File)
 				// Update sync state
-				_ = r.UpdateSyncState(res.File)
+				_ = r.recordSync(res.File, ActionPush)
 			}
 		}
+	}
 
-		// Commit if there were successful pushes
-		// Use AddAll to stage everything (both backup and sync path files)
-		// so all changes are captured in a single commit
-		if len(successfulPushes) > 0 {
-			result.CommitMessage = GenerateCommitMessage(successfulPushes)
-			if err := r.gitRepo.AddAll(); err != nil {
-				result.Error = fmt.Errorf("add failed: %w", err)
-			} else if err := r.gitRepo.Commit(result.CommitMessage); err != nil {
-				result.Error = fmt.Errorf("commit failed: %w", err)
-			} else {
-				result.Committed = true
-			}
+	// Resolve conflicts the user has a policy for
+	resolved := make(map[string]bool)
+	result.PolicyResults = r.ResolveConflicts(detection.ConflictFiles)
+	for _, res := range result.PolicyResults {
+		if res.Error != nil {
+			continue
 		}
+		resolved[res.File.AppID+"/"+res.File.RelPath] = true
+		if res.Action == ActionPush {
+			successfulPushes = append(successfulPushes, res.File)
+		}
+	}
 
-		// Save sync state
+	// Commit if there were successful pushes
+	// Use AddAll to stage everything (both backup and sync path files)
+	// so all changes are captured in a single commit
+	if len(successfulPushes) > 0 {
+		result.CommitMessage = GenerateCommitMessage(successfulPushes)
+		if err := r.gitRepo.AddAll(); err != nil {
+			result.Error = fmt.Errorf("add failed: %w", err)
+		} else if err := r.gitRepo.Commit(result.CommitMessage); err != nil {
+			result.Error = fmt.Errorf("commit failed: %w", err)
+		} else {
+			result.Committed = true
+		}
+	}
+
+	// Save sync state
+	if len(backupFiles) > 0 || len(result.PolicyResults) > 0 {
 		_ = r.detector.SaveState()
 	}
 
 	// Collect sync files that need manual action
-	result.SyncFiles = detection.GetSyncFilesWithChanges()
+	for _, f := range detection.GetSyncFilesWithChanges() {
+		if !resolved[f.AppID+"/"+f.RelPath] {
+			result.SyncFiles = append(result.SyncFiles, f)
+		}
+	}
 
 	return result
 }
@@ -0,0 +1,199 @@
+// Package registry fetches the community app-definition catalog so new
+// detections arrive without a binary release. The catalog is an apps.yaml
+// published with a detached ed25519 signature (URL + ".sig", base64); it is
+// only saved after the signature verifies against the configured key.
+package registry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"dotsync/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the verified catalog kept in the config dir
+const FileName = "registry.yaml"
+
+// SignatureSuffix is appended to the catalog URL to fetch its signature
+const SignatureSuffix = ".sig"
+
+// maxCatalogSize caps how much of a response is read
+const maxCatalogSize = 16 << 20
+
+// Errors returned while updating the catalog
+var (
+	ErrNoURL        = errors.New("no registry URL configured")
+	ErrNoKey        = errors.New("no registry public key configured")
+	ErrBadSignature = errors.New("catalog signature does not verify")
+)
+
+// Path returns the catalog path inside configDir
+func Path(configDir string) string {
+	return filepath.Join(configDir, FileName)
+}
+
+// Verify checks a base64 ed25519 signature of data against a base64
+// public key
+func Verify(data, signature []byte, publicKey string) error {
+	if strings.TrimSpace(publicKey) == "" {
+		return ErrNoKey
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid registry public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Parse reads the definitions in a catalog. Entries without an ID or
+// config paths are dropped.
+func Parse(data []byte) ([]models.AppDefinition, error) {
+	var catalog models.AppConfig
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+	var defs []models.AppDefinition
+	for _, def := range catalog.Apps {
+		if def.ID != "" && len(def.ConfigPaths) > 0 {
+			defs = append(defs, def)
+		}
+	}
+	return defs, nil
+}
+
+// Load reads the saved catalog; a missing file is an empty catalog
+func Load(configDir string) ([]models.AppDefinition, error) {
+	data, err := os.ReadFile(Path(configDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Save replaces the saved catalog in one step
+func Save(configDir string, data []byte) error {
+	path := Path(configDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Fetcher downloads and verifies catalogs
+type Fetcher struct {
+	URL       string
+	PublicKey string
+	client    *http.Client
+}
+
+// NewFetcher creates a fetcher for the catalog at url, verified with the
+// base64 ed25519 publicKey
+func NewFetcher(url, publicKey string) *Fetcher {
+	return &Fetcher{URL: url, PublicKey: publicKey, client: http.DefaultClient}
+}
+
+// WithClient sets the HTTP client, e.g. one with a timeout
+func (f *Fetcher) WithClient(client *http.Client) *Fetcher {
+	f.client = client
+	return f
+}
+
+// Fetch downloads the catalog and its signature and returns the catalog
+// bytes once the signature verifies
+func (f *Fetcher) Fetch(ctx context.Context) ([]byte, error) {
+	if strings.TrimSpace(f.URL) == "" {
+		return nil, ErrNoURL
+	}
+	if strings.TrimSpace(f.PublicKey) == "" {
+		return nil, ErrNoKey
+	}
+	data, err := f.get(ctx, f.URL)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := f.get(ctx, f.URL+SignatureSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("fetching signature: %w", err)
+	}
+	if err := Verify(data, sig, f.PublicKey); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// get downloads one URL, failing on non-2xx responses
+func (f *Fetcher) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxCatalogSize))
+}
+
+// Update is the outcome of refreshing the catalog
+type Update struct {
+	Apps       []models.AppDefinition // Every definition in the new catalog
+	Added      []models.AppDefinition // Apps not supported before the update
+	Changed    []models.AppDefinition // Apps whose config paths changed
+	Overridden []string               // Catalog IDs the user's apps.yaml overrides
+}
+
+// Diff compares a new catalog with the definitions known before (builtin
+// and previously fetched) and the user's custom definitions, which keep
+// precedence over the catalog
+func Diff(catalog, known, custom []models.AppDefinition) *Update {
+	byID := make(map[string]models.AppDefinition, len(known))
+	for _, def := range known {
+		byID[def.ID] = def
+	}
+	isCustom := make(map[string]bool, len(custom))
+	for _, def := range custom {
+		isCustom[def.ID] = true
+	}
+
+	u := &Update{Apps: catalog}
+	for _, def := range catalog {
+		if isCustom[def.ID] {
+			u.Overridden = append(u.Overridden, def.ID)
+			continue
+		}
+		old, ok := byID[def.ID]
+		switch {
+		case !ok:
+			u.Added = append(u.Added, def)
+		case !slices.Equal(old.ConfigPaths, def.ConfigPaths):
+			u.Changed = append(u.Changed, def)
+		}
+	}
+	return u
+}
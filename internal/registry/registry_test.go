@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dotsync/internal/models"
+)
+
+const catalog = `apps:
+  - id: ghostty
+    name: Ghostty
+    category: terminal
+    config_paths: ["~/.config/ghostty"]
+  - id: bat
+    name: bat
+    config_paths: ["~/.config/bat", "~/.batrc"]
+  - id: incomplete
+    name: No paths
+`
+
+func signedServer(t *testing.T, data []byte, priv ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps.yaml":
+			w.Write(data)
+		case "/apps.yaml.sig":
+			w.Write([]byte(sig + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchVerifiesSignature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	key := base64.StdEncoding.EncodeToString(pub)
+	srv := signedServer(t, []byte(catalog), priv)
+
+	data, err := NewFetcher(srv.URL+"/apps.yaml", key).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if string(data) != catalog {
+		t.Errorf("Expected the catalog bytes, got %q", data)
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	_, err = NewFetcher(srv.URL+"/apps.yaml", base64.StdEncoding.EncodeToString(otherPub)).Fetch(context.Background())
+	if !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for the wrong key, got %v", err)
+	}
+
+	if _, err := NewFetcher(srv.URL+"/missing.yaml", key).Fetch(context.Background()); err == nil {
+		t.Error("Expected an error for a 404")
+	}
+	if _, err := NewFetcher(srv.URL+"/apps.yaml", "").Fetch(context.Background()); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+	if _, err := NewFetcher("", key).Fetch(context.Background()); !errors.Is(err, ErrNoURL) {
+		t.Errorf("Expected ErrNoURL, got %v", err)
+	}
+}
+
+func TestVerifyRejectsTamperedData(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(catalog)))
+	key := base64.StdEncoding.EncodeToString(pub)
+
+	if err := Verify([]byte(catalog), []byte(sig), key); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	if err := Verify([]byte(catalog+"  - id: evil\n"), []byte(sig), key); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for tampered data, got %v", err)
+	}
+	if err := Verify([]byte(catalog), []byte(sig), "not-a-key"); err == nil {
+		t.Error("Expected an error for an invalid key")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	if defs, err := Load(dir); err != nil || defs != nil {
+		t.Fatalf("Expected an empty catalog when none is saved, got %v, %v", defs, err)
+	}
+	if err := Save(dir, []byte(catalog)); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	defs, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(defs) != 2 || defs[0].ID != "ghostty" || defs[1].ID != "bat" {
+		t.Errorf("Expected ghostty and bat (incomplete entry dropped), got %+v", defs)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	defs, _ := Parse([]byte(catalog))
+	defs = append(defs, models.AppDefinition{ID: "nvim", ConfigPaths: []string{"~/.config/nvim"}})
+	known := []models.AppDefinition{
+		{ID: "bat", ConfigPaths: []string{"~/.config/bat"}},
+		{ID: "nvim", ConfigPaths: []string{"~/.config/nvim"}},
+	}
+	custom := []models.AppDefinition{{ID: "ghostty", ConfigPaths: []string{"~/ghostty"}}}
+
+	u := Diff(defs, known, custom)
+	if len(u.Apps) != 3 {
+		t.Errorf("Expected all catalog apps, got %d", len(u.Apps))
+	}
+	if len(u.Added) != 0 {
+		t.Errorf("Expected ghostty to be overridden rather than added, got %+v", u.Added)
+	}
+	if len(u.Changed) != 1 || u.Changed[0].ID != "bat" {
+		t.Errorf("Expected bat to be changed, got %+v", u.Changed)
+	}
+	if len(u.Overridden) != 1 || u.Overridden[0] != "ghostty" {
+		t.Errorf("Expected ghostty overridden, got %v", u.Overridden)
+	}
+
+	if u := Diff(defs, known, nil); len(u.Added) != 1 || u.Added[0].ID != "ghostty" {
+		t.Errorf("Expected ghostty to be new, got %+v", u.Added)
+	}
+}
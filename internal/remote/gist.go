@@ -0,0 +1,278 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// GistBackend keeps the store in a private GitHub Gist through the gh CLI.
+// Gists are flat, so files are named after their path with "__" for "/",
+// and a manifest maps the names back. Every push is a new gist revision;
+// a target of ID@REV pulls that revision.
+type GistBackend struct {
+	Dir   string
+	ID    string
+	Rev   string   // Revision to pull; empty for the latest
+	Paths []string // Store paths (slash-separated files or dirs) to push; nil for the whole store
+	run   runner
+}
+
+// SkippedError reports files a push left out because a gist can't hold
+// them; everything else was pushed
+type SkippedError struct {
+	Files []string // Each skipped store path with the reason
+}
+
+func (e *SkippedError) Error() string {
+	return "gists hold small text files only, skipped " + strings.Join(e.Files, "; ")
+}
+
+// gistManifestName is the gist file mapping gist names to store paths
+const gistManifestName = "dotsync-manifest.json"
+
+// gistFileLimit is the largest file the gist API returns in full
+const gistFileLimit = 1 << 20
+
+// gistManifest maps gist file names to store paths
+type gistManifest struct {
+	Files map[string]string `json:"files"`
+	Empty []string          `json:"empty,omitempty"` // Store paths of empty files, which gists can't hold
+}
+
+// gistFile is a file of a gist in API requests and responses; a nil
+// entry in a request deletes the file
+type gistFile struct {
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+type gist struct {
+	Files map[string]*gistFile `json:"files"`
+}
+
+var gistIDRe = regexp.MustCompile(`^[0-9a-f]{20,}$`)
+
+// parseGistTarget reads a gist ID from "ID", "gist:ID" or a gist URL, with
+// an optional "@REV"
+func parseGistTarget(target string) (id, rev string, err error) {
+	target = strings.TrimPrefix(strings.TrimSpace(target), "gist:")
+	target, rev, _ = strings.Cut(target, "@")
+	target = strings.TrimSuffix(strings.TrimSuffix(target, "/"), ".git")
+	if i := strings.LastIndex(target, "/"); i >= 0 {
+		target = target[i+1:]
+	}
+	if !gistIDRe.MatchString(target) {
+		return "", "", fmt.Errorf("gist backend needs a gist ID or URL; create a secret gist with `gh gist create`")
+	}
+	return target, rev, nil
+}
+
+// Name returns the backend name
+func (b *GistBackend) Name() string { return "gist" }
+
+// Push makes the gist match the local store, or the Paths of it: changed
+// files are updated, files gone from the store deleted, and the manifest
+// rewritten. Files a gist can't hold are left out and returned as a
+// *SkippedError once the rest is pushed.
+func (b *GistBackend) Push(message string) error {
+	manifest := gistManifest{Files: make(map[string]string)}
+	files := make(map[string]*gistFile)
+	var skipped []string
+
+	err := filepath.WalkDir(b.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" || d.Name() == ".DS_Store" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(b.Dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !b.inScope(rel) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(data) > gistFileLimit:
+			skipped = append(skipped, rel+" is over 1 MB")
+		case !utf8.Valid(data):
+			skipped = append(skipped, rel+" is binary")
+		case len(data) == 0:
+			manifest.Empty = append(manifest.Empty, rel)
+		default:
+			name := gistName(rel)
+			if other, ok := manifest.Files[name]; ok {
+				skipped = append(skipped, fmt.Sprintf("%s and %s share the gist name %s", other, rel, name))
+				return nil
+			}
+			manifest.Files[name] = rel
+			files[name] = &gistFile{Content: string(data)}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	files[gistManifestName] = &gistFile{Content: string(data)}
+
+	// Files the gist has that the store no longer does
+	current, err := b.fetch("")
+	if err != nil {
+		return err
+	}
+	for name := range current.Files {
+		if _, ok := files[name]; !ok {
+			files[name] = nil
+		}
+	}
+
+	if err := b.update(files); err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		return &SkippedError{Files: skipped}
+	}
+	return nil
+}
+
+// inScope reports whether the store path rel is pushed: one of Paths or
+// under one of them
+func (b *GistBackend) inScope(rel string) bool {
+	if b.Paths == nil {
+		return true
+	}
+	for _, p := range b.Paths {
+		if rel == p || strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Pull writes the gist's files, at Rev when set, into the local store.
+// Local files that were not pushed yet are kept.
+func (b *GistBackend) Pull() error {
+	g, err := b.fetch(b.Rev)
+	if err != nil {
+		return err
+	}
+	m, ok := g.Files[gistManifestName]
+	if !ok || m == nil {
+		return fmt.Errorf("gist %s has no %s; push to it first", b.ID, gistManifestName)
+	}
+	var manifest gistManifest
+	if err := json.Unmarshal([]byte(m.Content), &manifest); err != nil {
+		return fmt.Errorf("read %s: %w", gistManifestName, err)
+	}
+
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	write := func(rel, content string) {
+		dst := filepath.Join(b.Dir, filepath.FromSlash(rel))
+		if !strings.HasPrefix(dst, filepath.Clean(b.Dir)+string(filepath.Separator)) {
+			errs = append(errs, fmt.Errorf("%s is outside the store", rel))
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if err := os.WriteFile(dst, []byte(content), 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range names {
+		f := g.Files[name]
+		switch {
+		case f == nil:
+			errs = append(errs, fmt.Errorf("%s is missing from the gist", name))
+		case f.Truncated:
+			errs = append(errs, fmt.Errorf("%s is too large to download from the gist", name))
+		default:
+			write(manifest.Files[name], f.Content)
+		}
+	}
+	for _, rel := range manifest.Empty {
+		write(rel, "")
+	}
+	return errors.Join(errs...)
+}
+
+// gistName is the flat gist file name of a store path
+func gistName(rel string) string {
+	return strings.ReplaceAll(rel, "/", "__")
+}
+
+// fetch reads the gist, at rev when set
+func (b *GistBackend) fetch(rev string) (*gist, error) {
+	endpoint := "gists/" + b.ID
+	if rev != "" {
+		endpoint += "/" + rev
+	}
+	output, err := b.run("gh", "api", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("gh api %s failed: %s", endpoint, strings.TrimSpace(string(output)))
+	}
+	var g gist
+	if err := json.Unmarshal(output, &g); err != nil {
+		return nil, fmt.Errorf("read gist %s: %w", b.ID, err)
+	}
+	return &g, nil
+}
+
+// update sends the changed files, creating a new gist revision
+func (b *GistBackend) update(files map[string]*gistFile) error {
+	body, err := json.Marshal(gist{Files: files})
+	if err != nil {
+		return err
+	}
+	// gh reads the request body from a file
+	tmp, err := os.CreateTemp("", "dotsync-gist-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	output, err := b.run("gh", "api", "--method", "PATCH", "gists/"+b.ID, "--input", tmp.Name())
+	if err != nil {
+		return fmt.Errorf("gh api gists/%s failed: %s", b.ID, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
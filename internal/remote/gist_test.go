@@ -0,0 +1,158 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeGist serves gh api calls for one gist, keeping a revision per update
+type fakeGist struct {
+	revisions []map[string]*gistFile
+}
+
+func (f *fakeGist) run(name string, args ...string) ([]byte, error) {
+	latest := map[string]*gistFile{}
+	if len(f.revisions) > 0 {
+		latest = f.revisions[len(f.revisions)-1]
+	}
+	if len(args) >= 5 && args[1] == "--method" && args[2] == "PATCH" {
+		body, err := os.ReadFile(args[5])
+		if err != nil {
+			return nil, err
+		}
+		var req gist
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, err
+		}
+		next := make(map[string]*gistFile)
+		for k, v := range latest {
+			next[k] = v
+		}
+		for k, v := range req.Files {
+			if v == nil {
+				delete(next, k)
+			} else {
+				next[k] = v
+			}
+		}
+		f.revisions = append(f.revisions, next)
+		return []byte("{}"), nil
+	}
+
+	files := latest
+	if parts := strings.Split(args[1], "/"); len(parts) == 3 {
+		var rev int
+		fmt.Sscanf(parts[2], "%d", &rev)
+		files = f.revisions[rev]
+	}
+	return json.Marshal(gist{Files: files})
+}
+
+func TestParseGistTarget(t *testing.T) {
+	tests := map[string][2]string{
+		"aa5a315d61ae9438b18d":                            {"aa5a315d61ae9438b18d", ""},
+		"gist:aa5a315d61ae9438b18d@3":                     {"aa5a315d61ae9438b18d", "3"},
+		"https://gist.github.com/me/aa5a315d61ae9438b18d": {"aa5a315d61ae9438b18d", ""},
+	}
+	for target, want := range tests {
+		id, rev, err := parseGistTarget(target)
+		if err != nil || id != want[0] || rev != want[1] {
+			t.Errorf("parseGistTarget(%q) = %q, %q, %v; want %q, %q", target, id, rev, err, want[0], want[1])
+		}
+	}
+	if _, _, err := parseGistTarget("s3://bucket"); err == nil {
+		t.Error("Expected an error for a target that is not a gist")
+	}
+}
+
+func TestGistBackend(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "nvim", "lua"), 0755)
+	os.WriteFile(filepath.Join(dir, "nvim", "lua", "init.lua"), []byte("vim.o.number = true"), 0644)
+	os.MkdirAll(filepath.Join(dir, "git"), 0755)
+	os.WriteFile(filepath.Join(dir, "git", ".gitignore"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, ".zshrc"), []byte("v1"), 0644)
+
+	fake := &fakeGist{}
+	b := &GistBackend{Dir: dir, ID: "aa5a315d61ae9438b18d", run: fake.run}
+	if err := b.Push("first"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if got := fake.revisions[0]["nvim__lua__init.lua"]; got == nil || got.Content != "vim.o.number = true" {
+		t.Errorf("Expected nvim/lua/init.lua as nvim__lua__init.lua, got %+v", fake.revisions[0])
+	}
+
+	os.WriteFile(filepath.Join(dir, ".zshrc"), []byte("v2"), 0644)
+	os.RemoveAll(filepath.Join(dir, "nvim"))
+	if err := b.Push("second"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if _, ok := fake.revisions[1]["nvim__lua__init.lua"]; ok {
+		t.Error("Expected files gone from the store deleted from the gist")
+	}
+
+	// A fresh machine pulls the latest revision, or an earlier one
+	other := t.TempDir()
+	pull := &GistBackend{Dir: other, ID: b.ID, run: fake.run}
+	if err := pull.Pull(); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(other, ".zshrc")); string(data) != "v2" {
+		t.Errorf("Expected v2, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(other, "git", ".gitignore")); err != nil {
+		t.Error("Expected the empty file restored")
+	}
+
+	pull.Rev = "0"
+	if err := pull.Pull(); err != nil {
+		t.Fatalf("Pull of revision 0 failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(other, "nvim", "lua", "init.lua")); string(data) != "vim.o.number = true" {
+		t.Errorf("Expected init.lua from revision 0, got %q", data)
+	}
+}
+
+func TestGistBackend_SkipsBinary(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "icon.png"), []byte{0x89, 0xff, 0xfe}, 0644)
+	os.WriteFile(filepath.Join(dir, ".zshrc"), []byte("v1"), 0644)
+	fake := &fakeGist{}
+	b := &GistBackend{Dir: dir, ID: "aa5a315d61ae9438b18d", run: fake.run}
+
+	err := b.Push("")
+	var skipped *SkippedError
+	if !errors.As(err, &skipped) || !strings.Contains(err.Error(), "icon.png") {
+		t.Fatalf("Expected a SkippedError naming the binary file, got %v", err)
+	}
+	if len(fake.revisions) != 1 || fake.revisions[0][".zshrc"] == nil {
+		t.Error("Expected the other files pushed anyway")
+	}
+}
+
+func TestGistBackend_Paths(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "nvim", "lua"), 0755)
+	os.WriteFile(filepath.Join(dir, "nvim", "lua", "init.lua"), []byte("vim.o.number = true"), 0644)
+	os.WriteFile(filepath.Join(dir, "wallpaper.jpg"), make([]byte, gistFileLimit+1), 0644)
+	os.WriteFile(filepath.Join(dir, ".zshrc"), []byte("v1"), 0644)
+	fake := &fakeGist{}
+	b := &GistBackend{Dir: dir, ID: "aa5a315d61ae9438b18d", run: fake.run}
+
+	Limit(b, []string{"nvim", "missing"})
+	if err := b.Push(""); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	files := fake.revisions[0]
+	if files["nvim__lua__init.lua"] == nil {
+		t.Error("Expected files under a listed directory pushed")
+	}
+	if files[".zshrc"] != nil || files["wallpaper.jpg"] != nil {
+		t.Errorf("Expected only the listed paths pushed, got %+v", files)
+	}
+}
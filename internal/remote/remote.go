@@ -0,0 +1,265 @@
+// Package remote abstracts where the dotfiles store is published: a git
+// remote, any rclone remote (S3, Backblaze B2, Drive...), S3 via the aws CLI,
+// or a GitHub Gist via the gh CLI.
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"dotsync/internal/git"
+)
+
+// Backend pushes the local dotfiles store to a remote and pulls it back
+type Backend interface {
+	Name() string
+	Push(message string) error // Publish local changes; message is used by backends with history
+	Pull() error               // Fetch remote changes into the local store
+}
+
+// Kind selects the backend(s) used for the dotfiles store
+type Kind string
+
+const (
+	KindGit       Kind = "git"        // Git remote of the dotfiles repo (default)
+	KindRclone    Kind = "rclone"     // Any rclone remote, e.g. "b2:bucket/dotfiles"
+	KindS3        Kind = "s3"         // S3 via aws CLI, e.g. "s3://bucket/dotfiles"
+	KindGitRclone Kind = "git+rclone" // Git plus an rclone mirror
+	KindGitS3     Kind = "git+s3"     // Git plus an S3 mirror
+	KindGist      Kind = "gist"       // Private GitHub Gist via gh, for a few small configs without a repo
+)
+
+// Kinds lists all kinds in the order they cycle in settings
+var Kinds = []Kind{KindGit, KindRclone, KindS3, KindGitRclone, KindGitS3, KindGist}
+
+// ParseKind converts a config string to a Kind, defaulting to KindGit
+func ParseKind(s string) Kind {
+	for _, k := range Kinds {
+		if string(k) == s {
+			return k
+		}
+	}
+	return KindGit
+}
+
+// Next returns the next kind in the cycle
+func (k Kind) Next() Kind {
+	for i, kind := range Kinds {
+		if kind == k {
+			return Kinds[(i+1)%len(Kinds)]
+		}
+	}
+	return KindGit
+}
+
+// UsesGit returns true if the kind includes the git remote
+func (k Kind) UsesGit() bool {
+	return k == KindGit || strings.HasPrefix(string(k), "git+")
+}
+
+// Mirror returns the non-git part of the kind, or "" for plain git
+func (k Kind) Mirror() Kind {
+	switch k {
+	case KindRclone, KindGitRclone:
+		return KindRclone
+	case KindS3, KindGitS3:
+		return KindS3
+	case KindGist:
+		return KindGist
+	default:
+		return ""
+	}
+}
+
+// runner executes an external command and returns its combined output
+type runner func(name string, args ...string) ([]byte, error)
+
+func execRunner(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// New creates the backend for kind. dir is the local dotfiles store and
+// target the mirror destination (unused for plain git): an rclone remote,
+// an s3:// URL or a gist ID.
+func New(kind Kind, dir, target string) (Backend, error) {
+	var backends []Backend
+
+	if kind.UsesGit() {
+		backends = append(backends, &GitBackend{repo: git.NewRepo(dir)})
+	}
+
+	switch kind.Mirror() {
+	case KindRclone:
+		if target == "" {
+			return nil, fmt.Errorf("rclone backend needs a target like remote:bucket/path")
+		}
+		backends = append(backends, &RcloneBackend{Dir: dir, Target: target, run: execRunner})
+	case KindS3:
+		if !strings.HasPrefix(target, "s3://") {
+			return nil, fmt.Errorf("s3 backend needs a target like s3://bucket/path")
+		}
+		backends = append(backends, &S3Backend{Dir: dir, Target: target, run: execRunner})
+	case KindGist:
+		id, rev, err := parseGistTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, &GistBackend{Dir: dir, ID: id, Rev: rev, run: execRunner})
+	}
+
+	if len(backends) == 1 {
+		return backends[0], nil
+	}
+	return Multi(backends), nil
+}
+
+// Limit makes the gist part of b push only paths, slash-separated and
+// relative to the store. Other backends keep publishing the whole store.
+func Limit(b Backend, paths []string) {
+	switch b := b.(type) {
+	case *GistBackend:
+		b.Paths = paths
+	case Multi:
+		for _, inner := range b {
+			Limit(inner, paths)
+		}
+	}
+}
+
+// GitBackend commits and pushes the dotfiles repo
+type GitBackend struct {
+	repo *git.Repo
+}
+
+// Name returns the backend name
+func (b *GitBackend) Name() string { return "git" }
+
+// Push stages everything, commits and pushes if a remote is configured.
+// A dotfiles directory that is not a git repo is left alone.
+func (b *GitBackend) Push(message string) error {
+	if !b.repo.IsRepo() {
+		return nil
+	}
+	if err := b.repo.AddAll(); err != nil {
+		return fmt.Errorf("git add: %w", err)
+	}
+	if err := b.repo.Commit(message); err != nil {
+		return fmt.Errorf("git commit: %w", err)
+	}
+	if b.repo.HasRemote() {
+		if err := b.repo.Push(); err != nil {
+			return fmt.Errorf("git push: %w", err)
+		}
+	}
+	return nil
+}
+
+// Pull pulls from the git remote if one is configured
+func (b *GitBackend) Pull() error {
+	if !b.repo.IsRepo() || !b.repo.HasRemote() {
+		return nil
+	}
+	return b.repo.Pull()
+}
+
+// syncExcludes keeps VCS metadata out of object-store mirrors
+var syncExcludes = []string{".git/**", ".DS_Store"}
+
+// RcloneBackend mirrors the store to an rclone remote
+type RcloneBackend struct {
+	Dir    string
+	Target string
+	run    runner
+}
+
+// Name returns the backend name
+func (b *RcloneBackend) Name() string { return "rclone" }
+
+// Push makes the remote match the local store (remote deletions included)
+func (b *RcloneBackend) Push(message string) error {
+	return b.rclone("sync", b.Dir, b.Target)
+}
+
+// Pull copies newer remote files into the local store without deleting
+// local files that were not pushed yet
+func (b *RcloneBackend) Pull() error {
+	return b.rclone("copy", "--update", b.Target, b.Dir)
+}
+
+func (b *RcloneBackend) rclone(args ...string) error {
+	for _, ex := range syncExcludes {
+		args = append(args, "--exclude", ex)
+	}
+	if output, err := b.run("rclone", args...); err != nil {
+		return fmt.Errorf("rclone %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// S3Backend mirrors the store to S3 using the aws CLI
+type S3Backend struct {
+	Dir    string
+	Target string
+	run    runner
+}
+
+// Name returns the backend name
+func (b *S3Backend) Name() string { return "s3" }
+
+// Push makes the bucket prefix match the local store
+func (b *S3Backend) Push(message string) error {
+	return b.s3Sync(b.Dir, b.Target, "--delete")
+}
+
+// Pull downloads changed objects without deleting local files
+func (b *S3Backend) Pull() error {
+	return b.s3Sync(b.Target, b.Dir)
+}
+
+func (b *S3Backend) s3Sync(src, dst string, extra ...string) error {
+	args := []string{"s3", "sync", src, dst}
+	args = append(args, extra...)
+	for _, ex := range syncExcludes {
+		args = append(args, "--exclude", strings.TrimSuffix(ex, "*"))
+	}
+	if output, err := b.run("aws", args...); err != nil {
+		return fmt.Errorf("aws s3 sync failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Multi runs several backends in order, e.g. git plus a mirror
+type Multi []Backend
+
+// Name returns the combined backend name
+func (m Multi) Name() string {
+	names := make([]string, len(m))
+	for i, b := range m {
+		names[i] = b.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Push pushes to every backend, continuing past failures
+func (m Multi) Push(message string) error {
+	var errs []error
+	for _, b := range m {
+		if err := b.Push(message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Pull pulls from every backend, continuing past failures
+func (m Multi) Pull() error {
+	var errs []error
+	for _, b := range m {
+		if err := b.Pull(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
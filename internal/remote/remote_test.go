@@ -0,0 +1,169 @@
+package remote
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeRunner struct {
+	calls  []string
+	output string
+	err    error
+}
+
+func (f *fakeRunner) run(name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	return []byte(f.output), f.err
+}
+
+func TestParseKind(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Kind
+	}{
+		{"", KindGit},
+		{"git", KindGit},
+		{"rclone", KindRclone},
+		{"git+s3", KindGitS3},
+		{"ftp", KindGit},
+	}
+
+	for _, tt := range tests {
+		if got := ParseKind(tt.input); got != tt.want {
+			t.Errorf("ParseKind(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestKindNextCycles(t *testing.T) {
+	k := KindGit
+	for range Kinds {
+		k = k.Next()
+	}
+	if k != KindGit {
+		t.Errorf("Expected cycle back to git, got %q", k)
+	}
+}
+
+func TestKindParts(t *testing.T) {
+	tests := []struct {
+		kind    Kind
+		usesGit bool
+		mirror  Kind
+	}{
+		{KindGit, true, ""},
+		{KindRclone, false, KindRclone},
+		{KindS3, false, KindS3},
+		{KindGitRclone, true, KindRclone},
+		{KindGitS3, true, KindS3},
+		{KindGist, false, KindGist},
+	}
+
+	for _, tt := range tests {
+		if got := tt.kind.UsesGit(); got != tt.usesGit {
+			t.Errorf("%s.UsesGit() = %v, want %v", tt.kind, got, tt.usesGit)
+		}
+		if got := tt.kind.Mirror(); got != tt.mirror {
+			t.Errorf("%s.Mirror() = %q, want %q", tt.kind, got, tt.mirror)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := New(KindRclone, dir, ""); err == nil {
+		t.Error("Expected error for rclone without target")
+	}
+	if _, err := New(KindS3, dir, "bucket/path"); err == nil {
+		t.Error("Expected error for s3 target without s3:// prefix")
+	}
+
+	b, err := New(KindGitS3, dir, "s3://bucket/dotfiles")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if b.Name() != "git+s3" {
+		t.Errorf("Expected git+s3, got %s", b.Name())
+	}
+
+	b, err = New(KindRclone, dir, "b2:bucket")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, ok := b.(*RcloneBackend); !ok {
+		t.Errorf("Expected *RcloneBackend, got %T", b)
+	}
+}
+
+func TestRcloneBackend(t *testing.T) {
+	fake := &fakeRunner{}
+	b := &RcloneBackend{Dir: "/dots", Target: "b2:bucket/dots", run: fake.run}
+
+	if err := b.Push("msg"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if err := b.Pull(); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+
+	want := []string{
+		"rclone sync /dots b2:bucket/dots --exclude .git/** --exclude .DS_Store",
+		"rclone copy --update b2:bucket/dots /dots --exclude .git/** --exclude .DS_Store",
+	}
+	for i, call := range want {
+		if fake.calls[i] != call {
+			t.Errorf("call %d = %q, want %q", i, fake.calls[i], call)
+		}
+	}
+}
+
+func TestS3Backend(t *testing.T) {
+	fake := &fakeRunner{}
+	b := &S3Backend{Dir: "/dots", Target: "s3://bucket/dots", run: fake.run}
+
+	if err := b.Push("msg"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if err := b.Pull(); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+
+	want := []string{
+		"aws s3 sync /dots s3://bucket/dots --delete --exclude .git/* --exclude .DS_Store",
+		"aws s3 sync s3://bucket/dots /dots --exclude .git/* --exclude .DS_Store",
+	}
+	for i, call := range want {
+		if fake.calls[i] != call {
+			t.Errorf("call %d = %q, want %q", i, fake.calls[i], call)
+		}
+	}
+}
+
+func TestBackendErrorIncludesOutput(t *testing.T) {
+	fake := &fakeRunner{output: "access denied\n", err: errors.New("exit status 1")}
+	b := &RcloneBackend{Dir: "/dots", Target: "b2:bucket", run: fake.run}
+
+	err := b.Push("msg")
+	if err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("Expected error with command output, got %v", err)
+	}
+}
+
+func TestMultiContinuesPastFailures(t *testing.T) {
+	failing := &fakeRunner{err: errors.New("boom")}
+	ok := &fakeRunner{}
+	m := Multi{
+		&RcloneBackend{Dir: "/a", Target: "r:a", run: failing.run},
+		&S3Backend{Dir: "/a", Target: "s3://a", run: ok.run},
+	}
+
+	err := m.Push("msg")
+	if err == nil || !strings.Contains(err.Error(), "rclone") {
+		t.Errorf("Expected rclone error, got %v", err)
+	}
+	if len(ok.calls) != 1 {
+		t.Errorf("Second backend should still run, got %d calls", len(ok.calls))
+	}
+}
@@ -0,0 +1,241 @@
+// Package report builds drift summaries (pending pushes, pulls and conflicts)
+// across a machine's profiles and delivers them to a file or by email, for
+// use from cron/launchd.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dotsync/internal/models"
+)
+
+// Kind is the type of pending action for a file
+type Kind string
+
+const (
+	KindPush     Kind = "push"
+	KindPull     Kind = "pull"
+	KindConflict Kind = "conflict"
+)
+
+// Entry is a single file that needs attention
+type Entry struct {
+	Profile string `json:"profile,omitempty"` // "" for the default config
+	AppID   string `json:"app_id"`
+	AppName string `json:"app_name"`
+	RelPath string `json:"rel_path"`
+	Kind    Kind   `json:"kind"`
+}
+
+// Summary holds the pending actions for one machine, across its profiles
+type Summary struct {
+	Machine     string    `json:"machine"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Profiles    []string  `json:"profiles,omitempty"` // Profiles covered; "" is the default config
+	Entries     []Entry   `json:"entries"`
+}
+
+// New creates an empty summary; Add fills it one profile at a time
+func New(machine string) *Summary {
+	return &Summary{
+		Machine:     machine,
+		GeneratedAt: time.Now(),
+	}
+}
+
+// Build creates a summary from apps whose files have conflict types set
+// (see sync.UpdateSyncStatusWithHashes)
+func Build(machine string, apps []*models.App) *Summary {
+	s := New(machine)
+	s.Add("", apps)
+	return s
+}
+
+// Add adds the pending actions of a profile's apps, so one summary covers
+// every profile on the machine. profile is "" for the default config.
+func (s *Summary) Add(profile string, apps []*models.App) {
+	s.Profiles = append(s.Profiles, profile)
+	for _, app := range apps {
+		for _, file := range app.Files {
+			kind, ok := kindFor(file.ConflictType)
+			if !ok {
+				continue
+			}
+			s.Entries = append(s.Entries, Entry{
+				Profile: profile,
+				AppID:   app.ID,
+				AppName: app.Name,
+				RelPath: file.RelPath,
+				Kind:    kind,
+			})
+		}
+	}
+
+	sort.Slice(s.Entries, func(i, j int) bool {
+		if s.Entries[i].Profile != s.Entries[j].Profile {
+			return s.Entries[i].Profile < s.Entries[j].Profile
+		}
+		if s.Entries[i].AppID != s.Entries[j].AppID {
+			return s.Entries[i].AppID < s.Entries[j].AppID
+		}
+		return s.Entries[i].RelPath < s.Entries[j].RelPath
+	})
+}
+
+// profileName labels a profile in the text report
+func profileName(profile string) string {
+	if profile == "" {
+		return "default"
+	}
+	return profile
+}
+
+// kindFor maps a conflict type to a pending action
+func kindFor(c models.ConflictType) (Kind, bool) {
+	switch c {
+	case models.ConflictLocalModified, models.ConflictLocalNew, models.ConflictLocalDeleted:
+		return KindPush, true
+	case models.ConflictDotfilesModified, models.ConflictDotfilesNew, models.ConflictDotfilesDeleted:
+		return KindPull, true
+	case models.ConflictBothModified:
+		return KindConflict, true
+	default:
+		return "", false
+	}
+}
+
+// Count returns the number of entries of a kind
+func (s *Summary) Count(kind Kind) int {
+	n := 0
+	for _, e := range s.Entries {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// IsClean returns true if nothing is pending
+func (s *Summary) IsClean() bool {
+	return len(s.Entries) == 0
+}
+
+// Subject returns a one-line subject for email delivery
+func (s *Summary) Subject() string {
+	if s.IsClean() {
+		return fmt.Sprintf("dotsync: %s is in sync", s.Machine)
+	}
+	return fmt.Sprintf("dotsync: %s has %d to push, %d to pull, %d conflicts",
+		s.Machine, s.Count(KindPush), s.Count(KindPull), s.Count(KindConflict))
+}
+
+// Text renders the summary as plain text
+func (s *Summary) Text() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("dotsync drift report for %s\n", s.Machine))
+	b.WriteString(fmt.Sprintf("Generated: %s\n", s.GeneratedAt.Format("2006-01-02 15:04")))
+	if len(s.Profiles) > 1 {
+		names := make([]string, len(s.Profiles))
+		for i, p := range s.Profiles {
+			names[i] = profileName(p)
+		}
+		b.WriteString(fmt.Sprintf("Profiles: %s\n", strings.Join(names, ", ")))
+	}
+	b.WriteString("\n")
+
+	if s.IsClean() {
+		b.WriteString("Everything is in sync.\n")
+		return b.String()
+	}
+
+	sections := []struct {
+		kind  Kind
+		title string
+	}{
+		{KindConflict, "Conflicts (changed on both sides)"},
+		{KindPush, "Pending pushes (local changes)"},
+		{KindPull, "Pending pulls (dotfiles changes)"},
+	}
+
+	for _, sec := range sections {
+		count := s.Count(sec.kind)
+		if count == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s: %d\n", sec.title, count))
+		for _, e := range s.Entries {
+			if e.Kind != sec.kind {
+				continue
+			}
+			if len(s.Profiles) > 1 {
+				b.WriteString(fmt.Sprintf("  - [%s] %s/%s\n", profileName(e.Profile), e.AppID, e.RelPath))
+			} else {
+				b.WriteString(fmt.Sprintf("  - %s/%s\n", e.AppID, e.RelPath))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// WriteFile writes the text report to path, creating parent directories
+func (s *Summary) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(s.Text()), 0644)
+}
+
+// mailers are tried in order when sending email
+var mailers = []string{"sendmail", "msmtp"}
+
+// FindMailer returns the path of the first available mailer, or ""
+func FindMailer() string {
+	for _, name := range mailers {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	// sendmail often lives outside PATH
+	for _, path := range []string{"/usr/sbin/sendmail", "/usr/lib/sendmail"} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Message builds an RFC 5322 message for the summary
+func (s *Summary) Message(to string) []byte {
+	var b bytes.Buffer
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + s.Subject() + "\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(s.Text(), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// SendMail sends the summary through sendmail/msmtp
+func (s *Summary) SendMail(to string) error {
+	mailer := FindMailer()
+	if mailer == "" {
+		return fmt.Errorf("no mailer found (install sendmail or msmtp)")
+	}
+
+	cmd := exec.Command(mailer, "-t")
+	cmd.Stdin = bytes.NewReader(s.Message(to))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %s", filepath.Base(mailer), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
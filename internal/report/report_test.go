@@ -0,0 +1,126 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/models"
+)
+
+func testApps() []*models.App {
+	return []*models.App{
+		{
+			ID:   "zsh",
+			Name: "Zsh",
+			Files: []models.File{
+				{RelPath: ".zshrc", ConflictType: models.ConflictLocalModified},
+				{RelPath: ".zprofile", ConflictType: models.ConflictNone},
+			},
+		},
+		{
+			ID:   "git",
+			Name: "Git",
+			Files: []models.File{
+				{RelPath: ".gitconfig", ConflictType: models.ConflictBothModified},
+				{RelPath: ".gitignore_global", ConflictType: models.ConflictDotfilesNew},
+			},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	s := Build("laptop", testApps())
+
+	if len(s.Entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(s.Entries))
+	}
+	if s.Entries[0].AppID != "git" {
+		t.Errorf("Entries should be sorted by app, got %s first", s.Entries[0].AppID)
+	}
+
+	tests := []struct {
+		kind Kind
+		want int
+	}{
+		{KindPush, 1},
+		{KindPull, 1},
+		{KindConflict, 1},
+	}
+	for _, tt := range tests {
+		if got := s.Count(tt.kind); got != tt.want {
+			t.Errorf("Count(%s) = %d, want %d", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestSubjectAndText(t *testing.T) {
+	clean := Build("desktop", nil)
+	if !clean.IsClean() {
+		t.Error("Summary without entries should be clean")
+	}
+	if !strings.Contains(clean.Subject(), "in sync") {
+		t.Errorf("Clean subject = %q", clean.Subject())
+	}
+
+	s := Build("laptop", testApps())
+	if s.Subject() != "dotsync: laptop has 1 to push, 1 to pull, 1 conflicts" {
+		t.Errorf("Subject() = %q", s.Subject())
+	}
+
+	text := s.Text()
+	for _, want := range []string{"git/.gitconfig", "zsh/.zshrc", "Conflicts", "Pending pulls"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text() missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestAddProfiles(t *testing.T) {
+	s := Build("laptop", testApps())
+	s.Add("work", []*models.App{{
+		ID:    "zsh",
+		Files: []models.File{{RelPath: ".zshrc", ConflictType: models.ConflictDotfilesModified}},
+	}})
+
+	if len(s.Entries) != 4 || s.Entries[3].Profile != "work" {
+		t.Fatalf("Expected the work profile's entry last, got %+v", s.Entries)
+	}
+	if s.Subject() != "dotsync: laptop has 1 to push, 2 to pull, 1 conflicts" {
+		t.Errorf("Subject() = %q", s.Subject())
+	}
+	text := s.Text()
+	for _, want := range []string{"Profiles: default, work", "[default] git/.gitconfig", "[work] zsh/.zshrc"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text() missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "drift.txt")
+
+	if err := Build("laptop", testApps()).WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !strings.Contains(string(data), "laptop") {
+		t.Error("Report file should mention machine name")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	msg := string(Build("laptop", testApps()).Message("me@example.com"))
+
+	if !strings.HasPrefix(msg, "To: me@example.com\r\n") {
+		t.Errorf("Message should start with To header: %q", msg[:30])
+	}
+	if !strings.Contains(msg, "Subject: dotsync: laptop") {
+		t.Error("Message should contain subject")
+	}
+}
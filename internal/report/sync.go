@@ -0,0 +1,131 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dotsync/internal/audit"
+)
+
+// SyncReport records what one push, pull or quick sync did to each file,
+// for CI pipelines and personal records
+type SyncReport struct {
+	Action      string     `json:"action"` // push, push+commit, pull or quicksync
+	Machine     string     `json:"machine,omitempty"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Error       string     `json:"error,omitempty"` // The operation as a whole failed
+	Files       []SyncFile `json:"files"`
+	Notes       []string   `json:"notes,omitempty"` // e.g. the commit a quick sync made
+}
+
+// SyncFile is the outcome for one file
+type SyncFile struct {
+	Action  string `json:"action"` // push, pull or backup
+	AppID   string `json:"app_id"`
+	RelPath string `json:"rel_path"`
+	Result  string `json:"result"` // ok, failed, conflict or skipped
+	Error   string `json:"error,omitempty"`
+	Detail  string `json:"detail,omitempty"` // e.g. the conflict policy that decided it
+}
+
+// NewSyncReport builds a report from the audit entries logged for an
+// operation. Entries without a file become notes.
+func NewSyncReport(action, machine string, entries []audit.Entry, err error) *SyncReport {
+	r := &SyncReport{
+		Action:      action,
+		Machine:     machine,
+		GeneratedAt: time.Now(),
+		Files:       []SyncFile{},
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	for _, e := range entries {
+		if e.AppID == "" {
+			if e.Detail != "" {
+				r.Notes = append(r.Notes, e.Detail)
+			}
+			continue
+		}
+		r.Files = append(r.Files, SyncFile{
+			Action:  e.Action,
+			AppID:   e.AppID,
+			RelPath: e.File,
+			Result:  e.Result,
+			Error:   e.Error,
+			Detail:  e.Detail,
+		})
+	}
+	return r
+}
+
+// Count returns the number of files with a result
+func (r *SyncReport) Count(result string) int {
+	n := 0
+	for _, f := range r.Files {
+		if f.Result == result {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed reports whether the operation or any file failed
+func (r *SyncReport) Failed() bool {
+	return r.Error != "" || r.Count(audit.ResultFailed) > 0
+}
+
+// Markdown renders the report as a markdown document
+func (r *SyncReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# dotsync %s report\n\n", r.Action)
+	if r.Machine != "" {
+		fmt.Fprintf(&b, "- Machine: %s\n", r.Machine)
+	}
+	fmt.Fprintf(&b, "- Generated: %s\n", r.GeneratedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "- Files: %d ok, %d failed, %d conflicts, %d skipped\n",
+		r.Count(audit.ResultOK), r.Count(audit.ResultFailed), r.Count(audit.ResultConflict), r.Count(audit.ResultSkipped))
+	if r.Error != "" {
+		fmt.Fprintf(&b, "- Error: %s\n", r.Error)
+	}
+	for _, note := range r.Notes {
+		fmt.Fprintf(&b, "- %s\n", note)
+	}
+
+	if len(r.Files) > 0 {
+		b.WriteString("\n| App | File | Action | Result | Details |\n")
+		b.WriteString("|-----|------|--------|--------|---------|\n")
+		for _, f := range r.Files {
+			details := f.Detail
+			if f.Error != "" {
+				details = f.Error
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |\n",
+				f.AppID, f.RelPath, f.Action, f.Result, strings.ReplaceAll(details, "|", "\\|"))
+		}
+	}
+	return b.String()
+}
+
+// WriteFile writes the report to path, as JSON when the path ends in
+// .json and as markdown otherwise, creating parent directories
+func (r *SyncReport) WriteFile(path string) error {
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var err error
+		if data, err = json.MarshalIndent(r, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(r.Markdown())
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/audit"
+)
+
+func testEntries() []audit.Entry {
+	return []audit.Entry{
+		{Action: audit.ActionPull, AppID: "zsh", File: ".zshrc", Result: audit.ResultOK, Detail: "prefer-remote"},
+		{Action: audit.ActionPull, AppID: "git", File: ".gitconfig", Result: audit.ResultFailed, Error: "permission denied"},
+		{Action: audit.ActionPull, AppID: "nvim", File: "init.lua", Result: audit.ResultConflict},
+		{Action: audit.ActionBackup, Result: audit.ResultOK, Detail: "commit: backup"},
+	}
+}
+
+func TestNewSyncReport(t *testing.T) {
+	r := NewSyncReport("pull", "laptop", testEntries(), nil)
+
+	if len(r.Files) != 3 || len(r.Notes) != 1 {
+		t.Fatalf("Expected 3 files and 1 note, got %d and %d", len(r.Files), len(r.Notes))
+	}
+	tests := []struct {
+		result string
+		want   int
+	}{
+		{audit.ResultOK, 1},
+		{audit.ResultFailed, 1},
+		{audit.ResultConflict, 1},
+		{audit.ResultSkipped, 0},
+	}
+	for _, tt := range tests {
+		if got := r.Count(tt.result); got != tt.want {
+			t.Errorf("Count(%s) = %d, want %d", tt.result, got, tt.want)
+		}
+	}
+	if !r.Failed() {
+		t.Error("Expected a failed file to fail the report")
+	}
+
+	failed := NewSyncReport("push", "laptop", nil, errors.New("dotfiles path missing"))
+	if !failed.Failed() || failed.Files == nil {
+		t.Errorf("Expected a failed report with an empty file list, got %+v", failed)
+	}
+}
+
+func TestSyncReportWriteFile(t *testing.T) {
+	r := NewSyncReport("pull", "laptop", testEntries(), nil)
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "ci", "report.json")
+	if err := r.WriteFile(jsonPath); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, _ := os.ReadFile(jsonPath)
+	var decoded SyncReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Action != "pull" || len(decoded.Files) != 3 || decoded.Files[1].Error != "permission denied" {
+		t.Errorf("Unexpected JSON report: %+v", decoded)
+	}
+
+	mdPath := filepath.Join(dir, "report.md")
+	if err := r.WriteFile(mdPath); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, _ = os.ReadFile(mdPath)
+	for _, want := range []string{"# dotsync pull report", "1 ok, 1 failed, 1 conflicts", "| git | `.gitconfig` | pull | failed | permission denied |", "- commit: backup"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Markdown missing %q:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package review steps through the diffs of many changed files one at a
+// time, collecting a push, pull, merge or skip decision for each, and then
+// applies the accepted pushes and pulls in one batch.
+package review
+
+import (
+	"fmt"
+
+	"dotsync/internal/config"
+	"dotsync/internal/models"
+	"dotsync/internal/sync"
+)
+
+// Decision is what to do with a reviewed file
+type Decision int
+
+const (
+	Pending Decision = iota // Not decided yet
+	Push                    // Copy local to dotfiles
+	Pull                    // Copy dotfiles to local, backing up local first
+	Merged                  // Merged into the local file, then pushed
+	Skip                    // Leave both sides alone
+	Picked                  // Some hunks pushed on the merge screen already
+)
+
+// String returns a short label of the decision
+func (d Decision) String() string {
+	switch d {
+	case Push:
+		return "push"
+	case Pull:
+		return "pull"
+	case Merged:
+		return "merge"
+	case Skip:
+		return "skip"
+	case Picked:
+		return "picked"
+	default:
+		return "pending"
+	}
+}
+
+// Item is a file in the queue
+type Item struct {
+	App      *models.App
+	File     *models.File
+	Decision Decision
+}
+
+// Queue is the list of files under review and the one being shown
+type Queue struct {
+	Items   []*Item
+	Current int
+}
+
+// New queues the selected files of the selected apps that differ between
+// local and dotfiles. Directories and excluded placeholders are left out.
+func New(apps []*models.App) *Queue {
+	q := &Queue{}
+	for _, app := range apps {
+		if !app.Selected {
+			continue
+		}
+		for i := range app.Files {
+			file := &app.Files[i]
+			if !file.Selected || !models.FilterChanged.Matches(file) {
+				continue
+			}
+			q.Items = append(q.Items, &Item{App: app, File: file})
+		}
+	}
+	return q
+}
+
+// Item returns the file being reviewed, or nil once every file is decided
+func (q *Queue) Item() *Item {
+	if q.Current < len(q.Items) {
+		return q.Items[q.Current]
+	}
+	return nil
+}
+
+// Done reports whether every file has been decided
+func (q *Queue) Done() bool {
+	return q.Current >= len(q.Items)
+}
+
+// Decide records the decision for the current file and moves to the next
+func (q *Queue) Decide(d Decision) {
+	if item := q.Item(); item != nil {
+		item.Decision = d
+		q.Current++
+	}
+}
+
+// Back returns to the previous file so its decision can be changed
+func (q *Queue) Back() {
+	if q.Current > 0 {
+		q.Current--
+	}
+}
+
+// Count returns how many files got decision d
+func (q *Queue) Count(d Decision) int {
+	n := 0
+	for _, item := range q.Items {
+		if item.Decision == d {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary describes the decisions, e.g. "3 push, 1 pull, 2 skip"
+func (q *Queue) Summary() string {
+	return fmt.Sprintf("%d push, %d pull, %d merge, %d skip",
+		q.Count(Push), q.Count(Pull), q.Count(Merged), q.Count(Skip))
+}
+
+// Result is the outcome of applying one decision
+type Result struct {
+	Item *Item
+	Err  error
+}
+
+// Apply carries out the accepted decisions the way a push or pull does:
+// pushes and merges export the local file to its dotfiles path in the
+// layout, pulls back up the local file and import the dotfiles version
+// over it. Applied files are recorded as synced in state, which may be
+// nil. Skipped files are left out of the results.
+func (q *Queue) Apply(cfg *config.Config, state *sync.StateManager) []Result {
+	var results []Result
+	for _, item := range q.Items {
+		if item.Decision == Pending || item.Decision == Skip || item.Decision == Picked {
+			continue
+		}
+		localPath := item.File.Path
+		dotfilePath := sync.DotfilePath(cfg.DotfilesPath, item.App.ID, *item.File)
+
+		var err error
+		if item.Decision == Pull {
+			err = sync.NewImporter(cfg).ImportFile(item.App.ID, item.File.RelPath, dotfilePath, localPath)
+		} else {
+			err = sync.NewExporter(cfg).ExportFile(item.App.ID, item.File.RelPath, localPath, dotfilePath)
+		}
+		results = append(results, Result{Item: item, Err: err})
+		if err != nil {
+			continue
+		}
+
+		sync.GetHashCache().InvalidatePath(localPath)
+		sync.GetHashCache().InvalidatePath(dotfilePath)
+		// Filters can leave the two sides different
+		localHash, _ := sync.ComputeFileHash(localPath)
+		dotfilesHash, _ := sync.ComputeFileHash(dotfilePath)
+		item.File.LocalHash = localHash
+		item.File.DotfilesHash = dotfilesHash
+		item.File.ConflictType = models.ConflictNone
+		if state != nil {
+			state.SetFileState(item.App.ID, item.File.RelPath, localHash, dotfilesHash)
+		}
+	}
+	return results
+}
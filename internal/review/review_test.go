@@ -0,0 +1,130 @@
+package review
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/config"
+	"dotsync/internal/layout"
+	"dotsync/internal/models"
+	"dotsync/internal/sync"
+)
+
+func TestNew(t *testing.T) {
+	app := &models.App{ID: "nvim", Selected: true, Files: []models.File{
+		{RelPath: "init.lua", Selected: true, ConflictType: models.ConflictLocalModified},
+		{RelPath: "synced.lua", Selected: true},
+		{RelPath: "lua", IsDir: true, Selected: true, ConflictType: models.ConflictLocalModified},
+		{RelPath: "keys.lua", ConflictType: models.ConflictBothModified},
+	}}
+	other := &models.App{ID: "zsh", Files: []models.File{
+		{RelPath: ".zshrc", Selected: true, ConflictType: models.ConflictLocalModified},
+	}}
+
+	q := New([]*models.App{app, other})
+	if len(q.Items) != 1 || q.Items[0].File.RelPath != "init.lua" {
+		t.Fatalf("Expected only the selected changed file, got %d items", len(q.Items))
+	}
+}
+
+func TestDecide(t *testing.T) {
+	q := &Queue{Items: []*Item{{}, {}, {}}}
+	q.Decide(Push)
+	q.Decide(Skip)
+	q.Back()
+	q.Decide(Pull)
+	if q.Done() {
+		t.Fatal("Expected one file left to review")
+	}
+	q.Decide(Merged)
+	if !q.Done() || q.Item() != nil {
+		t.Error("Expected the queue to be done")
+	}
+	q.Decide(Skip) // No-op once done
+	if got := q.Summary(); got != "1 push, 1 pull, 1 merge, 0 skip" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	dotfiles := filepath.Join(dir, "dotfiles")
+	write := func(path, content string) {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	local := func(name string) string { return filepath.Join(dir, "home", name) }
+	write(local("a"), "local a")
+	write(local("b"), "local b")
+	write(local("c"), "local c")
+	write(filepath.Join(dotfiles, "app", "a"), "dotfiles a")
+	write(filepath.Join(dotfiles, "app", "b"), "dotfiles b")
+	write(filepath.Join(dotfiles, "app", "c"), "dotfiles c")
+
+	app := &models.App{ID: "app"}
+	file := func(name string) *models.File {
+		return &models.File{Path: local(name), RelPath: name, ConflictType: models.ConflictBothModified}
+	}
+	q := &Queue{Items: []*Item{
+		{App: app, File: file("a"), Decision: Push},
+		{App: app, File: file("b"), Decision: Pull},
+		{App: app, File: file("c"), Decision: Skip},
+	}}
+	state := sync.NewStateManager(dir)
+
+	cfg := &config.Config{DotfilesPath: dotfiles, BackupPath: filepath.Join(dir, "backups")}
+	results := q.Apply(cfg, state)
+	if len(results) != 2 || results[0].Err != nil || results[1].Err != nil {
+		t.Fatalf("Expected two applied decisions, got %+v", results)
+	}
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+	if got := read(filepath.Join(dotfiles, "app", "a")); got != "local a" {
+		t.Errorf("Expected a pushed, got %q", got)
+	}
+	if got := read(local("b")); got != "dotfiles b" {
+		t.Errorf("Expected b pulled, got %q", got)
+	}
+	if got := read(filepath.Join(dotfiles, "app", "c")); got != "dotfiles c" {
+		t.Errorf("Expected c skipped, got %q", got)
+	}
+	if q.Items[0].File.ConflictType != models.ConflictNone || q.Items[2].File.ConflictType != models.ConflictBothModified {
+		t.Error("Expected only applied files to be marked synced")
+	}
+	if _, ok := state.GetFileState("app", "b"); !ok {
+		t.Error("Expected the pulled file recorded in the sync state")
+	}
+}
+
+func TestApply_LayoutAndFilters(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	cfg := &config.Config{DotfilesPath: filepath.Join(dir, "dotfiles"), BackupPath: filepath.Join(dir, "backups")}
+	home, _ := layout.New(layout.Home)
+	if err := layout.Save(cfg.DotfilesPath, home); err != nil {
+		t.Fatal(err)
+	}
+
+	localPath := filepath.Join(dir, ".config", "app", "app.conf")
+	os.MkdirAll(filepath.Dir(localPath), 0755)
+	os.WriteFile(localPath, []byte("a\n# dotsync:local-begin\nsecret\n# dotsync:local-end\n"), 0644)
+
+	app := &models.App{ID: "app"}
+	file := &models.File{Path: localPath, RelPath: "app.conf", ConflictType: models.ConflictLocalModified}
+	q := &Queue{Items: []*Item{{App: app, File: file, Decision: Push}}}
+
+	if results := q.Apply(cfg, nil); len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("Expected the push applied, got %+v", results)
+	}
+	pushed, err := os.ReadFile(filepath.Join(cfg.DotfilesPath, ".config", "app", "app.conf"))
+	if err != nil {
+		t.Fatalf("Expected the push at the home layout path: %v", err)
+	}
+	if strings.Contains(string(pushed), "secret") {
+		t.Errorf("Expected machine-local sections stripped, got %q", pushed)
+	}
+}
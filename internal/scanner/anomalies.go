@@ -0,0 +1,273 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"dotsync/internal/models"
+)
+
+// AnomalyKind identifies a problem with app definitions
+type AnomalyKind int
+
+const (
+	AnomalyDuplicateID      AnomalyKind = iota // Same ID defined more than once
+	AnomalySharedConfigPath                    // Different IDs claim the same config path
+)
+
+// String returns a string representation of the anomaly kind
+func (k AnomalyKind) String() string {
+	switch k {
+	case AnomalyDuplicateID:
+		return "duplicate ID"
+	case AnomalySharedConfigPath:
+		return "shared config path"
+	default:
+		return "unknown"
+	}
+}
+
+// Anomaly describes a definition problem detected at startup
+type Anomaly struct {
+	Kind  AnomalyKind
+	IDs   []string // Affected app IDs (one for duplicates)
+	Path  string   // Shared config path (AnomalySharedConfigPath only)
+	Count int      // Number of definitions involved
+}
+
+// Description returns a one-line description of the anomaly
+func (a Anomaly) Description() string {
+	switch a.Kind {
+	case AnomalyDuplicateID:
+		return fmt.Sprintf("%s is defined %d times", a.IDs[0], a.Count)
+	case AnomalySharedConfigPath:
+		return fmt.Sprintf("%s is claimed by %s", a.Path, strings.Join(a.IDs, ", "))
+	default:
+		return ""
+	}
+}
+
+// Overrides are user decisions that resolve definition anomalies
+type Overrides struct {
+	Disabled []string          // App IDs to ignore entirely
+	Aliases  map[string]string // Alias ID -> canonical ID; alias paths merge into canonical
+}
+
+// IsDisabled returns true if the app ID is disabled
+func (o Overrides) IsDisabled(id string) bool {
+	for _, d := range o.Disabled {
+		if d == id {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectAnomalies finds duplicate IDs and config paths shared by different IDs
+func DetectAnomalies(defs []models.AppDefinition) []Anomaly {
+	var anomalies []Anomaly
+
+	idCount := make(map[string]int)
+	var idOrder []string
+	pathOwners := make(map[string][]string)
+	var pathOrder []string
+
+	for _, def := range defs {
+		if idCount[def.ID] == 0 {
+			idOrder = append(idOrder, def.ID)
+		}
+		idCount[def.ID]++
+
+		for _, p := range def.ConfigPaths {
+			owners := pathOwners[p]
+			if len(owners) == 0 {
+				pathOrder = append(pathOrder, p)
+			}
+			if !containsString(owners, def.ID) {
+				pathOwners[p] = append(owners, def.ID)
+			}
+		}
+	}
+
+	for _, id := range idOrder {
+		if idCount[id] > 1 {
+			anomalies = append(anomalies, Anomaly{
+				Kind:  AnomalyDuplicateID,
+				IDs:   []string{id},
+				Count: idCount[id],
+			})
+		}
+	}
+
+	for _, p := range pathOrder {
+		if owners := pathOwners[p]; len(owners) > 1 {
+			anomalies = append(anomalies, Anomaly{
+				Kind:  AnomalySharedConfigPath,
+				IDs:   owners,
+				Path:  p,
+				Count: len(owners),
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// NormalizeDefinitions collapses duplicate IDs (merging their config paths,
+// first definition wins otherwise), applies aliases and drops disabled apps.
+// Order of first appearance is preserved.
+func NormalizeDefinitions(defs []models.AppDefinition, overrides Overrides) []models.AppDefinition {
+	var result []models.AppDefinition
+	indexByID := make(map[string]int)
+
+	for _, def := range defs {
+		id := def.ID
+		if target, ok := overrides.Aliases[id]; ok && target != id {
+			id = target
+		}
+
+		if idx, exists := indexByID[id]; exists {
+			result[idx].ConfigPaths = mergePaths(result[idx].ConfigPaths, def.ConfigPaths)
+			result[idx].EncryptedFiles = mergePaths(result[idx].EncryptedFiles, def.EncryptedFiles)
+			continue
+		}
+
+		// An alias seen before its canonical definition keeps its own metadata
+		// under the canonical ID until the canonical one shows up
+		def.ConfigPaths = append([]string(nil), def.ConfigPaths...)
+		def.EncryptedFiles = append([]string(nil), def.EncryptedFiles...)
+		def.ID = id
+		indexByID[id] = len(result)
+		result = append(result, def)
+	}
+
+	if len(overrides.Disabled) == 0 {
+		return result
+	}
+
+	filtered := result[:0]
+	for _, def := range result {
+		if !overrides.IsDisabled(def.ID) {
+			filtered = append(filtered, def)
+		}
+	}
+	return filtered
+}
+
+// mergePaths appends paths from b that are not already in a
+func mergePaths(a, b []string) []string {
+	for _, p := range b {
+		if !containsString(a, p) {
+			a = append(a, p)
+		}
+	}
+	return a
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Definitions returns the built-in definitions, with their known
+// duplicates and variants folded into canonical apps, merged with custom
+// ones before any user override (use for anomaly detection)
+func (s *Scanner) Definitions() []models.AppDefinition {
+	defs := mergeDefinitions(s.canonicalDefinitions(s.getBuiltinDefinitions()), s.extraDefs)
+	if customDefs, err := s.loadCustomDefinitions(); err == nil {
+		defs = mergeDefinitions(defs, customDefs)
+	}
+	return defs
+}
+
+// BuiltinDefinitions returns the definitions compiled into dotsync
+func (s *Scanner) BuiltinDefinitions() []models.AppDefinition {
+	return s.getBuiltinDefinitions()
+}
+
+// CustomDefinitions returns the user's definitions from apps.yaml, if any
+func (s *Scanner) CustomDefinitions() []models.AppDefinition {
+	defs, _ := s.loadCustomDefinitions()
+	return defs
+}
+
+// effectiveDefinitions returns the definitions used for scanning: builtin
+// duplicates and variants folded, custom definitions applied, then user
+// overrides
+func (s *Scanner) effectiveDefinitions() []models.AppDefinition {
+	defs := s.canonicalDefinitions(s.getBuiltinDefinitions())
+	defs = mergeDefinitions(defs, s.extraDefs)
+	if customDefs, err := s.loadCustomDefinitions(); err == nil {
+		defs = mergeDefinitions(defs, customDefs)
+	}
+	defs = NormalizeDefinitions(defs, s.overrides)
+	if s.pathFilter != nil {
+		defs = filterPaths(defs, s.pathFilter)
+	}
+	return defs
+}
+
+// WithPathFilter drops config paths for which skip returns true, and the
+// definitions left without any, e.g. /etc paths handled by system mode
+func (s *Scanner) WithPathFilter(skip func(path string) bool) *Scanner {
+	s.pathFilter = skip
+	return s
+}
+
+// filterPaths returns defs without the config paths skip matches
+func filterPaths(defs []models.AppDefinition, skip func(string) bool) []models.AppDefinition {
+	kept := make([]models.AppDefinition, 0, len(defs))
+	for _, def := range defs {
+		paths := make([]string, 0, len(def.ConfigPaths))
+		for _, path := range def.ConfigPaths {
+			if !skip(path) {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		def.ConfigPaths = paths
+		kept = append(kept, def)
+	}
+	return kept
+}
+
+// WithDefinitions adds definitions from the community registry and plugins,
+// later ones winning. They override builtins with the same ID; the user's
+// custom definitions override them.
+func (s *Scanner) WithDefinitions(defs []models.AppDefinition) *Scanner {
+	s.extraDefs = defs
+	return s
+}
+
+// WithOverrides sets the user overrides applied during Scan
+func (s *Scanner) WithOverrides(o Overrides) *Scanner {
+	s.overrides = o
+	return s
+}
+
+// Resolved returns true if the anomaly no longer produces duplicate apps.
+// Duplicate IDs are always collapsed by NormalizeDefinitions; shared paths
+// are resolved once at most one of the IDs is still active.
+func (a Anomaly) Resolved(o Overrides) bool {
+	if a.Kind == AnomalyDuplicateID {
+		return true
+	}
+
+	active := 0
+	for _, id := range a.IDs {
+		if o.IsDisabled(id) {
+			continue
+		}
+		if target, ok := o.Aliases[id]; ok && target != id {
+			continue
+		}
+		active++
+	}
+	return active <= 1
+}
@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/models"
+)
+
+func anomalyDefs() []models.AppDefinition {
+	return []models.AppDefinition{
+		{ID: "bat", Name: "bat", ConfigPaths: []string{"~/.config/bat"}},
+		{ID: "nvim", Name: "Neovim", ConfigPaths: []string{"~/.config/nvim"}},
+		{ID: "lazyvim", Name: "LazyVim", ConfigPaths: []string{"~/.config/nvim", "~/.config/lazyvim"}},
+		{ID: "bat", Name: "Bat", ConfigPaths: []string{"~/.config/bat", "~/.batrc"}},
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	anomalies := DetectAnomalies(anomalyDefs())
+
+	if len(anomalies) != 2 {
+		t.Fatalf("Expected 2 anomalies, got %d: %+v", len(anomalies), anomalies)
+	}
+
+	dup := anomalies[0]
+	if dup.Kind != AnomalyDuplicateID || dup.IDs[0] != "bat" || dup.Count != 2 {
+		t.Errorf("Unexpected duplicate anomaly: %+v", dup)
+	}
+
+	shared := anomalies[1]
+	if shared.Kind != AnomalySharedConfigPath || shared.Path != "~/.config/nvim" {
+		t.Errorf("Unexpected shared path anomaly: %+v", shared)
+	}
+	if len(shared.IDs) != 2 || shared.IDs[0] != "nvim" || shared.IDs[1] != "lazyvim" {
+		t.Errorf("Shared path IDs = %v", shared.IDs)
+	}
+}
+
+func TestNormalizeDefinitions_CollapsesDuplicates(t *testing.T) {
+	defs := NormalizeDefinitions(anomalyDefs(), Overrides{})
+
+	if len(defs) != 3 {
+		t.Fatalf("Expected 3 definitions, got %d", len(defs))
+	}
+	if defs[0].ID != "bat" || defs[0].Name != "bat" {
+		t.Errorf("First definition should win, got %+v", defs[0])
+	}
+	if len(defs[0].ConfigPaths) != 2 {
+		t.Errorf("Duplicate paths should merge, got %v", defs[0].ConfigPaths)
+	}
+}
+
+func TestNormalizeDefinitions_Overrides(t *testing.T) {
+	defs := NormalizeDefinitions(anomalyDefs(), Overrides{
+		Disabled: []string{"bat"},
+		Aliases:  map[string]string{"lazyvim": "nvim"},
+	})
+
+	if len(defs) != 1 {
+		t.Fatalf("Expected 1 definition, got %d: %+v", len(defs), defs)
+	}
+	if defs[0].ID != "nvim" {
+		t.Errorf("Expected nvim, got %s", defs[0].ID)
+	}
+	if len(defs[0].ConfigPaths) != 2 {
+		t.Errorf("Alias paths should merge into canonical, got %v", defs[0].ConfigPaths)
+	}
+}
+
+func TestNormalizeDefinitions_DoesNotMutateInput(t *testing.T) {
+	input := anomalyDefs()
+	NormalizeDefinitions(input, Overrides{})
+
+	if len(input[0].ConfigPaths) != 1 {
+		t.Errorf("Input definitions should not be modified, got %v", input[0].ConfigPaths)
+	}
+}
+
+func TestAnomalyResolved(t *testing.T) {
+	shared := Anomaly{Kind: AnomalySharedConfigPath, IDs: []string{"nvim", "lazyvim", "astronvim"}}
+
+	tests := []struct {
+		name      string
+		overrides Overrides
+		want      bool
+	}{
+		{"no overrides", Overrides{}, false},
+		{"one handled", Overrides{Disabled: []string{"lazyvim"}}, false},
+		{"all but one handled", Overrides{
+			Disabled: []string{"lazyvim"},
+			Aliases:  map[string]string{"astronvim": "nvim"},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shared.Resolved(tt.overrides); got != tt.want {
+				t.Errorf("Resolved() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	dup := Anomaly{Kind: AnomalyDuplicateID, IDs: []string{"bat"}, Count: 2}
+	if !dup.Resolved(Overrides{}) {
+		t.Error("Duplicate IDs are collapsed automatically and should count as resolved")
+	}
+}
+
+func TestBuiltinDefinitionsNormalizeToUniqueIDs(t *testing.T) {
+	s := New("")
+	seen := make(map[string]bool)
+	for _, def := range s.effectiveDefinitions() {
+		if seen[def.ID] {
+			t.Errorf("Duplicate ID after normalization: %s", def.ID)
+		}
+		seen[def.ID] = true
+	}
+}
+
+func TestWithDefinitionsAddsPluginApps(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "apps.yaml")).WithDefinitions([]models.AppDefinition{
+		{ID: "dconf", Name: "dconf", Category: "other", ConfigPaths: []string{"~/.config/dconf/user"}},
+	})
+
+	found := false
+	for _, def := range s.effectiveDefinitions() {
+		if def.ID == "dconf" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the plugin definition to be scanned")
+	}
+}
+
+func TestWithPathFilterDropsPaths(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "apps.yaml")).WithDefinitions([]models.AppDefinition{
+		{ID: "keyd", Name: "keyd", Category: "other", ConfigPaths: []string{"/etc/keyd", "~/.config/keyd"}},
+		{ID: "greetd", Name: "greetd", Category: "other", ConfigPaths: []string{"/etc/greetd"}},
+	}).WithPathFilter(func(path string) bool { return strings.HasPrefix(path, "/etc/") })
+
+	for _, def := range s.effectiveDefinitions() {
+		switch def.ID {
+		case "greetd":
+			t.Error("Expected greetd to be dropped with its only path")
+		case "keyd":
+			if len(def.ConfigPaths) != 1 || def.ConfigPaths[0] != "~/.config/keyd" {
+				t.Errorf("Expected only the home path of keyd, got %v", def.ConfigPaths)
+			}
+		}
+	}
+}
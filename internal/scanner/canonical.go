@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"path/filepath"
+
+	"dotsync/internal/models"
+	"dotsync/internal/vfs"
+)
+
+// canonicalIDs folds built-in definitions that describe the same app under
+// another ID into the canonical one, merging their config paths, so the
+// app is listed once and its files sync under one dotfiles directory
+var canonicalIDs = map[string]string{
+	"bashrc":             "bash",
+	"ssh-config":         "ssh",
+	"raycast-extensions": "raycast",
+	"mise-config":        "mise",
+	"nix-config":         "nix",
+	"neomustrr":          "neomutt",
+	"wgetrc":             "wget",
+	"curlrc":             "curl",
+	"pip-config":         "pip",
+	"cargo-config":       "cargo",
+	"prettier-config":    "prettier",
+	"act-config":         "act",
+	"colima-config":      "colima",
+	"gpg-config":         "gnupg",
+	"hyperfine-config":   "hyperfine",
+	"xinit":              "xinitrc",
+	"op-cli":             "1password-cli",
+	"syncthing-config":   "syncthing",
+	"dunst-config":       "dunst",
+	"picom-config":       "picom",
+	"flameshot-config":   "flameshot",
+	"lm-studio":          "lmstudio",
+	"taskfile":           "task",
+}
+
+// Variant is a distribution of an app that keeps its config where the app
+// does, such as LazyVim in ~/.config/nvim. Markers are paths inside the
+// config directory that only the variant creates.
+type Variant struct {
+	ID      string
+	Name    string
+	Markers []string
+}
+
+// variantFamily is an app whose config directory its variants share
+type variantFamily struct {
+	Dir      string
+	Variants []Variant
+}
+
+// variantFamilies are keyed by the canonical app ID
+var variantFamilies = map[string]variantFamily{
+	"nvim": {
+		Dir: "~/.config/nvim",
+		Variants: []Variant{
+			{ID: "lazyvim", Name: "LazyVim", Markers: []string{"lazyvim.json"}},
+			{ID: "astronvim", Name: "AstroNvim", Markers: []string{"lua/community.lua", "lua/plugins/astrocore.lua", "lua/astronvim"}},
+			{ID: "nvchad", Name: "NvChad", Markers: []string{"lua/chadrc.lua"}},
+		},
+	},
+}
+
+// CanonicalID returns the ID id is folded into, or id itself
+func CanonicalID(id string) string {
+	if target, ok := canonicalIDs[id]; ok {
+		return target
+	}
+	for base, family := range variantFamilies {
+		for _, v := range family.Variants {
+			if v.ID == id {
+				return base
+			}
+		}
+	}
+	return id
+}
+
+// DetectVariant returns the variant of app base installed here, if any
+func (s *Scanner) DetectVariant(base string) (Variant, bool) {
+	family, ok := variantFamilies[base]
+	if !ok {
+		return Variant{}, false
+	}
+	dir := s.expandPath(family.Dir)
+	for _, v := range family.Variants {
+		for _, marker := range v.Markers {
+			if _, err := vfs.Stat(filepath.Join(dir, filepath.FromSlash(marker))); err == nil {
+				return v, true
+			}
+		}
+	}
+	return Variant{}, false
+}
+
+// canonicalDefinitions folds the built-in duplicates and variants into
+// their canonical apps. An app whose variant is installed is named after
+// it, e.g. "Neovim (LazyVim)", while keeping the canonical ID so its files
+// sync with machines running another variant or none.
+func (s *Scanner) canonicalDefinitions(defs []models.AppDefinition) []models.AppDefinition {
+	aliases := make(map[string]string, len(canonicalIDs))
+	for _, def := range defs {
+		if target := CanonicalID(def.ID); target != def.ID {
+			aliases[def.ID] = target
+		}
+	}
+	defs = NormalizeDefinitions(defs, Overrides{Aliases: aliases})
+
+	for i := range defs {
+		if v, ok := s.DetectVariant(defs[i].ID); ok {
+			defs[i].Name += " (" + v.Name + ")"
+		}
+	}
+	return defs
+}
@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalID(t *testing.T) {
+	tests := map[string]string{
+		"bashrc":     "bash",
+		"ssh-config": "ssh",
+		"lazyvim":    "nvim",
+		"astronvim":  "nvim",
+		"nvim":       "nvim",
+		"zsh":        "zsh",
+	}
+	for id, want := range tests {
+		if got := CanonicalID(id); got != want {
+			t.Errorf("CanonicalID(%s) = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func TestCanonicalDefinitions_NoSharedPathsBetweenDuplicates(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "apps.yaml"))
+	s.homeDir = t.TempDir()
+
+	byID := make(map[string]bool)
+	for _, def := range s.Definitions() {
+		byID[def.ID] = true
+	}
+	for alias := range canonicalIDs {
+		if byID[alias] {
+			t.Errorf("%s should be folded into %s", alias, canonicalIDs[alias])
+		}
+	}
+	for _, a := range DetectAnomalies(s.Definitions()) {
+		if strings.Contains(a.Path, "nvim") {
+			t.Errorf("Neovim distros should not claim its path anymore: %s", a.Description())
+		}
+	}
+}
+
+func TestDetectVariant(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "apps.yaml"))
+	s.homeDir = t.TempDir()
+	nvimDir := filepath.Join(s.homeDir, ".config", "nvim")
+	os.MkdirAll(nvimDir, 0755)
+
+	if _, ok := s.DetectVariant("nvim"); ok {
+		t.Error("A plain Neovim config should not be a variant")
+	}
+	os.WriteFile(filepath.Join(nvimDir, "lazyvim.json"), []byte("{}"), 0644)
+	v, ok := s.DetectVariant("nvim")
+	if !ok || v.ID != "lazyvim" {
+		t.Fatalf("Expected LazyVim detected, got %+v", v)
+	}
+
+	for _, def := range s.effectiveDefinitions() {
+		if def.ID == "nvim" && def.Name != "Neovim (LazyVim)" {
+			t.Errorf("Expected the detected distro in the name, got %q", def.Name)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// Default scan limits, keeping discovered dirs like ~/.config/gcloud or
+// plugin trees from pulling in tens of thousands of files
+const (
+	DefaultMaxDepth = 5
+	DefaultMaxFiles = 500
+)
+
+// Limits bound how much of an app's config directories a scan collects.
+// Zero values use the defaults.
+type Limits struct {
+	MaxDepth int      // Directory levels walked below a config directory
+	MaxFiles int      // Files collected per app, over all its config paths
+	Ignore   []string // Glob patterns matched against names and relative paths
+}
+
+// WithLimits sets the depth, file count and ignore patterns of the scan
+func (s *Scanner) WithLimits(l Limits) *Scanner {
+	s.limits = l
+	return s
+}
+
+// maxDepth returns the depth limit in effect
+func (l Limits) maxDepth() int {
+	if l.MaxDepth > 0 {
+		return l.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+// maxFiles returns the per-app file limit in effect
+func (l Limits) maxFiles() int {
+	if l.MaxFiles > 0 {
+		return l.MaxFiles
+	}
+	return DefaultMaxFiles
+}
+
+// ignored reports whether an entry matches an ignore pattern, by its name
+// ("*.sqlite") or by its path relative to the config directory's parent
+// ("gcloud/logs"), a matched directory ignoring everything below it
+func (l Limits) ignored(name, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range l.Ignore {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+	}
+	return false
+}
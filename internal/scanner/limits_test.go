@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/models"
+	"dotsync/internal/subtree"
+)
+
+func TestWalkFiles_Limits(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tool")
+	deep := filepath.Join(dir, "a", "b", "c")
+	os.MkdirAll(deep, 0755)
+	os.WriteFile(filepath.Join(deep, "deep.conf"), []byte("x"), 0644)
+	for i := 0; i < 5; i++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.conf", i)), []byte("x"), 0644)
+	}
+	os.MkdirAll(filepath.Join(dir, "logs"), 0755)
+	os.WriteFile(filepath.Join(dir, "logs", "today.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "state.sqlite"), []byte("x"), 0644)
+
+	s := New("").WithLimits(Limits{MaxDepth: 2, Ignore: []string{"*.sqlite", "tool/logs"}})
+	files, truncated, err := s.walkFiles(dir, nil, subtree.Rules{}, s.limits.maxFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated {
+		t.Error("Expected the depth limit to truncate")
+	}
+	for _, f := range files {
+		switch f.Name {
+		case "deep.conf", "today.txt", "state.sqlite":
+			t.Errorf("Expected %s left out", f.RelPath)
+		}
+	}
+
+	if _, truncated, _ := New("").walkFiles(dir, nil, subtree.Rules{}, 3); !truncated {
+		t.Error("Expected the file limit to truncate")
+	}
+	if _, truncated, _ := New("").walkFiles(dir, nil, subtree.Rules{}, 100); truncated {
+		t.Error("Expected no truncation within the default limits")
+	}
+}
+
+func TestScanSingleApp_FileLimitPerApp(t *testing.T) {
+	root := t.TempDir()
+	var paths []string
+	for _, name := range []string{"one", "two"} {
+		dir := filepath.Join(root, name)
+		os.MkdirAll(dir, 0755)
+		for i := 0; i < 3; i++ {
+			os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.conf", i)), []byte("x"), 0644)
+		}
+		paths = append(paths, dir)
+	}
+
+	s := New("").WithLimits(Limits{MaxFiles: 4})
+	app := s.scanSingleApp(models.AppDefinition{ID: "tool", Name: "Tool", ConfigPaths: paths})
+	if app == nil {
+		t.Fatal("Expected the app found")
+	}
+	if !app.Truncated {
+		t.Error("Expected the app marked truncated")
+	}
+	regular := 0
+	for _, f := range app.Files {
+		if !f.IsDir {
+			regular++
+		}
+	}
+	if regular > 4 {
+		t.Errorf("Expected at most 4 files over both paths, got %d", regular)
+	}
+}
@@ -1,7 +1,10 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,28 +13,34 @@ import (
 	"sync"
 	"time"
 
+	"dotsync/internal/browser"
+	"dotsync/internal/gitignore"
+	"dotsync/internal/layout"
 	"dotsync/internal/models"
+	"dotsync/internal/nestedrepo"
+	"dotsync/internal/subtree"
+	"dotsync/internal/symlink"
+	"dotsync/internal/system"
+	"dotsync/internal/vfs"
 
 	"gopkg.in/yaml.v3"
 )
 
-// DebugMode enables debug logging
-var DebugMode = false
-
-// debugLog logs a message if debug mode is enabled
-func debugLog(format string, args ...interface{}) {
-	if DebugMode {
-		fmt.Fprintf(os.Stderr, "[SCANNER] "+format+"\n", args...)
-	}
-}
-
 // Scanner detects installed applications and their config files
 type Scanner struct {
 	configPath string
 	homeDir    string
-	brewApps   map[string]bool // Apps installed via Homebrew
-	brewMu     sync.RWMutex    // Protects brewApps from concurrent access
-	brewWg     sync.WaitGroup  // Waits for brew loading to complete
+	brewApps   map[string]bool          // Apps installed via Homebrew
+	brewMu     sync.RWMutex             // Protects brewApps from concurrent access
+	brewWg     sync.WaitGroup           // Waits for brew loading to complete
+	brewDone   chan struct{}            // Closed once brew loading finished
+	warnings   []string                 // Detection sources that failed, protected by brewMu
+	brewLate   bool                     // Waited BrewWait for brew once already; protected by brewMu
+	overrides  Overrides                // User fixes for definition anomalies
+	filters    map[string]subtree.Rules // Per-app include/exclude rules
+	extraDefs  []models.AppDefinition   // Definitions contributed by plugins
+	limits     Limits                   // Depth, file count and ignore patterns
+	pathFilter func(string) bool        // Config paths left out of the scan
 }
 
 // New creates a new Scanner
@@ -41,58 +50,130 @@ func New(configPath string) *Scanner {
 		configPath: configPath,
 		homeDir:    homeDir,
 		brewApps:   make(map[string]bool),
+		brewDone:   make(chan struct{}),
 	}
 
 	// Load brew apps in background - don't block scanner creation
 	s.brewWg.Add(1)
 	go func() {
 		defer s.brewWg.Done()
+		defer close(s.brewDone)
 		s.loadBrewApps()
 	}()
 
 	return s
 }
 
+// BrewTimeout bounds each `brew list` call, which can hang on a broken tap
+// or a locked Homebrew
+var BrewTimeout = 10 * time.Second
+
+// BrewWait bounds how long a scan waits for the Homebrew list before going
+// on without it
+var BrewWait = 5 * time.Second
+
 // loadBrewApps loads list of apps installed via Homebrew
 func (s *Scanner) loadBrewApps() {
 	start := time.Now()
-	debugLog("Loading Homebrew apps...")
+	slog.Debug("loading Homebrew apps")
 
-	// Get formulae with timeout
-	out, err := exec.Command("brew", "list", "--formula", "-1").Output()
-	if err == nil {
-		for _, app := range strings.Split(string(out), "\n") {
-			app = strings.TrimSpace(app)
-			if app != "" {
-				s.brewMu.Lock()
-				s.brewApps[strings.ToLower(app)] = true
-				s.brewMu.Unlock()
-			}
-		}
+	if _, err := exec.LookPath("brew"); err != nil {
+		slog.Debug("Homebrew not installed")
+		return
 	}
-
-	// Get casks
-	out, err = exec.Command("brew", "list", "--cask", "-1").Output()
-	if err == nil {
+	for _, kind := range []string{"--formula", "--cask"} {
+		out, err := brewList(kind)
+		if err != nil {
+			s.warn(fmt.Sprintf("Homebrew detection failed (brew list %s): %v", kind, err))
+			continue
+		}
+		s.brewMu.Lock()
 		for _, app := range strings.Split(string(out), "\n") {
-			app = strings.TrimSpace(app)
-			if app != "" {
-				s.brewMu.Lock()
+			if app = strings.TrimSpace(app); app != "" {
 				s.brewApps[strings.ToLower(app)] = true
-				s.brewMu.Unlock()
 			}
 		}
+		s.brewMu.Unlock()
 	}
 
 	s.brewMu.RLock()
 	count := len(s.brewApps)
 	s.brewMu.RUnlock()
-	debugLog("Loaded %d Homebrew apps in %v", count, time.Since(start))
+	slog.Debug("loaded Homebrew apps", "count", count, "elapsed", time.Since(start))
+}
+
+// brewList runs `brew list KIND -1`, giving up after BrewTimeout
+func brewList(kind string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), BrewTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "brew", "list", kind, "-1").Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %v", BrewTimeout)
+	}
+	return out, err
+}
+
+// waitBrew waits for the Homebrew list, at most BrewWait in total over
+// the scanner's life. It reports whether the list is complete; a scan that
+// stops waiting goes on with what was loaded and records a warning.
+func (s *Scanner) waitBrew() bool {
+	if s.brewDone == nil {
+		return true
+	}
+	select {
+	case <-s.brewDone:
+		return true
+	default:
+	}
+	s.brewMu.RLock()
+	late := s.brewLate
+	s.brewMu.RUnlock()
+	if late {
+		return false
+	}
+
+	select {
+	case <-s.brewDone:
+		return true
+	case <-time.After(BrewWait):
+		s.brewMu.Lock()
+		s.brewLate = true
+		s.brewMu.Unlock()
+		s.warn(fmt.Sprintf("Homebrew detection still running after %v; apps only installed via brew may be missing", BrewWait))
+		return false
+	}
+}
+
+// warn records a failed detection source once
+func (s *Scanner) warn(msg string) {
+	s.brewMu.Lock()
+	defer s.brewMu.Unlock()
+	for _, w := range s.warnings {
+		if w == msg {
+			return
+		}
+	}
+	slog.Warn("scan warning", "msg", msg)
+	s.warnings = append(s.warnings, msg)
+}
+
+// Warnings returns the detection sources that failed or timed out, so the
+// results may be incomplete
+func (s *Scanner) Warnings() []string {
+	s.brewMu.RLock()
+	defer s.brewMu.RUnlock()
+	return append([]string(nil), s.warnings...)
+}
+
+// WithFilters sets per-app subtree include/exclude rules, keyed by app ID
+func (s *Scanner) WithFilters(filters map[string]subtree.Rules) *Scanner {
+	s.filters = filters
+	return s
 }
 
 // IsBrewInstalled checks if an app is installed via Homebrew
 func (s *Scanner) IsBrewInstalled(appName string) bool {
-	s.brewWg.Wait() // Ensure brew apps are loaded
+	s.waitBrew() // Bounded: a hanging brew must not stall the scan
 	s.brewMu.RLock()
 	defer s.brewMu.RUnlock()
 	return s.brewApps[strings.ToLower(appName)]
@@ -100,33 +181,47 @@ func (s *Scanner) IsBrewInstalled(appName string) bool {
 
 // Scan detects all installed apps and their files using parallel processing
 func (s *Scanner) Scan() ([]*models.App, error) {
+	return s.ScanContext(context.Background())
+}
+
+// ScanContext is Scan stopping early when ctx is cancelled, returning the
+// apps found so far together with ctx's error
+func (s *Scanner) ScanContext(ctx context.Context) ([]*models.App, error) {
 	start := time.Now()
-	debugLog("Starting scan...")
+	slog.Debug("scan started")
 
 	// Load app definitions (built-in + optional custom overrides)
-	defs := s.getBuiltinDefinitions()
-	if customDefs, err := s.loadCustomDefinitions(); err == nil {
-		defs = mergeDefinitions(defs, customDefs)
+	defs := s.effectiveDefinitions()
+	slog.Debug("loaded app definitions", "count", len(defs), "elapsed", time.Since(start))
+
+	// Brew-installed apps count as installed, so wait for the list first
+	s.waitBrew()
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	debugLog("Loaded %d app definitions in %v", len(defs), time.Since(start))
 
 	// Use parallel scanning for better performance
 	parallelStart := time.Now()
-	apps := s.scanAppsParallel(defs)
-	debugLog("Parallel scan found %d installed apps in %v", len(apps), time.Since(parallelStart))
+	apps := s.scanAppsParallel(ctx, defs)
+	slog.Debug("parallel scan finished", "apps", len(apps), "elapsed", time.Since(parallelStart))
+	if err := ctx.Err(); err != nil {
+		slog.Debug("scan cancelled", "elapsed", time.Since(start))
+		return apps, err
+	}
 
 	// Also scan for unknown apps in common locations
 	unknownStart := time.Now()
 	unknownApps := s.scanUnknownApps(apps)
 	apps = append(apps, unknownApps...)
-	debugLog("Found %d unknown apps in %v", len(unknownApps), time.Since(unknownStart))
+	slog.Debug("found unknown apps", "count", len(unknownApps), "elapsed", time.Since(unknownStart))
 
-	debugLog("Total scan completed in %v", time.Since(start))
-	return apps, nil
+	slog.Debug("scan finished", "elapsed", time.Since(start))
+	return apps, ctx.Err()
 }
 
-// scanAppsParallel scans apps in parallel using worker pool pattern
-func (s *Scanner) scanAppsParallel(defs []models.AppDefinition) []*models.App {
+// scanAppsParallel scans apps in parallel using worker pool pattern. Once
+// ctx is cancelled the remaining definitions are skipped.
+func (s *Scanner) scanAppsParallel(ctx context.Context, defs []models.AppDefinition) []*models.App {
 	numWorkers := runtime.NumCPU() * 2 // IO-bound, so use more workers
 	if numWorkers > 16 {
 		numWorkers = 16 // Cap at 16 workers
@@ -143,6 +238,9 @@ func (s *Scanner) scanAppsParallel(defs []models.AppDefinition) []*models.App {
 		go func() {
 			defer wg.Done()
 			for def := range jobs {
+				if ctx.Err() != nil {
+					continue // Drain the queue without scanning
+				}
 				if app := s.scanSingleApp(def); app != nil {
 					results <- app
 				}
@@ -182,10 +280,16 @@ func (s *Scanner) scanSingleApp(def models.AppDefinition) *models.App {
 		if s.pathExists(expandedPath) {
 			app.Installed = true
 
-			// Collect files
-			files, err := s.collectFiles(expandedPath, def.EncryptedFiles)
+			// Collect files, up to the app's file limit over all its paths
+			budget := s.limits.maxFiles() - len(app.Files)
+			if budget <= 0 {
+				app.Truncated = true
+				continue
+			}
+			files, truncated, err := s.walkFiles(expandedPath, def.EncryptedFiles, s.filters[def.ID], budget)
 			if err == nil {
 				app.Files = append(app.Files, files...)
+				app.Truncated = app.Truncated || truncated
 			}
 		}
 	}
@@ -213,7 +317,7 @@ func (s *Scanner) scanUnknownApps(knownApps []*models.App) []*models.App {
 
 	// Scan ~/.config/
 	configDir := filepath.Join(s.homeDir, ".config")
-	entries, err := os.ReadDir(configDir)
+	entries, err := vfs.ReadDir(configDir)
 	if err == nil {
 		for _, entry := range entries {
 			if !entry.IsDir() {
@@ -230,7 +334,7 @@ func (s *Scanner) scanUnknownApps(knownApps []*models.App) []*models.App {
 
 			// Check if has config files
 			dirPath := filepath.Join(configDir, name)
-			files, _ := s.collectFiles(dirPath, nil)
+			files, truncated, _ := s.walkFiles(dirPath, nil, subtree.Rules{}, s.limits.maxFiles())
 
 			if len(files) > 0 {
 				app := &models.App{
@@ -240,6 +344,7 @@ func (s *Scanner) scanUnknownApps(knownApps []*models.App) []*models.App {
 					Icon:      "📦",
 					Installed: true,
 					Files:     files,
+					Truncated: truncated,
 				}
 				unknown = append(unknown, app)
 				knownIDs[id] = true
@@ -10289,10 +10394,7 @@ func (s *Scanner) getBuiltinDefinitions() []models.AppDefinition {
 
 // ScanAll returns all apps including not installed ones
 func (s *Scanner) ScanAll() ([]*models.App, error) {
-	defs := s.getBuiltinDefinitions()
-	if customDefs, err := s.loadCustomDefinitions(); err == nil {
-		defs = mergeDefinitions(defs, customDefs)
-	}
+	defs := s.effectiveDefinitions()
 
 	var apps []*models.App
 
@@ -10318,6 +10420,108 @@ func (s *Scanner) ScanAll() ([]*models.App, error) {
 	return apps, nil
 }
 
+// ScanDotfiles builds the apps a fresh machine would receive from the
+// dotfiles store: every config path whose copy exists in dotfiles, whether
+// or not it exists locally, found through the repo's layout. Returns the
+// apps and the dotfiles app directories that no definition maps back to a
+// local path; layouts without per-app directories report none.
+func (s *Scanner) ScanDotfiles(dotfilesPath string) ([]*models.App, []string) {
+	var apps []*models.App
+	mapped := make(map[string]bool)
+	lay := layout.For(dotfilesPath)
+
+	for _, def := range s.effectiveDefinitions() {
+		app := models.NewApp(def)
+
+		expanded := make([]string, len(def.ConfigPaths))
+		for i, configPath := range def.ConfigPaths {
+			expanded[i] = s.expandPath(configPath)
+		}
+		// Definitions listing both the macOS and the Linux location of a
+		// file restore it where this platform keeps it
+		for _, expandedPath := range layout.NativePaths(expanded, runtime.GOOS) {
+			relPath := filepath.Base(expandedPath)
+
+			info, err := vfs.Stat(layout.Find(lay, dotfilesPath, def.ID, relPath, expandedPath))
+			if err != nil {
+				continue
+			}
+			app.Files = append(app.Files, models.File{
+				Name:       relPath,
+				Path:       expandedPath,
+				RelPath:    relPath,
+				IsDir:      info.IsDir(),
+				Size:       info.Size(),
+				ModTime:    info.ModTime(),
+				Selected:   true,
+				SyncStatus: models.StatusMissing,
+			})
+		}
+
+		if len(app.Files) > 0 {
+			app.Selected = true
+			mapped[def.ID] = true
+			apps = append(apps, app)
+		}
+	}
+
+	// Only per-app directories can be told apart from other content
+	if !layout.IsDefault(lay) {
+		return apps, nil
+	}
+	var unmapped []string
+	entries, _ := vfs.ReadDir(dotfilesPath)
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && !mapped[e.Name()] && e.Name() != system.Dir {
+			unmapped = append(unmapped, e.Name())
+		}
+	}
+
+	return apps, unmapped
+}
+
+// ResolvePath finds the app owning a local path and the path's RelPath
+// within that app's dotfiles directory. The most specific config path wins.
+func (s *Scanner) ResolvePath(path string) (appID, relPath string, ok bool) {
+	path = filepath.Clean(s.expandPath(path))
+	best := -1
+
+	for _, def := range s.effectiveDefinitions() {
+		for _, configPath := range def.ConfigPaths {
+			root := filepath.Clean(s.expandPath(configPath))
+			if len(root) <= best {
+				continue
+			}
+			if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+				continue
+			}
+			best = len(root)
+			appID = def.ID
+			relPath = filepath.Join(filepath.Base(root), strings.TrimPrefix(path, root))
+		}
+	}
+
+	return appID, relPath, best >= 0
+}
+
+// LocalPath is the inverse of ResolvePath: it returns the local path that
+// an app's RelPath (config path basename plus subpath) maps to.
+func (s *Scanner) LocalPath(appID, relPath string) (string, bool) {
+	first, rest, _ := strings.Cut(filepath.ToSlash(relPath), "/")
+	for _, def := range s.effectiveDefinitions() {
+		if def.ID != appID {
+			continue
+		}
+		for _, configPath := range def.ConfigPaths {
+			root := filepath.Clean(s.expandPath(configPath))
+			if filepath.Base(root) == first {
+				return filepath.Join(root, filepath.FromSlash(rest)), true
+			}
+		}
+	}
+	return "", false
+}
+
 // definitionsPath returns the custom definitions file path.
 func (s *Scanner) definitionsPath() string {
 	if strings.TrimSpace(s.configPath) != "" {
@@ -10329,7 +10533,7 @@ func (s *Scanner) definitionsPath() string {
 // loadCustomDefinitions loads custom app definitions from user config file.
 func (s *Scanner) loadCustomDefinitions() ([]models.AppDefinition, error) {
 	path := s.definitionsPath()
-	data, err := os.ReadFile(path)
+	data, err := vfs.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -10366,7 +10570,7 @@ func mergeDefinitions(builtin, custom []models.AppDefinition) []models.AppDefini
 
 // loadDefinitions loads app definitions from YAML
 func (s *Scanner) loadDefinitions() ([]models.AppDefinition, error) {
-	data, err := os.ReadFile(s.configPath)
+	data, err := vfs.ReadFile(s.configPath)
 	if err != nil {
 		return nil, err
 	}
@@ -10389,34 +10593,53 @@ func (s *Scanner) expandPath(path string) string {
 
 // pathExists checks if a path exists
 func (s *Scanner) pathExists(path string) bool {
-	_, err := os.Stat(path)
+	_, err := vfs.Stat(path)
 	return err == nil
 }
 
-// Maximum files to collect per directory (to avoid scanning huge directories)
-const maxFilesPerDir = 200
-
-// Maximum depth to scan in directories
-const maxScanDepth = 5
-
 // collectFiles collects all files from a path
 func (s *Scanner) collectFiles(path string, encryptedFiles []string) ([]models.File, error) {
-	var files []models.File
+	return s.collectFilesFiltered(path, encryptedFiles, subtree.Rules{})
+}
+
+// collectFilesFiltered collects files from a path, honoring subtree rules.
+// Explicitly excluded paths are returned as unselected placeholders (so they
+// can be re-included from the file panel) and are not walked.
+func (s *Scanner) collectFilesFiltered(path string, encryptedFiles []string, rules subtree.Rules) ([]models.File, error) {
+	files, _, err := s.walkFiles(path, encryptedFiles, rules, s.limits.maxFiles())
+	return files, err
+}
 
-	info, err := os.Stat(path)
+// walkFiles is collectFilesFiltered collecting at most maxFiles files and
+// honoring the scan limits; truncated reports whether files were left out
+// for the file count or the depth
+func (s *Scanner) walkFiles(path string, encryptedFiles []string, rules subtree.Rules, maxFiles int) (files []models.File, truncated bool, err error) {
+
+	info, err := vfs.Stat(path)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	if !info.IsDir() {
 		// Single file
 		file, err := models.NewFile(path, filepath.Dir(path))
 		if err != nil {
-			return nil, err
+			return nil, false, err
+		}
+		if s.limits.ignored(file.Name, file.RelPath) {
+			return nil, false, nil
+		}
+		if !rules.Allows(file.RelPath) {
+			if !rules.IsExcluded(file.RelPath) {
+				return nil, false, nil
+			}
+			file.Excluded = true
+			file.Selected = false
 		}
 		file.Encrypted = s.isEncrypted(file.Name, encryptedFiles)
+		file.LinkTarget, _ = symlink.Target(path)
 		files = append(files, *file)
-		return files, nil
+		return files, false, nil
 	}
 
 	// Directory - use parent as basePath so RelPath includes the folder name
@@ -10429,12 +10652,15 @@ func (s *Scanner) collectFiles(path string, encryptedFiles []string) ([]models.F
 	if err == nil {
 		dirFile.IsDir = true
 		dirFile.RelPath = folderName
+		dirFile.LinkTarget, _ = symlink.Target(path)
 		files = append(files, *dirFile)
 	}
 
 	fileCount := 0
+	maxDepth := s.limits.maxDepth()
+	ignore := gitignore.New(path) // The config dir's own .gitignore files
 
-	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+	err = vfs.WalkDir(path, func(p string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
@@ -10446,7 +10672,8 @@ func (s *Scanner) collectFiles(path string, encryptedFiles []string) ([]models.F
 
 		// Check depth limit
 		currentDepth := strings.Count(p, string(os.PathSeparator)) - baseDepth
-		if d.IsDir() && currentDepth >= maxScanDepth {
+		if d.IsDir() && currentDepth >= maxDepth {
+			truncated = true
 			return filepath.SkipDir
 		}
 
@@ -10463,17 +10690,61 @@ func (s *Scanner) collectFiles(path string, encryptedFiles []string) ([]models.F
 			return nil
 		}
 
+		// User ignore patterns and the config dir's .gitignore files
+		if relPath, err := filepath.Rel(basePath, p); err == nil && s.limits.ignored(d.Name(), relPath) || ignore.Ignored(p, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			ignore.Load(p)
+		}
+
 		// Check file limit
-		if fileCount >= maxFilesPerDir {
+		if fileCount >= maxFiles {
+			truncated = true
 			return filepath.SkipAll
 		}
 
+		// Subtree rules: excluded paths become placeholders, others are dropped
+		if relPath, err := filepath.Rel(basePath, p); err == nil && !rules.Allows(relPath) {
+			if rules.IsExcluded(relPath) {
+				if file, err := models.NewFile(p, basePath); err == nil {
+					file.IsDir = d.IsDir()
+					file.Excluded = true
+					file.Selected = false
+					files = append(files, *file)
+				}
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Nested git repos (plugin managers) are recorded as a single entry
+		// and pinned by remote+commit instead of walking their contents
+		if d.IsDir() && nestedrepo.IsRepo(p) {
+			file, err := models.NewFile(p, basePath)
+			if err == nil {
+				file.IsDir = true
+				file.NestedRepo = true
+				files = append(files, *file)
+				fileCount++
+			}
+			return filepath.SkipDir
+		}
+
 		// Add both files and directories - use parent of root as basePath
 		// so RelPath includes the root folder name
 		file, err := models.NewFile(p, basePath)
 		if err == nil {
 			file.IsDir = d.IsDir()
 			file.Encrypted = s.isEncrypted(file.Name, encryptedFiles)
+			if d.Type()&os.ModeSymlink != 0 {
+				file.LinkTarget, _ = symlink.Target(p)
+			}
 			files = append(files, *file)
 			fileCount++
 		}
@@ -10481,7 +10752,7 @@ func (s *Scanner) collectFiles(path string, encryptedFiles []string) ([]models.F
 		return nil
 	})
 
-	return files, err
+	return files, truncated, err
 }
 
 // isEncrypted checks if a file should be encrypted
@@ -10501,6 +10772,10 @@ func (s *Scanner) shouldSkip(name string) bool {
 			return true
 		}
 	}
+	// Browser cookies, logins and keys never sync
+	if browser.IsSensitive(name) {
+		return true
+	}
 	// Check for patterns like *.log, *.bak
 	suffixes := []string{".log", ".bak", ".backup", ".swp", ".swo"}
 	for _, suffix := range suffixes {
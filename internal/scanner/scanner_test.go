@@ -1,11 +1,16 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"dotsync/internal/models"
+	"dotsync/internal/subtree"
 
 	"gopkg.in/yaml.v3"
 )
@@ -53,6 +58,33 @@ func TestIsBrewInstalled(t *testing.T) {
 	}
 }
 
+func TestWaitBrew_Bounded(t *testing.T) {
+	wait := BrewWait
+	BrewWait = 20 * time.Millisecond
+	defer func() { BrewWait = wait }()
+
+	// A brew list that never finishes
+	s := &Scanner{brewApps: map[string]bool{"git": true}, brewDone: make(chan struct{})}
+	start := time.Now()
+	if s.IsBrewInstalled("git") != true {
+		t.Error("Expected what was loaded so far to be used")
+	}
+	if s.IsBrewInstalled("neovim") || s.waitBrew() {
+		t.Error("Expected the list reported incomplete")
+	}
+	if elapsed := time.Since(start); elapsed > 10*BrewWait {
+		t.Errorf("Expected one bounded wait, took %v", elapsed)
+	}
+	if w := s.Warnings(); len(w) != 1 || !strings.Contains(w[0], "Homebrew") {
+		t.Errorf("Expected one Homebrew warning, got %v", w)
+	}
+
+	close(s.brewDone)
+	if !s.waitBrew() {
+		t.Error("Expected the list complete once loading finished")
+	}
+}
+
 func TestExpandPath(t *testing.T) {
 	s := New("")
 
@@ -194,6 +226,34 @@ func TestCollectFiles(t *testing.T) {
 	}
 }
 
+func TestCollectFiles_RecordsLinkTargets(t *testing.T) {
+	s := New("")
+
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "real.toml"), []byte("x"), 0644)
+	dir := filepath.Join(tempDir, "app")
+	os.MkdirAll(dir, 0755)
+	os.Symlink("../real.toml", filepath.Join(dir, "config.toml"))
+	os.Symlink("app/config.toml", filepath.Join(tempDir, "top.toml"))
+
+	files, err := s.collectFiles(dir, nil)
+	if err != nil {
+		t.Fatalf("collectFiles failed: %v", err)
+	}
+	targets := make(map[string]string)
+	for _, f := range files {
+		targets[f.RelPath] = f.LinkTarget
+	}
+	if targets["app"] != "" || targets[filepath.Join("app", "config.toml")] != "../real.toml" {
+		t.Errorf("Unexpected link targets %v", targets)
+	}
+
+	files, err = s.collectFiles(filepath.Join(tempDir, "top.toml"), nil)
+	if err != nil || len(files) != 1 || files[0].LinkTarget != "app/config.toml" {
+		t.Errorf("Expected the top-level link target, got %+v (%v)", files, err)
+	}
+}
+
 func TestCollectFiles_SkipsHiddenAndCache(t *testing.T) {
 	s := New("")
 
@@ -229,6 +289,20 @@ func TestScan(t *testing.T) {
 	t.Logf("Found %d apps", len(apps))
 }
 
+func TestScanContext_Cancelled(t *testing.T) {
+	s := New("")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	apps, err := s.ScanContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if len(apps) != 0 {
+		t.Errorf("Expected no apps from a scan cancelled before it started, got %d", len(apps))
+	}
+}
+
 func TestSkipPatterns(t *testing.T) {
 	expected := []string{".DS_Store", ".git", "node_modules", "__pycache__"}
 	for _, pattern := range expected {
@@ -454,6 +528,33 @@ func TestCollectFiles_WithSubdirectories(t *testing.T) {
 	}
 }
 
+func TestCollectFiles_Gitignore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "doom")
+	os.MkdirAll(filepath.Join(dir, ".local"), 0755)
+	os.MkdirAll(filepath.Join(dir, "eln-cache"), 0755)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("eln-cache/\n*.elc\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "init.el"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "init.elc"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "eln-cache", "a.eln"), []byte("x"), 0644)
+
+	files, err := New("").collectFiles(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, f := range files {
+		names[f.RelPath] = true
+	}
+	if !names[filepath.Join("doom", "init.el")] || !names[filepath.Join("doom", ".gitignore")] {
+		t.Errorf("Expected init.el and .gitignore collected, got %v", names)
+	}
+	for _, ignored := range []string{"init.elc", "eln-cache", filepath.Join("eln-cache", "a.eln")} {
+		if names[filepath.Join("doom", ignored)] {
+			t.Errorf("Expected %s ignored", ignored)
+		}
+	}
+}
+
 func TestCollectFiles_SingleFile(t *testing.T) {
 	tempDir := t.TempDir()
 	s := New("")
@@ -553,3 +654,182 @@ func TestScan_MergesBuiltinAndCustomDefinitions(t *testing.T) {
 		t.Fatalf("missing IDs in merged list: %#v", ids)
 	}
 }
+
+func TestCollectFiles_NestedRepo(t *testing.T) {
+	tempDir := t.TempDir()
+	s := New("")
+
+	// Simulate a plugin checkout inside the config dir
+	pluginDir := filepath.Join(tempDir, "plugins", "telescope")
+	os.MkdirAll(filepath.Join(pluginDir, ".git"), 0755)
+	os.WriteFile(filepath.Join(pluginDir, "plugin.lua"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "init.lua"), []byte("y"), 0644)
+
+	files, err := s.collectFiles(tempDir, nil)
+	if err != nil {
+		t.Fatalf("collectFiles failed: %v", err)
+	}
+
+	var nested *models.File
+	for i := range files {
+		if files[i].Name == "plugin.lua" {
+			t.Error("Files inside nested repo should not be collected")
+		}
+		if files[i].Name == "telescope" {
+			nested = &files[i]
+		}
+	}
+	if nested == nil {
+		t.Fatal("Nested repo should be collected as a single entry")
+	}
+	if !nested.NestedRepo || !nested.IsDir {
+		t.Errorf("Nested repo entry should be a NestedRepo dir, got %+v", nested)
+	}
+}
+
+func TestCollectFilesFiltered_Exclude(t *testing.T) {
+	tempDir := t.TempDir()
+	s := New("")
+
+	fishDir := filepath.Join(tempDir, "fish")
+	os.MkdirAll(filepath.Join(fishDir, "completions"), 0755)
+	os.WriteFile(filepath.Join(fishDir, "config.fish"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(fishDir, "completions", "git.fish"), []byte("y"), 0644)
+
+	rules := subtree.Rules{Exclude: []string{"fish/completions"}}
+	files, err := s.collectFilesFiltered(fishDir, nil, rules)
+	if err != nil {
+		t.Fatalf("collectFilesFiltered failed: %v", err)
+	}
+
+	var placeholder *models.File
+	for i := range files {
+		if files[i].Name == "git.fish" {
+			t.Error("Files under an excluded dir should not be collected")
+		}
+		if files[i].RelPath == filepath.Join("fish", "completions") {
+			placeholder = &files[i]
+		}
+	}
+	if placeholder == nil {
+		t.Fatal("Excluded dir should be kept as a placeholder")
+	}
+	if !placeholder.Excluded || placeholder.Selected {
+		t.Errorf("Placeholder should be excluded and unselected, got %+v", placeholder)
+	}
+}
+
+func TestScanDotfiles(t *testing.T) {
+	tmpHome := t.TempDir()
+	dotfiles := filepath.Join(tmpHome, "dotfiles")
+	customPath := filepath.Join(tmpHome, "apps.yaml")
+
+	cfg := models.AppConfig{Apps: []models.AppDefinition{{
+		ID:          "sandbox-app",
+		Name:        "Sandbox App",
+		ConfigPaths: []string{"~/.sandboxrc", "~/.config/sandbox"},
+	}}}
+	data, _ := yaml.Marshal(cfg)
+	os.WriteFile(customPath, data, 0644)
+
+	os.MkdirAll(filepath.Join(dotfiles, "sandbox-app", "sandbox"), 0755)
+	os.WriteFile(filepath.Join(dotfiles, "sandbox-app", ".sandboxrc"), []byte("x"), 0644)
+	os.MkdirAll(filepath.Join(dotfiles, "mystery"), 0755)
+
+	s := New(customPath)
+	s.homeDir = tmpHome
+
+	apps, unmapped := s.ScanDotfiles(dotfiles)
+
+	var app *models.App
+	for _, a := range apps {
+		if a.ID == "sandbox-app" {
+			app = a
+		}
+	}
+	if app == nil {
+		t.Fatal("Expected sandbox-app from dotfiles even though it is not installed locally")
+	}
+	if len(app.Files) != 2 || !app.Selected {
+		t.Fatalf("Expected 2 selected files, got %+v", app.Files)
+	}
+	if app.Files[0].Path != filepath.Join(tmpHome, ".sandboxrc") || app.Files[0].RelPath != ".sandboxrc" {
+		t.Errorf("Unexpected file mapping: %+v", app.Files[0])
+	}
+	if !app.Files[1].IsDir {
+		t.Error("Config directory should be marked IsDir")
+	}
+
+	if len(unmapped) != 1 || unmapped[0] != "mystery" {
+		t.Errorf("Expected mystery to be unmapped, got %v", unmapped)
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	tmpHome := t.TempDir()
+	customPath := filepath.Join(tmpHome, "apps.yaml")
+
+	cfg := models.AppConfig{Apps: []models.AppDefinition{
+		{ID: "editor", ConfigPaths: []string{"~/.config/editor"}},
+		{ID: "editor-lsp", ConfigPaths: []string{"~/.config/editor/lsp"}},
+		{ID: "shellrc", ConfigPaths: []string{"~/.shellrc"}},
+	}}
+	data, _ := yaml.Marshal(cfg)
+	os.WriteFile(customPath, data, 0644)
+
+	s := New(customPath)
+	s.homeDir = tmpHome
+
+	tests := []struct {
+		path    string
+		appID   string
+		relPath string
+		ok      bool
+	}{
+		{filepath.Join(tmpHome, ".config", "editor", "init.lua"), "editor", filepath.Join("editor", "init.lua"), true},
+		{filepath.Join(tmpHome, ".config", "editor", "lsp", "go.lua"), "editor-lsp", filepath.Join("lsp", "go.lua"), true},
+		{"~/.shellrc", "shellrc", ".shellrc", true},
+		{filepath.Join(tmpHome, ".shellrc.bak"), "", "", false},
+	}
+
+	for _, tt := range tests {
+		appID, relPath, ok := s.ResolvePath(tt.path)
+		if appID != tt.appID || relPath != tt.relPath || ok != tt.ok {
+			t.Errorf("ResolvePath(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.path, appID, relPath, ok, tt.appID, tt.relPath, tt.ok)
+		}
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	tmpHome := t.TempDir()
+	customPath := filepath.Join(tmpHome, "apps.yaml")
+
+	cfg := models.AppConfig{Apps: []models.AppDefinition{
+		{ID: "editor", ConfigPaths: []string{"~/.config/editor", "~/.editorrc"}},
+	}}
+	data, _ := yaml.Marshal(cfg)
+	os.WriteFile(customPath, data, 0644)
+
+	s := New(customPath)
+	s.homeDir = tmpHome
+
+	tests := []struct {
+		appID   string
+		relPath string
+		want    string
+		ok      bool
+	}{
+		{"editor", filepath.Join("editor", "init.lua"), filepath.Join(tmpHome, ".config", "editor", "init.lua"), true},
+		{"editor", ".editorrc", filepath.Join(tmpHome, ".editorrc"), true},
+		{"editor", "other", "", false},
+		{"missing", ".editorrc", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := s.LocalPath(tt.appID, tt.relPath)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("LocalPath(%q, %q) = (%q, %v), want (%q, %v)", tt.appID, tt.relPath, got, ok, tt.want, tt.ok)
+		}
+	}
+}
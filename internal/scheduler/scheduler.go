@@ -0,0 +1,329 @@
+// Package scheduler syncs scheduled jobs: the user's crontab (captured with
+// `crontab -l` into a staged file) and systemd user units in
+// ~/.config/systemd/user, shown as one pseudo-app. After a pull the staged
+// crontab is installed and the units enabled, once the user confirms.
+package scheduler
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dotsync/internal/models"
+)
+
+// AppID is the pseudo-app holding the crontab and user units
+const AppID = "scheduled-tasks"
+
+// DirName is the staging directory inside the config dir
+const DirName = "scheduler"
+
+// CrontabFile is the staged crontab's file name
+const CrontabFile = "crontab"
+
+// offeredFile remembers the last plan shown, so the same plan is not
+// offered again after every pull
+const offeredFile = "offered"
+
+// unitSuffixes are the unit types enabled after a pull
+var unitSuffixes = []string{".service", ".timer", ".socket", ".path"}
+
+// RunFunc runs a command with optional stdin and returns its stdout
+type RunFunc func(stdin []byte, name string, args ...string) ([]byte, error)
+
+// run executes a command on this machine
+func run(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	return cmd.Output()
+}
+
+// Scheduler captures and installs scheduled jobs
+type Scheduler struct {
+	Dir      string // Staging directory
+	UnitsDir string // systemd user unit directory
+	run      RunFunc
+	lookPath func(string) (string, error)
+}
+
+// New creates a scheduler staging into configDir/scheduler
+func New(configDir string) *Scheduler {
+	home, _ := os.UserHomeDir()
+	return &Scheduler{
+		Dir:      filepath.Join(configDir, DirName),
+		UnitsDir: filepath.Join(home, ".config", "systemd", "user"),
+		run:      run,
+		lookPath: exec.LookPath,
+	}
+}
+
+// WithRunner replaces how commands run, e.g. to fake crontab in tests
+func (s *Scheduler) WithRunner(run RunFunc, lookPath func(string) (string, error)) *Scheduler {
+	s.run = run
+	s.lookPath = lookPath
+	return s
+}
+
+// CrontabPath is the staged crontab
+func (s *Scheduler) CrontabPath() string {
+	return filepath.Join(s.Dir, CrontabFile)
+}
+
+func (s *Scheduler) has(tool string) bool {
+	_, err := s.lookPath(tool)
+	return err == nil
+}
+
+// Definitions returns the pseudo-app definition when cron or systemd user
+// units are available here
+func (s *Scheduler) Definitions() []models.AppDefinition {
+	_, unitsErr := os.Stat(s.UnitsDir)
+	if !s.has("crontab") && unitsErr != nil {
+		return nil
+	}
+	return []models.AppDefinition{{
+		ID:          AppID,
+		Name:        "Cron & systemd units",
+		Category:    "system",
+		Icon:        "⏰",
+		ConfigPaths: []string{s.CrontabPath(), s.UnitsDir},
+	}}
+}
+
+// Capture stages the current crontab. A user without a crontab leaves
+// nothing staged.
+func (s *Scheduler) Capture() error {
+	if !s.has("crontab") {
+		return nil
+	}
+	out, err := s.run(nil, "crontab", "-l")
+	if err != nil {
+		// crontab -l fails when the user has no crontab yet
+		return nil
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	if old, err := os.ReadFile(s.CrontabPath()); err == nil && bytes.Equal(old, out) {
+		return nil
+	}
+	return os.WriteFile(s.CrontabPath(), out, 0644)
+}
+
+// AddCronJob adds a job running command on schedule (e.g. "0 9 * * *") to
+// the user's crontab and stages the result. A crontab that already runs
+// command is left alone; the result reports whether the crontab changed.
+func (s *Scheduler) AddCronJob(schedule, command string) (bool, error) {
+	if !s.has("crontab") {
+		return false, fmt.Errorf("crontab is not available")
+	}
+	// crontab -l fails when the user has no crontab yet
+	current, _ := s.run(nil, "crontab", "-l")
+	for _, line := range strings.Split(string(current), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") && strings.HasSuffix(line, command) {
+			return false, nil
+		}
+	}
+
+	updated := string(current)
+	if updated != "" && !strings.HasSuffix(updated, "\n") {
+		updated += "\n"
+	}
+	updated += schedule + " " + command + "\n"
+	if _, err := s.run([]byte(updated), "crontab", "-"); err != nil {
+		return false, fmt.Errorf("installing crontab: %w", err)
+	}
+	return true, s.Capture()
+}
+
+// Plan is what installing the pulled jobs would change
+type Plan struct {
+	Crontab []byte   // Staged crontab to install, nil when it's already active
+	Units   []string // Unit files to enable
+}
+
+// IsEmpty returns true if there is nothing to install
+func (p *Plan) IsEmpty() bool {
+	return p == nil || (p.Crontab == nil && len(p.Units) == 0)
+}
+
+// fingerprint identifies the plan's crontab and units
+func (p *Plan) fingerprint() string {
+	h := sha256.New()
+	h.Write(p.Crontab)
+	for _, unit := range p.Units {
+		fmt.Fprintf(h, "\x00%s", unit)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Plan compares the staged crontab with the active one and lists the
+// installable units that aren't enabled yet. A plan that was already
+// offered comes back empty, so the user is only asked when it changes.
+func (s *Scheduler) Plan() *Plan {
+	p := &Plan{}
+	if staged, err := os.ReadFile(s.CrontabPath()); err == nil && s.has("crontab") {
+		active, _ := s.run(nil, "crontab", "-l")
+		if !bytes.Equal(staged, active) {
+			p.Crontab = staged
+		}
+	}
+	if s.has("systemctl") {
+		for _, unit := range InstallableUnits(s.UnitsDir) {
+			// is-enabled exits non-zero for units that aren't enabled
+			if _, err := s.run(nil, "systemctl", "--user", "is-enabled", "--quiet", unit); err != nil {
+				p.Units = append(p.Units, unit)
+			}
+		}
+	}
+	if !p.IsEmpty() {
+		if offered, err := os.ReadFile(filepath.Join(s.Dir, offeredFile)); err == nil && string(offered) == p.fingerprint() {
+			return &Plan{}
+		}
+	}
+	return p
+}
+
+// offered records p as shown to the user
+func (s *Scheduler) offered(p *Plan) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Dir, offeredFile), []byte(p.fingerprint()), 0644)
+}
+
+// InstallableUnits lists the unit files in dir that have an [Install]
+// section, so they can be enabled
+func InstallableUnits(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var units []string
+	for _, e := range entries {
+		if e.IsDir() || !hasUnitSuffix(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil || !bytes.Contains(data, []byte("[Install]")) {
+			continue
+		}
+		units = append(units, e.Name())
+	}
+	sort.Strings(units)
+	return units
+}
+
+func hasUnitSuffix(name string) bool {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Install applies a plan: installs the crontab, reloads systemd and
+// enables the units
+func (s *Scheduler) Install(p *Plan) error {
+	if p.Crontab != nil {
+		if _, err := s.run(nil, "crontab", s.CrontabPath()); err != nil {
+			return fmt.Errorf("installing crontab: %w", err)
+		}
+	}
+	if len(p.Units) > 0 {
+		if _, err := s.run(nil, "systemctl", "--user", "daemon-reload"); err != nil {
+			return fmt.Errorf("systemctl daemon-reload: %w", err)
+		}
+		args := append([]string{"--user", "enable", "--now"}, p.Units...)
+		if _, err := s.run(nil, "systemctl", args...); err != nil {
+			return fmt.Errorf("enabling units: %w", err)
+		}
+	}
+	return nil
+}
+
+// Session shows a plan on the terminal, asks for confirmation and installs
+// it. It satisfies tea.ExecCommand so the TUI can hand the terminal over.
+type Session struct {
+	Scheduler *Scheduler
+	Plan      *Plan
+	Installed bool // Whether the user confirmed and the install succeeded
+
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// NewSession creates a confirmation session for a plan
+func NewSession(s *Scheduler, p *Plan) *Session {
+	return &Session{Scheduler: s, Plan: p, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+// SetStdin sets where the answer is read from
+func (s *Session) SetStdin(r io.Reader) { s.stdin = r }
+
+// SetStdout sets where the plan is printed
+func (s *Session) SetStdout(w io.Writer) { s.stdout = w }
+
+// SetStderr sets where errors go
+func (s *Session) SetStderr(w io.Writer) { s.stderr = w }
+
+// Run prints the plan and installs it if the user answers yes
+func (s *Session) Run() error {
+	fmt.Fprintln(s.stdout, "==> Pulled scheduled jobs")
+	if s.Plan.Crontab != nil {
+		fmt.Fprintln(s.stdout, "Crontab to install:")
+		for _, line := range strings.Split(strings.TrimRight(string(s.Plan.Crontab), "\n"), "\n") {
+			fmt.Fprintf(s.stdout, "    %s\n", line)
+		}
+	}
+	if len(s.Plan.Units) > 0 {
+		fmt.Fprintf(s.stdout, "systemd user units to enable: %s\n", strings.Join(s.Plan.Units, ", "))
+	}
+	fmt.Fprint(s.stdout, "Install these on this machine? [y/N] ")
+	if err := s.Scheduler.offered(s.Plan); err != nil {
+		fmt.Fprintf(s.stderr, "==> %v\n", err)
+	}
+
+	answer, _ := bufio.NewReader(s.stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+	default:
+		fmt.Fprintln(s.stdout, "Skipped - you will be asked again when the pulled jobs change")
+		return nil
+	}
+
+	if err := s.Scheduler.Install(s.Plan); err != nil {
+		fmt.Fprintf(s.stderr, "==> %v\n", err)
+		return err
+	}
+	s.Installed = true
+	return nil
+}
+
+// Summary returns a short summary of a finished session, or "" when the
+// install was skipped
+func (s *Session) Summary() string {
+	if !s.Installed {
+		return ""
+	}
+	var parts []string
+	if s.Plan.Crontab != nil {
+		parts = append(parts, "crontab installed")
+	}
+	if n := len(s.Plan.Units); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d units enabled", n))
+	}
+	return "Scheduled jobs: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTools records commands and keeps an in-memory crontab and set of
+// enabled units
+type fakeTools struct {
+	crontab  string // Empty means the user has no crontab
+	enabled  map[string]bool
+	commands []string
+}
+
+func (f *fakeTools) run(stdin []byte, name string, args ...string) ([]byte, error) {
+	f.commands = append(f.commands, strings.Join(append([]string{name}, args...), " "))
+	if name == "systemctl" && len(args) > 2 {
+		switch args[1] {
+		case "is-enabled":
+			if !f.enabled[args[len(args)-1]] {
+				return nil, errors.New("disabled")
+			}
+		case "enable":
+			if f.enabled == nil {
+				f.enabled = map[string]bool{}
+			}
+			for _, unit := range args[3:] {
+				f.enabled[unit] = true
+			}
+		}
+		return nil, nil
+	}
+	if name == "crontab" && len(args) == 1 {
+		switch args[0] {
+		case "-l":
+			if f.crontab == "" {
+				return nil, errors.New("no crontab for user")
+			}
+			return []byte(f.crontab), nil
+		case "-":
+			f.crontab = string(stdin)
+			return nil, nil
+		}
+		data, err := os.ReadFile(args[0])
+		f.crontab = string(data)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func newTestScheduler(t *testing.T, f *fakeTools) *Scheduler {
+	t.Helper()
+	s := New(t.TempDir())
+	s.UnitsDir = filepath.Join(t.TempDir(), "systemd", "user")
+	return s.WithRunner(f.run, func(string) (string, error) { return "/usr/bin/tool", nil })
+}
+
+func TestCapture(t *testing.T) {
+	f := &fakeTools{}
+	s := newTestScheduler(t, f)
+
+	if err := s.Capture(); err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if _, err := os.Stat(s.CrontabPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing staged without a crontab, got %v", err)
+	}
+
+	f.crontab = "0 9 * * * dotsync report\n"
+	if err := s.Capture(); err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	data, _ := os.ReadFile(s.CrontabPath())
+	if string(data) != f.crontab {
+		t.Errorf("Expected the crontab staged, got %q", data)
+	}
+
+	defs := s.Definitions()
+	if len(defs) != 1 || defs[0].ID != AppID || defs[0].ConfigPaths[0] != s.CrontabPath() || defs[0].ConfigPaths[1] != s.UnitsDir {
+		t.Errorf("Unexpected definitions %+v", defs)
+	}
+}
+
+func TestAddCronJob(t *testing.T) {
+	f := &fakeTools{crontab: "@reboot backup.sh"}
+	s := newTestScheduler(t, f)
+
+	added, err := s.AddCronJob("0 9 * * *", "/usr/bin/dotsync report")
+	if err != nil || !added {
+		t.Fatalf("Expected the job added, got %v, %v", added, err)
+	}
+	want := "@reboot backup.sh\n0 9 * * * /usr/bin/dotsync report\n"
+	if f.crontab != want {
+		t.Errorf("Expected the job appended, got %q", f.crontab)
+	}
+	if data, _ := os.ReadFile(s.CrontabPath()); string(data) != want {
+		t.Errorf("Expected the new crontab staged, got %q", data)
+	}
+
+	if added, err := s.AddCronJob("30 8 * * *", "/usr/bin/dotsync report"); err != nil || added {
+		t.Errorf("Expected an existing job left alone, got %v, %v", added, err)
+	}
+}
+
+func TestPlanAndSession(t *testing.T) {
+	f := &fakeTools{crontab: "# old\n"}
+	s := newTestScheduler(t, f)
+	os.MkdirAll(s.Dir, 0755)
+	os.WriteFile(s.CrontabPath(), []byte("0 9 * * * dotsync report\n"), 0644)
+	os.MkdirAll(s.UnitsDir, 0755)
+	os.WriteFile(filepath.Join(s.UnitsDir, "backup.timer"), []byte("[Timer]\nOnCalendar=daily\n[Install]\nWantedBy=timers.target\n"), 0644)
+	os.WriteFile(filepath.Join(s.UnitsDir, "backup.service"), []byte("[Service]\nExecStart=/bin/true\n"), 0644)
+	os.WriteFile(filepath.Join(s.UnitsDir, "notes.txt"), []byte("[Install]\n"), 0644)
+
+	plan := s.Plan()
+	if plan.IsEmpty() || string(plan.Crontab) != "0 9 * * * dotsync report\n" {
+		t.Fatalf("Expected the staged crontab in the plan, got %+v", plan)
+	}
+	if len(plan.Units) != 1 || plan.Units[0] != "backup.timer" {
+		t.Errorf("Expected only the installable timer, got %v", plan.Units)
+	}
+
+	// Declining changes nothing
+	session := NewSession(s, plan)
+	var out bytes.Buffer
+	session.SetStdin(strings.NewReader("n\n"))
+	session.SetStdout(&out)
+	if err := session.Run(); err != nil || session.Installed {
+		t.Fatalf("Expected a skipped install, got %v", err)
+	}
+	if f.crontab != "# old\n" || session.Summary() != "" {
+		t.Errorf("Expected the crontab untouched, got %q", f.crontab)
+	}
+	if !strings.Contains(out.String(), "backup.timer") || !strings.Contains(out.String(), "dotsync report") {
+		t.Errorf("Expected the plan to be shown, got %s", out.String())
+	}
+
+	session.SetStdin(strings.NewReader("y\n"))
+	if err := session.Run(); err != nil || !session.Installed {
+		t.Fatalf("Expected the install to run, got %v", err)
+	}
+	if f.crontab != "0 9 * * * dotsync report\n" {
+		t.Errorf("Expected the crontab installed, got %q", f.crontab)
+	}
+	last := f.commands[len(f.commands)-1]
+	if !f.enabled["backup.timer"] {
+		t.Error("Expected the timer enabled")
+	}
+	if last != "systemctl --user enable --now backup.timer" {
+		t.Errorf("Expected the timer enabled, got %q", last)
+	}
+	if got := session.Summary(); got != "Scheduled jobs: crontab installed, 1 units enabled" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+
+	// Once installed, the crontab and enabled units no longer need installing
+	os.Remove(filepath.Join(s.Dir, offeredFile))
+	if plan := s.Plan(); !plan.IsEmpty() {
+		t.Errorf("Expected the active crontab and enabled units to be left out, got %+v", plan)
+	}
+}
+
+func TestPlan_OfferedOnce(t *testing.T) {
+	f := &fakeTools{enabled: map[string]bool{"sync.timer": true}}
+	s := newTestScheduler(t, f)
+	os.MkdirAll(s.UnitsDir, 0755)
+	os.WriteFile(filepath.Join(s.UnitsDir, "sync.timer"), []byte("[Install]\nWantedBy=timers.target\n"), 0644)
+	os.WriteFile(filepath.Join(s.UnitsDir, "backup.timer"), []byte("[Install]\nWantedBy=timers.target\n"), 0644)
+
+	plan := s.Plan()
+	if len(plan.Units) != 1 || plan.Units[0] != "backup.timer" {
+		t.Fatalf("Expected only the disabled unit, got %v", plan.Units)
+	}
+
+	session := NewSession(s, plan)
+	session.SetStdin(strings.NewReader("n\n"))
+	session.SetStdout(&bytes.Buffer{})
+	if err := session.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if plan := s.Plan(); !plan.IsEmpty() {
+		t.Errorf("Expected a declined plan not to be offered again, got %+v", plan)
+	}
+
+	// A pull that changes something asks again
+	os.WriteFile(filepath.Join(s.UnitsDir, "notes.timer"), []byte("[Install]\nWantedBy=timers.target\n"), 0644)
+	if plan := s.Plan(); len(plan.Units) != 2 {
+		t.Errorf("Expected the changed plan offered, got %+v", plan)
+	}
+}
@@ -0,0 +1,134 @@
+// Package secrets finds credentials in config files: tokens with a known
+// shape, private keys, passwords in URLs and the values of keys named like
+// secrets. Redact replaces them, and email addresses, with placeholders so
+// a config can be shared publicly.
+package secrets
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Finding is a secret or email address found in a file
+type Finding struct {
+	Line int    // 1-based line where it starts
+	Kind string // What was found, e.g. github-token or email
+}
+
+// KindEmail is the kind of email address findings
+const KindEmail = "email"
+
+// EmailPlaceholder replaces email addresses in redacted content
+const EmailPlaceholder = "{{email}}"
+
+// Placeholder replaces a secret of the given kind in redacted content
+func Placeholder(kind string) string {
+	return "{{secret:" + kind + "}}"
+}
+
+// rule matches one kind of secret. group is the submatch holding the
+// secret itself; 0 redacts the whole match.
+type rule struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+}
+
+var rules = []rule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), 0},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`), 0},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`), 0},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), 0},
+	{"api-key", regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35})`), 0},
+	{"url-password", regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@]+:([^\s@/]+)@`), 1},
+	{"password", regexp.MustCompile(`(?i)[A-Za-z0-9_.-]*(?:password|passwd|secret|token|api_?key|access_?key|private_?key)[A-Za-z0-9_.-]*["']?\s*[:=]\s*["']?([^\s"',;#]+)`), 1},
+}
+
+var emailRe = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)
+
+// span is a redacted byte range of the content
+type span struct {
+	start, end int
+	kind       string
+}
+
+// Scan returns the secrets and email addresses in data, in file order
+func Scan(data []byte) []Finding {
+	_, findings := Redact(data)
+	return findings
+}
+
+// Redact replaces the secrets in data with Placeholder(kind) and email
+// addresses with EmailPlaceholder, returning the result and what was
+// replaced. Values that already are placeholders or variable references
+// ($TOKEN, ${TOKEN}) are left alone.
+func Redact(data []byte) ([]byte, []Finding) {
+	var spans []span
+	add := func(s span) {
+		for _, o := range spans {
+			if s.start < o.end && o.start < s.end {
+				return // Overlaps an earlier, more specific match
+			}
+		}
+		spans = append(spans, s)
+	}
+
+	for _, r := range rules {
+		for _, m := range r.re.FindAllSubmatchIndex(data, -1) {
+			start, end := m[2*r.group], m[2*r.group+1]
+			if start < 0 || !isSecretValue(data[start:end], r.group > 0) {
+				continue
+			}
+			add(span{start, end, r.kind})
+		}
+	}
+	for _, m := range emailRe.FindAllIndex(data, -1) {
+		add(span{m[0], m[1], KindEmail})
+	}
+	if len(spans) == 0 {
+		return data, nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var out bytes.Buffer
+	findings := make([]Finding, 0, len(spans))
+	last := 0
+	for _, s := range spans {
+		out.Write(data[last:s.start])
+		if s.kind == KindEmail {
+			out.WriteString(EmailPlaceholder)
+		} else {
+			out.WriteString(Placeholder(s.kind))
+		}
+		last = s.end
+		findings = append(findings, Finding{Line: bytes.Count(data[:s.start], []byte("\n")) + 1, Kind: s.kind})
+	}
+	out.Write(data[last:])
+	return out.Bytes(), findings
+}
+
+// isSecretValue filters the values of secret-named keys: references to
+// variables, placeholders, paths, switches and numbers aren't secrets
+func isSecretValue(value []byte, assigned bool) bool {
+	if !assigned {
+		return true
+	}
+	v := string(value)
+	switch {
+	case len(v) < 6:
+		return false
+	case strings.HasPrefix(v, "$"), strings.HasPrefix(v, "{{"), strings.HasPrefix(v, "%"):
+		return false
+	case strings.HasPrefix(v, "/"), strings.HasPrefix(v, "~/"):
+		return false // A path to where the secret is kept
+	case strings.Trim(v, "0123456789") == "":
+		return false
+	}
+	switch strings.ToLower(v) {
+	case "true", "false", "null", "none", "changeme":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+// Package share writes a sanitized copy of stored configs to a second,
+// public repo. Secrets found by the secrets scanner are replaced with
+// placeholders and email addresses templated, while the private dotfiles
+// repo keeps the full files.
+package share
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"dotsync/internal/secrets"
+)
+
+// Entry is a stored config to share
+type Entry struct {
+	AppID string
+	Path  string // Relative to the private repo; directories are walked
+}
+
+// File is a file written to the public repo, or left out of it
+type File struct {
+	AppID    string
+	Path     string // Relative to both repos
+	Findings []secrets.Finding
+	Skipped  string // Why the file was left out, e.g. binary
+	Err      error
+}
+
+// sniffLen is how much of a file is checked for NUL bytes
+const sniffLen = 8000
+
+// Export writes each entry from the private repo into the public one at
+// the same path, with secrets redacted. Binary files are skipped, as the
+// scanner can't look inside them. With dryRun nothing is written.
+func Export(private, public string, entries []Entry, dryRun bool) []File {
+	var files []File
+	for _, e := range entries {
+		root := filepath.Join(private, e.Path)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(private, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, exportFile(private, public, e.AppID, rel, dryRun))
+			return nil
+		})
+		if err != nil {
+			files = append(files, File{AppID: e.AppID, Path: e.Path, Err: err})
+		}
+	}
+	return files
+}
+
+// exportFile writes the sanitized copy of one file
+func exportFile(private, public, appID, rel string, dryRun bool) File {
+	f := File{AppID: appID, Path: rel}
+	src := filepath.Join(private, rel)
+	info, err := os.Lstat(src)
+	if err != nil {
+		f.Err = err
+		return f
+	}
+	if !info.Mode().IsRegular() {
+		f.Skipped = "not a regular file"
+		return f
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		f.Err = err
+		return f
+	}
+	if bytes.IndexByte(data[:min(len(data), sniffLen)], 0) >= 0 {
+		f.Skipped = "binary"
+		return f
+	}
+
+	var out []byte
+	out, f.Findings = secrets.Redact(data)
+	if dryRun {
+		return f
+	}
+	dst := filepath.Join(public, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		f.Err = err
+		return f
+	}
+	f.Err = os.WriteFile(dst, out, info.Mode().Perm())
+	return f
+}
+
+// Count returns how many of the findings are secrets and how many are
+// email addresses
+func (f File) Count() (secretCount, emails int) {
+	for _, finding := range f.Findings {
+		if finding.Kind == secrets.KindEmail {
+			emails++
+		} else {
+			secretCount++
+		}
+	}
+	return secretCount, emails
+}
@@ -0,0 +1,122 @@
+package shellrc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markers around the section dotsync manages in an rc file
+const (
+	BeginMarker = "# >>> dotsync shell blocks >>>"
+	EndMarker   = "# <<< dotsync shell blocks <<<"
+)
+
+const includeHeader = `# Managed by dotsync: shell blocks shared across machines.
+# Choose blocks with "dotsync shell select"; edits here are replaced on pull.
+`
+
+// SharedPath is where the shared blocks live in the dotfiles repo
+func SharedPath(dotfilesPath string) string {
+	return filepath.Join(dotfilesPath, ".dotsync", "shell", "blocks.sh")
+}
+
+// IncludePath is the machine-local include file sourced from rc files
+func IncludePath(configDir string) string {
+	return filepath.Join(configDir, "shell", "blocks.sh")
+}
+
+// DefaultRCFiles returns the rc files in home that exist
+func DefaultRCFiles(home string) []string {
+	var files []string
+	for _, name := range []string{".zshrc", ".bashrc"} {
+		p := filepath.Join(home, name)
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// ParseFile parses an rc or include file; a missing file has no blocks
+func ParseFile(path string) ([]Block, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(data)), nil
+}
+
+// Select returns the blocks whose IDs are in ids, in file order
+func Select(blocks []Block, ids []string) []Block {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	var out []Block
+	for _, b := range blocks {
+		if want[b.ID()] {
+			out = append(out, b)
+			delete(want, b.ID()) // First definition wins
+		}
+	}
+	return out
+}
+
+// Merge updates shared with blocks: existing IDs are replaced in place and
+// new ones appended
+func Merge(shared, blocks []Block) []Block {
+	out := append([]Block(nil), shared...)
+	for _, b := range blocks {
+		if existing := Find(out, b.ID()); existing != nil {
+			*existing = b
+		} else {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// Render writes blocks as an include file that Parse reads back
+func Render(blocks []Block) string {
+	var b strings.Builder
+	b.WriteString(includeHeader)
+	for _, block := range blocks {
+		b.WriteString("\n")
+		b.WriteString(block.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// WriteFile renders blocks into path, creating its directory
+func WriteFile(path string, blocks []Block) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(Render(blocks)), 0644)
+}
+
+// EnsureSource returns rc with a managed section sourcing includePath,
+// replacing an existing section in place or appending a new one
+func EnsureSource(rc, includePath string) string {
+	quoted := "'" + strings.ReplaceAll(includePath, "'", `'\''`) + "'"
+	section := BeginMarker + "\n[ -f " + quoted + " ] && . " + quoted + "\n" + EndMarker
+
+	begin := strings.Index(rc, BeginMarker)
+	end := strings.Index(rc, EndMarker)
+	if begin >= 0 && end > begin {
+		return rc[:begin] + section + rc[end+len(EndMarker):]
+	}
+
+	if rc != "" && !strings.HasSuffix(rc, "\n") {
+		rc += "\n"
+	}
+	if rc != "" {
+		rc += "\n"
+	}
+	return rc + section + "\n"
+}
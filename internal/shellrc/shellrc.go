@@ -0,0 +1,187 @@
+// Package shellrc splits shell rc files (.zshrc, .bashrc) into named blocks
+// (aliases, exports, functions, PATH entries) so single blocks can be
+// synced across machines through a managed include file.
+package shellrc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kind is the type of a shell block
+type Kind string
+
+const (
+	KindAlias    Kind = "alias"
+	KindExport   Kind = "export"
+	KindFunction Kind = "function"
+	KindPath     Kind = "path"
+)
+
+// Block is one definition in an rc file, with the comment lines directly
+// above it
+type Block struct {
+	Kind Kind
+	Name string
+	Text string // Source text, without a trailing newline
+	Line int    // 1-based line where the block (or its comment) starts
+}
+
+// ID identifies a block across machines, e.g. alias:gs or path:$HOME/go/bin
+func (b Block) ID() string {
+	return string(b.Kind) + ":" + b.Name
+}
+
+var (
+	aliasRe    = regexp.MustCompile(`^alias\s+(?:-[a-zA-Z]+\s+)*([^=\s]+)=`)
+	exportRe   = regexp.MustCompile(`^export\s+([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+	pathRe     = regexp.MustCompile(`^(?:export\s+)?PATH=(.*)$`)
+	zshPathRe  = regexp.MustCompile(`^path(\+?)=\((.*)\)$`)
+	funcRe     = regexp.MustCompile(`^(?:function\s+([A-Za-z_][\w.:-]*)\s*(?:\(\s*\))?|([A-Za-z_][\w.:-]*)\s*\(\s*\))\s*\{?`)
+	pathVarRef = regexp.MustCompile(`^\$\{?PATH\}?$|^\$\{?path\}?$|^\$\{?path\[@\]\}?$`)
+)
+
+// Parse splits an rc file into blocks. Lines that aren't aliases, exports,
+// functions or PATH changes are ignored, as is dotsync's managed section.
+func Parse(src string) []Block {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var blocks []Block
+	commentStart := -1
+	inManaged := false
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == BeginMarker:
+			inManaged = true
+			continue
+		case trimmed == EndMarker:
+			inManaged = false
+			commentStart = -1
+			continue
+		case inManaged:
+			continue
+		case trimmed == "":
+			commentStart = -1
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			if commentStart < 0 {
+				commentStart = i
+			}
+			continue
+		}
+
+		// Join backslash continuations into one logical line
+		end := i
+		logical := trimmed
+		for strings.HasSuffix(logical, "\\") && end+1 < len(lines) {
+			end++
+			logical = strings.TrimSuffix(logical, "\\") + " " + strings.TrimSpace(lines[end])
+		}
+
+		block, ok := classify(logical)
+		if ok && block.Kind == KindFunction {
+			end = functionEnd(lines, i)
+		}
+		if ok {
+			start := i
+			if commentStart >= 0 {
+				start = commentStart
+			}
+			block.Text = strings.Join(lines[start:end+1], "\n")
+			block.Line = start + 1
+			blocks = append(blocks, block)
+		}
+		commentStart = -1
+		i = end
+	}
+	return blocks
+}
+
+// classify recognizes the kind and name of a logical line
+func classify(line string) (Block, bool) {
+	if m := aliasRe.FindStringSubmatch(line); m != nil {
+		return Block{Kind: KindAlias, Name: m[1]}, true
+	}
+	if m := pathRe.FindStringSubmatch(line); m != nil {
+		return Block{Kind: KindPath, Name: pathEntries(m[1], ":")}, true
+	}
+	if m := zshPathRe.FindStringSubmatch(line); m != nil {
+		return Block{Kind: KindPath, Name: pathEntries(m[2], " ")}, true
+	}
+	if m := exportRe.FindStringSubmatch(line); m != nil {
+		return Block{Kind: KindExport, Name: m[1]}, true
+	}
+	if m := funcRe.FindStringSubmatch(line); m != nil && (strings.HasPrefix(line, "function") || strings.Contains(line, "{") || strings.HasSuffix(line, ")")) {
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+		return Block{Kind: KindFunction, Name: name}, true
+	}
+	return Block{}, false
+}
+
+// pathEntries names a PATH change by the entries it adds, leaving out the
+// reference to the existing $PATH
+func pathEntries(value, sep string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	var entries []string
+	for _, e := range strings.Split(value, sep) {
+		e = strings.Trim(strings.TrimSpace(e), `"'`)
+		if e != "" && !pathVarRef.MatchString(e) {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == 0 {
+		return value
+	}
+	return strings.Join(entries, ":")
+}
+
+// functionEnd returns the line where the function starting at start closes
+// its outermost brace. Braces inside quotes and comments don't count.
+func functionEnd(lines []string, start int) int {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		var quote byte
+		line := lines[i]
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case quote != 0:
+				if c == '\\' && quote == '"' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '\\':
+				j++
+			case c == '\'' || c == '"':
+				quote = c
+			case c == '#' && (j == 0 || line[j-1] == ' ' || line[j-1] == '\t'):
+				j = len(line)
+			case c == '{':
+				depth++
+				opened = true
+			case c == '}':
+				depth--
+			}
+		}
+		if opened && depth <= 0 {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// Find returns the block with the given ID, or nil
+func Find(blocks []Block, id string) *Block {
+	for i := range blocks {
+		if blocks[i].ID() == id {
+			return &blocks[i]
+		}
+	}
+	return nil
+}
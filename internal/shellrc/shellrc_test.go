@@ -0,0 +1,144 @@
+package shellrc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const zshrc = `# Prompt setup
+autoload -U compinit && compinit
+
+# Git shortcuts
+alias gs='git status'
+alias -g L='| less'
+export EDITOR=nvim
+export PATH="$HOME/go/bin:$PATH"
+path+=(~/.cargo/bin)
+PATH=/opt/bin:$PATH
+
+# Make a dir and cd into it
+mkcd() {
+  mkdir -p "$1" && cd "$1"   # braces in "{strings}" don't count
+}
+
+function extract {
+  case $1 in
+    *.tar.gz) tar xzf "$1" ;;
+  esac
+}
+
+long() { echo one; }
+export LONG=a\
+b
+
+# >>> dotsync shell blocks >>>
+alias managed=x
+# <<< dotsync shell blocks <<<
+`
+
+func TestParse(t *testing.T) {
+	blocks := Parse(zshrc)
+
+	want := []struct {
+		id   string
+		line int
+	}{
+		{"alias:gs", 4},
+		{"alias:L", 6},
+		{"export:EDITOR", 7},
+		{"path:$HOME/go/bin", 8},
+		{"path:~/.cargo/bin", 9},
+		{"path:/opt/bin", 10},
+		{"function:mkcd", 12},
+		{"function:extract", 17},
+		{"function:long", 23},
+		{"export:LONG", 24},
+	}
+	if len(blocks) != len(want) {
+		var ids []string
+		for _, b := range blocks {
+			ids = append(ids, b.ID())
+		}
+		t.Fatalf("Expected %d blocks, got %d: %v", len(want), len(blocks), ids)
+	}
+	for i, w := range want {
+		if blocks[i].ID() != w.id || blocks[i].Line != w.line {
+			t.Errorf("Block %d = %s at line %d, want %s at line %d", i, blocks[i].ID(), blocks[i].Line, w.id, w.line)
+		}
+	}
+
+	if text := Find(blocks, "alias:gs").Text; text != "# Git shortcuts\nalias gs='git status'" {
+		t.Errorf("Expected the comment above to be part of the block, got %q", text)
+	}
+	if text := Find(blocks, "function:mkcd").Text; !strings.HasSuffix(text, "\n}") || !strings.Contains(text, "mkdir") {
+		t.Errorf("Expected the whole function body, got %q", text)
+	}
+	if text := Find(blocks, "export:LONG").Text; text != "export LONG=a\\\nb" {
+		t.Errorf("Expected the continuation line, got %q", text)
+	}
+}
+
+func TestSelectAndMerge(t *testing.T) {
+	blocks := Parse(zshrc)
+	selected := Select(blocks, []string{"function:mkcd", "alias:gs", "alias:missing"})
+	if len(selected) != 2 || selected[0].ID() != "alias:gs" {
+		t.Fatalf("Expected gs and mkcd in file order, got %+v", selected)
+	}
+
+	shared := Parse("alias gs='git status -s'\nalias ll='ls -l'\n")
+	merged := Merge(shared, selected)
+	if len(merged) != 3 || merged[0].Text != "# Git shortcuts\nalias gs='git status'" || merged[2].ID() != "function:mkcd" {
+		t.Errorf("Unexpected merge result %+v", merged)
+	}
+
+	// Render and Parse round-trip
+	again := Parse(Render(merged))
+	if len(again) != len(merged) {
+		t.Fatalf("Expected %d blocks after round-trip, got %d", len(merged), len(again))
+	}
+	for i := range merged {
+		if again[i].ID() != merged[i].ID() || again[i].Text != merged[i].Text {
+			t.Errorf("Round-trip changed block %d: %+v", i, again[i])
+		}
+	}
+}
+
+func TestEnsureSource(t *testing.T) {
+	include := "/home/me/.config/dotsync/shell/blocks.sh"
+
+	rc := EnsureSource("alias x=y", include)
+	if !strings.HasPrefix(rc, "alias x=y\n\n"+BeginMarker) || !strings.Contains(rc, ". '"+include+"'") {
+		t.Errorf("Unexpected rc:\n%s", rc)
+	}
+	if again := EnsureSource(rc, include); again != rc {
+		t.Errorf("EnsureSource should be idempotent, got:\n%s", again)
+	}
+
+	moved := EnsureSource(rc+"alias z=w\n", "/other/blocks.sh")
+	if strings.Count(moved, BeginMarker) != 1 || !strings.Contains(moved, "/other/blocks.sh") || !strings.HasSuffix(moved, "alias z=w\n") {
+		t.Errorf("Expected the section to be updated in place, got:\n%s", moved)
+	}
+}
+
+func TestWriteAndParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shell", "blocks.sh")
+	if blocks, err := ParseFile(path); err != nil || blocks != nil {
+		t.Errorf("Missing file should have no blocks, got %v (%v)", blocks, err)
+	}
+
+	if err := WriteFile(path, Parse("alias a=b\n")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	blocks, err := ParseFile(path)
+	if err != nil || len(blocks) != 1 || blocks[0].ID() != "alias:a" {
+		t.Errorf("Expected alias:a, got %v (%v)", blocks, err)
+	}
+
+	home := t.TempDir()
+	os.WriteFile(filepath.Join(home, ".bashrc"), nil, 0644)
+	if files := DefaultRCFiles(home); len(files) != 1 || filepath.Base(files[0]) != ".bashrc" {
+		t.Errorf("Expected only .bashrc, got %v", files)
+	}
+}
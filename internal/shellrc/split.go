@@ -0,0 +1,234 @@
+package shellrc
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Moved is a piece of an rc file Split moves into the local include
+type Moved struct {
+	Line   int    // 1-based line where the piece starts
+	Text   string // Source text, without a trailing newline
+	Reason string // Why it looks machine-specific
+}
+
+// SplitPlan is the result of splitting an rc file into a shared core and
+// a machine-local include
+type SplitPlan struct {
+	Shared string // The rc file without the moved lines, sourcing LocalPath
+	Local  string // Moved lines, to append to LocalPath
+	Moved  []Moved
+}
+
+var (
+	homePathRe   = regexp.MustCompile(`(?:/Users|/home)/([A-Za-z0-9._-]+)`)
+	brewPrefixRe = regexp.MustCompile(`/opt/homebrew|/home/linuxbrew|/usr/local/Cellar`)
+	mountPathRe  = regexp.MustCompile(`(?:^|[\s"'=:])(?:/Volumes|/mnt|/media)/`)
+	hostRe       = regexp.MustCompile(`\$\{?HOST(?:NAME)?\}?\b|\$\(\s*hostname\b|` + "`hostname")
+	secretRe     = regexp.MustCompile(`(?i)^export\s+[A-Za-z0-9_]*(?:TOKEN|SECRET|PASSWORD|PASSWD|API_?KEY|ACCESS_KEY)[A-Za-z0-9_]*=`)
+	toolBeginRe  = regexp.MustCompile(`^#\s*>>>\s*(.+?)\s*>>>\s*$`)
+	toolEndRe    = regexp.MustCompile(`^#\s*<<<\s*(.+?)\s*<<<\s*$`)
+	compoundRe   = regexp.MustCompile(`^(if|case|for|while|until)\b`)
+	compoundEnd  = map[string]string{"if": "fi", "case": "esac", "for": "done", "while": "done", "until": "done"}
+)
+
+// machineSpecific returns why text only makes sense on this machine, or ""
+func machineSpecific(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		switch {
+		case secretRe.MatchString(trimmed):
+			return "secret"
+		case brewPrefixRe.MatchString(trimmed):
+			return "Homebrew prefix"
+		case homePathRe.MatchString(trimmed):
+			return "hardcoded home directory"
+		case mountPathRe.MatchString(trimmed):
+			return "mounted volume"
+		case hostRe.MatchString(trimmed):
+			return "hostname check"
+		}
+	}
+	return ""
+}
+
+// LocalPath returns the local include of an rc file, e.g. ~/.zshrc.local
+func LocalPath(rc string) string {
+	return rc + ".local"
+}
+
+// sourceLine sources the local include of rc when it exists
+func sourceLine(rc string) string {
+	local := `"$HOME/` + filepath.Base(LocalPath(rc)) + `"`
+	return "[ -f " + local + " ] && . " + local
+}
+
+// Split moves the machine-specific parts of an rc file (hardcoded home
+// directories, Homebrew prefixes, mounted volumes, hostname checks and
+// secrets) into a local include that the shared part sources at its end,
+// so the rc file itself can be synced. Functions, if/case/loop statements
+// and tool-managed sections such as conda's ">>> conda initialize >>>"
+// move as a whole, with the comment lines directly above them.
+func Split(src, rc string) SplitPlan {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	source := sourceLine(rc)
+	var plan SplitPlan
+	var shared, local []string
+	commentStart := -1
+	inManaged := false
+	afterMove := false // Collapse the blank lines around a moved piece
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == BeginMarker:
+			inManaged = true
+		case trimmed == EndMarker:
+			inManaged = false
+		}
+		if trimmed == "" && commentStart < 0 && afterMove && (len(shared) == 0 || shared[len(shared)-1] == "") {
+			continue
+		}
+		if inManaged || trimmed == EndMarker || trimmed == "" || trimmed == source {
+			shared, commentStart = flushComments(shared, lines, commentStart, i), -1
+			shared = append(shared, lines[i])
+			afterMove = afterMove && trimmed == ""
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") && !toolBeginRe.MatchString(trimmed) {
+			if commentStart < 0 {
+				commentStart = i
+			}
+			continue
+		}
+
+		end := unitEnd(lines, i)
+		start := i
+		if commentStart >= 0 {
+			start = commentStart
+		}
+		text := strings.Join(lines[start:end+1], "\n")
+		if reason := machineSpecific(strings.Join(lines[i:end+1], "\n")); reason != "" {
+			plan.Moved = append(plan.Moved, Moved{Line: start + 1, Text: text, Reason: reason})
+			local = append(local, text)
+			afterMove = true
+		} else {
+			shared = append(shared, lines[start:end+1]...)
+			afterMove = false
+		}
+		commentStart = -1
+		i = end
+	}
+	shared = flushComments(shared, lines, commentStart, len(lines))
+
+	if len(plan.Moved) == 0 {
+		plan.Shared = src
+		return plan
+	}
+	out := strings.TrimRight(strings.Join(shared, "\n"), "\n")
+	if !strings.Contains(src, source) {
+		if out != "" {
+			out += "\n\n"
+		}
+		out += "# Machine-specific settings, not synced\n" + source
+	}
+	plan.Shared = out + "\n"
+	plan.Local = strings.Join(local, "\n") + "\n"
+	return plan
+}
+
+// flushComments keeps pending comment lines that no moved piece took
+func flushComments(shared, lines []string, start, end int) []string {
+	if start < 0 {
+		return shared
+	}
+	return append(shared, lines[start:end]...)
+}
+
+// unitEnd returns the last line of the statement starting at line start:
+// a tool-managed section, function, compound statement or logical line
+func unitEnd(lines []string, start int) int {
+	trimmed := strings.TrimSpace(lines[start])
+	if m := toolBeginRe.FindStringSubmatch(trimmed); m != nil {
+		for i := start + 1; i < len(lines); i++ {
+			if e := toolEndRe.FindStringSubmatch(strings.TrimSpace(lines[i])); e != nil && e[1] == m[1] {
+				return i
+			}
+		}
+		return start
+	}
+
+	end := start
+	logical := trimmed
+	for strings.HasSuffix(logical, "\\") && end+1 < len(lines) {
+		end++
+		logical = strings.TrimSuffix(logical, "\\") + " " + strings.TrimSpace(lines[end])
+	}
+	if block, ok := classify(logical); ok && block.Kind == KindFunction {
+		return functionEnd(lines, start)
+	}
+	if m := compoundRe.FindStringSubmatch(logical); m != nil {
+		return compoundStatementEnd(lines, start, m[1])
+	}
+	return end
+}
+
+// compoundStatementEnd finds the line closing an if/case/loop statement,
+// counting nested statements of the same kind
+func compoundStatementEnd(lines []string, start int, keyword string) int {
+	closer := compoundEnd[keyword]
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		for _, word := range strings.FieldsFunc(lines[i], func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ';'
+		}) {
+			if strings.HasPrefix(word, "#") {
+				break
+			}
+			switch {
+			case word == keyword || (closer == "done" && compoundEnd[word] == "done"):
+				depth++
+			case word == closer:
+				depth--
+			}
+		}
+		if depth <= 0 {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// WriteSplit applies a plan to rc: the local include gets the moved lines
+// appended and rc is rewritten as the shared part
+func WriteSplit(rc string, plan SplitPlan) error {
+	if len(plan.Moved) == 0 {
+		return nil
+	}
+	localPath := LocalPath(rc)
+	existing, err := os.ReadFile(localPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	local := string(existing)
+	if local != "" && !strings.HasSuffix(local, "\n") {
+		local += "\n"
+	}
+	if local != "" {
+		local += "\n"
+	}
+	if err := os.WriteFile(localPath, []byte(local+plan.Local), 0600); err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(rc); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return os.WriteFile(rc, []byte(plan.Shared), perm)
+}
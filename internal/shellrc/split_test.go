@@ -0,0 +1,83 @@
+package shellrc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const monolithicRC = `alias gs='git status'
+export EDITOR=nvim
+
+# Work laptop
+export PATH="/Users/alice/work/bin:$PATH"
+eval "$(/opt/homebrew/bin/brew shellenv)"
+export GITHUB_TOKEN=ghp_xxx
+
+if [ "$(hostname)" = "work-mbp" ]; then
+  export HTTP_PROXY=http://proxy:3128
+fi
+
+# >>> conda initialize >>>
+__conda_setup="$('/Users/alice/miniconda3/bin/conda' 'shell.zsh' 'hook')"
+eval "$__conda_setup"
+# <<< conda initialize <<<
+
+alias ll='ls -l'
+`
+
+func TestSplit(t *testing.T) {
+	plan := Split(monolithicRC, "/Users/alice/.zshrc")
+	if len(plan.Moved) != 5 {
+		t.Fatalf("Expected 5 moved pieces, got %+v", plan.Moved)
+	}
+	wantReasons := []string{"hardcoded home directory", "Homebrew prefix", "secret", "hostname check", "hardcoded home directory"}
+	for i, m := range plan.Moved {
+		if m.Reason != wantReasons[i] {
+			t.Errorf("Moved[%d] reason = %q, want %q", i, m.Reason, wantReasons[i])
+		}
+	}
+	if plan.Moved[0].Line != 4 || !strings.HasPrefix(plan.Moved[0].Text, "# Work laptop\n") {
+		t.Errorf("Expected the comment to move with its line, got %+v", plan.Moved[0])
+	}
+	if !strings.HasSuffix(plan.Moved[3].Text, "fi") || !strings.HasSuffix(plan.Moved[4].Text, "# <<< conda initialize <<<") {
+		t.Errorf("Expected statements and tool sections to move whole, got %+v", plan.Moved[3:])
+	}
+
+	wantShared := `alias gs='git status'
+export EDITOR=nvim
+
+alias ll='ls -l'
+
+# Machine-specific settings, not synced
+[ -f "$HOME/.zshrc.local" ] && . "$HOME/.zshrc.local"
+`
+	if plan.Shared != wantShared {
+		t.Errorf("Shared =\n%s\nwant\n%s", plan.Shared, wantShared)
+	}
+
+	// Splitting the shared part again finds nothing
+	if again := Split(plan.Shared, "/Users/alice/.zshrc"); len(again.Moved) != 0 || again.Shared != plan.Shared {
+		t.Errorf("Expected a split rc file to stay as it is, got %+v", again)
+	}
+}
+
+func TestWriteSplit(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), ".bashrc")
+	os.WriteFile(rc, []byte("alias a=b\nexport PATH=/home/bob/bin:$PATH\n"), 0644)
+	os.WriteFile(LocalPath(rc), []byte("export OLD=1"), 0600)
+
+	data, _ := os.ReadFile(rc)
+	if err := WriteSplit(rc, Split(string(data), rc)); err != nil {
+		t.Fatalf("WriteSplit failed: %v", err)
+	}
+	local, _ := os.ReadFile(LocalPath(rc))
+	if string(local) != "export OLD=1\n\nexport PATH=/home/bob/bin:$PATH\n" {
+		t.Errorf("Expected the moved line appended to the local file, got %q", local)
+	}
+	shared, _ := os.ReadFile(rc)
+	if !strings.HasPrefix(string(shared), "alias a=b\n\n") || !strings.Contains(string(shared), `. "$HOME/.bashrc.local"`) {
+		t.Errorf("Unexpected shared rc %q", shared)
+	}
+}
@@ -0,0 +1,214 @@
+// Package sshconfig splits ~/.ssh/config into Host/Match blocks so blocks
+// can be shared or kept machine-only, and merges shared blocks into a local
+// file instead of replacing it.
+package sshconfig
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Block is one Host or Match section, with the comment lines directly above
+// it and the blank lines after it
+type Block struct {
+	Keyword  string   // Host or Match
+	Patterns []string // Arguments of the Host/Match line
+	Text     string
+}
+
+// Name identifies a block by its Host/Match arguments, e.g. "work *.corp"
+func (b Block) Name() string {
+	return strings.Join(b.Patterns, " ")
+}
+
+// Config is a parsed ssh config file
+type Config struct {
+	Preamble string // Global options before the first block
+	Blocks   []Block
+}
+
+// IsConfig reports whether path is an ssh client config (~/.ssh/config)
+func IsConfig(path string) bool {
+	return filepath.Base(path) == "config" && filepath.Base(filepath.Dir(path)) == ".ssh"
+}
+
+// Parse splits src into the preamble and blocks. Joining them again gives
+// back src unchanged.
+func Parse(src string) *Config {
+	lines := strings.SplitAfter(src, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	c := &Config{}
+	var current []string // Lines of the block being read, or the preamble
+	var header *Block
+
+	flush := func(more bool) []string {
+		// Trailing comments belong to the next block
+		cut := len(current)
+		for cut > 0 && isComment(current[cut-1]) {
+			cut--
+		}
+		if !more {
+			cut = len(current)
+		}
+		text := strings.Join(current[:cut], "")
+		if header == nil {
+			c.Preamble = text
+		} else {
+			header.Text = text
+			c.Blocks = append(c.Blocks, *header)
+		}
+		return append([]string(nil), current[cut:]...)
+	}
+
+	for _, line := range lines {
+		keyword, args := splitDirective(line)
+		if keyword == "host" || keyword == "match" {
+			current = flush(true)
+			header = &Block{Keyword: strings.TrimSpace(line)[:len(keyword)], Patterns: args}
+		}
+		current = append(current, line)
+	}
+	flush(false)
+	return c
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
+
+// splitDirective returns the lower-cased keyword of a config line and its
+// arguments. Keywords may be separated from arguments by spaces or "=".
+func splitDirective(line string) (string, []string) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", nil
+	}
+	end := strings.IndexAny(trimmed, " \t=")
+	if end < 0 {
+		return strings.ToLower(trimmed), nil
+	}
+	rest := strings.TrimLeft(trimmed[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+	return strings.ToLower(trimmed[:end]), strings.Fields(rest)
+}
+
+// String joins the config back into file content
+func (c *Config) String() string {
+	var b strings.Builder
+	b.WriteString(c.Preamble)
+	for _, block := range c.Blocks {
+		b.WriteString(block.Text)
+	}
+	return b.String()
+}
+
+// Find returns the block with the given name, or nil
+func (c *Config) Find(name string) *Block {
+	for i := range c.Blocks {
+		if c.Blocks[i].Name() == name {
+			return &c.Blocks[i]
+		}
+	}
+	return nil
+}
+
+// MachineOnly reports whether a block matches one of the machine-only
+// rules. A rule matches the block name or any of its patterns, with
+// filepath.Match globs.
+func MachineOnly(b Block, rules []string) bool {
+	for _, rule := range rules {
+		if rule == b.Name() {
+			return true
+		}
+		for _, p := range b.Patterns {
+			if ok, _ := filepath.Match(rule, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Shared returns the config without machine-only blocks, for the dotfiles
+// copy
+func Shared(c *Config, rules []string) *Config {
+	out := &Config{Preamble: c.Preamble}
+	for _, b := range c.Blocks {
+		if !MachineOnly(b, rules) {
+			out.Blocks = append(out.Blocks, b)
+		}
+	}
+	return out
+}
+
+// Merge brings shared blocks into local. Local blocks are replaced in place
+// by the shared block of the same name; machine-only blocks and blocks not
+// in shared are kept. New shared blocks go before a catch-all "Host *"
+// block, since ssh uses the first value it finds. The local preamble is
+// kept unless it is empty.
+func Merge(local, shared *Config, rules []string) *Config {
+	out := &Config{Preamble: local.Preamble}
+	if strings.TrimSpace(out.Preamble) == "" {
+		out.Preamble = shared.Preamble
+	}
+
+	used := make(map[string]bool)
+	for _, b := range local.Blocks {
+		if !MachineOnly(b, rules) {
+			if s := shared.Find(b.Name()); s != nil {
+				// Keep the local spacing after the block
+				text := strings.TrimRight(s.Text, "\n") + b.Text[len(strings.TrimRight(b.Text, "\n")):]
+				b = *s
+				b.Text = text
+				used[b.Name()] = true
+			}
+		}
+		out.Blocks = append(out.Blocks, b)
+	}
+
+	var added []Block
+	for _, s := range shared.Blocks {
+		if !used[s.Name()] && !MachineOnly(s, rules) && local.Find(s.Name()) == nil {
+			added = append(added, s)
+		}
+	}
+	if len(added) == 0 {
+		return out
+	}
+
+	at := len(out.Blocks)
+	for i, b := range out.Blocks {
+		if strings.EqualFold(b.Keyword, "host") && b.Name() == "*" {
+			at = i
+			break
+		}
+	}
+	// Keep a blank line between the block before the insertion and the new ones
+	if at > 0 && !strings.HasSuffix(out.Blocks[at-1].Text, "\n\n") {
+		out.Blocks[at-1].Text = ensureNewline(out.Blocks[at-1].Text) + "\n"
+	} else if at == 0 && out.Preamble != "" && !strings.HasSuffix(out.Preamble, "\n\n") {
+		out.Preamble = ensureNewline(out.Preamble) + "\n"
+	}
+	for i := range added {
+		added[i].Text = strings.TrimRight(added[i].Text, "\n") + "\n\n"
+	}
+	if at == len(out.Blocks) {
+		last := &added[len(added)-1]
+		last.Text = strings.TrimSuffix(last.Text, "\n")
+	}
+
+	blocks := append([]Block(nil), out.Blocks[:at]...)
+	blocks = append(blocks, added...)
+	out.Blocks = append(blocks, out.Blocks[at:]...)
+	return out
+}
+
+func ensureNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
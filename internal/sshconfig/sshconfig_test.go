@@ -0,0 +1,112 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+const localConfig = `Include ~/.ssh/config.d/*
+AddKeysToAgent yes
+
+# Home server
+Host nas
+  HostName 192.168.1.10
+
+Host work-bastion
+  HostName bastion.corp
+  User me
+
+Host *
+  ServerAliveInterval 60
+`
+
+func TestParse(t *testing.T) {
+	c := Parse(localConfig)
+	if c.String() != localConfig {
+		t.Errorf("Parse should round-trip, got:\n%s", c.String())
+	}
+	if c.Preamble != "Include ~/.ssh/config.d/*\nAddKeysToAgent yes\n\n" {
+		t.Errorf("Unexpected preamble %q", c.Preamble)
+	}
+	if len(c.Blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(c.Blocks))
+	}
+	if c.Blocks[0].Name() != "nas" || c.Blocks[0].Text != "# Home server\nHost nas\n  HostName 192.168.1.10\n\n" {
+		t.Errorf("Comment should belong to the block below it, got %q", c.Blocks[0].Text)
+	}
+
+	m := Parse("Host=a b\n  User x\nmatch host c\n")
+	if len(m.Blocks) != 2 || m.Blocks[0].Name() != "a b" || m.Blocks[1].Keyword != "match" {
+		t.Errorf("Unexpected blocks %+v", m.Blocks)
+	}
+}
+
+func TestShared(t *testing.T) {
+	shared := Shared(Parse(localConfig), []string{"work-*"})
+	if shared.Find("work-bastion") != nil || shared.Find("nas") == nil {
+		t.Errorf("Machine-only blocks should be left out, got:\n%s", shared.String())
+	}
+}
+
+func TestMerge(t *testing.T) {
+	shared := Parse(`Host nas
+  HostName nas.lan
+
+Host github
+  User git
+
+Host work-bastion
+  HostName other.corp
+`)
+
+	merged := Merge(Parse(localConfig), shared, []string{"work-bastion"})
+	want := `Include ~/.ssh/config.d/*
+AddKeysToAgent yes
+
+Host nas
+  HostName nas.lan
+
+Host work-bastion
+  HostName bastion.corp
+  User me
+
+Host github
+  User git
+
+Host *
+  ServerAliveInterval 60
+`
+	if got := merged.String(); got != want {
+		t.Errorf("Merge =\n%s\nwant\n%s", got, want)
+	}
+
+	// Replaced blocks keep the local spacing
+	merged = Merge(Parse("Host a\n  User x\n\nHost b\n"), Parse("Host a\n  User y"), nil)
+	if got := merged.String(); got != "Host a\n  User y\n\nHost b\n" {
+		t.Errorf("Unexpected replace result %q", got)
+	}
+
+	// Without a catch-all, new blocks are appended
+	merged = Merge(Parse("Host a\n  User x\n"), Parse("Host b\n  User y\n"), nil)
+	if got := merged.String(); got != "Host a\n  User x\n\nHost b\n  User y\n" {
+		t.Errorf("Unexpected append result %q", got)
+	}
+
+	// An empty local file takes the shared preamble
+	merged = Merge(Parse(""), Parse("ForwardAgent no\n\nHost b\n"), nil)
+	if got := merged.String(); got != "ForwardAgent no\n\nHost b\n" {
+		t.Errorf("Unexpected result for empty local %q", got)
+	}
+}
+
+func TestIsConfig(t *testing.T) {
+	tests := map[string]bool{
+		"/home/me/.ssh/config":      true,
+		"/home/me/.ssh/known_hosts": false,
+		"/home/me/.config/config":   false,
+	}
+	for path, want := range tests {
+		if got := IsConfig(path); got != want {
+			t.Errorf("IsConfig(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
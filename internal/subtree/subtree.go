@@ -0,0 +1,115 @@
+// Package subtree decides which parts of a directory-based app config are
+// synced, based on per-app include/exclude rules.
+package subtree
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Rules holds include/exclude patterns for one app. Patterns are paths
+// relative to the app's dotfiles directory (the file RelPath, e.g.
+// "fish/completions" or "nvim/lazy-lock.json") and may use filepath.Match
+// globs. A pattern matching a directory applies to everything under it.
+type Rules struct {
+	Include []string `json:"include,omitempty"` // If set, only these subtrees are synced
+	Exclude []string `json:"exclude,omitempty"` // Subtrees that are never synced
+}
+
+// IsEmpty returns true if the rules don't filter anything
+func (r Rules) IsEmpty() bool {
+	return len(r.Include) == 0 && len(r.Exclude) == 0
+}
+
+// Allows reports whether relPath should be synced. The most specific
+// (longest) matching pattern wins when include and exclude overlap.
+func (r Rules) Allows(relPath string) bool {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+
+	in := longestMatch(r.Include, relPath)
+	ex := longestMatch(r.Exclude, relPath)
+
+	if len(r.Include) > 0 && in < 0 && !isAncestorOfAny(relPath, r.Include) {
+		return false
+	}
+	if ex >= 0 && ex >= in {
+		return false
+	}
+	return true
+}
+
+// IsExcluded returns true if relPath is exactly one of the exclude patterns
+func (r Rules) IsExcluded(relPath string) bool {
+	return contains(r.Exclude, filepath.ToSlash(relPath))
+}
+
+// IsIncluded returns true if relPath is exactly one of the include patterns
+func (r Rules) IsIncluded(relPath string) bool {
+	return contains(r.Include, filepath.ToSlash(relPath))
+}
+
+// ToggleExclude adds or removes relPath from the exclude list
+func (r *Rules) ToggleExclude(relPath string) {
+	r.Exclude = toggle(r.Exclude, filepath.ToSlash(relPath))
+}
+
+// ToggleInclude adds or removes relPath from the include list
+func (r *Rules) ToggleInclude(relPath string) {
+	r.Include = toggle(r.Include, filepath.ToSlash(relPath))
+}
+
+// longestMatch returns the length of the longest pattern matching relPath
+// or one of its ancestors, or -1 if none match
+func longestMatch(patterns []string, relPath string) int {
+	best := -1
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		for p := relPath; p != "." && p != "/" && p != ""; p = parent(p) {
+			if ok, _ := filepath.Match(pattern, p); ok {
+				if len(pattern) > best {
+					best = len(pattern)
+				}
+				break
+			}
+		}
+	}
+	return best
+}
+
+// isAncestorOfAny returns true if relPath is a directory on the way to one
+// of the patterns (so the walk can reach included subtrees)
+func isAncestorOfAny(relPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		if strings.HasPrefix(pattern, relPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func parent(p string) string {
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return ""
+	}
+	return p[:i]
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func toggle(list []string, s string) []string {
+	for i, item := range list {
+		if item == s {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,61 @@
+package subtree
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   Rules
+		relPath string
+		want    bool
+	}{
+		{"empty rules", Rules{}, "fish/config.fish", true},
+		{"excluded dir", Rules{Exclude: []string{"fish/completions"}}, "fish/completions", false},
+		{"under excluded dir", Rules{Exclude: []string{"fish/completions"}}, "fish/completions/git.fish", false},
+		{"sibling of excluded", Rules{Exclude: []string{"fish/completions"}}, "fish/functions/x.fish", true},
+		{"prefix is not parent", Rules{Exclude: []string{"fish/comp"}}, "fish/completions", true},
+		{"glob exclude", Rules{Exclude: []string{"nvim/*.json"}}, "nvim/lazy-lock.json", false},
+		{"include only", Rules{Include: []string{"nvim/lua"}}, "nvim/lua/plugins.lua", true},
+		{"outside include", Rules{Include: []string{"nvim/lua"}}, "nvim/after/x.lua", false},
+		{"ancestor of include", Rules{Include: []string{"nvim/lua"}}, "nvim", true},
+		{"specific include beats exclude", Rules{
+			Exclude: []string{"nvim/lua"},
+			Include: []string{"nvim/lua/core"},
+		}, "nvim/lua/core/init.lua", true},
+		{"specific exclude beats include", Rules{
+			Include: []string{"nvim/lua"},
+			Exclude: []string{"nvim/lua/secret.lua"},
+		}, "nvim/lua/secret.lua", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.Allows(tt.relPath); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.relPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggle(t *testing.T) {
+	var r Rules
+	if !r.IsEmpty() {
+		t.Error("Zero rules should be empty")
+	}
+
+	r.ToggleExclude("fish/completions")
+	if !r.IsExcluded("fish/completions") {
+		t.Error("Path should be excluded after toggle")
+	}
+
+	r.ToggleInclude("fish/functions")
+	if !r.IsIncluded("fish/functions") {
+		t.Error("Path should be included after toggle")
+	}
+
+	r.ToggleExclude("fish/completions")
+	r.ToggleInclude("fish/functions")
+	if !r.IsEmpty() {
+		t.Errorf("Rules should be empty after toggling back, got %+v", r)
+	}
+}
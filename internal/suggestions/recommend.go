@@ -0,0 +1,155 @@
+package suggestions
+
+import (
+	"fmt"
+	"sort"
+
+	"dotsync/internal/models"
+)
+
+// Kind identifies what a recommendation is about
+type Kind string
+
+const (
+	KindConflicts    Kind = "conflicts"     // Files changed on both sides
+	KindNeverPushed  Kind = "never_pushed"  // Apps with nothing in dotfiles yet
+	KindUnpushed     Kind = "unpushed"      // Local changes waiting for a push
+	KindManyMachines Kind = "many_machines" // Apps backed up separately on several machines
+	KindLargeFile    Kind = "large_file"    // Files too big to belong in a git repo
+)
+
+// LargeFileSize is the size from which a file is reported as large
+const LargeFileSize = 5 << 20
+
+// ManyMachines is the number of machines from which an app backed up on
+// each of them is better off in sync mode
+const ManyMachines = 2
+
+// Recommendation is one thing the user may want to do, with the apps or
+// files it concerns
+type Recommendation struct {
+	Kind    Kind
+	Message string
+	Action  Action
+	AppIDs  []string
+	Files   []string // Local paths
+}
+
+// Facts is what recommendations are drawn from
+type Facts struct {
+	Apps     []*models.App
+	Tracked  map[string]bool     // Apps with a recorded sync state
+	Machines map[string][]string // App ID -> machines holding a backup of it
+}
+
+// Recommend lists what the user may want to do, most pressing first
+func (a *Analyzer) Recommend(f Facts) []Recommendation {
+	var conflicts, unpushed, never []string
+	var largeApps, largeFiles []string
+	conflictFiles := 0
+
+	for _, app := range f.Apps {
+		hasConflict, hasUnpushed, allNew, files := false, false, true, 0
+		for _, file := range app.Files {
+			if file.Excluded {
+				continue
+			}
+			files++
+			switch file.ConflictType {
+			case models.ConflictBothModified:
+				hasConflict = true
+				conflictFiles++
+			case models.ConflictLocalModified:
+				hasUnpushed = true
+			}
+			if file.ConflictType != models.ConflictLocalNew {
+				allNew = false
+			}
+			if !file.IsDir && file.Selected && file.Size >= LargeFileSize {
+				largeFiles = append(largeFiles, file.Path)
+				if len(largeApps) == 0 || largeApps[len(largeApps)-1] != app.ID {
+					largeApps = append(largeApps, app.ID)
+				}
+			}
+		}
+		if hasConflict {
+			conflicts = append(conflicts, app.ID)
+		}
+		if hasUnpushed {
+			unpushed = append(unpushed, app.ID)
+		}
+		if files > 0 && allNew && !f.Tracked[app.ID] {
+			never = append(never, app.ID)
+		}
+	}
+
+	var recs []Recommendation
+	if len(conflicts) > 0 {
+		recs = append(recs, Recommendation{
+			Kind:    KindConflicts,
+			Message: plural(conflictFiles, "file changed", "files changed") + " both locally and in dotfiles",
+			Action:  Action{Key: "Enter", Label: "Show conflicts", Description: "Filter the app list to conflicted files"},
+			AppIDs:  conflicts,
+		})
+	}
+	if len(unpushed) > 0 {
+		recs = append(recs, Recommendation{
+			Kind:    KindUnpushed,
+			Message: plural(len(unpushed), "app has", "apps have") + " local changes not pushed yet",
+			Action:  Action{Key: "Enter", Label: "Push them", Description: "Select these apps and push"},
+			AppIDs:  unpushed,
+		})
+	}
+	if len(never) > 0 {
+		recs = append(recs, Recommendation{
+			Kind:    KindNeverPushed,
+			Message: plural(len(never), "app never pushed", "apps never pushed"),
+			Action:  Action{Key: "Enter", Label: "Push them", Description: "Select these apps and push"},
+			AppIDs:  never,
+		})
+	}
+	recs = append(recs, a.manyMachines(f.Machines)...)
+	if len(largeFiles) > 0 {
+		recs = append(recs, Recommendation{
+			Kind:    KindLargeFile,
+			Message: plural(len(largeFiles), "large file", "large files") + " selected for sync",
+			Action:  Action{Key: "Enter", Label: "Deselect", Description: "Leave these files out of the sync"},
+			AppIDs:  largeApps,
+			Files:   largeFiles,
+		})
+	}
+	return recs
+}
+
+// manyMachines recommends sync mode for each app that is backed up
+// separately on several machines
+func (a *Analyzer) manyMachines(machines map[string][]string) []Recommendation {
+	ids := make([]string, 0, len(machines))
+	for id := range machines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var recs []Recommendation
+	for _, id := range ids {
+		n := len(machines[id])
+		if n < ManyMachines || (a.modesConfig != nil && a.modesConfig.IsAppSynced(id)) {
+			continue
+		}
+		recs = append(recs, Recommendation{
+			Kind:    KindManyMachines,
+			Message: fmt.Sprintf("%s is backed up separately on %d machines - consider sync mode", id, n),
+			Action:  Action{Key: "Enter", Label: "Enable sync", Description: "Share one copy across machines"},
+			AppIDs:  []string{id},
+		})
+	}
+	return recs
+}
+
+// plural formats n with the singular or plural noun phrase
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -3,6 +3,7 @@ package suggestions
 import (
 	"testing"
 
+	"dotsync/internal/models"
 	"dotsync/internal/modes"
 )
 
@@ -200,3 +201,54 @@ func TestQuickAnalyzeSingularMessages(t *testing.T) {
 		t.Errorf("expected singular message, got %s", s.Message)
 	}
 }
+
+func TestRecommend(t *testing.T) {
+	apps := []*models.App{
+		{ID: "zsh", Files: []models.File{
+			{Path: "/h/.zshrc", ConflictType: models.ConflictBothModified},
+			{Path: "/h/.zsh_history", Selected: true, Size: LargeFileSize},
+		}},
+		{ID: "git", Files: []models.File{{Path: "/h/.gitconfig", ConflictType: models.ConflictLocalModified}}},
+		{ID: "fish", Files: []models.File{{Path: "/h/fish", IsDir: true, ConflictType: models.ConflictLocalNew}}},
+		{ID: "kitty", Files: []models.File{{Path: "/h/kitty.conf", ConflictType: models.ConflictLocalNew}}},
+	}
+	modesCfg := modes.Default()
+	modesCfg.SyncedApps["git"] = true
+	facts := Facts{
+		Apps:     apps,
+		Tracked:  map[string]bool{"kitty": true},
+		Machines: map[string][]string{"zsh": {"a", "b", "c"}, "git": {"a", "b"}, "nvim": {"a"}},
+	}
+
+	recs := NewAnalyzer(modesCfg).Recommend(facts)
+	var kinds []Kind
+	for _, r := range recs {
+		kinds = append(kinds, r.Kind)
+	}
+	want := []Kind{KindConflicts, KindUnpushed, KindNeverPushed, KindManyMachines, KindLargeFile}
+	if len(kinds) != len(want) {
+		t.Fatalf("Recommend() kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("Recommend()[%d] = %s, want %s", i, kinds[i], want[i])
+		}
+	}
+
+	if recs[0].Message != "1 file changed both locally and in dotfiles" {
+		t.Errorf("Unexpected conflicts message %q", recs[0].Message)
+	}
+	if len(recs[2].AppIDs) != 1 || recs[2].AppIDs[0] != "fish" {
+		t.Errorf("Expected only the untracked new app as never pushed, got %v", recs[2].AppIDs)
+	}
+	if recs[3].Message != "zsh is backed up separately on 3 machines - consider sync mode" {
+		t.Errorf("Unexpected machines message %q", recs[3].Message)
+	}
+	if len(recs[4].Files) != 1 || recs[4].Files[0] != "/h/.zsh_history" {
+		t.Errorf("Expected the large selected file, got %v", recs[4].Files)
+	}
+
+	if recs := NewAnalyzer(modesCfg).Recommend(Facts{}); len(recs) != 0 {
+		t.Errorf("Expected no recommendations without apps, got %+v", recs)
+	}
+}
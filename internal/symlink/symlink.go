@@ -0,0 +1,69 @@
+// Package symlink decides how configs that are symlinks (e.g. managed by
+// GNU stow) sync, instead of blindly copying whatever they point to.
+package symlink
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Policy controls how a symlinked config syncs
+type Policy string
+
+const (
+	PolicyFollow     Policy = "follow"           // Sync through the link; pull writes into the target (default)
+	PolicyCopyTarget Policy = "copy-target"      // Store the target's content; pull replaces the link with a regular copy
+	PolicySkip       Policy = "skip"             // Leave symlinks out of push and pull
+	PolicyPreserve   Policy = "preserve-as-link" // Store the link itself; pull recreates it
+)
+
+// Policies lists all policies in the order they cycle in settings
+var Policies = []Policy{PolicyFollow, PolicyCopyTarget, PolicySkip, PolicyPreserve}
+
+// ParsePolicy converts a config string to a Policy, defaulting to PolicyFollow
+func ParsePolicy(s string) Policy {
+	for _, p := range Policies {
+		if string(p) == s {
+			return p
+		}
+	}
+	return PolicyFollow
+}
+
+// Next returns the next policy in the cycle
+func (p Policy) Next() Policy {
+	for i, policy := range Policies {
+		if policy == p {
+			return Policies[(i+1)%len(Policies)]
+		}
+	}
+	return PolicyFollow
+}
+
+// Target returns the target of path as stored in the link, or false if
+// path isn't a symlink
+func Target(path string) (string, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
+
+// Create makes path a symlink to target, replacing whatever is there
+func Create(target, path string) error {
+	if current, ok := Target(path); ok && current == target {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return os.Symlink(target, path)
+}
@@ -0,0 +1,55 @@
+package symlink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Policy
+	}{
+		{"", PolicyFollow},
+		{"copy-target", PolicyCopyTarget},
+		{"skip", PolicySkip},
+		{"preserve-as-link", PolicyPreserve},
+		{"bogus", PolicyFollow},
+	}
+	for _, tt := range tests {
+		if got := ParsePolicy(tt.input); got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+	if PolicyPreserve.Next() != PolicyFollow {
+		t.Error("Expected the cycle to wrap around")
+	}
+}
+
+func TestTargetAndCreate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "zshrc")
+	os.WriteFile(file, []byte("x"), 0644)
+	if _, ok := Target(file); ok {
+		t.Error("A regular file is not a symlink")
+	}
+
+	link := filepath.Join(dir, "home", ".zshrc")
+	if err := Create("../zshrc", link); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if target, ok := Target(link); !ok || target != "../zshrc" {
+		t.Errorf("Expected ../zshrc, got %q (%v)", target, ok)
+	}
+
+	// Replaces a regular file
+	os.Remove(link)
+	os.WriteFile(link, []byte("old"), 0644)
+	if err := Create("../zshrc", link); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if data, err := os.ReadFile(link); err != nil || string(data) != "x" {
+		t.Errorf("Expected the link to resolve to the target, got %q (%v)", data, err)
+	}
+}
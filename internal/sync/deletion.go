@@ -0,0 +1,198 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"dotsync/internal/config"
+	"dotsync/internal/layout"
+	"dotsync/internal/vfs"
+)
+
+// TombstoneFile lists configs deleted on some machine, at the dotfiles root
+const TombstoneFile = ".dotsync-tombstones.json"
+
+// Tombstone records a config deleted on one machine so others prune it too
+type Tombstone struct {
+	AppID     string    `json:"app_id"`
+	RelPath   string    `json:"rel_path"`
+	Hash      string    `json:"hash"` // Last synced content; only unmodified copies are pruned
+	Machine   string    `json:"machine,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// LoadTombstones reads the tombstones from a dotfiles directory
+func LoadTombstones(dotfilesPath string) ([]Tombstone, error) {
+	data, err := vfs.ReadFile(filepath.Join(dotfilesPath, TombstoneFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tombstones []Tombstone
+	if err := json.Unmarshal(data, &tombstones); err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
+
+// SaveTombstones writes the tombstones to a dotfiles directory
+func SaveTombstones(dotfilesPath string, tombstones []Tombstone) error {
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].AppID+"/"+tombstones[i].RelPath < tombstones[j].AppID+"/"+tombstones[j].RelPath
+	})
+	data, err := json.MarshalIndent(tombstones, "", "  ")
+	if err != nil {
+		return err
+	}
+	return vfs.WriteFile(filepath.Join(dotfilesPath, TombstoneFile), data, 0644)
+}
+
+// DeletionSide is where a config was deleted
+type DeletionSide int
+
+const (
+	DeletedLocally    DeletionSide = iota // Push removes the dotfiles copy
+	DeletedInDotfiles                     // Pull removes the local file
+)
+
+// Deletion is a config deleted on one side since it was last synced
+type Deletion struct {
+	AppID        string
+	RelPath      string
+	LocalPath    string
+	DotfilesPath string
+	Side         DeletionSide
+	Hash         string // Last synced hash of the surviving copy
+	Machine      string // Machine that deleted it (tombstones only)
+}
+
+// LocalPathFunc maps an app's RelPath back to its local path
+type LocalPathFunc func(appID, relPath string) (string, bool)
+
+// FindDeletions lists configs that were synced before and are now gone on
+// one side. Local deletions come from sync state; dotfiles deletions are
+// only reported from tombstones, so a missing or unmounted store never
+// looks like a mass deletion. Files modified since the last sync are skipped.
+func FindDeletions(cfg *config.Config, stateManager *StateManager, localPath LocalPathFunc) ([]Deletion, error) {
+	var deletions []Deletion
+	lay := layout.For(cfg.DotfilesPath)
+
+	for _, state := range stateManager.Files() {
+		local, ok := localPath(state.AppID, state.RelPath)
+		if !ok || state.LocalHash == "" {
+			continue
+		}
+		dotfiles := layout.Find(lay, cfg.DotfilesPath, state.AppID, state.RelPath, local)
+		if exists(local) || !exists(dotfiles) {
+			continue
+		}
+		if hash, err := ComputeFileHash(dotfiles); err != nil || hash != state.DotfilesHash {
+			continue // Changed in dotfiles since the deletion; not safe to prune
+		}
+		deletions = append(deletions, Deletion{
+			AppID:        state.AppID,
+			RelPath:      state.RelPath,
+			LocalPath:    local,
+			DotfilesPath: dotfiles,
+			Side:         DeletedLocally,
+			Hash:         state.DotfilesHash,
+		})
+	}
+
+	tombstones, err := LoadTombstones(cfg.DotfilesPath)
+	if err != nil {
+		return deletions, fmt.Errorf("read tombstones: %w", err)
+	}
+	for _, t := range tombstones {
+		local, ok := localPath(t.AppID, t.RelPath)
+		if !ok || !exists(local) {
+			continue
+		}
+		dotfiles := layout.Find(lay, cfg.DotfilesPath, t.AppID, t.RelPath, local)
+		if exists(dotfiles) {
+			continue // Pushed again after the deletion
+		}
+		if hash, err := ComputeFileHash(local); err != nil || hash != t.Hash {
+			continue // Edited here since; keep it
+		}
+		deletions = append(deletions, Deletion{
+			AppID:        t.AppID,
+			RelPath:      t.RelPath,
+			LocalPath:    local,
+			DotfilesPath: dotfiles,
+			Side:         DeletedInDotfiles,
+			Hash:         t.Hash,
+			Machine:      t.Machine,
+		})
+	}
+
+	return deletions, nil
+}
+
+// PropagateDeletions applies deletions on the other side: local deletions
+// remove the dotfiles copy and leave a tombstone, tombstoned files are
+// backed up and removed locally. Sync state for each file is dropped.
+func PropagateDeletions(cfg *config.Config, stateManager *StateManager, deletions []Deletion, machine string) (int, error) {
+	tombstones, err := LoadTombstones(cfg.DotfilesPath)
+	if err != nil {
+		return 0, fmt.Errorf("read tombstones: %w", err)
+	}
+	index := make(map[string]int)
+	for i, t := range tombstones {
+		index[t.AppID+"/"+t.RelPath] = i
+	}
+
+	done := 0
+	var errs []error
+	for _, d := range deletions {
+		switch d.Side {
+		case DeletedLocally:
+			if err := vfs.RemoveAll(d.DotfilesPath); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			t := Tombstone{AppID: d.AppID, RelPath: d.RelPath, Hash: d.Hash, Machine: machine, DeletedAt: time.Now()}
+			if i, ok := index[d.AppID+"/"+d.RelPath]; ok {
+				tombstones[i] = t
+			} else {
+				index[d.AppID+"/"+d.RelPath] = len(tombstones)
+				tombstones = append(tombstones, t)
+			}
+			GetHashCache().InvalidatePath(d.DotfilesPath)
+		case DeletedInDotfiles:
+			if _, err := Backup(d.LocalPath, cfg.BackupPath); err != nil {
+				errs = append(errs, fmt.Errorf("backup %s: %w", d.LocalPath, err))
+				continue
+			}
+			if err := vfs.RemoveAll(d.LocalPath); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			GetHashCache().InvalidatePath(d.LocalPath)
+		}
+		stateManager.RemoveFileState(d.AppID, d.RelPath)
+		done++
+	}
+
+	if len(tombstones) > 0 {
+		if err := SaveTombstones(cfg.DotfilesPath, tombstones); err != nil {
+			errs = append(errs, fmt.Errorf("write tombstones: %w", err))
+		}
+	}
+	if err := stateManager.Save(); err != nil {
+		errs = append(errs, fmt.Errorf("save sync state: %w", err))
+	}
+	return done, errors.Join(errs...)
+}
+
+func exists(path string) bool {
+	_, err := vfs.Lstat(path)
+	return err == nil
+}
@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/config"
+)
+
+func TestDeletionPropagation(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+
+	localDir := filepath.Join(tempDir, "home")
+	os.MkdirAll(localDir, 0755)
+	os.MkdirAll(filepath.Join(cfg.DotfilesPath, "app"), 0755)
+	localPath := func(appID, relPath string) (string, bool) {
+		return filepath.Join(localDir, relPath), appID == "app"
+	}
+
+	// Machine A synced app/old.conf, then deleted it locally
+	dotfilesCopy := filepath.Join(cfg.DotfilesPath, "app", "old.conf")
+	os.WriteFile(dotfilesCopy, []byte("content"), 0644)
+	hash, _ := ComputeFileHashNoCache(dotfilesCopy)
+	smA := NewStateManager(filepath.Join(tempDir, "a"))
+	smA.SetFileState("app", "old.conf", hash, hash)
+
+	deletions, err := FindDeletions(cfg, smA, localPath)
+	if err != nil {
+		t.Fatalf("FindDeletions failed: %v", err)
+	}
+	if len(deletions) != 1 || deletions[0].Side != DeletedLocally {
+		t.Fatalf("Expected one local deletion, got %+v", deletions)
+	}
+
+	if n, err := PropagateDeletions(cfg, smA, deletions, "machine-a"); err != nil || n != 1 {
+		t.Fatalf("PropagateDeletions = (%d, %v)", n, err)
+	}
+	if _, err := os.Stat(dotfilesCopy); !os.IsNotExist(err) {
+		t.Error("Dotfiles copy should be removed")
+	}
+	if _, ok := smA.GetFileState("app", "old.conf"); ok {
+		t.Error("Sync state should be dropped")
+	}
+	tombstones, _ := LoadTombstones(cfg.DotfilesPath)
+	if len(tombstones) != 1 || tombstones[0].Machine != "machine-a" || tombstones[0].Hash != hash {
+		t.Fatalf("Unexpected tombstones: %+v", tombstones)
+	}
+
+	// Machine B still has an unmodified copy and prunes it from the tombstone
+	os.WriteFile(filepath.Join(localDir, "old.conf"), []byte("content"), 0644)
+	smB := NewStateManager(filepath.Join(tempDir, "b"))
+
+	deletions, err = FindDeletions(cfg, smB, localPath)
+	if err != nil {
+		t.Fatalf("FindDeletions failed: %v", err)
+	}
+	if len(deletions) != 1 || deletions[0].Side != DeletedInDotfiles || deletions[0].Machine != "machine-a" {
+		t.Fatalf("Expected one tombstoned deletion, got %+v", deletions)
+	}
+	if _, err := PropagateDeletions(cfg, smB, deletions, "machine-b"); err != nil {
+		t.Fatalf("PropagateDeletions failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(localDir, "old.conf")); !os.IsNotExist(err) {
+		t.Error("Local copy should be removed")
+	}
+	if entries, _ := os.ReadDir(cfg.BackupPath); len(entries) == 0 {
+		t.Error("Local copy should be backed up before removal")
+	}
+}
+
+func TestFindDeletions_KeepsModifiedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	os.MkdirAll(cfg.DotfilesPath, 0755)
+
+	localDir := filepath.Join(tempDir, "home")
+	os.MkdirAll(localDir, 0755)
+	os.WriteFile(filepath.Join(localDir, "edited.conf"), []byte("edited here"), 0644)
+	localPath := func(appID, relPath string) (string, bool) {
+		return filepath.Join(localDir, relPath), true
+	}
+
+	SaveTombstones(cfg.DotfilesPath, []Tombstone{{AppID: "app", RelPath: "edited.conf", Hash: "old"}})
+
+	deletions, err := FindDeletions(cfg, NewStateManager(tempDir), localPath)
+	if err != nil {
+		t.Fatalf("FindDeletions failed: %v", err)
+	}
+	if len(deletions) != 0 {
+		t.Errorf("Locally edited file should not be pruned, got %+v", deletions)
+	}
+}
@@ -2,10 +2,11 @@ package sync
 
 import (
 	"bufio"
-	"os"
 	"strconv"
 	"strings"
 
+	"dotsync/internal/vfs"
+
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
@@ -54,7 +55,7 @@ func ComputeDiff(oldPath, newPath string) (*DiffResult, error) {
 	}
 
 	// Read old file
-	oldContent, oldErr := os.ReadFile(oldPath)
+	oldContent, oldErr := vfs.ReadFile(oldPath)
 	result.OldExists = oldErr == nil
 	oldText := ""
 	if oldErr == nil {
@@ -62,7 +63,7 @@ func ComputeDiff(oldPath, newPath string) (*DiffResult, error) {
 	}
 
 	// Read new file
-	newContent, newErr := os.ReadFile(newPath)
+	newContent, newErr := vfs.ReadFile(newPath)
 	result.NewExists = newErr == nil
 	newText := ""
 	if newErr == nil {
@@ -109,8 +110,9 @@ func ComputeDiff(oldPath, newPath string) (*DiffResult, error) {
 	// Use line mode diff for better results on text files
 	chars1, chars2, lineArray := dmp.DiffLinesToChars(oldText, newText)
 	diffs := dmp.DiffMain(chars1, chars2, false)
-	diffs = dmp.DiffCharsToLines(diffs, lineArray)
+	// Clean up while each char is still a whole line, so edits stay line-aligned
 	diffs = dmp.DiffCleanupSemantic(diffs)
+	diffs = dmp.DiffCharsToLines(diffs, lineArray)
 
 	// Check if identical
 	if len(diffs) == 1 && diffs[0].Type == diffmatchpatch.DiffEqual {
@@ -173,23 +175,7 @@ func convertToHunks(diffs []diffmatchpatch.Diff, oldLines, newLines []string) []
 
 		case diffmatchpatch.DiffDelete:
 			if currentHunk == nil {
-				currentHunk = &DiffHunk{
-					StartOld: max(1, oldLineNum-contextLines),
-					StartNew: max(1, newLineNum-contextLines),
-				}
-				// Add context before
-				if equalsSinceChange > 0 {
-					contextStart := max(0, len(oldLines)-equalsSinceChange)
-					for i := max(0, contextStart); i < contextStart+min(contextLines, equalsSinceChange) && i < len(oldLines); i++ {
-						if i < oldLineNum-1 {
-							currentHunk.DiffLines = append(currentHunk.DiffLines, DiffLine{
-								Type:    DiffEqual,
-								Content: oldLines[i],
-								LineNum: i + 1,
-							})
-						}
-					}
-				}
+				currentHunk = startHunk(oldLines, oldLineNum, newLineNum, min(contextLines, equalsSinceChange))
 			}
 			equalsSinceChange = 0
 
@@ -206,10 +192,7 @@ func convertToHunks(diffs []diffmatchpatch.Diff, oldLines, newLines []string) []
 
 		case diffmatchpatch.DiffInsert:
 			if currentHunk == nil {
-				currentHunk = &DiffHunk{
-					StartOld: max(1, oldLineNum-contextLines),
-					StartNew: max(1, newLineNum-contextLines),
-				}
+				currentHunk = startHunk(oldLines, oldLineNum, newLineNum, min(contextLines, equalsSinceChange))
 			}
 			equalsSinceChange = 0
 
@@ -233,9 +216,27 @@ func convertToHunks(diffs []diffmatchpatch.Diff, oldLines, newLines []string) []
 	return hunks
 }
 
+// startHunk opens a hunk at the given 1-based line numbers, led by the
+// context lines just before them
+func startHunk(oldLines []string, oldLineNum, newLineNum, context int) *DiffHunk {
+	context = min(context, oldLineNum-1)
+	hunk := &DiffHunk{
+		StartOld: oldLineNum - context,
+		StartNew: newLineNum - context,
+	}
+	for i := oldLineNum - 1 - context; i < oldLineNum-1 && i < len(oldLines); i++ {
+		hunk.DiffLines = append(hunk.DiffLines, DiffLine{
+			Type:    DiffEqual,
+			Content: oldLines[i],
+			LineNum: i + 1,
+		})
+	}
+	return hunk
+}
+
 // readLines reads a file into lines (kept for compatibility)
 func readLines(path string) ([]string, error) {
-	file, err := os.Open(path)
+	file, err := vfs.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"runtime"
+	"sync"
+
+	"dotsync/internal/models"
+)
+
+// DiffStat counts how a local file differs from its dotfiles copy: the
+// lines added and removed locally since it was last pushed
+type DiffStat struct {
+	App          *models.App
+	File         *models.File
+	Added        int
+	Removed      int
+	LocalOnly    bool // Not in dotfiles yet
+	DotfilesOnly bool // Missing locally
+	Err          error
+}
+
+// DiffStats compares the selected files of the selected apps with the
+// dotfiles repo, several files at a time, and returns the ones that
+// differ in app and file order. Directories and excluded placeholders are
+// left out.
+func DiffStats(dotfilesPath string, apps []*models.App) []DiffStat {
+	var stats []DiffStat
+	for _, app := range apps {
+		if !app.Selected {
+			continue
+		}
+		for i := range app.Files {
+			file := &app.Files[i]
+			if file.Selected && !file.IsDir && !file.Excluded {
+				stats = append(stats, DiffStat{App: app, File: file})
+			}
+		}
+	}
+
+	numWorkers := min(runtime.NumCPU()*2, 16, len(stats)) // IO-bound
+	jobs := make(chan int, len(stats))
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				stats[i].compute(dotfilesPath)
+			}
+		}()
+	}
+	for i := range stats {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	changed := stats[:0]
+	for _, s := range stats {
+		if s.Err != nil || s.Added > 0 || s.Removed > 0 || s.LocalOnly || s.DotfilesOnly {
+			changed = append(changed, s)
+		}
+	}
+	return changed
+}
+
+// compute fills in the stat, with the dotfiles copy as the old side
+func (s *DiffStat) compute(dotfilesPath string) {
+	result, err := ComputeDiff(DotfilePath(dotfilesPath, s.App.ID, *s.File), s.File.Path)
+	if err != nil {
+		s.Err = err
+		return
+	}
+	if result.Identical {
+		return
+	}
+	s.Added, s.Removed = result.LinesAdded, result.LinesRemoved
+	s.LocalOnly = !result.OldExists
+	s.DotfilesOnly = !result.NewExists
+}
@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/models"
+)
+
+func TestDiffStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	dotfiles := filepath.Join(tmpDir, "dotfiles")
+	local := filepath.Join(tmpDir, "home")
+	write := func(path, content string) {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	write(filepath.Join(local, "same"), "a\nb\n")
+	write(filepath.Join(dotfiles, "app", "same"), "a\nb\n")
+	write(filepath.Join(local, "edited"), "a\nB\nc\n")
+	write(filepath.Join(dotfiles, "app", "edited"), "a\nb\n")
+	write(filepath.Join(local, "new"), "x\n")
+	write(filepath.Join(local, "unselected"), "y\n")
+
+	file := func(name string, selected bool) models.File {
+		return models.File{Name: name, RelPath: name, Path: filepath.Join(local, name), Selected: selected}
+	}
+	app := &models.App{ID: "app", Selected: true, Files: []models.File{
+		file("same", true), file("edited", true), file("new", true), file("unselected", false),
+		{Name: "dir", RelPath: "dir", Path: local, IsDir: true, Selected: true},
+	}}
+	other := &models.App{ID: "other", Files: []models.File{file("new", true)}}
+
+	stats := DiffStats(dotfiles, []*models.App{app, other})
+	if len(stats) != 2 {
+		t.Fatalf("Expected the edited and new files, got %+v", stats)
+	}
+	if s := stats[0]; s.File.RelPath != "edited" || s.Added != 2 || s.Removed != 1 || s.LocalOnly {
+		t.Errorf("Expected edited with +2 -1, got %+v", s)
+	}
+	if s := stats[1]; s.File.RelPath != "new" || !s.LocalOnly || s.App != app {
+		t.Errorf("Expected new to be local only, got %+v", s)
+	}
+	if stats := DiffStats(dotfiles, nil); len(stats) != 0 {
+		t.Errorf("Expected no stats without apps, got %+v", stats)
+	}
+}
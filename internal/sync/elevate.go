@@ -0,0 +1,47 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ElevatedCopyCmd returns the command copying src to dst as root through
+// sudo, for configs under system paths (/etc/nginx, /etc/pacman.conf) that
+// a normal user can't read or write. The file is copied as is, without the
+// filters a regular sync applies. With own the copy is handed back to the
+// current user, as pushes into the dotfiles repo need.
+func ElevatedCopyCmd(src, dst string, isDir, own bool) (*exec.Cmd, error) {
+	if runtime.GOOS == "windows" {
+		return nil, errors.New("copying with elevated permissions needs sudo, which Windows lacks")
+	}
+	sudo, err := exec.LookPath("sudo")
+	if err != nil {
+		return nil, fmt.Errorf("sudo not found: %w", err)
+	}
+	owner := ""
+	if own {
+		owner = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	}
+	return exec.Command(sudo, elevatedCopyArgs(src, dst, isDir, owner)...), nil
+}
+
+// elevatedCopyArgs is the shell command of ElevatedCopyCmd, with the paths
+// passed as arguments rather than quoted into the script. A non-empty
+// owner is chowned the copy.
+func elevatedCopyArgs(src, dst string, isDir bool, owner string) []string {
+	script := `set -e; mkdir -p "$(dirname "$2")"; `
+	if isDir {
+		script += `mkdir -p "$2"; cp -pR "$1/." "$2"; `
+	} else {
+		script += `cp -p "$1" "$2"; `
+	}
+	args := []string{src, dst}
+	if owner != "" {
+		script += `chown -R "$3" "$2"; `
+		args = append(args, owner)
+	}
+	return append([]string{"sh", "-c", script, "sh"}, args...)
+}
@@ -0,0 +1,39 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestElevatedCopyArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sh on Windows")
+	}
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "etc", "nginx")
+	os.MkdirAll(filepath.Join(src, "sites"), 0755)
+	os.WriteFile(filepath.Join(src, "nginx.conf"), []byte("worker_processes 1;"), 0644)
+	os.WriteFile(filepath.Join(src, "sites", "it's default"), []byte("server {}"), 0644)
+
+	// The script runs as is without sudo, owned by whoever runs the test
+	owner := fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	dst := filepath.Join(tempDir, "dotfiles", "nginx", "nginx")
+	if out, err := exec.Command("sh", elevatedCopyArgs(src, dst, true, owner)[1:]...).CombinedOutput(); err != nil {
+		t.Fatalf("copy failed: %v: %s", err, out)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "sites", "it's default")); err != nil || string(data) != "server {}" {
+		t.Errorf("Expected the tree copied, got %q, %v", data, err)
+	}
+
+	file := filepath.Join(tempDir, "restored", "nginx.conf")
+	if out, err := exec.Command("sh", elevatedCopyArgs(filepath.Join(src, "nginx.conf"), file, false, "")[1:]...).CombinedOutput(); err != nil {
+		t.Fatalf("copy failed: %v: %s", err, out)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("Expected the file copied: %v", err)
+	}
+}
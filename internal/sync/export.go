@@ -5,15 +5,32 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"dotsync/internal/browser"
+	"dotsync/internal/clone"
 	"dotsync/internal/config"
+	"dotsync/internal/gitconfig"
+	"dotsync/internal/gitignore"
+	"dotsync/internal/jsonkeys"
+	"dotsync/internal/layout"
+	"dotsync/internal/localsection"
 	"dotsync/internal/models"
+	"dotsync/internal/nestedrepo"
+	"dotsync/internal/sshconfig"
+	"dotsync/internal/subtree"
+	"dotsync/internal/symlink"
+	"dotsync/internal/vfs"
+	"dotsync/internal/xattr"
 )
 
 // Exporter handles exporting configs from system to dotfiles
 type Exporter struct {
-	config *config.Config
+	config   *config.Config
+	volatile []string // JSONPath rules of the app being exported
+	appID    string   // App being exported
+	perms    *Perms   // Modes of the exported files, while exporting an app
 }
 
 // NewExporter creates a new Exporter
@@ -34,13 +51,28 @@ type ExportResult struct {
 func (e *Exporter) ExportApp(app *models.App) ([]ExportResult, error) {
 	var results []ExportResult
 
-	destDir := e.config.GetDestPath(app.ID)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	lay, err := layout.Load(e.config.DotfilesPath)
+	if err != nil {
+		return nil, err
+	}
+	if layout.IsDefault(lay) {
+		if err := vfs.MkdirAll(e.config.GetDestPath(app.ID), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create destination directory: %w", err)
+		}
+	}
+
+	perms, err := LoadPerms(e.config.DotfilesPath)
+	if err != nil {
+		return nil, err
 	}
 
+	rules := e.subtreeRules(app.ID)
+	e.volatile = e.config.VolatileKeys[app.ID]
+	e.appID, e.perms = app.ID, perms
+	defer func() { e.volatile, e.appID, e.perms = nil, "", nil }()
+
 	for _, file := range app.Files {
-		if !file.Selected {
+		if !file.Selected || file.Excluded || !rules.Allows(file.RelPath) || e.skipsSymlink(file.Path) {
 			continue
 		}
 
@@ -50,14 +82,22 @@ func (e *Exporter) ExportApp(app *models.App) ([]ExportResult, error) {
 			Encrypted: file.Encrypted,
 		}
 
-		destPath := filepath.Join(destDir, file.RelPath)
+		destPath := layout.Find(lay, e.config.DotfilesPath, app.ID, file.RelPath, file.Path)
 
-		if file.IsDir {
-			err := e.copyDir(file.Path, destPath)
+		if handled, err := e.exportSymlink(file.Path, destPath, file.RelPath, rules); handled {
+			result.Success = err == nil
+			result.Error = err
+		} else if file.NestedRepo && e.nestedRepoMode() != nestedrepo.ModeCopy {
+			err := e.exportNestedRepo(lay, app.ID, file)
+			result.Success = err == nil
+			result.Error = err
+		} else if file.IsDir {
+			perms.Forget(app.ID, file.RelPath) // Files gone locally drop out
+			err := e.copyTree(file.Path, destPath, file.RelPath, rules)
 			result.Success = err == nil
 			result.Error = err
 		} else {
-			err := e.copyFile(file.Path, destPath)
+			err := e.exportFile(file.Path, destPath, file.RelPath)
 			result.Success = err == nil
 			result.Error = err
 		}
@@ -65,6 +105,9 @@ func (e *Exporter) ExportApp(app *models.App) ([]ExportResult, error) {
 		results = append(results, result)
 	}
 
+	if err := perms.Save(); err != nil {
+		return results, fmt.Errorf("failed to save permissions manifest: %w", err)
+	}
 	return results, nil
 }
 
@@ -87,15 +130,48 @@ func (e *Exporter) ExportAll(apps []*models.App) ([]ExportResult, error) {
 	return allResults, nil
 }
 
+// ExportFile pushes one file or directory of an app from src to dst in
+// dotfiles the way a push does, leaving out machine-specific parts. It is
+// for pushes that pick the dotfiles path themselves, like quick backup.
+func (e *Exporter) ExportFile(appID, relPath, src, dst string) error {
+	perms, err := LoadPerms(e.config.DotfilesPath)
+	if err != nil {
+		return err
+	}
+	rules := e.subtreeRules(appID)
+	e.volatile = e.config.VolatileKeys[appID]
+	e.appID, e.perms = appID, perms
+	defer func() { e.volatile, e.appID, e.perms = nil, "", nil }()
+
+	if e.skipsSymlink(src) {
+		return nil
+	}
+	if handled, err := e.exportSymlink(src, dst, relPath, rules); handled {
+		if err != nil {
+			return err
+		}
+	} else if info, err := vfs.Stat(src); err != nil {
+		return err
+	} else if info.IsDir() {
+		perms.Forget(appID, relPath) // Files gone locally drop out
+		if err := e.copyTree(src, dst, relPath, rules); err != nil {
+			return err
+		}
+	} else if err := e.exportFile(src, dst, relPath); err != nil {
+		return err
+	}
+	return perms.Save()
+}
+
 // copyFile copies a single file
 func (e *Exporter) copyFile(src, dst string) error {
 	// Create destination directory
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+	if err := vfs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return err
 	}
 
 	// Open source file
-	srcFile, err := os.Open(src)
+	srcFile, err := vfs.Open(src)
 	if err != nil {
 		return err
 	}
@@ -107,8 +183,13 @@ func (e *Exporter) copyFile(src, dst string) error {
 		return err
 	}
 
+	// Clone large files where the filesystem can, copy otherwise
+	if vfs.Real() && srcInfo.Size() >= clone.MinSize && clone.File(src, dst) == nil {
+		return nil
+	}
+
 	// Create destination file
-	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
+	dstFile, err := vfs.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
 	if err != nil {
 		return err
 	}
@@ -122,21 +203,200 @@ func (e *Exporter) copyFile(src, dst string) error {
 	return nil
 }
 
+// exportFile copies a file into dotfiles, leaving out machine-specific
+// parts: git identities, machine-only ssh hosts, volatile JSON keys and
+// machine-local sections
+func (e *Exporter) exportFile(src, dst, relPath string) error {
+	if relPath == "" {
+		return e.copyFile(src, dst)
+	}
+	e.recordMode(src, relPath)
+	if err := e.writeFile(src, dst, relPath); err != nil {
+		return err
+	}
+	return e.syncXattrs(src, dst, relPath)
+}
+
+// writeFile writes the dotfiles copy of an exported file
+func (e *Exporter) writeFile(src, dst, relPath string) error {
+	if e.config != nil && gitconfig.IsConfig(src) {
+		// Identities and credentials become placeholders
+		return writeFiltered(src, dst, func(data []byte) ([]byte, error) {
+			return []byte(gitconfig.Templatize(string(data), e.config.GitProtectedKeys)), nil
+		})
+	}
+	if e.config != nil && len(e.config.SSHLocalHosts) > 0 && sshconfig.IsConfig(src) {
+		return writeFiltered(src, dst, func(data []byte) ([]byte, error) {
+			shared := sshconfig.Shared(sshconfig.Parse(string(data)), e.config.SSHLocalHosts)
+			return []byte(shared.String()), nil
+		})
+	}
+
+	rules := jsonkeys.For(e.volatile, relPath)
+	local := localsection.FileHas(src)
+	if len(rules) == 0 && !local {
+		return e.copyFile(src, dst)
+	}
+	// Never fall back to a plain copy: the keys may hold secrets
+	return writeFiltered(src, dst, func(data []byte) ([]byte, error) {
+		data = localsection.Strip(data)
+		if len(rules) == 0 {
+			return data, nil
+		}
+		stripped, err := jsonkeys.Strip(data, rules)
+		if err != nil {
+			return nil, fmt.Errorf("strip volatile keys from %s: %w", relPath, err)
+		}
+		return stripped, nil
+	})
+}
+
+// exportSymlink applies the app's symlink policy when src is a symlink and
+// reports whether it handled the copy. Links to files that sync through
+// their target are left to the regular copy. Exporters without a config
+// (plain copies) keep links as links.
+func (e *Exporter) exportSymlink(src, dst, relPath string, rules subtree.Rules) (bool, error) {
+	target, ok := symlink.Target(src)
+	if !ok {
+		return false, nil
+	}
+	if e.skipsSymlink(src) {
+		return true, nil
+	}
+	if e.config == nil || e.config.SymlinkPolicy(e.appID) == symlink.PolicyPreserve {
+		return true, symlink.Create(target, dst)
+	}
+
+	// Follow and copy-target store the target's content
+	info, err := vfs.Stat(src)
+	if err != nil {
+		return true, fmt.Errorf("broken symlink %s -> %s: %w", src, target, err)
+	}
+	// The dotfiles copy may still be a link from an earlier policy
+	if _, ok := symlink.Target(dst); ok {
+		if err := vfs.Remove(dst); err != nil {
+			return true, err
+		}
+	}
+	if info.IsDir() {
+		return true, e.copyTree(src, dst, relPath, rules)
+	}
+	return false, nil
+}
+
+// skipsSymlink reports whether path is a symlink the app's policy leaves out
+func (e *Exporter) skipsSymlink(path string) bool {
+	if e.config == nil || e.config.SymlinkPolicy(e.appID) != symlink.PolicySkip {
+		return false
+	}
+	_, ok := symlink.Target(path)
+	return ok
+}
+
+// syncXattrs strips noisy extended attributes from the dotfiles copy and,
+// under the preserve policy, records the source's other attributes
+func (e *Exporter) syncXattrs(src, dst, relPath string) error {
+	if e.config == nil {
+		return nil
+	}
+	policy := xattr.ParsePolicy(e.config.Xattrs)
+	if policy == xattr.PolicyOff {
+		return nil
+	}
+	if err := xattr.Strip(dst); err != nil {
+		return err
+	}
+	if policy == xattr.PolicyPreserve && e.perms != nil {
+		attrs, err := xattr.Kept(src)
+		if err != nil {
+			return err
+		}
+		e.perms.RecordXattrs(e.appID, relPath, attrs)
+	}
+	return nil
+}
+
+// recordMode notes the mode of an exported file or directory in the
+// permissions manifest
+func (e *Exporter) recordMode(src, relPath string) {
+	if e.perms == nil {
+		return
+	}
+	if info, err := vfs.Stat(src); err == nil {
+		e.perms.Record(e.appID, relPath, info.Mode())
+	}
+}
+
+// writeFiltered writes src's content, passed through filter, to dst
+func writeFiltered(src, dst string, filter func([]byte) ([]byte, error)) error {
+	info, err := vfs.Stat(src)
+	if err != nil {
+		return err
+	}
+	data, err := vfs.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	out, err := filter(data)
+	if err != nil {
+		return err
+	}
+	if err := vfs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return vfs.WriteFile(dst, out, info.Mode().Perm())
+}
+
 // copyDir copies a directory recursively
 func (e *Exporter) copyDir(src, dst string) error {
+	return e.copyTree(src, dst, "", subtree.Rules{})
+}
+
+// subtreeRules returns the include/exclude rules for an app
+func (e *Exporter) subtreeRules(appID string) subtree.Rules {
+	if e.config == nil {
+		return subtree.Rules{}
+	}
+	return e.config.SubtreeRules[appID]
+}
+
+// copyTree copies a directory recursively, skipping entries the rules or
+// the config dir's .gitignore files exclude. relPath is the app-relative
+// path of src ("" disables filtering).
+func (e *Exporter) copyTree(src, dst, relPath string, rules subtree.Rules) error {
+	var ignore *gitignore.Matcher
+	if relPath != "" {
+		ignore = gitignore.ForDir(configRoot(src, relPath), src)
+	}
+	return e.copyTreeIgnoring(src, dst, relPath, rules, ignore)
+}
+
+// configRoot returns the config directory holding path, whose RelPath
+// starts with that directory's name
+func configRoot(path, relPath string) string {
+	for range strings.Count(filepath.Clean(relPath), string(filepath.Separator)) {
+		path = filepath.Dir(path)
+	}
+	return path
+}
+
+func (e *Exporter) copyTreeIgnoring(src, dst, relPath string, rules subtree.Rules, ignore *gitignore.Matcher) error {
 	// Get source info
-	srcInfo, err := os.Stat(src)
+	srcInfo, err := vfs.Stat(src)
 	if err != nil {
 		return err
 	}
 
 	// Create destination directory
-	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+	if err := vfs.MkdirAll(dst, srcInfo.Mode()); err != nil {
 		return err
 	}
+	if relPath != "" {
+		e.recordMode(src, relPath)
+	}
 
 	// Read directory entries
-	entries, err := os.ReadDir(src)
+	entries, err := vfs.ReadDir(src)
 	if err != nil {
 		return err
 	}
@@ -146,16 +406,34 @@ func (e *Exporter) copyDir(src, dst string) error {
 		dstPath := filepath.Join(dst, entry.Name())
 
 		// Skip hidden files and common unwanted files
-		if shouldSkipFile(entry.Name()) {
+		if shouldSkipFile(entry.Name()) || ignore.Ignored(srcPath, entry.IsDir()) {
 			continue
 		}
 
-		if entry.IsDir() {
-			if err := e.copyDir(srcPath, dstPath); err != nil {
+		// Nested repos are exported as their own entries
+		if entry.IsDir() && e.nestedRepoMode() != nestedrepo.ModeCopy && nestedrepo.IsRepo(srcPath) {
+			continue
+		}
+
+		childRel := ""
+		if relPath != "" {
+			childRel = filepath.Join(relPath, entry.Name())
+			if !rules.Allows(childRel) {
+				continue
+			}
+		}
+
+		if handled, err := e.exportSymlink(srcPath, dstPath, childRel, rules); handled {
+			if err != nil {
+				return err
+			}
+		} else if entry.IsDir() {
+			ignore.Load(srcPath)
+			if err := e.copyTreeIgnoring(srcPath, dstPath, childRel, rules, ignore); err != nil {
 				return err
 			}
 		} else {
-			if err := e.copyFile(srcPath, dstPath); err != nil {
+			if err := e.exportFile(srcPath, dstPath, childRel); err != nil {
 				return err
 			}
 		}
@@ -164,6 +442,46 @@ func (e *Exporter) copyDir(src, dst string) error {
 	return nil
 }
 
+// nestedRepoMode returns how nested git repos are exported.
+// Exporters without a config (used internally for copying) always copy.
+func (e *Exporter) nestedRepoMode() nestedrepo.Mode {
+	if e.config == nil {
+		return nestedrepo.ModeCopy
+	}
+	return nestedrepo.ParseMode(e.config.NestedRepos)
+}
+
+// exportNestedRepo pins a nested git repo in the app's manifest or records it
+// as a submodule of the dotfiles repo, depending on the configured mode
+func (e *Exporter) exportNestedRepo(lay layout.Layout, appID string, file models.File) error {
+	repo, err := nestedrepo.Inspect(file.Path)
+	if err != nil {
+		return err
+	}
+	repo.Path = file.RelPath
+
+	appDir := manifestDir(lay, e.config.DotfilesPath, appID)
+
+	if e.nestedRepoMode() == nestedrepo.ModeSubmodule {
+		dst := layout.Find(lay, e.config.DotfilesPath, appID, file.RelPath, file.Path)
+		relPath, err := filepath.Rel(e.config.DotfilesPath, dst)
+		if err != nil {
+			return err
+		}
+		if err := nestedrepo.AddSubmodule(e.config.DotfilesPath, relPath, *repo); err != nil {
+			return err
+		}
+	}
+
+	// The manifest is written in both modes so pull can restore either way
+	manifest, err := nestedrepo.LoadManifest(appDir)
+	if err != nil {
+		return err
+	}
+	manifest.Upsert(*repo)
+	return manifest.Save(appDir)
+}
+
 // shouldSkipFile returns true if the file should be skipped
 func shouldSkipFile(name string) bool {
 	skipPatterns := []string{
@@ -180,23 +498,23 @@ func shouldSkipFile(name string) bool {
 			return true
 		}
 	}
-	return false
+	return browser.IsSensitive(name)
 }
 
 // Backup backs up a file/directory before importing
 func Backup(path string, backupDir string) (string, error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	if _, err := vfs.Stat(path); os.IsNotExist(err) {
 		return "", nil // Nothing to backup
 	}
 
 	timestamp := time.Now().Format("20060102_150405")
 	backupPath := filepath.Join(backupDir, timestamp, filepath.Base(path))
 
-	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+	if err := vfs.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
 		return "", err
 	}
 
-	info, err := os.Stat(path)
+	info, err := vfs.Stat(path)
 	if err != nil {
 		return "", err
 	}
@@ -1,12 +1,15 @@
 package sync
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"dotsync/internal/clone"
 	"dotsync/internal/config"
 	"dotsync/internal/models"
+	"dotsync/internal/subtree"
 )
 
 func TestNewExporter(t *testing.T) {
@@ -386,6 +389,25 @@ func TestCopyFile_Success(t *testing.T) {
 	}
 }
 
+func TestCopyFile_Large(t *testing.T) {
+	tempDir := t.TempDir()
+	srcFile := filepath.Join(tempDir, "source.bin")
+	dstFile := filepath.Join(tempDir, "dest", "copied.bin")
+
+	// Large enough to be cloned, copied where the filesystem can't clone
+	data := bytes.Repeat([]byte{0, 1, 2, 3}, clone.MinSize)
+	os.WriteFile(srcFile, data, 0755)
+	os.MkdirAll(filepath.Dir(dstFile), 0755)
+	os.WriteFile(dstFile, []byte("older and shorter"), 0644)
+
+	if err := (&Exporter{}).copyFile(srcFile, dstFile); err != nil {
+		t.Fatalf("copyFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(dstFile); !bytes.Equal(content, data) {
+		t.Errorf("Content mismatch: got %d bytes, want %d", len(content), len(data))
+	}
+}
+
 func TestCopyFile_SourceNotExist(t *testing.T) {
 	tempDir := t.TempDir()
 	dstFile := filepath.Join(tempDir, "dest.txt")
@@ -409,6 +431,10 @@ func TestShouldSkipFile_AllPatterns(t *testing.T) {
 		{"__pycache__", true},
 		{".cache", true},
 		{"Cache", true},
+		// Browser state
+		{"cookies.sqlite", true},
+		{"logins.json", true},
+		{"Login Data", true},
 		// Non-skip patterns
 		{"config.json", false},
 		{"init.lua", false},
@@ -426,3 +452,323 @@ func TestShouldSkipFile_AllPatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestCopyDir_NestedRepoMode(t *testing.T) {
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src")
+	pluginDir := filepath.Join(srcDir, "plugin")
+	os.MkdirAll(filepath.Join(pluginDir, ".git"), 0755)
+	os.WriteFile(filepath.Join(pluginDir, "plugin.lua"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "init.lua"), []byte("y"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+
+	// Copy mode, the default, copies the working tree
+	exporter := NewExporter(cfg)
+	dst := filepath.Join(tempDir, "copy")
+	if err := exporter.copyDir(srcDir, dst); err != nil {
+		t.Fatalf("copyDir failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "plugin", "plugin.lua")); err != nil {
+		t.Error("Nested repo should be copied in copy mode")
+	}
+
+	// Manifest mode leaves nested repos to their own entries
+	cfg.NestedRepos = "manifest"
+	dst = filepath.Join(tempDir, "manifest")
+	if err := exporter.copyDir(srcDir, dst); err != nil {
+		t.Fatalf("copyDir failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "plugin")); !os.IsNotExist(err) {
+		t.Error("Nested repo should be skipped in manifest mode")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "init.lua")); err != nil {
+		t.Error("Regular files should still be copied")
+	}
+}
+
+func TestExportApp_NestedRepoWithoutRemote(t *testing.T) {
+	tempDir := t.TempDir()
+	pluginDir := filepath.Join(tempDir, "src", "plugin")
+	os.MkdirAll(filepath.Join(pluginDir, ".git"), 0755)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.NestedRepos = "manifest"
+
+	exporter := NewExporter(cfg)
+	app := &models.App{
+		ID: "test",
+		Files: []models.File{
+			{Name: "plugin", Path: pluginDir, RelPath: "plugin", IsDir: true, NestedRepo: true, Selected: true},
+		},
+	}
+
+	results, err := exporter.ExportApp(app)
+	if err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Success {
+		t.Error("Pinning an unreadable repo should fail per-file")
+	}
+}
+
+func TestExportApp_SubtreeRules(t *testing.T) {
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src", "fish")
+	os.MkdirAll(filepath.Join(srcDir, "completions"), 0755)
+	os.WriteFile(filepath.Join(srcDir, "config.fish"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "completions", "git.fish"), []byte("y"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.SubtreeRules = map[string]subtree.Rules{
+		"fish": {Exclude: []string{"fish/completions"}},
+	}
+
+	exporter := NewExporter(cfg)
+	app := &models.App{
+		ID: "fish",
+		Files: []models.File{
+			{Name: "fish", Path: srcDir, RelPath: "fish", IsDir: true, Selected: true},
+		},
+	}
+
+	if _, err := exporter.ExportApp(app); err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+
+	dest := filepath.Join(cfg.DotfilesPath, "fish", "fish")
+	if _, err := os.Stat(filepath.Join(dest, "config.fish")); err != nil {
+		t.Error("Allowed files should be exported")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "completions")); !os.IsNotExist(err) {
+		t.Error("Excluded subtree should not be exported")
+	}
+}
+
+func TestExportApp_Gitignore(t *testing.T) {
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src", "nvim")
+	os.MkdirAll(filepath.Join(srcDir, "lua", "build"), 0755)
+	os.MkdirAll(filepath.Join(srcDir, "plugin"), 0755)
+	os.WriteFile(filepath.Join(srcDir, ".gitignore"), []byte("plugin/\n"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "init.lua"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "plugin", "packer_compiled.lua"), []byte("y"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "lua", ".gitignore"), []byte("build\n"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "lua", "build", "out.so"), []byte("z"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	exporter := NewExporter(cfg)
+	app := &models.App{
+		ID: "nvim",
+		Files: []models.File{
+			{Name: "nvim", Path: srcDir, RelPath: "nvim", IsDir: true, Selected: true},
+			{Name: "lua", Path: filepath.Join(srcDir, "lua"), RelPath: "nvim/lua", IsDir: true, Selected: true},
+		},
+	}
+
+	if _, err := exporter.ExportApp(app); err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+
+	dest := filepath.Join(cfg.DotfilesPath, "nvim", "nvim")
+	if _, err := os.Stat(filepath.Join(dest, "init.lua")); err != nil {
+		t.Error("Files not ignored should be exported")
+	}
+	if _, err := os.Stat(filepath.Join(dest, ".gitignore")); err != nil {
+		t.Error("The .gitignore itself should be exported")
+	}
+	for _, ignored := range []string{"plugin", filepath.Join("lua", "build")} {
+		if _, err := os.Stat(filepath.Join(dest, ignored)); !os.IsNotExist(err) {
+			t.Errorf("Ignored %s should not be exported", ignored)
+		}
+	}
+}
+
+func TestExportApp_VolatileKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src", "User")
+	os.MkdirAll(srcDir, 0755)
+	os.WriteFile(filepath.Join(srcDir, "settings.json"), []byte(`{"font": 12, "window": {"x": 4}}`), 0644)
+	os.WriteFile(filepath.Join(srcDir, "broken.json"), []byte(`{"window": `), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.VolatileKeys = map[string][]string{"vscode": {"$.window"}}
+
+	exporter := NewExporter(cfg)
+	app := &models.App{
+		ID: "vscode",
+		Files: []models.File{
+			{Name: "settings.json", Path: filepath.Join(srcDir, "settings.json"), RelPath: "User/settings.json", Selected: true},
+			{Name: "broken.json", Path: filepath.Join(srcDir, "broken.json"), RelPath: "User/broken.json", Selected: true},
+		},
+	}
+
+	results, err := exporter.ExportApp(app)
+	if err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+	if !results[0].Success || results[1].Success {
+		t.Fatalf("Expected settings.json to export and broken.json to fail, got %+v", results)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(cfg.DotfilesPath, "vscode", "User", "settings.json"))
+	if string(data) != `{"font": 12}` {
+		t.Errorf("Volatile keys should be stripped, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DotfilesPath, "vscode", "User", "broken.json")); !os.IsNotExist(err) {
+		t.Error("A file whose keys can't be stripped should not be exported")
+	}
+}
+
+func TestExportApp_SSHMachineOnlyHosts(t *testing.T) {
+	tempDir := t.TempDir()
+	sshDir := filepath.Join(tempDir, "home", ".ssh")
+	os.MkdirAll(sshDir, 0700)
+	os.WriteFile(filepath.Join(sshDir, "config"), []byte("Host nas\n  User me\n\nHost work\n  User corp\n"), 0600)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.SSHLocalHosts = []string{"work"}
+
+	app := &models.App{
+		ID:    "ssh",
+		Files: []models.File{{Name: "config", Path: filepath.Join(sshDir, "config"), RelPath: "config", Selected: true}},
+	}
+	if _, err := NewExporter(cfg).ExportApp(app); err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(cfg.DotfilesPath, "ssh", "config"))
+	if string(data) != "Host nas\n  User me\n\n" {
+		t.Errorf("Machine-only hosts should not be exported, got %q", data)
+	}
+}
+
+func TestExportApp_GitConfigPlaceholders(t *testing.T) {
+	tempDir := t.TempDir()
+	localPath := filepath.Join(tempDir, "home", ".gitconfig")
+	os.MkdirAll(filepath.Dir(localPath), 0755)
+	os.WriteFile(localPath, []byte("[user]\n\temail = me@corp.example\n[core]\n\teditor = nvim\n"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+
+	app := &models.App{
+		ID:    "git",
+		Files: []models.File{{Name: ".gitconfig", Path: localPath, RelPath: ".gitconfig", Selected: true}},
+	}
+	if _, err := NewExporter(cfg).ExportApp(app); err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(cfg.DotfilesPath, "git", ".gitconfig"))
+	if string(data) != "[user]\n\temail = {{machine:user.email}}\n[core]\n\teditor = nvim\n" {
+		t.Errorf("Identity should be replaced by a placeholder, got %q", data)
+	}
+}
+
+func TestExportApp_SymlinkPolicies(t *testing.T) {
+	tests := []struct {
+		policy   string
+		wantLink bool // Stored as a link
+		wantFile bool // Stored as the target's content
+	}{
+		{"follow", false, true},
+		{"copy-target", false, true},
+		{"skip", false, false},
+		{"preserve-as-link", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cfg := config.Default()
+			cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+			cfg.Symlinks = tt.policy
+
+			target := filepath.Join(tempDir, "stow", "zshrc")
+			os.MkdirAll(filepath.Dir(target), 0755)
+			os.WriteFile(target, []byte("setopt"), 0644)
+			link := filepath.Join(tempDir, "home", ".zshrc")
+			os.MkdirAll(filepath.Dir(link), 0755)
+			os.Symlink("../stow/zshrc", link)
+
+			app := &models.App{ID: "zsh", Selected: true, Files: []models.File{
+				{Name: ".zshrc", Path: link, RelPath: ".zshrc", Selected: true, LinkTarget: "../stow/zshrc"},
+			}}
+			results, err := NewExporter(cfg).ExportApp(app)
+			if err != nil {
+				t.Fatalf("ExportApp failed: %v", err)
+			}
+			if tt.policy == "skip" && len(results) != 0 {
+				t.Errorf("Expected skipped links to have no result, got %+v", results)
+			}
+
+			dest := filepath.Join(cfg.DotfilesPath, "zsh", ".zshrc")
+			info, err := os.Lstat(dest)
+			if !tt.wantLink && !tt.wantFile {
+				if err == nil {
+					t.Error("Expected nothing exported")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected %s exported: %v", dest, err)
+			}
+			if isLink := info.Mode()&os.ModeSymlink != 0; isLink != tt.wantLink {
+				t.Errorf("Stored as link = %v, want %v", isLink, tt.wantLink)
+			}
+			if tt.wantLink {
+				if got, _ := os.Readlink(dest); got != "../stow/zshrc" {
+					t.Errorf("Expected the link target kept, got %q", got)
+				}
+			} else if data, _ := os.ReadFile(dest); string(data) != "setopt" {
+				t.Errorf("Expected the target's content, got %q", data)
+			}
+		})
+	}
+}
+
+func TestExportFile_Filters(t *testing.T) {
+	tests := []struct {
+		name    string
+		appID   string
+		relPath string
+		local   string
+		want    string
+	}{
+		{"volatile keys", "vscode", "settings.json", `{"font": 12, "window": {"x": 4}}`, `{"font": 12}`},
+		{"machine-only ssh hosts", "ssh", "config", "Host nas\n  User me\n\nHost work\n  User corp\n", "Host nas\n  User me\n\n"},
+		{"git identity", "git", ".gitconfig", "[user]\n\temail = me@corp.example\n", "[user]\n\temail = {{machine:user.email}}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			localPath := filepath.Join(tempDir, "home", "."+tt.appID, tt.relPath)
+			if tt.appID == "git" {
+				localPath = filepath.Join(tempDir, "home", tt.relPath)
+			}
+			os.MkdirAll(filepath.Dir(localPath), 0755)
+			os.WriteFile(localPath, []byte(tt.local), 0644)
+
+			cfg := config.Default()
+			cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+			cfg.VolatileKeys = map[string][]string{"vscode": {"$.window"}}
+			cfg.SSHLocalHosts = []string{"work"}
+
+			// A machine backup path, as quick backup picks it
+			dst := filepath.Join(cfg.DotfilesPath, tt.appID, "laptop", tt.relPath)
+			if err := NewExporter(cfg).ExportFile(tt.appID, tt.relPath, localPath, dst); err != nil {
+				t.Fatalf("ExportFile failed: %v", err)
+			}
+			data, _ := os.ReadFile(dst)
+			if string(data) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package sync
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// Failure is why a file could not be synced
+type Failure string
+
+const (
+	FailurePermission Failure = "permission" // Source unreadable or destination not writable
+	FailureMissing    Failure = "missing"    // The file or its directory is gone since the scan
+	FailureNoSpace    Failure = "no-space"   // The destination disk is full
+	FailureOther      Failure = "other"
+)
+
+// ClassifyError tells why a sync of a file failed, for hints on fixing it
+func ClassifyError(err error) Failure {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return FailurePermission
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, syscall.ENOTDIR):
+		return FailureMissing
+	case errors.Is(err, syscall.ENOSPC):
+		return FailureNoSpace
+	default:
+		return FailureOther
+	}
+}
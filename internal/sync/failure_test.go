@@ -0,0 +1,27 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	_, missing := os.Open(filepath.Join(t.TempDir(), "gone"))
+	tests := []struct {
+		err  error
+		want Failure
+	}{
+		{missing, FailureMissing},
+		{fmt.Errorf("copy: %w", os.ErrPermission), FailurePermission},
+		{&os.PathError{Op: "write", Path: "/x", Err: errors.New("boom")}, FailureOther},
+		{nil, FailureOther},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,29 @@
 package sync
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"dotsync/internal/models"
+	"dotsync/internal/vfs"
 )
 
+// HashCacheFile keeps file hashes across runs in the config dir, so large
+// files that haven't changed size or ModTime aren't read again
+const HashCacheFile = "hashes.json"
+
+// hashChunk is how much of a file is hashed between cancellation checks
+const hashChunk = 1 << 20
+
 // HashCache provides ModTime-based caching for file hashes
 type HashCache struct {
 	mu      sync.RWMutex
@@ -21,6 +34,7 @@ type hashEntry struct {
 	modTime time.Time
 	size    int64
 	hash    string
+	dir     bool
 }
 
 // Global hash cache instance
@@ -35,7 +49,11 @@ func GetHashCache() *HashCache {
 
 // GetOrCompute returns cached hash if file hasn't changed, otherwise computes new hash
 func (c *HashCache) GetOrCompute(path string) (string, error) {
-	info, err := os.Stat(path)
+	return c.getOrCompute(context.Background(), path)
+}
+
+func (c *HashCache) getOrCompute(ctx context.Context, path string) (string, error) {
+	info, err := vfs.Stat(path)
 	if err != nil {
 		return "", err
 	}
@@ -55,7 +73,7 @@ func (c *HashCache) GetOrCompute(path string) (string, error) {
 	if info.IsDir() {
 		hash, err = computeDirHashInternal(path)
 	} else {
-		hash, err = computeFileHashInternal(path)
+		hash, err = hashFile(ctx, path, info.Size())
 	}
 	if err != nil {
 		return "", err
@@ -67,6 +85,7 @@ func (c *HashCache) GetOrCompute(path string) (string, error) {
 		modTime: info.ModTime(),
 		size:    info.Size(),
 		hash:    hash,
+		dir:     info.IsDir(),
 	}
 	c.mu.Unlock()
 
@@ -80,6 +99,58 @@ func (c *HashCache) Clear() {
 	c.mu.Unlock()
 }
 
+// cachedFile is a file hash as saved in HashCacheFile
+type cachedFile struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Hash    string    `json:"hash"`
+}
+
+// Load adds the file hashes saved at path. A missing file adds nothing.
+func (c *HashCache) Load(path string) error {
+	data, err := vfs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var files map[string]cachedFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p, f := range files {
+		if _, ok := c.entries[p]; !ok {
+			c.entries[p] = hashEntry{modTime: f.ModTime, size: f.Size, hash: f.Hash}
+		}
+	}
+	return nil
+}
+
+// Save writes the file hashes to path. Directory hashes stay in memory:
+// a directory's ModTime misses changes to the files inside it.
+func (c *HashCache) Save(path string) error {
+	c.mu.RLock()
+	files := make(map[string]cachedFile, len(c.entries))
+	for p, e := range c.entries {
+		if !e.dir {
+			files[p] = cachedFile{ModTime: e.modTime, Size: e.size, Hash: e.hash}
+		}
+	}
+	c.mu.RUnlock()
+
+	data, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+	if err := vfs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return vfs.WriteFile(path, data, 0600)
+}
+
 // CacheSize returns the number of cached entries
 func (c *HashCache) CacheSize() int {
 	c.mu.RLock()
@@ -92,17 +163,71 @@ func ComputeFileHash(path string) (string, error) {
 	return globalHashCache.GetOrCompute(path)
 }
 
+// ComputeFileHashContext is ComputeFileHash, stopping a file that is still
+// being read when ctx is cancelled
+func ComputeFileHashContext(ctx context.Context, path string) (string, error) {
+	return globalHashCache.getOrCompute(ctx, path)
+}
+
 // computeFileHashInternal computes SHA256 hash without caching
 func computeFileHashInternal(path string) (string, error) {
-	file, err := os.Open(path)
+	info, err := vfs.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return hashFile(context.Background(), path, info.Size())
+}
+
+// HashProgress is how far hashing a large file has got
+type HashProgress struct {
+	Path  string
+	Done  int64
+	Total int64
+}
+
+// hashing holds the progress of the large file being hashed, nil between
+// large files
+var hashing atomic.Pointer[HashProgress]
+
+// CurrentHash returns the progress of the large file being hashed, if any
+func CurrentHash() (HashProgress, bool) {
+	if p := hashing.Load(); p != nil {
+		return *p, true
+	}
+	return HashProgress{}, false
+}
+
+// hashFile streams the file through SHA256 in chunks, checking ctx between
+// them. Large files report their progress through CurrentHash.
+func hashFile(ctx context.Context, path string, size int64) (string, error) {
+	file, err := vfs.Open(path)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
+	large := size >= models.LargeFileSize
+	if large {
+		defer hashing.Store(nil)
+	}
+
 	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return "", err
+	var done int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, err := io.CopyN(hasher, file, hashChunk)
+		done += n
+		if large {
+			hashing.Store(&HashProgress{Path: path, Done: done, Total: size})
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
 	}
 
 	return hex.EncodeToString(hasher.Sum(nil)), nil
@@ -120,7 +245,7 @@ func ComputeDirHashNoCache(dirPath string) (string, error) {
 
 // ComputeFileHashNoCache computes file hash without caching (useful for tests)
 func ComputeFileHashNoCache(path string) (string, error) {
-	info, err := os.Stat(path)
+	info, err := vfs.Stat(path)
 	if err != nil {
 		return "", err
 	}
@@ -143,7 +268,7 @@ func computeDirHashInternal(dirPath string) (string, error) {
 	hasher := sha256.New()
 
 	var filePaths []string
-	err := filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
+	err := vfs.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
@@ -167,7 +292,7 @@ func computeDirHashInternal(dirPath string) (string, error) {
 		hasher.Write([]byte(relPath))
 
 		// Hash the file content
-		file, err := os.Open(fullPath)
+		file, err := vfs.Open(fullPath)
 		if err != nil {
 			continue
 		}
@@ -1,9 +1,15 @@
 package sync
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"dotsync/internal/models"
 )
 
 func TestComputeFileHash_Empty(t *testing.T) {
@@ -282,3 +288,58 @@ func TestHashCache_InvalidatePath(t *testing.T) {
 		t.Errorf("Cache should be empty after InvalidatePath, got %d", cache.CacheSize())
 	}
 }
+
+func TestHashCache_SaveLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "test.txt")
+	os.WriteFile(tmpFile, []byte("test content"), 0644)
+	cachePath := filepath.Join(tmpDir, "config", HashCacheFile)
+
+	cache := &HashCache{entries: make(map[string]hashEntry)}
+	cache.GetOrCompute(tmpFile)
+	cache.GetOrCompute(tmpDir)
+	if err := cache.Save(cachePath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded := &HashCache{entries: make(map[string]hashEntry)}
+	if err := loaded.Load(cachePath); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.CacheSize() != 1 {
+		t.Errorf("Expected only the file hash saved, got %d entries", loaded.CacheSize())
+	}
+
+	// An unchanged size and ModTime trust the saved hash without reading
+	info, _ := os.Stat(tmpFile)
+	data := fmt.Sprintf(`{%q:{"mod_time":%q,"size":%d,"hash":"saved"}}`, tmpFile, info.ModTime().Format(time.RFC3339Nano), info.Size())
+	os.WriteFile(cachePath, []byte(data), 0600)
+	loaded = &HashCache{entries: make(map[string]hashEntry)}
+	loaded.Load(cachePath)
+	if hash, _ := loaded.GetOrCompute(tmpFile); hash != "saved" {
+		t.Errorf("Expected the saved hash, got %s", hash)
+	}
+
+	if err := loaded.Load(filepath.Join(tmpDir, "missing.json")); err != nil {
+		t.Errorf("Expected no error for a missing cache, got %v", err)
+	}
+}
+
+func TestComputeFileHashContext_Cancelled(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "huge.img")
+	f, _ := os.Create(tmpFile)
+	f.Truncate(models.LargeFileSize)
+	f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ComputeFileHashContext(ctx, tmpFile); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the hash cancelled, got %v", err)
+	}
+	if _, ok := CurrentHash(); ok {
+		t.Error("Expected no hash in progress after it stopped")
+	}
+	if _, err := ComputeFileHashNoCache(tmpFile); err != nil {
+		t.Errorf("Expected a later hash to succeed, got %v", err)
+	}
+}
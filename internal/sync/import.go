@@ -1,17 +1,37 @@
 package sync
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"dotsync/internal/config"
+	"dotsync/internal/gitconfig"
+	"dotsync/internal/jsonkeys"
+	"dotsync/internal/layout"
+	"dotsync/internal/localsection"
 	"dotsync/internal/models"
+	"dotsync/internal/nestedrepo"
+	"dotsync/internal/policy"
+	"dotsync/internal/sshconfig"
+	"dotsync/internal/subtree"
+	"dotsync/internal/symlink"
+	"dotsync/internal/vfs"
+	"dotsync/internal/xattr"
 )
 
+// ErrConflict is returned for files changed both locally and in dotfiles since the last sync
+var ErrConflict = errors.New("changed locally and in dotfiles since last sync")
+
 // Importer handles importing configs from dotfiles to system
 type Importer struct {
-	config *config.Config
+	config       *config.Config
+	stateManager *StateManager
+	sandboxRoot  string // If set, files land under this directory instead of their real paths
 }
 
 // NewImporter creates a new Importer
@@ -19,6 +39,39 @@ func NewImporter(cfg *config.Config) *Importer {
 	return &Importer{config: cfg}
 }
 
+// WithStateManager enables conflict checks: files modified on both sides
+// since the last sync are skipped instead of overwritten
+func (i *Importer) WithStateManager(sm *StateManager) *Importer {
+	i.stateManager = sm
+	return i
+}
+
+// WithSandbox redirects every write into root, which stands in for $HOME
+// (paths outside home land under root/_root). No backups are made and no
+// conflict checks run, so the result is what a fresh machine would receive.
+func (i *Importer) WithSandbox(root string) *Importer {
+	i.sandboxRoot = root
+	return i
+}
+
+// SandboxPath maps a real local path to its location inside a sandbox root
+func SandboxPath(root, path string) string {
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(homeDir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join(root, rel)
+		}
+	}
+	return filepath.Join(root, "_root", path)
+}
+
+// destPath returns where a local path is written, honoring the sandbox
+func (i *Importer) destPath(path string) string {
+	if i.sandboxRoot == "" {
+		return path
+	}
+	return SandboxPath(i.sandboxRoot, path)
+}
+
 // ImportResult holds the result of an import operation
 type ImportResult struct {
 	App        *models.App
@@ -26,21 +79,166 @@ type ImportResult struct {
 	Success    bool
 	Error      error
 	BackupPath string
+	Conflict   bool          // Skipped because both sides changed; needs diff/merge
+	Policy     policy.Policy // Conflict policy that resolved this file, if any
+	KeptLocal  bool          // Resolved in favor of the local file; nothing was written
 }
 
-// ImportApp imports all selected files for an app
+// ImportApp imports all selected files for an app, as one pull
 func (i *Importer) ImportApp(app *models.App) ([]ImportResult, error) {
-	var results []ImportResult
+	return i.importApps([]*models.App{app})
+}
+
+// importTx collects the results of one pull while its files are staged
+type importTx struct {
+	stage   *staging
+	results []ImportResult
+	staged  []int         // Results waiting for the commit
+	clones  []pinnedClone // Pinned repos to clone once the commit succeeded
+	failed  string        // First file that failed to stage
+}
+
+// pinnedClone is a pinned nested repo a pull clones from its remote
+type pinnedClone struct {
+	result int // Index in results
+	repo   nestedrepo.Repo
+	dst    string
+}
+
+// fail records a file that could not be staged, which calls off the pull
+func (tx *importTx) fail(result ImportResult) {
+	if tx.failed == "" {
+		tx.failed = result.App.ID + "/" + result.File.RelPath
+	}
+	tx.results = append(tx.results, result)
+}
+
+// importApps pulls apps in one go. Every file is built in a staging dir
+// first; only when all of them are staged and validated do they move into
+// place, each with a rename. If any file fails, no local file changes and
+// the staged ones report ErrRolledBack.
+func (i *Importer) importApps(apps []*models.App) ([]ImportResult, error) {
+	dir := i.stagingDir()
+	if err := RecoverStaging(dir); err != nil {
+		return nil, fmt.Errorf("rolling back an interrupted pull: %w", err)
+	}
+	stage, err := newStaging(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	defer stage.cleanup()
+
+	tx := &importTx{stage: stage}
+	for _, app := range apps {
+		if err := i.stageApp(app, tx); err != nil {
+			tx.rollBack(fmt.Errorf("%w: %w", ErrRolledBack, err))
+			return tx.results, err
+		}
+	}
+
+	if tx.failed != "" {
+		tx.rollBack(fmt.Errorf("%w: %s failed", ErrRolledBack, tx.failed))
+		return tx.results, nil
+	}
+	if err := stage.commit(); err != nil {
+		tx.rollBack(fmt.Errorf("%w: %w", ErrRolledBack, err))
+		return tx.results, nil
+	}
+	for _, idx := range tx.staged {
+		tx.results[idx].Success = true
+	}
+
+	// Clones write straight into place, so they wait for the commit
+	for _, c := range tx.clones {
+		err := nestedrepo.Clone(c.repo, c.dst)
+		tx.results[c.result].Success = err == nil
+		tx.results[c.result].Error = err
+	}
+
+	// Directories were replaced wholesale, so re-clone any pinned repos they contained
+	lay := layout.For(i.config.DotfilesPath)
+	for _, app := range apps {
+		tx.results = append(tx.results, i.restorePinnedRepos(app, manifestDir(lay, i.config.DotfilesPath, app.ID))...)
+	}
+
+	return tx.results, nil
+}
+
+// ImportFile pulls one file or directory of an app from src in dotfiles to
+// the local path dst the way a pull does: the local copy is backed up and
+// keeps this machine's git identity, ssh hosts, volatile keys and local
+// sections. It is for pulls that pick the dotfiles path themselves, like
+// quick sync and conflict resolution.
+func (i *Importer) ImportFile(appID, relPath, src, dst string) error {
+	dir := i.stagingDir()
+	if err := RecoverStaging(dir); err != nil {
+		return fmt.Errorf("rolling back an interrupted pull: %w", err)
+	}
+	stage, err := newStaging(dir)
+	if err != nil {
+		return fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	defer stage.cleanup()
+	perms, err := LoadPerms(i.config.DotfilesPath)
+	if err != nil {
+		return err
+	}
+
+	tx := &importTx{stage: stage}
+	result := ImportResult{App: &models.App{ID: appID}, File: models.File{Path: dst, RelPath: relPath}}
+	i.stageFile(tx, result, src, i.destPath(dst), i.config.SubtreeRules[appID], perms)
+	if len(tx.results) == 0 {
+		return nil // Left alone by the symlink policy
+	}
+	if err := tx.results[0].Error; err != nil {
+		return err
+	}
+	return stage.commit()
+}
+
+// rollBack fails every staged file with err
+func (tx *importTx) rollBack(err error) {
+	for _, idx := range tx.staged {
+		tx.results[idx].Error = err
+	}
+	for _, c := range tx.clones {
+		tx.results[c.result].Error = err
+	}
+}
+
+// stagingDir is where pulls stage files: next to the backups, which are
+// usually on the same disk as the configs, so moving in is a rename
+func (i *Importer) stagingDir() string {
+	switch {
+	case i.sandboxRoot != "":
+		return filepath.Join(i.sandboxRoot, ".dotsync-staging")
+	case i.config.BackupPath != "":
+		return filepath.Join(i.config.BackupPath, StagingDir)
+	}
+	return filepath.Join(os.TempDir(), "dotsync-staging")
+}
 
+// stageApp stages the selected files of an app into tx
+func (i *Importer) stageApp(app *models.App, tx *importTx) error {
 	srcDir := i.config.GetDestPath(app.ID)
+	lay, err := layout.Load(i.config.DotfilesPath)
+	if err != nil {
+		return err
+	}
+
+	// Apps without a directory in dotfiles have nothing to pull, except
+	// for files mapped elsewhere
+	_, err = vfs.Stat(srcDir)
+	noAppDir := os.IsNotExist(err) && layout.IsDefault(lay)
 
-	// Check if app directory exists in dotfiles
-	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
-		return results, nil // Skip if no dotfiles for this app
+	rules := i.config.SubtreeRules[app.ID]
+	perms, err := LoadPerms(i.config.DotfilesPath)
+	if err != nil {
+		return err
 	}
 
 	for _, file := range app.Files {
-		if !file.Selected {
+		if !file.Selected || file.Excluded || !rules.Allows(file.RelPath) {
 			continue
 		}
 
@@ -49,82 +247,455 @@ func (i *Importer) ImportApp(app *models.App) ([]ImportResult, error) {
 			File: file,
 		}
 
-		srcPath := filepath.Join(srcDir, file.RelPath)
-		dstPath := file.Path
+		srcPath := layout.Find(lay, i.config.DotfilesPath, app.ID, file.RelPath, file.Path)
+		dstPath := i.destPath(file.Path)
 
-		// Check if source exists in dotfiles
-		if _, err := os.Stat(srcPath); os.IsNotExist(err) {
-			result.Error = fmt.Errorf("file not found in dotfiles: %s", srcPath)
-			results = append(results, result)
-			continue
+		// Pinned nested repos are cloned from their remote, outside the
+		// staging, once the rest of the pull is in place
+		if file.NestedRepo {
+			if repo := i.pinnedRepo(manifestDir(lay, i.config.DotfilesPath, app.ID), file.RelPath); repo != nil {
+				tx.clones = append(tx.clones, pinnedClone{result: len(tx.results), repo: *repo, dst: dstPath})
+				tx.results = append(tx.results, result)
+				continue
+			}
 		}
 
-		// Create parent directory if not exists
-		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-			result.Error = fmt.Errorf("failed to create directory: %w", err)
-			results = append(results, result)
+		// Check if source exists in dotfiles (links stored as links may not resolve here)
+		if _, err := vfs.Lstat(srcPath); os.IsNotExist(err) {
+			if noAppDir {
+				continue
+			}
+			result.Error = fmt.Errorf("file not found in dotfiles: %s", srcPath)
+			tx.results = append(tx.results, result)
 			continue
 		}
 
-		// Backup existing file if it exists
-		if _, err := os.Stat(dstPath); err == nil {
-			backupPath, err := Backup(dstPath, i.config.BackupPath)
-			if err != nil {
-				result.Error = fmt.Errorf("backup failed: %w", err)
-				results = append(results, result)
-				continue
-			}
-			result.BackupPath = backupPath
+		i.stageFile(tx, result, srcPath, dstPath, rules, perms)
+	}
+	return nil
+}
+
+// stageFile stages the pulled copy of result's file from srcPath in
+// dotfiles for dstPath, unless a conflict or the symlink policy keeps it
+func (i *Importer) stageFile(tx *importTx, result ImportResult, srcPath, dstPath string, rules subtree.Rules, perms *Perms) {
+	app, file := result.App, result.File
+
+	// Symlinks on either side follow the app's symlink policy
+	action, writePath := i.symlinkAction(app.ID, srcPath, dstPath)
+	if action == linkSkip {
+		return
+	}
+	dstPath = writePath
+
+	// Never clobber local changes that conflict with dotfiles changes,
+	// unless the configured policy decides the winner
+	if i.sandboxRoot == "" && i.isConflicted(app.ID, file.RelPath, srcPath, dstPath) {
+		p := i.config.Conflicts.For(app.ID, file.RelPath)
+		side := p.Resolve(dstPath, srcPath)
+		if side == policy.SideAsk {
+			result.Conflict = true
+			result.Error = ErrConflict
+			tx.results = append(tx.results, result)
+			return
 		}
+		result.Policy = p
+		if side == policy.SideLocal {
+			result.KeptLocal = true
+			result.Success = true
+			tx.results = append(tx.results, result)
+			return
+		}
+	}
 
-		// Import the file
-		exporter := &Exporter{}
-		srcInfo, err := os.Stat(srcPath)
+	// Create parent directory if not exists
+	if err := vfs.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		result.Error = fmt.Errorf("failed to create directory: %w", err)
+		tx.fail(result)
+		return
+	}
+
+	// Backup existing file if it exists
+	if _, err := vfs.Stat(dstPath); err == nil && i.sandboxRoot == "" {
+		backupPath, err := Backup(dstPath, i.config.BackupPath)
 		if err != nil {
-			result.Error = fmt.Errorf("cannot stat source: %w", err)
-			results = append(results, result)
-			continue
+			result.Error = fmt.Errorf("backup failed: %w", err)
+			tx.fail(result)
+			return
 		}
+		result.BackupPath = backupPath
+	}
 
-		if srcInfo.IsDir() {
-			// Remove existing directory first
-			os.RemoveAll(dstPath)
-			err = exporter.copyDir(srcPath, dstPath)
-		} else {
-			err = exporter.copyFile(srcPath, dstPath)
-		}
+	srcInfo, err := vfs.Stat(srcPath)
+	if err != nil && action != linkCreate {
+		result.Error = fmt.Errorf("cannot stat source: %w", err)
+		tx.fail(result)
+		return
+	}
 
-		result.Success = err == nil
+	// Build the pulled copy in the staging dir. Files and filtered
+	// directories start from the local copy, which they merge into.
+	merge := action == linkNone && (!srcInfo.IsDir() || !rules.IsEmpty())
+	out, err := tx.stage.add(dstPath, merge)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to stage: %w", err)
+		tx.fail(result)
+		return
+	}
+
+	// Keep local values of volatile keys across the copy
+	volatile := i.config.VolatileKeys[app.ID]
+	saved := rebase(saveVolatile(volatile, file.RelPath, dstPath), dstPath, out)
+	// and this machine's local sections
+	sections := rebase(saveLocalSections(dstPath), dstPath, out)
+
+	// Import the file
+	exporter := &Exporter{}
+	if action == linkCreate {
+		target, _ := symlink.Target(srcPath)
+		err = symlink.Create(target, out)
+	} else if !srcInfo.IsDir() && gitconfig.IsConfig(file.Path) {
+		// Keep this machine's identity and credentials
+		err = renderGitConfig(srcPath, out, i.config)
+	} else if !srcInfo.IsDir() && sshconfig.IsConfig(file.Path) {
+		// Merge shared host blocks instead of replacing the local file
+		err = mergeSSHConfig(srcPath, out, i.config.SSHLocalHosts)
+	} else if srcInfo.IsDir() && !rules.IsEmpty() {
+		// Merge into the existing directory so excluded local subtrees survive
+		err = exporter.copyTree(srcPath, out, file.RelPath, rules)
+	} else if srcInfo.IsDir() {
+		err = exporter.copyDir(srcPath, out)
+	} else {
+		err = exporter.copyFile(srcPath, out)
+	}
+	if err == nil && action != linkCreate {
+		err = restoreVolatile(volatile, file.RelPath, out, saved)
+	}
+	if err == nil && action != linkCreate {
+		err = restoreLocalSections(sections)
+	}
+	if err == nil {
+		// Git keeps only the executable bit
+		err = perms.Apply(app.ID, file.RelPath, out, xattr.ParsePolicy(i.config.Xattrs))
+	}
+
+	if err != nil {
 		result.Error = err
-		results = append(results, result)
+		tx.fail(result)
+		return
 	}
+	tx.staged = append(tx.staged, len(tx.results))
+	tx.results = append(tx.results, result)
+}
 
-	return results, nil
+// rebase moves the keys of files saved from under from to the same paths
+// under to
+func rebase(saved map[string][]byte, from, to string) map[string][]byte {
+	if saved == nil {
+		return nil
+	}
+	moved := make(map[string][]byte, len(saved))
+	for p, data := range saved {
+		if rel, err := filepath.Rel(from, p); err == nil {
+			moved[filepath.Join(to, rel)] = data
+		}
+	}
+	return moved
 }
 
-// ImportAll imports all selected apps and files
-func (i *Importer) ImportAll(apps []*models.App) ([]ImportResult, error) {
-	var allResults []ImportResult
+// linkAction is what a pull does about a symlink at either end
+type linkAction int
 
-	for _, app := range apps {
-		if !app.Selected {
+const (
+	linkNone    linkAction = iota // Copy as usual
+	linkSkip                      // Leave the file alone
+	linkCreate                    // Recreate the link stored in dotfiles
+	linkReplace                   // Replace the local link with a regular copy
+)
+
+// symlinkAction decides how a pull treats symlinks under the app's policy
+// and returns the path to write to: the link's target when following it
+func (i *Importer) symlinkAction(appID, srcPath, dstPath string) (linkAction, string) {
+	p := i.config.SymlinkPolicy(appID)
+	_, localLink := symlink.Target(dstPath)
+	_, storedLink := symlink.Target(srcPath)
+	switch {
+	case p == symlink.PolicySkip && (localLink || storedLink):
+		return linkSkip, dstPath
+	case storedLink:
+		return linkCreate, dstPath
+	case !localLink:
+		return linkNone, dstPath
+	case p == symlink.PolicyCopyTarget:
+		return linkReplace, dstPath
+	}
+	// Follow writes into the target, keeping the link
+	if resolved, err := filepath.EvalSymlinks(dstPath); err == nil {
+		return linkNone, resolved
+	}
+	return linkReplace, dstPath // Broken link
+}
+
+// RewritesOnSync reports whether push and pull rewrite a file instead of
+// copying it (volatile JSON keys, merged ssh hosts, local sections), so the
+// local and dotfiles copies can differ right after a sync
+func RewritesOnSync(cfg *config.Config, appID string, file models.File) bool {
+	if file.IsDir {
+		return false
+	}
+	return gitconfig.IsConfig(file.Path) || sshconfig.IsConfig(file.Path) ||
+		len(jsonkeys.For(cfg.VolatileKeys[appID], file.RelPath)) > 0 ||
+		localsection.FileHas(file.Path)
+}
+
+// renderGitConfig writes the dotfiles git config to dstPath with the
+// protected keys taken from the local file and configured identity
+func renderGitConfig(srcPath, dstPath string, cfg *config.Config) error {
+	incoming, err := vfs.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	local, err := vfs.ReadFile(dstPath)
+	if err == nil {
+		if info, err := vfs.Stat(dstPath); err == nil {
+			perm = info.Mode().Perm()
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	out := gitconfig.Render(string(incoming), string(local), cfg.GitProtectedKeys, cfg.GitIdentity)
+	return vfs.WriteFile(dstPath, []byte(out), perm)
+}
+
+// mergeSSHConfig merges the host blocks of the dotfiles ssh config into the
+// local one, keeping local-only and machine-only blocks
+func mergeSSHConfig(srcPath, dstPath string, machineOnly []string) error {
+	shared, err := vfs.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+	local, err := vfs.ReadFile(dstPath)
+	if os.IsNotExist(err) {
+		return (&Exporter{}).copyFile(srcPath, dstPath)
+	}
+	if err != nil {
+		return err
+	}
+	merged := sshconfig.Merge(sshconfig.Parse(string(local)), sshconfig.Parse(string(shared)), machineOnly)
+	info, err := vfs.Stat(dstPath)
+	if err != nil {
+		return err
+	}
+	return vfs.WriteFile(dstPath, []byte(merged.String()), info.Mode().Perm())
+}
+
+// saveLocalSections reads the local files under path that have
+// machine-local sections, keyed by their path
+func saveLocalSections(path string) map[string][]byte {
+	saved := make(map[string][]byte)
+	vfs.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !localsection.FileHas(p) {
+			return nil
+		}
+		if data, err := vfs.ReadFile(p); err == nil {
+			saved[p] = data
+		}
+		return nil
+	})
+	return saved
+}
+
+// restoreLocalSections puts the saved local sections back into the pulled
+// files. Files the pull removed stay removed.
+func restoreLocalSections(saved map[string][]byte) error {
+	for p, data := range saved {
+		if _, err := vfs.Stat(p); err != nil {
 			continue
 		}
+		if err := localsection.RestoreFile(p, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		results, err := i.ImportApp(app)
+// saveVolatile reads the local JSON files under path that have volatile key
+// rules, keyed by their path
+func saveVolatile(rules []string, relPath, path string) map[string][]byte {
+	if len(rules) == 0 {
+		return nil
+	}
+	saved := make(map[string][]byte)
+	walkVolatile(rules, relPath, path, func(p string, _ []jsonkeys.Rule) error {
+		if data, err := vfs.ReadFile(p); err == nil {
+			saved[p] = data
+		}
+		return nil
+	})
+	return saved
+}
+
+// restoreVolatile puts the saved local values of volatile keys back into
+// the freshly imported files under path
+func restoreVolatile(rules []string, relPath, path string, saved map[string][]byte) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return walkVolatile(rules, relPath, path, func(p string, fileRules []jsonkeys.Rule) error {
+		return jsonkeys.RestoreFile(p, saved[p], fileRules)
+	})
+}
+
+// walkVolatile calls fn for each file under path (a file or directory at
+// app-relative relPath) that volatile key rules apply to
+func walkVolatile(rules []string, relPath, path string, fn func(p string, fileRules []jsonkeys.Rule) error) error {
+	return vfs.WalkDir(path, func(p string, d os.DirEntry, err error) error {
 		if err != nil {
-			return allResults, err
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		if fileRules := jsonkeys.For(rules, filepath.Join(relPath, rel)); len(fileRules) > 0 {
+			return fn(p, fileRules)
+		}
+		return nil
+	})
+}
+
+// nestedRepoStatus compares a local nested repo's HEAD with its pinned commit
+func nestedRepoStatus(localPath string, pinned *nestedrepo.Repo) (models.SyncStatus, models.ConflictType) {
+	local, err := nestedrepo.Inspect(localPath)
+	if err != nil {
+		return models.StatusMissing, models.ConflictDotfilesNew
+	}
+	if local.Commit == pinned.Commit {
+		return models.StatusSynced, models.ConflictNone
+	}
+	return models.StatusModified, models.ConflictLocalModified
+}
+
+// isConflicted reports whether a file was modified both locally and in
+// dotfiles since the last recorded sync. Files without sync history are
+// not considered conflicted, since there is no baseline to compare against.
+func (i *Importer) isConflicted(appID, relPath, srcPath, dstPath string) bool {
+	if i.stateManager == nil {
+		return false
+	}
+	if _, exists := i.stateManager.GetFileState(appID, relPath); !exists {
+		return false
+	}
+
+	srcInfo, err := vfs.Stat(srcPath)
+	if err != nil || srcInfo.IsDir() {
+		return false
+	}
+	dstInfo, err := vfs.Stat(dstPath)
+	if err != nil || dstInfo.IsDir() {
+		return false
+	}
+
+	localHash, err := ComputeFileHash(dstPath)
+	if err != nil {
+		return false
+	}
+	dotfilesHash, err := ComputeFileHash(srcPath)
+	if err != nil {
+		return false
+	}
+
+	return i.stateManager.DetectConflict(appID, relPath, localHash, dotfilesHash) == models.ConflictBothModified
+}
+
+// pinnedRepo returns the manifest entry for relPath, or nil
+func (i *Importer) pinnedRepo(appDir, relPath string) *nestedrepo.Repo {
+	manifest, err := nestedrepo.LoadManifest(appDir)
+	if err != nil {
+		return nil
+	}
+	return manifest.Find(relPath)
+}
+
+// restorePinnedRepos clones pinned repos that are missing locally. It
+// returns a failed result for the manifest or each repo it couldn't restore.
+func (i *Importer) restorePinnedRepos(app *models.App, appDir string) []ImportResult {
+	manifest, err := nestedrepo.LoadManifest(appDir)
+	if err != nil {
+		return []ImportResult{{
+			App:   app,
+			File:  models.File{Name: nestedrepo.ManifestFileName, Path: filepath.Join(appDir, nestedrepo.ManifestFileName), RelPath: nestedrepo.ManifestFileName},
+			Error: fmt.Errorf("read pinned repos: %w", err),
+		}}
+	}
+
+	var failed []ImportResult
+	for _, repo := range manifest.Repos {
+		dest := localPathFor(app, repo.Path)
+		if dest == "" || pulledAsRepo(app, repo.Path) {
+			continue
+		}
+		if err := nestedrepo.Clone(repo, i.destPath(dest)); err != nil {
+			failed = append(failed, ImportResult{
+				App:   app,
+				File:  models.File{Name: filepath.Base(dest), Path: dest, RelPath: repo.Path, IsDir: true, NestedRepo: true},
+				Error: fmt.Errorf("restore %s: %w", repo.Remote, err),
+			})
+		}
+	}
+	return failed
+}
+
+// pulledAsRepo reports whether relPath is a selected nested repo of app,
+// which the pull cloned as a file of its own
+func pulledAsRepo(app *models.App, relPath string) bool {
+	for _, f := range app.Files {
+		if f.Selected && f.NestedRepo && f.RelPath == relPath {
+			return true
+		}
+	}
+	return false
+}
+
+// localPathFor maps a path relative to the app's dotfiles directory to a
+// local path using the selected files of the app
+func localPathFor(app *models.App, relPath string) string {
+	for _, f := range app.Files {
+		if !f.Selected {
+			continue
+		}
+		if f.RelPath == relPath {
+			return f.Path
+		}
+		if f.IsDir && strings.HasPrefix(relPath, f.RelPath+string(filepath.Separator)) {
+			return filepath.Join(f.Path, strings.TrimPrefix(relPath, f.RelPath+string(filepath.Separator)))
 		}
-		allResults = append(allResults, results...)
 	}
+	return ""
+}
 
-	return allResults, nil
+// ImportAll imports all selected apps and files as one pull: if a file
+// fails, none of them changes
+func (i *Importer) ImportAll(apps []*models.App) ([]ImportResult, error) {
+	var selected []*models.App
+	for _, app := range apps {
+		if app.Selected {
+			selected = append(selected, app)
+		}
+	}
+	return i.importApps(selected)
 }
 
 // CompareFiles compares local and dotfiles versions
 func CompareFiles(localPath, dotfilesPath string) models.SyncStatus {
-	localInfo, localErr := os.Stat(localPath)
-	dotfilesInfo, dotfilesErr := os.Stat(dotfilesPath)
+	localInfo, localErr := vfs.Stat(localPath)
+	dotfilesInfo, dotfilesErr := vfs.Stat(dotfilesPath)
 
 	if localErr != nil && dotfilesErr != nil {
 		return models.StatusUnknown
@@ -150,11 +721,20 @@ func CompareFiles(localPath, dotfilesPath string) models.SyncStatus {
 
 // UpdateSyncStatus updates the sync status for all files in an app
 func UpdateSyncStatus(app *models.App, dotfilesPath string) {
-	appDir := filepath.Join(dotfilesPath, app.ID)
+	lay := layout.For(dotfilesPath)
+	manifest, _ := nestedrepo.LoadManifest(manifestDir(lay, dotfilesPath, app.ID))
 
 	for i := range app.Files {
 		file := &app.Files[i]
-		dotfilesFilePath := filepath.Join(appDir, file.RelPath)
+		dotfilesFilePath := layout.Find(lay, dotfilesPath, app.ID, file.RelPath, file.Path)
+
+		// Pinned nested repos compare by commit rather than content
+		if file.NestedRepo && manifest != nil {
+			if pinned := manifest.Find(file.RelPath); pinned != nil {
+				file.SyncStatus, file.ConflictType = nestedRepoStatus(file.Path, pinned)
+				continue
+			}
+		}
 		file.SyncStatus = CompareFiles(file.Path, dotfilesFilePath)
 	}
 }
@@ -162,73 +742,126 @@ func UpdateSyncStatus(app *models.App, dotfilesPath string) {
 // UpdateSyncStatusWithHashes updates sync status with hash-based conflict detection
 // This is optimized to use ModTime first, only computing hashes when there's a potential conflict
 func UpdateSyncStatusWithHashes(app *models.App, dotfilesPath string, stateManager *StateManager) {
-	appDir := filepath.Join(dotfilesPath, app.ID)
+	updateSyncStatusWithHashes(context.Background(), app, layout.For(dotfilesPath), dotfilesPath, stateManager)
+}
+
+// UpdateSyncStatusAll runs UpdateSyncStatusWithHashes over apps, stopping
+// when ctx is cancelled. Files not compared by then keep the status the
+// scan gave them, and ctx's error is returned.
+func UpdateSyncStatusAll(ctx context.Context, apps []*models.App, dotfilesPath string, stateManager *StateManager) error {
+	lay := layout.For(dotfilesPath)
+	for _, app := range apps {
+		if err := updateSyncStatusWithHashes(ctx, app, lay, dotfilesPath, stateManager); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func updateSyncStatusWithHashes(ctx context.Context, app *models.App, lay layout.Layout, dotfilesPath string, stateManager *StateManager) error {
 	for i := range app.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		file := &app.Files[i]
-		dotfilesFilePath := filepath.Join(appDir, file.RelPath)
-
-		// First, use fast ModTime-based comparison
-		file.SyncStatus = CompareFiles(file.Path, dotfilesFilePath)
+		dotfilesFilePath := layout.Find(lay, dotfilesPath, app.ID, file.RelPath, file.Path)
+		if err := updateFileStatus(ctx, app.ID, file, dotfilesFilePath, stateManager); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		// Only compute hashes if both files exist and ModTime suggests they're the same
-		// This avoids expensive hash computation in most cases
-		localExists := false
-		dotfilesExists := false
+// RefreshFileStatus re-hashes both sides of one file changed outside
+// dotsync and updates its size, sync status and conflict type, instead of
+// rescanning its app
+func RefreshFileStatus(ctx context.Context, appID string, file *models.File, dotfilesPath string, stateManager *StateManager) error {
+	dotfilesFilePath := DotfilePath(dotfilesPath, appID, *file)
+	globalHashCache.InvalidatePath(file.Path)
+	globalHashCache.InvalidatePath(dotfilesFilePath)
+	if info, err := vfs.Stat(file.Path); err == nil {
+		file.Size, file.ModTime = info.Size(), info.ModTime()
+	}
+	return updateFileStatus(ctx, appID, file, dotfilesFilePath, stateManager)
+}
 
-		if _, err := os.Stat(file.Path); err == nil {
-			localExists = true
-		}
-		if _, err := os.Stat(dotfilesFilePath); err == nil {
-			dotfilesExists = true
+// updateFileStatus compares a file with its dotfiles copy at dotfilesFilePath
+func updateFileStatus(ctx context.Context, appID string, file *models.File, dotfilesFilePath string, stateManager *StateManager) error {
+	// Links stored as links match when they point at the same target,
+	// whether or not the target resolves inside the dotfiles repo
+	if stored, ok := symlink.Target(dotfilesFilePath); ok && file.LinkTarget != "" {
+		file.SyncStatus, file.ConflictType = models.StatusSynced, models.ConflictNone
+		if stored != file.LinkTarget {
+			file.SyncStatus, file.ConflictType = models.StatusModified, models.ConflictLocalModified
 		}
+		return nil
+	}
 
-		// Fast path: if one doesn't exist, no need to hash
-		if !localExists && !dotfilesExists {
-			file.ConflictType = models.ConflictNone
-			continue
-		}
-		if !localExists {
-			file.ConflictType = models.ConflictDotfilesNew
-			continue
-		}
-		if !dotfilesExists {
-			file.ConflictType = models.ConflictLocalNew
-			continue
-		}
+	// First, use fast ModTime-based comparison
+	file.SyncStatus = CompareFiles(file.Path, dotfilesFilePath)
 
-		// Both exist - use quick comparison first for files (skip large directories)
-		if file.IsDir {
-			// For directories, use ModTime-based status instead of hashing
-			// This is much faster for large directories like nvim configs
-			switch file.SyncStatus {
-			case models.StatusSynced:
-				file.ConflictType = models.ConflictNone
-			case models.StatusModified:
-				file.ConflictType = models.ConflictLocalModified
-			case models.StatusOutdated:
-				file.ConflictType = models.ConflictDotfilesModified
-			default:
-				file.ConflictType = models.ConflictNone
-			}
-			continue
-		}
+	// Only compute hashes if both files exist and ModTime suggests they're the same
+	// This avoids expensive hash computation in most cases
+	localExists := false
+	dotfilesExists := false
 
-		// For regular files, compute hashes (they're usually small)
-		localHash, _ := ComputeFileHash(file.Path)
-		dotfilesHash, _ := ComputeFileHash(dotfilesFilePath)
+	if _, err := vfs.Stat(file.Path); err == nil {
+		localExists = true
+	}
+	if _, err := vfs.Stat(dotfilesFilePath); err == nil {
+		dotfilesExists = true
+	}
 
-		file.LocalHash = localHash
-		file.DotfilesHash = dotfilesHash
+	// Fast path: if one doesn't exist, no need to hash
+	if !localExists && !dotfilesExists {
+		file.ConflictType = models.ConflictNone
+		return nil
+	}
+	if !localExists {
+		file.ConflictType = models.ConflictDotfilesNew
+		return nil
+	}
+	if !dotfilesExists {
+		file.ConflictType = models.ConflictLocalNew
+		return nil
+	}
 
-		// Detect conflict using state manager
-		if stateManager != nil {
-			file.ConflictType = stateManager.DetectConflict(app.ID, file.RelPath, localHash, dotfilesHash)
-		} else {
-			// Fallback: simple hash comparison without history
-			file.ConflictType = detectConflictSimple(localHash, dotfilesHash)
+	// Both exist - use quick comparison first for files (skip large directories)
+	if file.IsDir {
+		// For directories, use ModTime-based status instead of hashing
+		// This is much faster for large directories like nvim configs
+		switch file.SyncStatus {
+		case models.StatusSynced:
+			file.ConflictType = models.ConflictNone
+		case models.StatusModified:
+			file.ConflictType = models.ConflictLocalModified
+		case models.StatusOutdated:
+			file.ConflictType = models.ConflictDotfilesModified
+		default:
+			file.ConflictType = models.ConflictNone
 		}
+		return nil
+	}
+
+	// For regular files, compute hashes. Large ones are streamed and
+	// stop when the scan is cancelled.
+	localHash, _ := ComputeFileHashContext(ctx, file.Path)
+	dotfilesHash, _ := ComputeFileHashContext(ctx, dotfilesFilePath)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	file.LocalHash = localHash
+	file.DotfilesHash = dotfilesHash
+
+	// Detect conflict using state manager
+	if stateManager != nil {
+		file.ConflictType = stateManager.DetectConflict(appID, file.RelPath, localHash, dotfilesHash)
+	} else {
+		// Fallback: simple hash comparison without history
+		file.ConflictType = detectConflictSimple(localHash, dotfilesHash)
 	}
+	return nil
 }
 
 // detectConflictSimple detects conflicts without sync state history
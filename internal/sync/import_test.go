@@ -1,13 +1,18 @@
 package sync
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"dotsync/internal/config"
+	"dotsync/internal/layout"
 	"dotsync/internal/models"
+	"dotsync/internal/nestedrepo"
+	"dotsync/internal/policy"
 )
 
 func TestNewImporter(t *testing.T) {
@@ -142,6 +147,67 @@ func TestUpdateSyncStatus(t *testing.T) {
 	}
 }
 
+func TestRefreshFileStatus(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	os.MkdirAll(filepath.Join(dotfilesDir, "testapp"), 0755)
+	os.WriteFile(filepath.Join(dotfilesDir, "testapp", "config.txt"), []byte("content"), 0644)
+	localFile := filepath.Join(tempDir, "config.txt")
+	os.WriteFile(localFile, []byte("content"), 0644)
+
+	sm := NewStateManager(tempDir)
+	app := &models.App{
+		ID:    "testapp",
+		Files: []models.File{{Name: "config.txt", Path: localFile, RelPath: "config.txt"}},
+	}
+	UpdateSyncStatusWithHashes(app, dotfilesDir, sm)
+	file := &app.Files[0]
+	sm.SetFileState("testapp", "config.txt", file.LocalHash, file.DotfilesHash)
+
+	// An edit keeping the ModTime is still picked up
+	info, _ := os.Stat(localFile)
+	os.WriteFile(localFile, []byte("edited content"), 0644)
+	os.Chtimes(localFile, info.ModTime(), info.ModTime())
+
+	if err := RefreshFileStatus(context.Background(), "testapp", file, dotfilesDir, sm); err != nil {
+		t.Fatalf("RefreshFileStatus failed: %v", err)
+	}
+	if file.ConflictType != models.ConflictLocalModified {
+		t.Errorf("Expected ConflictLocalModified, got %v", file.ConflictType)
+	}
+	if file.Size != int64(len("edited content")) {
+		t.Errorf("Expected the size updated, got %d", file.Size)
+	}
+}
+
+func TestUpdateSyncStatusAll_Cancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	localFile := filepath.Join(tempDir, "config.txt")
+	os.WriteFile(localFile, []byte("content"), 0644)
+
+	app := &models.App{
+		ID:    "testapp",
+		Files: []models.File{{Name: "config.txt", Path: localFile, RelPath: "config.txt"}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := UpdateSyncStatusAll(ctx, []*models.App{app}, filepath.Join(tempDir, "dotfiles"), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if app.Files[0].SyncStatus != models.StatusUnknown {
+		t.Error("Expected files left uncompared after cancellation")
+	}
+
+	if err := UpdateSyncStatusAll(context.Background(), []*models.App{app}, filepath.Join(tempDir, "dotfiles"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if app.Files[0].SyncStatus == models.StatusUnknown {
+		t.Error("Expected the status updated without cancellation")
+	}
+}
+
 func TestUpdateSyncStatusWithHashes(t *testing.T) {
 	tempDir := t.TempDir()
 	dotfilesDir := filepath.Join(tempDir, "dotfiles")
@@ -614,3 +680,613 @@ func TestCompareFiles_DotfilesNewer(t *testing.T) {
 		t.Errorf("Expected StatusOutdated (dotfiles newer), got %v", status)
 	}
 }
+
+func TestImportApp_SkipsConflicts(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	localDir := filepath.Join(tempDir, "local")
+	os.MkdirAll(filepath.Join(dotfilesDir, "test"), 0755)
+	os.MkdirAll(localDir, 0755)
+
+	localPath := filepath.Join(localDir, "config.txt")
+	srcPath := filepath.Join(dotfilesDir, "test", "config.txt")
+	os.WriteFile(localPath, []byte("local edit"), 0644)
+	os.WriteFile(srcPath, []byte("dotfiles edit"), 0644)
+
+	// Last sync recorded a different common version
+	sm := NewStateManager(tempDir)
+	sm.SetFileState("test", "config.txt", "base", "base")
+
+	cfg := config.Default()
+	cfg.DotfilesPath = dotfilesDir
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+
+	importer := NewImporter(cfg).WithStateManager(sm)
+	app := &models.App{
+		ID: "test",
+		Files: []models.File{
+			{Name: "config.txt", Path: localPath, RelPath: "config.txt", Selected: true},
+		},
+	}
+
+	results, err := importer.ImportApp(app)
+	if err != nil {
+		t.Fatalf("ImportApp failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if !results[0].Conflict || results[0].Success {
+		t.Errorf("Expected conflict result, got %+v", results[0])
+	}
+
+	content, _ := os.ReadFile(localPath)
+	if string(content) != "local edit" {
+		t.Errorf("Local file should not be overwritten, got %q", content)
+	}
+}
+
+func TestImportApp_ConflictPolicies(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     policy.Rules
+		want      string
+		keptLocal bool
+	}{
+		{"prefer-remote default", policy.Rules{Default: policy.PreferRemote}, "dotfiles edit", false},
+		{"prefer-local for app", policy.Rules{Default: policy.PreferRemote, Apps: map[string]policy.Policy{"test": policy.PreferLocal}}, "local edit", true},
+		{"prefer-remote for file", policy.Rules{Default: policy.PreferLocal, Files: map[string]policy.Policy{"test/config.txt": policy.PreferRemote}}, "dotfiles edit", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			dotfilesDir := filepath.Join(tempDir, "dotfiles")
+			os.MkdirAll(filepath.Join(dotfilesDir, "test"), 0755)
+
+			localPath := filepath.Join(tempDir, "config.txt")
+			os.WriteFile(localPath, []byte("local edit"), 0644)
+			os.WriteFile(filepath.Join(dotfilesDir, "test", "config.txt"), []byte("dotfiles edit"), 0644)
+
+			sm := NewStateManager(tempDir)
+			sm.SetFileState("test", "config.txt", "base", "base")
+
+			cfg := config.Default()
+			cfg.DotfilesPath = dotfilesDir
+			cfg.BackupPath = filepath.Join(tempDir, "backup")
+			cfg.Conflicts = tt.rules
+
+			app := &models.App{
+				ID: "test",
+				Files: []models.File{
+					{Name: "config.txt", Path: localPath, RelPath: "config.txt", Selected: true},
+				},
+			}
+
+			results, err := NewImporter(cfg).WithStateManager(sm).ImportApp(app)
+			if err != nil {
+				t.Fatalf("ImportApp failed: %v", err)
+			}
+			if len(results) != 1 || !results[0].Success || results[0].Conflict {
+				t.Fatalf("Expected resolved result, got %+v", results)
+			}
+			if results[0].Policy == "" || results[0].KeptLocal != tt.keptLocal {
+				t.Errorf("Unexpected resolution: %+v", results[0])
+			}
+
+			content, _ := os.ReadFile(localPath)
+			if string(content) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, content)
+			}
+		})
+	}
+}
+
+func TestImportApp_NoHistoryOverwrites(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	localDir := filepath.Join(tempDir, "local")
+	os.MkdirAll(filepath.Join(dotfilesDir, "test"), 0755)
+	os.MkdirAll(localDir, 0755)
+
+	localPath := filepath.Join(localDir, "config.txt")
+	os.WriteFile(localPath, []byte("local"), 0644)
+	os.WriteFile(filepath.Join(dotfilesDir, "test", "config.txt"), []byte("dotfiles"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = dotfilesDir
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+
+	// Without sync history there is no baseline, so pull proceeds (with backup)
+	importer := NewImporter(cfg).WithStateManager(NewStateManager(tempDir))
+	app := &models.App{
+		ID: "test",
+		Files: []models.File{
+			{Name: "config.txt", Path: localPath, RelPath: "config.txt", Selected: true},
+		},
+	}
+
+	results, err := importer.ImportApp(app)
+	if err != nil {
+		t.Fatalf("ImportApp failed: %v", err)
+	}
+	if len(results) != 1 || !results[0].Success || results[0].Conflict {
+		t.Errorf("Expected successful import, got %+v", results)
+	}
+}
+
+func TestSandboxPath(t *testing.T) {
+	homeDir, _ := os.UserHomeDir()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(homeDir, ".zshrc"), filepath.Join("/sandbox", ".zshrc")},
+		{filepath.Join(homeDir, ".config", "nvim"), filepath.Join("/sandbox", ".config", "nvim")},
+		{"/etc/hosts", filepath.Join("/sandbox", "_root", "etc", "hosts")},
+	}
+
+	for _, tt := range tests {
+		if got := SandboxPath("/sandbox", tt.path); got != tt.want {
+			t.Errorf("SandboxPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestImportApp_Sandbox(t *testing.T) {
+	tempDir := t.TempDir()
+	homeDir, _ := os.UserHomeDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	sandbox := filepath.Join(tempDir, "sandbox")
+	os.MkdirAll(filepath.Join(dotfilesDir, "test", "app"), 0755)
+	os.WriteFile(filepath.Join(dotfilesDir, "test", "app", "config.toml"), []byte("dotfiles"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = dotfilesDir
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+
+	// The real path is never touched; it lands inside the sandbox instead
+	realPath := filepath.Join(homeDir, ".config", "dotsync-sandbox-test-app")
+	app := &models.App{
+		ID: "test",
+		Files: []models.File{
+			{Name: "app", Path: realPath, RelPath: "app", IsDir: true, Selected: true},
+		},
+	}
+
+	results, err := NewImporter(cfg).WithSandbox(sandbox).ImportApp(app)
+	if err != nil {
+		t.Fatalf("ImportApp failed: %v", err)
+	}
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("Expected successful sandbox import, got %+v", results)
+	}
+
+	if _, err := os.Stat(realPath); !os.IsNotExist(err) {
+		t.Error("Sandbox import should not write to the real path")
+	}
+	content, err := os.ReadFile(filepath.Join(SandboxPath(sandbox, realPath), "config.toml"))
+	if err != nil || string(content) != "dotfiles" {
+		t.Errorf("Expected file in sandbox, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(cfg.BackupPath); !os.IsNotExist(err) {
+		t.Error("Sandbox import should not create backups")
+	}
+}
+
+func TestImportApp_VolatileKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	appDir := filepath.Join(dotfilesDir, "vscode", "User")
+	os.MkdirAll(appDir, 0755)
+	os.WriteFile(filepath.Join(appDir, "settings.json"), []byte(`{"font": 14}`), 0644)
+
+	localDir := filepath.Join(tempDir, "local", "User")
+	os.MkdirAll(localDir, 0755)
+	os.WriteFile(filepath.Join(localDir, "settings.json"), []byte(`{"font": 12, "window": {"x": 4}}`), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = dotfilesDir
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+	cfg.VolatileKeys = map[string][]string{"vscode": {"$.window"}}
+
+	importer := NewImporter(cfg)
+	app := &models.App{
+		ID: "vscode",
+		Files: []models.File{
+			{Name: "User", Path: localDir, RelPath: "User", IsDir: true, Selected: true},
+		},
+	}
+
+	results, err := importer.ImportApp(app)
+	if err != nil {
+		t.Fatalf("ImportApp failed: %v", err)
+	}
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("Import should succeed: %+v", results)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(localDir, "settings.json"))
+	if string(data) != `{"font": 14, "window": {"x": 4}}` {
+		t.Errorf("Local volatile keys should be kept, got %q", data)
+	}
+}
+
+func TestImportApp_LocalSections(t *testing.T) {
+	tempDir := t.TempDir()
+	localPath := filepath.Join(tempDir, "home", ".zshrc")
+	os.MkdirAll(filepath.Dir(localPath), 0755)
+	os.WriteFile(localPath, []byte("alias g=git\n# dotsync:local-begin\nexport WORK=1\n# dotsync:local-end\n"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+	app := &models.App{
+		ID:    "zsh",
+		Files: []models.File{{Name: ".zshrc", Path: localPath, RelPath: ".zshrc", Selected: true}},
+	}
+	if !RewritesOnSync(cfg, "zsh", app.Files[0]) {
+		t.Error("Files with local sections should count as rewritten on sync")
+	}
+
+	if _, err := NewExporter(cfg).ExportApp(app); err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+	dotfilePath := filepath.Join(cfg.DotfilesPath, "zsh", ".zshrc")
+	data, _ := os.ReadFile(dotfilePath)
+	if string(data) != "alias g=git\n# dotsync:local-begin\n# dotsync:local-end\n" {
+		t.Errorf("Local section body should stay out of dotfiles, got %q", data)
+	}
+
+	// Another machine changed the shared part
+	os.WriteFile(dotfilePath, []byte("alias g=git\nalias l=ls\n# dotsync:local-begin\n# dotsync:local-end\n"), 0644)
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("Import should succeed: %+v, %v", results, err)
+	}
+	data, _ = os.ReadFile(localPath)
+	if string(data) != "alias g=git\nalias l=ls\n# dotsync:local-begin\nexport WORK=1\n# dotsync:local-end\n" {
+		t.Errorf("Local section should survive the pull, got %q", data)
+	}
+}
+
+func TestImportApp_SSHConfigMerge(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	os.MkdirAll(filepath.Join(dotfilesDir, "ssh"), 0755)
+	os.WriteFile(filepath.Join(dotfilesDir, "ssh", "config"), []byte("Host nas\n  User shared\n\nHost github\n  User git\n"), 0644)
+
+	sshDir := filepath.Join(tempDir, "home", ".ssh")
+	os.MkdirAll(sshDir, 0700)
+	localPath := filepath.Join(sshDir, "config")
+	os.WriteFile(localPath, []byte("Host nas\n  User me\n\nHost work\n  User corp\n"), 0600)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = dotfilesDir
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+
+	app := &models.App{
+		ID:    "ssh",
+		Files: []models.File{{Name: "config", Path: localPath, RelPath: "config", Selected: true}},
+	}
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("ImportApp failed: %v %+v", err, results)
+	}
+
+	data, _ := os.ReadFile(localPath)
+	want := "Host nas\n  User shared\n\nHost work\n  User corp\n\nHost github\n  User git\n"
+	if string(data) != want {
+		t.Errorf("Expected merged config\n%s\ngot\n%s", want, data)
+	}
+	if info, _ := os.Stat(localPath); info.Mode().Perm() != 0600 {
+		t.Errorf("Merge should keep permissions, got %v", info.Mode().Perm())
+	}
+}
+
+func TestImportApp_GitConfigIdentity(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	os.MkdirAll(filepath.Join(dotfilesDir, "git"), 0755)
+	os.WriteFile(filepath.Join(dotfilesDir, "git", ".gitconfig"),
+		[]byte("[user]\n\temail = {{machine:user.email}}\n[core]\n\teditor = nvim\n"), 0644)
+
+	localPath := filepath.Join(tempDir, "home", ".gitconfig")
+	os.MkdirAll(filepath.Dir(localPath), 0755)
+	os.WriteFile(localPath, []byte("[user]\n\temail = me@home.example\n"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = dotfilesDir
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+
+	app := &models.App{
+		ID:    "git",
+		Files: []models.File{{Name: ".gitconfig", Path: localPath, RelPath: ".gitconfig", Selected: true}},
+	}
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("ImportApp failed: %v %+v", err, results)
+	}
+
+	data, _ := os.ReadFile(localPath)
+	if string(data) != "[user]\n\temail = me@home.example\n[core]\n\teditor = nvim\n" {
+		t.Errorf("Expected the local identity with the shared settings, got %q", data)
+	}
+}
+
+func TestImportApp_SymlinkPolicies(t *testing.T) {
+	tests := []struct {
+		policy     string
+		storedLink bool   // Dotfiles copy is a link
+		wantLink   string // Local link target afterwards, "" for a regular file
+		wantTarget string // Content of the stow target afterwards
+	}{
+		{"follow", false, "../stow/zshrc", "new"},
+		{"copy-target", false, "", "old"},
+		{"skip", false, "../stow/zshrc", "old"},
+		{"preserve-as-link", true, "../stow/zshrc", "old"},
+		{"follow", true, "../stow/zshrc", "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cfg := config.Default()
+			cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+			cfg.BackupPath = filepath.Join(tempDir, "backup")
+			cfg.Symlinks = tt.policy
+
+			target := filepath.Join(tempDir, "stow", "zshrc")
+			os.MkdirAll(filepath.Dir(target), 0755)
+			os.WriteFile(target, []byte("old"), 0644)
+			link := filepath.Join(tempDir, "home", ".zshrc")
+			os.MkdirAll(filepath.Dir(link), 0755)
+			os.Symlink("../stow/zshrc", link)
+
+			stored := filepath.Join(cfg.DotfilesPath, "zsh", ".zshrc")
+			os.MkdirAll(filepath.Dir(stored), 0755)
+			if tt.storedLink {
+				os.Symlink("../stow/zshrc", stored)
+			} else {
+				os.WriteFile(stored, []byte("new"), 0644)
+			}
+
+			app := &models.App{ID: "zsh", Selected: true, Files: []models.File{
+				{Name: ".zshrc", Path: link, RelPath: ".zshrc", Selected: true},
+			}}
+			if _, err := NewImporter(cfg).ImportApp(app); err != nil {
+				t.Fatalf("ImportApp failed: %v", err)
+			}
+
+			got, _ := os.Readlink(link)
+			if got != tt.wantLink {
+				t.Errorf("Local link = %q, want %q", got, tt.wantLink)
+			}
+			if tt.wantLink == "" {
+				if data, _ := os.ReadFile(link); string(data) != "new" {
+					t.Errorf("Expected a regular copy of dotfiles, got %q", data)
+				}
+			}
+			if data, _ := os.ReadFile(target); string(data) != tt.wantTarget {
+				t.Errorf("Target content = %q, want %q", data, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestExportImport_FlatLayout(t *testing.T) {
+	tempDir := t.TempDir()
+	localPath := filepath.Join(tempDir, "home", ".zshrc")
+	os.MkdirAll(filepath.Dir(localPath), 0755)
+	os.WriteFile(localPath, []byte("alias g=git\n"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+	flat, _ := layout.New(layout.Flat)
+	if err := layout.Save(cfg.DotfilesPath, flat); err != nil {
+		t.Fatal(err)
+	}
+	app := &models.App{
+		ID:       "zsh",
+		Selected: true,
+		Files:    []models.File{{Name: ".zshrc", Path: localPath, RelPath: ".zshrc", Selected: true}},
+	}
+
+	if _, err := NewExporter(cfg).ExportApp(app); err != nil {
+		t.Fatalf("ExportApp failed: %v", err)
+	}
+	dotfilePath := filepath.Join(cfg.DotfilesPath, ".zshrc")
+	if _, err := os.Stat(dotfilePath); err != nil {
+		t.Fatalf("Expected the file at the top of the repo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DotfilesPath, "zsh")); !os.IsNotExist(err) {
+		t.Error("Expected no per-app directory in the flat layout")
+	}
+	if got := DotfilePath(cfg.DotfilesPath, "zsh", app.Files[0]); got != dotfilePath {
+		t.Errorf("DotfilePath() = %s, want %s", got, dotfilePath)
+	}
+
+	os.WriteFile(dotfilePath, []byte("alias g=git\nalias l=ls\n"), 0644)
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("Import should succeed: %+v, %v", results, err)
+	}
+	if data, _ := os.ReadFile(localPath); string(data) != "alias g=git\nalias l=ls\n" {
+		t.Errorf("Expected the pulled file, got %q", data)
+	}
+}
+
+func TestUpdateSyncStatus_PinnedRepoFlatLayout(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	flat, _ := layout.New(layout.Flat)
+	if err := layout.Save(dotfilesDir, flat); err != nil {
+		t.Fatal(err)
+	}
+	manifest := &nestedrepo.Manifest{Version: 1}
+	manifest.Upsert(nestedrepo.Repo{Path: "plugin", Remote: "https://example.com/plugin.git", Commit: "abc123"})
+	if err := manifest.Save(manifestDir(flat, dotfilesDir, "nvim")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dotfilesDir, "nvim")); !os.IsNotExist(err) {
+		t.Error("Expected no per-app directory in the flat layout")
+	}
+
+	// The pin is found, so the missing clone shows up as such
+	app := &models.App{
+		ID:    "nvim",
+		Files: []models.File{{Name: "plugin", Path: filepath.Join(tempDir, "local", "plugin"), RelPath: "plugin", IsDir: true, NestedRepo: true}},
+	}
+	UpdateSyncStatus(app, dotfilesDir)
+	if app.Files[0].SyncStatus != models.StatusMissing {
+		t.Errorf("Expected the pinned repo missing locally, got %v", app.Files[0].SyncStatus)
+	}
+}
+
+func TestImportApp_PinnedRepoCloneFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+
+	// A repo pinned inside a directory that is pulled wholesale
+	appDir := filepath.Join(cfg.DotfilesPath, "nvim")
+	os.MkdirAll(filepath.Join(appDir, "lua"), 0755)
+	os.WriteFile(filepath.Join(appDir, "lua", "init.lua"), []byte("x"), 0644)
+	manifest := &nestedrepo.Manifest{Version: 1}
+	manifest.Upsert(nestedrepo.Repo{Path: filepath.Join("lua", "plugin"), Remote: filepath.Join(tempDir, "missing.git"), Commit: "abc123"})
+	if err := manifest.Save(appDir); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &models.App{
+		ID:    "nvim",
+		Files: []models.File{{Name: "lua", Path: filepath.Join(tempDir, "local", "lua"), RelPath: "lua", IsDir: true, Selected: true}},
+	}
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil {
+		t.Fatalf("ImportApp failed: %v", err)
+	}
+	if len(results) != 2 || !results[0].Success {
+		t.Fatalf("Expected the pulled directory and the failed clone, got %+v", results)
+	}
+	if r := results[1]; r.Error == nil || r.File.RelPath != filepath.Join("lua", "plugin") {
+		t.Errorf("Expected the failed clone reported, got %+v", r)
+	}
+}
+
+func TestImportApp_RolledBackSkipsPinnedRepos(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+
+	appDir := filepath.Join(cfg.DotfilesPath, "nvim")
+	os.MkdirAll(filepath.Join(appDir, "lua"), 0755)
+	os.WriteFile(filepath.Join(appDir, "lua", "init.lua"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(appDir, "b.conf"), []byte("y"), 0644)
+	manifest := &nestedrepo.Manifest{Version: 1}
+	manifest.Upsert(nestedrepo.Repo{Path: filepath.Join("lua", "plugin"), Remote: filepath.Join(tempDir, "missing.git")})
+	if err := manifest.Save(appDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// b.conf goes under a file, so it cannot be written
+	blocker := filepath.Join(tempDir, "blocker")
+	os.WriteFile(blocker, []byte("x"), 0644)
+	app := &models.App{
+		ID: "nvim",
+		Files: []models.File{
+			{Name: "lua", Path: filepath.Join(tempDir, "local", "lua"), RelPath: "lua", IsDir: true, Selected: true},
+			{Name: "b.conf", Path: filepath.Join(blocker, "b.conf"), RelPath: "b.conf", Selected: true},
+		},
+	}
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected no pinned repo restored after the rollback, got %+v", results)
+	}
+}
+
+func TestImportApp_RolledBackSkipsPinnedClones(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+
+	appDir := filepath.Join(cfg.DotfilesPath, "nvim")
+	os.MkdirAll(appDir, 0755)
+	os.WriteFile(filepath.Join(appDir, "b.conf"), []byte("y"), 0644)
+	manifest := &nestedrepo.Manifest{Version: 1}
+	manifest.Upsert(nestedrepo.Repo{Path: "plugin", Remote: filepath.Join(tempDir, "missing.git")})
+	if err := manifest.Save(appDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// b.conf goes under a file, so it cannot be written
+	blocker := filepath.Join(tempDir, "blocker")
+	os.WriteFile(blocker, []byte("x"), 0644)
+	app := &models.App{
+		ID: "nvim",
+		Files: []models.File{
+			{Name: "plugin", Path: filepath.Join(tempDir, "local", "plugin"), RelPath: "plugin", IsDir: true, NestedRepo: true, Selected: true},
+			{Name: "b.conf", Path: filepath.Join(blocker, "b.conf"), RelPath: "b.conf", Selected: true},
+		},
+	}
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !errors.Is(results[0].Error, ErrRolledBack) {
+		t.Fatalf("Expected the pinned repo rolled back without cloning, got %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "local")); !os.IsNotExist(err) {
+		t.Error("A rolled-back pull should not clone pinned repos")
+	}
+}
+
+func TestImportFile_KeepsMachineValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		appID    string
+		relPath  string
+		dotfiles string
+		local    string
+		want     string
+	}{
+		{"volatile keys", "vscode", "settings.json", `{"font": 14}`, `{"font": 12, "window": {"x": 4}}`, `{"font": 14, "window": {"x": 4}}`},
+		{"machine-only ssh hosts", "ssh", "config", "Host nas\n  User shared\n", "Host nas\n  User me\n\nHost work\n  User corp\n", "Host nas\n  User shared\n\nHost work\n  User corp\n"},
+		{"git identity", "git", ".gitconfig", "[user]\n\temail = {{machine:user.email}}\n", "[user]\n\temail = me@home.example\n", "[user]\n\temail = me@home.example\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			localPath := filepath.Join(tempDir, "home", "."+tt.appID, tt.relPath)
+			if tt.appID == "git" {
+				localPath = filepath.Join(tempDir, "home", tt.relPath)
+			}
+			os.MkdirAll(filepath.Dir(localPath), 0755)
+			os.WriteFile(localPath, []byte(tt.local), 0644)
+
+			cfg := config.Default()
+			cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+			cfg.BackupPath = filepath.Join(tempDir, "backups")
+			cfg.VolatileKeys = map[string][]string{"vscode": {"$.window"}}
+
+			src := filepath.Join(cfg.DotfilesPath, tt.appID, tt.relPath)
+			os.MkdirAll(filepath.Dir(src), 0755)
+			os.WriteFile(src, []byte(tt.dotfiles), 0644)
+
+			if err := NewImporter(cfg).ImportFile(tt.appID, tt.relPath, src, localPath); err != nil {
+				t.Fatalf("ImportFile failed: %v", err)
+			}
+			data, _ := os.ReadFile(localPath)
+			if string(data) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package sync
+
+import (
+	"path/filepath"
+
+	"dotsync/internal/layout"
+	"dotsync/internal/models"
+)
+
+// DotfilePath returns where an app's file is stored in the dotfiles repo,
+// following the repo's layout
+func DotfilePath(dotfilesPath, appID string, file models.File) string {
+	return layout.Find(layout.For(dotfilesPath), dotfilesPath, appID, file.RelPath, file.Path)
+}
+
+// manifestDir returns where the manifest of an app's pinned nested repos
+// is kept: the app's own directory in the default layout, and under the
+// repo's metadata in layouts without per-app directories
+func manifestDir(lay layout.Layout, dotfilesPath, appID string) string {
+	if layout.IsDefault(lay) {
+		return filepath.Join(dotfilesPath, appID)
+	}
+	return filepath.Join(dotfilesPath, ".dotsync", "repos", appID)
+}
@@ -2,9 +2,10 @@ package sync
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
+
+	"dotsync/internal/vfs"
 )
 
 // MergeResolution represents how a conflict hunk should be resolved
@@ -41,44 +42,62 @@ type MergeResult struct {
 	MergedContent   string
 }
 
-// NewMergeResult creates a MergeResult from a DiffResult
+// NewMergeResult creates a MergeResult from a DiffResult. Each contiguous
+// run of changed lines becomes its own hunk, so a diff hunk covering two
+// nearby changes yields two merge hunks.
 func NewMergeResult(diffResult *DiffResult, localPath, dotfilesPath string) *MergeResult {
 	result := &MergeResult{
 		FilePath:     diffResult.OldPath,
 		LocalPath:    localPath,
 		DotfilesPath: dotfilesPath,
-		TotalHunks:   len(diffResult.Hunks),
 	}
 
-	// Convert diff hunks to merge hunks
-	for i, hunk := range diffResult.Hunks {
-		mergeHunk := MergeHunk{
-			Index:      i,
-			StartLine:  hunk.StartOld,
-			Resolution: ResolutionPending,
-		}
+	for _, hunk := range diffResult.Hunks {
+		oldLine := hunk.StartOld
+		var context []string
+		var current *MergeHunk
 
-		// Separate lines by type
 		for _, line := range hunk.DiffLines {
-			switch line.Type {
-			case DiffDelete:
+			if line.Type == DiffEqual {
+				if current != nil {
+					current.ContextAfter = append(current.ContextAfter, line.Content)
+				}
+				context = append(context, line.Content)
+				oldLine++
+				continue
+			}
+
+			// A change after context lines starts a new hunk
+			if current == nil || len(current.ContextAfter) > 0 {
+				if current != nil {
+					result.Hunks = append(result.Hunks, *current)
+				}
+				current = &MergeHunk{
+					Index:         len(result.Hunks),
+					StartLine:     oldLine,
+					Resolution:    ResolutionPending,
+					ContextBefore: context,
+				}
+			}
+			context = nil
+
+			if line.Type == DiffDelete {
 				// Deleted lines are from local (old)
-				mergeHunk.LocalLines = append(mergeHunk.LocalLines, line.Content)
-			case DiffInsert:
+				current.LocalLines = append(current.LocalLines, line.Content)
+				oldLine++
+			} else {
 				// Inserted lines are from dotfiles (new)
-				mergeHunk.DotfilesLines = append(mergeHunk.DotfilesLines, line.Content)
-			case DiffEqual:
-				// Context lines - add to appropriate place
-				if len(mergeHunk.LocalLines) == 0 && len(mergeHunk.DotfilesLines) == 0 {
-					mergeHunk.ContextBefore = append(mergeHunk.ContextBefore, line.Content)
-				} else {
-					mergeHunk.ContextAfter = append(mergeHunk.ContextAfter, line.Content)
-				}
+				current.DotfilesLines = append(current.DotfilesLines, line.Content)
 			}
 		}
 
-		result.Hunks = append(result.Hunks, mergeHunk)
+		if current == nil {
+			// Keep a hunk without changed lines as a single pending hunk
+			current = &MergeHunk{Index: len(result.Hunks), StartLine: hunk.StartOld, ContextBefore: context}
+		}
+		result.Hunks = append(result.Hunks, *current)
 	}
+	result.TotalHunks = len(result.Hunks)
 
 	return result
 }
@@ -146,8 +165,40 @@ func (m *MergeResult) GenerateMergedContent() (string, error) {
 		return "", fmt.Errorf("not all hunks are resolved (%d/%d)", m.ResolvedHunks, m.TotalHunks)
 	}
 
+	content, err := m.assemble(func(h MergeHunk) []string { return h.ResolvedContent })
+	if err != nil {
+		return "", err
+	}
+	m.MergedContent = content
+	return m.MergedContent, nil
+}
+
+// Conflict markers written around unresolved hunks
+const (
+	MarkerLocal    = "<<<<<<< LOCAL"
+	MarkerSep      = "======="
+	MarkerDotfiles = ">>>>>>> DOTFILES"
+)
+
+// ContentWithMarkers returns the local file with resolved hunks applied and
+// pending ones left as conflict markers, for cleaning up by hand
+func (m *MergeResult) ContentWithMarkers() (string, error) {
+	return m.assemble(func(h MergeHunk) []string {
+		if h.Resolution != ResolutionPending {
+			return h.ResolvedContent
+		}
+		lines := append([]string{MarkerLocal}, h.LocalLines...)
+		lines = append(lines, MarkerSep)
+		lines = append(lines, h.DotfilesLines...)
+		return append(lines, MarkerDotfiles)
+	})
+}
+
+// assemble rebuilds the local file, replacing each hunk's local lines with
+// the lines hunkLines returns for it
+func (m *MergeResult) assemble(hunkLines func(h MergeHunk) []string) (string, error) {
 	// Read the base file (local version)
-	content, err := os.ReadFile(m.LocalPath)
+	content, err := vfs.ReadFile(m.LocalPath)
 	if err != nil {
 		return "", fmt.Errorf("cannot read local file: %w", err)
 	}
@@ -155,37 +206,64 @@ func (m *MergeResult) GenerateMergedContent() (string, error) {
 	lines := strings.Split(string(content), "\n")
 	var result []string
 
-	// Simple approach: rebuild the file with resolved hunks
-	// For a more sophisticated merge, we'd need line-by-line tracking
 	lineIndex := 0
 	for _, hunk := range m.Hunks {
-		// Add lines before this hunk
+		// Copy unchanged lines up to this hunk
 		for lineIndex < hunk.StartLine-1 && lineIndex < len(lines) {
 			result = append(result, lines[lineIndex])
 			lineIndex++
 		}
 
-		// Add context before
-		result = append(result, hunk.ContextBefore...)
-
-		// Add resolved content
-		result = append(result, hunk.ResolvedContent...)
+		result = append(result, hunkLines(hunk)...)
 
 		// Skip the original local lines that were part of this hunk
 		lineIndex += len(hunk.LocalLines)
-
-		// Add context after
-		result = append(result, hunk.ContextAfter...)
 	}
 
 	// Add remaining lines
-	for lineIndex < len(lines) {
-		result = append(result, lines[lineIndex])
-		lineIndex++
+	if lineIndex < len(lines) {
+		result = append(result, lines[lineIndex:]...)
 	}
 
-	m.MergedContent = strings.Join(result, "\n")
-	return m.MergedContent, nil
+	return strings.Join(result, "\n"), nil
+}
+
+// CherryPickContent returns the dotfiles version with only the hunks
+// resolved as keep-local (or by hand) taken from the local file. Every
+// other hunk keeps its dotfiles lines, so local-only changes such as
+// machine-specific PATH entries stay out of the dotfiles.
+func (m *MergeResult) CherryPickContent() (string, error) {
+	return m.assemble(func(h MergeHunk) []string {
+		switch h.Resolution {
+		case ResolutionKeepLocal, ResolutionManual:
+			return h.ResolvedContent
+		}
+		return h.DotfilesLines
+	})
+}
+
+// PickedHunks counts the hunks a cherry-pick pushes to dotfiles
+func (m *MergeResult) PickedHunks() int {
+	n := 0
+	for _, h := range m.Hunks {
+		if h.Resolution == ResolutionKeepLocal || h.Resolution == ResolutionManual {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteCherryPick writes CherryPickContent to the dotfiles path. The local
+// file is left as it is, held-back hunks included.
+func (m *MergeResult) WriteCherryPick() error {
+	content, err := m.CherryPickContent()
+	if err != nil {
+		return err
+	}
+	if err := vfs.MkdirAll(filepath.Dir(m.DotfilesPath), 0755); err != nil {
+		return fmt.Errorf("cannot create directory: %w", err)
+	}
+	return vfs.WriteFile(m.DotfilesPath, []byte(content), 0644)
 }
 
 // WriteMergedFile writes the merged content to the local path
@@ -197,11 +275,11 @@ func (m *MergeResult) WriteMergedFile() error {
 	}
 
 	// Create parent directory if needed
-	if err := os.MkdirAll(filepath.Dir(m.LocalPath), 0755); err != nil {
+	if err := vfs.MkdirAll(filepath.Dir(m.LocalPath), 0755); err != nil {
 		return fmt.Errorf("cannot create directory: %w", err)
 	}
 
-	return os.WriteFile(m.LocalPath, []byte(m.MergedContent), 0644)
+	return vfs.WriteFile(m.LocalPath, []byte(m.MergedContent), 0644)
 }
 
 // FormatHunkPreview formats a hunk for display in the UI
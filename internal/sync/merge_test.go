@@ -233,6 +233,68 @@ func TestGenerateMergedContent_Success(t *testing.T) {
 	}
 }
 
+func TestGenerateMergedContent_RoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	localFile := filepath.Join(tempDir, "local.txt")
+	dotfilesFile := filepath.Join(tempDir, "dotfiles.txt")
+	local := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	dotfiles := "new\na\nB\nc\nD\ne\nf\ng\nh\ni\nj\nk\n"
+	os.WriteFile(localFile, []byte(local), 0644)
+	os.WriteFile(dotfilesFile, []byte(dotfiles), 0644)
+
+	tests := []struct {
+		name    string
+		resolve func(*MergeResult)
+		want    string
+	}{
+		{"keep local", (*MergeResult).KeepAllLocal, local},
+		{"use dotfiles", (*MergeResult).UseAllDotfiles, dotfiles},
+	}
+
+	for _, tt := range tests {
+		diffResult, err := ComputeDiff(localFile, dotfilesFile)
+		if err != nil {
+			t.Fatalf("ComputeDiff failed: %v", err)
+		}
+		result := NewMergeResult(diffResult, localFile, dotfilesFile)
+		tt.resolve(result)
+		content, err := result.GenerateMergedContent()
+		if err != nil {
+			t.Fatalf("%s: GenerateMergedContent failed: %v", tt.name, err)
+		}
+		if content != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, content, tt.want)
+		}
+	}
+}
+
+func TestContentWithMarkers(t *testing.T) {
+	tempDir := t.TempDir()
+	localFile := filepath.Join(tempDir, "local.txt")
+	dotfilesFile := filepath.Join(tempDir, "dotfiles.txt")
+	os.WriteFile(localFile, []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj"), 0644)
+	os.WriteFile(dotfilesFile, []byte("a\nB\nc\nd\ne\nf\ng\nh\nI\nj"), 0644)
+
+	diffResult, err := ComputeDiff(localFile, dotfilesFile)
+	if err != nil {
+		t.Fatalf("ComputeDiff failed: %v", err)
+	}
+	result := NewMergeResult(diffResult, localFile, dotfilesFile)
+	if result.TotalHunks != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", result.TotalHunks)
+	}
+	result.ResolveHunk(0, ResolutionUseDotfiles)
+
+	content, err := result.ContentWithMarkers()
+	if err != nil {
+		t.Fatalf("ContentWithMarkers failed: %v", err)
+	}
+	want := "a\nB\nc\nd\ne\nf\ng\nh\n" + MarkerLocal + "\ni\n" + MarkerSep + "\nI\n" + MarkerDotfiles + "\nj"
+	if content != want {
+		t.Errorf("ContentWithMarkers = %q, want %q", content, want)
+	}
+}
+
 func TestWriteMergedFile(t *testing.T) {
 	tempDir := t.TempDir()
 	localFile := filepath.Join(tempDir, "local.txt")
@@ -462,3 +524,37 @@ func TestGenerateMergedContent_MultipleHunks(t *testing.T) {
 		t.Error("Merged content should not be empty")
 	}
 }
+
+func TestWriteCherryPick(t *testing.T) {
+	tempDir := t.TempDir()
+	localFile := filepath.Join(tempDir, "local.txt")
+	dotfilesFile := filepath.Join(tempDir, "dotfiles.txt")
+	local := "export EDITOR=nvim\nb\nc\nd\ne\nf\ng\nh\nexport PATH=/opt/work/bin\nj"
+	os.WriteFile(localFile, []byte(local), 0644)
+	os.WriteFile(dotfilesFile, []byte("export EDITOR=vim\nb\nc\nd\ne\nf\ng\nh\ni\nj"), 0644)
+
+	diffResult, err := ComputeDiff(localFile, dotfilesFile)
+	if err != nil {
+		t.Fatalf("ComputeDiff failed: %v", err)
+	}
+	result := NewMergeResult(diffResult, localFile, dotfilesFile)
+	if result.TotalHunks != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", result.TotalHunks)
+	}
+	// Push the editor change, keep the work PATH entry on this machine
+	result.ResolveHunk(0, ResolutionKeepLocal)
+	if result.PickedHunks() != 1 {
+		t.Errorf("Expected 1 picked hunk, got %d", result.PickedHunks())
+	}
+
+	if err := result.WriteCherryPick(); err != nil {
+		t.Fatalf("WriteCherryPick failed: %v", err)
+	}
+	data, _ := os.ReadFile(dotfilesFile)
+	if want := "export EDITOR=nvim\nb\nc\nd\ne\nf\ng\nh\ni\nj"; string(data) != want {
+		t.Errorf("Dotfiles = %q, want %q", data, want)
+	}
+	if data, _ := os.ReadFile(localFile); string(data) != local {
+		t.Errorf("Expected the local file untouched, got %q", data)
+	}
+}
@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dotsync/internal/config"
+	"dotsync/internal/layout"
+	"dotsync/internal/models"
+	"dotsync/internal/plugin"
+	"dotsync/internal/system"
+	"dotsync/internal/vfs"
+)
+
+// OrphanReason explains why an app directory is considered orphaned
+type OrphanReason int
+
+const (
+	OrphanUnknownApp   OrphanReason = iota // No app definition maps the directory to a local path
+	OrphanNotInstalled                     // The app isn't installed here and no file of it is tracked
+)
+
+// String returns a short description of the reason
+func (r OrphanReason) String() string {
+	switch r {
+	case OrphanUnknownApp:
+		return "no app definition maps it"
+	case OrphanNotInstalled:
+		return "app not installed, nothing tracked"
+	default:
+		return "unknown"
+	}
+}
+
+// Orphan is an app directory in the dotfiles repo that nothing uses anymore
+type Orphan struct {
+	AppID   string
+	Path    string
+	Reason  OrphanReason
+	Files   int
+	Size    int64
+	ModTime time.Time // Newest file in the directory
+}
+
+// reservedDirs are dotfiles directories dotsync uses for itself
+var reservedDirs = map[string]bool{
+	"packages":  true, // Package lists installed by provision
+	ArchivedDir: true, // Apps archived after being uninstalled
+	system.Dir:  true, // Root-owned configs of system mode
+
+	plugin.ExportDirName: true, // Files written by plugin export hooks
+}
+
+// FindOrphans lists the app directories in the dotfiles repo that no
+// definition maps back (unmapped, as returned by the scanner's
+// ScanDotfiles), or whose app isn't installed here and has no tracked file
+// in sync state. Hidden and dotsync's own directories are never orphans,
+// and neither is anything in repos without per-app directories.
+func FindOrphans(dotfilesPath string, unmapped []string, installed []*models.App, stateManager *StateManager) ([]Orphan, error) {
+	if !layout.IsDefault(layout.For(dotfilesPath)) {
+		return nil, nil
+	}
+	entries, err := vfs.ReadDir(dotfilesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	isUnmapped := make(map[string]bool, len(unmapped))
+	for _, id := range unmapped {
+		isUnmapped[id] = true
+	}
+	used := make(map[string]bool)
+	for _, app := range installed {
+		if len(app.Files) > 0 {
+			used[app.ID] = true
+		}
+	}
+	if stateManager != nil {
+		for _, f := range stateManager.Files() {
+			used[f.AppID] = true
+		}
+	}
+
+	var orphans []Orphan
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, ".") || reservedDirs[name] {
+			continue
+		}
+		var reason OrphanReason
+		switch {
+		case isUnmapped[name]:
+			reason = OrphanUnknownApp
+		case !used[name]:
+			reason = OrphanNotInstalled
+		default:
+			continue
+		}
+
+		orphan := Orphan{AppID: name, Path: filepath.Join(dotfilesPath, name), Reason: reason}
+		vfs.Walk(orphan.Path, func(_ string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			orphan.Files++
+			orphan.Size += info.Size()
+			if info.ModTime().After(orphan.ModTime) {
+				orphan.ModTime = info.ModTime()
+			}
+			return nil
+		})
+		orphans = append(orphans, orphan)
+	}
+
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].AppID < orphans[j].AppID })
+	return orphans, nil
+}
+
+// ArchiveOrphan moves an orphaned directory out of the dotfiles repo into
+// the backup directory, drops sync state left for its app and returns
+// where it went
+func ArchiveOrphan(cfg *config.Config, o Orphan, stateManager *StateManager) (string, error) {
+	dest := filepath.Join(cfg.BackupPath, "orphans", time.Now().Format("20060102_150405"), o.AppID)
+	if err := vfs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if err := vfs.Rename(o.Path, dest); err != nil {
+		// Different filesystem: copy, then remove
+		if err := (&Exporter{}).copyDir(o.Path, dest); err != nil {
+			return "", err
+		}
+		if err := vfs.RemoveAll(o.Path); err != nil {
+			return dest, err
+		}
+	}
+	forgetApp(o.AppID, stateManager)
+	return dest, nil
+}
+
+// DeleteOrphan removes an orphaned directory and any sync state left for
+// its app
+func DeleteOrphan(o Orphan, stateManager *StateManager) error {
+	if err := vfs.RemoveAll(o.Path); err != nil {
+		return err
+	}
+	forgetApp(o.AppID, stateManager)
+	return nil
+}
+
+// forgetApp drops the sync state of every file of an app
+func forgetApp(appID string, stateManager *StateManager) {
+	if stateManager == nil {
+		return
+	}
+	for _, f := range stateManager.Files() {
+		if f.AppID == appID {
+			stateManager.RemoveFileState(f.AppID, f.RelPath)
+		}
+	}
+}
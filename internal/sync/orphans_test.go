@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/config"
+	"dotsync/internal/models"
+)
+
+func TestFindOrphans(t *testing.T) {
+	dotfiles := t.TempDir()
+	for _, dir := range []string{"zsh", "oldapp", "retired", "tracked", ".git", ".dotsync", "packages"} {
+		os.MkdirAll(filepath.Join(dotfiles, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(dotfiles, "oldapp", "config"), []byte("12345"), 0644)
+	os.WriteFile(filepath.Join(dotfiles, "README.md"), nil, 0644)
+
+	installed := []*models.App{
+		{ID: "zsh", Files: []models.File{{RelPath: ".zshrc"}}},
+		{ID: "retired"}, // Detected but without any config files
+	}
+	sm := NewStateManager(t.TempDir())
+	sm.SetFileState("tracked", "config", "h", "h")
+
+	orphans, err := FindOrphans(dotfiles, []string{"oldapp"}, installed, sm)
+	if err != nil {
+		t.Fatalf("FindOrphans failed: %v", err)
+	}
+	if len(orphans) != 2 {
+		t.Fatalf("Expected oldapp and retired, got %+v", orphans)
+	}
+	if o := orphans[0]; o.AppID != "oldapp" || o.Reason != OrphanUnknownApp || o.Files != 1 || o.Size != 5 {
+		t.Errorf("Unexpected orphan %+v", o)
+	}
+	if o := orphans[1]; o.AppID != "retired" || o.Reason != OrphanNotInstalled {
+		t.Errorf("Unexpected orphan %+v", o)
+	}
+}
+
+func TestArchiveAndDeleteOrphan(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+	for _, app := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(cfg.DotfilesPath, app), 0755)
+		os.WriteFile(filepath.Join(cfg.DotfilesPath, app, "conf"), []byte(app), 0644)
+	}
+	sm := NewStateManager(tempDir)
+	sm.SetFileState("a", "conf", "h", "h")
+
+	dest, err := ArchiveOrphan(cfg, Orphan{AppID: "a", Path: filepath.Join(cfg.DotfilesPath, "a")}, sm)
+	if err != nil {
+		t.Fatalf("ArchiveOrphan failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "conf")); err != nil || string(data) != "a" {
+		t.Errorf("Expected the archived copy in %s: %v", dest, err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DotfilesPath, "a")); !os.IsNotExist(err) {
+		t.Error("Expected the directory to leave the dotfiles repo")
+	}
+	if _, ok := sm.GetFileState("a", "conf"); ok {
+		t.Error("Expected the app's sync state to be dropped")
+	}
+
+	if err := DeleteOrphan(Orphan{AppID: "b", Path: filepath.Join(cfg.DotfilesPath, "b")}, sm); err != nil {
+		t.Fatalf("DeleteOrphan failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DotfilesPath, "b")); !os.IsNotExist(err) {
+		t.Error("Expected the directory to be deleted")
+	}
+}
@@ -0,0 +1,202 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"dotsync/internal/vfs"
+	"dotsync/internal/xattr"
+)
+
+// Default modes that aren't recorded in the permissions manifest
+const (
+	defaultFileMode os.FileMode = 0644
+	defaultDirMode  os.FileMode = 0755
+)
+
+// PermsPath is where the permissions manifest lives in the dotfiles repo
+func PermsPath(dotfilesPath string) string {
+	return filepath.Join(dotfilesPath, ".dotsync", "permissions.json")
+}
+
+// Perms is the permissions manifest: the modes of exported files and
+// directories that differ from 0644/0755, e.g. 0600 credentials or 0700
+// ~/.ssh, and extended attributes under the preserve xattr policy. Git
+// keeps neither, so pull restores them from here. Owners aren't recorded
+// since user IDs differ between machines; pulled files belong to the user
+// running dotsync.
+type Perms struct {
+	Modes  map[string]string            `json:"modes"`            // "app/rel/path" -> octal mode, e.g. "0600"
+	Xattrs map[string]map[string][]byte `json:"xattrs,omitempty"` // "app/rel/path" -> attribute -> value
+
+	path    string
+	changed bool
+}
+
+// LoadPerms loads the manifest of a dotfiles repo; a missing file is an
+// empty manifest
+func LoadPerms(dotfilesPath string) (*Perms, error) {
+	p := &Perms{Modes: make(map[string]string), path: PermsPath(dotfilesPath)}
+	data, err := vfs.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return p, fmt.Errorf("parse %s: %w", p.path, err)
+	}
+	if p.Modes == nil {
+		p.Modes = make(map[string]string)
+	}
+	return p, nil
+}
+
+// Save writes the manifest if it changed since it was loaded
+func (p *Perms) Save() error {
+	if !p.changed {
+		return nil
+	}
+	if err := vfs.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := vfs.WriteFile(p.path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	p.changed = false
+	return nil
+}
+
+func permsKey(appID, relPath string) string {
+	return appID + "/" + filepath.ToSlash(relPath)
+}
+
+// Record stores the mode of an app's file or directory, dropping the entry
+// when the mode is the default
+func (p *Perms) Record(appID, relPath string, mode os.FileMode) {
+	key := permsKey(appID, relPath)
+	def := defaultFileMode
+	if mode.IsDir() {
+		def = defaultDirMode
+	}
+	if mode.Perm() == def {
+		if _, ok := p.Modes[key]; ok {
+			delete(p.Modes, key)
+			p.changed = true
+		}
+		return
+	}
+	value := fmt.Sprintf("%04o", mode.Perm())
+	if p.Modes[key] != value {
+		p.Modes[key] = value
+		p.changed = true
+	}
+}
+
+// RecordXattrs stores the extended attributes of an app's file, dropping
+// the entry when there are none
+func (p *Perms) RecordXattrs(appID, relPath string, attrs map[string][]byte) {
+	key := permsKey(appID, relPath)
+	if len(attrs) == 0 {
+		if _, ok := p.Xattrs[key]; ok {
+			delete(p.Xattrs, key)
+			p.changed = true
+		}
+		return
+	}
+	if reflect.DeepEqual(p.Xattrs[key], attrs) {
+		return
+	}
+	if p.Xattrs == nil {
+		p.Xattrs = make(map[string]map[string][]byte)
+	}
+	p.Xattrs[key] = attrs
+	p.changed = true
+}
+
+// Forget drops the entries of relPath and everything under it, before a
+// directory is exported again
+func (p *Perms) Forget(appID, relPath string) {
+	key := permsKey(appID, relPath)
+	for k := range p.Modes {
+		if k == key || strings.HasPrefix(k, key+"/") {
+			delete(p.Modes, k)
+			p.changed = true
+		}
+	}
+	for k := range p.Xattrs {
+		if k == key || strings.HasPrefix(k, key+"/") {
+			delete(p.Xattrs, k)
+			p.changed = true
+		}
+	}
+}
+
+// Mode returns the recorded mode of an app's file or directory
+func (p *Perms) Mode(appID, relPath string) (os.FileMode, bool) {
+	value, ok := p.Modes[permsKey(appID, relPath)]
+	if !ok {
+		return 0, false
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil {
+		return 0, false
+	}
+	return os.FileMode(mode).Perm(), true
+}
+
+// Apply sets the recorded modes on path, the local copy of relPath, and on
+// everything under it. Entries without a recorded mode are left alone.
+// Noisy extended attributes are stripped unless the policy is off, and
+// recorded ones restored under the preserve policy.
+func (p *Perms) Apply(appID, relPath, path string, policy xattr.Policy) error {
+	if len(p.Modes) == 0 && policy == xattr.PolicyOff {
+		return nil
+	}
+	return vfs.Walk(path, func(current string, info os.FileInfo, err error) error {
+		if err != nil || info.Mode()&os.ModeSymlink != 0 {
+			return err
+		}
+		rel, err := filepath.Rel(path, current)
+		if err != nil {
+			return err
+		}
+		rel = filepath.Join(relPath, rel)
+		if mode, ok := p.Mode(appID, rel); ok && info.Mode().Perm() != mode {
+			if err := vfs.Chmod(current, mode); err != nil {
+				return err
+			}
+		}
+		return p.applyXattrs(appID, rel, current, policy)
+	})
+}
+
+// applyXattrs strips noisy attributes from a pulled file and restores the
+// recorded ones
+func (p *Perms) applyXattrs(appID, relPath, path string, policy xattr.Policy) error {
+	if policy == xattr.PolicyOff {
+		return nil
+	}
+	if err := xattr.Strip(path); err != nil {
+		return err
+	}
+	if policy != xattr.PolicyPreserve {
+		return nil
+	}
+	for name, value := range p.Xattrs[permsKey(appID, relPath)] {
+		if err := xattr.Set(path, name, value); err != nil {
+			return fmt.Errorf("restore %s on %s: %w", name, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/config"
+	"dotsync/internal/models"
+	"dotsync/internal/xattr"
+)
+
+func TestPermsRecordAndSave(t *testing.T) {
+	dotfiles := t.TempDir()
+	perms, err := LoadPerms(dotfiles)
+	if err != nil {
+		t.Fatalf("LoadPerms failed: %v", err)
+	}
+
+	perms.Record("ssh", ".ssh", os.ModeDir|0700)
+	perms.Record("ssh", ".ssh/id_ed25519", 0600)
+	perms.Record("ssh", ".ssh/known_hosts", 0644)
+	perms.Record("scripts", "bin/run", 0755)
+	if len(perms.Modes) != 3 {
+		t.Errorf("Expected default file modes to be left out, got %v", perms.Modes)
+	}
+	if err := perms.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadPerms(dotfiles)
+	if err != nil {
+		t.Fatalf("LoadPerms failed: %v", err)
+	}
+	if mode, ok := loaded.Mode("ssh", ".ssh/id_ed25519"); !ok || mode != 0600 {
+		t.Errorf("Expected 0600, got %o (%v)", mode, ok)
+	}
+
+	loaded.Record("ssh", ".ssh/id_ed25519", 0644)
+	loaded.Forget("scripts", "bin")
+	if len(loaded.Modes) != 1 || !loaded.changed {
+		t.Errorf("Expected only the .ssh dir left, got %v", loaded.Modes)
+	}
+}
+
+func TestExportImport_PreservesModes(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+
+	local := filepath.Join(tempDir, "home", ".aws")
+	os.MkdirAll(local, 0700)
+	os.Chmod(local, 0700)
+	os.WriteFile(filepath.Join(local, "credentials"), []byte("secret"), 0600)
+	os.WriteFile(filepath.Join(local, "config"), []byte("region"), 0644)
+
+	app := &models.App{ID: "aws", Selected: true, Files: []models.File{
+		{Name: ".aws", Path: local, RelPath: ".aws", IsDir: true, Selected: true},
+	}}
+	if _, err := NewExporter(cfg).ExportAll([]*models.App{app}); err != nil {
+		t.Fatalf("ExportAll failed: %v", err)
+	}
+
+	// A fresh clone doesn't keep the modes
+	exported := filepath.Join(cfg.DotfilesPath, "aws", ".aws")
+	os.Chmod(filepath.Join(exported, "credentials"), 0644)
+	os.Chmod(exported, 0755)
+	os.RemoveAll(local)
+
+	results, err := NewImporter(cfg).ImportAll([]*models.App{app})
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("ImportAll failed: %v %+v", err, results)
+	}
+	for path, want := range map[string]os.FileMode{
+		local:                               0700,
+		filepath.Join(local, "credentials"): 0600,
+		filepath.Join(local, "config"):      0644,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s: expected %o, got %o", filepath.Base(path), want, info.Mode().Perm())
+		}
+	}
+}
+
+func TestExportImport_Xattrs(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	cfg.BackupPath = filepath.Join(tempDir, "backup")
+	cfg.Xattrs = string(xattr.PolicyPreserve)
+
+	local := filepath.Join(tempDir, "home", ".tool.sh")
+	os.MkdirAll(filepath.Dir(local), 0755)
+	os.WriteFile(local, []byte("echo hi"), 0755)
+	if err := xattr.Set(local, "user.dotsync.tag", []byte("keep")); err != nil {
+		t.Skipf("No user xattrs on this filesystem: %v", err)
+	}
+
+	app := &models.App{ID: "tool", Selected: true, Files: []models.File{
+		{Name: ".tool.sh", Path: local, RelPath: ".tool.sh", Selected: true},
+	}}
+	if _, err := NewExporter(cfg).ExportAll([]*models.App{app}); err != nil {
+		t.Fatalf("ExportAll failed: %v", err)
+	}
+	perms, err := LoadPerms(cfg.DotfilesPath)
+	if err != nil {
+		t.Fatalf("LoadPerms failed: %v", err)
+	}
+	if string(perms.Xattrs["tool/.tool.sh"]["user.dotsync.tag"]) != "keep" {
+		t.Errorf("Expected the attribute in the manifest, got %v", perms.Xattrs)
+	}
+
+	os.Remove(local)
+	results, err := NewImporter(cfg).ImportAll([]*models.App{app})
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("ImportAll failed: %v %+v", err, results)
+	}
+	attrs, err := xattr.Kept(local)
+	if err != nil {
+		t.Fatalf("Kept failed: %v", err)
+	}
+	if string(attrs["user.dotsync.tag"]) != "keep" {
+		t.Errorf("Expected the attribute restored, got %v", attrs)
+	}
+}
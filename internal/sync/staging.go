@@ -0,0 +1,260 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"dotsync/internal/lock"
+	"dotsync/internal/symlink"
+	"dotsync/internal/vfs"
+)
+
+// StagingDir is the directory in the backup path where pulls stage files
+const StagingDir = ".staging"
+
+// ErrRolledBack marks files a pull staged but did not move into place
+// because another file of the pull failed
+var ErrRolledBack = errors.New("rolled back")
+
+// journalFile records the moves of a commit inside its staging root
+const journalFile = "journal.json"
+
+// staging holds the files of one pull until all of them are ready, then
+// moves each into place with a rename. Every move is journaled first, so
+// a failed commit, or one cut short by a crash, can be rolled back.
+type staging struct {
+	root    string
+	entries []journalEntry
+}
+
+// journalEntry is one file's move into place
+type journalEntry struct {
+	Dest   string `json:"dest"`
+	Staged string `json:"staged"`
+	Old    string `json:"old,omitempty"` // Where the file it replaces was moved
+	Done   bool   `json:"done"`          // Moved into place
+}
+
+// newStaging creates a staging root in dir, named after this process so a
+// later pull can tell whether it was abandoned
+func newStaging(dir string) (*staging, error) {
+	root := filepath.Join(dir, fmt.Sprintf("%d-%d", time.Now().UnixNano(), os.Getpid()))
+	if err := vfs.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+	return &staging{root: root}, nil
+}
+
+// add returns the path to build dest's pulled copy at. With seed, what
+// dest holds now is copied there first, for pulls that merge into it.
+func (s *staging) add(dest string, seed bool) (string, error) {
+	staged := filepath.Join(s.root, "files", strconv.Itoa(len(s.entries)), filepath.Base(dest))
+	if err := vfs.MkdirAll(filepath.Dir(staged), 0700); err != nil {
+		return "", err
+	}
+	if _, err := vfs.Lstat(dest); err == nil && seed {
+		if err := copyAll(dest, staged); err != nil {
+			return "", err
+		}
+	}
+	s.entries = append(s.entries, journalEntry{Dest: dest, Staged: staged})
+	return staged, nil
+}
+
+// validate checks that every staged file is there before anything moves
+func (s *staging) validate() error {
+	for _, e := range s.entries {
+		if _, err := vfs.Lstat(e.Staged); err != nil {
+			return fmt.Errorf("nothing staged for %s: %w", e.Dest, err)
+		}
+	}
+	return nil
+}
+
+// commit moves every staged file into place, the file it replaces aside
+// first, and rolls all of them back if one fails
+func (s *staging) commit() error {
+	if len(s.entries) == 0 {
+		return nil
+	}
+	err := s.validate()
+	if err == nil {
+		err = s.save()
+	}
+	for idx := range s.entries {
+		if err != nil {
+			break
+		}
+		err = s.move(idx)
+	}
+	if err != nil {
+		if rollbackErr := s.rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w; rolling back: %w", err, rollbackErr)
+		}
+	}
+	return err
+}
+
+// move puts one staged file in place
+func (s *staging) move(idx int) error {
+	e := &s.entries[idx]
+	if _, err := vfs.Lstat(e.Dest); err == nil {
+		e.Old = filepath.Join(s.root, "old", strconv.Itoa(idx), filepath.Base(e.Dest))
+		if err := s.save(); err != nil {
+			return err
+		}
+		if err := vfs.MkdirAll(filepath.Dir(e.Old), 0700); err != nil {
+			return err
+		}
+		if err := movePath(e.Dest, e.Old); err != nil {
+			return err
+		}
+	}
+	if err := movePath(e.Staged, e.Dest); err != nil {
+		return err
+	}
+	e.Done = true
+	return s.save()
+}
+
+// rollback undoes the moves, newest first
+func (s *staging) rollback() error {
+	var errs []error
+	for idx := len(s.entries) - 1; idx >= 0; idx-- {
+		if err := s.entries[idx].undo(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// undo puts back what dest held before. A crash can stop a move between
+// any two steps, so each one is checked on disk.
+func (e journalEntry) undo() error {
+	_, stagedErr := vfs.Lstat(e.Staged)
+	_, destErr := vfs.Lstat(e.Dest)
+	if e.Done || (os.IsNotExist(stagedErr) && destErr == nil) {
+		if err := vfs.RemoveAll(e.Dest); err != nil {
+			return err
+		}
+	}
+	if e.Old == "" {
+		return nil
+	}
+	if _, err := vfs.Lstat(e.Old); err != nil {
+		return nil // Never moved aside
+	}
+	return movePath(e.Old, e.Dest)
+}
+
+// save writes the journal
+func (s *staging) save() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return vfs.WriteFile(filepath.Join(s.root, journalFile), data, 0600)
+}
+
+// cleanup removes the staging root
+func (s *staging) cleanup() error {
+	return vfs.RemoveAll(s.root)
+}
+
+// RecoverStaging rolls back the pulls staged in dir whose process died
+// mid-commit and removes what they staged. Roots of running pulls are left
+// alone.
+func RecoverStaging(dir string) error {
+	roots, err := vfs.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, d := range roots {
+		root := filepath.Join(dir, d.Name())
+		_, pidText, _ := strings.Cut(d.Name(), "-")
+		pid, _ := strconv.Atoi(pidText)
+		info, err := d.Info()
+		if err != nil || !lock.Stale(lock.Holder{PID: pid, Started: info.ModTime()}) {
+			continue
+		}
+		s := &staging{root: root}
+		if data, err := vfs.ReadFile(filepath.Join(root, journalFile)); err == nil {
+			if err := json.Unmarshal(data, &s.entries); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", root, err))
+				continue
+			}
+			if err := s.rollback(); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if err := s.cleanup(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// movePath renames src to dst. Across filesystems it copies next to dst
+// first, so dst still changes with a single rename.
+func movePath(src, dst string) error {
+	err := vfs.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	tmp := dst + ".dotsync-tmp"
+	if err := vfs.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := copyAll(src, tmp); err != nil {
+		vfs.RemoveAll(tmp)
+		return err
+	}
+	if err := vfs.Rename(tmp, dst); err != nil {
+		vfs.RemoveAll(tmp)
+		return err
+	}
+	return vfs.RemoveAll(src)
+}
+
+// copyAll copies a file, link or directory tree as is, skipping nothing
+func copyAll(src, dst string) error {
+	return vfs.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, _ := symlink.Target(path)
+			return symlink.Create(link, target)
+		case d.IsDir():
+			return vfs.MkdirAll(target, info.Mode().Perm())
+		}
+		data, err := vfs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return vfs.WriteFile(target, data, info.Mode().Perm())
+	})
+}
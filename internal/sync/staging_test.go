@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/config"
+	"dotsync/internal/models"
+)
+
+func TestStaging_Commit(t *testing.T) {
+	tempDir := t.TempDir()
+	existing := filepath.Join(tempDir, "local", "a.conf")
+	fresh := filepath.Join(tempDir, "local", "b.conf")
+	os.MkdirAll(filepath.Dir(existing), 0755)
+	os.WriteFile(existing, []byte("old"), 0644)
+
+	s, err := newStaging(filepath.Join(tempDir, "staging"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.cleanup()
+
+	// Seeding copies what is there now
+	out, _ := s.add(existing, true)
+	if data, _ := os.ReadFile(out); string(data) != "old" {
+		t.Errorf("Expected the staged copy seeded, got %q", data)
+	}
+	os.WriteFile(out, []byte("new a"), 0644)
+	out, _ = s.add(fresh, true)
+	os.WriteFile(out, []byte("new b"), 0644)
+
+	if err := s.commit(); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{existing: "new a", fresh: "new b"} {
+		if data, _ := os.ReadFile(path); string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+		}
+	}
+}
+
+func TestStaging_RollbackOnFailedMove(t *testing.T) {
+	tempDir := t.TempDir()
+	first := filepath.Join(tempDir, "local", "a.conf")
+	os.MkdirAll(filepath.Dir(first), 0755)
+	os.WriteFile(first, []byte("old"), 0644)
+
+	s, _ := newStaging(filepath.Join(tempDir, "staging"))
+	defer s.cleanup()
+	out, _ := s.add(first, false)
+	os.WriteFile(out, []byte("new"), 0644)
+
+	// The second file's parent is a file, so it cannot move in
+	blocker := filepath.Join(tempDir, "blocker")
+	os.WriteFile(blocker, []byte("x"), 0644)
+	out, _ = s.add(filepath.Join(blocker, "b.conf"), false)
+	os.WriteFile(out, []byte("new"), 0644)
+
+	if err := s.commit(); err == nil {
+		t.Fatal("Expected the commit to fail")
+	}
+	if data, _ := os.ReadFile(first); string(data) != "old" {
+		t.Errorf("Expected the first file rolled back, got %q", data)
+	}
+}
+
+func TestRecoverStaging(t *testing.T) {
+	tempDir := t.TempDir()
+	dir := filepath.Join(tempDir, "staging")
+	dest := filepath.Join(tempDir, "a.conf")
+	os.WriteFile(dest, []byte("pulled"), 0644)
+
+	// A pull that died after moving the old file aside and the new one in
+	root := filepath.Join(dir, "1-999999999")
+	old := filepath.Join(root, "old", "0", "a.conf")
+	os.MkdirAll(filepath.Dir(old), 0700)
+	os.WriteFile(old, []byte("old"), 0644)
+	data, _ := json.Marshal([]journalEntry{{Dest: dest, Staged: filepath.Join(root, "files", "0", "a.conf"), Old: old, Done: true}})
+	os.WriteFile(filepath.Join(root, journalFile), data, 0600)
+
+	// and one still running
+	running, _ := newStaging(dir)
+
+	if err := RecoverStaging(dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "old" {
+		t.Errorf("Expected the interrupted pull rolled back, got %q", data)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Error("Expected the abandoned staging root removed")
+	}
+	if _, err := os.Stat(running.root); err != nil {
+		t.Errorf("Expected the running pull left alone: %v", err)
+	}
+}
+
+func TestImportApp_RollsBackOnFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	appDir := filepath.Join(dotfilesDir, "testapp")
+	os.MkdirAll(appDir, 0755)
+	os.WriteFile(filepath.Join(appDir, "a.conf"), []byte("new"), 0644)
+	os.WriteFile(filepath.Join(appDir, "b.conf"), []byte("new"), 0644)
+
+	localDir := filepath.Join(tempDir, "local")
+	os.MkdirAll(localDir, 0755)
+	localA := filepath.Join(localDir, "a.conf")
+	os.WriteFile(localA, []byte("old"), 0644)
+	// b.conf goes under a file, so it cannot be written
+	blocker := filepath.Join(tempDir, "blocker")
+	os.WriteFile(blocker, []byte("x"), 0644)
+
+	cfg := config.Default()
+	cfg.DotfilesPath = dotfilesDir
+	cfg.BackupPath = filepath.Join(tempDir, "backups")
+
+	app := &models.App{
+		ID: "testapp",
+		Files: []models.File{
+			{Name: "a.conf", Path: localA, RelPath: "a.conf", Selected: true},
+			{Name: "b.conf", Path: filepath.Join(blocker, "b.conf"), RelPath: "b.conf", Selected: true},
+		},
+	}
+	results, err := NewImporter(cfg).ImportApp(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[1].Error == nil {
+		t.Fatalf("Expected b.conf to fail, got %+v", results)
+	}
+	if results[0].Success || !errors.Is(results[0].Error, ErrRolledBack) {
+		t.Errorf("Expected a.conf rolled back, got %v", results[0].Error)
+	}
+	if data, _ := os.ReadFile(localA); string(data) != "old" {
+		t.Errorf("Expected a.conf untouched, got %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(cfg.BackupPath, StagingDir)); len(entries) != 0 {
+		t.Errorf("Expected the staging dir cleaned up, got %d entries", len(entries))
+	}
+}
@@ -2,15 +2,20 @@ package sync
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"dotsync/internal/models"
+	"dotsync/internal/vfs"
 )
 
 // SyncState tracks the state of synced files for conflict detection
 type SyncState struct {
+	Version  int                  `json:"version"`
+	Checksum string               `json:"checksum,omitempty"` // SHA-256 of the state with this field empty
 	LastSync time.Time            `json:"last_sync"`
 	Files    map[string]FileState `json:"files"`
 }
@@ -22,11 +27,24 @@ type FileState struct {
 	LocalHash    string    `json:"local_hash"`
 	DotfilesHash string    `json:"dotfiles_hash"`
 	SyncedAt     time.Time `json:"synced_at"`
+	Partial      bool      `json:"partial,omitempty"` // Some local hunks were held back from dotfiles
+	PushedAt     time.Time `json:"pushed_at,omitzero"`
+	PulledAt     time.Time `json:"pulled_at,omitzero"`
 }
 
-// StateManager handles loading and saving sync state
+// LastSynced returns when the file was last pushed or pulled, zero if never
+func (f FileState) LastSynced() time.Time {
+	if f.PulledAt.After(f.PushedAt) {
+		return f.PulledAt
+	}
+	return f.PushedAt
+}
+
+// StateManager handles loading and saving sync state. It is safe for
+// concurrent use: background rehashes read it while the UI records syncs.
 type StateManager struct {
 	statePath string
+	mu        sync.RWMutex
 	state     *SyncState
 }
 
@@ -41,59 +59,194 @@ func NewStateManager(configDir string) *StateManager {
 	}
 }
 
-// Load loads the sync state from disk
+// Load loads the sync state from disk, migrating older schema versions.
+// A corrupted file is moved aside and the last good backup restored; the
+// returned *CorruptStateError says what happened.
 func (s *StateManager) Load() error {
-	data, err := os.ReadFile(s.statePath)
+	state, err := readState(s.statePath)
 	if os.IsNotExist(err) {
-		// No state file yet - that's OK
+		// A crash between the backup and the new file can leave only the backup
+		if backup, err := readState(s.backupPath()); err == nil {
+			s.setState(backup)
+		}
 		return nil
 	}
-	if err != nil {
+	if err == nil {
+		s.setState(state)
+		return nil
+	}
+	if !errors.Is(err, ErrStateCorrupt) {
 		return err
 	}
 
-	return json.Unmarshal(data, s.state)
+	corrupt := &CorruptStateError{
+		Path:    s.statePath,
+		SavedAs: s.statePath + ".corrupt-" + time.Now().Format("20060102-150405"),
+		Err:     err,
+	}
+	if renameErr := vfs.Rename(s.statePath, corrupt.SavedAs); renameErr != nil {
+		corrupt.SavedAs = ""
+	}
+	if backup, backupErr := readState(s.backupPath()); backupErr == nil {
+		s.setState(backup)
+		corrupt.Restored = true
+		if saveErr := s.Save(); saveErr != nil {
+			return saveErr
+		}
+	}
+	return corrupt
 }
 
-// Save saves the sync state to disk
+// Save saves the sync state to disk. The file is replaced atomically and
+// the previous good file is kept as a backup.
 func (s *StateManager) Save() error {
 	// Ensure directory exists
 	dir := filepath.Dir(s.statePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := vfs.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
+	s.mu.Lock()
+	s.state.Version = StateVersion
+	s.state.Checksum = s.state.checksum()
 	data, err := json.MarshalIndent(s.state, "", "  ")
+	s.mu.Unlock()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.statePath, data, 0644)
+	if previous, err := vfs.ReadFile(s.statePath); err == nil {
+		if _, err := decodeState(previous); err == nil {
+			if err := writeFileAtomic(s.backupPath(), previous, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return writeFileAtomic(s.statePath, data, 0644)
+}
+
+// setState replaces the whole state, as after a load
+func (s *StateManager) setState(state *SyncState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+}
+
+// backupPath is where the last good state file is kept
+func (s *StateManager) backupPath() string {
+	return s.statePath + ".bak"
 }
 
 // GetFileState returns the state for a specific file
 func (s *StateManager) GetFileState(appID, relPath string) (FileState, bool) {
 	key := appID + "/" + relPath
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	state, ok := s.state.Files[key]
 	return state, ok
 }
 
-// SetFileState updates the state for a specific file
+// SetFileState updates the state for a specific file, keeping its push
+// and pull times
 func (s *StateManager) SetFileState(appID, relPath, localHash, dotfilesHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setFileState(appID, relPath, localHash, dotfilesHash)
+}
+
+// setFileState is SetFileState for callers already holding the lock
+func (s *StateManager) setFileState(appID, relPath, localHash, dotfilesHash string) {
 	key := appID + "/" + relPath
+	previous := s.state.Files[key]
 	s.state.Files[key] = FileState{
 		AppID:        appID,
 		RelPath:      relPath,
 		LocalHash:    localHash,
 		DotfilesHash: dotfilesHash,
 		SyncedAt:     time.Now(),
+		PushedAt:     previous.PushedAt,
+		PulledAt:     previous.PulledAt,
 	}
 	s.state.LastSync = time.Now()
 }
 
+// RecordPush stamps a file whose state was just set as pushed now
+func (s *StateManager) RecordPush(appID, relPath string) {
+	key := appID + "/" + relPath
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.state.Files[key]; ok {
+		state.PushedAt = time.Now()
+		s.state.Files[key] = state
+	}
+}
+
+// RecordPull stamps a file whose state was just set as pulled now
+func (s *StateManager) RecordPull(appID, relPath string) {
+	key := appID + "/" + relPath
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.state.Files[key]; ok {
+		state.PulledAt = time.Now()
+		s.state.Files[key] = state
+	}
+}
+
+// LastSyncedByApp returns the last push or pull of any file of each app
+func (s *StateManager) LastSyncedByApp() map[string]time.Time {
+	times := make(map[string]time.Time)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.state.Files {
+		if t := f.LastSynced(); t.After(times[f.AppID]) {
+			times[f.AppID] = t
+		}
+	}
+	return times
+}
+
+// AppSyncTimes returns the latest push and pull of any of an app's files
+func (s *StateManager) AppSyncTimes(appID string) (pushed, pulled time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.state.Files {
+		if f.AppID != appID {
+			continue
+		}
+		if f.PushedAt.After(pushed) {
+			pushed = f.PushedAt
+		}
+		if f.PulledAt.After(pulled) {
+			pulled = f.PulledAt
+		}
+	}
+	return pushed, pulled
+}
+
+// SetPartialFileState records a cherry-picked push: the dotfiles got only
+// some of the local hunks, so the two hashes differ on purpose
+func (s *StateManager) SetPartialFileState(appID, relPath, localHash, dotfilesHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setFileState(appID, relPath, localHash, dotfilesHash)
+	key := appID + "/" + relPath
+	state := s.state.Files[key]
+	state.Partial = true
+	s.state.Files[key] = state
+}
+
+// IsPartial reports whether a file was last synced by a cherry-pick that
+// kept some hunks local-only
+func (s *StateManager) IsPartial(appID, relPath string) bool {
+	state, ok := s.GetFileState(appID, relPath)
+	return ok && state.Partial
+}
+
 // RemoveFileState removes the state for a file
 func (s *StateManager) RemoveFileState(appID, relPath string) {
 	key := appID + "/" + relPath
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	delete(s.state.Files, key)
 }
 
@@ -131,6 +284,12 @@ func (s *StateManager) DetectConflict(appID, relPath, currentLocalHash, currentD
 		return models.ConflictDotfilesDeleted
 	}
 
+	// A plain push or pull of a partially synced file would overwrite the
+	// held-back hunks, so any change to it goes back to the merge screen
+	if savedState.Partial && (localChanged || dotfilesChanged) && currentLocalHash != currentDotfilesHash {
+		return models.ConflictBothModified
+	}
+
 	// Check for modifications
 	if localChanged && dotfilesChanged {
 		// Both changed - but are they the same?
@@ -153,12 +312,25 @@ func (s *StateManager) DetectConflict(appID, relPath, currentLocalHash, currentD
 
 // GetLastSync returns the time of last sync
 func (s *StateManager) GetLastSync() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.state.LastSync
 }
 
+// Files returns the recorded state of every synced file
+func (s *StateManager) Files() []FileState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	files := make([]FileState, 0, len(s.state.Files))
+	for _, f := range s.state.Files {
+		files = append(files, f)
+	}
+	return files
+}
+
 // ClearState clears all state (for testing or reset)
 func (s *StateManager) ClearState() {
-	s.state = &SyncState{
+	s.setState(&SyncState{
 		Files: make(map[string]FileState),
-	}
+	})
 }
@@ -1,8 +1,11 @@
 package sync
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"dotsync/internal/models"
@@ -282,3 +285,202 @@ func TestStateManager_Load_InvalidJSON(t *testing.T) {
 		t.Error("Load should return error for invalid JSON")
 	}
 }
+
+func TestStateManager_Load_MigratesLegacy(t *testing.T) {
+	tmpDir := t.TempDir()
+	legacy := `{"last_sync":"2024-01-02T03:04:05Z","files":{"zsh/.zshrc":{"local_hash":"a","dotfiles_hash":"b"}}}`
+	os.WriteFile(filepath.Join(tmpDir, "sync_state.json"), []byte(legacy), 0644)
+
+	sm := NewStateManager(tmpDir)
+	if err := sm.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	state, ok := sm.GetFileState("zsh", ".zshrc")
+	if !ok || state.AppID != "zsh" || state.RelPath != ".zshrc" || state.LocalHash != "a" {
+		t.Errorf("Expected the legacy entry to be migrated, got %+v", state)
+	}
+
+	if err := sm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(tmpDir, "sync_state.json"))
+	if !strings.Contains(string(data), `"version": 3`) || !strings.Contains(string(data), `"checksum"`) {
+		t.Errorf("Expected a versioned, checksummed file, got:\n%s", data)
+	}
+}
+
+func TestStateManager_Load_RestoresBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateFile := filepath.Join(tmpDir, "sync_state.json")
+
+	sm := NewStateManager(tmpDir)
+	sm.SetFileState("git", ".gitconfig", "h1", "h1")
+	sm.Save()
+	sm.SetFileState("git", ".gitconfig", "h2", "h2")
+	sm.Save() // The first save is now the backup
+
+	// Hand-edited content no longer matches the checksum
+	data, _ := os.ReadFile(stateFile)
+	os.WriteFile(stateFile, []byte(strings.Replace(string(data), `"h2"`, `"h3"`, 1)), 0644)
+
+	sm2 := NewStateManager(tmpDir)
+	err := sm2.Load()
+	var corrupt *CorruptStateError
+	if !errors.As(err, &corrupt) || !corrupt.Restored || !errors.Is(err, ErrStateCorrupt) {
+		t.Fatalf("Expected a restored CorruptStateError, got %v", err)
+	}
+	if state, _ := sm2.GetFileState("git", ".gitconfig"); state.LocalHash != "h1" {
+		t.Errorf("Expected the backup state, got %+v", state)
+	}
+	if _, err := os.Stat(corrupt.SavedAs); err != nil {
+		t.Errorf("Expected the damaged file to be kept: %v", err)
+	}
+
+	// The restored state was written back and loads cleanly
+	if err := NewStateManager(tmpDir).Load(); err != nil {
+		t.Errorf("Expected the restored file to load, got %v", err)
+	}
+}
+
+func TestStateManager_Load_OnlyBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStateManager(tmpDir)
+	sm.SetFileState("app", "file", "h", "h")
+	sm.Save()
+	sm.Save()
+	os.Remove(filepath.Join(tmpDir, "sync_state.json"))
+
+	sm2 := NewStateManager(tmpDir)
+	if err := sm2.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, ok := sm2.GetFileState("app", "file"); !ok {
+		t.Error("Expected the state to come from the backup")
+	}
+}
+
+func TestStateManager_Load_NewerVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateFile := filepath.Join(tmpDir, "sync_state.json")
+	os.WriteFile(stateFile, []byte(`{"version": 99, "files": {}}`), 0644)
+
+	err := NewStateManager(tmpDir).Load()
+	if err == nil || errors.Is(err, ErrStateCorrupt) {
+		t.Fatalf("Expected a version error, got %v", err)
+	}
+	if _, statErr := os.Stat(stateFile); statErr != nil {
+		t.Errorf("A newer state file must be left in place: %v", statErr)
+	}
+}
+
+func TestStateManager_Save_Atomic(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStateManager(tmpDir)
+	sm.SetFileState("app", "file", "h", "h")
+	if err := sm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	entries, _ := os.ReadDir(tmpDir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("Temp file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestStateManager_PartialFileState(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+	sm.SetPartialFileState("zsh", ".zshrc", "local1", "dotfiles1")
+	if !sm.IsPartial("zsh", ".zshrc") {
+		t.Fatal("Expected the file to be marked partial")
+	}
+
+	if got := sm.DetectConflict("zsh", ".zshrc", "local1", "dotfiles1"); got != models.ConflictNone {
+		t.Errorf("Expected held-back hunks to count as synced, got %v", got)
+	}
+	if got := sm.DetectConflict("zsh", ".zshrc", "local2", "dotfiles1"); got != models.ConflictBothModified {
+		t.Errorf("Expected a local change to need a merge, got %v", got)
+	}
+	if got := sm.DetectConflict("zsh", ".zshrc", "local1", "dotfiles2"); got != models.ConflictBothModified {
+		t.Errorf("Expected a dotfiles change to need a merge, got %v", got)
+	}
+
+	// A full sync clears the marker
+	sm.SetFileState("zsh", ".zshrc", "same", "same")
+	if sm.IsPartial("zsh", ".zshrc") {
+		t.Error("Expected a full sync to clear the partial marker")
+	}
+}
+
+func TestStateManager_SyncTimes(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStateManager(tmpDir)
+	sm.RecordPush("zsh", ".zshrc") // No state yet: nothing to stamp
+	if _, ok := sm.GetFileState("zsh", ".zshrc"); ok {
+		t.Fatal("RecordPush must not create a state")
+	}
+
+	sm.SetFileState("zsh", ".zshrc", "h1", "h1")
+	sm.RecordPush("zsh", ".zshrc")
+	sm.SetFileState("zsh", ".zprofile", "h2", "h2")
+	sm.RecordPull("zsh", ".zprofile")
+	sm.SetFileState("zsh", ".zshrc", "h3", "h3") // Keeps the push time
+	sm.Save()
+
+	sm2 := NewStateManager(tmpDir)
+	if err := sm2.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	rc, _ := sm2.GetFileState("zsh", ".zshrc")
+	if rc.PushedAt.IsZero() || !rc.PulledAt.IsZero() || !rc.LastSynced().Equal(rc.PushedAt) {
+		t.Errorf("Expected .zshrc pushed only, got %+v", rc)
+	}
+	pushed, pulled := sm2.AppSyncTimes("zsh")
+	if !pushed.Equal(rc.PushedAt) || pulled.IsZero() {
+		t.Errorf("Expected the app's latest push and pull, got %v and %v", pushed, pulled)
+	}
+	if last := sm2.LastSyncedByApp()["zsh"]; !last.Equal(pulled) && !last.Equal(pushed) {
+		t.Errorf("Expected the app's last sync, got %v", last)
+	}
+	if pushed, pulled := sm2.AppSyncTimes("git"); !pushed.IsZero() || !pulled.IsZero() {
+		t.Errorf("Expected no times for an unsynced app, got %v and %v", pushed, pulled)
+	}
+}
+
+func TestStateManager_Load_Version2(t *testing.T) {
+	tmpDir := t.TempDir()
+	state := &SyncState{Version: 2, Files: map[string]FileState{
+		"git/.gitconfig": {AppID: "git", RelPath: ".gitconfig", LocalHash: "h", DotfilesHash: "h"},
+	}}
+	state.Checksum = state.checksum()
+	data, _ := json.Marshal(state)
+	os.WriteFile(filepath.Join(tmpDir, "sync_state.json"), data, 0644)
+
+	sm := NewStateManager(tmpDir)
+	if err := sm.Load(); err != nil {
+		t.Fatalf("Expected a version 2 file to load, got %v", err)
+	}
+	if f, ok := sm.GetFileState("git", ".gitconfig"); !ok || !f.LastSynced().IsZero() {
+		t.Errorf("Expected the entry without sync times, got %+v", f)
+	}
+}
+
+func TestStateManager_ConcurrentUse(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			sm.DetectConflict("app", "config", "a", "b")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		sm.SetFileState("app", "config", "a", "a")
+		sm.RecordPush("app", "config")
+		if err := sm.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dotsync/internal/vfs"
+)
+
+// StateVersion is the schema version of sync_state.json written by Save
+const StateVersion = 3
+
+// stateMigrations upgrade a decoded state one schema version at a time:
+// stateMigrations[v] turns version v into v+1
+var stateMigrations = map[int]func(*SyncState){
+	1: migrateStateV1,
+	2: migrateStateV2,
+}
+
+// ErrStateCorrupt is wrapped by errors for state files that can't be trusted
+var ErrStateCorrupt = errors.New("sync state is corrupt")
+
+// CorruptStateError reports a corrupted state file that Load replaced
+type CorruptStateError struct {
+	Path     string
+	SavedAs  string // Where the damaged file was moved, if it could be
+	Restored bool   // The last good backup was restored
+	Err      error
+}
+
+func (e *CorruptStateError) Error() string {
+	action := "starting with empty sync state"
+	if e.Restored {
+		action = "restored the last good backup"
+	}
+	msg := fmt.Sprintf("%s: %v; %s", e.Path, e.Err, action)
+	if e.SavedAs != "" {
+		msg += " (damaged file kept as " + filepath.Base(e.SavedAs) + ")"
+	}
+	return msg
+}
+
+func (e *CorruptStateError) Unwrap() error {
+	return e.Err
+}
+
+// checksum hashes the state with the checksum field left empty
+func (s *SyncState) checksum() string {
+	unsigned := *s
+	unsigned.Checksum = ""
+	data, _ := json.Marshal(unsigned)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// readState reads and decodes a state file
+func readState(path string) (*SyncState, error) {
+	data, err := vfs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeState(data)
+}
+
+// decodeState validates a state file and migrates it to StateVersion.
+// Files written before versioning (version 0) are treated as version 1.
+func decodeState(data []byte) (*SyncState, error) {
+	var state SyncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateCorrupt, err)
+	}
+	if state.Version > StateVersion {
+		return nil, fmt.Errorf("sync state version %d is newer than this dotsync supports (%d)", state.Version, StateVersion)
+	}
+	if state.Version >= 2 && state.Checksum != state.checksum() {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrStateCorrupt)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]FileState)
+	}
+
+	for v := max(state.Version, 1); v < StateVersion; v++ {
+		stateMigrations[v](&state)
+	}
+	state.Version = StateVersion
+	return &state, nil
+}
+
+// migrateStateV1 fills in the app and path of entries from their
+// "appID/relPath" key; early versions only stored hashes
+func migrateStateV1(state *SyncState) {
+	for key, f := range state.Files {
+		appID, relPath, ok := strings.Cut(key, "/")
+		if !ok {
+			delete(state.Files, key)
+			continue
+		}
+		if f.AppID == "" {
+			f.AppID = appID
+		}
+		if f.RelPath == "" {
+			f.RelPath = relPath
+		}
+		state.Files[key] = f
+	}
+}
+
+// migrateStateV2 has nothing to convert: version 3 added the push and pull
+// times, unknown for older entries. The bump keeps older dotsync from
+// reading the new fields as a checksum mismatch.
+func migrateStateV2(*SyncState) {}
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// into place, so readers never see a partly written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := vfs.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer vfs.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := vfs.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return vfs.Rename(tmp.Name(), path)
+}
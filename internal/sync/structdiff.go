@@ -0,0 +1,285 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dotsync/internal/vfs"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Structured config formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// ChangeKind is how a key differs between two config trees
+type ChangeKind int
+
+const (
+	KeyAdded ChangeKind = iota
+	KeyRemoved
+	KeyChanged
+)
+
+// KeyChange is one key that differs between two parsed configs
+type KeyChange struct {
+	Path string // Dotted key path, e.g. editor.fontSize or plugins[2]
+	Kind ChangeKind
+	Old  string // Old value as JSON; empty when added
+	New  string // New value as JSON; empty when removed
+}
+
+// StructuredDiff compares two configs by their parsed trees, so key order,
+// formatting and comments don't count as changes
+type StructuredDiff struct {
+	Format  string
+	Changes []KeyChange
+}
+
+// StructuredFormat returns the structured format of a file from its
+// extension, or "" for anything else
+func StructuredFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonc", ".code-workspace":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	return ""
+}
+
+// ComputeStructuredDiff compares two structured configs key by key. A
+// missing file counts as empty; an unsupported format or a file that
+// doesn't parse is an error.
+func ComputeStructuredDiff(oldPath, newPath string) (*StructuredDiff, error) {
+	format := StructuredFormat(oldPath)
+	if format == "" {
+		format = StructuredFormat(newPath)
+	}
+	if format == "" {
+		return nil, fmt.Errorf("not a structured config: %s", filepath.Base(oldPath))
+	}
+
+	oldTree, err := readTree(format, oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := readTree(format, newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StructuredDiff{Format: format}
+	compareTrees("", oldTree, newTree, &result.Changes)
+	return result, nil
+}
+
+// readTree parses a config file; a missing file is an empty tree
+func readTree(format, path string) (any, error) {
+	data, err := vfs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tree, err := ParseStructured(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	return tree, nil
+}
+
+// ParseStructured parses JSON (comments and trailing commas allowed), YAML
+// or TOML into maps, slices and scalars
+func ParseStructured(format string, data []byte) (any, error) {
+	var tree any
+	switch format {
+	case FormatJSON:
+		data = stripJSONC(data)
+		if len(bytes.TrimSpace(data)) == 0 {
+			return map[string]any{}, nil
+		}
+		if err := json.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+		if tree == nil {
+			return map[string]any{}, nil
+		}
+	case FormatTOML:
+		return parseTOML(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	return normalizeTree(tree), nil
+}
+
+// normalizeTree turns YAML's map[any]any into map[string]any
+func normalizeTree(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			t[k] = normalizeTree(child)
+		}
+		return t
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, child := range t {
+			m[fmt.Sprint(k)] = normalizeTree(child)
+		}
+		return m
+	case []any:
+		for i, child := range t {
+			t[i] = normalizeTree(child)
+		}
+		return t
+	}
+	return v
+}
+
+// compareTrees appends the differences between old and new under path
+func compareTrees(path string, old, new any, changes *[]KeyChange) {
+	if om, ok := old.(map[string]any); ok {
+		if nm, ok := new.(map[string]any); ok {
+			keys := make([]string, 0, len(om)+len(nm))
+			for k := range om {
+				keys = append(keys, k)
+			}
+			for k := range nm {
+				if _, ok := om[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				child := joinKey(path, k)
+				ov, inOld := om[k]
+				nv, inNew := nm[k]
+				switch {
+				case !inOld:
+					*changes = append(*changes, KeyChange{Path: child, Kind: KeyAdded, New: renderValue(nv)})
+				case !inNew:
+					*changes = append(*changes, KeyChange{Path: child, Kind: KeyRemoved, Old: renderValue(ov)})
+				default:
+					compareTrees(child, ov, nv, changes)
+				}
+			}
+			return
+		}
+	}
+
+	if oa, ok := old.([]any); ok {
+		if na, ok := new.([]any); ok {
+			for i := range max(len(oa), len(na)) {
+				child := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(oa):
+					*changes = append(*changes, KeyChange{Path: child, Kind: KeyAdded, New: renderValue(na[i])})
+				case i >= len(na):
+					*changes = append(*changes, KeyChange{Path: child, Kind: KeyRemoved, Old: renderValue(oa[i])})
+				default:
+					compareTrees(child, oa[i], na[i], changes)
+				}
+			}
+			return
+		}
+	}
+
+	if o, n := renderValue(old), renderValue(new); o != n {
+		*changes = append(*changes, KeyChange{Path: path, Kind: KeyChanged, Old: o, New: n})
+	}
+}
+
+// joinKey appends a key to a dotted path, quoting keys that contain dots
+// or spaces (e.g. VS Code's flat "editor.fontSize" keys)
+func joinKey(path, key string) string {
+	if key == "" || strings.ContainsAny(key, ". []\"") {
+		key = strconv.Quote(key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// renderValue formats a value as compact JSON
+func renderValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// stripJSONC removes // and /* */ comments and trailing commas so JSON with
+// comments (VS Code settings, tsconfig) parses as plain JSON
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Drop a trailing comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && isJSONSpace(out[j]) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,167 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStructuredFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"settings.json", FormatJSON},
+		{"tsconfig.JSONC", FormatJSON},
+		{"config.yml", FormatYAML},
+		{"alacritty.toml", FormatTOML},
+		{".zshrc", ""},
+	}
+
+	for _, tt := range tests {
+		if got := StructuredFormat(tt.path); got != tt.want {
+			t.Errorf("StructuredFormat(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestComputeStructuredDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []KeyChange
+	}{
+		{
+			name: "settings.json",
+			old: `{
+	// Editor
+	"editor.fontSize": 14,
+	"files.exclude": {"**/.git": true,},
+	"workbench.colorTheme": "Dark",
+}`,
+			new: `{"workbench.colorTheme": "Light", "editor.fontSize": 14, "editor.tabSize": 2, "files.exclude": {"**/.git": true}}`,
+			want: []KeyChange{
+				{Path: `"editor.tabSize"`, Kind: KeyAdded, New: "2"},
+				{Path: `"workbench.colorTheme"`, Kind: KeyChanged, Old: `"Dark"`, New: `"Light"`},
+			},
+		},
+		{
+			name: "config.yaml",
+			old:  "b: 1\na:\n  list: [x, y]\n",
+			new:  "a:\n  list: [x]\nb: 1\nc: true\n",
+			want: []KeyChange{
+				{Path: "a.list[1]", Kind: KeyRemoved, Old: `"y"`},
+				{Path: "c", Kind: KeyAdded, New: "true"},
+			},
+		},
+		{
+			name: "config.toml",
+			old:  "[font]\nsize = 12\nfamily = \"Mono\"\n",
+			new:  "# reordered\n[font]\nfamily = 'Mono'\nsize = 13\n",
+			want: []KeyChange{
+				{Path: "font.size", Kind: KeyChanged, Old: "12", New: "13"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		oldPath := filepath.Join(dir, "old-"+tt.name)
+		newPath := filepath.Join(dir, "new-"+tt.name)
+		os.WriteFile(oldPath, []byte(tt.old), 0644)
+		os.WriteFile(newPath, []byte(tt.new), 0644)
+
+		result, err := ComputeStructuredDiff(oldPath, newPath)
+		if err != nil {
+			t.Fatalf("%s: ComputeStructuredDiff failed: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(result.Changes, tt.want) {
+			t.Errorf("%s: changes = %+v, want %+v", tt.name, result.Changes, tt.want)
+		}
+	}
+}
+
+func TestComputeStructuredDiff_Errors(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.json")
+	os.WriteFile(broken, []byte("{"), 0644)
+
+	if _, err := ComputeStructuredDiff(broken, broken); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+	if _, err := ComputeStructuredDiff(filepath.Join(dir, ".bashrc"), filepath.Join(dir, ".bashrc")); err == nil {
+		t.Error("Expected error for an unstructured file")
+	}
+
+	// A missing side is an empty tree
+	ok := filepath.Join(dir, "ok.json")
+	os.WriteFile(ok, []byte(`{"a": 1}`), 0644)
+	result, err := ComputeStructuredDiff(filepath.Join(dir, "missing.json"), ok)
+	if err != nil || len(result.Changes) != 1 || result.Changes[0].Kind != KeyAdded {
+		t.Errorf("Expected one added key, got %+v (%v)", result, err)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	src := `# comment
+title = "dotsync" # trailing
+answer = 4_2
+pi = 3.14
+enabled = true
+date = 1979-05-27T07:32:00Z
+day = 1979-05-27
+path = 'C:\Users'
+multi = """
+line one \
+  continued"""
+nested.key = "dotted"
+colors = [ "red",
+  "green", ] # multi-line
+
+[server]
+inline = { host = "localhost", port = 8080 }
+
+[[plugins]]
+name = "a"
+
+[[plugins]]
+name = "b"
+
+[plugins.opts]
+fast = false
+`
+	got, err := parseTOML(src)
+	if err != nil {
+		t.Fatalf("parseTOML failed: %v", err)
+	}
+
+	want := map[string]any{
+		"title":   "dotsync",
+		"answer":  int64(42),
+		"pi":      3.14,
+		"enabled": true,
+		"date":    "1979-05-27T07:32:00Z",
+		"day":     "1979-05-27",
+		"path":    `C:\Users`,
+		"multi":   "line one continued",
+		"nested":  map[string]any{"key": "dotted"},
+		"colors":  []any{"red", "green"},
+		"server": map[string]any{
+			"inline": map[string]any{"host": "localhost", "port": int64(8080)},
+		},
+		"plugins": []any{
+			map[string]any{"name": "a"},
+			map[string]any{"name": "b", "opts": map[string]any{"fast": false}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML =\n%#v\nwant\n%#v", got, want)
+	}
+
+	for _, bad := range []string{"key", "a = ", "a = 1\na = 2", `s = "open`, "[t\nx = 1"} {
+		if _, err := parseTOML(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML parses a TOML document into maps, slices and scalars, like the
+// JSON and YAML trees. Dates and times are kept as the strings they were
+// written as.
+func parseTOML(src string) (map[string]any, error) {
+	tree := map[string]any{}
+	if _, err := toml.Decode(src, &tree); err != nil {
+		return nil, err
+	}
+	return normalizeTOML(tree).(map[string]any), nil
+}
+
+// tomlLocalTimes are the layouts of the local date and time kinds, which the
+// decoder tells apart by the name of the time's location
+var tomlLocalTimes = map[string]string{
+	"datetime-local": "2006-01-02T15:04:05.999999999",
+	"date-local":     "2006-01-02",
+	"time-local":     "15:04:05.999999999",
+}
+
+// normalizeTOML turns arrays of tables into plain slices and dates and times
+// into strings
+func normalizeTOML(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			t[k] = normalizeTOML(child)
+		}
+		return t
+	case []map[string]any:
+		list := make([]any, len(t))
+		for i, child := range t {
+			list[i] = normalizeTOML(child)
+		}
+		return list
+	case []any:
+		for i, child := range t {
+			t[i] = normalizeTOML(child)
+		}
+		return t
+	case time.Time:
+		if layout, ok := tomlLocalTimes[t.Location().String()]; ok {
+			return t.Format(layout)
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"dotsync/internal/config"
+	"dotsync/internal/layout"
+	"dotsync/internal/models"
+	"dotsync/internal/vfs"
+)
+
+// ArchivedDir is the dotfiles directory archived apps are moved into
+const ArchivedDir = "_archived"
+
+// IsReservedDir reports whether a dotfiles directory belongs to dotsync
+// itself rather than to an app
+func IsReservedDir(name string) bool {
+	return reservedDirs[name]
+}
+
+// FindUninstalled returns the apps that were synced on this machine before
+// but whose configs are gone locally: they have files in the dotfiles repo
+// (dotfilesApps, as returned by the scanner's ScanDotfiles) and sync state
+// with a local hash, but aren't among the installed apps. They come back
+// flagged Uninstalled and unselected so a pull doesn't restore them.
+func FindUninstalled(dotfilesApps, installed []*models.App, stateManager *StateManager) []*models.App {
+	if stateManager == nil {
+		return nil
+	}
+	present := make(map[string]bool, len(installed))
+	for _, app := range installed {
+		present[app.ID] = true
+	}
+	synced := make(map[string]bool)
+	for _, f := range stateManager.Files() {
+		if f.LocalHash != "" {
+			synced[f.AppID] = true
+		}
+	}
+
+	var apps []*models.App
+	for _, app := range dotfilesApps {
+		if present[app.ID] || !synced[app.ID] {
+			continue
+		}
+		app.Uninstalled = true
+		app.Installed = false
+		app.Selected = false
+		for i := range app.Files {
+			app.Files[i].Selected = false
+			app.Files[i].SyncStatus = models.StatusMissing
+			app.Files[i].ConflictType = models.ConflictNone
+		}
+		apps = append(apps, app)
+	}
+	return apps
+}
+
+// ArchiveApp moves an app's dotfiles directory into _archived/ inside the
+// dotfiles repo, drops its sync state and returns where it went. An
+// earlier archive of the same app is kept by adding a timestamp. Layouts
+// without per-app directories are refused: the app's files are mixed in
+// with the others'.
+func ArchiveApp(cfg *config.Config, appID string, stateManager *StateManager) (string, error) {
+	if lay := layout.For(cfg.DotfilesPath); !layout.IsDefault(lay) {
+		return "", fmt.Errorf("archiving needs per-app directories, which the %s layout doesn't have", lay.Name())
+	}
+	src := filepath.Join(cfg.DotfilesPath, appID)
+	dest := filepath.Join(cfg.DotfilesPath, ArchivedDir, appID)
+	if _, err := vfs.Lstat(dest); err == nil {
+		dest += "-" + time.Now().Format("20060102_150405")
+	}
+	if err := vfs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if err := vfs.Rename(src, dest); err != nil {
+		return "", err
+	}
+	forgetApp(appID, stateManager)
+	return dest, nil
+}
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dotsync/internal/config"
+	"dotsync/internal/layout"
+	"dotsync/internal/models"
+)
+
+func TestFindUninstalled(t *testing.T) {
+	dotfilesApps := []*models.App{
+		{ID: "zsh", Selected: true, Files: []models.File{{RelPath: ".zshrc", Selected: true}}},
+		{ID: "kitty", Selected: true, Files: []models.File{{RelPath: "kitty", Selected: true, ConflictType: models.ConflictDotfilesNew}}},
+		{ID: "shared", Selected: true, Files: []models.File{{RelPath: "conf", Selected: true}}},
+	}
+	installed := []*models.App{{ID: "zsh"}}
+	sm := NewStateManager(t.TempDir())
+	sm.SetFileState("zsh", ".zshrc", "h", "h")
+	sm.SetFileState("kitty", "kitty", "h", "h")
+	sm.SetFileState("shared", "conf", "", "h") // Never on this machine
+
+	apps := FindUninstalled(dotfilesApps, installed, sm)
+	if len(apps) != 1 || apps[0].ID != "kitty" {
+		t.Fatalf("Expected only kitty, got %+v", apps)
+	}
+	app := apps[0]
+	if !app.Uninstalled || app.Selected || app.Files[0].Selected || app.Files[0].ConflictType != models.ConflictNone {
+		t.Errorf("Expected kitty flagged and unselected, got %+v", app)
+	}
+
+	if apps := FindUninstalled(dotfilesApps, installed, nil); apps != nil {
+		t.Errorf("Expected nothing without sync state, got %+v", apps)
+	}
+}
+
+func TestArchiveApp(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(tempDir, "dotfiles")
+	os.MkdirAll(filepath.Join(cfg.DotfilesPath, ArchivedDir, "kitty"), 0755)
+	os.MkdirAll(filepath.Join(cfg.DotfilesPath, "kitty"), 0755)
+	os.WriteFile(filepath.Join(cfg.DotfilesPath, "kitty", "kitty.conf"), []byte("font"), 0644)
+	sm := NewStateManager(tempDir)
+	sm.SetFileState("kitty", "kitty.conf", "h", "h")
+
+	dest, err := ArchiveApp(cfg, "kitty", sm)
+	if err != nil {
+		t.Fatalf("ArchiveApp failed: %v", err)
+	}
+	if filepath.Dir(dest) != filepath.Join(cfg.DotfilesPath, ArchivedDir) || filepath.Base(dest) == "kitty" {
+		t.Errorf("Expected a timestamped dir next to the earlier archive, got %s", dest)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "kitty.conf")); err != nil || string(data) != "font" {
+		t.Errorf("Expected the archived copy in %s: %v", dest, err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DotfilesPath, "kitty")); !os.IsNotExist(err) {
+		t.Errorf("Expected kitty removed from dotfiles, got %v", err)
+	}
+	if _, ok := sm.GetFileState("kitty", "kitty.conf"); ok {
+		t.Error("Expected kitty's sync state to be dropped")
+	}
+	if !IsReservedDir(ArchivedDir) {
+		t.Error("Expected _archived to be reserved")
+	}
+}
+
+func TestArchiveApp_HomeLayout(t *testing.T) {
+	cfg := config.Default()
+	cfg.DotfilesPath = t.TempDir()
+	os.MkdirAll(filepath.Join(cfg.DotfilesPath, "kitty"), 0755)
+	home, _ := layout.New(layout.Home)
+	if err := layout.Save(cfg.DotfilesPath, home); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ArchiveApp(cfg, "kitty", nil); err == nil || !strings.Contains(err.Error(), "per-app directories") {
+		t.Errorf("Expected archiving refused in the home layout, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DotfilesPath, "kitty")); err != nil {
+		t.Errorf("Expected dotfiles/kitty left alone: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package system keeps root-owned configs such as /etc/nginx or /etc/keyd
+// apart from the user's. Once enabled, definitions' paths outside the home
+// directory leave the regular scan and are stored under dotfiles/system/
+// by their absolute path; pushing reads them as the user where it can and
+// pulling writes them back with sudo.
+package system
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dotsync/internal/models"
+)
+
+// Dir is the dotfiles directory holding system configs
+const Dir = "system"
+
+// IsSystemPath reports whether a definition's config path lives outside
+// the home directory, like /etc/nginx
+func IsSystemPath(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false // ~/... and relative paths
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return true
+	}
+	rel, err := filepath.Rel(homeDir, path)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// StorePath returns where the system config at path is stored
+func StorePath(dotfilesPath, path string) string {
+	return filepath.Join(dotfilesPath, Dir, filepath.Clean(path))
+}
+
+// State compares a system config with its stored copy
+type State string
+
+const (
+	StateSynced     State = "synced"
+	StateChanged    State = "changed"     // Both exist and differ
+	StateLocalOnly  State = "local-only"  // Not pushed yet
+	StateStoredOnly State = "stored-only" // Pushed elsewhere, missing here
+	StateUnreadable State = "unreadable"  // Can't be read without root
+)
+
+// Entry is one system config path of an app definition
+type Entry struct {
+	AppID   string
+	AppName string
+	Path    string // The config on this machine
+	Stored  string // Its copy in dotfiles/system
+	IsDir   bool
+	State   State
+}
+
+// Entries lists the system config paths of defs that exist here or in the
+// store, by app and path
+func Entries(dotfilesPath string, defs []models.AppDefinition) []Entry {
+	var entries []Entry
+	seen := make(map[string]bool)
+	for _, def := range defs {
+		for _, path := range def.ConfigPaths {
+			if !IsSystemPath(path) || seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			e := Entry{AppID: def.ID, AppName: def.Name, Path: path, Stored: StorePath(dotfilesPath, path)}
+			local, localErr := os.Stat(e.Path)
+			stored, storedErr := os.Stat(e.Stored)
+			switch {
+			case localErr != nil && storedErr != nil:
+				continue
+			case storedErr != nil:
+				e.IsDir, e.State = local.IsDir(), StateLocalOnly
+			case localErr != nil:
+				e.IsDir, e.State = stored.IsDir(), StateStoredOnly
+			default:
+				e.IsDir, e.State = local.IsDir(), compare(e.Path, e.Stored)
+			}
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].AppID != entries[j].AppID {
+			return entries[i].AppID < entries[j].AppID
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// compare tells whether the trees at a and b hold the same files
+func compare(a, b string) State {
+	same, err := sameTree(a, b)
+	switch {
+	case err != nil && os.IsPermission(err):
+		return StateUnreadable
+	case err != nil || !same:
+		return StateChanged
+	default:
+		return StateSynced
+	}
+}
+
+func sameTree(a, b string) (bool, error) {
+	files := 0
+	same := true
+	err := filepath.WalkDir(a, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		files++
+		rel, _ := filepath.Rel(a, p)
+		if eq, err := sameFile(p, filepath.Join(b, rel)); err != nil || !eq {
+			same = false
+			if err != nil && os.IsPermission(err) {
+				return err
+			}
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil || !same {
+		return false, err
+	}
+	// Files only in the stored copy
+	stored := 0
+	filepath.WalkDir(b, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			stored++
+		}
+		return nil
+	})
+	return files == stored, nil
+}
+
+func sameFile(a, b string) (bool, error) {
+	da, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	db, err := os.ReadFile(b)
+	if err != nil {
+		return false, nil // Missing in the store
+	}
+	return bytes.Equal(da, db), nil
+}
+
+// Push stores e's config in the dotfiles repo. Configs the user can't read
+// are copied by elevate (sudo), which must leave the copy the user's.
+func Push(e Entry, elevate func(src, dst string, isDir bool) error) error {
+	if err := os.RemoveAll(e.Stored); err != nil {
+		return err
+	}
+	err := copyTree(e.Path, e.Stored)
+	if err != nil && os.IsPermission(err) && elevate != nil {
+		os.RemoveAll(e.Stored)
+		return elevate(e.Path, e.Stored, e.IsDir)
+	}
+	return err
+}
+
+// copyTree copies a file or directory as the current user
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, p)
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil // Sockets, devices and links stay behind
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,69 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotsync/internal/models"
+)
+
+func TestIsSystemPath(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	tests := map[string]bool{
+		"/etc/nginx":                           true,
+		"/etc/sddm.conf":                       true,
+		"~/.config/nvim":                       false,
+		filepath.Join(homeDir, ".config/keyd"): false,
+	}
+	for path, want := range tests {
+		if got := IsSystemPath(path); got != want {
+			t.Errorf("IsSystemPath(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestEntriesAndPush(t *testing.T) {
+	// Temp dirs stand in for /etc: they live outside the home directory
+	root := t.TempDir()
+	if !IsSystemPath(root) {
+		t.Skip("temp dir is inside the home directory")
+	}
+	dotfiles := filepath.Join(root, "dotfiles")
+	keyd := filepath.Join(root, "etc", "keyd")
+	os.MkdirAll(keyd, 0755)
+	os.WriteFile(filepath.Join(keyd, "default.conf"), []byte("[ids]\n*"), 0644)
+	gone := filepath.Join(root, "etc", "greetd")
+	os.MkdirAll(StorePath(dotfiles, gone), 0755)
+	os.WriteFile(filepath.Join(StorePath(dotfiles, gone), "config.toml"), []byte("x"), 0644)
+
+	defs := []models.AppDefinition{
+		{ID: "keyd", Name: "keyd", ConfigPaths: []string{keyd, "~/.config/keyd"}},
+		{ID: "greetd", Name: "greetd", ConfigPaths: []string{gone, filepath.Join(root, "etc", "absent")}},
+	}
+	entries := Entries(dotfiles, defs)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", entries)
+	}
+	if entries[0].AppID != "greetd" || entries[0].State != StateStoredOnly {
+		t.Errorf("Expected greetd stored only, got %+v", entries[0])
+	}
+	e := entries[1]
+	if e.AppID != "keyd" || e.State != StateLocalOnly || !e.IsDir {
+		t.Fatalf("Expected keyd local only, got %+v", e)
+	}
+
+	if err := Push(e, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := Entries(dotfiles, defs[:1]); got[0].State != StateSynced {
+		t.Errorf("Expected keyd synced after push, got %s", got[0].State)
+	}
+	os.WriteFile(filepath.Join(keyd, "default.conf"), []byte("[ids]\nk"), 0644)
+	if got := Entries(dotfiles, defs[:1]); got[0].State != StateChanged {
+		t.Errorf("Expected keyd changed after an edit, got %s", got[0].State)
+	}
+}
@@ -0,0 +1,253 @@
+// Package termimg renders image thumbnails with terminal graphics
+// protocols (kitty and sixel) for the file preview.
+package termimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"strings"
+
+	// Decoders for the formats the preview can show
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// Protocol is a terminal graphics protocol
+type Protocol string
+
+const (
+	None  Protocol = "none"
+	Kitty Protocol = "kitty"
+	Sixel Protocol = "sixel"
+)
+
+// Env overrides detection: kitty, sixel or none
+const Env = "DOTSYNC_IMAGES"
+
+// Assumed size of a terminal cell in pixels, used to size sixel output
+const (
+	CellWidth  = 8
+	CellHeight = 16
+)
+
+// imageID identifies dotsync's kitty image so redraws replace it
+const imageID = 4242
+
+// Detect picks the protocol the terminal supports from the environment
+func Detect() Protocol {
+	return detect(os.Getenv)
+}
+
+func detect(getenv func(string) string) Protocol {
+	switch Protocol(strings.ToLower(getenv(Env))) {
+	case Kitty:
+		return Kitty
+	case Sixel:
+		return Sixel
+	case None, "off":
+		return None
+	}
+
+	term := getenv("TERM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty":
+		return Kitty
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "ghostty", "WezTerm":
+		return Kitty
+	case "iTerm.app", "mintty":
+		return Sixel
+	}
+	if strings.Contains(term, "sixel") || strings.HasPrefix(term, "foot") || term == "mlterm" || term == "contour" {
+		return Sixel
+	}
+	return None
+}
+
+// Format returns the image format of data ("png", "jpeg", "gif"), or ""
+// when it is not an image the preview can decode
+func Format(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return "png"
+	case "image/jpeg":
+		return "jpeg"
+	case "image/gif":
+		return "gif"
+	}
+	return ""
+}
+
+// Thumbnail decodes an image and encodes it for the protocol, fitting it in
+// cols x rows cells without upscaling. It returns the escape sequence, the
+// rows it covers and the image's original size.
+func Thumbnail(p Protocol, data []byte, cols, rows int) (string, int, image.Point, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, image.Point{}, err
+	}
+	size := img.Bounds().Size()
+	if p == None || cols <= 0 || rows <= 0 {
+		return "", 0, size, nil
+	}
+
+	thumb := scale(img, cols*CellWidth, rows*CellHeight)
+	ts := thumb.Bounds().Size()
+	usedCols := (ts.X + CellWidth - 1) / CellWidth
+	usedRows := (ts.Y + CellHeight - 1) / CellHeight
+
+	switch p {
+	case Kitty:
+		seq, err := encodeKitty(thumb, usedCols, usedRows)
+		return seq, usedRows, size, err
+	case Sixel:
+		return encodeSixel(thumb), usedRows, size, nil
+	}
+	return "", 0, size, fmt.Errorf("unknown protocol %q", p)
+}
+
+// Clear returns the sequence removing a thumbnail from the screen
+func Clear(p Protocol) string {
+	if p == Kitty {
+		return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", imageID)
+	}
+	return ""
+}
+
+// scale shrinks img to fit maxW x maxH, keeping its aspect ratio
+func scale(img image.Image, maxW, maxH int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxW {
+		h = max(h*maxW/w, 1)
+		w = maxW
+	}
+	if h > maxH {
+		w = max(w*maxH/h, 1)
+		h = maxH
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			sx := b.Min.X + x*b.Dx()/w
+			sy := b.Min.Y + y*b.Dy()/h
+			out.Set(x, y, img.At(sx, sy))
+		}
+	}
+	return out
+}
+
+// encodeKitty sends img as PNG over the kitty graphics protocol, placed at
+// the cursor without moving it
+func encodeKitty(img image.Image, cols, rows int) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	const chunk = 4096
+	var b strings.Builder
+	for i := 0; i < len(payload); i += chunk {
+		end := min(i+chunk, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,i=%d,p=1,q=2,C=1,c=%d,r=%d,m=%d;", imageID, cols, rows, more)
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;", more)
+		}
+		b.WriteString(payload[i:end])
+		b.WriteString("\x1b\\")
+	}
+	return b.String(), nil
+}
+
+// encodeSixel draws img as sixels using a 6x6x6 color cube. Transparent
+// pixels are left undrawn.
+func encodeSixel(img *image.NRGBA) string {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1bPq\"1;1;%d;%d", w, h)
+	for i := range 216 {
+		r, g, bl := i/36, i/6%6, i%6
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*20, g*20, bl*20)
+	}
+
+	// Palette index of each pixel, -1 for transparent
+	index := func(x, y int) int {
+		c := img.NRGBAAt(x, y)
+		if c.A < 128 {
+			return -1
+		}
+		return cubeIndex(c)
+	}
+
+	for band := 0; band < h; band += 6 {
+		// Sixel bits per color per column in this band
+		bits := make(map[int][]byte)
+		var order []int
+		for x := range w {
+			for dy := 0; dy < 6 && band+dy < h; dy++ {
+				c := index(x, band+dy)
+				if c < 0 {
+					continue
+				}
+				row, ok := bits[c]
+				if !ok {
+					row = make([]byte, w)
+					bits[c] = row
+					order = append(order, c)
+				}
+				row[x] |= 1 << dy
+			}
+		}
+
+		for i, c := range order {
+			if i > 0 {
+				b.WriteByte('$')
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRun(&b, bits[c])
+		}
+		b.WriteByte('-')
+	}
+
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// writeSixelRun writes one color's sixels, run-length encoded
+func writeSixelRun(b *strings.Builder, row []byte) {
+	for x := 0; x < len(row); {
+		n := 1
+		for x+n < len(row) && row[x+n] == row[x] {
+			n++
+		}
+		ch := byte('?' + row[x])
+		if n > 3 {
+			fmt.Fprintf(b, "!%d%c", n, ch)
+		} else {
+			b.WriteString(strings.Repeat(string(ch), n))
+		}
+		x += n
+	}
+}
+
+// cubeIndex maps a color to the nearest entry of the 6x6x6 cube
+func cubeIndex(c color.NRGBA) int {
+	level := func(v uint8) int { return (int(v)*5 + 127) / 255 }
+	return level(c.R)*36 + level(c.G)*6 + level(c.B)
+}
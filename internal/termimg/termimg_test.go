@@ -0,0 +1,100 @@
+package termimg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want Protocol
+	}{
+		{map[string]string{}, None},
+		{map[string]string{"TERM": "xterm-256color"}, None},
+		{map[string]string{"TERM": "xterm-kitty"}, Kitty},
+		{map[string]string{"KITTY_WINDOW_ID": "1"}, Kitty},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, Kitty},
+		{map[string]string{"TERM": "foot"}, Sixel},
+		{map[string]string{"TERM": "xterm-kitty", Env: "sixel"}, Sixel},
+		{map[string]string{"TERM": "xterm-kitty", Env: "off"}, None},
+	}
+
+	for _, tt := range tests {
+		got := detect(func(k string) string { return tt.env[k] })
+		if got != tt.want {
+			t.Errorf("detect(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+// testPNG encodes a w x h image, red on the left half and blue on the right
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFormat(t *testing.T) {
+	if got := Format(testPNG(t, 2, 2)); got != "png" {
+		t.Errorf("Expected png, got %q", got)
+	}
+	if got := Format([]byte("plain text")); got != "" {
+		t.Errorf("Expected no format for text, got %q", got)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	data := testPNG(t, 160, 64)
+
+	seq, rows, size, err := Thumbnail(Kitty, data, 10, 10)
+	if err != nil {
+		t.Fatalf("Thumbnail failed: %v", err)
+	}
+	if size != image.Pt(160, 64) {
+		t.Errorf("Expected original size 160x64, got %v", size)
+	}
+	// 160x64 fits 80x32 pixels in 10 columns: 2 rows of 16px
+	if rows != 2 {
+		t.Errorf("Expected 2 rows, got %d", rows)
+	}
+	if !strings.HasPrefix(seq, "\x1b_Ga=T,f=100") || !strings.HasSuffix(seq, "\x1b\\") {
+		t.Errorf("Unexpected kitty sequence %q", seq[:min(len(seq), 40)])
+	}
+
+	seq, _, _, err = Thumbnail(Sixel, data, 10, 10)
+	if err != nil {
+		t.Fatalf("Thumbnail failed: %v", err)
+	}
+	if !strings.HasPrefix(seq, "\x1bPq\"1;1;80;32") || !strings.HasSuffix(seq, "\x1b\\") {
+		t.Errorf("Unexpected sixel header %q", seq[:min(len(seq), 40)])
+	}
+	// Red is cube entry 5*36 = 180, blue is 5
+	if !strings.Contains(seq, "#180!40~") || !strings.Contains(seq, "#5!40?!40~") {
+		t.Error("Expected run-length encoded red and blue halves")
+	}
+
+	if seq, rows, _, err := Thumbnail(None, data, 10, 10); err != nil || seq != "" || rows != 0 {
+		t.Errorf("None should only decode, got (%q, %d, %v)", seq, rows, err)
+	}
+	if _, _, _, err := Thumbnail(Kitty, []byte("not an image"), 10, 10); err == nil {
+		t.Error("Expected error for undecodable data")
+	}
+}
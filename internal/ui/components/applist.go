@@ -2,13 +2,15 @@ package components
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"dotsync/internal/i18n"
 	"dotsync/internal/modes"
 	"dotsync/internal/models"
 	"dotsync/internal/ui"
-
-	"github.com/charmbracelet/lipgloss"
 )
 
 // AppList is a list component for apps
@@ -20,6 +22,37 @@ type AppList struct {
 	Focused     bool
 	Title       string
 	ModesConfig *modes.ModesConfig
+
+	// Grouped renders apps under collapsible category headers. The cursor
+	// then moves over headers as well as apps.
+	Grouped   bool
+	collapsed map[string]bool
+
+	// StatusFilter hides apps without a file in the filtered state; source
+	// keeps the apps given to SetApps so the filter can be lifted again
+	StatusFilter models.StatusFilter
+	source       []*models.App
+
+	visual visualRange // Rows being marked in visual mode
+
+	// Linear renders the list without a border or divider, one panel below
+	// the other, for screen readers
+	Linear bool
+
+	// SortStale lists the apps synced least recently first, by SyncTimes:
+	// the last push or pull of any of their files by app ID
+	SortStale bool
+	SyncTimes map[string]time.Time
+}
+
+// CategoryOrder is the order of known categories in the grouped list;
+// other categories follow alphabetically
+var CategoryOrder = []string{"ai", "shell", "editor", "terminal", "git", "dev", "cli", "productivity", "cloud"}
+
+// appRow is one line of the list: a category header (app is nil) or an app
+type appRow struct {
+	category string
+	app      *models.App
 }
 
 // NewAppList creates a new app list
@@ -27,20 +60,278 @@ func NewAppList(apps []*models.App) *AppList {
 	modesCfg, _ := modes.Load()
 	return &AppList{
 		Apps:        apps,
+		source:      apps,
 		Cursor:      0,
 		Width:       30,
 		Height:      15,
 		Focused:     true,
 		Title:       "Applications",
 		ModesConfig: modesCfg,
+		collapsed:   make(map[string]bool),
 	}
 }
 
-// SetApps updates the apps list
+// SetApps updates the apps list, keeping the cursor on the same app when
+// it is still listed
 func (l *AppList) SetApps(apps []*models.App) {
+	current := l.Current()
+	wasEmpty := len(l.Apps) == 0
+	l.source = apps
 	l.Apps = apps
-	if l.Cursor >= len(apps) {
-		l.Cursor = max(0, len(apps)-1)
+	if l.StatusFilter != models.FilterNone {
+		l.Apps = nil
+		for _, app := range apps {
+			if app.HasFilesMatching(l.StatusFilter) {
+				l.Apps = append(l.Apps, app)
+			}
+		}
+	}
+	l.visual.stop()
+	if l.Grouped && (current != nil || wasEmpty) {
+		// Follow the current app, or start on the first app of a new list
+		for i, row := range l.rows() {
+			if row.app != nil && (row.app == current || wasEmpty) {
+				l.Cursor = i
+				return
+			}
+		}
+	}
+	l.clampCursor()
+}
+
+// SetStatusFilter lists only the apps with files passing f, re-applying
+// it to the apps last given to SetApps
+func (l *AppList) SetStatusFilter(f models.StatusFilter) {
+	l.StatusFilter = f
+	l.SetApps(l.source)
+}
+
+// SetGrouped switches between the grouped and the flat list, keeping the
+// cursor on the current app
+func (l *AppList) SetGrouped(grouped bool) {
+	current := l.Current()
+	l.Grouped = grouped
+	l.Cursor = 0
+	l.visual.stop()
+	for i, row := range l.rows() {
+		if row.app != nil && row.app == current {
+			l.Cursor = i
+			break
+		}
+	}
+	l.clampCursor()
+}
+
+// FocusApp moves the cursor to app, expanding its category if needed.
+// Returns false when app is not listed.
+func (l *AppList) FocusApp(app *models.App) bool {
+	if l.Grouped && l.collapsed[appCategory(app)] {
+		l.collapsed[appCategory(app)] = false
+		l.visual.stop()
+	}
+	for i, row := range l.rows() {
+		if row.app == app {
+			l.Cursor = i
+			return true
+		}
+	}
+	return false
+}
+
+// OnHeader reports whether the cursor is on a category header
+func (l *AppList) OnHeader() bool {
+	rows := l.rows()
+	return l.Cursor < len(rows) && rows[l.Cursor].app == nil
+}
+
+// CurrentCategory returns the category of the row under the cursor
+func (l *AppList) CurrentCategory() string {
+	rows := l.rows()
+	if l.Cursor < len(rows) {
+		return rows[l.Cursor].category
+	}
+	return ""
+}
+
+// IsCollapsed reports whether category is collapsed
+func (l *AppList) IsCollapsed(category string) bool {
+	return l.collapsed[category]
+}
+
+// ToggleCollapse collapses or expands the category under the cursor and
+// moves the cursor to its header
+func (l *AppList) ToggleCollapse() {
+	if !l.Grouped {
+		return
+	}
+	category := l.CurrentCategory()
+	if category == "" {
+		return
+	}
+	if l.collapsed == nil {
+		l.collapsed = make(map[string]bool)
+	}
+	l.collapsed[category] = !l.collapsed[category]
+	l.visual.stop()
+	for i, row := range l.rows() {
+		if row.app == nil && row.category == category {
+			l.Cursor = i
+			return
+		}
+	}
+}
+
+// StartVisual starts marking a range of rows at the cursor
+func (l *AppList) StartVisual() {
+	l.visual.start(l.Cursor)
+}
+
+// CancelVisual leaves visual mode without changing the selection
+func (l *AppList) CancelVisual() {
+	l.visual.stop()
+}
+
+// InVisual reports whether a range is being marked
+func (l *AppList) InVisual() bool {
+	return l.visual.active
+}
+
+// ApplyVisual selects every app in the marked range, or deselects them
+// when they are all selected already, and leaves visual mode. A collapsed
+// category header in the range stands for all of its apps. Returns the
+// number of apps in the range.
+func (l *AppList) ApplyVisual() int {
+	if !l.visual.active {
+		return 0
+	}
+	l.visual.stop()
+
+	rows := l.rows()
+	first, last := l.visual.bounds(l.Cursor)
+	var apps []*models.App
+	for i := first; i <= last && i < len(rows); i++ {
+		switch {
+		case rows[i].app != nil:
+			apps = append(apps, rows[i].app)
+		case l.collapsed[rows[i].category]:
+			apps = append(apps, l.CategoryApps(rows[i].category)...)
+		}
+	}
+	toggleApps(apps)
+	return len(apps)
+}
+
+// ToggleVisible selects every listed app (the current search or category
+// filter), or deselects them all when they are all selected
+func (l *AppList) ToggleVisible() {
+	toggleApps(l.Apps)
+}
+
+// toggleApps selects all apps, or deselects them if all are selected
+func toggleApps(apps []*models.App) {
+	all := len(apps) > 0
+	for _, app := range apps {
+		all = all && (app.Selected || app.Uninstalled)
+	}
+	for _, app := range apps {
+		if !app.Uninstalled {
+			app.Selected = !all
+		}
+	}
+}
+
+// SelectCategory selects every app in the category under the cursor, or
+// deselects them all when they are already selected
+func (l *AppList) SelectCategory() {
+	toggleApps(l.CategoryApps(l.CurrentCategory()))
+}
+
+// CategoryApps returns the listed apps in category
+func (l *AppList) CategoryApps(category string) []*models.App {
+	var apps []*models.App
+	for _, app := range l.Apps {
+		if appCategory(app) == category {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// rows returns the lines of the list in display order
+func (l *AppList) rows() []appRow {
+	apps := l.Apps
+	if l.SortStale {
+		apps = slices.Clone(l.Apps)
+		sort.SliceStable(apps, func(i, j int) bool {
+			return l.SyncTimes[apps[i].ID].Before(l.SyncTimes[apps[j].ID])
+		})
+	}
+	if !l.Grouped {
+		rows := make([]appRow, len(apps))
+		for i, app := range apps {
+			rows[i] = appRow{category: appCategory(app), app: app}
+		}
+		return rows
+	}
+
+	byCategory := make(map[string][]*models.App)
+	for _, app := range apps {
+		category := appCategory(app)
+		byCategory[category] = append(byCategory[category], app)
+	}
+
+	var rows []appRow
+	for _, category := range sortCategories(byCategory) {
+		rows = append(rows, appRow{category: category})
+		if l.collapsed[category] {
+			continue
+		}
+		for _, app := range byCategory[category] {
+			rows = append(rows, appRow{category: category, app: app})
+		}
+	}
+	return rows
+}
+
+// appCategory returns the normalized category of app
+func appCategory(app *models.App) string {
+	if app.Category == "" {
+		return "other"
+	}
+	return strings.ToLower(app.Category)
+}
+
+// sortCategories orders the categories by CategoryOrder, then by name
+func sortCategories(byCategory map[string][]*models.App) []string {
+	rank := make(map[string]int, len(CategoryOrder))
+	for i, c := range CategoryOrder {
+		rank[c] = i + 1
+	}
+
+	categories := make([]string, 0, len(byCategory))
+	for c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		ri, rj := rank[categories[i]], rank[categories[j]]
+		if ri == 0 {
+			ri = len(CategoryOrder) + 1
+		}
+		if rj == 0 {
+			rj = len(CategoryOrder) + 1
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return categories[i] < categories[j]
+	})
+	return categories
+}
+
+// clampCursor keeps the cursor within the rows
+func (l *AppList) clampCursor() {
+	if n := len(l.rows()); l.Cursor >= n {
+		l.Cursor = max(0, n-1)
 	}
 }
 
@@ -66,7 +357,7 @@ func (l *AppList) MoveUp() {
 
 // MoveDown moves cursor down
 func (l *AppList) MoveDown() {
-	if l.Cursor < len(l.Apps)-1 {
+	if l.Cursor < len(l.rows())-1 {
 		l.Cursor++
 	}
 }
@@ -90,9 +381,7 @@ func (l *AppList) PageDown() {
 		pageSize = 10
 	}
 	l.Cursor += pageSize
-	if l.Cursor >= len(l.Apps) {
-		l.Cursor = max(0, len(l.Apps)-1)
-	}
+	l.clampCursor()
 }
 
 // GoToFirst moves cursor to the first item
@@ -102,22 +391,27 @@ func (l *AppList) GoToFirst() {
 
 // GoToLast moves cursor to the last item
 func (l *AppList) GoToLast() {
-	if len(l.Apps) > 0 {
-		l.Cursor = len(l.Apps) - 1
+	if n := len(l.rows()); n > 0 {
+		l.Cursor = n - 1
 	}
 }
 
-// Toggle toggles selection of current item
+// Toggle toggles selection of current item; on a category header it
+// selects or deselects the whole category
 func (l *AppList) Toggle() {
-	if len(l.Apps) > 0 && l.Cursor < len(l.Apps) {
-		l.Apps[l.Cursor].ToggleSelected()
+	if l.OnHeader() {
+		l.SelectCategory()
+		return
+	}
+	if app := l.Current(); app != nil {
+		app.ToggleSelected()
 	}
 }
 
-// SelectAll selects all apps
+// SelectAll selects all apps, leaving out uninstalled ones
 func (l *AppList) SelectAll() {
 	for _, app := range l.Apps {
-		app.Selected = true
+		app.Selected = !app.Uninstalled
 	}
 }
 
@@ -128,10 +422,11 @@ func (l *AppList) DeselectAll() {
 	}
 }
 
-// Current returns the currently selected app
+// Current returns the app under the cursor, or nil on a category header
 func (l *AppList) Current() *models.App {
-	if len(l.Apps) > 0 && l.Cursor < len(l.Apps) {
-		return l.Apps[l.Cursor]
+	rows := l.rows()
+	if l.Cursor < len(rows) {
+		return rows[l.Cursor].app
 	}
 	return nil
 }
@@ -170,23 +465,33 @@ func (l *AppList) View() string {
 	} else if len(l.Apps) > 0 {
 		title = fmt.Sprintf("%s (%d)", l.Title, len(l.Apps))
 	}
+	if l.StatusFilter != models.FilterNone {
+		title += " · " + i18n.T("filter."+l.StatusFilter.String())
+	}
+	if l.Linear && l.Focused {
+		title += " " + i18n.T("panel.focused")
+	}
 	b.WriteString(ui.PanelTitleStyle.Render(title))
 	b.WriteString("\n")
-	b.WriteString(ui.DividerStyle.Render(strings.Repeat("─", l.Width-2)))
-	b.WriteString("\n")
+	if !l.Linear {
+		b.WriteString(ui.DividerStyle.Render(strings.Repeat("─", l.Width-2)))
+		b.WriteString("\n")
+	}
 
 	if len(l.Apps) == 0 {
-		b.WriteString(ui.ItemStyle.Render("No apps found"))
+		b.WriteString(ui.ItemStyle.Render(i18n.T("apps.none")))
 		return l.wrapInPanel(b.String())
 	}
 
+	rows := l.rows()
+
 	// Calculate visible range
 	visibleHeight := l.Height - 3 // Minus title and divider
 	startIdx := 0
 	if l.Cursor >= visibleHeight {
 		startIdx = l.Cursor - visibleHeight + 1
 	}
-	endIdx := min(startIdx+visibleHeight, len(l.Apps))
+	endIdx := min(startIdx+visibleHeight, len(rows))
 
 	// Show scroll indicator at top
 	if startIdx > 0 {
@@ -196,8 +501,13 @@ func (l *AppList) View() string {
 
 	// Render visible items
 	for i := startIdx; i < endIdx; i++ {
-		app := l.Apps[i]
-		line := l.renderItem(app, i == l.Cursor)
+		var line string
+		inRange := l.visual.contains(i, l.Cursor)
+		if rows[i].app == nil {
+			line = l.renderHeader(rows[i].category, i == l.Cursor, inRange)
+		} else {
+			line = l.renderItem(rows[i].app, i == l.Cursor, inRange)
+		}
 		b.WriteString(line)
 		if i < endIdx-1 {
 			b.WriteString("\n")
@@ -205,14 +515,14 @@ func (l *AppList) View() string {
 	}
 
 	// Show scroll indicator at bottom with position info
-	if endIdx < len(l.Apps) {
+	if endIdx < len(rows) {
 		b.WriteString("\n")
 		b.WriteString(ui.MutedStyle.Render("  ↓ more"))
 	}
 
 	// Add position indicator when scrolling
-	if len(l.Apps) > visibleHeight {
-		position := fmt.Sprintf(" %d/%d ", l.Cursor+1, len(l.Apps))
+	if len(rows) > visibleHeight {
+		position := fmt.Sprintf(" %d/%d ", l.Cursor+1, len(rows))
 		b.WriteString("\n")
 		b.WriteString(ui.MutedStyle.Render(strings.Repeat(" ", (l.Width-len(position)-4)/2) + position))
 	}
@@ -220,8 +530,37 @@ func (l *AppList) View() string {
 	return l.wrapInPanel(b.String())
 }
 
+// renderHeader renders a category header with its app and selection counts
+func (l *AppList) renderHeader(category string, isCursor, inRange bool) string {
+	apps := l.CategoryApps(category)
+	selected := 0
+	for _, app := range apps {
+		if app.Selected {
+			selected++
+		}
+	}
+
+	arrow := "▾"
+	if l.collapsed[category] {
+		arrow = "▸"
+	}
+	count := fmt.Sprintf("(%d)", len(apps))
+	if selected > 0 {
+		count = fmt.Sprintf("(%d/%d)", selected, len(apps))
+	}
+	content := fmt.Sprintf("%s %s %s", arrow, category, ui.MutedStyle.Render(count))
+
+	if isCursor && l.Focused {
+		return ui.SelectedItemStyle.Width(l.Width - 4).Render(content)
+	}
+	if inRange {
+		return ui.VisualItemStyle.Width(l.Width - 4).Render(content)
+	}
+	return ui.CategoryStyle.Render(content)
+}
+
 // renderItem renders a single app item
-func (l *AppList) renderItem(app *models.App, isCursor bool) string {
+func (l *AppList) renderItem(app *models.App, isCursor, inRange bool) string {
 	checkbox := ui.RenderCheckbox(app.Selected)
 	icon := app.Icon
 	if icon == "" {
@@ -270,21 +609,38 @@ func (l *AppList) renderItem(app *models.App, isCursor bool) string {
 	}
 
 	content := fmt.Sprintf("%s %s %s %s %s %s", checkbox, icon, name, ui.MutedStyle.Render(filesCount), modeStyle.Render(modeIndicator), statusIndicator)
+	if l.SortStale {
+		age := i18n.T("time.short.never")
+		if synced := l.SyncTimes[app.ID]; !synced.IsZero() {
+			age = ShortAge(synced)
+		}
+		content += " " + ui.MutedStyle.Render(age)
+	}
+	if app.Uninstalled {
+		// Nothing to sync until it's reinstalled; X archives its dotfiles
+		content = fmt.Sprintf("%s %s %s %s %s", checkbox, icon, ui.MutedStyle.Render(name), ui.MutedStyle.Render(filesCount), ui.MissingStyle.Render(i18n.T("apps.uninstalled")))
+	}
+	if l.Grouped {
+		content = "  " + content // Indent under the category header
+	}
 
 	if isCursor && l.Focused {
 		return ui.SelectedItemStyle.Width(l.Width - 4).Render(content)
 	}
+	if inRange {
+		return ui.VisualItemStyle.Width(l.Width - 4).Render(content)
+	}
 	return ui.ItemStyle.Render(content)
 }
 
 // wrapInPanel wraps content in a panel border
 func (l *AppList) wrapInPanel(content string) string {
+	if l.Linear {
+		return content
+	}
 	style := ui.PanelStyle
 	if l.Focused {
 		style = ui.ActivePanelStyle
 	}
 	return style.Width(l.Width).Height(l.Height).Render(content)
 }
-
-// MutedStyle for scroll indicators
-var MutedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280"))
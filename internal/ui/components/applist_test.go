@@ -1,8 +1,11 @@
 package components
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	"dotsync/internal/i18n"
 	"dotsync/internal/models"
 )
 
@@ -359,3 +362,248 @@ func TestAppList_GoToLast_EmptyList(t *testing.T) {
 		t.Errorf("Expected cursor to stay at 0 for empty list, got %d", list.Cursor)
 	}
 }
+
+func TestAppList_Grouped(t *testing.T) {
+	apps := []*models.App{
+		{ID: "zsh", Name: "Zsh", Category: "shell"},
+		{ID: "claude", Name: "Claude", Category: "ai"},
+		{ID: "misc", Name: "Misc"},
+		{ID: "bash", Name: "Bash", Category: "Shell"},
+	}
+	list := NewAppList(apps)
+	list.SetGrouped(true)
+
+	// ai header, claude, shell header, zsh, bash, other header, misc
+	wantIDs := []string{"", "claude", "", "zsh", "bash", "", "misc"}
+	rows := list.rows()
+	if len(rows) != len(wantIDs) {
+		t.Fatalf("Expected %d rows, got %d", len(wantIDs), len(rows))
+	}
+	for i, want := range wantIDs {
+		got := ""
+		if rows[i].app != nil {
+			got = rows[i].app.ID
+		}
+		if got != want {
+			t.Errorf("Row %d: expected %q, got %q", i, want, got)
+		}
+	}
+
+	if list.Current() == nil || list.Current().ID != "zsh" {
+		t.Errorf("Cursor should stay on the first app when grouping, got %+v", list.Current())
+	}
+}
+
+func TestAppList_ToggleCollapse(t *testing.T) {
+	apps := []*models.App{
+		{ID: "claude", Category: "ai"},
+		{ID: "zsh", Category: "shell"},
+		{ID: "bash", Category: "shell"},
+	}
+	list := NewAppList(apps)
+	list.SetGrouped(true)
+	list.Cursor = 3 // zsh
+
+	list.ToggleCollapse()
+	if !list.IsCollapsed("shell") {
+		t.Fatal("Shell should be collapsed")
+	}
+	if !list.OnHeader() || list.CurrentCategory() != "shell" {
+		t.Errorf("Cursor should move to the shell header, got row %d", list.Cursor)
+	}
+	if n := len(list.rows()); n != 3 {
+		t.Errorf("Expected 3 rows with shell collapsed, got %d", n)
+	}
+
+	list.MoveDown()
+	if list.Cursor != 2 {
+		t.Errorf("Cursor should not move past the last row, got %d", list.Cursor)
+	}
+
+	list.ToggleCollapse()
+	if list.IsCollapsed("shell") {
+		t.Error("Shell should be expanded again")
+	}
+}
+
+func TestAppList_SelectCategory(t *testing.T) {
+	apps := []*models.App{
+		{ID: "claude", Category: "ai"},
+		{ID: "zsh", Category: "shell", Selected: true},
+		{ID: "bash", Category: "shell"},
+	}
+	list := NewAppList(apps)
+	list.SetGrouped(true)
+	list.Cursor = 2 // shell header
+
+	list.Toggle()
+	if !apps[1].Selected || !apps[2].Selected {
+		t.Error("Toggle on a header should select the whole category")
+	}
+	if apps[0].Selected {
+		t.Error("Other categories should be left alone")
+	}
+
+	list.Toggle()
+	if apps[1].Selected || apps[2].Selected {
+		t.Error("Toggle on a fully selected category should deselect it")
+	}
+}
+
+func TestAppList_SetApps_KeepsCurrentApp(t *testing.T) {
+	apps := []*models.App{
+		{ID: "claude", Category: "ai"},
+		{ID: "zsh", Category: "shell"},
+	}
+	list := NewAppList(apps)
+	list.SetGrouped(true)
+	list.Cursor = 3 // zsh
+
+	list.SetApps([]*models.App{apps[1]})
+	if list.Current() != apps[1] {
+		t.Errorf("Cursor should follow zsh, got row %d", list.Cursor)
+	}
+}
+
+func TestAppList_SetApps_GroupedStartsOnApp(t *testing.T) {
+	list := NewAppList(nil)
+	list.Grouped = true
+
+	list.SetApps([]*models.App{{ID: "zsh", Category: "shell"}})
+	if list.Current() == nil || list.Current().ID != "zsh" {
+		t.Errorf("A new grouped list should start on its first app, got row %d", list.Cursor)
+	}
+}
+
+func TestAppList_ApplyVisual(t *testing.T) {
+	apps := []*models.App{
+		{ID: "claude", Category: "ai"},
+		{ID: "zsh", Category: "shell"},
+		{ID: "bash", Category: "shell"},
+		{ID: "fish", Category: "shell"},
+	}
+	list := NewAppList(apps)
+	list.SetGrouped(true)
+	list.Cursor = 1 // claude
+
+	list.StartVisual()
+	list.MoveDown() // shell header
+	list.MoveDown() // zsh
+	if n := list.ApplyVisual(); n != 2 {
+		t.Errorf("Expected 2 apps in range, got %d", n)
+	}
+	if !apps[0].Selected || !apps[1].Selected || apps[2].Selected {
+		t.Error("Only claude and zsh should be selected")
+	}
+
+	list.Cursor = 2
+	list.ToggleCollapse() // shell collapsed, cursor on its header
+	list.StartVisual()
+	if n := list.ApplyVisual(); n != 3 {
+		t.Errorf("A collapsed header should stand for its 3 apps, got %d", n)
+	}
+}
+
+func TestAppList_ToggleVisible(t *testing.T) {
+	apps := []*models.App{{ID: "a", Selected: true}, {ID: "b"}}
+	list := NewAppList(apps)
+
+	list.ToggleVisible()
+	if !apps[0].Selected || !apps[1].Selected {
+		t.Error("ToggleVisible should select every listed app")
+	}
+	list.ToggleVisible()
+	if apps[0].Selected || apps[1].Selected {
+		t.Error("ToggleVisible should deselect when all are selected")
+	}
+}
+
+func TestAppList_FocusApp(t *testing.T) {
+	apps := []*models.App{
+		{ID: "claude", Category: "ai"},
+		{ID: "zsh", Category: "shell"},
+	}
+	list := NewAppList(apps)
+	list.SetGrouped(true)
+	list.Cursor = 2
+	list.ToggleCollapse() // Collapse shell
+
+	if !list.FocusApp(apps[1]) {
+		t.Fatal("FocusApp should find zsh")
+	}
+	if list.IsCollapsed("shell") || list.Current() != apps[1] {
+		t.Errorf("FocusApp should expand shell and move to zsh, got row %d", list.Cursor)
+	}
+	if list.FocusApp(&models.App{ID: "other"}) {
+		t.Error("FocusApp should fail for an unlisted app")
+	}
+}
+
+func TestAppList_StatusFilter(t *testing.T) {
+	synced := &models.App{ID: "synced", Files: []models.File{{Name: "a"}}}
+	changed := &models.App{ID: "changed", Files: []models.File{{Name: "b", ConflictType: models.ConflictLocalModified}}}
+	conflict := &models.App{ID: "conflict", Files: []models.File{{Name: "c", ConflictType: models.ConflictBothModified}}}
+	list := NewAppList([]*models.App{synced, changed, conflict})
+
+	list.SetStatusFilter(models.FilterConflicts)
+	if len(list.Apps) != 1 || list.Apps[0] != conflict {
+		t.Errorf("Expected only the conflicted app, got %d apps", len(list.Apps))
+	}
+	list.SetStatusFilter(models.FilterChanged)
+	if len(list.Apps) != 2 {
+		t.Errorf("Expected the changed and conflicted apps, got %d", len(list.Apps))
+	}
+
+	// The filter stays on for lists set later, and lifting it restores them
+	list.SetApps([]*models.App{synced, changed})
+	if len(list.Apps) != 1 || list.Apps[0] != changed {
+		t.Errorf("Expected the filter to apply to new apps, got %d apps", len(list.Apps))
+	}
+	list.SetStatusFilter(models.FilterNone)
+	if len(list.Apps) != 2 {
+		t.Errorf("Expected both apps back, got %d", len(list.Apps))
+	}
+}
+
+func TestAppList_Linear(t *testing.T) {
+	list := NewAppList([]*models.App{{ID: "app1", Name: "App 1"}})
+	list.Linear = true
+
+	view := list.View()
+	if strings.ContainsAny(view, "╭│─") {
+		t.Errorf("Expected no border or divider in linear mode:\n%s", view)
+	}
+	if !strings.Contains(view, i18n.T("panel.focused")) || !strings.Contains(view, "App 1") {
+		t.Errorf("Expected the focused title and the apps:\n%s", view)
+	}
+}
+
+func TestAppList_SortStale(t *testing.T) {
+	fresh := &models.App{ID: "fresh", Name: "Fresh", Category: "shell"}
+	old := &models.App{ID: "old", Name: "Old", Category: "shell"}
+	never := &models.App{ID: "never", Name: "Never", Category: "ai"}
+	list := NewAppList([]*models.App{fresh, old, never})
+	list.SyncTimes = map[string]time.Time{
+		"fresh": time.Now().Add(-time.Hour),
+		"old":   time.Now().Add(-72 * time.Hour),
+	}
+
+	list.SortStale = true
+	var order []string
+	for _, row := range list.rows() {
+		order = append(order, row.app.ID)
+	}
+	if strings.Join(order, ",") != "never,old,fresh" {
+		t.Errorf("Expected the stalest apps first, got %v", order)
+	}
+	if !strings.Contains(list.View(), "3d") {
+		t.Errorf("Expected the age of old in the list:\n%s", list.View())
+	}
+
+	// Categories keep their order; apps are sorted within them
+	list.SetGrouped(true)
+	list.SyncTimes["never"] = time.Now()
+	if list.Cursor = 3; list.Current() != old {
+		t.Errorf("Expected old first under shell, got %v", list.Current())
+	}
+}
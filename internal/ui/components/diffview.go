@@ -3,14 +3,20 @@ package components
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
+	"dotsync/internal/i18n"
 	"dotsync/internal/sync"
 	"dotsync/internal/ui"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-// DiffView displays a side-by-side diff of two files
+// MinSplitWidth is the narrowest width that shows the side-by-side view;
+// narrower terminals fall back to the unified view
+const MinSplitWidth = 100
+
+// DiffView displays the diff of two files, unified or side by side
 type DiffView struct {
 	Width  int
 	Height int
@@ -20,6 +26,11 @@ type DiffView struct {
 	DotfilePath string
 	DiffResult  *sync.DiffResult
 
+	// Base is the git revision the dotfiles side was read at; empty for
+	// the working dotfiles copy. Keep and merge only apply to the latter.
+	// SetDiff resets it.
+	Base string
+
 	// Navigation
 	ScrollOffset int
 	CurrentHunk  int
@@ -28,30 +39,45 @@ type DiffView struct {
 	highlighter     *ui.Highlighter
 	enableHighlight bool
 
+	// SideBySide shows local and dotfiles in two columns when wide enough
+	SideBySide bool
+
+	// Structured holds the key-level diff of JSON, YAML and TOML configs;
+	// nil for other files or when either side doesn't parse
+	Structured *sync.StructuredDiff
+	// Structural shows the key-level diff instead of lines when available
+	Structural bool
+
 	// Styles
-	addStyle     lipgloss.Style
-	deleteStyle  lipgloss.Style
-	contextStyle lipgloss.Style
-	headerStyle  lipgloss.Style
+	addStyle        lipgloss.Style
+	deleteStyle     lipgloss.Style
+	contextStyle    lipgloss.Style
+	headerStyle     lipgloss.Style
+	addWordStyle    lipgloss.Style
+	deleteWordStyle lipgloss.Style
 }
 
 // NewDiffView creates a new DiffView
 func NewDiffView() *DiffView {
-	return &DiffView{
+	d := &DiffView{
 		Width:           80,
 		Height:          20,
 		highlighter:     ui.NewHighlighter(),
 		enableHighlight: true,
-		addStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#a6e3a1")),
-		deleteStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#f38ba8")),
-		contextStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6c7086")),
-		headerStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#89b4fa")),
+		Structural:      true,
 	}
+	d.ApplyTheme()
+	return d
+}
+
+// ApplyTheme rebuilds the view's styles from the active ui theme
+func (d *DiffView) ApplyTheme() {
+	d.addStyle = lipgloss.NewStyle().Foreground(ui.Added)
+	d.deleteStyle = lipgloss.NewStyle().Foreground(ui.Removed)
+	d.contextStyle = lipgloss.NewStyle().Foreground(ui.Subtle)
+	d.headerStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.Info)
+	d.addWordStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.Added).Background(lipgloss.Color(ui.ActiveTheme().SuccessBg))
+	d.deleteWordStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.Removed).Background(lipgloss.Color(ui.ActiveTheme().ErrorBg))
 }
 
 // SetDiff sets the diff result to display
@@ -59,8 +85,16 @@ func (d *DiffView) SetDiff(result *sync.DiffResult, localPath, dotfilePath strin
 	d.DiffResult = result
 	d.LocalPath = localPath
 	d.DotfilePath = dotfilePath
+	d.Base = ""
 	d.ScrollOffset = 0
 	d.CurrentHunk = 0
+
+	d.Structured = nil
+	if result != nil && !result.Identical && sync.StructuredFormat(localPath) != "" {
+		if structured, err := sync.ComputeStructuredDiff(localPath, dotfilePath); err == nil {
+			d.Structured = structured
+		}
+	}
 }
 
 // ScrollUp scrolls the view up
@@ -92,7 +126,7 @@ func (d *DiffView) PrevHunk() {
 // View renders the diff view
 func (d *DiffView) View() string {
 	if d.DiffResult == nil {
-		return "No diff to display"
+		return i18n.T("diff.none")
 	}
 
 	var b strings.Builder
@@ -108,8 +142,13 @@ func (d *DiffView) View() string {
 	b.WriteString("\n\n")
 
 	// Diff content
-	content := d.renderDiff()
-	b.WriteString(content)
+	if d.structural() {
+		b.WriteString(d.renderStructured())
+	} else if d.split() {
+		b.WriteString(d.renderSplit())
+	} else {
+		b.WriteString(d.renderDiff())
+	}
 
 	// Footer
 	b.WriteString("\n")
@@ -133,9 +172,32 @@ func (d *DiffView) renderHeader() string {
 	if d.enableHighlight {
 		highlightStatus = " [syntax on]"
 	}
+	if d.structural() {
+		highlightStatus += " [structural]"
+	} else if d.split() {
+		highlightStatus += " [split]"
+	} else if d.SideBySide {
+		highlightStatus += " [unified: too narrow]"
+	}
 
 	return fmt.Sprintf("%s  %s  %s%s", title, ui.MutedStyle.Render(fileName),
-		ui.SyncedStyle.Render(fileType), ui.MutedStyle.Render(highlightStatus))
+		ui.SyncedStyle.Render(fileType), ui.MutedStyle.Render(highlightStatus+d.baseStatus()))
+}
+
+// baseStatus names what the local file is compared with
+func (d *DiffView) baseStatus() string {
+	if d.Base == "" {
+		return ""
+	}
+	return " [" + i18n.T("diff.vs", d.Base) + "]"
+}
+
+// dotfilesLabel titles the dotfiles column of the split view
+func (d *DiffView) dotfilesLabel() string {
+	if d.Base == "" {
+		return i18n.T("diff.dotfiles")
+	}
+	return i18n.T("diff.dotfiles") + " @ " + d.Base
 }
 
 // ToggleHighlight toggles syntax highlighting
@@ -143,6 +205,26 @@ func (d *DiffView) ToggleHighlight() {
 	d.enableHighlight = !d.enableHighlight
 }
 
+// ToggleSideBySide switches between the unified and side-by-side views
+func (d *DiffView) ToggleSideBySide() {
+	d.SideBySide = !d.SideBySide
+}
+
+// ToggleStructural switches between the key-level and line diff
+func (d *DiffView) ToggleStructural() {
+	d.Structural = !d.Structural
+}
+
+// structural reports whether the key-level diff is shown
+func (d *DiffView) structural() bool {
+	return d.Structural && d.Structured != nil
+}
+
+// split reports whether the side-by-side view is shown
+func (d *DiffView) split() bool {
+	return d.SideBySide && d.Width >= MinSplitWidth
+}
+
 func (d *DiffView) renderStats() string {
 	if d.DiffResult.Identical {
 		return ui.SyncedStyle.Render("✓ Files are identical")
@@ -156,13 +238,18 @@ func (d *DiffView) renderStats() string {
 		parts = append(parts, d.deleteStyle.Render(fmt.Sprintf("-%d", d.DiffResult.LinesRemoved)))
 	}
 
+	if d.structural() {
+		keys := fmt.Sprintf("%d keys changed", len(d.Structured.Changes))
+		return strings.Join(parts, " ") + "  " + ui.MutedStyle.Render(keys)
+	}
+
 	hunks := fmt.Sprintf("%d hunks", len(d.DiffResult.Hunks))
 	return strings.Join(parts, " ") + "  " + ui.MutedStyle.Render(hunks)
 }
 
 func (d *DiffView) renderDiff() string {
 	if d.DiffResult.Identical {
-		return ui.MutedStyle.Render("No differences found")
+		return ui.MutedStyle.Render(i18n.T("diff.identical"))
 	}
 
 	var lines []string
@@ -187,7 +274,46 @@ func (d *DiffView) renderDiff() string {
 		lines = append(lines, "") // Blank line between hunks
 	}
 
-	// Apply scroll offset
+	return d.scroll(lines)
+}
+
+// renderStructured lists changed keys, one per line
+func (d *DiffView) renderStructured() string {
+	if len(d.Structured.Changes) == 0 {
+		return ui.MutedStyle.Render(i18n.T("diff.no_semantic"))
+	}
+
+	lineWidth := d.Width - 4
+	var lines []string
+	for _, c := range d.Structured.Changes {
+		var line string
+		switch c.Kind {
+		case sync.KeyAdded:
+			line = d.addStyle.Render(clipText("+ "+c.Path+" = "+c.New, lineWidth))
+		case sync.KeyRemoved:
+			line = d.deleteStyle.Render(clipText("- "+c.Path+" = "+c.Old, lineWidth))
+		default:
+			// Split what's left after the path between both values
+			room := max((lineWidth-len(c.Path)-7)/2, 8)
+			line = d.headerStyle.Render("~ "+c.Path+": ") + d.deleteStyle.Render(clipText(c.Old, room)) +
+				ui.MutedStyle.Render(" → ") + d.addStyle.Render(clipText(c.New, room))
+		}
+		lines = append(lines, line)
+	}
+	return d.scroll(lines)
+}
+
+// clipText shortens s to width runes, marking the cut with "..."
+func clipText(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width || width < 4 {
+		return s
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+// scroll returns the lines visible at the current scroll offset
+func (d *DiffView) scroll(lines []string) string {
 	visibleLines := d.Height - 8 // Reserve space for header/footer
 	if visibleLines < 1 {
 		visibleLines = 10
@@ -205,6 +331,246 @@ func (d *DiffView) renderDiff() string {
 	return strings.Join(lines[start:end], "\n")
 }
 
+// renderSplit renders local (left) and dotfiles (right) side by side.
+// Both columns share one scroll offset, so they always stay aligned.
+func (d *DiffView) renderSplit() string {
+	if d.DiffResult.Identical {
+		return ui.MutedStyle.Render(i18n.T("diff.identical"))
+	}
+
+	colWidth := (d.Width - 4 - 3) / 2 // Padding and the " │ " separator
+	sep := ui.MutedStyle.Render(" │ ")
+
+	lines := []string{
+		ui.MutedStyle.Render(padRight(i18n.T("diff.local"), colWidth)) + sep + ui.MutedStyle.Render(d.dotfilesLabel()),
+	}
+	for hunkIdx, hunk := range d.DiffResult.Hunks {
+		hunkHeader := fmt.Sprintf("@@ Hunk %d @@", hunkIdx+1)
+		if hunkIdx == d.CurrentHunk {
+			hunkHeader = ui.SelectedItemStyle.Render(hunkHeader)
+		} else {
+			hunkHeader = ui.MutedStyle.Render(hunkHeader)
+		}
+		lines = append(lines, hunkHeader)
+
+		for _, pair := range pairLines(hunk.DiffLines) {
+			left, right := pair[0], pair[1]
+			var leftSegs, rightSegs []segment
+			if left != nil && right != nil && left.Type == sync.DiffDelete {
+				leftSegs, rightSegs = wordDiff(expandTabs(left.Content), expandTabs(right.Content))
+			}
+			lines = append(lines, d.splitCell(left, leftSegs, colWidth)+sep+d.splitCell(right, rightSegs, colWidth))
+		}
+		lines = append(lines, "")
+	}
+
+	return d.scroll(lines)
+}
+
+// splitCell renders one side of a side-by-side row, padded to width.
+// segs carries the word-level changes when the line has a counterpart.
+func (d *DiffView) splitCell(line *sync.DiffLine, segs []segment, width int) string {
+	if line == nil {
+		return strings.Repeat(" ", width)
+	}
+
+	content := expandTabs(line.Content)
+	if segs == nil {
+		segs = []segment{{text: content, changed: line.Type != sync.DiffEqual}}
+	}
+	segs = truncateSegments(segs, width-2)
+	used := 2
+	for _, s := range segs {
+		used += len([]rune(s.text))
+	}
+	pad := strings.Repeat(" ", max(0, width-used))
+
+	switch line.Type {
+	case sync.DiffEqual:
+		text := segs[0].text
+		if d.enableHighlight && d.highlighter != nil {
+			text = d.highlighter.HighlightLine(text, d.DiffResult.OldPath)
+		}
+		return d.contextStyle.Render("  ") + text + pad
+	case sync.DiffDelete:
+		return d.deleteStyle.Render("- ") + d.renderSegments(segs, d.deleteStyle, d.deleteWordStyle) + pad
+	default:
+		return d.addStyle.Render("+ ") + d.renderSegments(segs, d.addStyle, d.addWordStyle) + pad
+	}
+}
+
+// renderSegments styles changed words with word and the rest with base
+func (d *DiffView) renderSegments(segs []segment, base, word lipgloss.Style) string {
+	var b strings.Builder
+	for _, s := range segs {
+		if s.changed && len(segs) > 1 {
+			b.WriteString(word.Render(s.text))
+		} else {
+			b.WriteString(base.Render(s.text))
+		}
+	}
+	return b.String()
+}
+
+// pairLines lines up a hunk for the side-by-side view: unchanged lines
+// appear on both sides, and a run of deletions is paired line by line with
+// the insertions that follow it. A nil side is blank.
+func pairLines(lines []sync.DiffLine) [][2]*sync.DiffLine {
+	var pairs [][2]*sync.DiffLine
+	for i := 0; i < len(lines); {
+		if lines[i].Type == sync.DiffEqual {
+			pairs = append(pairs, [2]*sync.DiffLine{&lines[i], &lines[i]})
+			i++
+			continue
+		}
+
+		var dels, ins []*sync.DiffLine
+		for ; i < len(lines) && lines[i].Type == sync.DiffDelete; i++ {
+			dels = append(dels, &lines[i])
+		}
+		for ; i < len(lines) && lines[i].Type == sync.DiffInsert; i++ {
+			ins = append(ins, &lines[i])
+		}
+		for j := 0; j < max(len(dels), len(ins)); j++ {
+			var pair [2]*sync.DiffLine
+			if j < len(dels) {
+				pair[0] = dels[j]
+			}
+			if j < len(ins) {
+				pair[1] = ins[j]
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// segment is a run of text that is either shared by both lines or changed
+type segment struct {
+	text    string
+	changed bool
+}
+
+// maxWordDiffCells bounds the LCS table; longer lines are marked changed
+// as a whole
+const maxWordDiffCells = 40000
+
+// wordDiff compares two lines word by word and returns both split into
+// unchanged and changed segments
+func wordDiff(a, b string) ([]segment, []segment) {
+	ta, tb := tokenize(a), tokenize(b)
+	if len(ta)*len(tb) > maxWordDiffCells {
+		return []segment{{a, true}}, []segment{{b, true}}
+	}
+
+	// lcs[i][j] is the LCS length of ta[i:] and tb[j:]
+	lcs := make([][]int, len(ta)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(tb)+1)
+	}
+	for i := len(ta) - 1; i >= 0; i-- {
+		for j := len(tb) - 1; j >= 0; j-- {
+			if ta[i] == tb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var segA, segB []segment
+	i, j := 0, 0
+	for i < len(ta) || j < len(tb) {
+		switch {
+		case i < len(ta) && j < len(tb) && ta[i] == tb[j]:
+			segA = appendSegment(segA, ta[i], false)
+			segB = appendSegment(segB, tb[j], false)
+			i++
+			j++
+		case j < len(tb) && (i == len(ta) || lcs[i][j+1] >= lcs[i+1][j]):
+			segB = appendSegment(segB, tb[j], true)
+			j++
+		default:
+			segA = appendSegment(segA, ta[i], true)
+			i++
+		}
+	}
+	return segA, segB
+}
+
+// appendSegment adds text to segs, merging it into the last segment when
+// both are changed or both unchanged
+func appendSegment(segs []segment, text string, changed bool) []segment {
+	if n := len(segs); n > 0 && segs[n-1].changed == changed {
+		segs[n-1].text += text
+		return segs
+	}
+	return append(segs, segment{text, changed})
+}
+
+// tokenize splits a line into words, runs of spaces and single symbols
+func tokenize(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case isWordRune(runes[i]):
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+		case unicode.IsSpace(runes[i]):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		}
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// truncateSegments cuts segments to width runes, ending with "…" when cut
+func truncateSegments(segs []segment, width int) []segment {
+	total := 0
+	for _, s := range segs {
+		total += len([]rune(s.text))
+	}
+	if total <= width {
+		return segs
+	}
+
+	var out []segment
+	left := max(0, width-1)
+	for _, s := range segs {
+		r := []rune(s.text)
+		if len(r) >= left {
+			out = append(out, segment{string(r[:left]) + "…", s.changed})
+			break
+		}
+		out = append(out, s)
+		left -= len(r)
+	}
+	return out
+}
+
+// expandTabs replaces tabs so column widths can be counted in runes
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
+
+// padRight pads s with spaces to width runes
+func padRight(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
 func (d *DiffView) formatDiffLine(line sync.DiffLine, maxWidth int) string {
 	content := line.Content
 	if len(content) > maxWidth-2 {
@@ -236,14 +602,27 @@ func (d *DiffView) formatDiffLine(line sync.DiffLine, maxWidth int) string {
 
 func (d *DiffView) renderFooter() string {
 	items := []string{
-		ui.RenderHelpItem("j/k", "scroll"),
-		ui.RenderHelpItem("n/N", "next/prev hunk"),
-		ui.RenderHelpItem("1", "keep local"),
-		ui.RenderHelpItem("2", "use dotfiles"),
-		ui.RenderHelpItem("m", "merge"),
-		ui.RenderHelpItem("h", "highlight"),
-		ui.RenderHelpItem("ESC", "close"),
+		ui.RenderHelpItem("j/k", i18n.T("key.scroll")),
+		ui.RenderHelpItem("n/N", i18n.T("key.next_prev_hunk")),
+	}
+	if d.Base == "" {
+		items = append(items,
+			ui.RenderHelpItem("1", i18n.T("key.keep_local")),
+			ui.RenderHelpItem("2", i18n.T("key.use_dotfiles")),
+			ui.RenderHelpItem("m", i18n.T("key.merge")),
+		)
+	}
+	items = append(items,
+		ui.RenderHelpItem("b/B", i18n.T("key.diff_base")),
+		ui.RenderHelpItem("h", i18n.T("key.highlight")),
+		ui.RenderHelpItem("s", i18n.T("key.split")),
+	)
+	if d.Structured != nil {
+		items = append(items, ui.RenderHelpItem("t", i18n.T("key.structural")))
 	}
+	items = append(items,
+		ui.RenderHelpItem("ESC", i18n.T("key.close")),
+	)
 	return ui.HelpBarStyle.Render(strings.Join(items, "  "))
 }
 
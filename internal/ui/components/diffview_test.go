@@ -1,8 +1,12 @@
 package components
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"dotsync/internal/i18n"
 	"dotsync/internal/sync"
 )
 
@@ -164,6 +168,29 @@ func TestDiffView_View(t *testing.T) {
 	}
 }
 
+func TestDiffView_ViewBase(t *testing.T) {
+	dv := NewDiffView()
+	dv.Width = 120
+	dv.DiffResult = &sync.DiffResult{
+		LinesAdded: 1,
+		Hunks:      []sync.DiffHunk{{}},
+	}
+
+	view := dv.View()
+	if !strings.Contains(view, i18n.T("key.keep_local")) {
+		t.Error("Expected keep local in the help bar for the working copy")
+	}
+
+	dv.Base = "HEAD~1"
+	view = dv.View()
+	if !strings.Contains(view, "HEAD~1") {
+		t.Error("Expected the header to name the base revision")
+	}
+	if strings.Contains(view, i18n.T("key.keep_local")) {
+		t.Error("Keep local applies to the working copy only")
+	}
+}
+
 func TestDiffView_ViewIdentical(t *testing.T) {
 	dv := NewDiffView()
 	dv.DiffResult = &sync.DiffResult{
@@ -318,3 +345,127 @@ func TestDiffView_ViewWithHunks(t *testing.T) {
 		t.Error("View should render hunks")
 	}
 }
+
+func TestPairLines(t *testing.T) {
+	lines := []sync.DiffLine{
+		{Type: sync.DiffEqual, Content: "a"},
+		{Type: sync.DiffDelete, Content: "b"},
+		{Type: sync.DiffDelete, Content: "c"},
+		{Type: sync.DiffInsert, Content: "B"},
+		{Type: sync.DiffInsert, Content: "x"},
+		{Type: sync.DiffInsert, Content: "y"},
+		{Type: sync.DiffEqual, Content: "d"},
+	}
+
+	want := [][2]string{{"a", "a"}, {"b", "B"}, {"c", "x"}, {"", "y"}, {"d", "d"}}
+	pairs := pairLines(lines)
+	if len(pairs) != len(want) {
+		t.Fatalf("Expected %d rows, got %d", len(want), len(pairs))
+	}
+	for i, pair := range pairs {
+		var got [2]string
+		for side, line := range pair {
+			if line != nil {
+				got[side] = line.Content
+			}
+		}
+		if got != want[i] {
+			t.Errorf("Row %d: expected %v, got %v", i, want[i], got)
+		}
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	a, b := wordDiff("font_size = 12", "font_size = 14")
+
+	join := func(segs []segment, changed bool) string {
+		var parts []string
+		for _, s := range segs {
+			if s.changed == changed {
+				parts = append(parts, s.text)
+			}
+		}
+		return strings.Join(parts, "|")
+	}
+	if got := join(a, true); got != "12" {
+		t.Errorf("Expected only 12 changed on the left, got %q", got)
+	}
+	if got := join(b, true); got != "14" {
+		t.Errorf("Expected only 14 changed on the right, got %q", got)
+	}
+	if got := join(a, false); got != "font_size = " {
+		t.Errorf("Unexpected unchanged text %q", got)
+	}
+}
+
+func TestTruncateSegments(t *testing.T) {
+	segs := []segment{{"hello ", false}, {"world", true}}
+	out := truncateSegments(segs, 8)
+	if len(out) != 2 || out[1].text != "w…" || !out[1].changed {
+		t.Errorf("Unexpected truncation %+v", out)
+	}
+	if got := truncateSegments(segs, 20); len(got) != 2 || got[1].text != "world" {
+		t.Errorf("Short segments should be unchanged, got %+v", got)
+	}
+}
+
+func TestDiffView_SideBySide(t *testing.T) {
+	dv := NewDiffView()
+	dv.Height = 30
+	dv.DiffResult = &sync.DiffResult{
+		OldPath: "old.conf",
+		NewPath: "new.conf",
+		Hunks: []sync.DiffHunk{{DiffLines: []sync.DiffLine{
+			{Type: sync.DiffDelete, Content: "size = 12"},
+			{Type: sync.DiffInsert, Content: "size = 14"},
+		}}},
+	}
+
+	dv.ToggleSideBySide()
+	dv.Width = 120
+	if view := dv.View(); !strings.Contains(view, "│") {
+		t.Error("Wide terminal should render the split view")
+	}
+
+	dv.Width = 60
+	if view := dv.View(); strings.Contains(view, "│") || !strings.Contains(view, "too narrow") {
+		t.Error("Narrow terminal should fall back to the unified view")
+	}
+}
+
+func TestDiffView_Structural(t *testing.T) {
+	tmpDir := t.TempDir()
+	localPath := filepath.Join(tmpDir, "local.json")
+	dotfilePath := filepath.Join(tmpDir, "dotfiles.json")
+	os.WriteFile(localPath, []byte(`{"a": 1, "b": true}`), 0644)
+	os.WriteFile(dotfilePath, []byte("{\n  \"b\": true,\n  \"a\": 2\n}\n"), 0644)
+
+	result, err := sync.ComputeDiff(localPath, dotfilePath)
+	if err != nil {
+		t.Fatalf("ComputeDiff failed: %v", err)
+	}
+
+	dv := NewDiffView()
+	dv.Height = 30
+	dv.SetDiff(result, localPath, dotfilePath)
+	if dv.Structured == nil {
+		t.Fatal("Expected a structured diff for JSON files")
+	}
+	view := dv.View()
+	if !strings.Contains(view, "[structural]") || !strings.Contains(view, "~ a: ") || !strings.Contains(view, "1 keys changed") {
+		t.Errorf("Expected the key-level diff, got:\n%s", view)
+	}
+
+	dv.ToggleStructural()
+	if view := dv.View(); strings.Contains(view, "[structural]") || !strings.Contains(view, "@@ Hunk 1 @@") {
+		t.Errorf("Expected the line diff after toggling, got:\n%s", view)
+	}
+
+	// Unparseable configs fall back to the line diff
+	os.WriteFile(dotfilePath, []byte("{"), 0644)
+	result, _ = sync.ComputeDiff(localPath, dotfilePath)
+	dv.SetDiff(result, localPath, dotfilePath)
+	if dv.Structured != nil {
+		t.Error("Expected no structured diff for invalid JSON")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
+	"dotsync/internal/i18n"
 	"dotsync/internal/modes"
 	"dotsync/internal/models"
 	"dotsync/internal/ui"
@@ -22,6 +24,48 @@ type TreeNode struct {
 	Depth      int
 	Parent     *TreeNode
 	VisibleIdx int // Index in flattened visible list
+
+	stats *nodeStats // Recursive totals, computed on first use
+}
+
+// nodeStats holds the files under a node and their combined size
+type nodeStats struct {
+	files int
+	size  int64
+}
+
+// Stats returns how many files are under the node and their combined size.
+// Directories sum their subtree once and cache it; the tree is rebuilt
+// whenever the files change. Excluded placeholders count as nothing.
+func (n *TreeNode) Stats() (files int, size int64) {
+	if n.stats == nil {
+		n.stats = &nodeStats{}
+		if !n.IsDir {
+			if n.File != nil && !n.File.Excluded {
+				n.stats.files, n.stats.size = 1, n.File.Size
+			}
+		} else {
+			for _, child := range n.Children {
+				f, sz := child.Stats()
+				n.stats.files += f
+				n.stats.size += sz
+			}
+		}
+	}
+	return n.stats.files, n.stats.size
+}
+
+// FileTotals counts the regular files in a list and their combined size,
+// skipping directory entries and excluded placeholders
+func FileTotals(files []models.File) (count int, size int64) {
+	for _, f := range files {
+		if f.IsDir || f.Excluded {
+			continue
+		}
+		count++
+		size += f.Size
+	}
+	return count, size
 }
 
 // FileList is a list component for files with tree view
@@ -39,6 +83,24 @@ type FileList struct {
 	// Tree structure
 	root         *TreeNode
 	visibleNodes []*TreeNode // Flattened list of visible nodes
+
+	// StatusFilter hides files that are not in the filtered state, and
+	// directories left empty by that
+	StatusFilter models.StatusFilter
+
+	// Truncated marks an app whose files the scan limits cut short
+	Truncated bool
+
+	visual visualRange // Rows being marked in visual mode
+
+	// Linear renders the list without a border or divider, one panel below
+	// the other, for screen readers
+	Linear bool
+
+	// SyncTimes holds the last push or pull of files by RelPath, shown as
+	// their age. SortStale lists the longest unsynced files first.
+	SyncTimes map[string]time.Time
+	SortStale bool
 }
 
 // NewFileList creates a new file list
@@ -60,6 +122,7 @@ func (l *FileList) SetFiles(files []models.File, appName string) {
 	l.Files = files
 	l.AppName = appName
 	l.Cursor = 0
+	l.visual.stop()
 	l.buildTree()
 }
 
@@ -69,6 +132,15 @@ func (l *FileList) SetFilesWithAppID(files []models.File, appName, appID string)
 	l.AppName = appName
 	l.AppID = appID
 	l.Cursor = 0
+	l.visual.stop()
+	l.buildTree()
+}
+
+// SetStatusFilter shows only the files passing f and rebuilds the tree
+func (l *FileList) SetStatusFilter(f models.StatusFilter) {
+	l.StatusFilter = f
+	l.Cursor = 0
+	l.visual.stop()
 	l.buildTree()
 }
 
@@ -81,7 +153,9 @@ func (l *FileList) SetModesConfig(cfg *modes.ModesConfig) {
 func (l *FileList) Clear() {
 	l.Files = []models.File{}
 	l.AppName = ""
+	l.Truncated = false
 	l.Cursor = 0
+	l.visual.stop()
 	l.root = nil
 	l.visibleNodes = nil
 }
@@ -128,7 +202,7 @@ func (l *FileList) buildTree() {
 	// Second pass: add all files
 	for i := range l.Files {
 		file := &l.Files[i]
-		if file.IsDir {
+		if file.IsDir || !l.StatusFilter.Matches(file) {
 			continue
 		}
 
@@ -159,6 +233,10 @@ func (l *FileList) buildTree() {
 		parentNode.Children = append(parentNode.Children, fileNode)
 	}
 
+	if l.StatusFilter != models.FilterNone {
+		pruneEmptyDirs(l.root)
+	}
+
 	// Sort children at each level
 	l.sortChildren(l.root)
 
@@ -166,6 +244,21 @@ func (l *FileList) buildTree() {
 	l.rebuildVisibleNodes()
 }
 
+// pruneEmptyDirs drops the directories without files below them
+func pruneEmptyDirs(node *TreeNode) {
+	children := node.Children[:0]
+	for _, child := range node.Children {
+		if child.IsDir {
+			pruneEmptyDirs(child)
+			if len(child.Children) == 0 {
+				continue
+			}
+		}
+		children = append(children, child)
+	}
+	node.Children = children
+}
+
 // getOrCreateNode gets an existing node or creates directory nodes as needed
 func (l *FileList) getOrCreateNode(nodeMap map[string]*TreeNode, path string, file *models.File) *TreeNode {
 	if node, exists := nodeMap[path]; exists {
@@ -211,6 +304,14 @@ func (l *FileList) sortChildren(node *TreeNode) {
 		if node.Children[i].IsDir != node.Children[j].IsDir {
 			return node.Children[i].IsDir
 		}
+		// Then the least recently synced, never synced first
+		if l.SortStale && node.Children[i].File != nil && node.Children[j].File != nil {
+			ti := l.SyncTimes[node.Children[i].File.RelPath]
+			tj := l.SyncTimes[node.Children[j].File.RelPath]
+			if !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+		}
 		// Then alphabetically
 		return strings.ToLower(node.Children[i].Name) < strings.ToLower(node.Children[j].Name)
 	})
@@ -325,7 +426,7 @@ func (l *FileList) Toggle() {
 			// File - toggle selection
 			node.File.ToggleSelected()
 		}
-	} else if len(l.Files) > 0 && l.Cursor < len(l.Files) {
+	} else if l.StatusFilter == models.FilterNone && len(l.Files) > 0 && l.Cursor < len(l.Files) {
 		l.Files[l.Cursor].ToggleSelected()
 	}
 }
@@ -408,6 +509,7 @@ func (l *FileList) ToggleExpand() {
 		node := l.visibleNodes[l.Cursor]
 		if node.IsDir {
 			node.Expanded = !node.Expanded
+			l.visual.stop()
 			l.rebuildVisibleNodes()
 		}
 	}
@@ -427,17 +529,135 @@ func (l *FileList) DeselectAll() {
 	}
 }
 
+// StartVisual starts marking a range of rows at the cursor
+func (l *FileList) StartVisual() {
+	l.visual.start(l.Cursor)
+}
+
+// CancelVisual leaves visual mode without changing the selection
+func (l *FileList) CancelVisual() {
+	l.visual.stop()
+}
+
+// InVisual reports whether a range is being marked
+func (l *FileList) InVisual() bool {
+	return l.visual.active
+}
+
+// ApplyVisual selects every file in the marked range, or deselects them
+// when they are all selected already, and leaves visual mode. A collapsed
+// directory in the range stands for all files below it. Returns the number
+// of files in the range.
+func (l *FileList) ApplyVisual() int {
+	if !l.visual.active {
+		return 0
+	}
+	l.visual.stop()
+
+	first, last := l.visual.bounds(l.Cursor)
+	var files []*models.File
+	if len(l.visibleNodes) > 0 {
+		for i := first; i <= last && i < len(l.visibleNodes); i++ {
+			node := l.visibleNodes[i]
+			if node.IsDir && !node.Expanded {
+				files = appendNodeFiles(files, node)
+			} else if !node.IsDir && node.File != nil {
+				files = append(files, node.File)
+			}
+		}
+	} else if l.StatusFilter == models.FilterNone {
+		for i := first; i <= last && i < len(l.Files); i++ {
+			files = append(files, &l.Files[i])
+		}
+	}
+	toggleFiles(files)
+	return len(files)
+}
+
+// ToggleVisible selects every file of the app passing the status filter,
+// or deselects them all when they are all selected
+func (l *FileList) ToggleVisible() {
+	var files []*models.File
+	for i := range l.Files {
+		if l.StatusFilter.Matches(&l.Files[i]) {
+			files = append(files, &l.Files[i])
+		}
+	}
+	toggleFiles(files)
+}
+
+// appendNodeFiles appends the files below node
+func appendNodeFiles(files []*models.File, node *TreeNode) []*models.File {
+	if !node.IsDir && node.File != nil {
+		return append(files, node.File)
+	}
+	for _, child := range node.Children {
+		files = appendNodeFiles(files, child)
+	}
+	return files
+}
+
+// toggleFiles selects all files, or deselects them if all are selected
+func toggleFiles(files []*models.File) {
+	all := len(files) > 0
+	for _, f := range files {
+		all = all && f.Selected
+	}
+	for _, f := range files {
+		f.Selected = !all
+	}
+}
+
 // Current returns the currently selected file
 func (l *FileList) Current() *models.File {
 	if len(l.visibleNodes) > 0 && l.Cursor < len(l.visibleNodes) {
 		return l.visibleNodes[l.Cursor].File
 	}
-	if len(l.Files) > 0 && l.Cursor < len(l.Files) {
+	if l.StatusFilter == models.FilterNone && len(l.Files) > 0 && l.Cursor < len(l.Files) {
 		return &l.Files[l.Cursor]
 	}
 	return nil
 }
 
+// FocusPath moves the cursor to the file with relPath, expanding its
+// parent directories. Returns false when no such file is listed.
+func (l *FileList) FocusPath(relPath string) bool {
+	if l.root == nil {
+		for i, f := range l.Files {
+			if f.RelPath == relPath {
+				l.Cursor = i
+				return true
+			}
+		}
+		return false
+	}
+
+	node := findNode(l.root, relPath)
+	if node == nil {
+		return false
+	}
+	for p := node.Parent; p != nil; p = p.Parent {
+		p.Expanded = true
+	}
+	l.visual.stop()
+	l.rebuildVisibleNodes()
+	l.Cursor = node.VisibleIdx
+	return true
+}
+
+// findNode returns the node below n whose file has relPath
+func findNode(n *TreeNode, relPath string) *TreeNode {
+	if n.File != nil && n.File.RelPath == relPath {
+		return n
+	}
+	for _, child := range n.Children {
+		if found := findNode(child, relPath); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
 // CurrentNode returns the current tree node at cursor
 func (l *FileList) CurrentNode() *TreeNode {
 	if len(l.visibleNodes) > 0 && l.Cursor < len(l.visibleNodes) {
@@ -472,20 +692,32 @@ func (l *FileList) View() string {
 	title := l.Title
 	if l.AppName != "" {
 		if selectedCount > 0 {
-			title = fmt.Sprintf("📄 %s (%d/%d)", l.AppName, selectedCount, len(l.Files))
+			_, selectedSize := FileTotals(l.SelectedFiles())
+			title = fmt.Sprintf("📄 %s (%d/%d · %s)", l.AppName, selectedCount, len(l.Files), FormatBytes(selectedSize))
 		} else if len(l.Files) > 0 {
 			title = fmt.Sprintf("📄 %s (%d)", l.AppName, len(l.Files))
 		} else {
 			title = fmt.Sprintf("📄 %s", l.AppName)
 		}
 	}
+	if l.StatusFilter != models.FilterNone {
+		title += " · " + i18n.T("filter."+l.StatusFilter.String())
+	}
+	if l.Truncated {
+		title += " · ✂ " + i18n.T("files.truncated")
+	}
+	if l.Linear && l.Focused {
+		title += " " + i18n.T("panel.focused")
+	}
 	b.WriteString(ui.PanelTitleStyle.Render(title))
 	b.WriteString("\n")
-	b.WriteString(ui.DividerStyle.Render(strings.Repeat("─", l.Width-2)))
-	b.WriteString("\n")
+	if !l.Linear {
+		b.WriteString(ui.DividerStyle.Render(strings.Repeat("─", l.Width-2)))
+		b.WriteString("\n")
+	}
 
 	if len(l.Files) == 0 {
-		b.WriteString(ui.ItemStyle.Render("Select an app to see files"))
+		b.WriteString(ui.ItemStyle.Render(i18n.T("files.select_app")))
 		return l.wrapInPanel(b.String())
 	}
 
@@ -493,6 +725,10 @@ func (l *FileList) View() string {
 	if len(l.visibleNodes) > 0 {
 		return l.renderTreeView(&b)
 	}
+	if l.StatusFilter != models.FilterNone {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("files.none_filtered")))
+		return l.wrapInPanel(b.String())
+	}
 
 	// Fallback to flat view
 	return l.renderFlatView(&b)
@@ -510,14 +746,14 @@ func (l *FileList) renderTreeView(b *strings.Builder) string {
 
 	// Show scroll indicator at top
 	if startIdx > 0 {
-		b.WriteString(MutedStyle.Render("  ↑ more"))
+		b.WriteString(ui.MutedStyle.Render("  ↑ more"))
 		b.WriteString("\n")
 	}
 
 	// Render visible items
 	for i := startIdx; i < endIdx; i++ {
 		node := l.visibleNodes[i]
-		line := l.renderTreeNode(node, i == l.Cursor)
+		line := l.renderTreeNode(node, i == l.Cursor, l.visual.contains(i, l.Cursor))
 		b.WriteString(line)
 		if i < endIdx-1 {
 			b.WriteString("\n")
@@ -527,21 +763,21 @@ func (l *FileList) renderTreeView(b *strings.Builder) string {
 	// Show scroll indicator at bottom
 	if endIdx < len(l.visibleNodes) {
 		b.WriteString("\n")
-		b.WriteString(MutedStyle.Render("  ↓ more"))
+		b.WriteString(ui.MutedStyle.Render("  ↓ more"))
 	}
 
 	// Add position indicator when scrolling
 	if len(l.visibleNodes) > visibleHeight {
 		position := fmt.Sprintf(" %d/%d ", l.Cursor+1, len(l.visibleNodes))
 		b.WriteString("\n")
-		b.WriteString(MutedStyle.Render(strings.Repeat(" ", (l.Width-len(position)-4)/2) + position))
+		b.WriteString(ui.MutedStyle.Render(strings.Repeat(" ", (l.Width-len(position)-4)/2) + position))
 	}
 
 	return l.wrapInPanel(b.String())
 }
 
 // renderTreeNode renders a single tree node
-func (l *FileList) renderTreeNode(node *TreeNode, isCursor bool) string {
+func (l *FileList) renderTreeNode(node *TreeNode, isCursor, inRange bool) string {
 	// Build tree prefix
 	indent := strings.Repeat("  ", node.Depth)
 
@@ -587,13 +823,29 @@ func (l *FileList) renderTreeNode(node *TreeNode, isCursor bool) string {
 	} else if node.File != nil {
 		checkbox = ui.RenderCheckbox(node.File.Selected)
 	}
+	if node.File != nil && node.File.Excluded {
+		checkbox = ui.MutedStyle.Render("[⊘]")
+	}
 
 	// File/dir name with expand indicator for directories
 	name := node.Name
 	if node.IsDir && expandIndicator != "" {
 		name = expandIndicator + " " + name
 	}
-	maxNameLen := l.Width - 18 - (node.Depth * 2)
+	// Recursive totals: file count and size for directories, size for files
+	stats := ""
+	files, size := node.Stats()
+	switch {
+	case node.File != nil && node.File.Excluded:
+	case node.IsDir:
+		stats = fmt.Sprintf("%d · %s", files, FormatBytes(size))
+	case files > 0:
+		stats = FormatBytes(size)
+		if synced, ok := l.SyncTimes[node.File.RelPath]; ok && !synced.IsZero() {
+			stats += " · " + ShortAge(synced)
+		}
+	}
+	maxNameLen := l.Width - 18 - (node.Depth * 2) - len(stats)
 	if maxNameLen < 10 {
 		maxNameLen = 10
 	}
@@ -617,11 +869,20 @@ func (l *FileList) renderTreeNode(node *TreeNode, isCursor bool) string {
 		}
 	}
 
-	if node.File != nil {
+	if node.File != nil && node.File.Excluded {
+		// Excluded subtree placeholder - not synced, no status
+		suffix = " " + ui.MutedStyle.Render(i18n.T("files.excluded"))
+	} else if node.File != nil {
 		// Add encrypted indicator
 		if node.File.Encrypted {
 			suffix = " " + ui.EncryptedStyle.Render("🔒")
 		}
+		if node.File.LinkTarget != "" {
+			suffix += " " + ui.MutedStyle.Render("→ "+node.File.LinkTarget)
+		}
+		if node.File.Large() {
+			suffix += " " + ui.LargeFileStyle.Render("⚠ "+FormatBytes(node.File.Size))
+		}
 
 		// Status based on conflict type
 		statusIcon = node.File.ConflictType.ConflictIcon()
@@ -663,7 +924,7 @@ func (l *FileList) renderTreeNode(node *TreeNode, isCursor bool) string {
 	} else {
 		content = fmt.Sprintf("%s%s %s%s %s%s %s",
 			indent,
-			MutedStyle.Render(connector),
+			ui.MutedStyle.Render(connector),
 			checkbox,
 			icon,
 			ui.FileNameStyle.Render(name),
@@ -676,10 +937,16 @@ func (l *FileList) renderTreeNode(node *TreeNode, isCursor bool) string {
 	if statusIcon != "" {
 		content += " " + statusStyle.Render(statusIcon)
 	}
+	if stats != "" {
+		content += " " + ui.MutedStyle.Render(stats)
+	}
 
 	if isCursor && l.Focused {
 		return ui.SelectedItemStyle.Width(l.Width - 4).Render(content)
 	}
+	if inRange {
+		return ui.VisualItemStyle.Width(l.Width - 4).Render(content)
+	}
 	return ui.ItemStyle.Render(content)
 }
 
@@ -693,13 +960,13 @@ func (l *FileList) renderFlatView(b *strings.Builder) string {
 	endIdx := min(startIdx+visibleHeight, len(l.Files))
 
 	if startIdx > 0 {
-		b.WriteString(MutedStyle.Render("  ↑ more"))
+		b.WriteString(ui.MutedStyle.Render("  ↑ more"))
 		b.WriteString("\n")
 	}
 
 	for i := startIdx; i < endIdx; i++ {
 		file := l.Files[i]
-		line := l.renderItem(&file, i == l.Cursor)
+		line := l.renderItem(&file, i == l.Cursor, l.visual.contains(i, l.Cursor))
 		b.WriteString(line)
 		if i < endIdx-1 {
 			b.WriteString("\n")
@@ -708,20 +975,20 @@ func (l *FileList) renderFlatView(b *strings.Builder) string {
 
 	if endIdx < len(l.Files) {
 		b.WriteString("\n")
-		b.WriteString(MutedStyle.Render("  ↓ more"))
+		b.WriteString(ui.MutedStyle.Render("  ↓ more"))
 	}
 
 	if len(l.Files) > visibleHeight {
 		position := fmt.Sprintf(" %d/%d ", l.Cursor+1, len(l.Files))
 		b.WriteString("\n")
-		b.WriteString(MutedStyle.Render(strings.Repeat(" ", (l.Width-len(position)-4)/2) + position))
+		b.WriteString(ui.MutedStyle.Render(strings.Repeat(" ", (l.Width-len(position)-4)/2) + position))
 	}
 
 	return l.wrapInPanel(b.String())
 }
 
 // renderItem renders a single file item (for flat view)
-func (l *FileList) renderItem(file *models.File, isCursor bool) string {
+func (l *FileList) renderItem(file *models.File, isCursor, inRange bool) string {
 	checkbox := ui.RenderCheckbox(file.Selected)
 	icon := file.Icon()
 
@@ -738,6 +1005,16 @@ func (l *FileList) renderItem(file *models.File, isCursor bool) string {
 	if file.Encrypted {
 		suffix = " " + ui.EncryptedStyle.Render("lock")
 	}
+	if file.LinkTarget != "" {
+		suffix += " " + ui.MutedStyle.Render("→ "+file.LinkTarget)
+	}
+	if file.Large() {
+		suffix += " " + ui.LargeFileStyle.Render("⚠ "+FormatBytes(file.Size))
+	}
+	if file.Excluded {
+		checkbox = ui.MutedStyle.Render("[⊘]")
+		suffix = " " + ui.MutedStyle.Render(i18n.T("files.excluded"))
+	}
 
 	// Mode indicator
 	modeIndicator := ""
@@ -783,15 +1060,24 @@ func (l *FileList) renderItem(file *models.File, isCursor bool) string {
 		modeIndicator,
 		statusStyle.Render(statusIcon),
 	)
+	if synced, ok := l.SyncTimes[file.RelPath]; ok && !synced.IsZero() {
+		content += " " + ui.MutedStyle.Render(ShortAge(synced))
+	}
 
 	if isCursor && l.Focused {
 		return ui.SelectedItemStyle.Width(l.Width - 4).Render(content)
 	}
+	if inRange {
+		return ui.VisualItemStyle.Width(l.Width - 4).Render(content)
+	}
 	return ui.ItemStyle.Render(content)
 }
 
 // wrapInPanel wraps content in a panel border
 func (l *FileList) wrapInPanel(content string) string {
+	if l.Linear {
+		return content
+	}
 	style := ui.PanelStyle
 	if l.Focused {
 		style = ui.ActivePanelStyle
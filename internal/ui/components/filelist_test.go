@@ -1,7 +1,9 @@
 package components
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"dotsync/internal/models"
 )
@@ -411,3 +413,178 @@ func TestFileList_MoveDown_EmptyList(t *testing.T) {
 		t.Errorf("Cursor should stay at 0")
 	}
 }
+
+func TestFileList_ApplyVisual(t *testing.T) {
+	list := NewFileList()
+	list.SetFiles([]models.File{
+		{Name: "a", RelPath: "a"},
+		{Name: "b", RelPath: "b"},
+		{Name: "c", RelPath: "c"},
+		{Name: "d", RelPath: "d"},
+	}, "app")
+
+	list.Cursor = 1
+	list.StartVisual()
+	list.MoveDown()
+	if !list.InVisual() {
+		t.Fatal("Expected visual mode")
+	}
+	if n := list.ApplyVisual(); n != 2 {
+		t.Errorf("Expected 2 files in range, got %d", n)
+	}
+	for i, want := range []bool{false, true, true, false} {
+		if list.Files[i].Selected != want {
+			t.Errorf("File %d: expected selected=%v", i, want)
+		}
+	}
+	if list.InVisual() {
+		t.Error("ApplyVisual should leave visual mode")
+	}
+
+	// The same range again deselects it
+	list.StartVisual()
+	list.MoveUp()
+	list.ApplyVisual()
+	if list.Files[1].Selected || list.Files[2].Selected {
+		t.Error("A fully selected range should be deselected")
+	}
+}
+
+func TestFileList_ApplyVisual_CollapsedDir(t *testing.T) {
+	list := NewFileList()
+	list.SetFiles([]models.File{
+		{Name: "init.lua", RelPath: "lua/init.lua"},
+		{Name: "opts.lua", RelPath: "lua/opts.lua"},
+		{Name: "top", RelPath: "top"},
+	}, "nvim")
+
+	list.ToggleExpand() // Collapse lua/ under the cursor
+	list.StartVisual()
+	if n := list.ApplyVisual(); n != 2 {
+		t.Errorf("A collapsed directory should stand for its 2 files, got %d", n)
+	}
+}
+
+func TestFileList_ToggleVisible(t *testing.T) {
+	list := NewFileList()
+	list.Files = []models.File{
+		{Name: "file1.txt", Selected: true},
+		{Name: "file2.txt"},
+	}
+
+	list.ToggleVisible()
+	if !list.Files[0].Selected || !list.Files[1].Selected {
+		t.Error("ToggleVisible should select every file")
+	}
+	list.ToggleVisible()
+	if list.Files[0].Selected || list.Files[1].Selected {
+		t.Error("ToggleVisible should deselect when all are selected")
+	}
+}
+
+func TestFileList_FocusPath(t *testing.T) {
+	list := NewFileList()
+	list.SetFiles([]models.File{
+		{Name: "init.lua", RelPath: "lua/init.lua"},
+		{Name: "opts.lua", RelPath: "lua/opts.lua"},
+		{Name: "top", RelPath: "top"},
+	}, "nvim")
+	list.ToggleExpand() // Collapse lua/
+
+	if !list.FocusPath("lua/opts.lua") {
+		t.Fatal("FocusPath should find lua/opts.lua")
+	}
+	if f := list.Current(); f == nil || f.RelPath != "lua/opts.lua" {
+		t.Errorf("Cursor should be on lua/opts.lua, got %+v", f)
+	}
+	if list.FocusPath("missing") {
+		t.Error("FocusPath should fail for an unknown path")
+	}
+}
+
+func TestFileList_NodeStats(t *testing.T) {
+	list := NewFileList()
+	list.SetFiles([]models.File{
+		{Name: "nvim", RelPath: "nvim", IsDir: true, Size: 4096},
+		{Name: "init.lua", RelPath: "nvim/init.lua", Size: 1000},
+		{Name: "keys.lua", RelPath: "nvim/lua/keys.lua", Size: 500},
+		{Name: "plugins", RelPath: "nvim/plugins", IsDir: true, Excluded: true},
+		{Name: "extra.lua", RelPath: "nvim/lua/extra.lua", Size: 24, Selected: true},
+	}, "Neovim")
+
+	root := list.visibleNodes[0]
+	if files, size := root.Stats(); files != 3 || size != 1524 {
+		t.Errorf("Expected 3 files / 1524 bytes under nvim, got %d / %d", files, size)
+	}
+	var lua *TreeNode
+	for _, n := range list.visibleNodes {
+		if n.Name == "lua" {
+			lua = n
+		}
+	}
+	if lua == nil {
+		t.Fatal("Expected a nvim/lua node")
+	}
+	if files, size := lua.Stats(); files != 2 || size != 524 {
+		t.Errorf("Expected 2 files / 524 bytes under lua, got %d / %d", files, size)
+	}
+
+	if count, size := FileTotals(list.SelectedFiles()); count != 1 || size != 24 {
+		t.Errorf("Expected the selection total to be 1 file / 24 bytes, got %d / %d", count, size)
+	}
+}
+
+func TestFileList_StatusFilter(t *testing.T) {
+	list := NewFileList()
+	list.SetFiles([]models.File{
+		{Name: "nvim", RelPath: "nvim", IsDir: true},
+		{Name: "init.lua", RelPath: "nvim/init.lua", ConflictType: models.ConflictBothModified},
+		{Name: "keys.lua", RelPath: "nvim/lua/keys.lua"},
+		{Name: "opts.lua", RelPath: "nvim/lua/opts.lua", ConflictType: models.ConflictDotfilesModified},
+		{Name: "README", RelPath: "README"},
+	}, "Neovim")
+
+	list.SetStatusFilter(models.FilterConflicts)
+	if len(list.visibleNodes) != 2 || list.visibleNodes[1].Name != "init.lua" {
+		t.Fatalf("Expected nvim/init.lua only, got %d nodes", len(list.visibleNodes))
+	}
+
+	list.SetStatusFilter(models.FilterChanged)
+	if len(list.visibleNodes) != 4 {
+		t.Errorf("Expected nvim, lua and two changed files, got %d nodes", len(list.visibleNodes))
+	}
+	list.ToggleVisible()
+	if count := len(list.SelectedFiles()); count != 2 {
+		t.Errorf("Expected only the shown files selected, got %d", count)
+	}
+
+	list.SetFiles([]models.File{{Name: "README", RelPath: "README"}}, "Other")
+	if len(list.visibleNodes) != 0 || list.Current() != nil {
+		t.Error("Expected nothing listed when no file matches")
+	}
+}
+
+func TestFileList_SortStale(t *testing.T) {
+	list := NewFileList()
+	list.SyncTimes = map[string]time.Time{
+		"a.conf": time.Now().Add(-time.Hour),
+		"b.conf": time.Now().Add(-48 * time.Hour),
+	}
+	list.SortStale = true
+	list.SetFiles([]models.File{
+		{Name: "a.conf", RelPath: "a.conf", Size: 1},
+		{Name: "b.conf", RelPath: "b.conf", Size: 1},
+		{Name: "c.conf", RelPath: "c.conf", Size: 1},
+	}, "app")
+
+	var order []string
+	for _, n := range list.visibleNodes {
+		order = append(order, n.Name)
+	}
+	if strings.Join(order, ",") != "c.conf,b.conf,a.conf" {
+		t.Errorf("Expected never synced, then the oldest first, got %v", order)
+	}
+	if view := list.View(); !strings.Contains(view, "2d") || !strings.Contains(view, "1h") {
+		t.Errorf("Expected the ages of synced files:\n%s", view)
+	}
+}
@@ -1,11 +1,15 @@
 package components
 
 import (
+	"encoding/hex"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"dotsync/internal/termimg"
 	"dotsync/internal/ui"
 
 	"github.com/charmbracelet/bubbles/viewport"
@@ -31,6 +35,12 @@ type FilePreview struct {
 	// State
 	ready bool
 
+	// Image thumbnails
+	ImageProtocol termimg.Protocol // Graphics protocol of the terminal
+	thumbnail     string           // Escape sequence drawing the current image
+	thumbRows     int              // Rows the thumbnail covers
+	imageInfo     string           // Metadata shown below the thumbnail
+
 	// Styles
 	lineNumStyle lipgloss.Style
 	headerStyle  lipgloss.Style
@@ -44,25 +54,32 @@ func NewFilePreview() *FilePreview {
 	vp.MouseWheelEnabled = true
 	vp.MouseWheelDelta = 3
 
-	return &FilePreview{
-		viewport:    vp,
-		highlighter: ui.NewHighlighter(),
-		Width:       80,
-		Height:      20,
-		lineNumStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6c7086")).
-			Width(5).
-			Align(lipgloss.Right),
-		headerStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#89b4fa")),
-		infoStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6c7086")),
-		borderStyle: lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#89b4fa")).
-			Padding(0, 1),
+	p := &FilePreview{
+		viewport:      vp,
+		highlighter:   ui.NewHighlighter(),
+		Width:         80,
+		Height:        20,
+		ImageProtocol: termimg.Detect(),
 	}
+	p.ApplyTheme()
+	return p
+}
+
+// ApplyTheme rebuilds the preview's styles from the active ui theme
+func (p *FilePreview) ApplyTheme() {
+	p.lineNumStyle = lipgloss.NewStyle().
+		Foreground(ui.Subtle).
+		Width(5).
+		Align(lipgloss.Right)
+	p.headerStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Info)
+	p.infoStyle = lipgloss.NewStyle().
+		Foreground(ui.Subtle)
+	p.borderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Info).
+		Padding(0, 1)
 }
 
 // SetSize updates the viewport dimensions
@@ -93,10 +110,23 @@ func (p *FilePreview) Load(path string) error {
 		return err
 	}
 
+	p.thumbnail, p.thumbRows = "", 0
 	if info.IsDir() {
 		return p.loadDirectory(path)
 	}
 
+	head, err := readHead(path, 512)
+	if err != nil {
+		return err
+	}
+	if termimg.Format(head) != "" && info.Size() <= maxImageSize {
+		return p.loadImage(path, info)
+	}
+	if isBinaryContent(head) {
+		p.setBinary(path, info, head)
+		return nil
+	}
+
 	// Check file size - don't load huge files
 	if info.Size() > 1024*1024 { // 1MB limit
 		p.setMessage(path, info.Size(), []string{
@@ -117,13 +147,7 @@ func (p *FilePreview) Load(path string) error {
 
 	// Check if binary
 	if isBinaryContent(data) {
-		p.setMessage(path, info.Size(), []string{
-			"",
-			"  ⚠️  Binary file - cannot preview",
-			fmt.Sprintf("  Size: %s", formatBytes(info.Size())),
-			"",
-			"  Use an external editor to view this file.",
-		})
+		p.setBinary(path, info, data)
 		return nil
 	}
 
@@ -173,6 +197,86 @@ func (p *FilePreview) Load(path string) error {
 	return nil
 }
 
+// maxImageSize is the largest image decoded for a thumbnail
+const maxImageSize = 32 * 1024 * 1024
+
+// hexDumpBytes is how much of a binary file the summary dumps
+const hexDumpBytes = 256
+
+// readHead reads up to n bytes from the start of a file
+func readHead(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// loadImage shows an image's metadata and, when the terminal supports a
+// graphics protocol, a thumbnail
+func (p *FilePreview) loadImage(path string, info os.FileInfo) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Leave room for the metadata below the thumbnail
+	cols := max(p.Width-6, 1)
+	rows := max(p.viewport.Height-3, 1)
+	thumb, thumbRows, size, err := termimg.Thumbnail(p.ImageProtocol, data, cols, rows)
+	if err != nil {
+		p.setBinary(path, info, data)
+		return nil
+	}
+
+	lines := []string{
+		"",
+		fmt.Sprintf("  🖼  %s image  %d×%d", strings.ToUpper(termimg.Format(data)), size.X, size.Y),
+		fmt.Sprintf("  Size: %s", formatBytes(info.Size())),
+	}
+	if thumb == "" {
+		lines = append(lines, "",
+			fmt.Sprintf("  Inline thumbnails need a kitty or sixel terminal (set %s=kitty|sixel to force).", termimg.Env))
+	}
+	p.setMessage(path, info.Size(), lines)
+	p.thumbnail, p.thumbRows = thumb, thumbRows
+	p.imageInfo = strings.Join(lines, "\n")
+	return nil
+}
+
+// setBinary shows a metadata summary and hex dump of a binary file
+func (p *FilePreview) setBinary(path string, info os.FileInfo, data []byte) {
+	lines := []string{
+		"",
+		"  ⚠️  Binary file",
+		fmt.Sprintf("  Size:     %s", formatBytes(info.Size())),
+		fmt.Sprintf("  Type:     %s", http.DetectContentType(data)),
+		fmt.Sprintf("  Mode:     %s", info.Mode()),
+		fmt.Sprintf("  Modified: %s", info.ModTime().Format("2006-01-02 15:04")),
+		"",
+	}
+
+	dump := data[:min(len(data), hexDumpBytes)]
+	lines = append(lines, fmt.Sprintf("  First %d bytes:", len(dump)))
+	for _, line := range strings.Split(strings.TrimRight(hex.Dump(dump), "\n"), "\n") {
+		lines = append(lines, "  "+line)
+	}
+	p.setMessage(path, info.Size(), lines)
+}
+
+// HasImage reports whether a thumbnail is drawn; the screen must be
+// cleared when leaving the preview so the terminal drops it
+func (p *FilePreview) HasImage() bool {
+	return p.thumbnail != ""
+}
+
 // loadDirectory shows directory contents
 func (p *FilePreview) loadDirectory(path string) error {
 	entries, err := os.ReadDir(path)
@@ -242,11 +346,19 @@ func (p *FilePreview) View() string {
 
 	// Separator
 	b.WriteString(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#313244")).
+		Foreground(ui.Surface).
 		Render(strings.Repeat("─", p.Width-4)) + "\n")
 
-	// Viewport content
-	b.WriteString(p.viewport.View())
+	// Thumbnail above the metadata; the cursor is restored after drawing so
+	// the reserved rows below it keep the layout intact
+	if p.thumbnail != "" {
+		b.WriteString("\x1b7" + p.thumbnail + "\x1b8")
+		b.WriteString(strings.Repeat("\n", p.thumbRows))
+		b.WriteString(p.imageInfo)
+	} else {
+		// Viewport content
+		b.WriteString(p.viewport.View())
+	}
 
 	// Scroll indicator
 	if p.TotalLines > p.viewport.Height {
@@ -315,6 +427,11 @@ func isBinaryContent(data []byte) bool {
 	return float64(nonPrintable)/float64(checkLen) > 0.3
 }
 
+// FormatBytes formats bytes to human readable string
+func FormatBytes(bytes int64) string {
+	return formatBytes(bytes)
+}
+
 // formatBytes formats bytes to human readable string
 func formatBytes(bytes int64) string {
 	const unit = 1024
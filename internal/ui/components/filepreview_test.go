@@ -1,9 +1,15 @@
 package components
 
 import (
+	"bytes"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"dotsync/internal/termimg"
 )
 
 func TestNewFilePreview(t *testing.T) {
@@ -265,4 +271,39 @@ func TestFilePreview_LoadBinaryFile(t *testing.T) {
 	if view == "" {
 		t.Error("View should not be empty for binary file")
 	}
+	if !strings.Contains(view, "00 01 02 03 04") {
+		t.Error("Binary summary should include a hex dump")
+	}
+}
+
+func TestFilePreview_LoadImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "wallpaper.png")
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 64, 32)))
+	os.WriteFile(tmpFile, buf.Bytes(), 0644)
+
+	tests := []struct {
+		protocol  termimg.Protocol
+		wantImage bool
+	}{
+		{termimg.None, false},
+		{termimg.Kitty, true},
+		{termimg.Sixel, true},
+	}
+
+	for _, tt := range tests {
+		fp := NewFilePreview()
+		fp.ImageProtocol = tt.protocol
+		fp.SetSize(80, 30)
+		if err := fp.Load(tmpFile); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if fp.HasImage() != tt.wantImage {
+			t.Errorf("%s: HasImage = %v, want %v", tt.protocol, fp.HasImage(), tt.wantImage)
+		}
+		if view := fp.View(); !strings.Contains(view, "PNG image  64×32") {
+			t.Errorf("%s: view should show image metadata", tt.protocol)
+		}
+	}
 }
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"dotsync/internal/branchflow"
 	"dotsync/internal/git"
+	"dotsync/internal/i18n"
 	"dotsync/internal/ui"
 
 	"github.com/charmbracelet/lipgloss"
@@ -20,6 +22,11 @@ type GitPanel struct {
 	Commits  []git.CommitInfo
 	Branches []string
 
+	// Flow is set in the branch-per-machine workflow: push publishes the
+	// machine branch and pull merges main into it
+	Flow  *branchflow.Flow
+	Drift []branchflow.Drift
+
 	Cursor       int
 	ScrollOffset int
 	Mode         GitPanelMode
@@ -47,23 +54,22 @@ const (
 
 // NewGitPanel creates a new GitPanel
 func NewGitPanel() *GitPanel {
-	return &GitPanel{
+	g := &GitPanel{
 		Width:  80,
 		Height: 20,
 		Mode:   ModeStatus,
-		headerStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#89b4fa")),
-		stagedStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#a6e3a1")),
-		modifiedStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#f9e2af")),
-		untrackedStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6c7086")),
-		branchStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#cba6f7")).
-			Bold(true),
 	}
+	g.ApplyTheme()
+	return g
+}
+
+// ApplyTheme rebuilds the panel's styles from the active ui theme
+func (g *GitPanel) ApplyTheme() {
+	g.headerStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.Info)
+	g.stagedStyle = lipgloss.NewStyle().Foreground(ui.Added)
+	g.modifiedStyle = lipgloss.NewStyle().Foreground(ui.Warning)
+	g.untrackedStyle = lipgloss.NewStyle().Foreground(ui.Subtle)
+	g.branchStyle = lipgloss.NewStyle().Foreground(ui.Accent).Bold(true)
 }
 
 // SetRepo sets the git repository
@@ -90,6 +96,11 @@ func (g *GitPanel) Refresh() {
 
 	// Load branches
 	g.Branches = g.Repo.Branches()
+
+	g.Drift = nil
+	if g.Flow != nil {
+		g.Drift, _ = g.Flow.Drift()
+	}
 }
 
 // MoveUp moves cursor up
@@ -119,7 +130,7 @@ func (g *GitPanel) ScrollDown() {
 // View renders the git panel
 func (g *GitPanel) View() string {
 	if g.Repo == nil {
-		return "No repository configured"
+		return i18n.T("git.no_repo")
 	}
 
 	var b strings.Builder
@@ -142,6 +153,10 @@ func (g *GitPanel) View() string {
 		// Recent commits
 		commitsSection := g.renderCommits()
 		b.WriteString(commitsSection)
+
+		if g.Flow != nil {
+			b.WriteString(g.renderDrift())
+		}
 	}
 
 	b.WriteString("\n")
@@ -179,7 +194,7 @@ func (g *GitPanel) renderHeader() string {
 func (g *GitPanel) renderStatus() string {
 	var b strings.Builder
 
-	b.WriteString(ui.PanelTitleStyle.Render("Changes"))
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("git.changes")))
 	b.WriteString("\n")
 
 	if g.Status == nil {
@@ -225,7 +240,7 @@ func (g *GitPanel) renderCommits() string {
 	var b strings.Builder
 
 	b.WriteString("\n")
-	b.WriteString(ui.PanelTitleStyle.Render("Recent Commits"))
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("git.commits")))
 	b.WriteString("\n")
 
 	if len(g.Commits) == 0 {
@@ -245,36 +260,70 @@ func (g *GitPanel) renderCommits() string {
 	return b.String()
 }
 
+// renderDrift lists the machine branches with what they haven't merged
+// into main and what they miss from it
+func (g *GitPanel) renderDrift() string {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("git.machines", g.Flow.Main)))
+	b.WriteString("\n")
+
+	if len(g.Drift) == 0 {
+		b.WriteString(ui.MutedStyle.Render("  " + i18n.T("git.machines.none", g.Flow.Main)))
+		return b.String()
+	}
+
+	for _, d := range g.Drift {
+		name := d.Branch
+		if d.Current {
+			name = g.branchStyle.Render(name + " ✓")
+		}
+		drift := g.stagedStyle.Render(i18n.T("git.machines.merged"))
+		if d.Ahead > 0 || d.Behind > 0 {
+			drift = g.modifiedStyle.Render(i18n.T("git.machines.drift", d.Ahead, d.Behind))
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n", name, drift))
+	}
+
+	return b.String()
+}
+
 func (g *GitPanel) renderFooter() string {
 	var items []string
 
 	switch g.Mode {
 	case ModeBranches:
 		items = []string{
-			ui.RenderHelpItem("↑/↓", "navigate"),
-			ui.RenderHelpItem("Enter", "checkout"),
-			ui.RenderHelpItem("b", "back to status"),
-			ui.RenderHelpItem("ESC", "close"),
+			ui.RenderHelpItem("↑/↓", i18n.T("key.navigate")),
+			ui.RenderHelpItem("Enter", i18n.T("key.checkout")),
+			ui.RenderHelpItem("b", i18n.T("key.back_to_status")),
+			ui.RenderHelpItem("ESC", i18n.T("key.close")),
 		}
 	default:
 		// Highlight push if there are commits ahead
 		pushLabel := "push"
 		if g.Status != nil && g.Status.Ahead > 0 {
-			pushLabel = fmt.Sprintf("push ↑%d", g.Status.Ahead)
+			pushLabel = i18n.T("key.push_ahead", g.Status.Ahead)
 		}
 
 		items = []string{
-			ui.RenderHelpItem("a", "add all"),
-			ui.RenderHelpItem("c", "commit"),
+			ui.RenderHelpItem("a", i18n.T("key.add_all")),
+			ui.RenderHelpItem("c", i18n.T("key.commit")),
 			ui.RenderHelpItem("p", pushLabel),
-			ui.RenderHelpItem("f", "fetch"),
-			ui.RenderHelpItem("l", "pull"),
-			ui.RenderHelpItem("s", "stash"),
-			ui.RenderHelpItem("b", "branches"),
-			ui.RenderHelpItem("L", "lazygit"),
-			ui.RenderHelpItem("r", "refresh"),
-			ui.RenderHelpItem("ESC", "back"),
+			ui.RenderHelpItem("f", i18n.T("key.fetch")),
+			ui.RenderHelpItem("l", i18n.T("key.pull")),
+			ui.RenderHelpItem("s", i18n.T("key.stash")),
+			ui.RenderHelpItem("b", i18n.T("key.branches")),
 		}
+		if g.Flow != nil {
+			items = append(items, ui.RenderHelpItem("M", i18n.T("key.merge_main", g.Flow.Main)))
+		}
+		items = append(items,
+			ui.RenderHelpItem("L", i18n.T("key.lazygit")),
+			ui.RenderHelpItem("r", i18n.T("key.refresh")),
+			ui.RenderHelpItem("ESC", i18n.T("key.back")),
+		)
 	}
 
 	return ui.HelpBarStyle.Render(strings.Join(items, "  "))
@@ -331,7 +380,12 @@ func (g *GitPanel) Push() error {
 	if g.Repo == nil {
 		return fmt.Errorf("no repository")
 	}
-	err := g.Repo.Push()
+	var err error
+	if g.Flow != nil {
+		err = g.Flow.Publish()
+	} else {
+		err = g.Repo.Push()
+	}
 	if err == nil {
 		g.Refresh()
 	}
@@ -343,13 +397,29 @@ func (g *GitPanel) Pull() error {
 	if g.Repo == nil {
 		return fmt.Errorf("no repository")
 	}
-	err := g.Repo.Pull()
+	var err error
+	if g.Flow != nil {
+		err = g.Flow.Update()
+	} else {
+		err = g.Repo.Pull()
+	}
 	if err == nil {
 		g.Refresh()
 	}
 	return err
 }
 
+// MergeToMain merges this machine's branch into main in the
+// branch-per-machine workflow
+func (g *GitPanel) MergeToMain() error {
+	if g.Flow == nil {
+		return fmt.Errorf("branch per machine is off")
+	}
+	err := g.Flow.MergeToMain()
+	g.Refresh()
+	return err
+}
+
 // Fetch fetches from remote
 func (g *GitPanel) Fetch() error {
 	if g.Repo == nil {
@@ -448,7 +518,7 @@ func (g *GitPanel) GetSelectedBranch() string {
 func (g *GitPanel) renderBranches() string {
 	var b strings.Builder
 
-	b.WriteString(ui.PanelTitleStyle.Render("Branches"))
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("git.branches")))
 	b.WriteString("\n\n")
 
 	if len(g.Branches) == 0 {
@@ -472,9 +542,7 @@ func (g *GitPanel) renderBranches() string {
 		if branch == currentBranch {
 			branchDisplay = g.branchStyle.Render(branch + " ✓")
 		} else if i == g.BranchCursor {
-			branchDisplay = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#89b4fa")).
-				Render(branch)
+			branchDisplay = g.headerStyle.UnsetBold().Render(branch)
 		}
 
 		b.WriteString(fmt.Sprintf("%s%s\n", prefix, branchDisplay))
@@ -3,9 +3,12 @@ package components
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"dotsync/internal/branchflow"
 	"dotsync/internal/git"
+	"dotsync/internal/i18n"
 
 	gitLib "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -529,3 +532,21 @@ func TestGitPanel_FooterChangesWithMode(t *testing.T) {
 		t.Error("Views should be different for different modes")
 	}
 }
+
+func TestGitPanel_View_Drift(t *testing.T) {
+	gp := NewGitPanel()
+	gp.Repo = git.NewRepo("/tmp")
+	gp.Status = &git.Status{Branch: "machine/laptop", IsClean: true}
+	gp.Flow = branchflow.New(gp.Repo, "", "laptop")
+	gp.Drift = []branchflow.Drift{
+		{Branch: "machine/desktop", Ahead: 2, Behind: 1},
+		{Branch: "machine/laptop", Current: true},
+	}
+
+	view := gp.View()
+	for _, want := range []string{"machine/desktop", i18n.T("git.machines.drift", 2, 1), i18n.T("git.machines.merged")} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the drift section", want)
+		}
+	}
+}
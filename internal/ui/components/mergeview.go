@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"dotsync/internal/i18n"
 	"dotsync/internal/sync"
 	"dotsync/internal/ui"
 
@@ -29,22 +30,21 @@ type MergeView struct {
 
 // NewMergeView creates a new MergeView
 func NewMergeView() *MergeView {
-	return &MergeView{
+	m := &MergeView{
 		Width:  80,
 		Height: 20,
-		localStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#f38ba8")), // Red for local/delete
-		dotfilesStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#a6e3a1")), // Green for dotfiles/add
-		contextStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6c7086")), // Gray for context
-		headerStyle: lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#89b4fa")), // Blue for headers
-		resolvedStyle: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#a6e3a1")).
-			Bold(true),
 	}
+	m.ApplyTheme()
+	return m
+}
+
+// ApplyTheme rebuilds the view's styles from the active ui theme
+func (m *MergeView) ApplyTheme() {
+	m.localStyle = lipgloss.NewStyle().Foreground(ui.Removed)          // Red for local/delete
+	m.dotfilesStyle = lipgloss.NewStyle().Foreground(ui.Added)         // Green for dotfiles/add
+	m.contextStyle = lipgloss.NewStyle().Foreground(ui.Subtle)         // Gray for context
+	m.headerStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.Info) // Blue for headers
+	m.resolvedStyle = lipgloss.NewStyle().Foreground(ui.Added).Bold(true)
 }
 
 // SetMerge sets the merge result to display
@@ -137,7 +137,7 @@ func (m *MergeView) IsFullyResolved() bool {
 // View renders the merge view
 func (m *MergeView) View() string {
 	if m.MergeResult == nil {
-		return "No merge in progress"
+		return i18n.T("merge.none")
 	}
 
 	var b strings.Builder
@@ -203,7 +203,7 @@ func (m *MergeView) renderProgress() string {
 
 func (m *MergeView) renderCurrentHunk() string {
 	if m.CurrentHunk >= len(m.MergeResult.Hunks) {
-		return "No hunks to display"
+		return i18n.T("merge.no_hunks")
 	}
 
 	hunk := m.MergeResult.Hunks[m.CurrentHunk]
@@ -269,17 +269,19 @@ func (m *MergeView) renderCurrentHunk() string {
 
 func (m *MergeView) renderFooter() string {
 	items := []string{
-		ui.RenderHelpItem("j/k", "scroll"),
-		ui.RenderHelpItem("n/N", "next/prev hunk"),
-		ui.RenderHelpItem("1", "keep local"),
-		ui.RenderHelpItem("2", "use dotfiles"),
+		ui.RenderHelpItem("j/k", i18n.T("key.scroll")),
+		ui.RenderHelpItem("n/N", i18n.T("key.next_prev_hunk")),
+		ui.RenderHelpItem("1", i18n.T("key.keep_local")),
+		ui.RenderHelpItem("2", i18n.T("key.use_dotfiles")),
+		ui.RenderHelpItem("i", i18n.T("key.edit")),
+		ui.RenderHelpItem("c", i18n.T("key.cherry_pick")),
 	}
 
 	if m.IsFullyResolved() {
-		items = append(items, ui.RenderHelpItem("ENTER", "save merge"))
+		items = append(items, ui.RenderHelpItem("ENTER", i18n.T("key.save_merge")))
 	}
 
-	items = append(items, ui.RenderHelpItem("ESC", "cancel"))
+	items = append(items, ui.RenderHelpItem("ESC", i18n.T("key.cancel")))
 
 	return ui.HelpBarStyle.Render(strings.Join(items, "  "))
 }
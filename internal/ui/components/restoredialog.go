@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"dotsync/internal/i18n"
 	"dotsync/internal/ui"
 )
 
@@ -193,7 +194,7 @@ func (d *RestoreDialog) View() string {
 	var b strings.Builder
 
 	// Title
-	title := "Restore from another machine"
+	title := i18n.T("restore.title")
 	b.WriteString(ui.PanelTitleStyle.Render(title))
 	b.WriteString("\n")
 	b.WriteString(ui.DividerStyle.Render(strings.Repeat("-", d.Width-4)))
@@ -218,11 +219,11 @@ func (d *RestoreDialog) View() string {
 func (d *RestoreDialog) renderMachineSelection() string {
 	var b strings.Builder
 
-	b.WriteString(ui.MutedStyle.Render("Select source machine:"))
+	b.WriteString(ui.MutedStyle.Render(i18n.T("restore.select_machine")))
 	b.WriteString("\n\n")
 
 	if len(d.Machines) == 0 {
-		b.WriteString(ui.MutedStyle.Render("  No other machines found"))
+		b.WriteString(ui.MutedStyle.Render(i18n.T("restore.no_machines")))
 		return b.String()
 	}
 
@@ -233,7 +234,7 @@ func (d *RestoreDialog) renderMachineSelection() string {
 		}
 
 		// Format last sync time
-		lastSync := formatTimeAgo(machine.LastSync)
+		lastSync := FormatTimeAgo(machine.LastSync)
 		line := fmt.Sprintf("%s[%d] %s  (%s)", prefix, i+1, machine.Name, lastSync)
 
 		if i == d.MachineCursor {
@@ -252,11 +253,11 @@ func (d *RestoreDialog) renderFileSelection() string {
 	var b strings.Builder
 
 	machine := d.Machines[d.MachineCursor]
-	b.WriteString(ui.MutedStyle.Render(fmt.Sprintf("Files from %s:", machine.Name)))
+	b.WriteString(ui.MutedStyle.Render(i18n.T("restore.files_from", machine.Name)))
 	b.WriteString("\n\n")
 
 	if len(d.Files) == 0 {
-		b.WriteString(ui.MutedStyle.Render("  No files available"))
+		b.WriteString(ui.MutedStyle.Render(i18n.T("restore.no_files")))
 		return b.String()
 	}
 
@@ -292,7 +293,7 @@ func (d *RestoreDialog) renderFileSelection() string {
 		}
 	}
 	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render(fmt.Sprintf("Selected: %d/%d files", selectedCount, len(d.Files))))
+	b.WriteString(ui.MutedStyle.Render(i18n.T("restore.selected", selectedCount, len(d.Files))))
 
 	return b.String()
 }
@@ -301,51 +302,66 @@ func (d *RestoreDialog) renderFileSelection() string {
 func (d *RestoreDialog) renderHelp() string {
 	var items []string
 
-	items = append(items, ui.RenderHelpItem("Up/Down", "navigate"))
+	items = append(items, ui.RenderHelpItem("Up/Down", i18n.T("key.navigate")))
 
 	if d.Step == StepSelectFiles {
-		items = append(items, ui.RenderHelpItem("Space", "select"))
-		items = append(items, ui.RenderHelpItem("a", "all"))
+		items = append(items, ui.RenderHelpItem("Space", i18n.T("key.select")))
+		items = append(items, ui.RenderHelpItem("a", i18n.T("key.all")))
 	}
 
-	items = append(items, ui.RenderHelpItem("Enter", "confirm"))
+	items = append(items, ui.RenderHelpItem("Enter", i18n.T("key.confirm")))
 
 	if d.Step == StepSelectFiles {
-		items = append(items, ui.RenderHelpItem("Backspace", "back"))
+		items = append(items, ui.RenderHelpItem("Backspace", i18n.T("key.back")))
 	}
 
-	items = append(items, ui.RenderHelpItem("Esc", "cancel"))
+	items = append(items, ui.RenderHelpItem("Esc", i18n.T("key.cancel")))
 
 	return strings.Join(items, "  ")
 }
 
-// formatTimeAgo formats a time as relative time
-func formatTimeAgo(t time.Time) string {
+// ShortAge formats the time since t for narrow columns: 5m, 3h, 12d
+func ShortAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return i18n.T("time.short.now")
+	case d < time.Hour:
+		return i18n.T("time.short.minutes", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return i18n.T("time.short.hours", int(d.Hours()))
+	default:
+		return i18n.T("time.short.days", int(d.Hours()/24))
+	}
+}
+
+// FormatTimeAgo formats a time as relative time
+func FormatTimeAgo(t time.Time) string {
 	if t.IsZero() {
-		return "never"
+		return i18n.T("time.never")
 	}
 
 	duration := time.Since(t)
 
 	if duration < time.Minute {
-		return "just now"
+		return i18n.T("time.just_now")
 	} else if duration < time.Hour {
 		mins := int(duration.Minutes())
 		if mins == 1 {
-			return "1 minute ago"
+			return i18n.T("time.minute_ago")
 		}
-		return fmt.Sprintf("%d minutes ago", mins)
+		return i18n.T("time.minutes_ago", mins)
 	} else if duration < 24*time.Hour {
 		hours := int(duration.Hours())
 		if hours == 1 {
-			return "1 hour ago"
+			return i18n.T("time.hour_ago")
 		}
-		return fmt.Sprintf("%d hours ago", hours)
+		return i18n.T("time.hours_ago", hours)
 	} else {
 		days := int(duration.Hours() / 24)
 		if days == 1 {
-			return "1 day ago"
+			return i18n.T("time.day_ago")
 		}
-		return fmt.Sprintf("%d days ago", days)
+		return i18n.T("time.days_ago", days)
 	}
 }
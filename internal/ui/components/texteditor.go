@@ -0,0 +1,211 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dotsync/internal/i18n"
+	"dotsync/internal/ui"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// MaxEditSize is the largest file the built-in editor opens
+const MaxEditSize = 256 * 1024
+
+// maxEditLines is the textarea's line limit
+const maxEditLines = 10000
+
+// TextEditor is a minimal in-TUI editor for quick fixes to a config file
+type TextEditor struct {
+	textarea textarea.Model
+
+	Path     string
+	original string // Content on disk when loaded or last saved
+
+	Width  int
+	Height int
+
+	// Styles
+	headerStyle lipgloss.Style
+	infoStyle   lipgloss.Style
+	dirtyStyle  lipgloss.Style
+	borderStyle lipgloss.Style
+}
+
+// NewTextEditor creates a new TextEditor
+func NewTextEditor() *TextEditor {
+	ta := textarea.New()
+	ta.ShowLineNumbers = true
+	ta.CharLimit = 0
+	ta.MaxHeight = 0
+
+	e := &TextEditor{
+		textarea: ta,
+		Width:    80,
+		Height:   20,
+	}
+	e.ApplyTheme()
+	return e
+}
+
+// ApplyTheme rebuilds the editor's styles from the active ui theme
+func (e *TextEditor) ApplyTheme() {
+	e.headerStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.Info)
+	e.infoStyle = lipgloss.NewStyle().Foreground(ui.Subtle)
+	e.dirtyStyle = lipgloss.NewStyle().Foreground(ui.Warning)
+	e.borderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Info).
+		Padding(0, 1)
+}
+
+// SetSize updates the editor dimensions
+func (e *TextEditor) SetSize(width, height int) {
+	e.Width = width
+	e.Height = height
+
+	// Account for header (3 lines) and border (2 lines)
+	e.textarea.SetWidth(max(width-4, 10))
+	e.textarea.SetHeight(max(height-5, 3))
+}
+
+// Load opens the file at path. Directories, binaries and files over
+// MaxEditSize are refused.
+func (e *TextEditor) Load(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", filepath.Base(path))
+	}
+	if info.Size() > MaxEditSize {
+		return fmt.Errorf("file is too large to edit here (%s)", formatBytes(info.Size()))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if isBinaryContent(data) {
+		return fmt.Errorf("binary file cannot be edited here")
+	}
+
+	return e.SetContent(path, string(data))
+}
+
+// SetContent opens path with content that may differ from the file on
+// disk, e.g. a merge with conflict markers. Content the textarea would
+// alter (tabs, carriage returns, control characters) is refused.
+func (e *TextEditor) SetContent(path, content string) error {
+	if strings.Count(content, "\n") >= maxEditLines {
+		return fmt.Errorf("file has too many lines to edit here")
+	}
+	e.textarea.SetValue(content)
+	if e.textarea.Value() != content {
+		e.textarea.Reset()
+		return fmt.Errorf("file has tabs or control characters the built-in editor would change")
+	}
+
+	e.Path = path
+	e.original = ""
+	if data, err := os.ReadFile(path); err == nil {
+		e.original = string(data)
+	}
+	for e.textarea.Line() > 0 {
+		e.textarea.CursorUp()
+	}
+	e.textarea.CursorStart()
+	e.textarea.Focus()
+	return nil
+}
+
+// Value returns the edited content
+func (e *TextEditor) Value() string {
+	return e.textarea.Value()
+}
+
+// Dirty reports whether the content differs from the file on disk
+func (e *TextEditor) Dirty() bool {
+	return e.Value() != e.original
+}
+
+// ConflictMarkers counts conflict blocks still left in the content
+func (e *TextEditor) ConflictMarkers() int {
+	count := 0
+	for _, line := range strings.Split(e.Value(), "\n") {
+		if strings.HasPrefix(line, "<<<<<<<") {
+			count++
+		}
+	}
+	return count
+}
+
+// Save writes the content back to Path, keeping the file's permissions
+func (e *TextEditor) Save() error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(e.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0755); err != nil {
+		return fmt.Errorf("cannot create directory: %w", err)
+	}
+
+	content := e.Value()
+	tmp := e.Path + ".dotsync-edit"
+	if err := os.WriteFile(tmp, []byte(content), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, e.Path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	e.original = content
+	return nil
+}
+
+// Blur stops the editor from taking input
+func (e *TextEditor) Blur() {
+	e.textarea.Blur()
+}
+
+// Update forwards a message to the textarea
+func (e *TextEditor) Update(msg tea.Msg) (*TextEditor, tea.Cmd) {
+	var cmd tea.Cmd
+	e.textarea, cmd = e.textarea.Update(msg)
+	return e, cmd
+}
+
+// View renders the editor
+func (e *TextEditor) View() string {
+	var b strings.Builder
+
+	// Header
+	header := e.headerStyle.Render(fmt.Sprintf("✎ %s", filepath.Base(e.Path)))
+	info := e.infoStyle.Render(fmt.Sprintf("  %s %d", i18n.T("editor.line"), e.textarea.Line()+1))
+	if e.Dirty() {
+		info += e.dirtyStyle.Render("  " + i18n.T("editor.modified"))
+	}
+	if n := e.ConflictMarkers(); n > 0 {
+		info += e.dirtyStyle.Render("  " + i18n.T("editor.markers", n))
+	}
+	b.WriteString(header + info + "\n")
+
+	// File path
+	b.WriteString(e.infoStyle.Render(e.Path) + "\n")
+
+	// Separator
+	b.WriteString(lipgloss.NewStyle().
+		Foreground(ui.Surface).
+		Render(strings.Repeat("─", max(e.Width-4, 0))) + "\n")
+
+	b.WriteString(e.textarea.View())
+
+	return e.borderStyle.Width(e.Width).Height(e.Height).Render(b.String())
+}
@@ -0,0 +1,87 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTextEditor_LoadAndSave(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.toml")
+	os.WriteFile(path, []byte("a = 1\nb = 2\n"), 0600)
+
+	e := NewTextEditor()
+	if err := e.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if e.Value() != "a = 1\nb = 2\n" {
+		t.Errorf("Unexpected content %q", e.Value())
+	}
+	if e.Dirty() {
+		t.Error("Freshly loaded file should not be dirty")
+	}
+
+	e.textarea.InsertString("c = 3\n")
+	if !e.Dirty() {
+		t.Error("Edited content should be dirty")
+	}
+	if err := e.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if e.Dirty() {
+		t.Error("Saved content should not be dirty")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != e.Value() {
+		t.Errorf("File has %q, editor has %q", data, e.Value())
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Save should keep permissions, got %v", info.Mode().Perm())
+	}
+}
+
+func TestTextEditor_LoadRefuses(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"binary": {0x00, 0x01, 0x02},
+		"tabs":   []byte("[user]\n\tname = me\n"),
+		"crlf":   []byte("a\r\nb\r\n"),
+		"large":  []byte(strings.Repeat("x", MaxEditSize+1)),
+	}
+
+	e := NewTextEditor()
+	for name, data := range files {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, data, 0644)
+		if err := e.Load(path); err == nil {
+			t.Errorf("Expected %s file to be refused", name)
+		}
+	}
+	if err := e.Load(tmpDir); err == nil {
+		t.Error("Expected directory to be refused")
+	}
+}
+
+func TestTextEditor_SetContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "rc")
+	os.WriteFile(path, []byte("x\n"), 0644)
+
+	e := NewTextEditor()
+	content := "<<<<<<< LOCAL\nx\n=======\ny\n>>>>>>> DOTFILES\n"
+	if err := e.SetContent(path, content); err != nil {
+		t.Fatalf("SetContent failed: %v", err)
+	}
+	if !e.Dirty() {
+		t.Error("Content differing from disk should be dirty")
+	}
+	if e.ConflictMarkers() != 1 {
+		t.Errorf("Expected 1 conflict marker, got %d", e.ConflictMarkers())
+	}
+	if e.textarea.Line() != 0 {
+		t.Errorf("Cursor should start on the first line, got %d", e.textarea.Line())
+	}
+}
@@ -0,0 +1,36 @@
+package components
+
+// visualRange is a vim-style visual selection from an anchor row to the
+// cursor
+type visualRange struct {
+	active bool
+	anchor int
+}
+
+// start anchors a new range at cursor
+func (v *visualRange) start(cursor int) {
+	v.active = true
+	v.anchor = cursor
+}
+
+// stop ends the range
+func (v *visualRange) stop() {
+	v.active = false
+}
+
+// bounds returns the first and last row of the range ending at cursor
+func (v visualRange) bounds(cursor int) (int, int) {
+	if v.anchor < cursor {
+		return v.anchor, cursor
+	}
+	return cursor, v.anchor
+}
+
+// contains reports whether row i is inside the active range
+func (v visualRange) contains(i, cursor int) bool {
+	if !v.active {
+		return false
+	}
+	first, last := v.bounds(cursor)
+	return i >= first && i <= last
+}
@@ -12,13 +12,22 @@ import (
 
 // Highlighter provides syntax highlighting for code
 type Highlighter struct {
-	style *chroma.Style
+	style     *chroma.Style
+	styleName string
 }
 
-// NewHighlighter creates a new syntax highlighter
+// NewHighlighter creates a new syntax highlighter using the theme's syntax style
 func NewHighlighter() *Highlighter {
-	return &Highlighter{
-		style: styles.Get("catppuccin-mocha"),
+	h := &Highlighter{}
+	h.syncStyle()
+	return h
+}
+
+// syncStyle reloads the chroma style when the active theme changed it
+func (h *Highlighter) syncStyle() {
+	if name := activeTheme.Syntax; h.style == nil || h.styleName != name {
+		h.style = styles.Get(name)
+		h.styleName = name
 	}
 }
 
@@ -33,6 +42,7 @@ func (h *Highlighter) HighlightLine(line, filename string) string {
 	if err != nil {
 		return line
 	}
+	h.syncStyle()
 
 	var result strings.Builder
 	for token := iterator(); token != chroma.EOF; token = iterator() {
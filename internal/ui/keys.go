@@ -20,6 +20,7 @@ type KeyMap struct {
 	DeselectAll key.Binding
 	SelectMod   key.Binding // Select modified apps/files
 	SelectOut   key.Binding // Select outdated apps/files (need pull)
+	Filter      key.Binding // Show only conflicted or out-of-sync apps/files
 	Push        key.Binding // Push local configs to dotfiles
 	Pull        key.Binding // Pull configs from dotfiles to local
 	Scan        key.Binding
@@ -43,8 +44,30 @@ type KeyMap struct {
 	QuickSync     key.Binding // Quick backup (backup all + commit)
 	ToggleMode    key.Binding // Toggle sync ON/OFF
 	Restore       key.Binding // Open restore dialog
-	OpenEditor    key.Binding // Open current file in editor
+	OpenEditor    key.Binding // Compare current file with its dotfiles copy in an editor
+	EditFile      key.Binding // Open current local file in an editor
+	EditHere      key.Binding // Edit the file in the built-in editor
 	CheckConflict key.Binding // Check for conflicts
+	ConflictQueue key.Binding // Open queue of conflicts skipped by pull
+	Review        key.Binding // Step through the diffs of selected changes
+	Upstream      key.Binding // Review and pull changes pushed from other machines
+	Digest        key.Binding // Weekly activity digest
+	AuditLog      key.Binding // History of sync operations
+	AppInfo       key.Binding // Details of the app under the cursor
+	DiffStat      key.Binding // Lines changed locally in the selected apps
+	GroupApps     key.Binding // Group apps by category
+	SortStale     key.Binding // List the least recently synced first
+	Visual        key.Binding // Mark a range of rows to select
+	ToggleShown   key.Binding // Toggle selection of every listed item
+	Dashboard     key.Binding // Sync health overview
+	ArchiveApp    key.Binding // Archive the dotfiles of an uninstalled app
+	SaveReport    key.Binding // Write a report of the last push/pull/quick sync
+	Suggestions   key.Binding // Recommended actions
+	MapPath       key.Binding // Set where a file is stored in the repo
+	ShrinkPanel   key.Binding // Narrow the apps panel
+	GrowPanel     key.Binding // Widen the apps panel
+	ResetSplit    key.Binding // Split the panels in half again
+	Zen           key.Binding // Show only the focused panel
 }
 
 // DefaultKeyMap returns the default keybindings
@@ -106,6 +129,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("D"),
 			key.WithHelp("D", "deselect all"),
 		),
+		Filter: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "status filter"),
+		),
 		Push: key.NewBinding(
 			key.WithKeys("p"),
 			key.WithHelp("p", "push to dotfiles"),
@@ -202,12 +229,100 @@ func DefaultKeyMap() KeyMap {
 		),
 		OpenEditor: key.NewBinding(
 			key.WithKeys("e"),
-			key.WithHelp("e", "open in editor"),
+			key.WithHelp("e", "diff in editor"),
+		),
+		EditFile: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "edit local file"),
+		),
+		EditHere: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "edit here"),
 		),
 		CheckConflict: key.NewBinding(
 			key.WithKeys("c"),
 			key.WithHelp("c", "check conflicts"),
 		),
+		ConflictQueue: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "conflict queue"),
+		),
+		Review: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "review queue"),
+		),
+		Upstream: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "upstream changes"),
+		),
+		Digest: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "weekly digest"),
+		),
+		AuditLog: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "audit log"),
+		),
+		AppInfo: key.NewBinding(
+			key.WithKeys("I"),
+			key.WithHelp("I", "app details"),
+		),
+		DiffStat: key.NewBinding(
+			key.WithKeys("#"),
+			key.WithHelp("#", "changes since push"),
+		),
+		GroupApps: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "group by category"),
+		),
+		SortStale: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "sort by staleness"),
+		),
+		Visual: key.NewBinding(
+			key.WithKeys("V"),
+			key.WithHelp("V", "visual select"),
+		),
+		ToggleShown: key.NewBinding(
+			key.WithKeys("*"),
+			key.WithHelp("*", "toggle all shown"),
+		),
+		Dashboard: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "dashboard"),
+		),
+		ArchiveApp: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "archive uninstalled"),
+		),
+		SaveReport: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "save sync report"),
+		),
+		Suggestions: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "suggestions"),
+		),
+		MapPath: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "map repo path"),
+		),
+		ShrinkPanel: key.NewBinding(
+			key.WithKeys("<"),
+			key.WithHelp("<", "narrow apps panel"),
+		),
+		GrowPanel: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "widen apps panel"),
+		),
+		ResetSplit: key.NewBinding(
+			key.WithKeys("="),
+			key.WithHelp("=", "even split"),
+		),
+		Zen: key.NewBinding(
+			key.WithKeys("Z"),
+			key.WithHelp("Z", "zen mode"),
+		),
 	}
 }
 
@@ -220,18 +335,20 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		// Navigation
-		{k.Up, k.Down, k.PageUp, k.PageDown, k.Home, k.End},
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Home, k.End, k.GroupApps, k.SortStale},
+		// Layout
+		{k.ShrinkPanel, k.GrowPanel, k.ResetSplit, k.Zen},
 		// Panel & Selection
-		{k.Tab, k.Space, k.Enter, k.SelectAll, k.DeselectAll},
+		{k.Tab, k.Space, k.Enter, k.SelectAll, k.DeselectAll, k.Visual, k.ToggleShown},
 		// Quick Selection
-		{k.SelectMod, k.SelectOut, k.Refresh, k.Undo},
+		{k.SelectMod, k.SelectOut, k.Filter, k.Refresh, k.Undo},
 		// Quick Sync & Mode
-		{k.QuickSync, k.ToggleMode, k.AddCustom},
+		{k.QuickSync, k.ToggleMode, k.AddCustom, k.ArchiveApp, k.MapPath},
 		// Sync Operations
 		{k.Push, k.Pull, k.Scan, k.Brewfile, k.Restore},
 		// Diff & Merge
-		{k.Diff, k.Merge, k.OpenEditor, k.CheckConflict},
+		{k.Diff, k.DiffStat, k.Merge, k.OpenEditor, k.EditFile, k.EditHere, k.CheckConflict, k.ConflictQueue, k.Review},
 		// Git & General
-		{k.Git, k.Help, k.Escape, k.Quit},
+		{k.Git, k.Dashboard, k.Suggestions, k.Digest, k.AuditLog, k.AppInfo, k.Help, k.Escape, k.Quit},
 	}
 }
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// plainRunes maps the glyphs dotsync draws to ASCII of the same width
+var plainRunes = map[rune]string{
+	// Borders and separators
+	'─': "-", '━': "-", '═': "=", '│': "|", '┃': "|", '║': "|",
+	'╭': "+", '╮': "+", '╰': "+", '╯': "+", '┌': "+", '┐': "+", '└': "+", '┘': "+",
+	'├': "+", '┤': "+", '┬': "+", '┴': "+", '┼': "+",
+	// Status icons and markers
+	'✓': "v", '✔': "v", '✗': "x", '✘': "x", '●': "*", '○': "o", '•': "*", '·': ".",
+	'▲': "^", '▼': "v", '‼': "!", '∅': "-", '⚠': "!", '…': ".",
+	'→': ">", '←': "<", '↑': "^", '↓': "v", '↔': "-", '⇄': "-",
+	'▶': ">", '►': ">", '▸': ">", '▹': ">", '❯': ">", '›': ">",
+	// Progress bars and scrollbars
+	'█': "#", '▓': "#", '▒': "=", '░': "-", '▌': "#", '▐': "#",
+	// Typographic quotes and dashes
+	'–': "-", '—': "-", '“': `"`, '”': `"`, '‘': "'", '’': "'",
+}
+
+// PlainText rewrites rendered output for terminals without emoji or nerd
+// fonts and for screen readers. Known glyphs become ASCII, and other
+// symbols (emoji, icons from nerd fonts, spinner dots) become spaces, so
+// boxes keep their width. Letters of any script are kept.
+func PlainText(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case plainRunes[r] != "":
+			b.WriteString(plainRunes[r])
+		case r == '\u200d' || (r >= '\ufe00' && r <= '\ufe0f'):
+			// Joiners and emoji variation selectors take no space
+		case unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteString(strings.Repeat(" ", max(lipgloss.Width(string(r)), 1)))
+		}
+	}
+	return b.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := map[string]string{
+		"ascii only":          "ascii only",
+		"╭──╮\n│ ✓ │":         "+--+\n| v |",
+		"🔄 Dotsync":           "   Dotsync",
+		"⚠️ 2 failed → retry": "! 2 failed > retry",
+		"Tiếng Việt":          "Tiếng Việt",
+		"\uf121 nvim":         "  nvim",
+		"⠋ Scanning":          "  Scanning",
+	}
+	for in, want := range tests {
+		if got := PlainText(in); got != want {
+			t.Errorf("PlainText(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	box := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Width(20).Render("📁 Files")
+	if lipgloss.Width(PlainText(box)) != lipgloss.Width(box) {
+		t.Errorf("Expected boxes to keep their width:\n%s", PlainText(box))
+	}
+}
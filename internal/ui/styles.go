@@ -2,192 +2,279 @@ package ui
 
 import "github.com/charmbracelet/lipgloss"
 
-// Colors
+// Colors of the active theme
 var (
-	Primary    = lipgloss.Color("#7C3AED") // Purple
-	Secondary  = lipgloss.Color("#06B6D4") // Cyan
-	Success    = lipgloss.Color("#10B981") // Green
-	Warning    = lipgloss.Color("#F59E0B") // Amber
-	Error      = lipgloss.Color("#EF4444") // Red
-	Muted      = lipgloss.Color("#6B7280") // Gray
-	Background = lipgloss.Color("#1F2937") // Dark gray
-	Foreground = lipgloss.Color("#F9FAFB") // Light
-	Border     = lipgloss.Color("#374151") // Border gray
-	Highlight  = lipgloss.Color("#8B5CF6") // Light purple
-	Selected   = lipgloss.Color("#4F46E5") // Indigo
+	Primary    lipgloss.Color // Purple
+	Secondary  lipgloss.Color // Cyan
+	Success    lipgloss.Color // Green
+	Warning    lipgloss.Color // Amber
+	Error      lipgloss.Color // Red
+	Muted      lipgloss.Color // Gray
+	Background lipgloss.Color // Dark gray
+	Foreground lipgloss.Color // Light
+	Border     lipgloss.Color // Border gray
+	Highlight  lipgloss.Color // Light purple
+	Selected   lipgloss.Color // Indigo
+	Outdated   lipgloss.Color // Light blue
+	Conflict   lipgloss.Color // Pink
+
+	Added   lipgloss.Color // Diff additions
+	Removed lipgloss.Color // Diff deletions
+	Info    lipgloss.Color // Headers in diff, merge and preview views
+	Subtle  lipgloss.Color // Context lines and hints
+	Accent  lipgloss.Color // Git branch
+	Surface lipgloss.Color // Separators and input backgrounds
 )
 
 // Styles
 var (
+	AppStyle          lipgloss.Style
+	HeaderStyle       lipgloss.Style
+	TitleStyle        lipgloss.Style
+	VersionStyle      lipgloss.Style
+	PanelStyle        lipgloss.Style
+	PanelTitleStyle   lipgloss.Style
+	ActivePanelStyle  lipgloss.Style
+	ItemStyle         lipgloss.Style
+	SelectedItemStyle lipgloss.Style
+	VisualItemStyle   lipgloss.Style
+	CursorStyle       lipgloss.Style
+
+	CheckboxChecked   string
+	CheckboxUnchecked string
+
+	StatusBarStyle  lipgloss.Style
+	StatusTextStyle lipgloss.Style
+	HelpBarStyle    lipgloss.Style
+	HelpKeyStyle    lipgloss.Style
+	HelpDescStyle   lipgloss.Style
+	CategoryStyle   lipgloss.Style
+
+	FileNameStyle  lipgloss.Style
+	FilePathStyle  lipgloss.Style
+	FileSizeStyle  lipgloss.Style
+	EncryptedStyle lipgloss.Style
+	LargeFileStyle lipgloss.Style
+
+	SyncedStyle   lipgloss.Style
+	ModifiedStyle lipgloss.Style
+	NewStyle      lipgloss.Style
+	MissingStyle  lipgloss.Style
+	OutdatedStyle lipgloss.Style
+	ConflictStyle lipgloss.Style
+
+	MutedStyle    lipgloss.Style
+	ProgressStyle lipgloss.Style
+	DividerStyle  lipgloss.Style
+
+	SuccessNotifyStyle lipgloss.Style
+	ErrorNotifyStyle   lipgloss.Style
+	WarningNotifyStyle lipgloss.Style
+	InfoNotifyStyle    lipgloss.Style
+
+	DialogStyle       lipgloss.Style
+	ButtonStyle       lipgloss.Style
+	ButtonActiveStyle lipgloss.Style
+)
+
+func init() {
+	ApplyTheme(Presets[ThemeDark])
+}
+
+// setColors copies the palette of t into the color variables
+func setColors(t Theme) {
+	Primary = lipgloss.Color(t.Primary)
+	Secondary = lipgloss.Color(t.Secondary)
+	Success = lipgloss.Color(t.Success)
+	Warning = lipgloss.Color(t.Warning)
+	Error = lipgloss.Color(t.Error)
+	Muted = lipgloss.Color(t.Muted)
+	Background = lipgloss.Color(t.Background)
+	Foreground = lipgloss.Color(t.Foreground)
+	Border = lipgloss.Color(t.Border)
+	Highlight = lipgloss.Color(t.Highlight)
+	Selected = lipgloss.Color(t.Selected)
+	Outdated = lipgloss.Color(t.Outdated)
+	Conflict = lipgloss.Color(t.Conflict)
+	Added = lipgloss.Color(t.Added)
+	Removed = lipgloss.Color(t.Removed)
+	Info = lipgloss.Color(t.Info)
+	Subtle = lipgloss.Color(t.Subtle)
+	Accent = lipgloss.Color(t.Accent)
+	Surface = lipgloss.Color(t.Surface)
+}
+
+// buildStyles derives every style from the color variables
+func buildStyles() {
+	t := activeTheme
+
 	// App container
 	AppStyle = lipgloss.NewStyle().
-			Padding(0, 1)
+		Padding(0, 1)
 
 	// Header
 	HeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(Primary).
-			Padding(0, 1).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(Primary).
+		Padding(0, 1).
+		MarginBottom(1)
 
 	TitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(Foreground)
+		Bold(true).
+		Foreground(Foreground)
 
 	VersionStyle = lipgloss.NewStyle().
-			Foreground(Muted).
-			Italic(true)
+		Foreground(Muted).
+		Italic(true)
 
 	// Panels
 	PanelStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(Border).
-			Padding(0, 1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(Border).
+		Padding(0, 1)
 
 	PanelTitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(Secondary).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(Secondary).
+		Padding(0, 1)
 
 	ActivePanelStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(Primary).
-				Padding(0, 1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(Primary).
+		Padding(0, 1)
 
 	// List items
 	ItemStyle = lipgloss.NewStyle().
-			Padding(0, 1)
+		Padding(0, 1)
 
 	SelectedItemStyle = lipgloss.NewStyle().
-				Padding(0, 1).
-				Background(Selected).
-				Foreground(Foreground)
+		Padding(0, 1).
+		Background(Selected).
+		Foreground(Foreground)
+
+	// Rows inside a visual-mode range
+	VisualItemStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		Background(Surface).
+		Foreground(Foreground)
 
 	CursorStyle = lipgloss.NewStyle().
-			Foreground(Primary).
-			Bold(true)
+		Foreground(Primary).
+		Bold(true)
 
 	// Checkbox
-	CheckboxChecked   = lipgloss.NewStyle().Foreground(Success).Render("[✓]")
+	CheckboxChecked = lipgloss.NewStyle().Foreground(Success).Render("[✓]")
 	CheckboxUnchecked = lipgloss.NewStyle().Foreground(Muted).Render("[ ]")
 
 	// Status bar
 	StatusBarStyle = lipgloss.NewStyle().
-			Foreground(Muted).
-			Padding(0, 1).
-			MarginTop(1)
+		Foreground(Muted).
+		Padding(0, 1).
+		MarginTop(1)
 
 	StatusTextStyle = lipgloss.NewStyle().
-			Foreground(Foreground)
+		Foreground(Foreground)
 
 	// Help bar
 	HelpBarStyle = lipgloss.NewStyle().
-			Foreground(Muted).
-			Padding(0, 1)
+		Foreground(Muted).
+		Padding(0, 1)
 
 	HelpKeyStyle = lipgloss.NewStyle().
-			Foreground(Secondary).
-			Bold(true)
+		Foreground(Secondary).
+		Bold(true)
 
 	HelpDescStyle = lipgloss.NewStyle().
-			Foreground(Muted)
+		Foreground(Muted)
 
 	// Category header
 	CategoryStyle = lipgloss.NewStyle().
-			Foreground(Warning).
-			Bold(true).
-			Padding(0, 1)
+		Foreground(Warning).
+		Bold(true).
+		Padding(0, 1)
 
 	// File specific
 	FileNameStyle = lipgloss.NewStyle().
-			Foreground(Foreground)
+		Foreground(Foreground)
 
 	FilePathStyle = lipgloss.NewStyle().
-			Foreground(Muted).
-			Italic(true)
+		Foreground(Muted).
+		Italic(true)
 
 	FileSizeStyle = lipgloss.NewStyle().
-			Foreground(Muted)
+		Foreground(Muted)
 
 	EncryptedStyle = lipgloss.NewStyle().
-			Foreground(Warning)
+		Foreground(Warning)
+
+	LargeFileStyle = lipgloss.NewStyle().
+		Foreground(Warning).
+		Bold(true)
 
 	// Sync status
 	SyncedStyle = lipgloss.NewStyle().
-			Foreground(Success)
+		Foreground(Success)
 
 	ModifiedStyle = lipgloss.NewStyle().
-			Foreground(Warning)
+		Foreground(Warning)
 
 	NewStyle = lipgloss.NewStyle().
-			Foreground(Secondary)
+		Foreground(Secondary)
 
 	MissingStyle = lipgloss.NewStyle().
-			Foreground(Error)
+		Foreground(Error)
 
 	OutdatedStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#60A5FA")) // Light blue for outdated
+		Foreground(Outdated)
 
 	ConflictStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#F472B6")). // Pink for conflicts
-			Bold(true)
+		Foreground(Conflict).
+		Bold(true)
 
 	// Muted text
 	MutedStyle = lipgloss.NewStyle().
-			Foreground(Muted)
+		Foreground(Muted)
 
 	// Progress
 	ProgressStyle = lipgloss.NewStyle().
-			Foreground(Primary)
+		Foreground(Primary)
 
 	// Divider
 	DividerStyle = lipgloss.NewStyle().
-			Foreground(Border)
+		Foreground(Border)
 
 	// Notification/Toast styles
-	SuccessNotifyStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#10B981")).
-				Background(lipgloss.Color("#064E3B")).
-				Padding(0, 1).
-				Bold(true)
-
-	ErrorNotifyStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FCA5A5")).
-				Background(lipgloss.Color("#7F1D1D")).
-				Padding(0, 1).
-				Bold(true)
-
-	WarningNotifyStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FCD34D")).
-				Background(lipgloss.Color("#78350F")).
-				Padding(0, 1).
-				Bold(true)
-
-	InfoNotifyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#93C5FD")).
-			Background(lipgloss.Color("#1E3A5F")).
+	notify := func(fg lipgloss.Color, bg string) lipgloss.Style {
+		return lipgloss.NewStyle().
+			Foreground(fg).
+			Background(lipgloss.Color(bg)).
 			Padding(0, 1).
 			Bold(true)
+	}
+	SuccessNotifyStyle = notify(Success, t.SuccessBg)
+	ErrorNotifyStyle = notify(Error, t.ErrorBg)
+	WarningNotifyStyle = notify(Warning, t.WarningBg)
+	InfoNotifyStyle = notify(Info, t.InfoBg)
 
 	// Dialog box style
 	DialogStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(Primary).
-			Padding(1, 2).
-			Width(60)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(Primary).
+		Padding(1, 2).
+		Width(60)
 
 	// Button styles
 	ButtonStyle = lipgloss.NewStyle().
-			Foreground(Foreground).
-			Background(Border).
-			Padding(0, 2)
+		Foreground(Foreground).
+		Background(Border).
+		Padding(0, 2)
 
 	ButtonActiveStyle = lipgloss.NewStyle().
-				Foreground(Foreground).
-				Background(Primary).
-				Padding(0, 2).
-				Bold(true)
-)
+		Foreground(Foreground).
+		Background(Primary).
+		Padding(0, 2).
+		Bold(true)
+}
 
 // RenderCheckbox returns a styled checkbox
 func RenderCheckbox(checked bool) string {
@@ -0,0 +1,272 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Theme names
+const (
+	ThemeDark       = "dark"
+	ThemeLight      = "light"
+	ThemeSolarized  = "solarized"
+	ThemeCatppuccin = "catppuccin"
+	ThemeCustom     = "custom" // Loaded from a theme file
+)
+
+// ThemeNames lists the selectable themes in settings order
+var ThemeNames = []string{ThemeDark, ThemeLight, ThemeSolarized, ThemeCatppuccin, ThemeCustom}
+
+// Theme is a color palette for every style in the UI. Colors are hex
+// strings or ANSI color numbers, as accepted by lipgloss.Color.
+type Theme struct {
+	Name string `json:"name"`
+	Base string `json:"base,omitempty"` // Preset that fills in colors a custom theme leaves out
+
+	Primary    string `json:"primary,omitempty"`
+	Secondary  string `json:"secondary,omitempty"`
+	Success    string `json:"success,omitempty"`
+	Warning    string `json:"warning,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Muted      string `json:"muted,omitempty"`
+	Background string `json:"background,omitempty"`
+	Foreground string `json:"foreground,omitempty"`
+	Border     string `json:"border,omitempty"`
+	Highlight  string `json:"highlight,omitempty"`
+	Selected   string `json:"selected,omitempty"`
+	Outdated   string `json:"outdated,omitempty"`
+	Conflict   string `json:"conflict,omitempty"`
+
+	// Diff, merge, preview and git panel colors
+	Added   string `json:"added,omitempty"`
+	Removed string `json:"removed,omitempty"`
+	Info    string `json:"info,omitempty"`
+	Subtle  string `json:"subtle,omitempty"`
+	Accent  string `json:"accent,omitempty"`
+	Surface string `json:"surface,omitempty"`
+
+	// Notification backgrounds
+	SuccessBg string `json:"success_bg,omitempty"`
+	ErrorBg   string `json:"error_bg,omitempty"`
+	WarningBg string `json:"warning_bg,omitempty"`
+	InfoBg    string `json:"info_bg,omitempty"`
+
+	Syntax string `json:"syntax,omitempty"` // Chroma style for syntax highlighting
+}
+
+// Presets are the built-in themes
+var Presets = map[string]Theme{
+	ThemeDark: {
+		Name:       ThemeDark,
+		Primary:    "#7C3AED",
+		Secondary:  "#06B6D4",
+		Success:    "#10B981",
+		Warning:    "#F59E0B",
+		Error:      "#EF4444",
+		Muted:      "#6B7280",
+		Background: "#1F2937",
+		Foreground: "#F9FAFB",
+		Border:     "#374151",
+		Highlight:  "#8B5CF6",
+		Selected:   "#4F46E5",
+		Outdated:   "#60A5FA",
+		Conflict:   "#F472B6",
+		Added:      "#a6e3a1",
+		Removed:    "#f38ba8",
+		Info:       "#89b4fa",
+		Subtle:     "#6c7086",
+		Accent:     "#cba6f7",
+		Surface:    "#313244",
+		SuccessBg:  "#064E3B",
+		ErrorBg:    "#7F1D1D",
+		WarningBg:  "#78350F",
+		InfoBg:     "#1E3A5F",
+		Syntax:     "catppuccin-mocha",
+	},
+	ThemeLight: {
+		Name:       ThemeLight,
+		Primary:    "#6D28D9",
+		Secondary:  "#0E7490",
+		Success:    "#047857",
+		Warning:    "#B45309",
+		Error:      "#B91C1C",
+		Muted:      "#6B7280",
+		Background: "#F9FAFB",
+		Foreground: "#111827",
+		Border:     "#D1D5DB",
+		Highlight:  "#7C3AED",
+		Selected:   "#C7D2FE",
+		Outdated:   "#1D4ED8",
+		Conflict:   "#BE185D",
+		Added:      "#15803D",
+		Removed:    "#B91C1C",
+		Info:       "#1D4ED8",
+		Subtle:     "#9CA3AF",
+		Accent:     "#7E22CE",
+		Surface:    "#E5E7EB",
+		SuccessBg:  "#D1FAE5",
+		ErrorBg:    "#FEE2E2",
+		WarningBg:  "#FEF3C7",
+		InfoBg:     "#DBEAFE",
+		Syntax:     "github",
+	},
+	ThemeSolarized: {
+		Name:       ThemeSolarized,
+		Primary:    "#6c71c4",
+		Secondary:  "#2aa198",
+		Success:    "#859900",
+		Warning:    "#b58900",
+		Error:      "#dc322f",
+		Muted:      "#586e75",
+		Background: "#002b36",
+		Foreground: "#eee8d5",
+		Border:     "#073642",
+		Highlight:  "#d33682",
+		Selected:   "#073642",
+		Outdated:   "#268bd2",
+		Conflict:   "#d33682",
+		Added:      "#859900",
+		Removed:    "#dc322f",
+		Info:       "#268bd2",
+		Subtle:     "#657b83",
+		Accent:     "#6c71c4",
+		Surface:    "#073642",
+		SuccessBg:  "#073642",
+		ErrorBg:    "#073642",
+		WarningBg:  "#073642",
+		InfoBg:     "#073642",
+		Syntax:     "solarized-dark",
+	},
+	ThemeCatppuccin: {
+		Name:       ThemeCatppuccin,
+		Primary:    "#cba6f7",
+		Secondary:  "#89dceb",
+		Success:    "#a6e3a1",
+		Warning:    "#f9e2af",
+		Error:      "#f38ba8",
+		Muted:      "#6c7086",
+		Background: "#1e1e2e",
+		Foreground: "#cdd6f4",
+		Border:     "#45475a",
+		Highlight:  "#b4befe",
+		Selected:   "#45475a",
+		Outdated:   "#89b4fa",
+		Conflict:   "#f5c2e7",
+		Added:      "#a6e3a1",
+		Removed:    "#f38ba8",
+		Info:       "#89b4fa",
+		Subtle:     "#6c7086",
+		Accent:     "#cba6f7",
+		Surface:    "#313244",
+		SuccessBg:  "#313244",
+		ErrorBg:    "#313244",
+		WarningBg:  "#313244",
+		InfoBg:     "#313244",
+		Syntax:     "catppuccin-mocha",
+	},
+}
+
+// activeTheme is the theme the current styles were built from
+var activeTheme = Presets[ThemeDark]
+
+// ActiveTheme returns the theme in use
+func ActiveTheme() Theme {
+	return activeTheme
+}
+
+// ThemeName normalizes a configured theme name; unknown names mean dark
+func ThemeName(name string) string {
+	for _, n := range ThemeNames {
+		if n == name {
+			return n
+		}
+	}
+	return ThemeDark
+}
+
+// NextTheme returns the theme name after name in ThemeNames
+func NextTheme(name string) string {
+	name = ThemeName(name)
+	for i, n := range ThemeNames {
+		if n == name {
+			return ThemeNames[(i+1)%len(ThemeNames)]
+		}
+	}
+	return ThemeDark
+}
+
+// LoadTheme resolves a theme name. "custom" reads the JSON theme file at
+// customPath; unknown names fall back to the dark theme.
+func LoadTheme(name, customPath string) (Theme, error) {
+	if name != ThemeCustom {
+		if t, ok := Presets[name]; ok {
+			return t, nil
+		}
+		return Presets[ThemeDark], nil
+	}
+	return LoadThemeFile(customPath)
+}
+
+// LoadThemeFile reads a custom theme. Colors it leaves out come from its
+// base preset (dark by default), so a file can override just a few colors.
+func LoadThemeFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Presets[ThemeDark], err
+	}
+
+	var custom Theme
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return Presets[ThemeDark], fmt.Errorf("parse theme %s: %w", path, err)
+	}
+
+	base, ok := Presets[custom.Base]
+	if !ok {
+		base = Presets[ThemeDark]
+	}
+	return custom.over(base), nil
+}
+
+// over fills the colors t leaves empty from base
+func (t Theme) over(base Theme) Theme {
+	fill := func(v *string, fallback string) {
+		if *v == "" {
+			*v = fallback
+		}
+	}
+	fill(&t.Name, ThemeCustom)
+	fill(&t.Primary, base.Primary)
+	fill(&t.Secondary, base.Secondary)
+	fill(&t.Success, base.Success)
+	fill(&t.Warning, base.Warning)
+	fill(&t.Error, base.Error)
+	fill(&t.Muted, base.Muted)
+	fill(&t.Background, base.Background)
+	fill(&t.Foreground, base.Foreground)
+	fill(&t.Border, base.Border)
+	fill(&t.Highlight, base.Highlight)
+	fill(&t.Selected, base.Selected)
+	fill(&t.Outdated, base.Outdated)
+	fill(&t.Conflict, base.Conflict)
+	fill(&t.Added, base.Added)
+	fill(&t.Removed, base.Removed)
+	fill(&t.Info, base.Info)
+	fill(&t.Subtle, base.Subtle)
+	fill(&t.Accent, base.Accent)
+	fill(&t.Surface, base.Surface)
+	fill(&t.SuccessBg, base.SuccessBg)
+	fill(&t.ErrorBg, base.ErrorBg)
+	fill(&t.WarningBg, base.WarningBg)
+	fill(&t.InfoBg, base.InfoBg)
+	fill(&t.Syntax, base.Syntax)
+	return t
+}
+
+// ApplyTheme rebuilds every package style from t. Components cache their
+// own styles and must refresh them afterwards.
+func ApplyTheme(t Theme) {
+	activeTheme = t.over(Presets[ThemeDark])
+	setColors(activeTheme)
+	buildStyles()
+}
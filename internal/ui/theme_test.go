@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestPresetsAreComplete(t *testing.T) {
+	for _, name := range ThemeNames {
+		if name == ThemeCustom {
+			continue
+		}
+		preset, ok := Presets[name]
+		if !ok {
+			t.Fatalf("Missing preset %q", name)
+		}
+		if filled := preset.over(Theme{}); filled != preset {
+			t.Errorf("Preset %q leaves colors empty", name)
+		}
+	}
+}
+
+func TestNextThemeCycles(t *testing.T) {
+	name := ThemeDark
+	for range ThemeNames {
+		name = NextTheme(name)
+	}
+	if name != ThemeDark {
+		t.Errorf("Expected cycle back to dark, got %q", name)
+	}
+	if got := NextTheme(""); got != ThemeLight {
+		t.Errorf("Unset theme should behave as dark, got next %q", got)
+	}
+}
+
+func TestLoadThemeFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "theme.json")
+	os.WriteFile(path, []byte(`{"name":"mine","base":"light","primary":"#123456"}`), 0644)
+
+	theme, err := LoadTheme(ThemeCustom, path)
+	if err != nil {
+		t.Fatalf("LoadTheme failed: %v", err)
+	}
+	if theme.Primary != "#123456" || theme.Name != "mine" {
+		t.Errorf("Custom colors should win, got %+v", theme)
+	}
+	if theme.Foreground != Presets[ThemeLight].Foreground {
+		t.Errorf("Missing colors should come from the base preset, got %q", theme.Foreground)
+	}
+
+	if _, err := LoadTheme(ThemeCustom, filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected error for a missing custom theme")
+	}
+	os.WriteFile(path, []byte("{"), 0644)
+	if theme, err := LoadTheme(ThemeCustom, path); err == nil || theme.Name != ThemeDark {
+		t.Errorf("Broken theme file should fall back to dark, got (%q, %v)", theme.Name, err)
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	defer ApplyTheme(Presets[ThemeDark])
+
+	ApplyTheme(Presets[ThemeLight])
+	if Foreground != lipgloss.Color(Presets[ThemeLight].Foreground) {
+		t.Errorf("Expected light foreground, got %q", Foreground)
+	}
+	if ActiveTheme().Name != ThemeLight {
+		t.Errorf("Expected active theme light, got %q", ActiveTheme().Name)
+	}
+	if SyncedStyle.GetForeground() != Success {
+		t.Error("Styles should be rebuilt from the new palette")
+	}
+}
@@ -0,0 +1,364 @@
+package vfs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Mem is an in-memory filesystem for tests. It has no symlinks and no
+// owners; paths are cleaned and the root directory always exists.
+type Mem struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode
+	temps int // Names handed out by CreateTemp
+}
+
+type memNode struct {
+	mode    fs.FileMode
+	data    []byte
+	modTime time.Time
+}
+
+// NewMem returns an empty in-memory filesystem
+func NewMem() *Mem {
+	return &Mem{nodes: map[string]*memNode{
+		string(filepath.Separator): {mode: fs.ModeDir | 0755, modTime: time.Now()},
+	}}
+}
+
+func pathErr(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// lookup returns the node at a cleaned path; the lock must be held
+func (m *Mem) lookup(op, name string) (string, *memNode, error) {
+	name = filepath.Clean(name)
+	n, ok := m.nodes[name]
+	if !ok {
+		return name, nil, pathErr(op, name, fs.ErrNotExist)
+	}
+	return name, n, nil
+}
+
+// parentDir fails unless the parent of a cleaned path is a directory
+func (m *Mem) parentDir(op, name string) error {
+	parent, ok := m.nodes[filepath.Dir(name)]
+	if !ok {
+		return pathErr(op, name, fs.ErrNotExist)
+	}
+	if !parent.mode.IsDir() {
+		return pathErr(op, name, syscall.ENOTDIR)
+	}
+	return nil
+}
+
+// children returns the paths directly inside a cleaned dir path
+func (m *Mem) children(dir string) []string {
+	prefix := dir + string(filepath.Separator)
+	if dir == string(filepath.Separator) {
+		prefix = dir
+	}
+	var paths []string
+	for p := range m.nodes {
+		if p != dir && strings.HasPrefix(p, prefix) && !strings.ContainsRune(p[len(prefix):], filepath.Separator) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Stat returns the info of a file or directory
+func (m *Mem) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name, n, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return memInfo{filepath.Base(name), *n}, nil
+}
+
+// Lstat is Stat: Mem has no symlinks
+func (m *Mem) Lstat(name string) (fs.FileInfo, error) {
+	info, err := m.Stat(name)
+	if err != nil {
+		err.(*fs.PathError).Op = "lstat"
+	}
+	return info, err
+}
+
+// ReadFile returns a copy of a file's content
+func (m *Mem) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name, n, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.mode.IsDir() {
+		return nil, pathErr("read", name, syscall.EISDIR)
+	}
+	return append([]byte(nil), n.data...), nil
+}
+
+// WriteFile creates or truncates a file; perm applies to new files only
+func (m *Mem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	f, err := m.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	f.Write(data)
+	return f.Close()
+}
+
+// ReadDir lists a directory sorted by name
+func (m *Mem) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name, n, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, pathErr("readdirent", name, syscall.ENOTDIR)
+	}
+	var entries []fs.DirEntry
+	for _, p := range m.children(name) {
+		entries = append(entries, fs.FileInfoToDirEntry(memInfo{filepath.Base(p), *m.nodes[p]}))
+	}
+	return entries, nil
+}
+
+// MkdirAll creates a directory and any missing parents
+func (m *Mem) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	var missing []string
+	for p := path; ; p = filepath.Dir(p) {
+		if n, ok := m.nodes[p]; ok {
+			if !n.mode.IsDir() {
+				return pathErr("mkdir", p, syscall.ENOTDIR)
+			}
+			break
+		}
+		missing = append(missing, p)
+		if p == filepath.Dir(p) {
+			break
+		}
+	}
+	for _, p := range missing {
+		m.nodes[p] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	}
+	return nil
+}
+
+// Remove deletes a file or an empty directory
+func (m *Mem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name, n, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	if n.mode.IsDir() && len(m.children(name)) > 0 {
+		return pathErr("remove", name, syscall.ENOTEMPTY)
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+// RemoveAll deletes a path and everything below it
+func (m *Mem) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	for p := range m.nodes {
+		if p == path || strings.HasPrefix(p, path+string(filepath.Separator)) {
+			delete(m.nodes, p)
+		}
+	}
+	return nil
+}
+
+// Rename moves a file or a directory with everything below it
+func (m *Mem) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldpath, n, err := m.lookup("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	newpath = filepath.Clean(newpath)
+	if err := m.parentDir("rename", newpath); err != nil {
+		return err
+	}
+	if existing, ok := m.nodes[newpath]; ok && existing.mode.IsDir() != n.mode.IsDir() {
+		return pathErr("rename", newpath, syscall.EEXIST)
+	}
+	if n.mode.IsDir() && len(m.children(newpath)) > 0 {
+		return pathErr("rename", newpath, syscall.ENOTEMPTY)
+	}
+	moved := make(map[string]*memNode)
+	for p, node := range m.nodes {
+		if p == oldpath || strings.HasPrefix(p, oldpath+string(filepath.Separator)) {
+			moved[newpath+p[len(oldpath):]] = node
+			delete(m.nodes, p)
+		}
+	}
+	for p, node := range moved {
+		m.nodes[p] = node
+	}
+	return nil
+}
+
+// Chmod sets the permission bits of a file or directory
+func (m *Mem) Chmod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, n, err := m.lookup("chmod", name)
+	if err != nil {
+		return err
+	}
+	n.mode = n.mode&^fs.ModePerm | mode.Perm()
+	return nil
+}
+
+// OpenFile opens a file honoring O_CREATE, O_EXCL, O_TRUNC and O_APPEND
+func (m *Mem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	n, ok := m.nodes[name]
+	switch {
+	case ok && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, pathErr("open", name, fs.ErrExist)
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, pathErr("open", name, fs.ErrNotExist)
+	case !ok:
+		if err := m.parentDir("open", name); err != nil {
+			return nil, err
+		}
+		n = &memNode{mode: perm.Perm(), modTime: time.Now()}
+		m.nodes[name] = n
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if n.mode.IsDir() && writable {
+		return nil, pathErr("open", name, syscall.EISDIR)
+	}
+	if writable && flag&os.O_TRUNC != 0 {
+		n.data, n.modTime = nil, time.Now()
+	}
+	f := &memFile{fs: m, name: name, node: n, writable: writable}
+	if flag&os.O_APPEND != 0 {
+		f.offset = len(n.data)
+	}
+	return f, nil
+}
+
+// CreateTemp creates a new file in dir, with the last "*" in pattern
+// replaced by a counter
+func (m *Mem) CreateTemp(dir, pattern string) (File, error) {
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for {
+		m.mu.Lock()
+		m.temps++
+		name := filepath.Join(dir, fmt.Sprintf("%s%d%s", prefix, m.temps, suffix))
+		m.mu.Unlock()
+		f, err := m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil || !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
+
+// memFile is an open Mem file; writes land in the filesystem at once
+type memFile struct {
+	fs       *Mem
+	name     string
+	node     *memNode
+	offset   int
+	writable bool
+	closed   bool
+}
+
+func (f *memFile) Name() string { return f.name }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, pathErr("read", f.name, fs.ErrClosed)
+	}
+	if f.node.mode.IsDir() {
+		return 0, pathErr("read", f.name, syscall.EISDIR)
+	}
+	if f.offset >= len(f.node.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += n
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, pathErr("write", f.name, fs.ErrClosed)
+	}
+	if !f.writable {
+		return 0, pathErr("write", f.name, syscall.EBADF)
+	}
+	if end := f.offset + len(p); end > len(f.node.data) {
+		f.node.data = append(f.node.data, make([]byte, end-len(f.node.data))...)
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset += len(p)
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return memInfo{filepath.Base(f.name), *f.node}, nil
+}
+
+func (f *memFile) Sync() error { return nil }
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return pathErr("close", f.name, fs.ErrClosed)
+	}
+	f.closed = true
+	return nil
+}
+
+// memInfo is a snapshot of a node
+type memInfo struct {
+	name string
+	node memNode
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i memInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i memInfo) ModTime() time.Time { return i.node.modTime }
+func (i memInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
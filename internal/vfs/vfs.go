@@ -0,0 +1,198 @@
+// Package vfs is the filesystem scanner, sync and backup read and write
+// through. It mirrors the os functions they use and sends them to the
+// real filesystem, or to an in-memory one (Mem) in tests that simulate
+// several machines.
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// File is an open file: *os.File or a Mem file
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+	Name() string
+	Stat() (fs.FileInfo, error)
+	Sync() error
+}
+
+// FS is a filesystem with the methods of the os package of the same names
+type FS interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	ReadDir(name string) ([]fs.DirEntry, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
+	Chmod(name string, mode fs.FileMode) error
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	CreateTemp(dir, pattern string) (File, error)
+}
+
+// OS is the real filesystem
+var OS FS = osFS{}
+
+// current receives every call of the package functions
+var current = OS
+
+// Use sends the package functions to fsys until the returned restore is
+// called. Tests swap in a Mem this way; nothing else should.
+func Use(fsys FS) (restore func()) {
+	previous := current
+	current = fsys
+	return func() { current = previous }
+}
+
+// Real reports whether the package functions go to the real filesystem,
+// for what only it can do, like cloning files
+func Real() bool { return current == OS }
+
+// Stat is os.Stat on the current filesystem
+func Stat(name string) (fs.FileInfo, error) { return current.Stat(name) }
+
+// Lstat is os.Lstat on the current filesystem
+func Lstat(name string) (fs.FileInfo, error) { return current.Lstat(name) }
+
+// ReadFile is os.ReadFile on the current filesystem
+func ReadFile(name string) ([]byte, error) { return current.ReadFile(name) }
+
+// WriteFile is os.WriteFile on the current filesystem
+func WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return current.WriteFile(name, data, perm)
+}
+
+// ReadDir is os.ReadDir on the current filesystem
+func ReadDir(name string) ([]fs.DirEntry, error) { return current.ReadDir(name) }
+
+// MkdirAll is os.MkdirAll on the current filesystem
+func MkdirAll(path string, perm fs.FileMode) error { return current.MkdirAll(path, perm) }
+
+// Remove is os.Remove on the current filesystem
+func Remove(name string) error { return current.Remove(name) }
+
+// RemoveAll is os.RemoveAll on the current filesystem
+func RemoveAll(path string) error { return current.RemoveAll(path) }
+
+// Rename is os.Rename on the current filesystem
+func Rename(oldpath, newpath string) error { return current.Rename(oldpath, newpath) }
+
+// Chmod is os.Chmod on the current filesystem
+func Chmod(name string, mode fs.FileMode) error { return current.Chmod(name, mode) }
+
+// Open is os.Open on the current filesystem
+func Open(name string) (File, error) { return current.OpenFile(name, os.O_RDONLY, 0) }
+
+// Create is os.Create on the current filesystem
+func Create(name string) (File, error) {
+	return current.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile is os.OpenFile on the current filesystem
+func OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	return current.OpenFile(name, flag, perm)
+}
+
+// CreateTemp is os.CreateTemp on the current filesystem
+func CreateTemp(dir, pattern string) (File, error) { return current.CreateTemp(dir, pattern) }
+
+// WalkDir is filepath.WalkDir on the current filesystem
+func WalkDir(root string, fn fs.WalkDirFunc) error {
+	if current == OS {
+		return filepath.WalkDir(root, fn)
+	}
+	info, err := current.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDir(root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkDir follows filepath.WalkDir: lexical order, no symlinks followed
+func walkDir(path string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(path, d, nil); err != nil || !d.IsDir() {
+		if err == filepath.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := current.ReadDir(path)
+	if err != nil {
+		// Second call, to report the ReadDir error
+		if err = fn(path, d, err); err != nil {
+			if err == filepath.SkipDir && d.IsDir() {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, entry := range entries {
+		if err := walkDir(filepath.Join(path, entry.Name()), entry, fn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk is filepath.Walk on the current filesystem
+func Walk(root string, fn filepath.WalkFunc) error {
+	if current == OS {
+		return filepath.Walk(root, fn)
+	}
+	return WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(path, nil, err)
+		}
+		info, err := d.Info()
+		return fn(path, info, err)
+	})
+}
+
+// osFS calls the os package
+type osFS struct{}
+
+func (osFS) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
+func (osFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)   { return os.ReadFile(name) }
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Chmod(name string, mode fs.FileMode) error    { return os.Chmod(name, mode) }
+
+// The methods returning files never wrap a nil *os.File in a non-nil File
+
+func (osFS) CreateTemp(dir, pattern string) (File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (osFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,107 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMem(t *testing.T) {
+	m := NewMem()
+	if err := m.WriteFile("/home/me/.zshrc", nil, 0644); !os.IsNotExist(err) {
+		t.Errorf("Expected not-exist writing without a parent, got %v", err)
+	}
+	if err := m.MkdirAll("/home/me/.config/nvim", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteFile("/home/me/.config/nvim/init.lua", []byte("set nu"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := m.Stat("/home/me/.config/nvim/init.lua")
+	if err != nil || info.Size() != 6 || info.Mode().Perm() != 0600 || info.IsDir() {
+		t.Errorf("Unexpected info %+v, %v", info, err)
+	}
+	if err := m.MkdirAll("/home/me/.config/nvim/init.lua/x", 0755); err == nil {
+		t.Error("Expected an error making a dir under a file")
+	}
+
+	f, err := m.OpenFile("/home/me/.config/nvim/init.lua", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("\nset rnu"))
+	f.Close()
+	f, _ = m.OpenFile("/home/me/.config/nvim/init.lua", os.O_RDONLY, 0)
+	data, _ := io.ReadAll(f)
+	if string(data) != "set nu\nset rnu" {
+		t.Errorf("Expected the appended content, got %q", data)
+	}
+
+	if err := m.Remove("/home/me/.config"); err == nil {
+		t.Error("Expected an error removing a non-empty dir")
+	}
+	if err := m.Rename("/home/me/.config", "/home/me/config"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Stat("/home/me/config/nvim/init.lua"); err != nil {
+		t.Errorf("Expected the file moved with its dir: %v", err)
+	}
+	entries, _ := m.ReadDir("/home/me")
+	if len(entries) != 1 || entries[0].Name() != "config" || !entries[0].IsDir() {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	tmp, err := m.CreateTemp("/home/me", ".state.tmp-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp.Close()
+	if err := m.Rename(tmp.Name(), "/home/me/state.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	m.RemoveAll("/home/me/config")
+	if _, err := m.Stat("/home/me/config/nvim"); !os.IsNotExist(err) {
+		t.Errorf("Expected the tree removed, got %v", err)
+	}
+}
+
+// TestWalkDir checks that walks on a Mem match filepath.WalkDir on disk,
+// skips included
+func TestWalkDir(t *testing.T) {
+	files := []string{"a/x", "a/y", "b/c/z", "b/d", "e"}
+	dir := t.TempDir()
+	mem := NewMem()
+	for _, f := range files {
+		for _, fsys := range []FS{OS, mem} {
+			path := filepath.Join(dir, f)
+			fsys.MkdirAll(filepath.Dir(path), 0755)
+			fsys.WriteFile(path, nil, 0644)
+		}
+	}
+
+	walk := func(fsys FS) []string {
+		defer Use(fsys)()
+		var visited []string
+		WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			rel, _ := filepath.Rel(dir, path)
+			visited = append(visited, rel)
+			switch rel {
+			case "a/x":
+				return filepath.SkipDir // Skips the rest of a
+			case "b/c":
+				return filepath.SkipDir
+			}
+			return err
+		})
+		return visited
+	}
+	want, got := walk(OS), walk(mem)
+	if !slices.Equal(got, want) {
+		t.Errorf("Mem walk %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+// Package xattr handles extended attributes on synced files: macOS
+// quarantine and Spotlight metadata are stripped so restored scripts
+// aren't blocked by Gatekeeper, and other attributes can be carried over.
+package xattr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Policy controls what happens to extended attributes on push and pull
+type Policy string
+
+const (
+	PolicyStrip    Policy = "strip"    // Drop noisy attributes from written files (default)
+	PolicyPreserve Policy = "preserve" // Also record the other attributes and restore them on pull
+	PolicyOff      Policy = "off"      // Leave attributes alone
+)
+
+// Policies lists all policies in the order they cycle in settings
+var Policies = []Policy{PolicyStrip, PolicyPreserve, PolicyOff}
+
+// ParsePolicy converts a config string to a Policy, defaulting to PolicyStrip
+func ParsePolicy(s string) Policy {
+	for _, p := range Policies {
+		if string(p) == s {
+			return p
+		}
+	}
+	return PolicyStrip
+}
+
+// Next returns the next policy in the cycle
+func (p Policy) Next() Policy {
+	for i, policy := range Policies {
+		if policy == p {
+			return Policies[(i+1)%len(Policies)]
+		}
+	}
+	return PolicyStrip
+}
+
+// Noisy are attributes that are never synced; a trailing "*" matches a prefix
+var Noisy = []string{"com.apple.quarantine", "com.apple.metadata:*", "com.apple.lastuseddate#PS", "com.apple.provenance"}
+
+// ErrUnsupported is returned on platforms without extended attributes
+var ErrUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// IsNoisy reports whether an attribute is one of Noisy
+func IsNoisy(name string) bool {
+	for _, n := range Noisy {
+		if prefix, ok := strings.CutSuffix(n, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Kept returns the attributes of path worth syncing, leaving out Noisy ones.
+// Files on filesystems without attributes have none.
+func Kept(path string) (map[string][]byte, error) {
+	attrs, err := List(path)
+	if err != nil {
+		return nil, err
+	}
+	for name := range attrs {
+		if IsNoisy(name) {
+			delete(attrs, name)
+		}
+	}
+	return attrs, nil
+}
+
+// Strip removes the Noisy attributes from path
+func Strip(path string) error {
+	attrs, err := List(path)
+	if err != nil {
+		return err
+	}
+	for name := range attrs {
+		if IsNoisy(name) {
+			if err := Remove(path, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package xattr
+
+// List returns no attributes on platforms without them
+func List(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+// Set is unsupported on this platform
+func Set(path, name string, value []byte) error {
+	return ErrUnsupported
+}
+
+// Remove has nothing to remove on this platform
+func Remove(path, name string) error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package xattr
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestIsNoisy(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"com.apple.quarantine", true},
+		{"com.apple.metadata:kMDItemWhereFroms", true},
+		{"com.apple.FinderInfo", false},
+		{"user.comment", false},
+	}
+	for _, tt := range tests {
+		if got := IsNoisy(tt.name); got != tt.want {
+			t.Errorf("IsNoisy(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if ParsePolicy("") != PolicyStrip || ParsePolicy("preserve") != PolicyPreserve || PolicyOff.Next() != PolicyStrip {
+		t.Error("Unexpected policy parsing")
+	}
+}
+
+func TestKeptAndStrip(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("user.* attributes are Linux names")
+	}
+	path := filepath.Join(t.TempDir(), "script.sh")
+	os.WriteFile(path, []byte("#!/bin/sh"), 0755)
+	if err := Set(path, "user.comment", []byte("keep")); err != nil {
+		t.Skipf("Filesystem has no user attributes: %v", err)
+	}
+
+	old := Noisy
+	Noisy = append(Noisy, "user.quarantine")
+	t.Cleanup(func() { Noisy = old })
+	if err := Set(path, "user.quarantine", []byte("0081;")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	kept, err := Kept(path)
+	if err != nil {
+		t.Fatalf("Kept failed: %v", err)
+	}
+	if len(kept) != 1 || string(kept["user.comment"]) != "keep" {
+		t.Errorf("Expected only user.comment, got %v", kept)
+	}
+
+	if err := Strip(path); err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	attrs, _ := List(path)
+	if _, ok := attrs["user.quarantine"]; ok || len(attrs) != 1 {
+		t.Errorf("Expected the noisy attribute stripped, got %v", attrs)
+	}
+}
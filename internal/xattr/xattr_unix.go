@@ -0,0 +1,73 @@
+//go:build linux || darwin
+
+package xattr
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// List returns every extended attribute of path. Filesystems without
+// attribute support have none.
+func List(path string) (map[string][]byte, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, ignoreUnsupported(err)
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, ignoreUnsupported(err)
+	}
+
+	attrs := make(map[string][]byte)
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		value, err := get(path, string(name))
+		if err != nil {
+			return nil, err
+		}
+		attrs[string(name)] = value
+	}
+	return attrs, nil
+}
+
+func get(path, name string) ([]byte, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, size)
+	if size == 0 {
+		return value, nil
+	}
+	size, err = unix.Getxattr(path, name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value[:size], nil
+}
+
+// Set sets an extended attribute on path
+func Set(path, name string, value []byte) error {
+	return unix.Setxattr(path, name, value, 0)
+}
+
+// Remove removes an extended attribute from path; a missing one is fine
+func Remove(path, name string) error {
+	if err := unix.Removexattr(path, name); err != nil && !errors.Is(err, unix.ENODATA) {
+		return err
+	}
+	return nil
+}
+
+func ignoreUnsupported(err error) error {
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return nil
+	}
+	return err
+}
@@ -1,22 +1,69 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"dotsync/internal/audit"
+	"dotsync/internal/bootstrap"
+	"dotsync/internal/branchflow"
 	"dotsync/internal/brew"
+	"dotsync/internal/browser"
+	"dotsync/internal/changelog"
 	"dotsync/internal/config"
+	"dotsync/internal/crash"
 	"dotsync/internal/customapps"
+	"dotsync/internal/dashboard"
+	"dotsync/internal/desktop"
+	"dotsync/internal/digest"
+	"dotsync/internal/fswatch"
+	"dotsync/internal/fuzzy"
 	"dotsync/internal/git"
+	"dotsync/internal/health"
+	"dotsync/internal/i18n"
+	"dotsync/internal/layout"
+	"dotsync/internal/lock"
+	"dotsync/internal/logging"
+	"dotsync/internal/metrics"
 	"dotsync/internal/models"
+	"dotsync/internal/nestedrepo"
+	"dotsync/internal/notify"
+	"dotsync/internal/onboarding"
+	"dotsync/internal/peer"
+	"dotsync/internal/plugin"
+	"dotsync/internal/policy"
+	"dotsync/internal/porcelain"
+	"dotsync/internal/provision"
+	"dotsync/internal/registry"
+	"dotsync/internal/remote"
+	"dotsync/internal/report"
+	"dotsync/internal/review"
 	"dotsync/internal/scanner"
+	"dotsync/internal/scheduler"
+	"dotsync/internal/share"
+	"dotsync/internal/shellrc"
+	"dotsync/internal/sshconfig"
+	"dotsync/internal/subtree"
+	"dotsync/internal/symlink"
 	"dotsync/internal/sync"
+	"dotsync/internal/system"
 	"dotsync/internal/ui"
 	"dotsync/internal/ui/components"
+	"dotsync/internal/xattr"
 
 	// New modules for backup mode features
 	"dotsync/internal/backup"
@@ -34,21 +81,18 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 // Version info (set by ldflags)
 var (
 	version   = "dev"
 	buildTime = "unknown"
-	debugMode = false // Enable with --debug flag
-)
 
-// debugLog logs a message if debug mode is enabled
-func debugLog(format string, args ...interface{}) {
-	if debugMode {
-		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
-	}
-}
+	// reportPath is where --report writes the report of each push, pull or
+	// quick sync (JSON for .json, markdown otherwise)
+	reportPath string
+)
 
 // Screen represents different screens in the app
 type Screen int
@@ -60,15 +104,32 @@ const (
 	ScreenSyncing // Sync progress screen
 	ScreenConfirm // Confirmation screen before pull
 	ScreenHelp
-	ScreenDiff      // Diff viewer screen
-	ScreenGit       // Git operations screen
-	ScreenMerge     // Merge conflict resolution screen
-	ScreenCommit    // Commit message input screen
-	ScreenPreview   // File preview screen
-	ScreenSettings  // Settings screen
-	ScreenAddCustom // Add custom folder/app source
-	ScreenRestore   // Restore from another machine
-	ScreenQuickSync // Quick sync progress/result
+	ScreenDiff        // Diff viewer screen
+	ScreenGit         // Git operations screen
+	ScreenMerge       // Merge conflict resolution screen
+	ScreenCommit      // Commit message input screen
+	ScreenPreview     // File preview screen
+	ScreenSettings    // Settings screen
+	ScreenAddCustom   // Add custom folder/app source
+	ScreenRestore     // Restore from another machine
+	ScreenQuickSync   // Quick sync progress/result
+	ScreenConflicts   // Queue of conflicts skipped by pull
+	ScreenDefinitions // Definition anomalies (duplicate IDs, shared paths)
+	ScreenDigest      // Weekly activity digest
+	ScreenChangelog   // Per-app changes between two snapshots of the repo
+	ScreenAudit       // Audit log of sync operations
+	ScreenDashboard   // Sync health overview
+	ScreenEdit        // Built-in text editor
+	ScreenOrphans     // Orphaned app directories in dotfiles
+	ScreenReview      // Batch review of changed files, one diff at a time
+	ScreenSuggestions // Recommended actions
+	ScreenOnboarding  // First backup wizard for an empty dotfiles repo
+	ScreenMapPath     // Explicit repo path of a file
+	ScreenSyncResult  // Outcome of a push or pull with follow-up actions
+	ScreenAppInfo     // Details of one app: paths, sizes, sync times, history
+	ScreenDiffStat    // Lines changed locally in the selected apps
+	ScreenLogs        // Tail of the log file
+	ScreenResume      // Offer to resume a sync interrupted by a crash
 )
 
 // Panel represents which panel is focused
@@ -88,12 +149,44 @@ const (
 	SetupConfirm
 )
 
+// OnboardStep represents steps in the first backup wizard
+type OnboardStep int
+
+const (
+	OnboardPick    OnboardStep = iota // Choose the apps to back up
+	OnboardPushing                    // Pushing and committing
+	OnboardRemote                     // Optionally add a git remote and push
+)
+
 // SettingsField represents which field is being edited in settings
 type SettingsField int
 
 const (
 	SettingsDotfilesPath SettingsField = iota
 	SettingsBackupPath
+	SettingsGitUserName
+	SettingsGitUserEmail
+	SettingsGitSigningKey
+	SettingsRemoteBackend
+	SettingsRemoteTarget
+	SettingsMachineBranches
+	SettingsHealthChecks
+	SettingsBootstrap
+	SettingsReadOnly
+	SettingsAutoPush
+	SettingsIconSet
+	SettingsTheme
+	SettingsLanguage
+	SettingsDashboard
+	SettingsNotifications
+	SettingsDiffTool
+	SettingsMergeTool
+	SettingsNestedRepos
+	SettingsSymlinks
+	SettingsXattrs
+	SettingsConflictPolicy
+	SettingsDefinitions
+	SettingsOrphans
 	SettingsFieldCount // Used to wrap around
 )
 
@@ -135,6 +228,7 @@ type Model struct {
 	mergeView   *components.MergeView
 	gitPanel    *components.GitPanel
 	filePreview *components.FilePreview
+	textEditor  *components.TextEditor
 	spinner     spinner.Model
 	progress    progress.Model
 	help        help.Model
@@ -151,6 +245,10 @@ type Model struct {
 	height       int
 	syncing      bool
 	syncResults  []sync.ExportResult
+	syncReport   *report.SyncReport // Report of the last push, pull or quick sync
+	scannedAt    time.Time          // When m.apps was last scanned, for the status file
+	notifier     *notify.Notifier   // Desktop notifications for sync events
+	reportPath   string             // --report file, written after every sync
 
 	// Sync progress tracking
 	syncTotal   int
@@ -170,18 +268,89 @@ type Model struct {
 	addCustomName string
 
 	// Confirmation dialog
-	confirmAction SyncAction
-	confirmCursor int
-	fileDiffs     []FileDiff
+	confirmAction      SyncAction
+	confirmCursor      int
+	fileDiffs          []FileDiff
+	deletions          []sync.Deletion // Deletions the push/pull can propagate
+	propagateDeletions bool            // User chose to propagate deletions
 
 	// Diff viewer state
 	currentDiffFile *models.File
 	currentDiffApp  *models.App
+	diffBaseDir     string // Temp dir holding the dotfiles side read at diffView.Base
+	diffBaseInput   bool   // Typing a revision to compare with
+
+	// Built-in editor state
+	editApp     *models.App
+	editFile    *models.File
+	editReturn  Screen // Screen the editor was opened from
+	editDiscard bool   // Esc pressed once with unsaved changes
+
+	// Conflict queue (files pull refused to overwrite)
+	conflictQueue     []conflictItem
+	conflictCursor    int
+	resolvingConflict bool // Diff/merge was opened from the conflict queue
+
+	// Batch review queue; the merge screen returns to it while set
+	reviewQueue *review.Queue
+
+	// First backup wizard
+	onboardStep      OnboardStep
+	onboardChoices   []onboarding.Choice
+	onboardCursor    int
+	onboardAfterScan bool // Setup just finished; offer the wizard if dotfiles is empty
+
+	// File whose repo path is being edited
+	mapPathApp  *models.App
+	mapPathFile *models.File
+
+	// Cloning an existing dotfiles repo from the setup wizard
+	setupCloneURL    string // Git URL pasted instead of a path
+	restoreAfterScan bool   // The clone is in; offer a pull plan once scanned
+	onboardRestore   bool   // The wizard picks apps to pull rather than push
+
+	// Cancels the running scan; Esc on the scanning screen
+	scanCancel context.CancelFunc
+
+	// Filesystem events for both sides of each tracked file, to re-hash the
+	// ones edited outside dotsync; nil when polling (watchStamps) instead
+	fsWatcher  *fswatch.Watcher
+	watchRefs  map[string][]fileRef // Files by watched path
+	fsPending  []string             // Changed paths held back while state is being written
+	fsRetrying bool                 // An fsRetryMsg is on its way
+
+	// Size and ModTime of both sides of each tracked file at the last
+	// watch check
+	watchStamps map[string]fileStamp
+
+	// Outcome of the last push or pull (syncResults, syncAction)
+	syncErr          error // The whole sync failed
+	syncResultCursor int   // Failed file under the cursor
+	syncResultDetail bool  // Showing the error of the failed file in full
+
+	// Recommended actions; nil while they are being gathered
+	recommendations  []suggestions.Recommendation
+	recommendCursor  int
+	recommendLoading bool
+
+	// Definition anomalies detected during scan
+	anomalies     []scanner.Anomaly
+	anomalyRows   []anomalyRow
+	anomalyCursor int
+	anomalyDirty  bool // Overrides changed; rescan on exit
+
+	// Orphaned dotfiles cleanup
+	orphans       []sync.Orphan
+	orphanCursor  int
+	orphanConfirm string // App whose deletion waits for a second D
+
+	archiveConfirm string // Uninstalled app whose archiving waits for a second X
 
 	// Search state
-	searchMode   bool
-	searchQuery  string
-	filteredApps []*models.App
+	searchMode      bool
+	searchQuery     string
+	filteredApps    []*models.App
+	searchFileMatch map[string]string // app ID -> file path that matched the search
 
 	// Category filter
 	categoryFilter string
@@ -198,8 +367,58 @@ type Model struct {
 	suggestion    *suggestions.Suggestion
 	editorInst    editor.Editor
 
+	// Held while a push, pull or quick backup runs
+	syncLock *lock.Lock
+
+	// Audit log and its viewer
+	auditLog     *audit.Log
+	auditEntries []audit.Entry
+	auditCursor  int
+	auditFilter  audit.Filter
+
+	// Tail of the log file, opened from the audit log
+	logLines  []string
+	logOffset int // First visible line
+
+	// Sync left unfinished by a crash or kill, offered after the scan
+	interrupted *lock.Holder
+	crashReport string // Newest crash report, if written since it started
+
+	// App details popup
+	infoApp     *models.App
+	infoCommits []git.CommitInfo // Latest commits touching its files
+
+	// Diffstat of the selected apps against the dotfiles repo
+	diffStats      []sync.DiffStat
+	diffStatCursor int
+
 	// New: Quick sync state
-	quickSyncResult *quicksync.Result
+	quickSyncResult   *quicksync.Result
+	quickSyncCursor   int
+	diffFromQuickSync bool // Diff/merge was opened from the quick sync results
+	diffFromStat      bool // Diff was opened from the diffstat view
+
+	// Weekly digest
+	digest *digest.Digest
+
+	// Changelog screen
+	changelog       *changelog.Changelog
+	changelogUntil  time.Time // End of the week shown
+	changelogScroll int
+
+	// What upstream has that the dotfiles repo lacks, from the last
+	// background fetch; nil when nothing is waiting
+	upstream       *changelog.Changelog
+	upstreamReview bool     // The changelog screen shows upstream's changes
+	upstreamPulled []string // Tracked apps just pulled from upstream, named once rescanned
+
+	// Dashboard
+	dashboard     *dashboard.Summary
+	openDashboard bool // Show the dashboard when the startup scan completes
+
+	// Panel layout
+	zen           bool // Only the focused panel is shown, at full width
+	draggingSplit bool // The divider between the panels is being dragged
 
 	// New: Restore dialog state
 	restoreMachines        []backup.Machine
@@ -222,14 +441,41 @@ type FileDiff struct {
 
 // Messages
 type scanCompleteMsg struct {
-	apps []*models.App
-	err  error
+	apps      []*models.App
+	err       error
+	anomalies []scanner.Anomaly
+	warnings  []string // Detection sources that failed or timed out
+}
+
+// anomalyRow is one app ID of an anomaly on the definitions screen
+type anomalyRow struct {
+	anomaly int // Index into Model.anomalies
+	id      string
+}
+
+// retryFileMsg is the outcome of syncing one failed file again
+type retryFileMsg struct {
+	result sync.ExportResult
 }
 
 type syncCompleteMsg struct {
-	results []sync.ExportResult
-	err     error
-	action  string
+	results   []sync.ExportResult
+	err       error
+	action    string
+	health    []health.Result
+	conflicts []sync.ImportResult  // Files pull skipped because both sides changed
+	resolved  []sync.ImportResult  // Conflicts decided by a conflict policy
+	deleted   int                  // Deletions propagated to the other side
+	bootstrap []bootstrap.Script   // Install scripts of apps pulled here for the first time
+	plugins   []plugin.Result      // Plugin export and post-sync hooks that ran
+	scheduler *scheduler.Plan      // Pulled crontab and units waiting for confirmation
+	skipped   *remote.SkippedError // Files the remote backend couldn't hold; the rest was pushed
+}
+
+// conflictItem is a file waiting in the conflict queue
+type conflictItem struct {
+	app  *models.App
+	file *models.File
 }
 
 type syncProgressMsg struct {
@@ -243,8 +489,14 @@ type configSavedMsg struct {
 }
 
 type diffCompleteMsg struct {
-	diffs []FileDiff
-	err   error
+	diffs     []FileDiff
+	deletions []sync.Deletion
+	err       error
+}
+
+// diffStatMsg carries the diffstat computed in the background
+type diffStatMsg struct {
+	stats []sync.DiffStat
 }
 
 type refreshCompleteMsg struct {
@@ -253,15 +505,84 @@ type refreshCompleteMsg struct {
 	categoryFilter string
 }
 
+// onboardPushedMsg wraps the result of the wizard's first push
+type onboardPushedMsg struct {
+	result tea.Msg
+}
+
+// setupClonedMsg is sent when the setup wizard has cloned a dotfiles repo
+type setupClonedMsg struct {
+	err error
+}
+
+// restorePlanMsg carries the apps of a cloned repo matched to this machine
+type restorePlanMsg struct {
+	choices  []onboarding.Choice
+	layout   onboarding.Layout
+	unmapped []string
+}
+
+// onboardRemoteMsg is sent when the wizard has added a remote and pushed
+type onboardRemoteMsg struct {
+	url string
+	err error
+}
+
+// suggestionsMsg carries freshly gathered recommendations
+type suggestionsMsg struct {
+	recommendations []suggestions.Recommendation
+}
+
+// orphansMsg carries the orphaned dotfiles directories found by a scan
+type orphansMsg struct {
+	orphans []sync.Orphan
+	err     error
+}
+
 type lazygitFinishedMsg struct {
 	err error
 }
 
+// schedulerDoneMsg is sent when the pulled crontab and units were
+// confirmed and installed, or skipped
+type schedulerDoneMsg struct {
+	summary string
+	err     error
+}
+
+// bootstrapDoneMsg is sent when the bootstrap scripts after a pull finish
+type bootstrapDoneMsg struct {
+	results []bootstrap.Result
+	err     error
+}
+
+// externalToolMsg is sent when an external diff or merge tool exits
+type externalToolMsg struct {
+	app   *models.App
+	file  *models.File
+	tool  string
+	merge bool
+	err   error
+}
+
 func New() *Model {
 	cfg, _ := config.Load()
+	models.SetIconSet(models.IconSet(cfg.IconSet))
+	if cfg.Plain || cfg.Accessible {
+		models.SetIconSet(models.IconSetLabels) // Text labels read out better than glyphs
+	}
+	themeErr := loadTheme(cfg.Theme)
+	i18n.SetLanguage(i18n.Detect(cfg.Language))
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
+	if cfg.Plain {
+		s.Spinner = spinner.Line
+	}
+	if cfg.Accessible {
+		// A still frame, so screen readers aren't fed a redraw per tick
+		s.Spinner = spinner.Spinner{Frames: []string{"..."}, FPS: time.Second}
+	}
 	s.Style = ui.ProgressStyle
 
 	// Initialize progress bar with gradient
@@ -284,7 +605,10 @@ func New() *Model {
 
 	// Initialize state manager for conflict detection
 	stateManager := sync.NewStateManager(config.ConfigDir())
-	_ = stateManager.Load() // Load existing state if available
+	stateErr := stateManager.Load() // Load existing state if available
+	if err := sync.GetHashCache().Load(hashCachePath()); err != nil {
+		slog.Warn("loading hash cache failed", "err", err)
+	}
 
 	// Initialize modes config for sync/backup mode
 	modesCfg, _ := modes.Load()
@@ -298,6 +622,9 @@ func New() *Model {
 	// Initialize editor (auto-detect)
 	editorInst, _ := editor.Detect(nil)
 
+	// Every sync operation is appended to the audit log
+	auditLog := newAuditLog(modesCfg)
+
 	m := &Model{
 		config:        cfg,
 		stateManager:  stateManager,
@@ -305,12 +632,17 @@ func New() *Model {
 		backupManager: backupMgr,
 		quickSync:     qs,
 		editorInst:    editorInst,
+		auditLog:      auditLog,
+		reportPath:    reportPath,
+		notifier:      notify.New(!cfg.NotificationsOff),
+		fsWatcher:     newFSWatcher(),
 		appList:       components.NewAppList(nil),
 		fileList:      components.NewFileList(),
 		diffView:      components.NewDiffView(),
 		mergeView:     components.NewMergeView(),
 		gitPanel:      components.NewGitPanel(),
 		filePreview:   components.NewFilePreview(),
+		textEditor:    components.NewTextEditor(),
 		spinner:       s,
 		progress:      prog,
 		help:          help.New(),
@@ -325,13 +657,28 @@ func New() *Model {
 		setupStep:     SetupWelcome,
 	}
 
+	m.appList.Grouped = !cfg.FlatAppList
+	m.appList.Linear, m.fileList.Linear = cfg.Accessible, cfg.Accessible
+	m.openDashboard = cfg.Dashboard
+	m.loadInterrupted()
+
 	if cfg.FirstRun {
 		m.screen = ScreenSetup
 	}
+	if themeErr != nil {
+		m.status = fmt.Sprintf("Error loading theme: %v (using dark)", themeErr)
+	}
+	if stateErr != nil {
+		m.status = fmt.Sprintf("Error loading sync state: %v", stateErr)
+	}
 
 	// Initialize git panel with repo for header branch display
 	if cfg.IsGitRepo() {
 		repo := git.NewRepo(cfg.DotfilesPath)
+		m.gitPanel.Flow = m.branchFlow()
+		if err := m.enterMachineBranch(); err != nil {
+			m.status = fmt.Sprintf("Branch per machine: %v", err)
+		}
 		m.gitPanel.SetRepo(repo)
 	}
 
@@ -341,54 +688,436 @@ func New() *Model {
 func (m *Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
 	cmds = append(cmds, m.spinner.Tick)
+	if m.fsWatcher != nil {
+		cmds = append(cmds, m.waitFSEvents())
+	} else {
+		cmds = append(cmds, watchTick())
+	}
+	cmds = append(cmds, m.checkUpstream(true), fetchTick())
 
 	if m.screen == ScreenMain {
-		cmds = append(cmds, m.scanApps)
+		cmds = append(cmds, m.scanApps())
 	}
 
-	return tea.Batch(cmds...)
+	return crash.Cmd(tea.Batch(cmds...))
 }
 
-func (m *Model) scanApps() tea.Msg {
+// scanApps starts a scan that Esc on the scanning screen cancels
+func (m *Model) scanApps() tea.Cmd {
+	if m.scanCancel != nil {
+		m.scanCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.scanCancel = cancel
+	return func() tea.Msg { return m.scan(ctx) }
+}
+
+// cancelScan stops the running scan, which then reports what it found
+func (m *Model) cancelScan() bool {
+	if m.scanCancel == nil {
+		return false
+	}
+	m.scanCancel()
+	m.scanCancel = nil
+	return true
+}
+
+func (m *Model) scan(ctx context.Context) tea.Msg {
 	startTime := time.Now()
-	debugLog("Starting scan...")
+	slog.Debug("scan started")
 
-	s := scanner.New(m.config.AppsConfig)
+	// Desktop settings and the crontab are dumped to files first
+	captureSystemConfigs(m.config)
+
+	s := newScanner(m.config)
+	anomalies := scanner.DetectAnomalies(s.Definitions())
 
-	debugLog("Scanner created, starting parallel scan...")
 	scanStart := time.Now()
-	apps, err := s.Scan()
-	debugLog("Scan completed in %v, found %d apps", time.Since(scanStart), len(apps))
+	apps, err := s.ScanContext(ctx)
+	slog.Debug("scan completed", "apps", len(apps), "elapsed", time.Since(scanStart))
 
+	if errors.Is(err, context.Canceled) {
+		slog.Info("scan cancelled", "apps", len(apps))
+		return scanCompleteMsg{apps: apps, err: err, anomalies: anomalies, warnings: s.Warnings()}
+	}
 	if err != nil {
-		debugLog("Scan error: %v", err)
-		return scanCompleteMsg{apps: apps, err: err}
+		slog.Error("scan failed", "err", err)
+		return scanCompleteMsg{apps: apps, err: err, anomalies: anomalies, warnings: s.Warnings()}
 	}
 
-	debugLog("Starting hash-based sync status update...")
+	slog.Debug("sync status update started")
 	hashStart := time.Now()
-	for i, app := range apps {
-		debugLog("  [%d/%d] Updating sync status for %s (%d files)...", i+1, len(apps), app.Name, len(app.Files))
-		sync.UpdateSyncStatusWithHashes(app, m.config.DotfilesPath, m.stateManager)
+	if err := sync.UpdateSyncStatusAll(ctx, apps, m.config.DotfilesPath, m.stateManager); err != nil {
+		slog.Info("sync status update cancelled", "elapsed", time.Since(hashStart))
+		return scanCompleteMsg{apps: apps, err: err, anomalies: anomalies, warnings: s.Warnings()}
 	}
-	debugLog("Sync status update completed in %v", time.Since(hashStart))
+	slog.Debug("sync status update completed", "elapsed", time.Since(hashStart))
+	saveHashCache()
+
+	// Apps synced here before whose configs are gone locally
+	dotfilesApps, _ := s.ScanDotfiles(m.config.DotfilesPath)
+	apps = append(apps, sync.FindUninstalled(dotfilesApps, apps, m.stateManager)...)
+
+	warnings := s.Warnings()
+	slog.Info("scan finished", "apps", len(apps), "warnings", len(warnings), "elapsed", time.Since(startTime))
+	return scanCompleteMsg{apps: apps, err: err, anomalies: anomalies, warnings: warnings}
+}
+
+// hashCachePath is where file hashes are kept between runs
+func hashCachePath() string {
+	return filepath.Join(config.ConfigDir(), sync.HashCacheFile)
+}
+
+// saveHashCache keeps this scan's file hashes for the next one
+func saveHashCache() {
+	if err := sync.GetHashCache().Save(hashCachePath()); err != nil {
+		slog.Warn("saving hash cache failed", "err", err)
+	}
+}
 
-	debugLog("Total scan time: %v", time.Since(startTime))
-	return scanCompleteMsg{apps: apps, err: err}
+// largeFiles returns the selected files of selected apps that are at
+// least models.LargeFileSize, which are usually strays rather than configs
+func largeFiles(apps []*models.App) []string {
+	var large []string
+	for _, app := range apps {
+		for _, f := range app.Files {
+			if f.Selected && !f.Excluded && f.Large() {
+				large = append(large, app.ID+"/"+f.RelPath)
+			}
+		}
+	}
+	return large
 }
 
 func (m *Model) pushApps() tea.Msg {
+	pluginResults := m.pluginExports()
 	exporter := sync.NewExporter(m.config)
 	results, err := exporter.ExportAll(m.apps)
-	return syncCompleteMsg{results: results, err: err, action: "push"}
+	deleted, delErr := m.applyDeletions()
+	if err == nil {
+		err = delErr
+	}
+	if err == nil {
+		pluginResults = append(pluginResults, m.pluginPostSync("push", results)...)
+	}
+	return syncCompleteMsg{results: results, err: err, action: "push", deleted: deleted, plugins: pluginResults}
+}
+
+// pluginExports refreshes the desktop settings and crontab dumps and lets
+// plugins write their exports into the dotfiles repo before a push
+func (m *Model) pluginExports() []plugin.Result {
+	captureSystemConfigs(m.config)
+	runner, err := plugin.NewRunner(config.ConfigDir())
+	if err != nil {
+		slog.Warn("loading plugins failed", "err", err)
+	}
+	return runner.Export(context.Background(), m.config.DotfilesPath)
+}
+
+// pluginPostSync tells plugins which apps a push or pull just synced
+func (m *Model) pluginPostSync(action string, results []sync.ExportResult) []plugin.Result {
+	runner, err := plugin.NewRunner(config.ConfigDir())
+	if err != nil {
+		slog.Warn("loading plugins failed", "err", err)
+	}
+	var appIDs []string
+	for _, r := range results {
+		if r.Success && r.App != nil && !slices.Contains(appIDs, r.App.ID) {
+			appIDs = append(appIDs, r.App.ID)
+		}
+	}
+	return runner.PostSync(context.Background(), m.config.DotfilesPath, action, appIDs)
+}
+
+// findDeletions lists deletions a push (local) or pull (dotfiles) can propagate
+func (m *Model) findDeletions(side sync.DeletionSide) []sync.Deletion {
+	if m.stateManager == nil {
+		return nil
+	}
+	all, err := sync.FindDeletions(m.config, m.stateManager, newScanner(m.config).LocalPath)
+	if err != nil {
+		slog.Warn("finding deletions failed", "err", err)
+	}
+	var deletions []sync.Deletion
+	for _, d := range all {
+		if d.Side == side {
+			deletions = append(deletions, d)
+		}
+	}
+	return deletions
+}
+
+// applyDeletions propagates the pending deletions if the user opted in
+func (m *Model) applyDeletions() (int, error) {
+	if !m.propagateDeletions || len(m.deletions) == 0 || m.stateManager == nil {
+		return 0, nil
+	}
+	machine := ""
+	if m.modesConfig != nil {
+		machine = m.modesConfig.MachineName
+	}
+	n, err := sync.PropagateDeletions(m.config, m.stateManager, m.deletions, machine)
+	for _, d := range m.deletions {
+		side := "dotfiles copy"
+		if d.Side == sync.DeletedInDotfiles {
+			side = "local copy"
+		}
+		m.logAudit(audit.Entry{Action: audit.ActionDelete, AppID: d.AppID, File: d.RelPath, Result: audit.ResultOK, Detail: side})
+	}
+	return n, err
+}
+
+// newAuditLog opens the profile's audit log, stamping entries with this machine
+func newAuditLog(modesCfg *modes.ModesConfig) *audit.Log {
+	log := audit.New(audit.DefaultPath())
+	if modesCfg != nil {
+		log.WithMachine(modesCfg.MachineName)
+	}
+	return log
+}
+
+// acquireSyncLock takes the sync lock for command, showing who holds it
+// when another dotsync is already syncing
+func (m *Model) acquireSyncLock(command string) bool {
+	if m.blockedByReadOnly() {
+		return false
+	}
+	var appIDs []string
+	for _, app := range m.appList.SelectedApps() {
+		appIDs = append(appIDs, app.ID)
+	}
+	l, err := lock.Acquire(lock.Path(config.ConfigDir()), command, appIDs...)
+	if err != nil {
+		m.status = fmt.Sprintf("Error: %v", err)
+		return false
+	}
+	m.syncLock = l
+	return true
+}
+
+// blockedByReadOnly tells the user a write is disabled and returns true in
+// read-only mode
+func (m *Model) blockedByReadOnly() bool {
+	if err := m.config.CheckWritable(); err != nil {
+		m.status = "Error: " + err.Error()
+		return true
+	}
+	return false
+}
+
+// releaseSyncLock releases the sync lock once a sync has saved its state
+func (m *Model) releaseSyncLock() {
+	if err := m.syncLock.Release(); err != nil {
+		slog.Warn("releasing sync lock failed", "err", err)
+	}
+	m.syncLock = nil
+}
+
+// logAudit appends entries to the audit log and mirrors them to the log
+// file, failures and conflicts as warnings. Write errors only reach the log.
+func (m *Model) logAudit(entries ...audit.Entry) {
+	for _, e := range entries {
+		level := slog.LevelInfo
+		if e.Result == audit.ResultFailed || e.Result == audit.ResultConflict {
+			level = slog.LevelWarn
+		}
+		slog.Log(context.Background(), level, e.Action, "app", e.AppID, "file", e.File, "result", e.Result, "err", e.Error, "detail", e.Detail)
+	}
+	if m.auditLog == nil {
+		return
+	}
+	if err := m.auditLog.Append(entries...); err != nil {
+		slog.Warn("audit log write failed", "err", err)
+	}
+}
+
+// auditFile logs one operation on an app file
+func (m *Model) auditFile(action string, app *models.App, file *models.File, err error, detail string) {
+	if app == nil || file == nil {
+		return
+	}
+	result, message := audit.ResultFor(err)
+	hash, _ := sync.ComputeFileHash(file.Path)
+	m.logAudit(audit.Entry{Action: action, AppID: app.ID, File: file.RelPath, LocalHash: hash, Result: result, Error: message, Detail: detail})
+}
+
+// auditSync logs the per-file results of a push or pull and returns the
+// logged entries
+func (m *Model) auditSync(msg syncCompleteMsg) []audit.Entry {
+	action := audit.ActionPush
+	if msg.action == "pull" {
+		action = audit.ActionPull
+	}
+
+	var entries []audit.Entry
+	for _, r := range msg.results {
+		if r.App == nil {
+			continue
+		}
+		result, message := audit.ResultFor(r.Error)
+		if !r.Success && r.Error == nil {
+			result = audit.ResultSkipped
+		}
+		entries = append(entries, audit.Entry{
+			Action:       action,
+			AppID:        r.App.ID,
+			File:         r.File.RelPath,
+			LocalHash:    r.File.LocalHash,
+			DotfilesHash: r.File.DotfilesHash,
+			Result:       result,
+			Error:        message,
+			Detail:       msg.action,
+		})
+	}
+	for _, r := range msg.resolved {
+		for i := range entries {
+			if entries[i].AppID == r.App.ID && entries[i].File == r.File.RelPath {
+				entries[i].Detail = string(r.Policy)
+			}
+		}
+	}
+	for _, r := range msg.conflicts {
+		entries = append(entries, audit.Entry{
+			Action:       action,
+			AppID:        r.App.ID,
+			File:         r.File.RelPath,
+			LocalHash:    r.File.LocalHash,
+			DotfilesHash: r.File.DotfilesHash,
+			Result:       audit.ResultConflict,
+		})
+	}
+	m.logAudit(entries...)
+	return entries
+}
+
+// auditQuickSync logs what a quick backup did to each file and returns the
+// logged entries
+func (m *Model) auditQuickSync(result *quicksync.Result) []audit.Entry {
+	var entries []audit.Entry
+	for _, item := range result.Items {
+		entry := audit.Entry{
+			Action:       audit.ActionBackup,
+			AppID:        item.File.AppID,
+			File:         item.File.RelPath,
+			LocalHash:    item.File.LocalHash,
+			DotfilesHash: item.File.RemoteHash,
+			Detail:       string(item.Policy),
+		}
+		switch item.Outcome {
+		case quicksync.OutcomeBackedUp:
+			entry.Result = audit.ResultOK
+		case quicksync.OutcomePulled:
+			entry.Action = audit.ActionPull
+			entry.Result = audit.ResultOK
+		case quicksync.OutcomeFailed:
+			entry.Result, entry.Error = audit.ResultFor(item.Error)
+		case quicksync.OutcomeConflict:
+			entry.Result = audit.ResultConflict
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if result.Committed {
+		entries = append(entries, audit.Entry{Action: audit.ActionBackup, Result: audit.ResultOK, Detail: "commit: " + result.CommitMessage})
+	}
+	m.logAudit(entries...)
+	return entries
+}
+
+// writeStatusFile refreshes the status file read by status bars and
+// monitoring scripts. Failures only go to the log file.
+func (m *Model) writeStatusFile() {
+	if m.scannedAt.IsZero() {
+		return
+	}
+	machine := ""
+	if m.modesConfig != nil {
+		machine = m.modesConfig.MachineName
+	}
+	if err := metrics.Build(machine, m.apps, m.stateManager, m.scannedAt).Write(metrics.DefaultPath()); err != nil {
+		slog.Warn("status file write failed", "err", err)
+	}
+}
+
+// notify shows a desktop notification in the background, unless they are
+// turned off in Settings
+func (m *Model) notify(title, message string) tea.Cmd {
+	if m.notifier == nil || !m.notifier.Enabled {
+		return nil
+	}
+	n := m.notifier
+	return func() tea.Msg {
+		if err := n.Notify(title, message); err != nil {
+			slog.Warn("notification failed", "err", err)
+		}
+		return nil
+	}
+}
+
+// quickSyncNotification describes a finished quick backup
+func quickSyncNotification(result *quicksync.Result) (title, message string) {
+	switch {
+	case result.Error != nil:
+		return i18n.T("notify.backup_failed"), result.Error.Error()
+	case result.PushError != nil:
+		return i18n.T("notify.push_failed"), result.PushError.Error()
+	case result.SyncConflicts > 0:
+		return i18n.T("notify.conflicts"), i18n.T("notify.conflicts_backup", result.SyncConflicts)
+	}
+	return i18n.T("notify.backup_done"), result.Summary()
+}
+
+// recordSyncReport keeps the report of the operation that just finished,
+// for the save-report key, and writes it to the --report file if one was
+// given
+func (m *Model) recordSyncReport(action string, entries []audit.Entry, err error) error {
+	machine := ""
+	if m.modesConfig != nil {
+		machine = m.modesConfig.MachineName
+	}
+	m.syncReport = report.NewSyncReport(action, machine, entries, err)
+	if m.reportPath == "" {
+		return nil
+	}
+	return m.syncReport.WriteFile(m.reportPath)
+}
+
+// handleSaveReport writes the last sync report to the --report file, or
+// to a timestamped markdown file in the config dir
+func (m *Model) handleSaveReport() (tea.Model, tea.Cmd) {
+	if m.syncReport == nil {
+		m.status = "No sync report yet - push, pull or quick sync first"
+		return m, nil
+	}
+	path := m.reportPath
+	if path == "" {
+		name := m.syncReport.Action + "-" + m.syncReport.GeneratedAt.Format("20060102_150405") + ".md"
+		path = filepath.Join(config.ConfigDir(), "reports", strings.ReplaceAll(name, "+", "-"))
+	}
+	if err := m.syncReport.WriteFile(path); err != nil {
+		m.status = fmt.Sprintf("Error writing report: %v", err)
+	} else {
+		m.status = fmt.Sprintf("✓ Report written to %s", path)
+	}
+	return m, nil
 }
 
 func (m *Model) pullApps() tea.Msg {
-	importer := sync.NewImporter(m.config)
+	importer := sync.NewImporter(m.config).WithStateManager(m.stateManager)
 	var results []sync.ExportResult
+	var conflicts, resolved []sync.ImportResult
 	importResults, err := importer.ImportAll(m.apps)
 
 	for _, r := range importResults {
+		if r.Conflict {
+			conflicts = append(conflicts, r)
+			continue
+		}
+		if r.Policy != "" {
+			resolved = append(resolved, r)
+			slog.Info("conflict resolved", "app", r.App.ID, "file", r.File.RelPath, "policy", r.Policy, "kept_local", r.KeptLocal)
+		}
 		results = append(results, sync.ExportResult{
 			App:     r.App,
 			File:    r.File,
@@ -397,11 +1126,73 @@ func (m *Model) pullApps() tea.Msg {
 		})
 	}
 
-	return syncCompleteMsg{results: results, err: err, action: "pull"}
+	// Run health probes for apps that were restored
+	var healthResults []health.Result
+	if m.config.HealthChecks {
+		var appIDs []string
+		for _, r := range importResults {
+			if r.Success && r.App != nil {
+				appIDs = append(appIDs, r.App.ID)
+			}
+		}
+		healthResults = health.NewChecker().Run(context.Background(), appIDs)
+	}
+
+	// Install scripts run once per app on this machine, after the TUI
+	// hands over the terminal
+	var scripts []bootstrap.Script
+	if m.config.Bootstrap {
+		var appIDs []string
+		for _, r := range importResults {
+			if r.Success && r.App != nil {
+				appIDs = append(appIDs, r.App.ID)
+			}
+		}
+		if record, err := bootstrap.LoadRecord(config.ConfigDir()); err == nil {
+			scripts = record.Pending(m.config.DotfilesPath, appIDs)
+		} else {
+			slog.Warn("loading bootstrap record failed", "err", err)
+		}
+	}
+
+	// Pulled desktop settings only take effect once loaded back
+	// and pulled crontabs and units are installed after confirmation
+	capturer := newDesktop(m.config)
+	applied := make(map[string]bool)
+	var schedulerPlan *scheduler.Plan
+	for _, r := range importResults {
+		if !r.Success || r.App == nil || applied[r.App.ID] {
+			continue
+		}
+		if r.App.ID == scheduler.AppID {
+			applied[r.App.ID] = true
+			if plan := scheduler.New(config.ConfigDir()).Plan(); !plan.IsEmpty() {
+				schedulerPlan = plan
+			}
+		}
+		if r.App.ID == desktop.GnomeAppID || r.App.ID == desktop.KDEAppID {
+			applied[r.App.ID] = true
+			if applyErr := capturer.Apply(r.App.ID); applyErr != nil && err == nil {
+				err = applyErr
+			}
+		}
+	}
+
+	deleted, delErr := m.applyDeletions()
+	if err == nil {
+		err = delErr
+	}
+	var pluginResults []plugin.Result
+	if err == nil {
+		pluginResults = m.pluginPostSync("pull", results)
+	}
+
+	return syncCompleteMsg{results: results, err: err, action: "pull", health: healthResults, conflicts: conflicts, resolved: resolved, deleted: deleted, bootstrap: scripts, plugins: pluginResults, scheduler: schedulerPlan}
 }
 
 func (m *Model) scanDiffs() tea.Msg {
 	var diffs []FileDiff
+	lay := layout.For(m.config.DotfilesPath)
 
 	selected := m.appList.SelectedApps()
 	for _, app := range selected {
@@ -409,8 +1200,6 @@ func (m *Model) scanDiffs() tea.Msg {
 			continue
 		}
 
-		appDir := filepath.Join(m.config.DotfilesPath, app.ID)
-
 		for _, file := range app.Files {
 			if !file.Selected {
 				continue
@@ -427,7 +1216,7 @@ func (m *Model) scanDiffs() tea.Msg {
 			}
 
 			// Check dotfiles version
-			dotfilePath := filepath.Join(appDir, file.RelPath)
+			dotfilePath := layout.Find(lay, m.config.DotfilesPath, app.ID, file.RelPath, file.Path)
 			if info, err := os.Stat(dotfilePath); err == nil {
 				diff.DotfileExists = true
 				diff.DotfileModTime = info.ModTime().Format("2006-01-02 15:04")
@@ -448,11 +1237,12 @@ func (m *Model) scanDiffs() tea.Msg {
 		}
 	}
 
-	return diffCompleteMsg{diffs: diffs}
+	return diffCompleteMsg{diffs: diffs, deletions: m.findDeletions(sync.DeletedInDotfiles)}
 }
 
 func (m *Model) scanPushDiffs() tea.Msg {
 	var diffs []FileDiff
+	lay := layout.For(m.config.DotfilesPath)
 
 	selected := m.appList.SelectedApps()
 	for _, app := range selected {
@@ -460,8 +1250,6 @@ func (m *Model) scanPushDiffs() tea.Msg {
 			continue
 		}
 
-		appDir := filepath.Join(m.config.DotfilesPath, app.ID)
-
 		for _, file := range app.Files {
 			if !file.Selected {
 				continue
@@ -478,7 +1266,7 @@ func (m *Model) scanPushDiffs() tea.Msg {
 			}
 
 			// Check dotfiles version
-			dotfilePath := filepath.Join(appDir, file.RelPath)
+			dotfilePath := layout.Find(lay, m.config.DotfilesPath, app.ID, file.RelPath, file.Path)
 			if info, err := os.Stat(dotfilePath); err == nil {
 				diff.DotfileExists = true
 				diff.DotfileModTime = info.ModTime().Format("2006-01-02 15:04")
@@ -499,7 +1287,7 @@ func (m *Model) scanPushDiffs() tea.Msg {
 		}
 	}
 
-	return diffCompleteMsg{diffs: diffs}
+	return diffCompleteMsg{diffs: diffs, deletions: m.findDeletions(sync.DeletedLocally)}
 }
 
 func (m *Model) saveConfig() tea.Msg {
@@ -510,7 +1298,19 @@ func (m *Model) saveConfig() tea.Msg {
 	return configSavedMsg{err: err}
 }
 
+// Update handles a message. In accessible mode each new status is also
+// printed as a line above the view, where screen readers announce it.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer crash.Guard()
+	before := m.status
+	model, cmd := m.update(msg)
+	if m.config.Accessible && m.status != before && m.status != "" {
+		cmd = tea.Batch(cmd, tea.Println(ui.PlainText(m.status)))
+	}
+	return model, crash.Cmd(cmd)
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -522,6 +1322,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.helpVP.Width = m.width - 4
 			m.helpVP.Height = m.height - 4
 		}
+		if m.screen == ScreenEdit {
+			m.textEditor.SetSize(m.width-4, m.height-4)
+		}
 		return m, nil
 
 	case tea.KeyMsg:
@@ -534,6 +1337,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.filePreview, cmd = m.filePreview.Update(msg)
 			return m, cmd
 		}
+		return m.handleSplitDrag(msg)
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -547,43 +1351,72 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case scanCompleteMsg:
 		m.screen = ScreenMain
-		if msg.err != nil {
+		m.anomalies = msg.anomalies
+		m.scanCancel = nil
+		if errors.Is(msg.err, context.Canceled) {
+			// Show what was found; the status file and follow-up flows
+			// need a complete scan
+			m.apps = msg.apps
+			m.appList.SetApps(m.apps)
+			m.status = fmt.Sprintf("Scan cancelled: showing %d apps found so far (s to rescan)", len(m.apps))
+			m.openDashboard, m.onboardAfterScan, m.restoreAfterScan = false, false, false
+			m.upstreamPulled = nil
+		} else if msg.err != nil {
 			m.status = fmt.Sprintf("Error: %v", msg.err)
 			m.err = msg.err
 		} else {
 			m.apps = msg.apps
 			m.appList.SetApps(m.apps)
 			m.status = fmt.Sprintf("Found %d apps with configs", len(m.apps))
+			if len(msg.warnings) > 0 {
+				m.status += " • ⚠ " + strings.Join(msg.warnings, "; ")
+			}
+			if large := largeFiles(m.apps); len(large) > 0 {
+				m.status += " • " + i18n.T("scan.large_files", len(large), components.FormatBytes(models.LargeFileSize), large[0])
+			}
+			if len(m.upstreamPulled) > 0 {
+				m.status = i18n.T("upstream.pulled", strings.Join(m.upstreamPulled, ", "))
+				m.upstreamPulled = nil
+			}
+			m.scannedAt = time.Now()
+			m.writeStatusFile()
+			cmds = append(cmds, m.watchTracked())
+			if m.openDashboard {
+				m.openDashboard = false
+				_, cmd := m.handleDashboard()
+				cmds = append(cmds, cmd)
+			}
+			if m.onboardAfterScan {
+				m.onboardAfterScan = false
+				if onboarding.IsEmpty(m.config.DotfilesPath) {
+					m.startOnboarding()
+				}
+			}
+			if m.restoreAfterScan {
+				m.restoreAfterScan = false
+				m.status = "Matching cloned apps to this machine..."
+				cmds = append(cmds, m.buildRestorePlan(m.apps))
+			}
+			if m.interrupted != nil && m.screen == ScreenMain {
+				m.screen = ScreenResume
+			}
 		}
 
 	case syncCompleteMsg:
 		m.screen = ScreenMain
 		m.syncing = false
+		m.openSyncResult(msg)
 		if msg.err != nil {
 			m.status = fmt.Sprintf("Error: %v", msg.err)
+			if err := m.recordSyncReport(msg.action, nil, msg.err); err != nil {
+				m.status += fmt.Sprintf(" • Error writing report: %v", err)
+			}
 		} else {
 			success := 0
 			for _, r := range msg.results {
 				if r.Success {
 					success++
-					// Update sync state for successfully synced files
-					if m.stateManager != nil && r.App != nil {
-						localHash := r.File.LocalHash
-						dotfilesHash := r.File.DotfilesHash
-
-						// After sync, both hashes should be the same
-						if msg.action == "push" || msg.action == "push+commit" {
-							// After push, dotfiles now has the local content
-							dotfilesHash = localHash
-						} else {
-							// After pull, local now has the dotfiles content
-							localHash = dotfilesHash
-						}
-
-						if localHash != "" || dotfilesHash != "" {
-							m.stateManager.SetFileState(r.App.ID, r.File.RelPath, localHash, dotfilesHash)
-						}
-					}
+					m.recordFileState(msg.action, r)
 				}
 			}
 
@@ -591,6 +1424,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.stateManager != nil {
 				_ = m.stateManager.Save()
 			}
+			entries := m.auditSync(msg)
 
 			action := "Pushed"
 			nextHint := " • Press 'g' to commit changes"
@@ -601,23 +1435,201 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				nextHint = " • Committed and pushed to remote"
 			}
 			m.status = fmt.Sprintf("✓ %s %d/%d files%s", action, success, len(msg.results), nextHint)
+			if denied := permissionFailures(msg.results); denied > 0 {
+				m.status += fmt.Sprintf(" • %d denied: S retries with sudo, s skips", denied)
+			}
+			if len(msg.resolved) > 0 {
+				m.status += fmt.Sprintf(" • %s", policySummary(msg.resolved))
+			}
+			if msg.deleted > 0 {
+				m.status += fmt.Sprintf(" • %d deletions propagated", msg.deleted)
+			}
+			if msg.skipped != nil {
+				m.status += fmt.Sprintf(" • %d files left out of the %s", len(msg.skipped.Files), m.config.RemoteBackend)
+				slog.Warn("push skipped files", "err", msg.skipped)
+			}
+			if summary := plugin.Summary(msg.plugins); summary != "" {
+				m.status += " • " + summary
+				if err := plugin.Errors(msg.plugins); err != nil {
+					slog.Warn("plugin hooks failed", "err", err)
+				}
+			}
+			if summary := health.Summary(msg.health); summary != "" {
+				if health.HasFailures(msg.health) {
+					m.status = fmt.Sprintf("Error: %s after pull - restored config may be broken", summary)
+				} else {
+					m.status += " • " + summary
+				}
+			}
+
+			// Conflicted files were left untouched; queue them for review
+			if len(msg.conflicts) > 0 {
+				cmds = append(cmds, m.notify(i18n.T("notify.conflicts"), i18n.T("notify.conflicts_pull", len(msg.conflicts))))
+				m.setConflictQueue(msg.conflicts)
+				m.screen = ScreenConflicts
+				m.status = fmt.Sprintf("Pulled %d/%d files • %d conflicts skipped - resolve each via diff/merge",
+					success, len(msg.results), len(msg.conflicts))
+			}
+			if err := m.recordSyncReport(msg.action, entries, nil); err != nil {
+				m.status = fmt.Sprintf("Error writing report: %v", err)
+			}
+			m.writeStatusFile()
+			// Files pulled into new directories can be watched now
+			cmds = append(cmds, m.watchTracked())
+		}
+		m.releaseSyncLock()
+
+		// Confirm pulled scheduled jobs before bootstrap scripts, which
+		// may depend on them
+		var execs []tea.Cmd
+		if msg.err == nil && msg.scheduler != nil {
+			session := scheduler.NewSession(scheduler.New(config.ConfigDir()), msg.scheduler)
+			execs = append(execs, tea.Exec(session, func(err error) tea.Msg {
+				return schedulerDoneMsg{summary: session.Summary(), err: err}
+			}))
+		}
+		if msg.err == nil && len(msg.bootstrap) > 0 {
+			record, err := bootstrap.LoadRecord(config.ConfigDir())
+			if err != nil {
+				m.status = fmt.Sprintf("Error loading bootstrap record: %v", err)
+				return m, nil
+			}
+			session := bootstrap.NewSession(msg.bootstrap, record).WithPause()
+			execs = append(execs, tea.Exec(session, func(err error) tea.Msg {
+				return bootstrapDoneMsg{results: session.Results, err: err}
+			}))
+		}
+		if len(execs) > 0 {
+			return m, tea.Batch(append(cmds, tea.Sequence(execs...))...)
 		}
-		m.syncResults = msg.results
 
-	case syncProgressMsg:
-		m.syncCurrent = msg.current
-		m.syncTotal = msg.total
-		m.status = fmt.Sprintf("Syncing: %s", msg.file)
+	case retryFileMsg:
+		m.syncing = false
+		m.applyRetry(msg.result)
 		return m, nil
 
-	case diffCompleteMsg:
-		m.fileDiffs = msg.diffs
-		m.screen = ScreenConfirm
-		m.confirmCursor = 0
+	case dashboardMsg:
+		if m.screen != ScreenDashboard {
+			return m, nil
+		}
+		m.dashboard = msg.summary
+		return m, nil
 
-	case refreshCompleteMsg:
+	case onboardPushedMsg:
+		_, cmd := m.Update(msg.result)
+		if done, ok := msg.result.(syncCompleteMsg); !ok || done.err != nil {
+			return m, cmd
+		}
+		m.screen = ScreenOnboarding
+		m.onboardStep = OnboardRemote
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "git@github.com:you/dotfiles.git"
+		m.textInput.Focus()
+		return m, tea.Batch(cmd, textinput.Blink)
+
+	case setupClonedMsg:
+		m.syncing = false
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Error: %v", msg.err)
+			m.setupStep = SetupPath
+			m.textInput.SetValue(m.setupCloneURL)
+			m.textInput.Focus()
+			return m, textinput.Blink
+		}
+		m.setupCloneURL = ""
+		m.config.FirstRun = false
+		m.restoreAfterScan = true
+		return m, m.saveConfig
+
+	case restorePlanMsg:
+		if msg.layout != onboarding.LayoutDotsync || len(msg.choices) == 0 {
+			m.status = fmt.Sprintf("Cloned a %s repo: no app to restore", msg.layout)
+			if len(msg.unmapped) > 0 {
+				m.status += fmt.Sprintf(" • unknown dirs: %s", strings.Join(msg.unmapped, ", "))
+			}
+			return m, nil
+		}
+		m.onboardChoices = msg.choices
+		m.onboardCursor = 0
+		m.onboardStep = OnboardPick
+		m.onboardRestore = true
+		m.screen = ScreenOnboarding
+		m.status = ""
+		return m, nil
+
+	case onboardRemoteMsg:
+		m.syncing = false
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Error: %v", msg.err)
+			m.textInput.Focus()
+			return m, nil
+		}
 		m.screen = ScreenMain
+		m.status = fmt.Sprintf("✓ First backup pushed to %s", msg.url)
+		return m, nil
+
+	case suggestionsMsg:
+		if m.screen != ScreenSuggestions {
+			return m, nil
+		}
+		m.recommendations = msg.recommendations
+		m.recommendCursor = 0
+		m.recommendLoading = false
+		return m, nil
+
+	case changelogMsg:
+		if m.screen != ScreenChangelog {
+			return m, nil
+		}
+		m.changelog = msg.changelog
+		m.changelogScroll = 0
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Error building changelog: %v", msg.err)
+		} else {
+			m.status = ""
+		}
+		return m, nil
+
+	case digestMsg:
+		if m.screen != ScreenDigest {
+			return m, nil
+		}
+		m.digest = msg.digest
 		if msg.err != nil {
+			m.status = fmt.Sprintf("Error reading git history: %v", msg.err)
+		} else {
+			m.status = ""
+		}
+		return m, nil
+
+	case syncProgressMsg:
+		m.syncCurrent = msg.current
+		m.syncTotal = msg.total
+		m.status = fmt.Sprintf("Syncing: %s", msg.file)
+		return m, nil
+
+	case diffCompleteMsg:
+		m.fileDiffs = msg.diffs
+		m.deletions = msg.deletions
+		m.propagateDeletions = false
+		m.screen = ScreenConfirm
+		m.confirmCursor = 0
+
+	case diffStatMsg:
+		m.diffStats = msg.stats
+		m.diffStatCursor = 0
+		m.screen = ScreenDiffStat
+		m.status = ""
+
+	case refreshCompleteMsg:
+		m.screen = ScreenMain
+		m.scanCancel = nil
+		if errors.Is(msg.err, context.Canceled) {
+			m.apps = msg.apps
+			m.appList.SetApps(m.apps)
+			m.status = fmt.Sprintf("Refresh cancelled: showing %d apps found so far", len(m.apps))
+			m.updateFileList()
+		} else if msg.err != nil {
 			m.status = fmt.Sprintf("Refresh error: %v", msg.err)
 			m.err = msg.err
 		} else {
@@ -639,6 +1651,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.status = fmt.Sprintf("Refreshed: %d apps found", len(m.apps))
 			}
 			m.updateFileList()
+			cmds = append(cmds, m.watchTracked())
 		}
 
 	case configSavedMsg:
@@ -647,22 +1660,40 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.screen = ScreenScanning
 			m.status = "Scanning for apps..."
-			return m, m.scanApps
+			return m, m.scanApps()
 		}
 
 	case quickSyncCompleteMsg:
 		m.syncing = false
+		m.releaseSyncLock()
 		if msg.result == nil {
 			m.status = "Quick backup failed"
-			return m, nil
+			return m, m.notify(i18n.T("notify.backup_failed"), m.status)
+		}
+
+		m.quickSyncResult = msg.result
+		m.quickSyncCursor = 0
+		entries := m.auditQuickSync(msg.result)
+		m.writeStatusFile()
+		cmds = append(cmds, m.notify(quickSyncNotification(msg.result)))
+		if len(msg.result.Items) > 0 || msg.result.PushError != nil {
+			m.screen = ScreenQuickSync
+		}
+		if err := m.recordSyncReport("quicksync", entries, msg.result.Error); err != nil {
+			m.status = fmt.Sprintf("Error writing report: %v", err)
+			return m, tea.Batch(cmds...)
 		}
 
 		if msg.result.Error != nil {
 			m.status = fmt.Sprintf("Quick backup error: %v", msg.result.Error)
-			return m, nil
+			return m, tea.Batch(cmds...)
 		}
 
 		m.status = msg.result.Summary()
+		if msg.result.PushError != nil {
+			m.status = fmt.Sprintf("Error: backed up locally but push failed: %v", msg.result.PushError)
+			return m, tea.Batch(cmds...)
+		}
 
 		// If there are pending sync files, show count
 		if msg.result.HasSyncPending() {
@@ -679,6 +1710,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.status += " | Sync files:" + pendingInfo
 		}
 
+	case reviewAppliedMsg:
+		return m.handleReviewApplied(msg)
+
 	case conflictCheckMsg:
 		if msg.detection == nil {
 			m.status = "Conflict check failed"
@@ -708,6 +1742,79 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.status = "Editor closed"
 		}
 
+	case externalToolMsg:
+		return m.handleExternalToolDone(msg)
+
+	case rehashedMsg:
+		return m.handleRehashed(msg)
+
+	case fsChangedMsg:
+		cmds = append(cmds, m.waitFSEvents())
+		// A sync or scan sets the statuses of what it touches itself
+		if !m.syncing && m.scanCancel == nil {
+			cmds = append(cmds, m.rehashChanged(msg.paths))
+		}
+		return m, tea.Batch(cmds...)
+
+	case fsRetryMsg:
+		m.fsRetrying = false
+		if m.syncing || m.scanCancel != nil {
+			m.fsPending = nil
+			return m, nil
+		}
+		return m, m.rehashChanged(nil)
+
+	case watchTickMsg:
+		return m, m.checkWatched()
+
+	case fetchTickMsg:
+		// A sync may be committing or pulling the repo; try next time
+		if m.syncing {
+			return m, fetchTick()
+		}
+		return m, tea.Batch(m.checkUpstream(true), fetchTick())
+
+	case upstreamMsg:
+		return m.handleUpstream(msg)
+
+	case watchedMsg:
+		m.watchStamps = msg.stamps
+		if len(msg.changed) == 0 {
+			return m, watchTick()
+		}
+		return m, tea.Batch(m.rehashFiles(msg.changed, nil), watchTick())
+
+	case schedulerDoneMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Error: scheduled jobs: %v", msg.err)
+		} else if msg.summary != "" {
+			m.status += " • " + msg.summary
+		}
+		return m, nil
+
+	case bootstrapDoneMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Error: bootstrap: %v", msg.err)
+		} else if summary := bootstrap.Summary(msg.results); bootstrap.HasFailures(msg.results) {
+			m.status = fmt.Sprintf("Error: %s - fix the script and pull again to retry", summary)
+		} else {
+			m.status += " • " + summary
+		}
+		return m, nil
+
+	case orphansMsg:
+		if m.screen != ScreenOrphans {
+			return m, nil
+		}
+		m.orphans = msg.orphans
+		m.orphanCursor = 0
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Error scanning dotfiles: %v", msg.err)
+		} else {
+			m.status = fmt.Sprintf("%d orphaned directories", len(msg.orphans))
+		}
+		return m, nil
+
 	case lazygitFinishedMsg:
 		if msg.err != nil {
 			m.status = fmt.Sprintf("Lazygit error: %v", msg.err)
@@ -745,6 +1852,8 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleCommitKeys(msg)
 	case ScreenPreview:
 		return m.handlePreviewKeys(msg)
+	case ScreenEdit:
+		return m.handleEditKeys(msg)
 	case ScreenHelp:
 		if key.Matches(msg, m.keys.Escape, m.keys.Help, m.keys.Quit) {
 			m.screen = ScreenMain
@@ -758,10 +1867,47 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleSettingsKeys(msg)
 	case ScreenAddCustom:
 		return m.handleAddCustomKeys(msg)
+	case ScreenMapPath:
+		return m.handleMapPathKeys(msg)
+	case ScreenConflicts:
+		return m.handleConflictKeys(msg)
+	case ScreenReview:
+		return m.handleReviewKeys(msg)
+	case ScreenQuickSync:
+		return m.handleQuickSyncKeys(msg)
+	case ScreenDefinitions:
+		return m.handleDefinitionsKeys(msg)
+	case ScreenOrphans:
+		return m.handleOrphansKeys(msg)
+	case ScreenDigest:
+		return m.handleDigestKeys(msg)
+	case ScreenChangelog:
+		return m.handleChangelogKeys(msg)
+	case ScreenAudit:
+		return m.handleAuditKeys(msg)
+	case ScreenLogs:
+		return m.handleLogsKeys(msg)
+	case ScreenResume:
+		return m.handleResumeKeys(msg)
+	case ScreenAppInfo:
+		return m.handleAppInfoKeys(msg)
+	case ScreenDiffStat:
+		return m.handleDiffStatKeys(msg)
+	case ScreenDashboard:
+		return m.handleDashboardKeys(msg)
+	case ScreenSuggestions:
+		return m.handleSuggestionsKeys(msg)
+	case ScreenSyncResult:
+		return m.handleSyncResultKeys(msg)
+	case ScreenOnboarding:
+		return m.handleOnboardingKeys(msg)
 	case ScreenScanning:
 		if key.Matches(msg, m.keys.Quit) {
 			return m, tea.Quit
 		}
+		if key.Matches(msg, m.keys.Escape) && m.cancelScan() {
+			m.status = "Cancelling scan..."
+		}
 		return m, nil
 	case ScreenSyncing:
 		if key.Matches(msg, m.keys.Quit) {
@@ -786,15 +1932,31 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleSearchKeys(msg)
 	}
 
+	// Visual mode: movement extends the range; these keys finish it
+	if m.inVisual() {
+		switch {
+		case key.Matches(msg, m.keys.Escape):
+			m.appList.CancelVisual()
+			m.fileList.CancelVisual()
+			m.status = "Visual mode cancelled"
+			return m, nil
+		case key.Matches(msg, m.keys.Visual, m.keys.Space, m.keys.Enter):
+			return m.applyVisual()
+		}
+	}
+
 	switch {
 	case key.Matches(msg, m.keys.Quit):
 		return m, tea.Quit
 
 	case key.Matches(msg, m.keys.Escape):
-		// Esc: clear active filters (search or category)
-		if m.searchQuery != "" || m.categoryFilter != "" {
+		// Esc: clear active filters (search, category or status)
+		if m.searchQuery != "" || m.categoryFilter != "" || m.appList.StatusFilter != models.FilterNone {
 			return m.clearAllFilters()
 		}
+		if m.focusedPanel == PanelFiles && m.singlePanel() {
+			m.togglePanel() // Back to the app list
+		}
 		return m, nil
 
 	case key.Matches(msg, m.keys.Help):
@@ -835,6 +1997,53 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.handleToggle()
 		return m, nil
 
+	case key.Matches(msg, m.keys.Enter):
+		// Enter on a category header collapses or expands it
+		if m.focusedPanel == PanelApps && m.appList.OnHeader() {
+			m.appList.ToggleCollapse()
+			m.updateFileList()
+		} else if m.focusedPanel == PanelApps && m.singlePanel() && m.appList.Current() != nil {
+			m.togglePanel() // Into the app's files
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.GroupApps):
+		return m.handleGroupApps()
+
+	case key.Matches(msg, m.keys.SortStale):
+		return m.handleSortStale()
+
+	case key.Matches(msg, m.keys.ShrinkPanel):
+		return m.resizePanels(-5)
+
+	case key.Matches(msg, m.keys.GrowPanel):
+		return m.resizePanels(5)
+
+	case key.Matches(msg, m.keys.ResetSplit):
+		return m.resizePanels(0)
+
+	case key.Matches(msg, m.keys.Zen):
+		return m.handleZen()
+
+	case key.Matches(msg, m.keys.Visual):
+		if m.focusedPanel == PanelApps {
+			m.appList.StartVisual()
+		} else {
+			m.fileList.StartVisual()
+		}
+		m.status = "Visual mode: move to extend, V/Space to select, Esc to cancel"
+		return m, nil
+
+	case key.Matches(msg, m.keys.ToggleShown):
+		m.saveSelectionState()
+		if m.focusedPanel == PanelApps {
+			m.appList.ToggleVisible()
+		} else {
+			m.fileList.ToggleVisible()
+			m.syncFilesToApp()
+		}
+		return m, nil
+
 	case key.Matches(msg, m.keys.SelectAll):
 		m.handleSelectAll(true)
 		return m, nil
@@ -849,6 +2058,9 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.SelectOut):
 		return m.handleSelectOutdated()
 
+	case key.Matches(msg, m.keys.Filter):
+		return m.handleStatusFilter()
+
 	case key.Matches(msg, m.keys.Refresh):
 		return m.handleRefresh()
 
@@ -864,7 +2076,7 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.Scan):
 		m.screen = ScreenScanning
 		m.status = "Scanning..."
-		return m, m.scanApps
+		return m, m.scanApps()
 
 	case key.Matches(msg, m.keys.Diff):
 		return m.handleDiff()
@@ -888,6 +2100,10 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Expand directory or enter files panel
 		if m.focusedPanel == PanelFiles {
 			m.fileList.ToggleExpand()
+		} else if m.appList.OnHeader() {
+			if m.appList.IsCollapsed(m.appList.CurrentCategory()) {
+				m.appList.ToggleCollapse()
+			}
 		} else {
 			m.togglePanel()
 			m.updateFileList()
@@ -905,8 +2121,10 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.togglePanel()
 				m.updateFileList()
 			}
-		} else {
-			// Already in apps panel, do nothing
+		} else if m.appList.Grouped && !m.appList.IsCollapsed(m.appList.CurrentCategory()) {
+			// Collapse the category of the current app
+			m.appList.ToggleCollapse()
+			m.updateFileList()
 		}
 		return m, nil
 
@@ -916,7 +2134,7 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.searchMode = true
 		m.searchQuery = ""
 		m.textInput.SetValue("")
-		m.textInput.Placeholder = "Search apps..."
+		m.textInput.Placeholder = "Search apps and files..."
 		m.textInput.Focus()
 		m.status = "Type to search, Enter to confirm, Esc to cancel"
 		return m, textinput.Blink
@@ -947,12 +2165,47 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.QuickSync): // Q (Shift+Q): Quick Backup
 		return m.handleQuickSync()
 
-	case key.Matches(msg, m.keys.OpenEditor): // e: Open in Editor
-		return m.handleOpenEditor()
+	case key.Matches(msg, m.keys.OpenEditor): // e: Compare in editor
+		return m.handleOpenEditor(true)
+
+	case key.Matches(msg, m.keys.EditFile): // E: Edit local file
+		return m.handleOpenEditor(false)
 
 	case key.Matches(msg, m.keys.CheckConflict): // c: Check conflicts
 		return m.handleCheckConflicts()
 
+	case key.Matches(msg, m.keys.ConflictQueue): // C (Shift+C): Conflict queue
+		return m.handleConflictQueue()
+
+	case key.Matches(msg, m.keys.Review): // A (Shift+A): Review queue
+		return m.handleReview()
+	case key.Matches(msg, m.keys.Upstream): // U (Shift+U): Changes pushed from other machines
+		return m.handleUpstreamReview()
+
+	case key.Matches(msg, m.keys.Digest): // W (Shift+W): Weekly digest
+		return m.handleDigest()
+
+	case key.Matches(msg, m.keys.AuditLog): // H (Shift+H): Audit log
+		return m.handleAuditLog()
+
+	case key.Matches(msg, m.keys.AppInfo): // I (Shift+I): App details
+		return m.handleAppInfo()
+
+	case key.Matches(msg, m.keys.DiffStat): // #: Changes since last push
+		return m.handleDiffStat()
+
+	case key.Matches(msg, m.keys.Dashboard): // o: Dashboard
+		return m.handleDashboard()
+
+	case key.Matches(msg, m.keys.Suggestions): // T (Shift+T): Suggestions
+		return m.handleSuggestions()
+
+	case key.Matches(msg, m.keys.ArchiveApp): // X (Shift+X): Archive uninstalled app
+		return m.handleArchiveApp()
+
+	case key.Matches(msg, m.keys.SaveReport): // w: Write report of the last sync
+		return m.handleSaveReport()
+
 	case key.Matches(msg, m.keys.ToggleMode): // t: Toggle mode
 		return m.handleToggleMode()
 
@@ -961,6 +2214,15 @@ func (m *Model) handleMainKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case msg.String() == "P": // Shift+P: Push + Commit
 		return m.handlePushAndCommit()
+
+	case msg.String() == "x": // x: Exclude/re-include subtree
+		return m.handleToggleSubtree(false)
+
+	case msg.String() == "i": // i: Include-only subtree
+		return m.handleToggleSubtree(true)
+
+	case key.Matches(msg, m.keys.MapPath):
+		return m.handleMapPath()
 	}
 
 	return m, nil
@@ -1019,6 +2281,9 @@ func (m *Model) handleHomeEnd(home bool) {
 
 func (m *Model) handleToggle() {
 	if m.focusedPanel == PanelApps {
+		if m.appList.OnHeader() {
+			m.saveSelectionState() // Whole category changes at once
+		}
 		m.appList.Toggle()
 	} else {
 		m.fileList.Toggle()
@@ -1026,6 +2291,64 @@ func (m *Model) handleToggle() {
 	}
 }
 
+// inVisual reports whether the focused list is marking a range
+func (m *Model) inVisual() bool {
+	if m.focusedPanel == PanelApps {
+		return m.appList.InVisual()
+	}
+	return m.fileList.InVisual()
+}
+
+// applyVisual toggles the selection of the marked range in the focused list
+func (m *Model) applyVisual() (tea.Model, tea.Cmd) {
+	m.saveSelectionState()
+	if m.focusedPanel == PanelApps {
+		n := m.appList.ApplyVisual()
+		m.status = fmt.Sprintf("Toggled %d apps", n)
+	} else {
+		n := m.fileList.ApplyVisual()
+		m.syncFilesToApp()
+		m.status = fmt.Sprintf("Toggled %d files", n)
+	}
+	return m, nil
+}
+
+// handleGroupApps switches the app list between category groups and a
+// flat list, remembering the choice in the config
+func (m *Model) handleGroupApps() (tea.Model, tea.Cmd) {
+	m.appList.SetGrouped(!m.appList.Grouped)
+	m.config.FlatAppList = !m.appList.Grouped
+	m.updateFileList()
+	if err := m.config.Save(); err != nil {
+		m.status = fmt.Sprintf("Error saving config: %v", err)
+		return m, nil
+	}
+	if m.appList.Grouped {
+		m.status = "✓ Apps grouped by category"
+	} else {
+		m.status = "✓ Showing a flat app list"
+	}
+	return m, nil
+}
+
+// handleSortStale switches both lists between their usual order and the
+// least recently pushed or pulled first, keeping the cursor on the app
+func (m *Model) handleSortStale() (tea.Model, tea.Cmd) {
+	current := m.appList.Current()
+	m.appList.SortStale = !m.appList.SortStale
+	m.fileList.SortStale = m.appList.SortStale
+	if current != nil {
+		m.appList.FocusApp(current)
+	}
+	m.updateFileList()
+	if m.appList.SortStale {
+		m.status = "Sorted by last push or pull, never synced first • S restores the order"
+	} else {
+		m.status = "Sorted by name"
+	}
+	return m, nil
+}
+
 func (m *Model) handleSelectAll(selectAll bool) {
 	m.saveSelectionState() // Save before changing
 	if m.focusedPanel == PanelApps {
@@ -1051,6 +2374,9 @@ func (m *Model) syncFilesToApp() {
 }
 
 func (m *Model) handlePush() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
 	selectedApps := m.appList.SelectedApps()
 	if len(selectedApps) == 0 {
 		m.status = "No apps selected"
@@ -1079,6 +2405,9 @@ func (m *Model) handlePush() (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handlePull() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
 	if len(m.appList.SelectedApps()) == 0 {
 		m.status = "No apps selected"
 		return m, nil
@@ -1113,10 +2442,17 @@ func (m *Model) handleDiff() (tea.Model, tea.Cmd) {
 
 	m.currentDiffFile = currentFile
 	m.currentDiffApp = currentApp
+	m.resolvingConflict = false
+	m.diffFromQuickSync = false
+	m.diffFromStat = false
 
 	// Compute diff
 	localPath := currentFile.Path
-	dotfilePath := filepath.Join(m.config.DotfilesPath, currentApp.ID, currentFile.RelPath)
+	dotfilePath := sync.DotfilePath(m.config.DotfilesPath, currentApp.ID, *currentFile)
+
+	if m.config.DiffTool != "" && !currentFile.IsDir {
+		return m.runExternalTool(currentApp, currentFile, localPath, dotfilePath, false)
+	}
 
 	diffResult, err := sync.ComputeDiff(localPath, dotfilePath)
 	if err != nil {
@@ -1151,11 +2487,15 @@ func (m *Model) handleGit() (tea.Model, tea.Cmd) {
 
 	// Initialize git panel with repository
 	repo := git.NewRepo(m.config.DotfilesPath)
+	m.gitPanel.Flow = m.branchFlow()
+	branchErr := m.enterMachineBranch()
 	m.gitPanel.SetRepo(repo)
 	m.gitPanel.Width = m.width - 4
 	m.gitPanel.Height = m.height - 6
 	m.screen = ScreenGit
-	if m.status != "Git repository initialized" {
+	if branchErr != nil {
+		m.status = fmt.Sprintf("Branch per machine: %v", branchErr)
+	} else if m.status != "Git repository initialized" {
 		m.status = "Git operations"
 	}
 
@@ -1197,7 +2537,35 @@ func (m *Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "enter":
 			// Save the edited value
 			value := m.textInput.Value()
-			if value != "" {
+			if m.isGitIdentityField() {
+				// Identity fields may be cleared
+				switch m.settingsField {
+				case SettingsGitUserName:
+					m.config.GitUserName = strings.TrimSpace(value)
+				case SettingsGitUserEmail:
+					m.config.GitUserEmail = strings.TrimSpace(value)
+				case SettingsGitSigningKey:
+					m.config.GitSigningKey = strings.TrimSpace(value)
+				}
+
+				if err := m.config.Save(); err != nil {
+					m.status = fmt.Sprintf("Error saving config: %v", err)
+				} else if err := m.applyGitIdentity(); err != nil {
+					m.status = fmt.Sprintf("Saved, but git config error: %v", err)
+				} else {
+					m.status = "✓ Git identity applied to dotfiles repo"
+				}
+			} else if m.settingsField == SettingsRemoteTarget {
+				// Remote targets are rclone/S3 URLs, not local paths
+				m.config.RemoteTarget = strings.TrimSpace(value)
+				if err := m.config.Save(); err != nil {
+					m.status = fmt.Sprintf("Error saving config: %v", err)
+				} else if _, err := m.remoteBackend(); err != nil {
+					m.status = fmt.Sprintf("Saved, but remote is incomplete: %v", err)
+				} else {
+					m.status = "✓ Remote target saved"
+				}
+			} else if value != "" {
 				// Expand ~ to home directory
 				if strings.HasPrefix(value, "~/") {
 					homeDir, _ := os.UserHomeDir()
@@ -1219,6 +2587,7 @@ func (m *Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					if err := m.config.EnsureDirectories(); err != nil {
 						m.status = fmt.Sprintf("Saved, but dir error: %v", err)
 					} else if m.settingsField == SettingsDotfilesPath {
+						_ = m.applyGitIdentity()
 						m.status = fmt.Sprintf("Dotfiles path set to %s", value)
 					} else {
 						m.status = "Settings saved!"
@@ -1257,2255 +2626,8849 @@ func (m *Model) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "enter", " ":
-		// Start editing the current field
-		m.settingsEditing = true
-		switch m.settingsField {
-		case SettingsDotfilesPath:
-			m.textInput.SetValue(m.config.DotfilesPath)
-			m.textInput.Placeholder = "Enter dotfiles path..."
-		case SettingsBackupPath:
-			m.textInput.SetValue(m.config.BackupPath)
-			m.textInput.Placeholder = "Enter backup path..."
-		}
-		m.textInput.Focus()
-		return m, textinput.Blink
-	}
-
-	return m, nil
-}
-
-func (m *Model) handleAddCustom() (tea.Model, tea.Cmd) {
-	if m.focusedPanel != PanelApps {
-		m.status = "Switch to Apps panel to add custom source"
-		return m, nil
-	}
-
-	m.screen = ScreenAddCustom
-	m.addCustomStep = AddCustomStepName
-	m.addCustomMode = "folder"
-	m.addCustomName = ""
-	m.textInput.SetValue("")
-	m.textInput.Placeholder = "Enter source name (e.g. Hammerspoon)"
-	m.textInput.Focus()
-	m.status = "Add custom source"
-	return m, textinput.Blink
-}
-
-func (m *Model) handleAddCustomKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "esc", "q":
-		m.textInput.Blur()
-		m.screen = ScreenMain
-		m.status = "Cancelled adding custom source"
-		return m, nil
-
-	case "tab":
-		if m.addCustomMode == "folder" {
-			m.addCustomMode = "app"
-		} else {
-			m.addCustomMode = "folder"
+		// Boolean fields toggle in place
+		if m.settingsField == SettingsHealthChecks {
+			m.config.HealthChecks = !m.config.HealthChecks
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Health checks after pull: %s", onOff(m.config.HealthChecks))
+			}
+			return m, nil
 		}
-		return m, nil
-
-	case "enter":
-		if m.addCustomStep == AddCustomStepName {
-			name := strings.TrimSpace(m.textInput.Value())
-			if name == "" {
-				m.status = "Name is required"
+		if m.settingsField == SettingsMachineBranches {
+			if m.blockedByReadOnly() {
 				return m, nil
 			}
-			m.addCustomName = name
-			m.addCustomStep = AddCustomStepPaths
-			if m.addCustomMode == "folder" {
-				m.textInput.Placeholder = "Enter one path (e.g. ~/.hammerspoon)"
+			m.config.MachineBranches = !m.config.MachineBranches
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+				return m, nil
+			}
+			m.gitPanel.Flow = m.branchFlow()
+			if err := m.enterMachineBranch(); err != nil {
+				m.status = fmt.Sprintf("Saved, but cannot switch branch: %v", err)
+			} else if m.gitPanel.Flow != nil {
+				m.status = fmt.Sprintf("Branch per machine: on, pushing to %s", m.gitPanel.Flow.Branch)
 			} else {
-				m.textInput.Placeholder = "Enter path(s), comma-separated"
+				m.status = "Branch per machine: off"
 			}
-			m.textInput.SetValue("")
-			m.status = "Enter path(s)"
+			m.gitPanel.Refresh()
 			return m, nil
 		}
-
-		paths := parsePathsInput(m.textInput.Value())
-		def, err := customapps.BuildDefinition(customapps.FormInput{
-			Mode:  m.addCustomMode,
-			Name:  m.addCustomName,
-			Paths: paths,
-		})
-		if err != nil {
-			m.status = fmt.Sprintf("Error: %v", err)
+		if m.settingsField == SettingsBootstrap {
+			m.config.Bootstrap = !m.config.Bootstrap
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Bootstrap scripts after first pull: %s", onOff(m.config.Bootstrap))
+			}
 			return m, nil
 		}
-
-		storePath := m.config.AppsConfig
-		store := customapps.New(storePath)
-		if err := store.Add(def); err != nil {
-			m.status = fmt.Sprintf("Error: %v", err)
+		if m.settingsField == SettingsReadOnly {
+			if config.IsReadOnlyForced() {
+				m.status = "Read-only mode is forced by --read-only or $" + config.ReadOnlyEnv
+				return m, nil
+			}
+			m.config.ReadOnly = !m.config.ReadOnly
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Read-only mode: %s", onOff(m.config.ReadOnly))
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsAutoPush {
+			if m.modesConfig == nil {
+				m.status = "Modes not initialized"
+				return m, nil
+			}
+			m.modesConfig.AutoPush = !m.modesConfig.AutoPush
+			if err := m.modesConfig.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving modes: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Push after quick backup: %s", onOff(m.modesConfig.AutoPush))
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsDefinitions {
+			return m.handleDefinitions()
+		}
+		if m.settingsField == SettingsOrphans {
+			return m.handleOrphans()
+		}
+		if m.settingsField == SettingsRemoteBackend {
+			m.config.RemoteBackend = string(remote.ParseKind(m.config.RemoteBackend).Next())
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Remote backend: %s", m.config.RemoteBackend)
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsIconSet {
+			set := models.ParseIconSet(m.config.IconSet).Next()
+			m.config.IconSet = string(set)
+			models.SetIconSet(set)
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Status icons: %s", set)
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsTheme {
+			m.config.Theme = ui.NextTheme(m.config.Theme)
+			themeErr := m.applyTheme()
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else if themeErr != nil {
+				m.status = fmt.Sprintf("Error loading theme: %v (using dark)", themeErr)
+			} else {
+				m.status = fmt.Sprintf("✓ Theme: %s", m.config.Theme)
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsLanguage {
+			lang := i18n.Language().Next()
+			m.config.Language = string(lang)
+			i18n.SetLanguage(lang)
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = i18n.T("settings.language_set", lang.Name())
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsNotifications {
+			m.config.NotificationsOff = !m.config.NotificationsOff
+			m.notifier.Enabled = !m.config.NotificationsOff
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Desktop notifications: %s", onOff(!m.config.NotificationsOff))
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsDashboard {
+			m.config.Dashboard = !m.config.Dashboard
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Dashboard on startup: %s", onOff(m.config.Dashboard))
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsDiffTool || m.settingsField == SettingsMergeTool {
+			tool, label := &m.config.DiffTool, "Diff tool"
+			if m.settingsField == SettingsMergeTool {
+				tool, label = &m.config.MergeTool, "Merge tool"
+			}
+			*tool = editor.NextTool(*tool)
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else if t, err := editor.ParseTool(*tool); err == nil && !t.IsInstalled() {
+				m.status = fmt.Sprintf("%s: %s (not found in $PATH)", label, *tool)
+			} else {
+				m.status = fmt.Sprintf("%s: %s", label, toolName(*tool))
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsConflictPolicy {
+			m.config.Conflicts.Default = policy.Parse(string(m.config.Conflicts.Default)).Next()
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Conflict policy: %s (per-app/per-file overrides in config.json)", m.config.Conflicts.Default)
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsNestedRepos {
+			m.config.NestedRepos = string(nestedrepo.ParseMode(m.config.NestedRepos).Next())
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Nested git repos: %s", m.config.NestedRepos)
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsSymlinks {
+			m.config.Symlinks = string(symlink.ParsePolicy(m.config.Symlinks).Next())
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Symlinked configs: %s", m.config.Symlinks)
+			}
+			return m, nil
+		}
+		if m.settingsField == SettingsXattrs {
+			m.config.Xattrs = string(xattr.ParsePolicy(m.config.Xattrs).Next())
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			} else {
+				m.status = fmt.Sprintf("Extended attributes: %s", m.config.Xattrs)
+			}
 			return m, nil
 		}
 
-		m.textInput.Blur()
-		m.screen = ScreenScanning
-		m.status = fmt.Sprintf("Added custom source %q, rescanning...", def.Name)
-		return m, m.scanApps
+		// Start editing the current field
+		m.settingsEditing = true
+		switch m.settingsField {
+		case SettingsDotfilesPath:
+			m.textInput.SetValue(m.config.DotfilesPath)
+			m.textInput.Placeholder = "Enter dotfiles path..."
+		case SettingsBackupPath:
+			m.textInput.SetValue(m.config.BackupPath)
+			m.textInput.Placeholder = "Enter backup path..."
+		case SettingsGitUserName:
+			m.textInput.SetValue(m.config.GitUserName)
+			m.textInput.Placeholder = "Name for dotfiles commits (empty = unset)"
+		case SettingsGitUserEmail:
+			m.textInput.SetValue(m.config.GitUserEmail)
+			m.textInput.Placeholder = "Email for dotfiles commits (empty = unset)"
+		case SettingsGitSigningKey:
+			m.textInput.SetValue(m.config.GitSigningKey)
+			m.textInput.Placeholder = "GPG/SSH signing key (empty = unsigned)"
+		case SettingsRemoteTarget:
+			m.textInput.SetValue(m.config.RemoteTarget)
+			m.textInput.Placeholder = "rclone remote (b2:bucket/dotfiles), s3://bucket/dotfiles or a gist ID"
+		}
+		m.textInput.Focus()
+		return m, textinput.Blink
 	}
 
-	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
-	return m, cmd
+	return m, nil
 }
 
-func parsePathsInput(input string) []string {
-	parts := strings.FieldsFunc(input, func(r rune) bool {
-		return r == ',' || r == '\n'
-	})
-	paths := make([]string, 0, len(parts))
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			paths = append(paths, p)
-		}
+// newScanner creates a scanner with plugin definitions and the user's
+// definition overrides applied
+func newScanner(cfg *config.Config) *scanner.Scanner {
+	s := scanner.New(cfg.AppsConfig).
+		WithDefinitions(slices.Concat(registryDefinitions(), browserDefinitions(), newDesktop(cfg).Definitions(),
+			scheduler.New(config.ConfigDir()).Definitions(), pluginDefinitions())).
+		WithOverrides(scannerOverrides(cfg)).
+		WithFilters(cfg.SubtreeRules).
+		WithLimits(scanner.Limits{MaxDepth: cfg.ScanMaxDepth, MaxFiles: cfg.ScanMaxFiles, Ignore: cfg.ScanIgnore})
+	if cfg.SystemConfigs {
+		s.WithPathFilter(system.IsSystemPath) // Synced by `dotsync system` only
+	}
+	return s
+}
+
+// browserDefinitions lists the safe files of each browser's default profile
+func browserDefinitions() []models.AppDefinition {
+	home, _ := os.UserHomeDir()
+	return browser.Definitions(home)
+}
+
+// captureSystemConfigs dumps desktop settings and the crontab to their
+// staged files so they scan and push like configs
+func captureSystemConfigs(cfg *config.Config) {
+	if err := newDesktop(cfg).Capture(); err != nil {
+		slog.Warn("capturing desktop settings failed", "err", err)
+	}
+	if err := scheduler.New(config.ConfigDir()).Capture(); err != nil {
+		slog.Warn("capturing crontab failed", "err", err)
 	}
-	return paths
 }
 
-func (m *Model) handlePreview() (tea.Model, tea.Cmd) {
-	// Only preview when in Files panel
-	if m.focusedPanel != PanelFiles {
-		m.status = "Switch to Files panel to preview (Tab)"
-		return m, nil
+// newDesktop creates the GNOME/KDE settings capturer for the configured
+// dconf paths and KDE groups
+func newDesktop(cfg *config.Config) *desktop.Capturer {
+	return desktop.New(config.ConfigDir(), cfg.DconfPaths, cfg.KDEGroups)
+}
+
+// registryDefinitions loads the community catalog fetched by `dotsync registry`
+func registryDefinitions() []models.AppDefinition {
+	defs, err := registry.Load(config.ConfigDir())
+	if err != nil {
+		slog.Warn("loading registry catalog failed", "err", err)
 	}
+	return defs
+}
 
-	file := m.fileList.Current()
-	if file == nil {
-		m.status = "No file selected"
-		return m, nil
+// pluginDefinitions asks the installed plugins for extra app definitions
+func pluginDefinitions() []models.AppDefinition {
+	runner, err := plugin.NewRunner(config.ConfigDir())
+	if err != nil {
+		slog.Warn("loading plugins failed", "err", err)
+	}
+	defs, results := runner.Detect(context.Background())
+	if err := plugin.Errors(results); err != nil {
+		slog.Warn("plugin detectors failed", "err", err)
 	}
+	return defs
+}
 
-	// Set size and load file for preview
-	m.filePreview.SetSize(m.width-4, m.height-4)
-	if err := m.filePreview.Load(file.Path); err != nil {
-		m.status = fmt.Sprintf("Cannot preview: %v", err)
-		return m, nil
+// scannerOverrides converts config settings to scanner overrides
+func scannerOverrides(cfg *config.Config) scanner.Overrides {
+	return scanner.Overrides{Disabled: cfg.DisabledApps, Aliases: cfg.AppAliases}
+}
+
+// definitionsSummary describes unresolved definition anomalies for settings
+func (m *Model) definitionsSummary() string {
+	overrides := scannerOverrides(m.config)
+	unresolved := 0
+	for _, a := range m.anomalies {
+		if !a.Resolved(overrides) {
+			unresolved++
+		}
+	}
+	if unresolved == 0 {
+		return fmt.Sprintf("%d anomalies, all resolved", len(m.anomalies))
 	}
+	return fmt.Sprintf("%d to review (Enter)", unresolved)
+}
 
-	m.screen = ScreenPreview
-	m.status = "File preview - j/k scroll, mouse wheel, q to close"
+// handleDefinitions opens the definition maintenance screen
+func (m *Model) handleDefinitions() (tea.Model, tea.Cmd) {
+	m.anomalyRows = nil
+	for i, a := range m.anomalies {
+		for _, id := range a.IDs {
+			m.anomalyRows = append(m.anomalyRows, anomalyRow{anomaly: i, id: id})
+		}
+	}
+	m.anomalyCursor = 0
+	m.anomalyDirty = false
+	m.screen = ScreenDefinitions
+	m.status = "d: disable/enable app  •  a: alias to first app in group"
 	return m, nil
 }
 
-func (m *Model) handlePreviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+func (m *Model) handleDefinitionsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
 	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
-		m.screen = ScreenMain
-		m.status = "Ready"
+		if m.anomalyDirty {
+			m.screen = ScreenScanning
+			m.status = "Definitions updated, rescanning..."
+			return m, m.scanApps()
+		}
+		m.screen = ScreenSettings
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.anomalyCursor > 0 {
+			m.anomalyCursor--
+		}
 		return m, nil
 
+	case key.Matches(msg, m.keys.Down):
+		if m.anomalyCursor < len(m.anomalyRows)-1 {
+			m.anomalyCursor++
+		}
+		return m, nil
+	}
+
+	if m.anomalyCursor >= len(m.anomalyRows) {
+		return m, nil
+	}
+	row := m.anomalyRows[m.anomalyCursor]
+	anomaly := m.anomalies[row.anomaly]
+
+	switch msg.String() {
+	case "d":
+		if idx := indexOf(m.config.DisabledApps, row.id); idx >= 0 {
+			m.config.DisabledApps = append(m.config.DisabledApps[:idx], m.config.DisabledApps[idx+1:]...)
+			m.status = fmt.Sprintf("Enabled %s", row.id)
+		} else {
+			m.config.DisabledApps = append(m.config.DisabledApps, row.id)
+			m.status = fmt.Sprintf("Disabled %s", row.id)
+		}
+
+	case "a":
+		target := anomaly.IDs[0]
+		if anomaly.Kind != scanner.AnomalySharedConfigPath || row.id == target {
+			m.status = "Select a non-primary app in a shared-path group to alias"
+			return m, nil
+		}
+		if _, ok := m.config.AppAliases[row.id]; ok {
+			delete(m.config.AppAliases, row.id)
+			m.status = fmt.Sprintf("Removed alias %s", row.id)
+		} else {
+			if m.config.AppAliases == nil {
+				m.config.AppAliases = make(map[string]string)
+			}
+			m.config.AppAliases[row.id] = target
+			m.status = fmt.Sprintf("%s now merges into %s", row.id, target)
+		}
+
 	default:
-		// Forward all other keys to viewport for scrolling
-		var cmd tea.Cmd
-		m.filePreview, cmd = m.filePreview.Update(msg)
-		return m, cmd
+		return m, nil
 	}
+
+	if err := m.config.Save(); err != nil {
+		m.status = fmt.Sprintf("Error saving config: %v", err)
+	}
+	m.anomalyDirty = true
+	return m, nil
 }
 
-func (m *Model) handleDiffKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// handleOrphans opens the orphaned dotfiles cleanup screen
+func (m *Model) handleOrphans() (tea.Model, tea.Cmd) {
+	m.orphans = nil
+	m.orphanCursor = 0
+	m.orphanConfirm = ""
+	m.screen = ScreenOrphans
+	m.status = "Scanning dotfiles for orphaned directories..."
+	return m, m.findOrphans
+}
+
+// findOrphans looks for dotfiles app directories nothing maps to or tracks
+func (m *Model) findOrphans() tea.Msg {
+	_, unmapped := newScanner(m.config).ScanDotfiles(m.config.DotfilesPath)
+	orphans, err := sync.FindOrphans(m.config.DotfilesPath, unmapped, m.apps, m.stateManager)
+	return orphansMsg{orphans: orphans, err: err}
+}
+
+func (m *Model) handleOrphansKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
 	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
-		m.screen = ScreenMain
-		m.status = "Ready"
+		m.orphanConfirm = ""
+		m.screen = ScreenSettings
+		m.status = ""
 		return m, nil
 
 	case key.Matches(msg, m.keys.Up):
-		m.diffView.ScrollUp()
+		if m.orphanCursor > 0 {
+			m.orphanCursor--
+		}
+		m.orphanConfirm = ""
 		return m, nil
 
 	case key.Matches(msg, m.keys.Down):
-		m.diffView.ScrollDown()
+		if m.orphanCursor < len(m.orphans)-1 {
+			m.orphanCursor++
+		}
+		m.orphanConfirm = ""
 		return m, nil
+	}
 
-	case key.Matches(msg, m.keys.NextHunk):
-		m.diffView.NextHunk()
+	if m.orphanCursor >= len(m.orphans) {
 		return m, nil
-
-	case key.Matches(msg, m.keys.PrevHunk):
-		m.diffView.PrevHunk()
+	}
+	orphan := m.orphans[m.orphanCursor]
+	if (msg.String() == "a" || msg.String() == "D") && m.blockedByReadOnly() {
 		return m, nil
+	}
 
-	case key.Matches(msg, m.keys.KeepLocal):
-		// Keep local version - push to dotfiles
-		if m.currentDiffFile != nil && m.currentDiffApp != nil {
-			m.currentDiffFile.Selected = true
-			m.screen = ScreenMain
-			m.status = "Use 'p' to push local version to dotfiles"
+	var err error
+	switch msg.String() {
+	case "a":
+		var dest string
+		dest, err = sync.ArchiveOrphan(m.config, orphan, m.stateManager)
+		if err == nil {
+			m.status = fmt.Sprintf("Archived %s to %s", orphan.AppID, dest)
+			m.logAudit(audit.Entry{Action: audit.ActionDelete, AppID: orphan.AppID, Result: audit.ResultOK, Detail: "orphan archived to " + dest})
 		}
-		return m, nil
 
-	case key.Matches(msg, m.keys.UseDotfiles):
-		// Use dotfiles version - pull to local
-		if m.currentDiffFile != nil && m.currentDiffApp != nil {
-			m.currentDiffFile.Selected = true
-			m.screen = ScreenMain
-			m.status = "Use 'l' to pull dotfiles version to local"
+	case "D":
+		if m.orphanConfirm != orphan.AppID {
+			m.orphanConfirm = orphan.AppID
+			m.status = fmt.Sprintf("Press D again to delete %s (%d files) from dotfiles", orphan.AppID, orphan.Files)
+			return m, nil
+		}
+		err = sync.DeleteOrphan(orphan, m.stateManager)
+		if err == nil {
+			m.status = fmt.Sprintf("Deleted %s from dotfiles", orphan.AppID)
+			m.logAudit(audit.Entry{Action: audit.ActionDelete, AppID: orphan.AppID, Result: audit.ResultOK, Detail: "orphan deleted"})
 		}
-		return m, nil
-
-	case key.Matches(msg, m.keys.Merge):
-		// Open merge tool
-		return m.handleMerge()
 
-	case msg.String() == "h":
-		// Toggle syntax highlighting
-		m.diffView.ToggleHighlight()
+	default:
 		return m, nil
 	}
 
+	m.orphanConfirm = ""
+	if err != nil {
+		m.status = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
+	if m.stateManager != nil {
+		_ = m.stateManager.Save()
+	}
+	m.orphans = append(m.orphans[:m.orphanCursor], m.orphans[m.orphanCursor+1:]...)
+	if m.orphanCursor >= len(m.orphans) && m.orphanCursor > 0 {
+		m.orphanCursor--
+	}
 	return m, nil
 }
 
-func (m *Model) handleMerge() (tea.Model, tea.Cmd) {
-	// Get current diff and create merge result
-	if m.diffView.DiffResult == nil {
-		m.status = "No diff to merge"
+// handleArchiveApp moves the dotfiles of the uninstalled app under the
+// cursor into _archived/ after a second X
+func (m *Model) handleArchiveApp() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
+	app := m.appList.Current()
+	if m.focusedPanel != PanelApps || app == nil || !app.Uninstalled {
+		m.status = "X archives apps marked uninstalled"
+		return m, nil
+	}
+	if m.archiveConfirm != app.ID {
+		m.archiveConfirm = app.ID
+		m.status = fmt.Sprintf("Press X again to move %s's dotfiles into %s/", app.Name, sync.ArchivedDir)
 		return m, nil
 	}
+	m.archiveConfirm = ""
 
-	if m.diffView.DiffResult.Identical {
-		m.status = "Files are identical, no merge needed"
+	dest, err := sync.ArchiveApp(m.config, app.ID, m.stateManager)
+	if err != nil {
+		m.status = fmt.Sprintf("Error: %v", err)
 		return m, nil
 	}
+	if m.stateManager != nil {
+		_ = m.stateManager.Save()
+	}
+	m.logAudit(audit.Entry{Action: audit.ActionDelete, AppID: app.ID, Result: audit.ResultOK, Detail: "uninstalled app archived to " + dest})
 
-	// Create merge result from diff
-	mergeResult := sync.NewMergeResult(
-		m.diffView.DiffResult,
-		m.diffView.LocalPath,
-		m.diffView.DotfilePath,
-	)
+	for i, a := range m.apps {
+		if a == app {
+			m.apps = append(m.apps[:i], m.apps[i+1:]...)
+			break
+		}
+	}
+	m.appList.SetApps(m.apps)
+	m.updateFileList()
+	m.status = fmt.Sprintf("Archived %s to %s", app.Name, dest)
+	return m, nil
+}
 
-	m.mergeView.SetMerge(mergeResult)
-	m.mergeView.Width = m.width - 4
-	m.mergeView.Height = m.height - 6
-	m.screen = ScreenMerge
-	m.status = "Merge mode - resolve conflicts"
+// indexOf returns the index of s in list, or -1
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
 
-	return m, nil
+// isGitIdentityField returns true for settings applied to the dotfiles repo's git config
+func (m *Model) isGitIdentityField() bool {
+	switch m.settingsField {
+	case SettingsGitUserName, SettingsGitUserEmail, SettingsGitSigningKey:
+		return true
+	}
+	return false
 }
 
-func (m *Model) handleMergeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch {
-	case key.Matches(msg, m.keys.Escape):
-		// Go back to diff view
-		m.screen = ScreenDiff
-		m.status = "Back to diff view"
+// applyGitIdentity writes the configured identity to the dotfiles repo's local config
+func (m *Model) applyGitIdentity() error {
+	repo := git.NewRepo(m.config.DotfilesPath)
+	if !repo.IsRepo() {
+		return nil
+	}
+	return repo.SetIdentity(git.Identity{
+		Name:       m.config.GitUserName,
+		Email:      m.config.GitUserEmail,
+		SigningKey: m.config.GitSigningKey,
+	})
+}
+
+// branchFlow returns the branch-per-machine workflow of the dotfiles repo,
+// or nil when it is off
+func (m *Model) branchFlow() *branchflow.Flow {
+	return newBranchFlow(m.config, m.modesConfig)
+}
+
+// enterMachineBranch switches the dotfiles repo to this machine's branch
+// when the workflow is on, so sync pushes go there. Read-only mode leaves
+// the repo alone.
+func (m *Model) enterMachineBranch() error {
+	if m.gitPanel.Flow == nil || m.config.IsReadOnly() {
+		return nil
+	}
+	return m.gitPanel.Flow.Enter()
+}
+
+func newBranchFlow(cfg *config.Config, modesCfg *modes.ModesConfig) *branchflow.Flow {
+	if !cfg.MachineBranches || !cfg.IsGitRepo() {
+		return nil
+	}
+	machine := ""
+	if modesCfg != nil {
+		machine = modesCfg.MachineName
+	}
+	if machine == "" {
+		machine, _ = os.Hostname()
+	}
+	return branchflow.New(git.NewRepo(cfg.DotfilesPath), cfg.MainBranch, machine)
+}
+
+// remoteBackend creates the configured backend for the dotfiles store
+func (m *Model) remoteBackend() (remote.Backend, error) {
+	backend, err := remote.New(remote.ParseKind(m.config.RemoteBackend), m.config.DotfilesPath, m.config.RemoteTarget)
+	if err != nil {
+		return nil, err
+	}
+	remote.Limit(backend, m.storePaths())
+	return backend, nil
+}
+
+// remoteMirror creates the non-git part of the configured backend, or nil
+// when the store only lives in git
+func (m *Model) remoteMirror() (remote.Backend, error) {
+	mirror := remote.ParseKind(m.config.RemoteBackend).Mirror()
+	if mirror == "" {
+		return nil, nil
+	}
+	backend, err := remote.New(mirror, m.config.DotfilesPath, m.config.RemoteTarget)
+	if err != nil {
+		return nil, err
+	}
+	remote.Limit(backend, m.storePaths())
+	return backend, nil
+}
+
+// storePaths lists the store paths of selected and tracked files, plus the
+// layout file, for backends that hold only some of the store
+func (m *Model) storePaths() []string {
+	paths := []string{}
+	add := func(path string) {
+		rel, err := filepath.Rel(m.config.DotfilesPath, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	add(layout.FilePath(m.config.DotfilesPath))
+	for _, app := range m.apps {
+		for _, file := range app.Files {
+			if !file.Selected {
+				if m.stateManager == nil {
+					continue
+				}
+				if _, tracked := m.stateManager.GetFileState(app.ID, file.RelPath); !tracked {
+					continue
+				}
+			}
+			add(sync.DotfilePath(m.config.DotfilesPath, app.ID, file))
+		}
+	}
+	return paths
+}
+
+// splitSkipped separates a push that only left out files the backend
+// can't hold from a failed one
+func splitSkipped(err error) (*remote.SkippedError, error) {
+	if skipped, ok := err.(*remote.SkippedError); ok {
+		return skipped, nil
+	}
+	return nil, err
+}
+
+// pullRemote pulls the dotfiles repo from git, then from the mirror
+// backend if one is configured
+func (m *Model) pullRemote() error {
+	mirror, err := m.remoteMirror()
+	if err != nil {
+		return err
+	}
+	if remote.ParseKind(m.config.RemoteBackend).UsesGit() {
+		if err := m.gitPanel.Pull(); err != nil {
+			return err
+		}
+	}
+	if mirror != nil {
+		if err := mirror.Pull(); err != nil {
+			return err
+		}
+		m.gitPanel.Refresh()
+	}
+	return nil
+}
+
+// loadTheme applies the named UI theme; a broken custom theme falls back to dark
+func loadTheme(name string) error {
+	theme, err := ui.LoadTheme(name, config.ThemePath())
+	ui.ApplyTheme(theme)
+	return err
+}
+
+// applyTheme switches to the configured theme and refreshes component styles
+func (m *Model) applyTheme() error {
+	err := loadTheme(m.config.Theme)
+	m.spinner.Style = ui.ProgressStyle
+	m.diffView.ApplyTheme()
+	m.mergeView.ApplyTheme()
+	m.gitPanel.ApplyTheme()
+	m.filePreview.ApplyTheme()
+	m.textEditor.ApplyTheme()
+	return err
+}
+
+// onOff renders a boolean setting
+func onOff(enabled bool) string {
+	if enabled {
+		return i18n.T("settings.on")
+	}
+	return i18n.T("settings.off")
+}
+
+// policySummary describes conflicts decided by conflict policies
+func policySummary(resolved []sync.ImportResult) string {
+	counts := make(map[policy.Policy]int)
+	for _, r := range resolved {
+		counts[r.Policy]++
+	}
+	var parts []string
+	for _, p := range policy.Policies {
+		if counts[p] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", p, counts[p]))
+		}
+	}
+	return fmt.Sprintf("%d conflicts resolved by policy (%s)", len(resolved), strings.Join(parts, ", "))
+}
+
+func (m *Model) handleAddCustom() (tea.Model, tea.Cmd) {
+	if m.focusedPanel != PanelApps {
+		m.status = "Switch to Apps panel to add custom source"
 		return m, nil
+	}
 
-	case key.Matches(msg, m.keys.Quit):
+	m.screen = ScreenAddCustom
+	m.addCustomStep = AddCustomStepName
+	m.addCustomMode = "folder"
+	m.addCustomName = ""
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = "Enter source name (e.g. Hammerspoon)"
+	m.textInput.Focus()
+	m.status = "Add custom source"
+	return m, textinput.Blink
+}
+
+func (m *Model) handleAddCustomKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.textInput.Blur()
 		m.screen = ScreenMain
-		m.status = "Ready"
+		m.status = "Cancelled adding custom source"
 		return m, nil
 
-	case key.Matches(msg, m.keys.Up):
-		m.mergeView.ScrollUp()
+	case "tab":
+		if m.addCustomMode == "folder" {
+			m.addCustomMode = "app"
+		} else {
+			m.addCustomMode = "folder"
+		}
 		return m, nil
 
-	case key.Matches(msg, m.keys.Down):
-		m.mergeView.ScrollDown()
+	case "enter":
+		if m.addCustomStep == AddCustomStepName {
+			name := strings.TrimSpace(m.textInput.Value())
+			if name == "" {
+				m.status = "Name is required"
+				return m, nil
+			}
+			m.addCustomName = name
+			m.addCustomStep = AddCustomStepPaths
+			if m.addCustomMode == "folder" {
+				m.textInput.Placeholder = "Enter one path (e.g. ~/.hammerspoon)"
+			} else {
+				m.textInput.Placeholder = "Enter path(s), comma-separated"
+			}
+			m.textInput.SetValue("")
+			m.status = "Enter path(s)"
+			return m, nil
+		}
+
+		paths := parsePathsInput(m.textInput.Value())
+		def, err := customapps.BuildDefinition(customapps.FormInput{
+			Mode:  m.addCustomMode,
+			Name:  m.addCustomName,
+			Paths: paths,
+		})
+		if err != nil {
+			m.status = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+
+		storePath := m.config.AppsConfig
+		store := customapps.New(storePath)
+		if err := store.Add(def); err != nil {
+			m.status = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+
+		m.textInput.Blur()
+		m.screen = ScreenScanning
+		m.status = fmt.Sprintf("Added custom source %q, rescanning...", def.Name)
+		return m, m.scanApps()
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func parsePathsInput(input string) []string {
+	parts := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+	paths := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func (m *Model) handlePreview() (tea.Model, tea.Cmd) {
+	// Only preview when in Files panel
+	if m.focusedPanel != PanelFiles {
+		m.status = "Switch to Files panel to preview (Tab)"
 		return m, nil
+	}
 
-	case key.Matches(msg, m.keys.NextHunk):
-		m.mergeView.NextHunk()
+	file := m.fileList.Current()
+	if file == nil {
+		m.status = "No file selected"
 		return m, nil
+	}
 
-	case key.Matches(msg, m.keys.PrevHunk):
-		m.mergeView.PrevHunk()
+	// Set size and load file for preview
+	m.filePreview.SetSize(m.width-4, m.height-4)
+	if err := m.filePreview.Load(file.Path); err != nil {
+		m.status = fmt.Sprintf("Cannot preview: %v", err)
 		return m, nil
+	}
 
-	case key.Matches(msg, m.keys.KeepLocal):
-		m.mergeView.ResolveCurrentKeepLocal()
-		m.status = fmt.Sprintf("Resolved: keep local (%d/%d)",
-			m.mergeView.MergeResult.ResolvedHunks,
-			m.mergeView.MergeResult.TotalHunks)
+	m.screen = ScreenPreview
+	m.status = "File preview - j/k scroll, mouse wheel, q to close"
+	return m, nil
+}
+
+func (m *Model) handlePreviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.screen = ScreenMain
+		m.status = "Ready"
+		if m.filePreview.HasImage() {
+			// Terminal graphics outlive the text they were drawn with
+			return m, tea.ClearScreen
+		}
 		return m, nil
 
-	case key.Matches(msg, m.keys.UseDotfiles):
-		m.mergeView.ResolveCurrentUseDotfiles()
-		m.status = fmt.Sprintf("Resolved: use dotfiles (%d/%d)",
-			m.mergeView.MergeResult.ResolvedHunks,
-			m.mergeView.MergeResult.TotalHunks)
+	case key.Matches(msg, m.keys.EditHere):
+		file := m.fileList.Current()
+		if file == nil || file.IsDir {
+			m.status = "Select a file to edit"
+			return m, nil
+		}
+		if err := m.textEditor.Load(file.Path); err != nil {
+			m.status = fmt.Sprintf("Cannot edit: %v", err)
+			return m, nil
+		}
+		return m.openEditScreen(m.appList.Current(), file)
+
+	default:
+		// Forward all other keys to viewport for scrolling
+		var cmd tea.Cmd
+		m.filePreview, cmd = m.filePreview.Update(msg)
+		return m, cmd
+	}
+}
+
+// openEditScreen shows the built-in editor, already loaded, for file
+func (m *Model) openEditScreen(app *models.App, file *models.File) (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
 		return m, nil
+	}
+	m.editApp = app
+	m.editFile = file
+	m.editReturn = m.screen
+	m.editDiscard = false
+	m.textEditor.SetSize(m.width-4, m.height-4)
+	m.screen = ScreenEdit
+	m.status = "Editing - Ctrl+S save, Esc close"
+	return m, nil
+}
 
-	case key.Matches(msg, m.keys.Enter):
-		// Save merged file if fully resolved
-		if m.mergeView.IsFullyResolved() {
-			if err := m.mergeView.MergeResult.WriteMergedFile(); err != nil {
-				m.status = fmt.Sprintf("Error saving merge: %v", err)
-				return m, nil
+// handleEditKeys handles keys in the built-in editor
+func (m *Model) handleEditKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlS:
+		if err := m.textEditor.Save(); err != nil {
+			m.status = fmt.Sprintf("Error saving file: %v", err)
+			return m, nil
+		}
+		m.editDiscard = false
+		m.status = fmt.Sprintf("✓ Saved %s", filepath.Base(m.textEditor.Path))
+		if n := m.textEditor.ConflictMarkers(); n > 0 {
+			m.status = fmt.Sprintf("Saved %s • %d conflict markers left", filepath.Base(m.textEditor.Path), n)
+		}
+		if m.editApp != nil && m.editFile != nil {
+			if m.refreshFileSync(m.editApp, m.editFile) {
+				m.status += " • in sync with dotfiles"
 			}
-			m.screen = ScreenMain
-			m.status = "Merge saved successfully!"
-
-			// Update sync state
-			if m.stateManager != nil && m.currentDiffApp != nil && m.currentDiffFile != nil {
-				// Recompute hash after merge
-				newHash, _ := sync.ComputeFileHash(m.currentDiffFile.Path)
-				m.stateManager.SetFileState(
-					m.currentDiffApp.ID,
-					m.currentDiffFile.RelPath,
-					newHash,
-					newHash,
-				)
-				_ = m.stateManager.Save()
+			if m.editReturn == ScreenMerge {
+				m.auditFile(audit.ActionMerge, m.editApp, m.editFile, nil, "edited")
 			}
-		} else {
-			m.status = fmt.Sprintf("Resolve all hunks first (%d/%d)",
-				m.mergeView.MergeResult.ResolvedHunks,
-				m.mergeView.MergeResult.TotalHunks)
 		}
 		return m, nil
+
+	case tea.KeyEsc:
+		if m.textEditor.Dirty() && !m.editDiscard {
+			m.editDiscard = true
+			m.status = "Unsaved changes - Esc again to discard, Ctrl+S to save"
+			return m, nil
+		}
+		return m.closeEditScreen()
 	}
 
-	return m, nil
+	m.editDiscard = false
+	var cmd tea.Cmd
+	m.textEditor, cmd = m.textEditor.Update(msg)
+	return m, cmd
 }
 
-func (m *Model) handleConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Both push and pull have 2 options (0 and 1)
-	maxOptions := 1
+// closeEditScreen returns to the screen the editor was opened from,
+// refreshing its view of the file
+func (m *Model) closeEditScreen() (tea.Model, tea.Cmd) {
+	m.textEditor.Blur()
+	m.status = "Ready"
 
-	switch msg.String() {
+	switch m.editReturn {
+	case ScreenPreview:
+		if err := m.filePreview.Load(m.textEditor.Path); err != nil {
+			m.screen = ScreenMain
+			return m, nil
+		}
+		m.screen = ScreenPreview
+
+	case ScreenMerge:
+		// The merge was based on the old content; show the fresh diff
+		localPath, dotfilePath := m.diffView.LocalPath, m.diffView.DotfilePath
+		if diffResult, err := sync.ComputeDiff(localPath, dotfilePath); err == nil {
+			m.diffView.SetDiff(diffResult, localPath, dotfilePath)
+		}
+		m.screen = ScreenDiff
+
+	default:
+		m.screen = ScreenMain
+	}
+	return m, nil
+}
+
+func (m *Model) handleDiffKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.diffBaseInput {
+		switch msg.String() {
+		case "enter":
+			m.diffBaseInput = false
+			m.textInput.Blur()
+			return m.setDiffBase(strings.TrimSpace(m.textInput.Value()))
+		case "esc":
+			m.diffBaseInput = false
+			m.textInput.Blur()
+			m.status = ""
+			return m, nil
+		default:
+			var cmd tea.Cmd
+			m.textInput, cmd = m.textInput.Update(msg)
+			return m, cmd
+		}
+	}
+
+	if m.diffView.Base != "" && key.Matches(msg, m.keys.KeepLocal, m.keys.UseDotfiles, m.keys.Merge) {
+		m.status = i18n.T("diff.base.readonly", m.diffView.Base)
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.clearDiffBase()
+		if m.resolvingConflict {
+			m.screen = ScreenConflicts
+			m.status = fmt.Sprintf("%d conflicts remaining", len(m.conflictQueue))
+			return m, nil
+		}
+		if m.diffFromQuickSync {
+			m.screen = ScreenQuickSync
+			m.status = m.quickSyncResult.Summary()
+			return m, nil
+		}
+		if m.diffFromStat {
+			m.screen = ScreenDiffStat
+			m.status = ""
+			return m, nil
+		}
+		m.screen = ScreenMain
+		m.status = "Ready"
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		m.diffView.ScrollUp()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		m.diffView.ScrollDown()
+		return m, nil
+
+	case key.Matches(msg, m.keys.NextHunk):
+		m.diffView.NextHunk()
+		return m, nil
+
+	case key.Matches(msg, m.keys.PrevHunk):
+		m.diffView.PrevHunk()
+		return m, nil
+
+	case key.Matches(msg, m.keys.KeepLocal):
+		if m.resolvingConflict {
+			return m.resolveConflict(true)
+		}
+		// Keep local version - push to dotfiles
+		if m.currentDiffFile != nil && m.currentDiffApp != nil {
+			m.currentDiffFile.Selected = true
+			m.screen = ScreenMain
+			m.status = "Use 'p' to push local version to dotfiles"
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.UseDotfiles):
+		if m.resolvingConflict {
+			return m.resolveConflict(false)
+		}
+		// Use dotfiles version - pull to local
+		if m.currentDiffFile != nil && m.currentDiffApp != nil {
+			m.currentDiffFile.Selected = true
+			m.screen = ScreenMain
+			m.status = "Use 'l' to pull dotfiles version to local"
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Merge):
+		// Open merge tool
+		return m.handleMerge()
+
+	case msg.String() == "h":
+		// Toggle syntax highlighting
+		m.diffView.ToggleHighlight()
+		return m, nil
+
+	case msg.String() == "s":
+		// Toggle side-by-side view
+		m.diffView.ToggleSideBySide()
+		m.diffView.ScrollOffset = 0
+		return m, nil
+
+	case msg.String() == "t":
+		// Toggle key-level diff for JSON, YAML and TOML
+		m.diffView.ToggleStructural()
+		m.diffView.ScrollOffset = 0
+		return m, nil
+
+	case msg.String() == "b":
+		// Cycle the base: working copy, HEAD, then branches
+		return m.nextDiffBase()
+
+	case msg.String() == "B":
+		// Compare with any revision
+		if !git.NewRepo(m.config.DotfilesPath).IsRepo() {
+			m.status = i18n.T("diff.base.none")
+			return m, nil
+		}
+		m.diffBaseInput = true
+		m.textInput.SetValue(m.diffView.Base)
+		m.textInput.Placeholder = i18n.T("diff.base.prompt")
+		m.textInput.Focus()
+		m.status = i18n.T("diff.base.input")
+		return m, textinput.Blink
+	}
+
+	return m, nil
+}
+
+// diffBases lists the bases b cycles through: the working dotfiles copy,
+// HEAD, then the local and remote branches
+func (m *Model) diffBases() []string {
+	bases := []string{""}
+	repo := git.NewRepo(m.config.DotfilesPath)
+	if !repo.IsRepo() {
+		return bases
+	}
+	bases = append(bases, "HEAD")
+	bases = append(bases, repo.Branches()...)
+	return append(bases, repo.RemoteBranches()...)
+}
+
+// nextDiffBase compares with the base after the current one
+func (m *Model) nextDiffBase() (tea.Model, tea.Cmd) {
+	bases := m.diffBases()
+	if len(bases) == 1 {
+		m.status = i18n.T("diff.base.none")
+		return m, nil
+	}
+	next := 0
+	for i, base := range bases {
+		if base == m.diffView.Base {
+			next = (i + 1) % len(bases)
+			break
+		}
+	}
+	return m.setDiffBase(bases[next])
+}
+
+// setDiffBase recomputes the diff in view against the file's dotfiles
+// copy at rev, or against the working copy when rev is empty. A file the
+// revision doesn't have shows as added locally.
+func (m *Model) setDiffBase(rev string) (tea.Model, tea.Cmd) {
+	if m.currentDiffApp == nil || m.currentDiffFile == nil {
+		return m, nil
+	}
+	localPath := m.currentDiffFile.Path
+	dotfilePath := sync.DotfilePath(m.config.DotfilesPath, m.currentDiffApp.ID, *m.currentDiffFile)
+	basePath, baseDir := dotfilePath, ""
+
+	if rev != "" {
+		if m.currentDiffFile.IsDir {
+			m.status = "Directories can only be compared with the working copy"
+			return m, nil
+		}
+		rel, err := filepath.Rel(m.config.DotfilesPath, dotfilePath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			m.status = fmt.Sprintf("Diff error: %s is outside the dotfiles repo", dotfilePath)
+			return m, nil
+		}
+		content, err := git.NewRepo(m.config.DotfilesPath).ReadFile(rev, rel)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			m.status = fmt.Sprintf("Diff error: %v", err)
+			return m, nil
+		}
+		baseDir, err = os.MkdirTemp("", "dotsync-base-*")
+		if err != nil {
+			m.status = fmt.Sprintf("Diff error: %v", err)
+			return m, nil
+		}
+		// Same name as the dotfile, so highlighting and key-level diffs apply
+		basePath = filepath.Join(baseDir, filepath.Base(dotfilePath))
+		if content != nil {
+			if err := os.WriteFile(basePath, content, 0600); err != nil {
+				os.RemoveAll(baseDir)
+				m.status = fmt.Sprintf("Diff error: %v", err)
+				return m, nil
+			}
+		}
+	}
+
+	diffResult, err := sync.ComputeDiff(localPath, basePath)
+	if err != nil {
+		if baseDir != "" {
+			os.RemoveAll(baseDir)
+		}
+		m.status = fmt.Sprintf("Diff error: %v", err)
+		return m, nil
+	}
+	m.clearDiffBase()
+	m.diffBaseDir = baseDir
+	m.diffView.SetDiff(diffResult, localPath, basePath)
+	m.diffView.Base = rev
+	if rev == "" {
+		m.status = i18n.T("diff.base.working")
+	} else {
+		m.status = i18n.T("diff.base", rev)
+	}
+	return m, nil
+}
+
+// clearDiffBase removes the file read for a revision base and goes back to
+// comparing with the working copy
+func (m *Model) clearDiffBase() {
+	if m.diffBaseDir != "" {
+		os.RemoveAll(m.diffBaseDir)
+		m.diffBaseDir = ""
+	}
+	m.diffView.Base = ""
+}
+
+func (m *Model) handleMerge() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
+	// Get current diff and create merge result
+	if m.diffView.DiffResult == nil {
+		m.status = "No diff to merge"
+		return m, nil
+	}
+
+	if m.diffView.DiffResult.Identical {
+		m.status = "Files are identical, no merge needed"
+		return m, nil
+	}
+
+	if m.config.MergeTool != "" && m.currentDiffApp != nil && m.currentDiffFile != nil {
+		return m.runExternalTool(m.currentDiffApp, m.currentDiffFile, m.diffView.LocalPath, m.diffView.DotfilePath, true)
+	}
+
+	// Create merge result from diff
+	mergeResult := sync.NewMergeResult(
+		m.diffView.DiffResult,
+		m.diffView.LocalPath,
+		m.diffView.DotfilePath,
+	)
+
+	m.mergeView.SetMerge(mergeResult)
+	m.mergeView.Width = m.width - 4
+	m.mergeView.Height = m.height - 6
+	m.screen = ScreenMerge
+	m.status = "Merge mode - resolve conflicts"
+
+	return m, nil
+}
+
+func (m *Model) handleMergeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape):
+		// Go back to diff view
+		if m.reviewQueue != nil {
+			return m.showReviewItem()
+		}
+		m.screen = ScreenDiff
+		m.status = "Back to diff view"
+		return m, nil
+
+	case key.Matches(msg, m.keys.Quit):
+		if m.reviewQueue != nil {
+			return m.showReviewItem()
+		}
+		if m.resolvingConflict {
+			m.screen = ScreenConflicts
+			m.status = fmt.Sprintf("%d conflicts remaining", len(m.conflictQueue))
+			return m, nil
+		}
+		if m.diffFromQuickSync {
+			m.screen = ScreenQuickSync
+			m.status = m.quickSyncResult.Summary()
+			return m, nil
+		}
+		m.screen = ScreenMain
+		m.status = "Ready"
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		m.mergeView.ScrollUp()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		m.mergeView.ScrollDown()
+		return m, nil
+
+	case key.Matches(msg, m.keys.NextHunk):
+		m.mergeView.NextHunk()
+		return m, nil
+
+	case key.Matches(msg, m.keys.PrevHunk):
+		m.mergeView.PrevHunk()
+		return m, nil
+
+	case key.Matches(msg, m.keys.EditHere):
+		// Pending hunks become conflict markers to clean up by hand
+		content, err := m.mergeView.MergeResult.ContentWithMarkers()
+		if err != nil {
+			m.status = fmt.Sprintf("Cannot edit: %v", err)
+			return m, nil
+		}
+		if err := m.textEditor.SetContent(m.mergeView.MergeResult.LocalPath, content); err != nil {
+			m.status = fmt.Sprintf("Cannot edit: %v", err)
+			return m, nil
+		}
+		return m.openEditScreen(m.currentDiffApp, m.currentDiffFile)
+
+	case key.Matches(msg, m.keys.KeepLocal):
+		m.mergeView.ResolveCurrentKeepLocal()
+		m.status = fmt.Sprintf("Resolved: keep local (%d/%d)",
+			m.mergeView.MergeResult.ResolvedHunks,
+			m.mergeView.MergeResult.TotalHunks)
+		return m, nil
+
+	case key.Matches(msg, m.keys.UseDotfiles):
+		m.mergeView.ResolveCurrentUseDotfiles()
+		m.status = fmt.Sprintf("Resolved: use dotfiles (%d/%d)",
+			m.mergeView.MergeResult.ResolvedHunks,
+			m.mergeView.MergeResult.TotalHunks)
+		return m, nil
+
+	case msg.String() == "c":
+		return m.cherryPickPush()
+
+	case key.Matches(msg, m.keys.Enter):
+		// Save merged file if fully resolved
+		if m.mergeView.IsFullyResolved() {
+			if err := m.mergeView.MergeResult.WriteMergedFile(); err != nil {
+				m.status = fmt.Sprintf("Error saving merge: %v", err)
+				m.auditFile(audit.ActionMerge, m.currentDiffApp, m.currentDiffFile, err, "")
+				return m, nil
+			}
+			m.auditFile(audit.ActionMerge, m.currentDiffApp, m.currentDiffFile, nil, "")
+			if m.reviewQueue != nil {
+				// The merged local file is pushed with the rest of the batch
+				m.reviewQueue.Decide(review.Merged)
+				return m.showReviewItem()
+			}
+			if m.resolvingConflict {
+				// Merged content goes to both sides so the conflict is settled
+				return m.resolveConflict(true)
+			}
+			if m.diffFromQuickSync {
+				return m.finishQuickSyncMerge()
+			}
+			m.screen = ScreenMain
+			m.status = "Merge saved successfully!"
+
+			// Update sync state
+			if m.stateManager != nil && m.currentDiffApp != nil && m.currentDiffFile != nil {
+				// Recompute hash after merge
+				newHash, _ := sync.ComputeFileHash(m.currentDiffFile.Path)
+				m.stateManager.SetFileState(
+					m.currentDiffApp.ID,
+					m.currentDiffFile.RelPath,
+					newHash,
+					newHash,
+				)
+				_ = m.stateManager.Save()
+			}
+		} else {
+			m.status = fmt.Sprintf("Resolve all hunks first (%d/%d)",
+				m.mergeView.MergeResult.ResolvedHunks,
+				m.mergeView.MergeResult.TotalHunks)
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// cherryPickPush pushes only the hunks marked keep-local to dotfiles,
+// leaving the local file as it is. The file is recorded as partially
+// synced so later changes to it come back to the merge screen.
+func (m *Model) cherryPickPush() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() || m.currentDiffApp == nil || m.currentDiffFile == nil {
+		return m, nil
+	}
+	result := m.mergeView.MergeResult
+	picked := result.PickedHunks()
+	if picked == 0 {
+		m.status = "Mark the hunks to push with 1 first"
+		return m, nil
+	}
+
+	err := result.WriteCherryPick()
+	heldBack := result.TotalHunks - picked
+	m.auditFile(audit.ActionPush, m.currentDiffApp, m.currentDiffFile, err, fmt.Sprintf("cherry-pick: %d/%d hunks", picked, result.TotalHunks))
+	if err != nil {
+		m.status = fmt.Sprintf("Error pushing hunks: %v", err)
+		return m, nil
+	}
+
+	app, file := m.currentDiffApp, m.currentDiffFile
+	sync.GetHashCache().InvalidatePath(result.DotfilesPath)
+	file.LocalHash, _ = sync.ComputeFileHash(file.Path)
+	file.DotfilesHash, _ = sync.ComputeFileHash(result.DotfilesPath)
+	file.ConflictType = models.ConflictNone
+	if m.stateManager != nil {
+		if heldBack > 0 {
+			m.stateManager.SetPartialFileState(app.ID, file.RelPath, file.LocalHash, file.DotfilesHash)
+		} else {
+			m.stateManager.SetFileState(app.ID, file.RelPath, file.LocalHash, file.DotfilesHash)
+		}
+		m.stateManager.RecordPush(app.ID, file.RelPath)
+		_ = m.stateManager.Save()
+	}
+
+	status := fmt.Sprintf("✓ Pushed %d hunks of %s, %d kept local-only", picked, file.RelPath, heldBack)
+	switch {
+	case m.reviewQueue != nil:
+		m.reviewQueue.Decide(review.Picked)
+		model, cmd := m.showReviewItem()
+		m.status = status
+		return model, cmd
+	case m.resolvingConflict:
+		m.dropConflict(file)
+		m.screen = ScreenConflicts
+		if len(m.conflictQueue) == 0 {
+			m.resolvingConflict = false
+			m.screen = ScreenMain
+		}
+	default:
+		m.screen = ScreenMain
+	}
+	m.status = status
+	return m, nil
+}
+
+func (m *Model) handleConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Both push and pull have 2 options (0 and 1), plus a third that also
+	// propagates deletions when there are any
+	maxOptions := 1
+	if len(m.deletions) > 0 {
+		maxOptions = 2
+	}
+
+	switch msg.String() {
 	case "up", "k":
 		if m.confirmCursor > 0 {
 			m.confirmCursor--
 		}
-	case "down", "j":
-		if m.confirmCursor < maxOptions {
-			m.confirmCursor++
+	case "down", "j":
+		if m.confirmCursor < maxOptions {
+			m.confirmCursor++
+		}
+	case "enter", " ":
+		m.propagateDeletions = m.confirmCursor == 2
+		if m.propagateDeletions {
+			m.confirmCursor = int(ConfirmProceed)
+		}
+		if m.confirmAction == ActionPush {
+			// Push confirmation
+			switch ConfirmOption(m.confirmCursor) {
+			case ConfirmProceed:
+				if !m.acquireSyncLock("push") {
+					m.screen = ScreenMain
+					return m, nil
+				}
+				m.syncing = true
+				m.syncAction = "push"
+				m.syncTotal = len(m.fileDiffs)
+				m.syncCurrent = 0
+				m.screen = ScreenSyncing
+				m.status = fmt.Sprintf("Pushing %d files...", len(m.fileDiffs))
+				return m, m.pushApps
+			case ConfirmBackup: // Used as Cancel for push (index 1)
+				m.screen = ScreenMain
+				m.status = "Push cancelled"
+			}
+		} else {
+			// Pull confirmation (always backs up before pulling)
+			switch ConfirmOption(m.confirmCursor) {
+			case ConfirmProceed:
+				if !m.acquireSyncLock("pull") {
+					m.screen = ScreenMain
+					return m, nil
+				}
+				m.syncing = true
+				m.syncAction = "pull"
+				m.syncTotal = len(m.fileDiffs)
+				m.syncCurrent = 0
+				m.screen = ScreenSyncing
+				m.status = "Backing up and pulling..."
+				return m, m.pullApps
+			case ConfirmBackup: // Used as Cancel for pull (index 1)
+				m.screen = ScreenMain
+				m.status = "Pull cancelled"
+			}
+		}
+	case "esc", "q":
+		m.screen = ScreenMain
+		m.status = "Cancelled"
+	case "1":
+		m.confirmCursor = 0
+	case "2":
+		if maxOptions >= 1 {
+			m.confirmCursor = 1
+		}
+	case "3":
+		if maxOptions >= 2 {
+			m.confirmCursor = 2
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) handleSetupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.setupStep {
+	case SetupWelcome:
+		switch msg.String() {
+		case "enter", " ":
+			m.setupStep = SetupPath
+			m.textInput.SetValue(m.config.DotfilesPath)
+			m.textInput.Focus()
+			return m, textinput.Blink
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		}
+
+	case SetupPath:
+		switch msg.String() {
+		case "enter":
+			path := m.textInput.Value()
+			if path == "" {
+				path = m.config.DotfilesPath
+			}
+			m.setupCloneURL = ""
+			if git.IsURL(path) {
+				// Clone into the default location
+				m.setupCloneURL = strings.TrimSpace(path)
+				path = m.config.DotfilesPath
+			}
+			if strings.HasPrefix(path, "~/") {
+				homeDir, _ := os.UserHomeDir()
+				path = filepath.Join(homeDir, path[2:])
+			}
+			m.config.DotfilesPath = path
+			m.setupStep = SetupConfirm
+			m.textInput.Blur()
+		case "esc":
+			m.setupStep = SetupWelcome
+			m.textInput.Blur()
+		case "1", "2", "3":
+			paths := config.SuggestedPaths()
+			idx := int(msg.String()[0] - '1')
+			if idx < len(paths) {
+				m.textInput.SetValue(paths[idx])
+			}
+		default:
+			var cmd tea.Cmd
+			m.textInput, cmd = m.textInput.Update(msg)
+			return m, cmd
+		}
+
+	case SetupConfirm:
+		if m.syncing {
+			return m, nil
+		}
+		switch msg.String() {
+		case "enter", "y":
+			if m.setupCloneURL != "" {
+				m.syncing = true
+				m.status = "Cloning " + m.setupCloneURL + "..."
+				return m, m.cloneDotfiles(m.setupCloneURL, m.config.DotfilesPath)
+			}
+			m.config.FirstRun = false
+			m.onboardAfterScan = true
+			return m, m.saveConfig
+		case "n", "esc":
+			m.setupStep = SetupPath
+			m.textInput.Focus()
+			return m, textinput.Blink
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// cloneDotfiles clones an existing dotfiles repo for the setup wizard
+func (m *Model) cloneDotfiles(url, path string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := git.Clone(url, path)
+		return setupClonedMsg{err: err}
+	}
+}
+
+// buildRestorePlan detects the layout of the cloned dotfiles repo and
+// matches its apps to the ones installed here, by config found in the
+// scan, Homebrew or a binary on the PATH
+func (m *Model) buildRestorePlan(localApps []*models.App) tea.Cmd {
+	return func() tea.Msg {
+		s := newScanner(m.config)
+		dotfilesApps, unmapped := s.ScanDotfiles(m.config.DotfilesPath)
+		installed := func(id string) bool {
+			if s.IsBrewInstalled(id) {
+				return true
+			}
+			_, err := exec.LookPath(id)
+			return err == nil
+		}
+		return restorePlanMsg{
+			choices:  onboarding.RestorePlan(dotfilesApps, localApps, installed),
+			layout:   onboarding.DetectLayout(m.config.DotfilesPath, len(dotfilesApps)),
+			unmapped: unmapped,
+		}
+	}
+}
+
+// startOnboarding opens the first backup wizard with the recommended
+// installed apps picked
+func (m *Model) startOnboarding() {
+	m.onboardChoices = onboarding.Choices(m.apps)
+	m.onboardCursor = 0
+	m.onboardStep = OnboardPick
+	m.onboardRestore = false
+	m.screen = ScreenOnboarding
+}
+
+func (m *Model) handleOnboardingKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.onboardStep {
+	case OnboardPick:
+		switch msg.String() {
+		case "up", "k":
+			if m.onboardCursor > 0 {
+				m.onboardCursor--
+			}
+		case "down", "j":
+			if m.onboardCursor < len(m.onboardChoices)-1 {
+				m.onboardCursor++
+			}
+		case " ":
+			if m.onboardCursor < len(m.onboardChoices) {
+				c := &m.onboardChoices[m.onboardCursor]
+				c.Chosen = !c.Chosen
+			}
+		case "enter":
+			if m.onboardRestore {
+				return m.onboardPull()
+			}
+			return m.onboardPush()
+		case "esc", "q":
+			m.screen = ScreenMain
+			if m.onboardRestore {
+				m.onboardRestore = false
+				m.status = "Restore skipped • select apps and press p to pull"
+			} else {
+				m.status = "First backup skipped • select apps and press P to push + commit"
+			}
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+
+	case OnboardRemote:
+		switch msg.String() {
+		case "enter":
+			url := strings.TrimSpace(m.textInput.Value())
+			m.textInput.Blur()
+			if url == "" {
+				m.screen = ScreenMain
+				return m, nil
+			}
+			m.syncing = true
+			m.status = "Pushing to " + url + "..."
+			return m, m.onboardAddRemote(url)
+		case "esc":
+			m.textInput.Blur()
+			m.screen = ScreenMain
+		default:
+			var cmd tea.Cmd
+			m.textInput, cmd = m.textInput.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// onboardPush selects exactly the chosen apps, makes the dotfiles dir a git
+// repo and runs push + commit, coming back to the wizard when it's done
+func (m *Model) onboardPush() (tea.Model, tea.Cmd) {
+	chosen := onboarding.Chosen(m.onboardChoices)
+	if len(chosen) == 0 {
+		m.status = "Pick at least one app (SPACE), or ESC to skip"
+		return m, nil
+	}
+	if err := os.MkdirAll(m.config.DotfilesPath, 0755); err != nil {
+		m.status = fmt.Sprintf("Cannot create dotfiles dir: %v", err)
+		return m, nil
+	}
+	if !m.config.IsGitRepo() {
+		if err := m.config.InitGitRepo(); err != nil {
+			m.status = fmt.Sprintf("Cannot init git: %v", err)
+			return m, nil
+		}
+	}
+
+	for _, app := range m.apps {
+		app.Selected = slices.Contains(chosen, app)
+	}
+	m.appList.SetApps(m.apps)
+	m.updateFileList()
+
+	_, cmd := m.handlePushAndCommit()
+	if cmd == nil {
+		return m, nil
+	}
+	m.onboardStep = OnboardPushing
+	return m, func() tea.Msg {
+		return onboardPushedMsg{result: cmd()}
+	}
+}
+
+// onboardPull puts the chosen apps of the cloned repo in the app list,
+// selected alone, and opens the pull confirmation for them. Chosen apps
+// replace their local scan so files only in dotfiles get restored too.
+func (m *Model) onboardPull() (tea.Model, tea.Cmd) {
+	chosen := onboarding.Chosen(m.onboardChoices)
+	if len(chosen) == 0 {
+		m.status = "Pick at least one app (SPACE), or ESC to skip"
+		return m, nil
+	}
+
+	for _, app := range m.apps {
+		app.Selected = false
+	}
+	for _, app := range chosen {
+		app.Selected = true
+		i := slices.IndexFunc(m.apps, func(a *models.App) bool { return a.ID == app.ID })
+		if i >= 0 {
+			m.apps[i] = app
+		} else {
+			m.apps = append(m.apps, app)
+		}
+	}
+	m.appList.SetApps(m.apps)
+	m.updateFileList()
+	m.onboardRestore = false
+	m.screen = ScreenMain
+	return m.handlePull()
+}
+
+// onboardAddRemote points origin at url and pushes the first commit there
+func (m *Model) onboardAddRemote(url string) tea.Cmd {
+	return func() tea.Msg {
+		repo := git.NewRepo(m.config.DotfilesPath)
+		if err := repo.SetRemote("origin", url); err != nil {
+			return onboardRemoteMsg{url: url, err: err}
+		}
+		err := repo.PushWithUpstream("origin", repo.CurrentBranch())
+		return onboardRemoteMsg{url: url, err: err}
+	}
+}
+
+func (m *Model) togglePanel() {
+	m.appList.CancelVisual()
+	m.fileList.CancelVisual()
+	if m.focusedPanel == PanelApps {
+		m.focusedPanel = PanelFiles
+		m.appList.Focused = false
+		m.fileList.Focused = true
+	} else {
+		m.focusedPanel = PanelApps
+		m.appList.Focused = true
+		m.fileList.Focused = false
+	}
+}
+
+func (m *Model) updateFileList() {
+	m.fileList.SyncTimes, m.appList.SyncTimes = nil, nil
+	if m.stateManager != nil {
+		m.appList.SyncTimes = m.stateManager.LastSyncedByApp()
+	}
+	if app := m.appList.Current(); app != nil {
+		if m.stateManager != nil {
+			m.fileList.SyncTimes = make(map[string]time.Time)
+			for _, f := range app.Files {
+				if state, ok := m.stateManager.GetFileState(app.ID, f.RelPath); ok {
+					m.fileList.SyncTimes[f.RelPath] = state.LastSynced()
+				}
+			}
+		}
+		m.fileList.SetFiles(app.Files, app.Name)
+		m.fileList.Truncated = app.Truncated
+	} else {
+		m.fileList.Clear()
+	}
+}
+
+// Bounds of the apps panel width in percent, so neither panel vanishes
+const (
+	minPanelSplit = 20
+	maxPanelSplit = 80
+)
+
+func (m *Model) updatePanelSizes() {
+	panelHeight := m.height - 8
+	if m.config.Accessible {
+		// One panel below the other
+		m.appList.Width, m.fileList.Width = m.width-4, m.width-4
+		m.appList.Height, m.fileList.Height = (m.height-10)/2, (m.height-10)/2
+		return
+	}
+
+	total := (m.width - 4) / 2 * 2
+	appWidth := total * m.panelSplit() / 100
+	fileWidth := total - appWidth
+	if m.singlePanel() {
+		// The hidden panel's border and the gap go to the shown one
+		appWidth, fileWidth = total+4, total+4
+		panelHeight-- // Breadcrumbs
+	}
+
+	m.appList.Width = appWidth
+	m.appList.Height = panelHeight
+	m.fileList.Width = fileWidth
+	m.fileList.Height = panelHeight
+}
+
+// narrowWidth is the terminal width below which the panels no longer fit
+// side by side and only the focused one is shown
+const narrowWidth = 100
+
+// singlePanel reports whether only the focused panel is shown: in zen mode
+// or on a narrow terminal
+func (m *Model) singlePanel() bool {
+	return !m.config.Accessible && (m.zen || m.width < narrowWidth)
+}
+
+// renderBreadcrumbs tells where the single shown panel is: the app list,
+// or an app's files down to the directory under the cursor
+func (m *Model) renderBreadcrumbs() string {
+	parts := []string{i18n.T("breadcrumb.apps")}
+	hint := i18n.T("breadcrumb.to_files")
+	if m.focusedPanel == PanelFiles {
+		hint = i18n.T("breadcrumb.to_apps")
+		if app := m.appList.Current(); app != nil {
+			parts = append(parts, app.Name)
+		}
+		if node := m.fileList.CurrentNode(); node != nil {
+			dir := node.Path
+			if !node.IsDir {
+				dir = filepath.Dir(dir)
+			}
+			if dir != "." && dir != "" {
+				parts = append(parts, strings.Split(filepath.ToSlash(dir), "/")...)
+			}
+		}
+	}
+	return ui.PanelTitleStyle.Render(strings.Join(parts, " › ")) + ui.MutedStyle.Render("  "+hint)
+}
+
+// panelSplit returns the apps panel width in percent of the window
+func (m *Model) panelSplit() int {
+	if m.config.PanelSplit == 0 {
+		return 50
+	}
+	return max(minPanelSplit, min(maxPanelSplit, m.config.PanelSplit))
+}
+
+// resizePanels moves the split between the panels by delta percent, or
+// back to even with a delta of 0, and saves it
+func (m *Model) resizePanels(delta int) (tea.Model, tea.Cmd) {
+	split := 50
+	if delta != 0 {
+		split = max(minPanelSplit, min(maxPanelSplit, m.panelSplit()+delta))
+	}
+	m.setPanelSplit(split)
+	if err := m.config.Save(); err != nil {
+		m.status = fmt.Sprintf("Error saving config: %v", err)
+		return m, nil
+	}
+	m.status = fmt.Sprintf("Apps panel %d%% of the width", m.panelSplit())
+	return m, nil
+}
+
+// setPanelSplit applies a split without saving it
+func (m *Model) setPanelSplit(split int) {
+	m.config.PanelSplit = split
+	if split == 50 {
+		m.config.PanelSplit = 0 // The default, left out of the config file
+	}
+	m.updatePanelSizes()
+}
+
+// handleZen shows only the focused panel, or both again
+func (m *Model) handleZen() (tea.Model, tea.Cmd) {
+	m.zen = !m.zen
+	m.updatePanelSizes()
+	if m.zen {
+		m.status = "Zen mode • Tab switches panels, Z shows both"
+	} else {
+		m.status = "Showing both panels"
+	}
+	return m, nil
+}
+
+// handleSplitDrag lets the divider between the panels be dragged with the
+// mouse; the split is saved when the button is released
+func (m *Model) handleSplitDrag(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.screen != ScreenMain || m.singlePanel() || m.config.Accessible {
+		return m, nil
+	}
+	// AppStyle pads one column; the apps panel has a border on each side,
+	// then two columns of gap
+	divider := 1 + m.appList.Width + 2
+	switch msg.Action {
+	case tea.MouseActionPress:
+		if msg.Button == tea.MouseButtonLeft && msg.X >= divider-1 && msg.X <= divider+2 {
+			m.draggingSplit = true
+		}
+	case tea.MouseActionMotion:
+		if m.draggingSplit && m.width > 4 {
+			total := (m.width - 4) / 2 * 2
+			m.setPanelSplit(max(minPanelSplit, min(maxPanelSplit, (msg.X-3)*100/total)))
+		}
+	case tea.MouseActionRelease:
+		if m.draggingSplit {
+			m.draggingSplit = false
+			if err := m.config.Save(); err != nil {
+				m.status = fmt.Sprintf("Error saving config: %v", err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// View renders the current screen, rewritten to ASCII in plain mode
+func (m *Model) View() string {
+	defer crash.Guard()
+	if m.config.Plain || m.config.Accessible {
+		return ui.PlainText(m.view())
+	}
+	return m.view()
+}
+
+// view renders the current screen
+func (m *Model) view() string {
+	switch m.screen {
+	case ScreenSetup:
+		return m.renderSetup()
+	case ScreenConfirm:
+		return m.renderConfirm()
+	case ScreenDiff:
+		return m.renderDiff()
+	case ScreenMerge:
+		return m.renderMerge()
+	case ScreenGit:
+		return m.renderGit()
+	case ScreenCommit:
+		return m.renderCommitDialog()
+	case ScreenPreview:
+		return m.renderPreview()
+	case ScreenEdit:
+		return m.renderEdit()
+	case ScreenSettings:
+		return m.renderSettings()
+	case ScreenAddCustom:
+		return m.renderAddCustom()
+	case ScreenMapPath:
+		return m.renderMapPath()
+	case ScreenConflicts:
+		return m.renderConflicts()
+	case ScreenReview:
+		return m.renderReview()
+	case ScreenQuickSync:
+		return m.renderQuickSync()
+	case ScreenDefinitions:
+		return m.renderDefinitions()
+	case ScreenOrphans:
+		return m.renderOrphans()
+	case ScreenDigest:
+		return m.renderDigest()
+	case ScreenChangelog:
+		return m.renderChangelog()
+	case ScreenAudit:
+		return m.renderAudit()
+	case ScreenLogs:
+		return m.renderLogs()
+	case ScreenResume:
+		return m.renderResume()
+	case ScreenAppInfo:
+		return m.renderAppInfo()
+	case ScreenDiffStat:
+		return m.renderDiffStat()
+	case ScreenDashboard:
+		return m.renderDashboard()
+	case ScreenSuggestions:
+		return m.renderSuggestions()
+	case ScreenSyncResult:
+		return m.renderSyncResult()
+	case ScreenOnboarding:
+		return m.renderOnboarding()
+	default:
+		return m.renderMain()
+	}
+}
+
+func (m *Model) renderSetup() string {
+	width := 60
+	style := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary)
+
+	var content string
+
+	switch m.setupStep {
+	case SetupWelcome:
+		content = m.renderSetupWelcome()
+	case SetupPath:
+		content = m.renderSetupPath()
+	case SetupConfirm:
+		content = m.renderSetupConfirm()
+	}
+
+	box := style.Render(content)
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+func (m *Model) renderOnboarding() string {
+	var b strings.Builder
+	title := lipgloss.NewStyle().Bold(true).Foreground(ui.Primary)
+
+	if m.onboardStep == OnboardRemote {
+		b.WriteString(title.Render(i18n.T("onboard.remote.title")))
+		b.WriteString("\n\n")
+		b.WriteString(i18n.T("onboard.remote.desc") + "\n\n")
+		b.WriteString(m.textInput.View())
+		b.WriteString("\n\n")
+		if m.syncing {
+			b.WriteString(m.spinner.View() + m.status + "\n\n")
+		} else if strings.HasPrefix(m.status, "Error") {
+			b.WriteString(ui.ConflictStyle.Render(m.status) + "\n\n")
+		}
+		b.WriteString(ui.HelpBarStyle.Render(i18n.T("onboard.remote.help")))
+	} else {
+		prefix, recommended := "onboard.", "onboard.recommended"
+		if m.onboardRestore {
+			prefix, recommended = "onboard.restore.", "onboard.restore.installed"
+		}
+		b.WriteString(title.Render(i18n.T(prefix + "title")))
+		b.WriteString("\n\n")
+		b.WriteString(i18n.T(prefix+"desc") + "\n\n")
+		if len(m.onboardChoices) == 0 {
+			b.WriteString(ui.MutedStyle.Render(i18n.T(prefix+"none")) + "\n")
+		}
+
+		// Keep the cursor visible
+		visible := max(m.height-16, 5)
+		start := 0
+		if m.onboardCursor >= visible {
+			start = m.onboardCursor - visible + 1
+		}
+		end := min(start+visible, len(m.onboardChoices))
+		for i := start; i < end; i++ {
+			c := m.onboardChoices[i]
+			check := "[ ]"
+			if c.Chosen {
+				check = "[x]"
+			}
+			line := fmt.Sprintf("%s %s %s", check, c.App.Icon, c.App.Name)
+			if c.Recommended {
+				line += ui.MutedStyle.Render("  " + i18n.T(recommended))
+			}
+			if i == m.onboardCursor {
+				b.WriteString(ui.CursorStyle.Render("▸ ") + ui.SelectedItemStyle.Render(line))
+			} else {
+				b.WriteString("  " + line)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		if strings.HasPrefix(m.status, "Pick") {
+			b.WriteString(ui.MutedStyle.Render(m.status) + "\n\n")
+		}
+		b.WriteString(ui.HelpBarStyle.Render(i18n.T(prefix + "help")))
+	}
+
+	box := lipgloss.NewStyle().
+		Width(70).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary).
+		Render(b.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+func (m *Model) renderSetupWelcome() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Primary).
+		Render(i18n.T("setup.welcome.title"))
+
+	b.WriteString(title)
+	b.WriteString("\n\n")
+	b.WriteString(i18n.T("setup.welcome.intro") + "\n\n")
+	b.WriteString(i18n.T("setup.welcome.features") + "\n")
+	for _, feature := range []string{"detect", "selective", "apps", "git", "discover"} {
+		b.WriteString("  • " + i18n.T("setup.feature."+feature) + "\n")
+	}
+	b.WriteString("\n\n")
+	b.WriteString(ui.HelpBarStyle.Render(i18n.T("setup.welcome.help")))
+
+	return b.String()
+}
+
+func (m *Model) renderSetupPath() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Primary).
+		Render(i18n.T("setup.path.title"))
+
+	b.WriteString(title)
+	b.WriteString("\n\n")
+	b.WriteString(i18n.T("setup.path.question") + "\n\n")
+
+	paths := config.SuggestedPaths()
+	for i, path := range paths {
+		prefix := fmt.Sprintf("[%d] ", i+1)
+		exists := ""
+		if _, err := os.Stat(path); err == nil {
+			exists = i18n.T("setup.path.exists")
+		}
+		b.WriteString(ui.MutedStyle.Render(prefix))
+		b.WriteString(path)
+		b.WriteString(ui.MutedStyle.Render(exists))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n" + i18n.T("setup.path.custom") + "\n")
+	b.WriteString(m.textInput.View())
+	b.WriteString("\n" + ui.MutedStyle.Render(i18n.T("setup.path.clone")))
+	b.WriteString("\n\n")
+	if strings.HasPrefix(m.status, "Error") {
+		b.WriteString(ui.ConflictStyle.Render(m.status) + "\n\n")
+	}
+	b.WriteString(ui.HelpBarStyle.Render(i18n.T("setup.path.help")))
+
+	return b.String()
+}
+
+func (m *Model) renderSetupConfirm() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Primary).
+		Render(i18n.T("setup.confirm.title"))
+
+	b.WriteString(title)
+	b.WriteString("\n\n")
+	if m.setupCloneURL != "" {
+		b.WriteString(i18n.T("setup.confirm.clone") + "\n")
+		b.WriteString(ui.SelectedItemStyle.Render("  " + m.setupCloneURL))
+		b.WriteString("\n" + i18n.T("setup.confirm.into") + "\n")
+	} else {
+		b.WriteString(i18n.T("setup.confirm.location") + "\n")
+	}
+	b.WriteString(ui.SelectedItemStyle.Render("  " + m.config.DotfilesPath))
+	b.WriteString("\n\n")
+
+	if m.syncing {
+		b.WriteString(m.spinner.View() + m.status + "\n")
+	} else if m.setupCloneURL != "" {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("setup.confirm.restore") + "\n"))
+	} else if _, err := os.Stat(m.config.DotfilesPath); err == nil {
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("setup.confirm.exists") + "\n"))
+	} else {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("setup.confirm.create") + "\n"))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.HelpBarStyle.Render(i18n.T("setup.confirm.help")))
+
+	return b.String()
+}
+
+func (m *Model) renderConfirm() string {
+	width := 70
+
+	// Different styling for push vs pull
+	borderColor := ui.Warning
+	var titleText string
+	var descText string
+	var filesLabel string
+
+	if m.confirmAction == ActionPush {
+		borderColor = ui.Primary
+		titleText = i18n.T("confirm.push.title")
+		descText = i18n.T("confirm.push.desc")
+		filesLabel = i18n.T("confirm.push.files")
+	} else {
+		titleText = i18n.T("confirm.pull.title")
+		descText = i18n.T("confirm.pull.desc")
+		filesLabel = i18n.T("confirm.pull.files")
+	}
+
+	style := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor)
+
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(borderColor).
+		Render(titleText)
+
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	b.WriteString(descText)
+	b.WriteString("\n\n")
+
+	// Show files that will be affected
+	b.WriteString(ui.PanelTitleStyle.Render(filesLabel))
+	b.WriteString("\n")
+
+	maxShow := 8
+	for i, diff := range m.fileDiffs {
+		if i >= maxShow {
+			remaining := len(m.fileDiffs) - maxShow
+			b.WriteString(ui.MutedStyle.Render(i18n.T("confirm.more_files", remaining) + "\n"))
+			break
+		}
+
+		icon := "📄"
+		if diff.File.IsDir {
+			icon = "📁"
+		}
+
+		statusStyle := ui.MutedStyle
+		switch diff.Status {
+		case "new (will create)":
+			statusStyle = ui.NewStyle
+		case "different", "will overwrite":
+			statusStyle = ui.ModifiedStyle
+		case "not in dotfiles", "missing locally":
+			statusStyle = ui.MissingStyle
+		case "same":
+			statusStyle = ui.SyncedStyle
+		}
+
+		b.WriteString(fmt.Sprintf("  %s %s %s\n",
+			icon,
+			diff.File.Name,
+			statusStyle.Render("("+i18n.T("diffstatus."+diff.Status)+")"),
+		))
+	}
+
+	var affected []models.File
+	for _, diff := range m.fileDiffs {
+		affected = append(affected, diff.File)
+	}
+	count, size := components.FileTotals(affected)
+	b.WriteString(ui.MutedStyle.Render(i18n.T("confirm.total", count, components.FormatBytes(size))))
+	b.WriteString("\n")
+
+	if len(m.deletions) > 0 {
+		b.WriteString("\n")
+		label := i18n.T("confirm.deleted_local")
+		if m.confirmAction == ActionPull {
+			label = i18n.T("confirm.deleted_remote")
+		}
+		b.WriteString(ui.PanelTitleStyle.Render(label))
+		b.WriteString("\n")
+		for i, d := range m.deletions {
+			if i >= 4 {
+				b.WriteString(ui.MutedStyle.Render(i18n.T("confirm.more", len(m.deletions)-i) + "\n"))
+				break
+			}
+			line := fmt.Sprintf("  🗑 %s/%s", d.AppID, d.RelPath)
+			if d.Machine != "" {
+				line += ui.MutedStyle.Render(i18n.T("confirm.deleted_by", d.Machine))
+			}
+			b.WriteString(ui.MissingStyle.Render(line))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("confirm.choose")))
+	b.WriteString("\n")
+
+	// Different options for push vs pull
+	var options []struct {
+		key   string
+		label string
+		desc  string
+	}
+
+	if m.confirmAction == ActionPush {
+		options = []struct {
+			key   string
+			label string
+			desc  string
+		}{
+			{"1", i18n.T("confirm.push"), i18n.T("confirm.push.option")},
+			{"2", i18n.T("confirm.cancel"), i18n.T("confirm.cancel.option")},
+		}
+		if len(m.deletions) > 0 {
+			options = append(options, struct {
+				key   string
+				label string
+				desc  string
+			}{"3", i18n.T("confirm.push_delete"), i18n.T("confirm.push_delete.option", len(m.deletions))})
+		}
+	} else {
+		options = []struct {
+			key   string
+			label string
+			desc  string
+		}{
+			{"1", i18n.T("confirm.pull"), i18n.T("confirm.pull.option")},
+			{"2", i18n.T("confirm.cancel"), i18n.T("confirm.cancel.option")},
+		}
+		if len(m.deletions) > 0 {
+			options = append(options, struct {
+				key   string
+				label string
+				desc  string
+			}{"3", i18n.T("confirm.pull_delete"), i18n.T("confirm.pull_delete.option", len(m.deletions))})
+		}
+	}
+
+	for i, opt := range options {
+		cursor := "  "
+		optStyle := ui.ItemStyle
+		if i == m.confirmCursor {
+			cursor = ui.CursorStyle.Render("> ")
+			optStyle = ui.SelectedItemStyle
+		}
+
+		b.WriteString(cursor)
+		b.WriteString(optStyle.Render(fmt.Sprintf("[%s] %s", opt.key, opt.label)))
+		b.WriteString("\n")
+		b.WriteString("      ")
+		b.WriteString(ui.MutedStyle.Render(opt.desc))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.HelpBarStyle.Render(i18n.T("confirm.help")))
+
+	box := style.Render(b.String())
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+func (m *Model) renderMain() string {
+	var b strings.Builder
+
+	header := m.renderHeader()
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	switch m.screen {
+	case ScreenScanning:
+		// Nice loading screen with tips
+		var lines []string
+
+		// Title with spinner
+		lines = append(lines, m.spinner.View()+" "+i18n.T("scan.title"))
+		lines = append(lines, "")
+
+		// Scanning locations
+		lines = append(lines, i18n.T("scan.looking"))
+		lines = append(lines, "  • ~/.config/")
+		lines = append(lines, "  • ~/Library/Application Support/")
+		lines = append(lines, "  • "+i18n.T("scan.home"))
+		lines = append(lines, "")
+
+		// A large file being hashed can take a while
+		if h, ok := sync.CurrentHash(); ok {
+			lines = append(lines, i18n.T("scan.hashing", filepath.Base(h.Path), components.FormatBytes(h.Done), components.FormatBytes(h.Total)))
+			lines = append(lines, m.progress.ViewAs(float64(h.Done)/float64(h.Total)))
+			lines = append(lines, "")
+		}
+
+		// Show helpful tips with rotating animation
+		tips := []string{
+			i18n.T("scan.tip.search"),
+			i18n.T("scan.tip.category"),
+			i18n.T("scan.tip.select"),
+			i18n.T("scan.tip.diff"),
+			i18n.T("scan.tip.git"),
+			i18n.T("scan.tip.rescan"),
+		}
+		tipIndex := int(time.Now().Unix()/3) % len(tips)
+		lines = append(lines, tips[tipIndex])
+
+		// Join all lines
+		scanContent := strings.Join(lines, "\n")
+
+		// Create a styled box for scan content
+		scanBox := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(ui.Primary).
+			Padding(1, 3).
+			Render(scanContent)
+
+		// Get box dimensions
+		boxHeight := lipgloss.Height(scanBox)
+		boxWidth := lipgloss.Width(scanBox)
+
+		// Calculate padding to center
+		availableHeight := m.height - 6 // header + status + help + newlines
+		availableWidth := m.width - 2   // AppStyle padding
+
+		topPad := (availableHeight - boxHeight) / 2
+		if topPad < 0 {
+			topPad = 0
+		}
+		leftPad := (availableWidth - boxWidth) / 2
+		if leftPad < 0 {
+			leftPad = 0
+		}
+
+		// Build centered content with explicit padding
+		var scanOutput strings.Builder
+		for i := 0; i < topPad; i++ {
+			scanOutput.WriteString("\n")
+		}
+		// Add left padding to each line of the box
+		for _, line := range strings.Split(scanBox, "\n") {
+			scanOutput.WriteString(strings.Repeat(" ", leftPad))
+			scanOutput.WriteString(line)
+			scanOutput.WriteString("\n")
+		}
+
+		b.WriteString(scanOutput.String())
+
+	case ScreenSyncing:
+		// Sync progress screen with progress bar
+		var syncContent strings.Builder
+		progressKey := "sync.pushing"
+		if m.syncAction == "pull" {
+			progressKey = "sync.pulling"
+		}
+		syncContent.WriteString(i18n.T(progressKey, m.spinner.View()) + "\n\n")
+
+		// Progress bar
+		var progressPercent float64
+		if m.syncTotal > 0 {
+			progressPercent = float64(m.syncCurrent) / float64(m.syncTotal)
+		}
+		syncContent.WriteString(m.progress.ViewAs(progressPercent) + "\n\n")
+		syncContent.WriteString(ui.MutedStyle.Render(i18n.T("sync.progress", m.syncCurrent, m.syncTotal)))
+		syncContent.WriteString("\n\n")
+		syncContent.WriteString(ui.MutedStyle.Render(m.status))
+
+		content := lipgloss.NewStyle().
+			Width(m.width).
+			Height(m.height-6).
+			Align(lipgloss.Center, lipgloss.Center).
+			Render(syncContent.String())
+		b.WriteString(content)
+
+	case ScreenHelp:
+		b.WriteString(m.helpVP.View())
+
+	default:
+		if m.config.Accessible {
+			b.WriteString(m.appList.View() + "\n\n" + m.fileList.View())
+			break
+		}
+		if m.singlePanel() {
+			b.WriteString(m.renderBreadcrumbs() + "\n")
+			if m.focusedPanel == PanelApps {
+				b.WriteString(m.appList.View())
+			} else {
+				b.WriteString(m.fileList.View())
+			}
+			break
+		}
+		panels := lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			m.appList.View(),
+			"  ",
+			m.fileList.View(),
+		)
+		b.WriteString(panels)
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.renderStatusBar())
+	b.WriteString("\n")
+	b.WriteString(m.renderHelpBar())
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderHeader() string {
+	title := ui.TitleStyle.Render("🔄 Dotsync")
+	ver := ui.VersionStyle.Render("v" + version)
+	path := ui.MutedStyle.Render("  " + m.config.DotfilesPath)
+	if profile := config.Profile(); profile != "" {
+		path = ui.MutedStyle.Render("  ["+profile+"]") + path
+	}
+	if m.config.IsOverridden() {
+		path += ui.MutedStyle.Render(i18n.T("header.override"))
+	}
+	if m.config.IsReadOnly() {
+		path += ui.ModifiedStyle.Render(i18n.T("header.read_only"))
+	}
+
+	// Show git branch if in a git repo (cached from gitPanel)
+	gitInfo := ""
+	if m.config.IsGitRepo() && m.gitPanel != nil && m.gitPanel.Status != nil && m.gitPanel.Status.Branch != "" {
+		gitInfo = ui.MutedStyle.Render(" [" + m.gitPanel.Status.Branch + "]")
+	}
+
+	header := title + "  " + ver + path + gitInfo
+	// The banner takes the blank line under the header, so the panels keep
+	// their height
+	if m.screen == ScreenMain {
+		if banner := m.renderUpstreamBanner(); banner != "" {
+			return ui.HeaderStyle.MarginBottom(0).Render(header) + "\n" + banner
+		}
+	}
+	return ui.HeaderStyle.Render(header)
+}
+
+func (m *Model) renderStatusBar() string {
+	selectedApps := m.appList.SelectedApps()
+	totalApps := len(m.apps)
+
+	// Count selected files across all selected apps
+	selectedFiles := 0
+	modifiedFiles := 0
+	conflictFiles := 0
+	for _, app := range selectedApps {
+		for _, file := range app.Files {
+			if file.Selected {
+				selectedFiles++
+			}
+			// Count modified and conflict files
+			switch file.ConflictType {
+			case models.ConflictLocalModified, models.ConflictLocalNew:
+				modifiedFiles++
+			case models.ConflictBothModified:
+				conflictFiles++
+			}
+		}
+	}
+
+	// Build stats string
+	var stats []string
+	stats = append(stats, i18n.T("stats.apps", len(selectedApps), totalApps))
+	if selectedFiles > 0 {
+		stats = append(stats, i18n.T("stats.files", selectedFiles))
+	}
+	if modifiedFiles > 0 {
+		stats = append(stats, i18n.T("stats.modified", modifiedFiles))
+	}
+	if conflictFiles > 0 {
+		stats = append(stats, ui.ConflictStyle.Render(i18n.T("stats.conflicts", conflictFiles)))
+	}
+
+	// Show current panel indicator
+	panelIndicator := "📁"
+	if m.focusedPanel == PanelFiles {
+		panelIndicator = "📄"
+	}
+
+	// Style status message based on content
+	styledStatus := ui.StatusTextStyle.Render(m.status)
+	if strings.HasPrefix(m.status, "✓") {
+		styledStatus = ui.RenderNotification("success", strings.TrimPrefix(m.status, "✓ "))
+	} else if strings.HasPrefix(m.status, "Error") {
+		styledStatus = ui.RenderNotification("error", m.status)
+	} else if strings.Contains(m.status, "cancelled") || strings.Contains(m.status, "failed") {
+		styledStatus = ui.RenderNotification("warning", m.status)
+	}
+
+	return ui.StatusBarStyle.Render(
+		panelIndicator + " " + styledStatus + "  •  " + strings.Join(stats, "  •  "),
+	)
+}
+
+func (m *Model) renderHelpBar() string {
+	// Show different help bar based on current screen
+	switch m.screen {
+	case ScreenScanning:
+		items := []string{
+			ui.RenderHelpItem("esc", i18n.T("key.cancel")),
+			ui.RenderHelpItem("q", i18n.T("key.quit")),
+		}
+		return ui.HelpBarStyle.Render(i18n.T("helpbar.scanning") + strings.Join(items, "  "))
+
+	case ScreenSyncing:
+		items := []string{
+			ui.RenderHelpItem("q", i18n.T("key.quit")),
+		}
+		return ui.HelpBarStyle.Render(i18n.T("helpbar.syncing") + strings.Join(items, "  "))
+
+	case ScreenHelp:
+		scrollPct := fmt.Sprintf("%d%%", int(m.helpVP.ScrollPercent()*100))
+		items := []string{
+			ui.RenderHelpItem("↑↓/j/k", i18n.T("key.scroll")),
+			ui.RenderHelpItem("PgUp/PgDn", i18n.T("key.page")),
+			ui.RenderHelpItem("esc/?", i18n.T("key.close")),
+			ui.RenderHelpItem(scrollPct, ""),
+		}
+		return ui.HelpBarStyle.Render(strings.Join(items, "  "))
+	case ScreenAddCustom:
+		items := []string{
+			ui.RenderHelpItem("Enter", i18n.T("key.next_save")),
+			ui.RenderHelpItem("Tab", i18n.T("key.mode")),
+			ui.RenderHelpItem("Esc", i18n.T("key.cancel")),
+		}
+		return ui.HelpBarStyle.Render(i18n.T("helpbar.add_custom") + strings.Join(items, "  "))
+	}
+
+	// Show different help bar when in search mode
+	if m.searchMode {
+		items := []string{
+			ui.RenderHelpItem("↑↓", i18n.T("key.navigate")),
+			ui.RenderHelpItem("enter", i18n.T("key.confirm")),
+			ui.RenderHelpItem("esc", i18n.T("key.cancel")),
+		}
+		return ui.HelpBarStyle.Render("🔍 " + m.textInput.View() + "  " + strings.Join(items, "  "))
+	}
+
+	if m.screen == ScreenMain && m.inVisual() {
+		items := []string{
+			ui.RenderHelpItem("↑↓", i18n.T("key.navigate")),
+			ui.RenderHelpItem("V/space", i18n.T("key.select")),
+			ui.RenderHelpItem("esc", i18n.T("key.cancel")),
+		}
+		return ui.HelpBarStyle.Render(i18n.T("helpbar.visual") + strings.Join(items, "  "))
+	}
+
+	// Show filter hint if category filter is active
+	if m.categoryFilter != "" {
+		items := []string{
+			ui.RenderHelpItem("esc", i18n.T("key.clear")),
+			ui.RenderHelpItem("space", i18n.T("key.select")),
+			ui.RenderHelpItem("Q", i18n.T("key.backup")),
+			ui.RenderHelpItem("p", i18n.T("key.push")),
+			ui.RenderHelpItem("l", i18n.T("key.pull")),
+			ui.RenderHelpItem("?", i18n.T("key.help")),
+		}
+		return ui.HelpBarStyle.Render("📁 " + m.categoryFilter + "  " + strings.Join(items, "  "))
+	}
+
+	// Show search filter hint if search is active
+	if m.searchQuery != "" {
+		items := []string{
+			ui.RenderHelpItem("esc", i18n.T("key.clear")),
+			ui.RenderHelpItem("space", i18n.T("key.select")),
+			ui.RenderHelpItem("Q", i18n.T("key.backup")),
+			ui.RenderHelpItem("p", i18n.T("key.push")),
+			ui.RenderHelpItem("l", i18n.T("key.pull")),
+			ui.RenderHelpItem("?", i18n.T("key.help")),
+		}
+		return ui.HelpBarStyle.Render("🔍 \"" + m.searchQuery + "\"  " + strings.Join(items, "  "))
+	}
+
+	// Context-sensitive help based on panel and selection
+	var items []string
+
+	// Check if we have selected items
+	selectedApps := m.appList.SelectedApps()
+	hasSelection := len(selectedApps) > 0
+
+	if m.focusedPanel == PanelApps {
+		if hasSelection {
+			// Show sync actions when items are selected
+			items = []string{
+				ui.RenderHelpItem("Q", i18n.T("key.backup")),
+				ui.RenderHelpItem("p", i18n.T("key.push")),
+				ui.RenderHelpItem("l", i18n.T("key.pull")),
+				ui.RenderHelpItem("t", i18n.T("key.mode")),
+				ui.RenderHelpItem("tab", i18n.T("key.to_files")),
+				ui.RenderHelpItem("?", i18n.T("key.help")),
+			}
+		} else {
+			// Show selection actions when nothing selected
+			items = []string{
+				ui.RenderHelpItem("space", i18n.T("key.select")),
+				ui.RenderHelpItem("a", i18n.T("key.all")),
+				ui.RenderHelpItem("M", i18n.T("key.modified")),
+				ui.RenderHelpItem("O", i18n.T("key.outdated")),
+				ui.RenderHelpItem("+", i18n.T("key.add_custom")),
+				ui.RenderHelpItem("/", i18n.T("key.search")),
+				ui.RenderHelpItem("1-9", i18n.T("key.filter")),
+				ui.RenderHelpItem("?", i18n.T("key.help")),
+			}
+		}
+	} else {
+		// Files panel - show file-specific actions
+		if hasSelection {
+			items = []string{
+				ui.RenderHelpItem("Q", i18n.T("key.backup")),
+				ui.RenderHelpItem("p", i18n.T("key.push")),
+				ui.RenderHelpItem("l", i18n.T("key.pull")),
+				ui.RenderHelpItem("d", i18n.T("key.diff")),
+				ui.RenderHelpItem("e", i18n.T("key.edit")),
+				ui.RenderHelpItem("tab", i18n.T("key.to_apps")),
+				ui.RenderHelpItem("?", i18n.T("key.help")),
+			}
+		} else {
+			items = []string{
+				ui.RenderHelpItem("space", i18n.T("key.select")),
+				ui.RenderHelpItem("v", i18n.T("key.preview")),
+				ui.RenderHelpItem("d", i18n.T("key.diff")),
+				ui.RenderHelpItem("e", i18n.T("key.edit")),
+				ui.RenderHelpItem("tab", i18n.T("key.to_apps")),
+				ui.RenderHelpItem("?", i18n.T("key.help")),
+			}
+		}
+	}
+
+	return ui.HelpBarStyle.Render(strings.Join(items, "  "))
+}
+
+func (m *Model) renderHelp() string {
+	var b strings.Builder
+
+	type binding struct {
+		key  string
+		desc string
+	}
+	writeBindings := func(bindings []binding) {
+		for _, bind := range bindings {
+			b.WriteString(fmt.Sprintf("  %s  %s\n",
+				ui.HelpKeyStyle.Width(14).Render(bind.key),
+				ui.HelpDescStyle.Render(i18n.T(bind.desc)),
+			))
+		}
+	}
+
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("help.title")))
+	b.WriteString("\n\n")
+
+	// Quick Actions section (most important - at the top)
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.section.quick")))
+	b.WriteString("\n")
+	writeBindings([]binding{
+		{"Q", "help.quick.Q"},
+		{"P", "help.quick.P"},
+		{"p", "help.quick.p"},
+		{"l", "help.quick.l"},
+		{"c", "help.quick.c"},
+		{"C", "help.quick.C"},
+		{"A", "help.quick.A"},
+		{"U", "help.quick.U"},
+		{"W", "help.quick.W"},
+		{"H", "help.quick.H"},
+		{"I", "help.quick.I"},
+		{"o", "help.quick.o"},
+		{"T", "help.quick.T"},
+		{"L", "help.quick.L"},
+		{"X", "help.quick.X"},
+		{"w", "help.quick.w"},
+		{"e", "help.quick.e"},
+		{"E", "help.quick.E"},
+		{"i", "help.quick.i"},
+	})
+
+	// Mode section - More detailed explanation
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.section.modes")))
+	b.WriteString("\n")
+	writeBindings([]binding{
+		{"[B] Backup", "help.mode.backup"},
+		{"[B+S] Sync", "help.mode.sync"},
+	})
+	b.WriteString("\n")
+	writeBindings([]binding{
+		{"t", "help.mode.t"},
+		{"R", "help.mode.R"},
+	})
+
+	// Navigation section
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.section.nav")))
+	b.WriteString("\n")
+	writeBindings([]binding{
+		{"/", "help.nav.search"},
+		{"1-9", "help.nav.category"},
+		{"0", "help.nav.clear"},
+		{"z", "help.nav.group"},
+		{"S", "help.nav.stale"},
+		{"< > =", "help.nav.split"},
+		{"Z", "help.nav.zen"},
+		{"Enter ←/→", "help.nav.fold"},
+		{"↑/k ↓/j", "help.nav.move"},
+		{"Tab", "help.nav.panel"},
+		{"PgUp/PgDn", "help.nav.page"},
+		{"Home/End", "help.nav.jump"},
+	})
+
+	// Selection section
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.section.select")))
+	b.WriteString("\n")
+	writeBindings([]binding{
+		{"Space", "help.select.toggle"},
+		{"a", "help.select.all"},
+		{"D", "help.select.none"},
+		{"V", "help.select.visual"},
+		{"*", "help.select.shown"},
+		{"M", "help.select.modified"},
+		{"O", "help.select.outdated"},
+		{"F", "help.select.status"},
+		{"+", "help.select.custom"},
+		{"u", "help.select.undo"},
+		{"x", "help.select.exclude"},
+		{"i", "help.select.include"},
+	})
+
+	// File Actions section
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.section.file")))
+	b.WriteString("\n")
+	writeBindings([]binding{
+		{"v/Enter", "help.file.preview"},
+		{"d", "help.file.diff"},
+		{"d → b/B", "help.file.diffbase"},
+		{"#", "help.file.diffstat"},
+		{"m", "help.file.merge"},
+		{"s", "help.file.rescan"},
+		{"b", "help.file.brewfile"},
+		{"r", "help.file.refresh"},
+	})
+
+	// Git Operations section
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.section.git")))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("  %s\n", ui.HelpDescStyle.Render(i18n.T("help.git.autoinit"))))
+	writeBindings([]binding{
+		{"g", "help.git.open"},
+		{"a", "help.git.stage"},
+		{"c", "help.git.commit"},
+		{"p", "help.git.push"},
+		{"f", "help.git.fetch"},
+		{"l", "help.git.pull"},
+		{"b", "help.git.branch"},
+		{"M", "help.git.merge_main"},
+		{"L", "help.git.lazygit"},
+	})
+
+	// General section
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.section.general")))
+	b.WriteString("\n")
+	writeBindings([]binding{
+		{",", "help.general.settings"},
+		{"?", "help.general.help"},
+		{"Esc", "help.general.back"},
+		{"q", "help.general.quit"},
+	})
+
+	// Status icons legend
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("help.icons.title")))
+	b.WriteString("\n\n")
+	statusIcons := []struct {
+		icon string
+		desc string
+	}{
+		{models.StatusSynced.StatusIcon(), "help.icons.synced"},
+		{models.StatusModified.StatusIcon(), "help.icons.modified"},
+		{models.StatusOutdated.StatusIcon(), "help.icons.outdated"},
+		{models.ConflictBothModified.ConflictIcon(), "help.icons.conflict"},
+		{"[B]", "help.icons.backup"},
+		{"[B+S]", "help.icons.sync"},
+	}
+	for _, icon := range statusIcons {
+		b.WriteString(fmt.Sprintf("  %s  %s\n",
+			ui.HelpKeyStyle.Width(6).Render(icon.icon),
+			ui.HelpDescStyle.Render(i18n.T(icon.desc)),
+		))
+	}
+
+	// Quick reference - Backup explanation
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("help.how.title")))
+	b.WriteString("\n\n")
+	b.WriteString(ui.MutedStyle.Render("  Backup [B]:"))
+	b.WriteString("\n")
+	for _, key := range []string{"help.how.backup1", "help.how.backup2", "help.how.backup3"} {
+		b.WriteString("    • " + i18n.T(key) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render("  Backup + Sync [B+S]:"))
+	b.WriteString("\n")
+	for _, key := range []string{"help.how.sync1", "help.how.sync2", "help.how.sync3"} {
+		b.WriteString("    • " + i18n.T(key) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("help.close")))
+
+	return b.String()
+}
+
+func (m *Model) renderDiff() string {
+	var b strings.Builder
+
+	header := m.renderHeader()
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	// Render diff view
+	b.WriteString(m.diffView.View())
+	if m.diffBaseInput {
+		b.WriteString("\n")
+		b.WriteString(m.textInput.View())
+	}
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderMerge() string {
+	var b strings.Builder
+
+	header := m.renderHeader()
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	// Render merge view
+	b.WriteString(m.mergeView.View())
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderEdit() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+
+	b.WriteString(m.textEditor.View())
+	b.WriteString("\n")
+
+	helpItems := []string{
+		ui.RenderHelpItem("Ctrl+S", i18n.T("key.save")),
+		ui.RenderHelpItem("Esc", i18n.T("key.close")),
+	}
+	b.WriteString(ui.HelpBarStyle.Render(strings.Join(helpItems, "  ")))
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderPreview() string {
+	var b strings.Builder
+
+	header := m.renderHeader()
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	// Render file preview
+	b.WriteString(m.filePreview.View())
+	b.WriteString("\n")
+
+	// Help bar
+	helpItems := []string{
+		ui.RenderHelpItem("j/k", i18n.T("key.scroll")),
+		ui.RenderHelpItem("PgUp/Dn", i18n.T("key.page")),
+		ui.RenderHelpItem("Home/End", i18n.T("key.top_bottom")),
+		ui.RenderHelpItem("i", i18n.T("key.edit")),
+		ui.RenderHelpItem("q/Esc", i18n.T("key.close")),
+	}
+	b.WriteString(ui.HelpBarStyle.Render(strings.Join(helpItems, "  ")))
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderGit() string {
+	var b strings.Builder
+
+	header := m.renderHeader()
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	// Render git panel
+	b.WriteString(m.gitPanel.View())
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderSettings() string {
+	width := 70
+	style := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary)
+
+	var b strings.Builder
+
+	// Title
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Primary).
+		Render(i18n.T("settings.title"))
+
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	// Settings fields
+	fields := []struct {
+		name  string
+		value string
+		field SettingsField
+	}{
+		{i18n.T("settings.dotfiles_path"), m.config.DotfilesPath, SettingsDotfilesPath},
+		{i18n.T("settings.backup_path"), m.config.BackupPath, SettingsBackupPath},
+		{i18n.T("settings.git_name"), m.config.GitUserName, SettingsGitUserName},
+		{i18n.T("settings.git_email"), m.config.GitUserEmail, SettingsGitUserEmail},
+		{i18n.T("settings.signing_key"), m.config.GitSigningKey, SettingsGitSigningKey},
+		{i18n.T("settings.remote"), string(remote.ParseKind(m.config.RemoteBackend)), SettingsRemoteBackend},
+		{i18n.T("settings.remote_target"), m.config.RemoteTarget, SettingsRemoteTarget},
+		{i18n.T("settings.machine_branches"), onOff(m.config.MachineBranches), SettingsMachineBranches},
+		{i18n.T("settings.health_checks"), onOff(m.config.HealthChecks), SettingsHealthChecks},
+		{i18n.T("settings.bootstrap"), onOff(m.config.Bootstrap), SettingsBootstrap},
+		{i18n.T("settings.read_only"), onOff(m.config.IsReadOnly()), SettingsReadOnly},
+		{i18n.T("settings.auto_push"), onOff(m.modesConfig != nil && m.modesConfig.AutoPush), SettingsAutoPush},
+		{i18n.T("settings.icons"), string(models.ParseIconSet(m.config.IconSet)), SettingsIconSet},
+		{i18n.T("settings.theme"), ui.ThemeName(m.config.Theme), SettingsTheme},
+		{i18n.T("settings.language"), i18n.Language().Name(), SettingsLanguage},
+		{i18n.T("settings.dashboard"), onOff(m.config.Dashboard), SettingsDashboard},
+		{i18n.T("settings.notifications"), onOff(!m.config.NotificationsOff), SettingsNotifications},
+		{i18n.T("settings.diff_tool"), toolName(m.config.DiffTool), SettingsDiffTool},
+		{i18n.T("settings.merge_tool"), toolName(m.config.MergeTool), SettingsMergeTool},
+		{i18n.T("settings.nested_repos"), string(nestedrepo.ParseMode(m.config.NestedRepos)), SettingsNestedRepos},
+		{i18n.T("settings.symlinks"), string(symlink.ParsePolicy(m.config.Symlinks)), SettingsSymlinks},
+		{i18n.T("settings.xattrs"), string(xattr.ParsePolicy(m.config.Xattrs)), SettingsXattrs},
+		{i18n.T("settings.conflicts"), string(policy.Parse(string(m.config.Conflicts.Default))), SettingsConflictPolicy},
+		{i18n.T("settings.definitions"), m.definitionsSummary(), SettingsDefinitions},
+		{i18n.T("settings.orphans"), i18n.T("settings.orphans_scan"), SettingsOrphans},
+	}
+
+	for _, f := range fields {
+		isSelected := m.settingsField == f.field
+
+		// Label
+		labelStyle := lipgloss.NewStyle().Width(15)
+		if isSelected {
+			labelStyle = labelStyle.Bold(true).Foreground(ui.Primary)
+		} else {
+			labelStyle = labelStyle.Foreground(ui.Subtle)
+		}
+		b.WriteString(labelStyle.Render(f.name + ":"))
+		b.WriteString(" ")
+
+		// Value or input
+		if isSelected && m.settingsEditing {
+			// Show text input
+			b.WriteString(m.textInput.View())
+		} else {
+			valueStyle := lipgloss.NewStyle()
+			if isSelected {
+				valueStyle = valueStyle.
+					Background(ui.Surface).
+					Foreground(ui.Foreground).
+					Padding(0, 1)
+			} else {
+				valueStyle = valueStyle.Foreground(ui.Foreground)
+			}
+			b.WriteString(valueStyle.Render(f.value))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+
+	// Help text
+	helpStyle := lipgloss.NewStyle().Foreground(ui.Subtle)
+	if m.settingsEditing {
+		b.WriteString(helpStyle.Render(i18n.T("settings.help.editing")))
+	} else {
+		b.WriteString(helpStyle.Render(i18n.T("settings.help")))
+	}
+
+	// Current config file path
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render(i18n.T("settings.config_file", config.ConfigPath())))
+
+	box := style.Render(b.String())
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+func (m *Model) renderConflicts() string {
+	width := 74
+	style := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Warning)
+
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Warning).
+		Render(i18n.T("conflicts.title", len(m.conflictQueue)))
+	b.WriteString(title)
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("conflicts.desc")))
+	b.WriteString("\n\n")
+
+	if len(m.conflictQueue) == 0 {
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("conflicts.none")))
+		b.WriteString("\n")
+	}
+
+	for i, item := range m.conflictQueue {
+		line := fmt.Sprintf("%s %s/%s", item.file.ConflictType.ConflictIcon(), item.app.Name, item.file.RelPath)
+		if i == m.conflictCursor {
+			b.WriteString(ui.CursorStyle.Render("▸ "))
+			b.WriteString(ui.SelectedItemStyle.Render(line))
+		} else {
+			b.WriteString("  ")
+			b.WriteString(ui.ConflictStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	helpStyle := lipgloss.NewStyle().Foreground(ui.Subtle)
+	b.WriteString(helpStyle.Render(i18n.T("conflicts.help")))
+
+	box := style.Render(b.String())
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+func (m *Model) renderReview() string {
+	q := m.reviewQueue
+	if item := q.Item(); item != nil {
+		var b strings.Builder
+		b.WriteString(m.renderHeader())
+		b.WriteString("\n")
+		b.WriteString(ui.PanelTitleStyle.Render(i18n.T("review.progress", q.Current+1, len(q.Items), item.App.Name, item.File.RelPath)))
+		b.WriteString("  ")
+		b.WriteString(ui.MutedStyle.Render(i18n.T("review.keys")))
+		b.WriteString("\n")
+		b.WriteString(m.diffView.View())
+		return ui.AppStyle.Render(b.String())
+	}
+
+	style := lipgloss.NewStyle().
+		Width(74).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary)
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(ui.Primary).Render(i18n.T("review.title")))
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("review.desc", q.Summary())))
+	b.WriteString("\n\n")
+
+	limit := max(5, m.height-14)
+	for i, item := range q.Items {
+		if i == limit {
+			b.WriteString(ui.MutedStyle.Render(fmt.Sprintf("… %d more", len(q.Items)-limit)))
+			b.WriteString("\n")
+			break
+		}
+		line := fmt.Sprintf("%-6s %s/%s", item.Decision, item.App.Name, item.File.RelPath)
+		switch item.Decision {
+		case review.Skip:
+			b.WriteString(ui.MutedStyle.Render(line))
+		case review.Pull:
+			b.WriteString(ui.OutdatedStyle.Render(line))
+		default:
+			b.WriteString(ui.ModifiedStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(ui.Subtle).Render(i18n.T("review.help")))
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		style.Render(b.String()),
+	)
+}
+
+func (m *Model) renderQuickSync() string {
+	width := 78
+	style := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary)
+
+	var b strings.Builder
+	result := m.quickSyncResult
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Primary).
+		Render(i18n.T("quicksync.title", len(result.Items)))
+	b.WriteString(title)
+	b.WriteString("\n")
+	if result.Committed {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("quicksync.committed", result.CommitMessage)))
+		b.WriteString("\n")
+	}
+	if result.Pushed {
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("quicksync.pushed")))
+		b.WriteString("\n")
+	}
+	if result.PushError != nil {
+		b.WriteString(ui.MissingStyle.Render(fmt.Sprintf("✗ Push: %v", result.PushError)))
+		b.WriteString("\n")
+	}
+	if result.Error != nil {
+		b.WriteString(ui.MissingStyle.Render(fmt.Sprintf("✗ Git: %v", result.Error)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// Keep the cursor visible in long result lists
+	visible := m.height - 14
+	if visible < 5 {
+		visible = 5
+	}
+	start := 0
+	if m.quickSyncCursor >= visible {
+		start = m.quickSyncCursor - visible + 1
+	}
+	end := start + visible
+	if end > len(result.Items) {
+		end = len(result.Items)
+	}
+
+	for i := start; i < end; i++ {
+		item := result.Items[i]
+		line := fmt.Sprintf("%s %-10s %s/%s", item.Outcome.Icon(), item.Outcome, item.File.AppID, item.File.RelPath)
+		if item.Outcome == quicksync.OutcomePending {
+			line += fmt.Sprintf(" (%s)", item.File.State)
+		}
+		if item.Policy != "" {
+			line += fmt.Sprintf(" [%s]", item.Policy)
+		}
+		if item.Error != nil {
+			line += fmt.Sprintf(": %v", item.Error)
+		}
+
+		if i == m.quickSyncCursor {
+			b.WriteString(ui.CursorStyle.Render("▸ "))
+			b.WriteString(ui.SelectedItemStyle.Render(line))
+		} else {
+			b.WriteString("  ")
+			switch item.Outcome {
+			case quicksync.OutcomeBackedUp:
+				b.WriteString(ui.SyncedStyle.Render(line))
+			case quicksync.OutcomeFailed:
+				b.WriteString(ui.MissingStyle.Render(line))
+			case quicksync.OutcomeConflict:
+				b.WriteString(ui.ConflictStyle.Render(line))
+			case quicksync.OutcomePending:
+				b.WriteString(ui.ModifiedStyle.Render(line))
+			default:
+				b.WriteString(ui.MutedStyle.Render(line))
+			}
+		}
+		b.WriteString("\n")
+	}
+	if end < len(result.Items) {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("quicksync.more", len(result.Items)-end)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	helpStyle := lipgloss.NewStyle().Foreground(ui.Subtle)
+	b.WriteString(helpStyle.Render(i18n.T("quicksync.help")))
+
+	box := style.Render(b.String())
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+func (m *Model) renderOrphans() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("orphans.title")))
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("orphans.desc")))
+	b.WriteString("\n\n")
+
+	if len(m.orphans) == 0 {
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("orphans.none")))
+		b.WriteString("\n\n")
+		b.WriteString(ui.MutedStyle.Render(i18n.T("orphans.help_empty")))
+		return ui.AppStyle.Render(b.String())
+	}
+
+	// Keep the cursor visible
+	visible := m.height - 10
+	if visible < 5 {
+		visible = 5
+	}
+	start := 0
+	if m.orphanCursor >= visible {
+		start = m.orphanCursor - visible + 1
+	}
+	end := min(start+visible, len(m.orphans))
+
+	for i := start; i < end; i++ {
+		o := m.orphans[i]
+		modified := "-"
+		if !o.ModTime.IsZero() {
+			modified = o.ModTime.Format("2006-01-02")
+		}
+		detail := fmt.Sprintf("%d files, %s, last changed %s - %s", o.Files, components.FormatBytes(o.Size), modified, o.Reason)
+		line := fmt.Sprintf("%-24s %s", o.AppID, ui.MutedStyle.Render(detail))
+		if i == m.orphanCursor {
+			b.WriteString(ui.CursorStyle.Render("  ▸ "))
+			b.WriteString(ui.SelectedItemStyle.Render(line))
+		} else {
+			b.WriteString("    ")
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("orphans.help", m.orphanCursor+1, len(m.orphans))))
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderDefinitions() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("definitions.title")))
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("definitions.desc")))
+	b.WriteString("\n\n")
+
+	if len(m.anomalyRows) == 0 {
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("definitions.none")))
+		b.WriteString("\n")
+		return ui.AppStyle.Render(b.String())
+	}
+
+	overrides := scannerOverrides(m.config)
+
+	// Keep the cursor visible
+	visible := m.height - 10
+	if visible < 5 {
+		visible = 5
+	}
+	start := 0
+	if m.anomalyCursor >= visible {
+		start = m.anomalyCursor - visible + 1
+	}
+	end := start + visible
+	if end > len(m.anomalyRows) {
+		end = len(m.anomalyRows)
+	}
+
+	for i := start; i < end; i++ {
+		row := m.anomalyRows[i]
+		anomaly := m.anomalies[row.anomaly]
+
+		// Group header before the first row of each anomaly
+		if i == start || m.anomalyRows[i-1].anomaly != row.anomaly {
+			icon := "⚠"
+			style := ui.ModifiedStyle
+			if anomaly.Resolved(overrides) {
+				icon = "✓"
+				style = ui.SyncedStyle
+			}
+			b.WriteString(style.Render(fmt.Sprintf("%s %s", icon, anomaly.Description())))
+			b.WriteString("\n")
+		}
+
+		state := i18n.T("definitions.active")
+		if overrides.IsDisabled(row.id) {
+			state = i18n.T("definitions.disabled")
+		} else if target, ok := overrides.Aliases[row.id]; ok {
+			state = i18n.T("definitions.alias", target)
+		}
+
+		line := fmt.Sprintf("%-24s %s", row.id, ui.MutedStyle.Render(state))
+		if i == m.anomalyCursor {
+			b.WriteString(ui.CursorStyle.Render("  ▸ "))
+			b.WriteString(ui.SelectedItemStyle.Render(line))
+		} else {
+			b.WriteString("    ")
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("definitions.help", m.anomalyCursor+1, len(m.anomalyRows))))
+
+	return ui.AppStyle.Render(b.String())
+}
+
+func (m *Model) renderAddCustom() string {
+	width := 74
+	style := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary)
+
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.Primary).
+		Render(i18n.T("custom.title"))
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	mode := i18n.T("custom.folder")
+	if m.addCustomMode == "app" {
+		mode = i18n.T("custom.app")
+	}
+	b.WriteString(i18n.T("custom.mode"))
+	b.WriteString(ui.SelectedItemStyle.Render(mode))
+	b.WriteString("  ")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("custom.switch")))
+	b.WriteString("\n\n")
+
+	b.WriteString(i18n.T("custom.name"))
+	if m.addCustomStep == AddCustomStepName {
+		b.WriteString(m.textInput.View())
+	} else {
+		b.WriteString(ui.ItemStyle.Render(m.addCustomName))
+	}
+	b.WriteString("\n")
+
+	b.WriteString(i18n.T("custom.paths"))
+	if m.addCustomStep == AddCustomStepPaths {
+		b.WriteString(m.textInput.View())
+	} else {
+		if m.addCustomMode == "folder" {
+			b.WriteString(ui.MutedStyle.Render("~/.my-folder"))
+		} else {
+			b.WriteString(ui.MutedStyle.Render("~/.config/app, ~/.appconfig"))
+		}
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(ui.MutedStyle.Render(i18n.T("custom.notes")))
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("custom.note.folder")))
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("custom.note.app")))
+	b.WriteString("\n\n")
+	b.WriteString(ui.HelpBarStyle.Render(i18n.T("custom.help")))
+
+	box := style.Render(b.String())
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+	)
+}
+
+func (m *Model) handleGitKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Handle branch mode separately
+	if m.gitPanel.Mode == components.ModeBranches {
+		return m.handleGitBranchKeys(msg)
+	}
+
+	// Fetching and browsing stay available in read-only mode
+	switch msg.String() {
+	case "a", "c", "p", "l", "s", "S", "L", "M":
+		if m.blockedByReadOnly() {
+			return m, nil
+		}
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.screen = ScreenMain
+		m.status = "Ready"
+		return m, nil
+
+	case "a":
+		// Add all changes
+		if err := m.gitPanel.AddAll(); err != nil {
+			m.status = fmt.Sprintf("Add failed: %v", err)
+		} else {
+			m.status = "All changes staged"
+		}
+		return m, nil
+
+	case "c":
+		// Open commit message dialog
+		if !m.gitPanel.HasStagedChanges() {
+			m.status = "No staged changes to commit"
+			return m, nil
+		}
+		// Reset textarea for commit message
+		m.textArea.Reset()
+		m.textArea.Placeholder = i18n.T("commit.placeholder")
+		m.textArea.Focus()
+		m.screen = ScreenCommit
+		return m, textarea.Blink
+
+	case "p":
+		// Push to git, then to the mirror backend if one is configured
+		mirror, err := m.remoteMirror()
+		if err != nil {
+			m.status = fmt.Sprintf("Push failed: %v", err)
+			return m, nil
+		}
+		if remote.ParseKind(m.config.RemoteBackend).UsesGit() {
+			if err := m.gitPanel.Push(); err != nil {
+				m.status = fmt.Sprintf("Push failed: %v", err)
+				return m, nil
+			}
+		}
+		m.status = "Pushed successfully"
+		if mirror != nil {
+			skipped, err := splitSkipped(mirror.Push(""))
+			if err != nil {
+				m.status = fmt.Sprintf("Push failed: %v", err)
+				return m, nil
+			}
+			if skipped != nil {
+				m.status = fmt.Sprintf("Pushed • %v", skipped)
+			}
+		}
+		return m, nil
+
+	case "f":
+		// Fetch
+		if err := m.gitPanel.Fetch(); err != nil {
+			m.status = fmt.Sprintf("Fetch failed: %v", err)
+			return m, nil
+		}
+		m.status = "Fetched from remote"
+		return m, m.checkUpstream(false)
+
+	case "l":
+		if err := m.pullRemote(); err != nil {
+			m.status = fmt.Sprintf("Pull failed: %v", err)
+			return m, nil
+		}
+		m.status = "Pulled from remote"
+		return m, m.checkUpstream(false)
+
+	case "r":
+		// Refresh
+		m.gitPanel.Refresh()
+		m.status = "Git status refreshed"
+		return m, nil
+
+	case "s":
+		// Stash
+		if err := m.gitPanel.Stash(); err != nil {
+			m.status = fmt.Sprintf("Stash failed: %v", err)
+		} else {
+			m.status = "Changes stashed"
+		}
+		return m, nil
+
+	case "S":
+		// Stash pop
+		if err := m.gitPanel.StashPop(); err != nil {
+			m.status = fmt.Sprintf("Stash pop failed: %v", err)
+		} else {
+			m.status = "Stash popped"
+		}
+		return m, nil
+
+	case "M":
+		// Merge this machine's branch into main
+		if m.gitPanel.Flow == nil {
+			m.status = "Branch per machine is off (Settings or `dotsync branches enable`)"
+			return m, nil
+		}
+		if err := m.gitPanel.MergeToMain(); err != nil {
+			m.status = fmt.Sprintf("Merge failed: %v", err)
+		} else {
+			m.status = fmt.Sprintf("Merged %s into %s", m.gitPanel.Flow.Branch, m.gitPanel.Flow.Main)
+		}
+		return m, nil
+
+	case "b":
+		// Toggle branch mode
+		m.gitPanel.ToggleBranchMode()
+		if m.gitPanel.Mode == components.ModeBranches {
+			m.status = "Select branch to checkout"
+		} else {
+			m.status = "Git status"
+		}
+		return m, nil
+
+	case "L":
+		// Open lazygit
+		return m.handleLazygit()
+
+	case "j", "down":
+		m.gitPanel.MoveDown()
+		return m, nil
+
+	case "k", "up":
+		m.gitPanel.MoveUp()
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleGitBranchKeys handles keys in branch selection mode
+func (m *Model) handleGitBranchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "b":
+		// Exit branch mode
+		m.gitPanel.Mode = components.ModeStatus
+		m.status = "Git status"
+		return m, nil
+
+	case "j", "down":
+		m.gitPanel.MoveBranchDown()
+		return m, nil
+
+	case "k", "up":
+		m.gitPanel.MoveBranchUp()
+		return m, nil
+
+	case "enter":
+		// Checkout selected branch
+		if m.blockedByReadOnly() {
+			return m, nil
+		}
+		branch := m.gitPanel.GetSelectedBranch()
+		if branch == "" {
+			m.status = "No branch selected"
+			return m, nil
+		}
+		if err := m.gitPanel.CheckoutBranch(); err != nil {
+			m.status = fmt.Sprintf("Checkout failed: %v", err)
+		} else {
+			m.status = fmt.Sprintf("Switched to branch: %s", branch)
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleLazygit opens lazygit in the dotfiles directory
+func (m *Model) handleLazygit() (tea.Model, tea.Cmd) {
+	lazygitPath, err := exec.LookPath("lazygit")
+	if err != nil {
+		m.status = "lazygit not found — install: brew install lazygit"
+		return m, nil
+	}
+
+	c := exec.Command(lazygitPath, "-p", m.config.DotfilesPath)
+	return m, tea.ExecProcess(c, func(err error) tea.Msg {
+		return lazygitFinishedMsg{err: err}
+	})
+}
+
+// runExternalTool hands the terminal to the configured diff or merge tool
+// for local vs dotfiles; the file is re-hashed once it exits
+func (m *Model) runExternalTool(app *models.App, file *models.File, localPath, dotfilePath string, merge bool) (tea.Model, tea.Cmd) {
+	spec := m.config.DiffTool
+	if merge {
+		spec = m.config.MergeTool
+	}
+	tool, err := editor.ParseTool(spec)
+	if err != nil {
+		m.status = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
+	if !tool.IsInstalled() {
+		m.status = fmt.Sprintf("%s not found in $PATH", tool.Diff[0])
+		return m, nil
+	}
+
+	c := tool.DiffCmd(localPath, dotfilePath)
+	if merge {
+		// The merge result replaces the local file
+		if c, err = tool.MergeCmd(localPath, dotfilePath, localPath); err != nil {
+			m.status = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+	}
+
+	m.status = fmt.Sprintf("Waiting for %s...", tool.Name)
+	return m, tea.ExecProcess(c, func(err error) tea.Msg {
+		return externalToolMsg{app: app, file: file, tool: tool.Name, merge: merge, err: err}
+	})
+}
+
+// handleExternalToolDone re-hashes both sides in the background after an
+// external tool exits
+func (m *Model) handleExternalToolDone(msg externalToolMsg) (tea.Model, tea.Cmd) {
+	m.status = fmt.Sprintf("%s closed — checking %s...", msg.tool, msg.file.RelPath)
+	return m, m.rehashFiles([]fileRef{{app: msg.app, file: msg.file}}, &msg)
+}
+
+// finishExternalTool reports on a file re-hashed after an external tool
+// exited, marking it synced when the tool left both sides identical
+func (m *Model) finishExternalTool(msg externalToolMsg) {
+	// delta and friends exit non-zero when the files differ
+	if msg.err != nil && msg.merge {
+		m.markSynced(msg.app, msg.file)
+		m.status = fmt.Sprintf("Error: %s: %v", msg.tool, msg.err)
+		m.auditFile(audit.ActionMerge, msg.app, msg.file, msg.err, msg.tool)
+		return
+	}
+
+	if m.markSynced(msg.app, msg.file) {
+		if msg.merge {
+			m.auditFile(audit.ActionMerge, msg.app, msg.file, nil, msg.tool)
+		}
+		m.status = fmt.Sprintf("✓ %s: files are in sync", msg.file.RelPath)
+	} else {
+		m.status = fmt.Sprintf("%s closed — %s still differs", msg.tool, msg.file.RelPath)
+	}
+
+	if m.screen == ScreenDiff || m.screen == ScreenReview {
+		localPath := msg.file.Path
+		dotfilePath := sync.DotfilePath(m.config.DotfilesPath, msg.app.ID, *msg.file)
+		if diffResult, err := sync.ComputeDiff(localPath, dotfilePath); err == nil {
+			m.diffView.SetDiff(diffResult, localPath, dotfilePath)
+		}
+	}
+}
+
+// refreshFileSync re-hashes both sides of a file edited outside the sync
+// engine. It reports whether they now match, in which case the file is
+// recorded as synced.
+func (m *Model) refreshFileSync(app *models.App, file *models.File) bool {
+	rehashFile(m.config.DotfilesPath, m.stateManager, app.ID, file)
+	return m.markSynced(app, file)
+}
+
+// rehashFile updates the hashes, sync status and conflict type of a file
+// changed outside dotsync. Directories get content hashes too, so an edit
+// that left both sides equal shows.
+func rehashFile(dotfilesPath string, stateManager *sync.StateManager, appID string, file *models.File) {
+	_ = sync.RefreshFileStatus(context.Background(), appID, file, dotfilesPath, stateManager)
+	if file.IsDir {
+		file.LocalHash, _ = sync.ComputeDirHash(file.Path)
+		file.DotfilesHash, _ = sync.ComputeDirHash(sync.DotfilePath(dotfilesPath, appID, *file))
+	}
+}
+
+// markSynced records a file whose sides hash the same as synced, and
+// reports whether they do
+func (m *Model) markSynced(app *models.App, file *models.File) bool {
+	if file.LocalHash == "" || file.LocalHash != file.DotfilesHash {
+		return false
+	}
+	file.ConflictType = models.ConflictNone
+	file.SyncStatus = models.StatusSynced
+	if m.stateManager != nil {
+		m.stateManager.SetFileState(app.ID, file.RelPath, file.LocalHash, file.DotfilesHash)
+		_ = m.stateManager.Save()
+	}
+	return true
+}
+
+// fileRef is a tracked file of an app in the lists
+type fileRef struct {
+	app  *models.App
+	file *models.File
+}
+
+// rehashedMsg carries files re-hashed in the background
+type rehashedMsg struct {
+	refs    []fileRef
+	before  []models.File    // The files as they were when the rehash started
+	results []models.File    // Refreshed copies, in the order of refs
+	tool    *externalToolMsg // The external tool whose exit asked for it
+}
+
+// rehashFiles re-hashes files in the background. It works on copies, so
+// the lists stay responsive while large files are read.
+func (m *Model) rehashFiles(refs []fileRef, tool *externalToolMsg) tea.Cmd {
+	if len(refs) == 0 {
+		return nil
+	}
+	dotfilesPath, stateManager := m.config.DotfilesPath, m.stateManager
+	before := make([]models.File, len(refs))
+	for i, ref := range refs {
+		before[i] = *ref.file
+	}
+	copies := slices.Clone(before)
+	return func() tea.Msg {
+		for i := range copies {
+			rehashFile(dotfilesPath, stateManager, refs[i].app.ID, &copies[i])
+		}
+		return rehashedMsg{refs: refs, before: before, results: copies, tool: tool}
+	}
+}
+
+// handleRehashed puts the re-hashed status into the listed files. Files
+// that turned dirty are named in the status; ones a sync just settled
+// aren't news. Results for files a rescan dropped, or a sync changed while
+// they were hashed, are stale and left out.
+func (m *Model) handleRehashed(msg rehashedMsg) (tea.Model, tea.Cmd) {
+	var changed []string
+	fresh := make(map[*models.File]bool, len(msg.refs))
+	for i, ref := range msg.refs {
+		f, r := ref.file, msg.results[i]
+		if !m.listed(ref) || !sameFileStatus(*f, msg.before[i]) {
+			continue
+		}
+		fresh[f] = true
+		if f.ConflictType != r.ConflictType && r.ConflictType != models.ConflictNone {
+			changed = append(changed, r.RelPath)
+		}
+		f.Size, f.ModTime = r.Size, r.ModTime
+		f.SyncStatus, f.ConflictType = r.SyncStatus, r.ConflictType
+		f.LocalHash, f.DotfilesHash = r.LocalHash, r.DotfilesHash
+	}
+	m.writeStatusFile()
+
+	if msg.tool != nil {
+		if fresh[msg.tool.file] {
+			m.finishExternalTool(*msg.tool)
+		} else {
+			m.status = fmt.Sprintf("%s closed", msg.tool.tool)
+		}
+	} else if len(changed) > 0 && m.screen == ScreenMain {
+		m.status = i18n.T("watch.changed", len(changed), changed[0])
+	}
+	return m, nil
+}
+
+// listed reports whether a file is still one of the apps in the lists. A
+// rescan replaces them, leaving older pointers behind.
+func (m *Model) listed(ref fileRef) bool {
+	if !slices.Contains(m.apps, ref.app) {
+		return false
+	}
+	for i := range ref.app.Files {
+		if &ref.app.Files[i] == ref.file {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFileStatus reports whether nothing a rehash sets changed between two
+// versions of a file
+func sameFileStatus(a, b models.File) bool {
+	return a.Size == b.Size && a.ModTime.Equal(b.ModTime) &&
+		a.SyncStatus == b.SyncStatus && a.ConflictType == b.ConflictType &&
+		a.LocalHash == b.LocalHash && a.DotfilesHash == b.DotfilesHash
+}
+
+// fsDebounce gathers the filesystem events of a save or a checkout into
+// one re-hash and redraw
+const fsDebounce = 200 * time.Millisecond
+
+// fsChangedMsg lists watched paths changed on disk
+type fsChangedMsg struct {
+	paths []string
+}
+
+// fsRetryMsg re-hashes the changed paths held back while state was being
+// written
+type fsRetryMsg struct{}
+
+// rehashChanged re-hashes the files at changed paths. A merge, conflict
+// resolution or editor save records the files it writes itself, so while
+// one is open the paths are held back and retried after fsDebounce.
+func (m *Model) rehashChanged(paths []string) tea.Cmd {
+	m.fsPending = append(m.fsPending, paths...)
+	if m.screen == ScreenMerge || m.screen == ScreenEdit || m.resolvingConflict {
+		if m.fsRetrying || len(m.fsPending) == 0 {
+			return nil
+		}
+		m.fsRetrying = true
+		return tea.Tick(fsDebounce, func(time.Time) tea.Msg { return fsRetryMsg{} })
+	}
+	refs := m.changedRefs(m.fsPending)
+	m.fsPending = nil
+	return m.rehashFiles(refs, nil)
+}
+
+// Close releases what the model holds open past the program, the
+// filesystem watcher's inotify or kqueue handles
+func (m *Model) Close() {
+	if m.fsWatcher != nil {
+		m.fsWatcher.Close()
+	}
+}
+
+// newFSWatcher starts the filesystem watcher, nil if the platform or its
+// limits don't allow one, in which case tracked files are polled
+func newFSWatcher() *fswatch.Watcher {
+	w, err := fswatch.New(fsDebounce)
+	if err != nil {
+		slog.Warn("filesystem events unavailable, polling instead", "err", err)
+		return nil
+	}
+	return w
+}
+
+// waitFSEvents waits for the next batch of changed files
+func (m *Model) waitFSEvents() tea.Cmd {
+	events := m.fsWatcher.Events()
+	return func() tea.Msg {
+		paths, ok := <-events
+		if !ok {
+			return nil
+		}
+		return fsChangedMsg{paths: paths}
+	}
+}
+
+// watchTracked points the filesystem watcher at both sides of every tracked
+// file, after each scan and sync. If it can't watch them all, polling
+// takes over.
+func (m *Model) watchTracked() tea.Cmd {
+	if m.fsWatcher == nil {
+		return nil
+	}
+	m.watchRefs = make(map[string][]fileRef)
+	lay := layout.For(m.config.DotfilesPath)
+	for _, app := range m.apps {
+		for i := range app.Files {
+			f := &app.Files[i]
+			if f.IsDir || f.Excluded || f.NestedRepo {
+				continue
+			}
+			for _, path := range []string{f.Path, layout.Find(lay, m.config.DotfilesPath, app.ID, f.RelPath, f.Path)} {
+				path = filepath.Clean(path)
+				m.watchRefs[path] = append(m.watchRefs[path], fileRef{app, f})
+			}
+		}
+	}
+	paths := make([]string, 0, len(m.watchRefs))
+	for path := range m.watchRefs {
+		paths = append(paths, path)
+	}
+
+	if err := m.fsWatcher.Set(paths); err != nil {
+		slog.Warn("watching tracked files failed, polling instead", "err", err)
+		m.fsWatcher.Close()
+		m.fsWatcher, m.watchRefs = nil, nil
+		return watchTick()
+	}
+	return nil
+}
+
+// changedRefs returns the tracked files at the changed paths, each once
+func (m *Model) changedRefs(paths []string) []fileRef {
+	var refs []fileRef
+	seen := make(map[*models.File]bool)
+	for _, path := range paths {
+		for _, ref := range m.watchRefs[path] {
+			if !seen[ref.file] {
+				seen[ref.file] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// watchInterval is how often tracked files are polled for edits made
+// outside dotsync when filesystem events are unavailable
+var watchInterval = 3 * time.Second
+
+// fileStamp is what a watch check compares: a change to either means the
+// file needs re-hashing
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// watchTickMsg starts a watch check
+type watchTickMsg struct{}
+
+// watchedMsg lists the tracked files changed since the last watch check
+type watchedMsg struct {
+	stamps  map[string]fileStamp
+	changed []fileRef
+}
+
+func watchTick() tea.Cmd {
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg { return watchTickMsg{} })
+}
+
+// checkWatched stats both sides of every tracked file in the background and
+// reports the ones whose size or ModTime changed. Directories are left to
+// rescans: their ModTime misses edits to the files inside.
+func (m *Model) checkWatched() tea.Cmd {
+	if m.syncing || m.scanCancel != nil || m.screen == ScreenScanning || m.screen == ScreenSyncing {
+		return watchTick()
+	}
+
+	type watched struct {
+		ref             fileRef
+		appID, rel, abs string
+	}
+	var files []watched
+	for _, app := range m.apps {
+		for i := range app.Files {
+			f := &app.Files[i]
+			if f.IsDir || f.Excluded || f.NestedRepo {
+				continue
+			}
+			files = append(files, watched{fileRef{app, f}, app.ID, f.RelPath, f.Path})
+		}
+	}
+	dotfilesPath, previous := m.config.DotfilesPath, m.watchStamps
+
+	return func() tea.Msg {
+		lay := layout.For(dotfilesPath)
+		stamps := make(map[string]fileStamp, 2*len(files))
+		var changed []fileRef
+		for _, w := range files {
+			isChanged := false
+			for _, path := range []string{w.abs, layout.Find(lay, dotfilesPath, w.appID, w.rel, w.abs)} {
+				var stamp fileStamp
+				if info, err := os.Stat(path); err == nil {
+					stamp = fileStamp{info.Size(), info.ModTime()}
+				}
+				stamps[path] = stamp
+				if old, ok := previous[path]; ok && (old.size != stamp.size || !old.modTime.Equal(stamp.modTime)) {
+					isChanged = true
+				}
+			}
+			if isChanged {
+				changed = append(changed, w.ref)
+			}
+		}
+		return watchedMsg{stamps: stamps, changed: changed}
+	}
+}
+
+// toolName shows a configured external tool, or the built-in view
+func toolName(spec string) string {
+	if spec == "" {
+		return i18n.T("settings.builtin")
+	}
+	return spec
+}
+
+// handleCommitKeys handles keys in the commit message dialog
+func (m *Model) handleCommitKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		// Cancel commit
+		m.screen = ScreenGit
+		m.textArea.Blur()
+		m.status = "Commit cancelled"
+		return m, nil
+
+	case tea.KeyCtrlS:
+		// Ctrl+S to commit (since Enter is used for newline in textarea)
+		message := strings.TrimSpace(m.textArea.Value())
+		if message == "" {
+			m.status = "Commit message cannot be empty"
+			return m, nil
+		}
+		if err := m.gitPanel.Commit(message); err != nil {
+			m.status = fmt.Sprintf("Commit failed: %v", err)
+		} else {
+			m.status = "Committed! Press 'p' to push to remote"
+			// Show a prompt to push after successful commit
+			m.gitPanel.Refresh()
+		}
+		m.textArea.Blur()
+		m.textArea.Reset()
+		m.screen = ScreenGit
+		return m, nil
+	}
+
+	// Pass other keys to textarea
+	var cmd tea.Cmd
+	m.textArea, cmd = m.textArea.Update(msg)
+	return m, cmd
+}
+
+// renderCommitDialog renders the commit message input dialog
+func (m *Model) renderCommitDialog() string {
+	var b strings.Builder
+
+	// Header
+	header := m.renderHeader()
+	b.WriteString(header)
+	b.WriteString("\n\n")
+
+	// Dialog box
+	width := 60
+	style := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary)
+
+	var content strings.Builder
+	content.WriteString(ui.PanelTitleStyle.Render(i18n.T("commit.title")))
+	content.WriteString("\n\n")
+
+	// Show staged files count
+	stagedCount := 0
+	if m.gitPanel.Status != nil {
+		stagedCount = len(m.gitPanel.Status.Staged)
+	}
+	content.WriteString(i18n.T("commit.files", stagedCount) + "\n\n")
+
+	// Input field - using textarea for multi-line messages
+	content.WriteString(i18n.T("commit.message") + "\n")
+	content.WriteString(m.textArea.View())
+	content.WriteString("\n\n")
+
+	// Help text
+	content.WriteString(ui.MutedStyle.Render(i18n.T("commit.help")))
+
+	box := style.Render(content.String())
+
+	// Center the box
+	b.WriteString(box)
+
+	return ui.AppStyle.Render(b.String())
+}
+
+// handleSearchKeys handles key input in search mode
+func (m *Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		// Cancel search, restore original app list
+		m.searchMode = false
+		m.searchQuery = ""
+		m.textInput.Blur()
+		m.appList.SetApps(m.apps)
+		m.filteredApps = nil
+		m.searchFileMatch = nil
+		m.status = "Search cancelled"
+		m.updateFileList()
+		return m, nil
+
+	case tea.KeyEnter:
+		// Confirm search
+		m.searchMode = false
+		m.textInput.Blur()
+		if m.searchQuery == "" {
+			m.appList.SetApps(m.apps)
+			m.filteredApps = nil
+			m.status = fmt.Sprintf("Showing all %d apps", len(m.apps))
+		} else {
+			m.status = fmt.Sprintf("Showing %d matching apps", len(m.filteredApps))
+		}
+		// Jump into the file panel when a file path matched
+		if m.focusSearchMatch() && m.focusedPanel == PanelApps {
+			m.togglePanel()
+		}
+		return m, nil
+
+	case tea.KeyBackspace, tea.KeyDelete:
+		// Handle backspace in textinput
+		var cmd tea.Cmd
+		m.textInput, cmd = m.textInput.Update(msg)
+		m.searchQuery = m.textInput.Value()
+		m.filterApps()
+		return m, cmd
+
+	case tea.KeyUp:
+		// Navigate up in filtered results
+		m.appList.MoveUp()
+		m.focusSearchMatch()
+		return m, nil
+
+	case tea.KeyDown:
+		// Navigate down in filtered results
+		m.appList.MoveDown()
+		m.focusSearchMatch()
+		return m, nil
+
+	default:
+		// Handle regular typing
+		var cmd tea.Cmd
+		m.textInput, cmd = m.textInput.Update(msg)
+		m.searchQuery = m.textInput.Value()
+		m.filterApps()
+		return m, cmd
+	}
+}
+
+// filterApps fuzzy-matches the search query against app names, IDs,
+// categories and file paths, listing the best matches first
+func (m *Model) filterApps() {
+	m.searchFileMatch = nil
+	if m.searchQuery == "" {
+		m.appList.SetApps(m.apps)
+		m.filteredApps = nil
+		m.status = fmt.Sprintf("Type to search (%d apps)", len(m.apps))
+		return
+	}
+
+	type match struct {
+		app   *models.App
+		score int
+	}
+	var matches []match
+	m.searchFileMatch = make(map[string]string)
+
+	for _, app := range m.apps {
+		score, ok := fuzzy.Best(m.searchQuery, app.Name, app.ID, app.Category)
+		// A file path that matches better than the app itself is remembered
+		// so the file panel can jump to it
+		for _, file := range app.Files {
+			if s, fileOK := fuzzy.Score(m.searchQuery, file.RelPath); fileOK && (!ok || s > score) {
+				score, ok = s, true
+				m.searchFileMatch[app.ID] = file.RelPath
+			}
+		}
+		if ok {
+			matches = append(matches, match{app, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	filtered := make([]*models.App, len(matches))
+	for i, match := range matches {
+		filtered[i] = match.app
+	}
+
+	m.filteredApps = filtered
+	m.appList.SetApps(filtered)
+	if len(filtered) > 0 {
+		m.appList.FocusApp(filtered[0])
+	}
+	m.focusSearchMatch()
+	m.status = fmt.Sprintf("Found %d apps matching '%s'", len(filtered), m.searchQuery)
+}
+
+// focusSearchMatch shows the current app's files with the cursor on the
+// file that matched the search. Returns true if there was such a file.
+func (m *Model) focusSearchMatch() bool {
+	m.updateFileList()
+	app := m.appList.Current()
+	if app == nil {
+		return false
+	}
+	relPath := m.searchFileMatch[app.ID]
+	return relPath != "" && m.fileList.FocusPath(relPath)
+}
+
+// filterByCategory filters apps by category
+func (m *Model) filterByCategory(category string) (tea.Model, tea.Cmd) {
+	if m.categoryFilter == category {
+		// Toggle off if same category
+		return m.clearCategoryFilter()
+	}
+
+	m.categoryFilter = category
+	var filtered []*models.App
+
+	for _, app := range m.apps {
+		if strings.ToLower(app.Category) == category {
+			filtered = append(filtered, app)
+		}
+	}
+
+	m.filteredApps = filtered
+	m.appList.SetApps(filtered)
+	m.updateFileList()
+
+	categoryLabels := map[string]string{
+		"ai":           "AI Tools",
+		"shell":        "Shells",
+		"editor":       "Editors",
+		"terminal":     "Terminals",
+		"git":          "Git Tools",
+		"dev":          "Dev Tools",
+		"cli":          "CLI Tools",
+		"productivity": "Productivity",
+		"cloud":        "Cloud/Infra",
+	}
+
+	label := categoryLabels[category]
+	if label == "" {
+		label = category
+	}
+	m.status = fmt.Sprintf("Filtered: %s (%d apps) • Press 0 to clear", label, len(filtered))
+	return m, nil
+}
+
+// clearCategoryFilter clears the category filter
+func (m *Model) clearCategoryFilter() (tea.Model, tea.Cmd) {
+	m.categoryFilter = ""
+	m.searchQuery = ""
+	m.filteredApps = nil
+	m.appList.SetApps(m.apps)
+	m.updateFileList()
+	m.status = fmt.Sprintf("Showing all %d apps", len(m.apps))
+	return m, nil
+}
+
+// clearAllFilters clears the search, category and status filters
+func (m *Model) clearAllFilters() (tea.Model, tea.Cmd) {
+	m.appList.StatusFilter = models.FilterNone
+	m.fileList.StatusFilter = models.FilterNone
+	return m.clearCategoryFilter()
+}
+
+// handleStatusFilter cycles both panels between showing only conflicted
+// files, only out-of-sync files, and everything
+func (m *Model) handleStatusFilter() (tea.Model, tea.Cmd) {
+	filter := m.appList.StatusFilter.Next()
+	m.appList.SetStatusFilter(filter)
+	m.fileList.StatusFilter = filter
+	m.updateFileList()
+
+	switch filter {
+	case models.FilterConflicts:
+		m.status = fmt.Sprintf("Showing %d apps with conflicts • F: changed only, Esc: clear", len(m.appList.Apps))
+	case models.FilterChanged:
+		m.status = fmt.Sprintf("Showing %d apps with changes • F: show all, Esc: clear", len(m.appList.Apps))
+	default:
+		m.status = fmt.Sprintf("Showing all %d apps", len(m.appList.Apps))
+	}
+	return m, nil
+}
+
+// handleSelectModified selects all apps/files with modifications
+func (m *Model) handleSelectModified() (tea.Model, tea.Cmd) {
+	m.saveSelectionState() // Save before changing
+	modifiedCount := 0
+
+	if m.focusedPanel == PanelApps {
+		// Select all apps that have modified or conflicting files
+		for _, app := range m.apps {
+			hasModified := false
+			for _, file := range app.Files {
+				switch file.ConflictType {
+				case models.ConflictLocalModified, models.ConflictLocalNew,
+					models.ConflictDotfilesModified, models.ConflictDotfilesNew,
+					models.ConflictBothModified:
+					hasModified = true
+					break
+				}
+				if hasModified {
+					break
+				}
+			}
+			if hasModified {
+				app.Selected = true
+				modifiedCount++
+			}
+		}
+		m.appList.SetApps(m.apps)
+		m.status = fmt.Sprintf("Selected %d apps with modifications", modifiedCount)
+	} else {
+		// Select all files that have modifications in current file list
+		for i := range m.fileList.Files {
+			switch m.fileList.Files[i].ConflictType {
+			case models.ConflictLocalModified, models.ConflictLocalNew,
+				models.ConflictDotfilesModified, models.ConflictDotfilesNew,
+				models.ConflictBothModified:
+				m.fileList.Files[i].Selected = true
+				modifiedCount++
+			}
+		}
+		m.syncFilesToApp()
+		m.status = fmt.Sprintf("Selected %d modified files", modifiedCount)
+	}
+
+	return m, nil
+}
+
+// handleSelectOutdated selects all apps/files that need to be pulled (outdated)
+func (m *Model) handleSelectOutdated() (tea.Model, tea.Cmd) {
+	m.saveSelectionState() // Save before changing
+	outdatedCount := 0
+
+	if m.focusedPanel == PanelApps {
+		// Select all apps that have outdated files (need pull)
+		for _, app := range m.apps {
+			hasOutdated := false
+			for _, file := range app.Files {
+				switch file.ConflictType {
+				case models.ConflictDotfilesModified, models.ConflictDotfilesNew:
+					hasOutdated = true
+					break
+				}
+				if hasOutdated {
+					break
+				}
+			}
+			if hasOutdated {
+				app.Selected = true
+				outdatedCount++
+			}
+		}
+		m.appList.SetApps(m.apps)
+		m.status = fmt.Sprintf("Selected %d apps with outdated files (need pull)", outdatedCount)
+	} else {
+		// Select all files that are outdated in current file list
+		for i := range m.fileList.Files {
+			switch m.fileList.Files[i].ConflictType {
+			case models.ConflictDotfilesModified, models.ConflictDotfilesNew:
+				m.fileList.Files[i].Selected = true
+				outdatedCount++
+			}
+		}
+		m.syncFilesToApp()
+		m.status = fmt.Sprintf("Selected %d outdated files (need pull)", outdatedCount)
+	}
+
+	return m, nil
+}
+
+// handleRefresh refreshes the current view by rescanning
+func (m *Model) handleRefresh() (tea.Model, tea.Cmd) {
+	// If a category filter is active, preserve it after refresh
+	savedFilter := m.categoryFilter
+
+	m.screen = ScreenScanning
+	m.status = "Refreshing..."
+
+	if m.scanCancel != nil {
+		m.scanCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.scanCancel = cancel
+
+	// Create a wrapped scan function that restores filter after scan
+	return m, func() tea.Msg {
+		s := newScanner(m.config)
+		apps, err := s.ScanContext(ctx)
+		if err == nil {
+			err = sync.UpdateSyncStatusAll(ctx, apps, m.config.DotfilesPath, m.stateManager)
+		}
+		if err == nil {
+			saveHashCache()
+		}
+
+		// Restore category filter state in the message
+		return refreshCompleteMsg{
+			apps:           apps,
+			err:            err,
+			categoryFilter: savedFilter,
+		}
+	}
+}
+
+// saveSelectionState saves the current selection state for undo
+func (m *Model) saveSelectionState() {
+	m.lastAppSelections = make(map[string]bool)
+	m.lastFileSelections = make(map[string]bool)
+
+	for _, app := range m.apps {
+		m.lastAppSelections[app.ID] = app.Selected
+		for _, file := range app.Files {
+			m.lastFileSelections[file.Path] = file.Selected
+		}
+	}
+	m.canUndo = true
+}
+
+// handleUndo restores the previous selection state
+func (m *Model) handleUndo() (tea.Model, tea.Cmd) {
+	if !m.canUndo || m.lastAppSelections == nil {
+		m.status = "Nothing to undo"
+		return m, nil
+	}
+
+	// Restore app selections
+	for _, app := range m.apps {
+		if selected, ok := m.lastAppSelections[app.ID]; ok {
+			app.Selected = selected
+		}
+		// Restore file selections
+		for i := range app.Files {
+			if selected, ok := m.lastFileSelections[app.Files[i].Path]; ok {
+				app.Files[i].Selected = selected
+			}
+		}
+	}
+
+	m.appList.SetApps(m.apps)
+	m.updateFileList()
+	m.canUndo = false
+	m.status = "Selection restored"
+	return m, nil
+}
+
+// handleQuickSync runs the Quick Sync workflow
+func (m *Model) handleQuickSync() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
+	if m.quickSync == nil {
+		m.status = "Quick backup not initialized"
+		return m, nil
+	}
+
+	selectedApps := m.appList.SelectedApps()
+	if len(selectedApps) == 0 {
+		m.status = "No apps selected"
+		return m, nil
+	}
+
+	if !m.acquireSyncLock("quick backup") {
+		return m, nil
+	}
+	m.status = "Running quick backup..."
+	m.syncing = true
+
+	return m, func() tea.Msg {
+		result := m.quickSync.Run(selectedApps)
+		return quickSyncCompleteMsg{result: result}
+	}
+}
+
+func (m *Model) handleQuickSyncKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.quickSyncResult.Items
+
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.diffFromQuickSync = false
+		m.screen = ScreenMain
+		m.status = "Ready"
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.quickSyncCursor > 0 {
+			m.quickSyncCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.quickSyncCursor < len(items)-1 {
+			m.quickSyncCursor++
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Enter, m.keys.Diff):
+		return m.openQuickSyncDiff(false)
+
+	case key.Matches(msg, m.keys.Merge):
+		return m.openQuickSyncDiff(true)
+
+	case key.Matches(msg, m.keys.SaveReport):
+		return m.handleSaveReport()
+	}
+
+	return m, nil
+}
+
+// openQuickSyncDiff shows the diff for the result item under the cursor,
+// optionally jumping straight into merge
+func (m *Model) openQuickSyncDiff(merge bool) (tea.Model, tea.Cmd) {
+	items := m.quickSyncResult.Items
+	if m.quickSyncCursor >= len(items) {
+		return m, nil
+	}
+	item := items[m.quickSyncCursor]
+
+	localPath := item.File.FilePath
+	dotfilePath := item.File.DotfilesPath
+
+	diffResult, err := sync.ComputeDiff(localPath, dotfilePath)
+	if err != nil {
+		m.status = fmt.Sprintf("Diff error: %v", err)
+		return m, nil
+	}
+
+	app := &models.App{ID: item.File.AppID, Name: item.File.AppID}
+	for _, a := range m.apps {
+		if a.ID == item.File.AppID {
+			app = a
+			break
+		}
+	}
+
+	m.currentDiffFile = &models.File{
+		Name:    filepath.Base(localPath),
+		Path:    localPath,
+		RelPath: item.File.RelPath,
+	}
+	m.currentDiffApp = app
+	m.resolvingConflict = false
+	m.diffFromQuickSync = true
+
+	m.diffView.SetDiff(diffResult, localPath, dotfilePath)
+	m.diffView.Width = m.width - 4
+	m.diffView.Height = m.height - 6
+	m.screen = ScreenDiff
+	m.status = fmt.Sprintf("%s %s • m: merge • Esc: back to results", item.Outcome.Icon(), item.Outcome)
+
+	if merge {
+		return m.handleMerge()
+	}
+	return m, nil
+}
+
+// finishQuickSyncMerge exports the merged local file to its dotfiles path,
+// records the sync and marks the result item as backed up
+func (m *Model) finishQuickSyncMerge() (tea.Model, tea.Cmd) {
+	item := &m.quickSyncResult.Items[m.quickSyncCursor]
+
+	err := sync.NewExporter(m.config).ExportFile(item.File.AppID, item.File.RelPath, item.File.FilePath, item.File.DotfilesPath)
+	result, message := audit.ResultFor(err)
+	m.logAudit(audit.Entry{Action: audit.ActionMerge, AppID: item.File.AppID, File: item.File.RelPath, Result: result, Error: message, Detail: "quick backup"})
+	if err != nil {
+		m.status = fmt.Sprintf("Error: merged locally but copy to dotfiles failed: %v", err)
+		return m, nil
+	}
+	sync.GetHashCache().InvalidatePath(item.File.FilePath)
+	sync.GetHashCache().InvalidatePath(item.File.DotfilesPath)
+
+	if m.stateManager != nil {
+		// Filters can leave the copy different from the local file
+		localHash, err := sync.ComputeFileHash(item.File.FilePath)
+		dotfilesHash, err2 := sync.ComputeFileHash(item.File.DotfilesPath)
+		if err == nil && err2 == nil {
+			m.stateManager.SetFileState(item.File.AppID, item.File.RelPath, localHash, dotfilesHash)
+			m.stateManager.RecordPush(item.File.AppID, item.File.RelPath)
+			_ = m.stateManager.Save()
+		}
+	}
+
+	item.Outcome = quicksync.OutcomeBackedUp
+	item.Error = nil
+	m.screen = ScreenQuickSync
+	m.status = fmt.Sprintf("✓ Merged %s", item.File.RelPath)
+	return m, nil
+}
+
+// digestMsg carries a freshly built activity digest
+type digestMsg struct {
+	digest *digest.Digest
+	err    error
+}
+
+// handleDigest opens the weekly digest and builds it in the background
+func (m *Model) handleDigest() (tea.Model, tea.Cmd) {
+	m.screen = ScreenDigest
+	m.digest = nil
+	m.status = "Building digest..."
+	return m, m.buildDigest
+}
+
+func (m *Model) buildDigest() tea.Msg {
+	machine := ""
+	if m.modesConfig != nil {
+		machine = m.modesConfig.MachineName
+	}
+	d, err := digest.Build(m.config.DotfilesPath, machine, m.stateManager, time.Now().Add(-digest.Week))
+	return digestMsg{digest: d, err: err}
+}
+
+func (m *Model) handleDigestKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit, m.keys.Digest):
+		m.screen = ScreenMain
+		m.status = ""
+		return m, nil
+	case key.Matches(msg, m.keys.Refresh):
+		return m.handleDigest()
+	case msg.String() == "c":
+		m.changelogUntil = time.Now()
+		return m.handleChangelog()
+	}
+	return m, nil
+}
+
+func (m *Model) renderDigest() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("digest.title")))
+	b.WriteString("\n")
+
+	d := m.digest
+	if d == nil {
+		b.WriteString(m.spinner.View() + i18n.T("digest.loading"))
+		b.WriteString("\n")
+		return ui.AppStyle.Render(b.String())
+	}
+
+	b.WriteString(ui.MutedStyle.Render(i18n.T("digest.since", d.Since.Format("Mon 2006-01-02"))))
+	b.WriteString("\n\n")
+
+	row := func(label, value string) {
+		b.WriteString(fmt.Sprintf("  %s %s\n", lipgloss.NewStyle().Width(18).Render(label), value))
+	}
+
+	if !d.HasRepo {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("digest.no_repo")))
+		b.WriteString("\n\n")
+	} else {
+		row(i18n.T("digest.commits"), fmt.Sprintf("%d", d.Commits))
+		row(i18n.T("digest.files"), fmt.Sprintf("%d", d.Files))
+		row(i18n.T("digest.conflicts"), fmt.Sprintf("%d", d.Conflicts))
+		if len(d.Machines) > 0 {
+			row(i18n.T("digest.machines"), strings.Join(d.Machines, ", "))
+		} else {
+			row(i18n.T("digest.machines"), ui.MutedStyle.Render(i18n.T("digest.none")))
+		}
+		if len(d.Authors) > 0 {
+			row(i18n.T("digest.authors"), strings.Join(d.Authors, ", "))
+		}
+		growth := d.Growth()
+		sign := "+"
+		if growth < 0 {
+			sign, growth = "-", -growth
+		}
+		row(i18n.T("digest.size"), fmt.Sprintf("%s (%s%s)", components.FormatBytes(d.SizeAfter), sign, components.FormatBytes(growth)))
+	}
+	row(i18n.T("digest.synced"), i18n.T("digest.synced_files", d.Synced))
+
+	if len(d.Apps) > 0 {
+		b.WriteString("\n")
+		b.WriteString(ui.PanelTitleStyle.Render(i18n.T("digest.apps")))
+		b.WriteString("\n")
+		for i, app := range d.Apps {
+			if i >= 8 {
+				b.WriteString(ui.MutedStyle.Render(i18n.T("digest.more", len(d.Apps)-i)))
+				b.WriteString("\n")
+				break
+			}
+			b.WriteString(fmt.Sprintf("  %-24s %s\n", app.Name, i18n.T("digest.changes", app.Changes)))
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("digest.help")))
+	b.WriteString("\n")
+	return ui.AppStyle.Render(b.String())
+}
+
+// changelogMsg carries a freshly built changelog
+type changelogMsg struct {
+	changelog *changelog.Changelog
+	err       error
+}
+
+// handleChangelog opens the changelog of the week ending at
+// m.changelogUntil and builds it in the background
+func (m *Model) handleChangelog() (tea.Model, tea.Cmd) {
+	m.screen = ScreenChangelog
+	m.changelog = nil
+	m.status = "Building changelog..."
+	until := m.changelogUntil
+	dotfilesPath := m.config.DotfilesPath
+	return m, func() tea.Msg {
+		c, err := changelog.BuildWindow(dotfilesPath, until.Add(-digest.Week), until)
+		return changelogMsg{changelog: c, err: err}
+	}
+}
+
+func (m *Model) handleChangelogKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.upstreamReview {
+		return m.handleUpstreamKeys(msg)
+	}
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.screen = ScreenDigest
+		m.status = ""
+		return m, nil
+	case key.Matches(msg, m.keys.Up):
+		if m.changelogScroll > 0 {
+			m.changelogScroll--
+		}
+	case key.Matches(msg, m.keys.Down):
+		m.changelogScroll++
+	case msg.String() == "[":
+		m.changelogUntil = m.changelogUntil.Add(-digest.Week)
+		return m.handleChangelog()
+	case msg.String() == "]":
+		if next := m.changelogUntil.Add(digest.Week); next.Before(time.Now()) {
+			m.changelogUntil = next
+		} else {
+			m.changelogUntil = time.Now()
+		}
+		return m.handleChangelog()
+	case key.Matches(msg, m.keys.Refresh):
+		return m.handleChangelog()
+	case msg.String() == "e":
+		if m.changelog == nil {
+			return m, nil
+		}
+		path := filepath.Join(config.ConfigDir(), "changelogs", m.changelogUntil.Format("2006-01-02")+".md")
+		if err := m.changelog.WriteFile(path); err != nil {
+			m.status = fmt.Sprintf("Error writing changelog: %v", err)
+		} else {
+			m.status = fmt.Sprintf("✓ Changelog written to %s", path)
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) renderChangelog() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	title, help := "changelog.title", "changelog.help"
+	if m.upstreamReview {
+		title, help = "upstream.title", "upstream.help"
+	}
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T(title)))
+	b.WriteString("\n")
+
+	c := m.changelog
+	if c == nil {
+		b.WriteString(m.spinner.View() + i18n.T("changelog.loading"))
+		b.WriteString("\n")
+		return ui.AppStyle.Render(b.String())
+	}
+
+	b.WriteString(ui.MutedStyle.Render(c.Range()))
+	b.WriteString("\n\n")
+
+	var lines []string
+	if c.IsEmpty() {
+		lines = append(lines, ui.MutedStyle.Render(i18n.T("changelog.empty")))
+	} else {
+		added, removed := c.Lines()
+		lines = append(lines, i18n.T("changelog.summary", c.Commits, c.Files(), len(c.Apps), added, removed), "")
+		for _, app := range c.Apps {
+			lines = append(lines, fmt.Sprintf("%s %s", ui.PanelTitleStyle.Render(app.ID), ui.MutedStyle.Render(fmt.Sprintf("+%d -%d", app.Added, app.Removed))))
+			for _, f := range app.Files {
+				lines = append(lines, fmt.Sprintf("  %-9s %s  %s", f.Change, f.Name(), ui.MutedStyle.Render(f.Lines())))
+			}
+		}
+	}
+
+	height := max(5, m.height-10)
+	if m.changelogScroll > max(0, len(lines)-height) {
+		m.changelogScroll = max(0, len(lines)-height)
+	}
+	end := min(len(lines), m.changelogScroll+height)
+	for _, line := range lines[m.changelogScroll:end] {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T(help)))
+	b.WriteString("\n")
+	return ui.AppStyle.Render(b.String())
+}
+
+// fetchInterval is how often the dotfiles repo is fetched in the
+// background to spot changes pushed from other machines
+var fetchInterval = 15 * time.Minute
+
+// fetchTickMsg starts a background fetch
+type fetchTickMsg struct{}
+
+// upstreamMsg carries what upstream has that the dotfiles repo lacks
+type upstreamMsg struct {
+	changelog *changelog.Changelog
+	err       error
+}
+
+func fetchTick() tea.Cmd {
+	return tea.Tick(fetchInterval, func(time.Time) tea.Msg { return fetchTickMsg{} })
+}
+
+// checkUpstream diffs the dotfiles repo against what upstream has in the
+// background, fetching first if asked. A failed fetch, e.g. offline or
+// with a remote that needs a password, compares with the last fetch.
+func (m *Model) checkUpstream(fetch bool) tea.Cmd {
+	if !m.config.IsGitRepo() || !remote.ParseKind(m.config.RemoteBackend).UsesGit() {
+		return nil
+	}
+	dotfilesPath := m.config.DotfilesPath
+	flow := newBranchFlow(m.config, m.modesConfig)
+	return func() tea.Msg {
+		repo := git.NewRepo(dotfilesPath)
+		if fetch && repo.HasRemote() {
+			if err := repo.FetchQuiet(); err != nil {
+				slog.Debug("background fetch failed", "err", err)
+			}
+		}
+		rev := repo.Upstream()
+		if flow != nil {
+			rev = flow.Upstream()
+		}
+		if rev == "" {
+			return upstreamMsg{}
+		}
+		c, err := changelog.Incoming(dotfilesPath, rev)
+		return upstreamMsg{changelog: c, err: err}
+	}
+}
+
+// handleUpstream keeps what upstream has for the banner and the review
+func (m *Model) handleUpstream(msg upstreamMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		slog.Warn("checking upstream failed", "err", msg.err)
+		return m, nil
+	}
+	m.upstream = msg.changelog
+	if m.upstreamReview && m.screen == ScreenChangelog {
+		if m.upstream == nil {
+			m.upstreamReview = false
+			m.screen = ScreenMain
+			m.status = i18n.T("upstream.none")
+			return m, nil
+		}
+		m.changelog = m.upstream
+		m.status = ""
+	}
+	return m, nil
+}
+
+// upstreamApps returns the apps upstream changed that this machine tracks
+func (m *Model) upstreamApps() []string {
+	if m.upstream == nil {
+		return nil
+	}
+	var ids []string
+	for _, app := range m.upstream.Apps {
+		if slices.ContainsFunc(m.apps, func(a *models.App) bool { return a.ID == app.ID }) {
+			ids = append(ids, app.ID)
+		}
+	}
+	return ids
+}
+
+// renderUpstreamBanner tells which tracked apps changed upstream, "" when
+// none did
+func (m *Model) renderUpstreamBanner() string {
+	ids := m.upstreamApps()
+	if len(ids) == 0 {
+		return ""
+	}
+	names := strings.Join(ids[:min(3, len(ids))], ", ")
+	if len(ids) > 3 {
+		names += ", …"
+	}
+	return ui.OutdatedStyle.Padding(0, 1).Render(i18n.T("upstream.banner", len(ids), names))
+}
+
+// handleUpstreamReview shows the changes upstream has on the changelog
+// screen, to pull them from there
+func (m *Model) handleUpstreamReview() (tea.Model, tea.Cmd) {
+	if m.upstream == nil {
+		m.status = i18n.T("upstream.none")
+		return m, m.checkUpstream(true)
+	}
+	m.screen = ScreenChangelog
+	m.changelog = m.upstream
+	m.changelogScroll = 0
+	m.upstreamReview = true
+	m.status = ""
+	return m, nil
+}
+
+func (m *Model) handleUpstreamKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.upstreamReview = false
+		m.screen = ScreenMain
+		m.status = ""
+	case key.Matches(msg, m.keys.Up):
+		if m.changelogScroll > 0 {
+			m.changelogScroll--
+		}
+	case key.Matches(msg, m.keys.Down):
+		m.changelogScroll++
+	case key.Matches(msg, m.keys.Refresh):
+		m.status = i18n.T("upstream.fetching")
+		return m, m.checkUpstream(true)
+	case key.Matches(msg, m.keys.Pull):
+		if m.blockedByReadOnly() {
+			return m, nil
+		}
+		if err := m.pullRemote(); err != nil {
+			m.status = fmt.Sprintf("Pull failed: %v", err)
+			return m, nil
+		}
+		// The rescan marks the pulled apps outdated, ready to pull locally
+		m.upstreamPulled = m.upstreamApps()
+		m.upstream = nil
+		m.upstreamReview = false
+		m.screen = ScreenScanning
+		m.status = i18n.T("upstream.rescanning")
+		return m, m.scanApps()
+	}
+	return m, nil
+}
+
+// dashboardMsg carries a freshly built dashboard summary
+type dashboardMsg struct {
+	summary *dashboard.Summary
+}
+
+// handleDashboard opens the dashboard and gathers git, audit and backup
+// details in the background
+func (m *Model) handleDashboard() (tea.Model, tea.Cmd) {
+	m.screen = ScreenDashboard
+	m.dashboard = nil
+	apps := m.apps
+	return m, func() tea.Msg {
+		summary := dashboard.Build(apps)
+		if m.auditLog != nil {
+			entries, _ := m.auditLog.Read(audit.Filter{})
+			summary.WithAudit(entries)
+		}
+		if repo := git.NewRepo(m.config.DotfilesPath); repo.IsRepo() {
+			if status, err := repo.GetStatus(); err == nil {
+				summary.WithGit(status)
+			}
+		}
+		if m.backupManager != nil {
+			if machines, err := m.backupManager.ListMachines(); err == nil {
+				summary.WithMachines(machines)
+			}
+		}
+		return dashboardMsg{summary: summary}
+	}
+}
+
+func (m *Model) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit, m.keys.Enter, m.keys.Dashboard):
+		m.screen = ScreenMain
+		return m, nil
+	case key.Matches(msg, m.keys.Refresh):
+		return m.handleDashboard()
+	case key.Matches(msg, m.keys.Push):
+		// Select every app with local changes and confirm the push
+		m.screen = ScreenMain
+		m.focusedPanel = PanelApps
+		m.selectAppsWith(models.ConflictLocalModified, models.ConflictLocalNew)
+		return m.handlePush()
+	case key.Matches(msg, m.keys.Pull):
+		m.screen = ScreenMain
+		m.focusedPanel = PanelApps
+		m.selectAppsWith(models.ConflictDotfilesModified, models.ConflictDotfilesNew)
+		return m.handlePull()
+	case key.Matches(msg, m.keys.ConflictQueue):
+		return m.handleConflictQueue()
+	case key.Matches(msg, m.keys.Git):
+		return m.handleGit()
+	case key.Matches(msg, m.keys.Scan):
+		m.screen = ScreenScanning
+		m.status = "Scanning..."
+		return m, m.scanApps()
+	}
+	return m, nil
+}
+
+// selectAppsWith selects exactly the apps that have a file in one of the
+// given states
+func (m *Model) selectAppsWith(states ...models.ConflictType) {
+	m.saveSelectionState()
+	for _, app := range m.apps {
+		app.Selected = false
+		for _, file := range app.Files {
+			if !file.Excluded && slices.Contains(states, file.ConflictType) {
+				app.Selected = true
+				break
+			}
+		}
+	}
+}
+
+func (m *Model) renderDashboard() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("dashboard.title")))
+	b.WriteString("\n")
+
+	s := m.dashboard
+	if s == nil {
+		b.WriteString(m.spinner.View() + i18n.T("dashboard.loading"))
+		b.WriteString("\n")
+		return ui.AppStyle.Render(b.String())
+	}
+
+	if s.Healthy() {
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("dashboard.healthy")))
+	} else {
+		b.WriteString(ui.ModifiedStyle.Render(i18n.T("dashboard.attention")))
+	}
+	b.WriteString("\n\n")
+
+	row := func(label, value string) {
+		b.WriteString(fmt.Sprintf("  %s %s\n", lipgloss.NewStyle().Width(18).Render(label), value))
+	}
+	count := func(n int, style lipgloss.Style) string {
+		if n == 0 {
+			return ui.MutedStyle.Render("0")
+		}
+		return style.Render(fmt.Sprintf("%d", n))
+	}
+
+	row(i18n.T("dashboard.tracked"), i18n.T("dashboard.tracked_n", s.Apps, s.Files))
+	row(i18n.T("dashboard.modified"), count(s.Modified, ui.ModifiedStyle))
+	row(i18n.T("dashboard.outdated"), count(s.Outdated, ui.OutdatedStyle))
+	row(i18n.T("dashboard.conflicts"), count(s.Conflicts, ui.ConflictStyle))
+	b.WriteString("\n")
+	row(i18n.T("dashboard.last_push"), components.FormatTimeAgo(s.LastPush))
+	row(i18n.T("dashboard.last_pull"), components.FormatTimeAgo(s.LastPull))
+	row(i18n.T("dashboard.last_qb"), components.FormatTimeAgo(s.LastBackup))
+	if s.HasRepo {
+		row(i18n.T("dashboard.git"), i18n.T("dashboard.git_status", s.Branch, s.Ahead, s.Behind))
+	} else {
+		row(i18n.T("dashboard.git"), ui.MutedStyle.Render(i18n.T("dashboard.no_repo")))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("dashboard.machines")))
+	b.WriteString("\n")
+	if len(s.Machines) == 0 {
+		b.WriteString(ui.MutedStyle.Render("  " + i18n.T("dashboard.no_backups")))
+		b.WriteString("\n")
+	}
+	now := time.Now()
+	for _, machine := range s.Machines {
+		freshness := dashboard.Freshness(machine.LastSync, now)
+		style := ui.MutedStyle
+		switch freshness {
+		case dashboard.FreshnessFresh:
+			style = ui.SyncedStyle
+		case dashboard.FreshnessStale:
+			style = ui.ModifiedStyle
+		case dashboard.FreshnessOld:
+			style = ui.ConflictStyle
+		}
+		b.WriteString(fmt.Sprintf("  %-24s %-16s %s\n", machine.Name,
+			components.FormatTimeAgo(machine.LastSync), style.Render(i18n.T("dashboard."+freshness))))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("dashboard.help")))
+	b.WriteString("\n")
+	return ui.AppStyle.Render(b.String())
+}
+
+// handleSuggestions opens the suggestions screen and gathers sync state,
+// backups and modes into recommendations in the background
+func (m *Model) handleSuggestions() (tea.Model, tea.Cmd) {
+	m.screen = ScreenSuggestions
+	m.recommendations = nil
+	m.recommendLoading = true
+	apps := m.apps
+	return m, func() tea.Msg {
+		facts := suggestions.Facts{Apps: apps, Tracked: make(map[string]bool)}
+		if m.stateManager != nil {
+			for _, f := range m.stateManager.Files() {
+				facts.Tracked[f.AppID] = true
+			}
+		}
+		if m.backupManager != nil {
+			facts.Machines, _ = m.backupManager.AppMachines()
+		}
+		return suggestionsMsg{recommendations: suggestions.NewAnalyzer(m.modesConfig).Recommend(facts)}
+	}
+}
+
+func (m *Model) handleSuggestionsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit, m.keys.Suggestions):
+		m.screen = ScreenMain
+		return m, nil
+	case key.Matches(msg, m.keys.Refresh):
+		return m.handleSuggestions()
+	case key.Matches(msg, m.keys.Up):
+		if m.recommendCursor > 0 {
+			m.recommendCursor--
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.Down):
+		if m.recommendCursor < len(m.recommendations)-1 {
+			m.recommendCursor++
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.Enter):
+		if m.recommendCursor < len(m.recommendations) {
+			return m.actOnRecommendation(m.recommendations[m.recommendCursor])
+		}
+	}
+	return m, nil
+}
+
+// actOnRecommendation carries out the action of a recommendation. Actions
+// that change settings in place drop the recommendation and stay on the
+// screen; the others continue on the main screen.
+func (m *Model) actOnRecommendation(rec suggestions.Recommendation) (tea.Model, tea.Cmd) {
+	switch rec.Kind {
+	case suggestions.KindConflicts:
+		m.screen = ScreenMain
+		m.focusedPanel = PanelApps
+		m.appList.SetStatusFilter(models.FilterConflicts)
+		m.fileList.StatusFilter = models.FilterConflicts
+		m.updateFileList()
+		m.status = fmt.Sprintf("Showing %d apps with conflicts • Esc: clear", len(m.appList.Apps))
+		return m, nil
+
+	case suggestions.KindUnpushed, suggestions.KindNeverPushed:
+		m.saveSelectionState()
+		for _, app := range m.apps {
+			app.Selected = slices.Contains(rec.AppIDs, app.ID)
+		}
+		m.appList.SetApps(m.apps)
+		m.updateFileList()
+		m.screen = ScreenMain
+		m.focusedPanel = PanelApps
+		return m.handlePush()
+
+	case suggestions.KindManyMachines:
+		if m.modesConfig == nil {
+			m.status = "Modes not initialized"
+			return m, nil
+		}
+		for _, id := range rec.AppIDs {
+			if !m.modesConfig.IsAppSynced(id) {
+				m.modesConfig.ToggleAppSync(id)
+			}
+		}
+		if err := m.modesConfig.Save(); err != nil {
+			m.status = fmt.Sprintf("Failed to save mode: %v", err)
+			return m, nil
+		}
+		m.appList.SetModesConfig(m.modesConfig)
+		m.updateFileList()
+		m.status = fmt.Sprintf("%s: sync enabled", strings.Join(rec.AppIDs, ", "))
+
+	case suggestions.KindLargeFile:
+		m.saveSelectionState()
+		for _, app := range m.apps {
+			for i := range app.Files {
+				if slices.Contains(rec.Files, app.Files[i].Path) {
+					app.Files[i].Selected = false
+				}
+			}
+		}
+		m.appList.SetApps(m.apps)
+		m.updateFileList()
+		m.status = fmt.Sprintf("Deselected %d large files • u: undo", len(rec.Files))
+	}
+
+	m.recommendations = slices.Delete(m.recommendations, m.recommendCursor, m.recommendCursor+1)
+	if m.recommendCursor >= len(m.recommendations) && m.recommendCursor > 0 {
+		m.recommendCursor--
+	}
+	return m, nil
+}
+
+func (m *Model) renderSuggestions() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("suggestions.title")))
+	b.WriteString("\n")
+
+	if m.recommendLoading {
+		b.WriteString(m.spinner.View() + i18n.T("suggestions.loading"))
+		b.WriteString("\n")
+		return ui.AppStyle.Render(b.String())
+	}
+	b.WriteString(ui.MutedStyle.Render(i18n.T("suggestions.desc")))
+	b.WriteString("\n\n")
+
+	if len(m.recommendations) == 0 {
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("suggestions.none")))
+		b.WriteString("\n")
+		return ui.AppStyle.Render(b.String())
+	}
+
+	for i, rec := range m.recommendations {
+		style := ui.ModifiedStyle
+		if rec.Kind == suggestions.KindConflicts {
+			style = ui.ConflictStyle
+		}
+		line := style.Render(rec.Message)
+		if i == m.recommendCursor {
+			b.WriteString(ui.CursorStyle.Render("  ▸ "))
+		} else {
+			b.WriteString("    ")
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+
+		about := rec.AppIDs
+		if len(rec.Files) > 0 {
+			about = rec.Files
+		}
+		if len(about) > 5 {
+			about = append(slices.Clone(about[:5]), fmt.Sprintf("+%d more", len(about)-5))
+		}
+		detail := fmt.Sprintf("%s: %s - %s", rec.Action.Key, rec.Action.Label, strings.Join(about, ", "))
+		b.WriteString("      " + ui.MutedStyle.Render(detail))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("suggestions.help", m.recommendCursor+1, len(m.recommendations))))
+	b.WriteString("\n")
+	return ui.AppStyle.Render(b.String())
+}
+
+// recordFileState updates the sync state of a file synced successfully
+func (m *Model) recordFileState(action string, r sync.ExportResult) {
+	if m.stateManager == nil {
+		return
+	}
+	recordSyncState(m.config, m.stateManager, action, r)
+}
+
+// recordSyncState updates the sync state of a file pushed or pulled
+// successfully; the CLI's counterpart of recordFileState
+func recordSyncState(cfg *config.Config, stateManager *sync.StateManager, action string, r sync.ExportResult) {
+	if r.App == nil {
+		return
+	}
+	localHash := r.File.LocalHash
+	dotfilesHash := r.File.DotfilesHash
+
+	// After sync, both hashes should be the same
+	if sync.RewritesOnSync(cfg, r.App.ID, r.File) {
+		// Both sides can differ after the sync, so hash what was written
+		localHash, _ = sync.ComputeFileHashNoCache(r.File.Path)
+		dotfilesHash, _ = sync.ComputeFileHashNoCache(sync.DotfilePath(cfg.DotfilesPath, r.App.ID, r.File))
+	} else if action == "push" || action == "push+commit" {
+		// After push, dotfiles now has the local content
+		dotfilesHash = localHash
+	} else {
+		// After pull, local now has the dotfiles content
+		localHash = dotfilesHash
+	}
+
+	if localHash != "" || dotfilesHash != "" {
+		stateManager.SetFileState(r.App.ID, r.File.RelPath, localHash, dotfilesHash)
+		if action == "push" || action == "push+commit" {
+			stateManager.RecordPush(r.App.ID, r.File.RelPath)
+		} else {
+			stateManager.RecordPull(r.App.ID, r.File.RelPath)
+		}
+	}
+}
+
+// openSyncResult shows the outcome of a push or pull file by file, with
+// actions to retry the failed files, commit, and copy the errors
+func (m *Model) openSyncResult(msg syncCompleteMsg) {
+	m.syncResults = msg.results
+	m.syncAction = msg.action
+	m.syncErr = msg.err
+	m.syncResultCursor = 0
+	m.syncResultDetail = false
+	if msg.err != nil || len(msg.results) > 0 {
+		m.screen = ScreenSyncResult
+	}
+}
+
+// permissionFailures counts the files a sync was denied access to, such as
+// configs under /etc
+func permissionFailures(results []sync.ExportResult) int {
+	n := 0
+	for _, r := range results {
+		if !r.Success && sync.ClassifyError(r.Error) == sync.FailurePermission {
+			n++
 		}
-	case "enter", " ":
-		if m.confirmAction == ActionPush {
-			// Push confirmation
-			switch ConfirmOption(m.confirmCursor) {
-			case ConfirmProceed:
-				m.syncing = true
-				m.syncAction = "push"
-				m.syncTotal = len(m.fileDiffs)
-				m.syncCurrent = 0
-				m.screen = ScreenSyncing
-				m.status = fmt.Sprintf("Pushing %d files...", len(m.fileDiffs))
-				return m, m.pushApps
-			case ConfirmBackup: // Used as Cancel for push (index 1)
-				m.screen = ScreenMain
-				m.status = "Push cancelled"
+	}
+	return n
+}
+
+// failedSyncResults returns the files the last sync could not write
+func (m *Model) failedSyncResults() []sync.ExportResult {
+	var failed []sync.ExportResult
+	for _, r := range m.syncResults {
+		if !r.Success {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+func (m *Model) handleSyncResultKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	failed := m.failedSyncResults()
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit) && m.syncResultDetail:
+		m.syncResultDetail = false
+		return m, nil
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.screen = ScreenMain
+		return m, nil
+	case key.Matches(msg, m.keys.Enter):
+		if len(failed) == 0 {
+			m.screen = ScreenMain
+		} else {
+			m.syncResultDetail = !m.syncResultDetail
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.Up):
+		if m.syncResultCursor > 0 {
+			m.syncResultCursor--
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.Down):
+		if m.syncResultCursor < len(failed)-1 {
+			m.syncResultCursor++
+		}
+		return m, nil
+	}
+
+	var current *sync.ExportResult
+	if m.syncResultCursor < len(failed) {
+		current = &failed[m.syncResultCursor]
+	}
+	switch msg.String() {
+	case "r":
+		if current == nil || m.syncing {
+			return m, nil
+		}
+		if m.blockedByReadOnly() {
+			return m, nil
+		}
+		m.syncing = true
+		m.status = fmt.Sprintf("Retrying %s...", current.File.RelPath)
+		return m, m.retryFile(m.syncAction, *current)
+	case "R":
+		return m.retryFailedSync(failed)
+	case "S":
+		if current == nil || m.syncing || m.blockedByReadOnly() {
+			return m, nil
+		}
+		return m.retryFileElevated(*current)
+	case "s":
+		if current != nil {
+			m.skipFailedFile(*current)
+		}
+	case "g":
+		return m.commitSyncResult()
+	case "y":
+		if len(failed) == 0 && m.syncErr == nil {
+			m.status = "Nothing failed"
+			return m, nil
+		}
+		termenv.Copy(syncErrorDetails(m.syncAction, m.syncErr, failed))
+		m.status = "Error details copied to the clipboard"
+	}
+	return m, nil
+}
+
+// retryFile syncs one failed file again in the direction of the last sync
+func (m *Model) retryFile(action string, r sync.ExportResult) tea.Cmd {
+	single := *r.App
+	single.Selected = true
+	file := r.File
+	file.Selected = true
+	single.Files = []models.File{file}
+
+	return func() tea.Msg {
+		result := sync.ExportResult{App: r.App, File: r.File, Error: fmt.Errorf("nothing was synced")}
+		if action == "pull" {
+			results, err := sync.NewImporter(m.config).WithStateManager(m.stateManager).ImportApp(&single)
+			if err != nil {
+				result.Error = err
+			} else if len(results) > 0 {
+				result.Success, result.Error = results[0].Success, results[0].Error
+				if results[0].Conflict {
+					result.Success, result.Error = false, fmt.Errorf("changed on both sides; resolve it with diff/merge")
+				}
 			}
 		} else {
-			// Pull confirmation (always backs up before pulling)
-			switch ConfirmOption(m.confirmCursor) {
-			case ConfirmProceed:
-				m.syncing = true
-				m.syncAction = "pull"
-				m.syncTotal = len(m.fileDiffs)
-				m.syncCurrent = 0
-				m.screen = ScreenSyncing
-				m.status = "Backing up and pulling..."
-				return m, m.pullApps
-			case ConfirmBackup: // Used as Cancel for pull (index 1)
-				m.screen = ScreenMain
-				m.status = "Pull cancelled"
+			results, err := sync.NewExporter(m.config).ExportApp(&single)
+			if err != nil {
+				result.Error = err
+			} else if len(results) > 0 {
+				result.Success, result.Error = results[0].Success, results[0].Error
 			}
 		}
-	case "esc", "q":
+		if result.Success {
+			result.Error = nil
+		}
+		return retryFileMsg{result: result}
+	}
+}
+
+// retryFileElevated copies a file the last sync was denied access to with
+// sudo, handing the terminal over for the password prompt
+func (m *Model) retryFileElevated(r sync.ExportResult) (tea.Model, tea.Cmd) {
+	if r.App == nil {
+		return m, nil
+	}
+	src, dst := r.File.Path, sync.DotfilePath(m.config.DotfilesPath, r.App.ID, r.File)
+	own := true // Copies pushed into the repo stay the user's
+	if m.syncAction == "pull" {
+		src, dst, own = dst, src, false
+	}
+	c, err := sync.ElevatedCopyCmd(src, dst, r.File.IsDir, own)
+	if err != nil {
+		m.status = fmt.Sprintf("Error: %v • s skips the file from now on", err)
+		return m, nil
+	}
+
+	m.syncing = true
+	m.status = fmt.Sprintf("Copying %s with sudo...", r.File.RelPath)
+	return m, tea.ExecProcess(c, func(err error) tea.Msg {
+		result := r
+		result.Success, result.Error = err == nil, nil
+		if err != nil {
+			result.Error = fmt.Errorf("sudo copy: %w", err)
+		}
+		return retryFileMsg{result: result}
+	})
+}
+
+// applyRetry replaces the result of a retried file with its new outcome
+func (m *Model) applyRetry(r sync.ExportResult) {
+	for i := range m.syncResults {
+		old := m.syncResults[i]
+		if old.App == nil || r.App == nil || old.App.ID != r.App.ID || old.File.Path != r.File.Path {
+			continue
+		}
+		m.syncResults[i] = r
+		break
+	}
+	if !r.Success {
+		m.status = fmt.Sprintf("Still failing: %s: %v", r.File.RelPath, r.Error)
+		return
+	}
+	m.recordFileState(m.syncAction, r)
+	if m.stateManager != nil {
+		_ = m.stateManager.Save()
+	}
+	m.status = fmt.Sprintf("✓ %s synced", r.File.RelPath)
+	m.clampSyncResultCursor()
+}
+
+// skipFailedFile excludes a failed file from syncing from now on, like x
+// in the file panel, and drops it from the results
+func (m *Model) skipFailedFile(r sync.ExportResult) {
+	if r.App == nil {
+		return
+	}
+	if m.config.SubtreeRules == nil {
+		m.config.SubtreeRules = make(map[string]subtree.Rules)
+	}
+	rules := m.config.SubtreeRules[r.App.ID]
+	if !rules.IsExcluded(r.File.RelPath) {
+		rules.ToggleExclude(r.File.RelPath)
+	}
+	m.config.SubtreeRules[r.App.ID] = rules
+	if err := m.config.Save(); err != nil {
+		m.status = fmt.Sprintf("Error saving config: %v", err)
+		return
+	}
+
+	// Mark it excluded in place; the next scan lists it the same way
+	for i := range r.App.Files {
+		if r.App.Files[i].Path == r.File.Path {
+			r.App.Files[i].Excluded = true
+			r.App.Files[i].Selected = false
+		}
+	}
+	m.syncResults = slices.DeleteFunc(m.syncResults, func(o sync.ExportResult) bool {
+		return o.App == r.App && o.File.Path == r.File.Path
+	})
+	m.updateFileList()
+	m.clampSyncResultCursor()
+	m.status = fmt.Sprintf("Skipping %s from now on • x in the file panel re-includes it", r.File.RelPath)
+}
+
+// clampSyncResultCursor keeps the cursor on a failed file
+func (m *Model) clampSyncResultCursor() {
+	failed := len(m.failedSyncResults())
+	if m.syncResultCursor >= failed {
+		m.syncResultCursor = max(failed-1, 0)
+	}
+	if failed == 0 {
+		m.syncResultDetail = false
+	}
+}
+
+// retryFailedSync selects only the files the last sync failed on and runs
+// it again; u restores the previous selection
+func (m *Model) retryFailedSync(failed []sync.ExportResult) (tea.Model, tea.Cmd) {
+	if len(failed) == 0 {
+		m.status = "No failed files to retry"
+		return m, nil
+	}
+	retry := make(map[string]map[string]bool)
+	for _, r := range failed {
+		if r.App == nil {
+			continue
+		}
+		if retry[r.App.ID] == nil {
+			retry[r.App.ID] = make(map[string]bool)
+		}
+		retry[r.App.ID][r.File.Path] = true
+	}
+
+	m.saveSelectionState()
+	for _, app := range m.apps {
+		files := retry[app.ID]
+		app.Selected = files != nil
+		for i := range app.Files {
+			app.Files[i].Selected = files[app.Files[i].Path]
+		}
+	}
+	m.appList.SetApps(m.apps)
+	m.updateFileList()
+	m.screen = ScreenMain
+	if m.syncAction == "pull" {
+		return m.handlePull()
+	}
+	return m.handlePush()
+}
+
+// commitSyncResult opens the git panel with the pushed files staged and a
+// commit message describing them
+func (m *Model) commitSyncResult() (tea.Model, tea.Cmd) {
+	if m.syncAction != "push" {
+		return m.handleGit()
+	}
+	if _, cmd := m.handleGit(); m.screen != ScreenGit {
+		return m, cmd
+	}
+	if err := m.gitPanel.AddAll(); err != nil {
+		m.status = fmt.Sprintf("Add failed: %v", err)
+		return m, nil
+	}
+	if !m.gitPanel.HasStagedChanges() {
+		m.status = "Nothing to commit"
+		return m, nil
+	}
+
+	var files []quicksync.FileInfo
+	for _, r := range m.syncResults {
+		if r.Success && r.App != nil {
+			files = append(files, quicksync.FileInfo{AppID: r.App.ID, RelPath: r.File.RelPath})
+		}
+	}
+	m.textArea.Reset()
+	m.textArea.Placeholder = i18n.T("commit.placeholder")
+	m.textArea.SetValue(quicksync.GenerateCommitMessage(files))
+	m.textArea.Focus()
+	m.screen = ScreenCommit
+	return m, textarea.Blink
+}
+
+// syncErrorDetails formats the errors of a sync for a bug report
+func syncErrorDetails(action string, err error, failed []sync.ExportResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "dotsync %s %s\n", action, version)
+	if err != nil {
+		fmt.Fprintf(&b, "error: %v\n", err)
+	}
+	for _, r := range failed {
+		appID := ""
+		if r.App != nil {
+			appID = r.App.ID
+		}
+		fmt.Fprintf(&b, "%s/%s (%s): %v\n", appID, r.File.RelPath, r.File.Path, r.Error)
+	}
+	return b.String()
+}
+
+func (m *Model) renderSyncResult() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("syncresult.title", m.syncAction)))
+	b.WriteString("\n\n")
+
+	if m.syncErr != nil {
+		b.WriteString(ui.ConflictStyle.Render(i18n.T("syncresult.error", m.syncErr)))
+		b.WriteString("\n\n")
+	}
+
+	failed := m.failedSyncResults()
+	done := len(m.syncResults) - len(failed)
+	summary := ui.SyncedStyle.Render(i18n.T("syncresult.done", done, len(m.syncResults)))
+	if len(failed) > 0 {
+		summary += "  " + ui.ConflictStyle.Render(i18n.T("syncresult.failed", len(failed)))
+	}
+	b.WriteString(summary)
+	b.WriteString("\n")
+	if m.syncErr == nil && m.status != "" {
+		style := ui.MutedStyle
+		if strings.HasPrefix(m.status, "Error") {
+			style = ui.ConflictStyle
+		}
+		b.WriteString(style.Render(m.status))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.syncResultDetail && m.syncResultCursor < len(failed) {
+		b.WriteString(m.renderSyncFailure(failed[m.syncResultCursor]))
+		b.WriteString("\n")
+		b.WriteString(ui.MutedStyle.Render(i18n.T("syncresult.detail_help", m.syncResultCursor+1, len(failed))))
+		b.WriteString("\n")
+		return ui.AppStyle.Render(b.String())
+	}
+
+	// Failed files, the one under the cursor with its full error
+	visible := max(m.height-14, 3)
+	start := max(0, m.syncResultCursor-visible+1)
+	for i := start; i < len(failed) && i < start+visible; i++ {
+		r := failed[i]
+		appID := ""
+		if r.App != nil {
+			appID = r.App.ID
+		}
+		line := fmt.Sprintf("%s/%s", appID, r.File.RelPath)
+		if i == m.syncResultCursor {
+			b.WriteString(ui.CursorStyle.Render("  ▸ ") + ui.ConflictStyle.Render(line))
+			b.WriteString("\n")
+			b.WriteString("      " + ui.MutedStyle.Render(fmt.Sprintf("%v", r.Error)))
+			if sync.ClassifyError(r.Error) == sync.FailurePermission {
+				b.WriteString("\n      " + ui.ModifiedStyle.Render(i18n.T("syncresult.sudo_hint")))
+			}
+		} else {
+			b.WriteString("    " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	help := "syncresult.help"
+	if len(failed) > 0 || m.syncErr != nil {
+		help = "syncresult.help_failed"
+	}
+	b.WriteString(ui.MutedStyle.Render(i18n.T(help)))
+	b.WriteString("\n")
+	return ui.AppStyle.Render(b.String())
+}
+
+// renderSyncFailure shows where a failed file lives on both sides, why it
+// failed and how that is usually fixed
+func (m *Model) renderSyncFailure(r sync.ExportResult) string {
+	var b strings.Builder
+	label := lipgloss.NewStyle().Bold(true).Width(10)
+	row := func(name, value string) {
+		b.WriteString("  " + label.Render(name) + value + "\n")
+	}
+	if r.App != nil {
+		row(i18n.T("syncresult.app"), fmt.Sprintf("%s (%s)", r.App.Name, r.App.ID))
+		row(i18n.T("syncresult.dotfiles"), sync.DotfilePath(m.config.DotfilesPath, r.App.ID, r.File))
+	}
+	row(i18n.T("syncresult.local"), r.File.Path)
+	b.WriteString("\n")
+
+	kind := sync.ClassifyError(r.Error)
+	b.WriteString("  " + ui.ConflictStyle.Render(fmt.Sprintf("%v", r.Error)) + "\n")
+	if kind != sync.FailureOther {
+		b.WriteString("  " + ui.ModifiedStyle.Render(i18n.T("syncresult.kind."+string(kind))) + "\n")
+	}
+	if kind == sync.FailurePermission {
+		b.WriteString("  " + ui.ModifiedStyle.Render(i18n.T("syncresult.sudo_hint")) + "\n")
+	}
+	return b.String()
+}
+
+// auditActions are the action filters cycled in the audit log viewer
+var auditActions = []string{"", audit.ActionPush, audit.ActionPull, audit.ActionBackup,
+	audit.ActionMerge, audit.ActionResolve, audit.ActionRestore, audit.ActionDelete}
+
+// handleAuditLog opens the audit log viewer
+func (m *Model) handleAuditLog() (tea.Model, tea.Cmd) {
+	m.screen = ScreenAudit
+	m.auditCursor = 0
+	m.loadAudit()
+	return m, nil
+}
+
+// loadAudit reads the log with the current filter, newest first
+func (m *Model) loadAudit() {
+	m.auditEntries = nil
+	if m.auditLog == nil {
+		return
+	}
+	filter := m.auditFilter
+	filter.Limit = 1000
+	entries, err := m.auditLog.Read(filter)
+	if err != nil {
+		m.status = fmt.Sprintf("Error reading audit log: %v", err)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		m.auditEntries = append(m.auditEntries, entries[i])
+	}
+	if m.auditCursor >= len(m.auditEntries) {
+		m.auditCursor = max(0, len(m.auditEntries)-1)
+	}
+}
+
+func (m *Model) handleAuditKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit, m.keys.AuditLog):
 		m.screen = ScreenMain
-		m.status = "Cancelled"
-	case "1":
-		m.confirmCursor = 0
-	case "2":
-		if maxOptions >= 1 {
-			m.confirmCursor = 1
+		m.status = ""
+		return m, nil
+	case key.Matches(msg, m.keys.Up):
+		if m.auditCursor > 0 {
+			m.auditCursor--
 		}
-	case "3":
-		if maxOptions >= 2 {
-			m.confirmCursor = 2
+	case key.Matches(msg, m.keys.Down):
+		if m.auditCursor < len(m.auditEntries)-1 {
+			m.auditCursor++
+		}
+	case key.Matches(msg, m.keys.Home):
+		m.auditCursor = 0
+	case key.Matches(msg, m.keys.End):
+		m.auditCursor = max(0, len(m.auditEntries)-1)
+	case msg.String() == "f":
+		// Cycle the action filter
+		next := 0
+		for i, action := range auditActions {
+			if action == m.auditFilter.Action {
+				next = (i + 1) % len(auditActions)
+			}
 		}
+		m.auditFilter.Action = auditActions[next]
+		m.auditCursor = 0
+		m.loadAudit()
+	case msg.String() == "x":
+		m.auditFilter.Failed = !m.auditFilter.Failed
+		m.auditCursor = 0
+		m.loadAudit()
+	case msg.String() == "l":
+		m.screen = ScreenLogs
+		m.loadLogs()
+	case key.Matches(msg, m.keys.Refresh):
+		m.loadAudit()
+	}
+	return m, nil
+}
+
+func (m *Model) renderAudit() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("audit.title")))
+	b.WriteString("\n")
+
+	action := m.auditFilter.Action
+	if action == "" {
+		action = i18n.T("audit.all")
+	}
+	filter := i18n.T("audit.action", action)
+	if m.auditFilter.Failed {
+		filter += i18n.T("audit.failed_only")
+	}
+	b.WriteString(ui.MutedStyle.Render(filter))
+	b.WriteString("\n\n")
+
+	if len(m.auditEntries) == 0 {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("audit.none")))
+		b.WriteString("\n\n")
+		b.WriteString(ui.MutedStyle.Render(i18n.T("audit.help.empty")))
+		return ui.AppStyle.Render(b.String())
+	}
+
+	// Keep the cursor visible
+	visible := m.height - 12
+	if visible < 5 {
+		visible = 5
+	}
+	start := 0
+	if m.auditCursor >= visible {
+		start = m.auditCursor - visible + 1
+	}
+	end := start + visible
+	if end > len(m.auditEntries) {
+		end = len(m.auditEntries)
 	}
-	return m, nil
-}
 
-func (m *Model) handleSetupKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch m.setupStep {
-	case SetupWelcome:
-		switch msg.String() {
-		case "enter", " ":
-			m.setupStep = SetupPath
-			m.textInput.SetValue(m.config.DotfilesPath)
-			m.textInput.Focus()
-			return m, textinput.Blink
-		case "q", "ctrl+c":
-			return m, tea.Quit
+	for i := start; i < end; i++ {
+		e := m.auditEntries[i]
+		style := ui.SyncedStyle
+		switch e.Result {
+		case audit.ResultFailed:
+			style = ui.ConflictStyle
+		case audit.ResultConflict, audit.ResultSkipped:
+			style = ui.ModifiedStyle
 		}
 
-	case SetupPath:
-		switch msg.String() {
-		case "enter":
-			path := m.textInput.Value()
-			if path == "" {
-				path = m.config.DotfilesPath
-			}
-			if strings.HasPrefix(path, "~/") {
-				homeDir, _ := os.UserHomeDir()
-				path = filepath.Join(homeDir, path[2:])
-			}
-			m.config.DotfilesPath = path
-			m.setupStep = SetupConfirm
-			m.textInput.Blur()
-		case "esc":
-			m.setupStep = SetupWelcome
-			m.textInput.Blur()
-		case "1", "2", "3":
-			paths := config.SuggestedPaths()
-			idx := int(msg.String()[0] - '1')
-			if idx < len(paths) {
-				m.textInput.SetValue(paths[idx])
-			}
-		default:
-			var cmd tea.Cmd
-			m.textInput, cmd = m.textInput.Update(msg)
-			return m, cmd
+		target := e.AppID
+		if e.File != "" {
+			target += "/" + e.File
 		}
+		note := e.Detail
+		if e.Error != "" {
+			note = e.Error
+		}
+		line := fmt.Sprintf("%s  %-8s %s %-32s %s",
+			e.Time.Local().Format("01-02 15:04"), e.Action, style.Render(fmt.Sprintf("%-8s", e.Result)),
+			target, ui.MutedStyle.Render(note))
 
-	case SetupConfirm:
-		switch msg.String() {
-		case "enter", "y":
-			m.config.FirstRun = false
-			return m, m.saveConfig
-		case "n", "esc":
-			m.setupStep = SetupPath
-			m.textInput.Focus()
-			return m, textinput.Blink
-		case "q", "ctrl+c":
-			return m, tea.Quit
+		if i == m.auditCursor {
+			b.WriteString(ui.CursorStyle.Render("  ▸ "))
+		} else {
+			b.WriteString("    ")
 		}
+		b.WriteString(line)
+		b.WriteString("\n")
 	}
 
-	return m, nil
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("audit.help", m.auditCursor+1, len(m.auditEntries))))
+
+	return ui.AppStyle.Render(b.String())
 }
 
-func (m *Model) togglePanel() {
-	if m.focusedPanel == PanelApps {
-		m.focusedPanel = PanelFiles
-		m.appList.Focused = false
-		m.fileList.Focused = true
-	} else {
-		m.focusedPanel = PanelApps
-		m.appList.Focused = true
-		m.fileList.Focused = false
+// logTailLines is how many lines of the log file the log view reads
+const logTailLines = 500
+
+// loadLogs reads the end of the log file and scrolls to its last line
+func (m *Model) loadLogs() {
+	lines, err := logging.Tail(logging.Path(), logTailLines)
+	if err != nil {
+		m.status = i18n.T("logs.error", err)
 	}
+	m.logLines = lines
+	m.logOffset = max(0, len(lines)-m.logsVisible())
 }
 
-func (m *Model) updateFileList() {
-	if app := m.appList.Current(); app != nil {
-		m.fileList.SetFiles(app.Files, app.Name)
-	} else {
-		m.fileList.Clear()
+// logsVisible is how many log lines fit on the screen
+func (m *Model) logsVisible() int {
+	return max(5, m.height-10)
+}
+
+func (m *Model) handleLogsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := max(0, len(m.logLines)-m.logsVisible())
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		m.screen = ScreenMain
+		m.status = ""
+	case key.Matches(msg, m.keys.Escape), msg.String() == "l":
+		m.screen = ScreenAudit
+	case key.Matches(msg, m.keys.Up):
+		m.logOffset = max(0, m.logOffset-1)
+	case key.Matches(msg, m.keys.Down):
+		m.logOffset = min(last, m.logOffset+1)
+	case key.Matches(msg, m.keys.Home):
+		m.logOffset = 0
+	case key.Matches(msg, m.keys.End):
+		m.logOffset = last
+	case key.Matches(msg, m.keys.Refresh):
+		m.loadLogs()
 	}
+	return m, nil
 }
 
-func (m *Model) updatePanelSizes() {
-	panelWidth := (m.width - 4) / 2
-	panelHeight := m.height - 8
+func (m *Model) renderLogs() string {
+	var b strings.Builder
 
-	m.appList.Width = panelWidth
-	m.appList.Height = panelHeight
-	m.fileList.Width = panelWidth
-	m.fileList.Height = panelHeight
-}
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("logs.title")))
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(logging.Path()))
+	b.WriteString("\n\n")
 
-func (m *Model) View() string {
-	switch m.screen {
-	case ScreenSetup:
-		return m.renderSetup()
-	case ScreenConfirm:
-		return m.renderConfirm()
-	case ScreenDiff:
-		return m.renderDiff()
-	case ScreenMerge:
-		return m.renderMerge()
-	case ScreenGit:
-		return m.renderGit()
-	case ScreenCommit:
-		return m.renderCommitDialog()
-	case ScreenPreview:
-		return m.renderPreview()
-	case ScreenSettings:
-		return m.renderSettings()
-	case ScreenAddCustom:
-		return m.renderAddCustom()
-	default:
-		return m.renderMain()
+	if len(m.logLines) == 0 {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("logs.none")))
+		b.WriteString("\n\n")
+		b.WriteString(ui.MutedStyle.Render(i18n.T("logs.help.empty")))
+		return ui.AppStyle.Render(b.String())
+	}
+
+	end := min(len(m.logLines), m.logOffset+m.logsVisible())
+	width := max(20, m.width-6)
+	for _, line := range m.logLines[m.logOffset:end] {
+		style := lipgloss.NewStyle()
+		switch {
+		case strings.Contains(line, " level=ERROR "):
+			style = ui.ConflictStyle
+		case strings.Contains(line, " level=WARN "):
+			style = ui.ModifiedStyle
+		case strings.Contains(line, " level=DEBUG "):
+			style = ui.MutedStyle
+		}
+		b.WriteString("  ")
+		b.WriteString(style.MaxWidth(width).Render(line))
+		b.WriteString("\n")
 	}
-}
 
-func (m *Model) renderSetup() string {
-	width := 60
-	style := lipgloss.NewStyle().
-		Width(width).
-		Padding(1, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(ui.Primary)
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("logs.help", end, len(m.logLines))))
 
-	var content string
+	return ui.AppStyle.Render(b.String())
+}
 
-	switch m.setupStep {
-	case SetupWelcome:
-		content = m.renderSetupWelcome()
-	case SetupPath:
-		content = m.renderSetupPath()
-	case SetupConfirm:
-		content = m.renderSetupConfirm()
+// loadInterrupted picks up a push or pull whose process died holding the
+// sync lock, with the crash report it left if any
+func (m *Model) loadInterrupted() {
+	holder, ok := lock.Abandoned(lock.Path(config.ConfigDir()))
+	if !ok || !resumable(holder) {
+		return
+	}
+	m.interrupted = &holder
+	m.openDashboard = false
+	if report := crash.Latest(logging.Dir()); report != "" {
+		if info, err := os.Stat(report); err == nil && info.ModTime().After(holder.Started) {
+			m.crashReport = report
+		}
 	}
+}
 
-	box := style.Render(content)
+// resumable reports whether a lock was taken by a push or pull of apps
+func resumable(holder lock.Holder) bool {
+	return len(holder.Apps) > 0 && (holder.Command == "push" || holder.Command == "pull")
+}
 
-	return lipgloss.Place(
-		m.width, m.height,
-		lipgloss.Center, lipgloss.Center,
-		box,
-	)
+func (m *Model) handleResumeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	holder := m.interrupted
+	switch msg.String() {
+	case "enter", "y":
+		m.interrupted = nil
+		m.screen = ScreenMain
+		// Select what the interrupted sync had selected; the usual
+		// confirmation follows
+		selected := 0
+		for _, app := range m.apps {
+			app.Selected = slices.Contains(holder.Apps, app.ID)
+			if app.Selected {
+				selected++
+			}
+		}
+		m.appList.SetApps(m.apps)
+		m.updateFileList()
+		if selected == 0 {
+			m.status = i18n.T("resume.no_apps")
+			return m, nil
+		}
+		slog.Info("resuming interrupted sync", "action", holder.Command, "apps", holder.Apps)
+		if holder.Command == "pull" {
+			return m.handlePull()
+		}
+		return m.handlePush()
+	case "n", "esc", "q":
+		m.interrupted = nil
+		m.screen = ScreenMain
+		if err := lock.ClearAbandoned(lock.Path(config.ConfigDir())); err != nil {
+			m.status = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		m.status = i18n.T("resume.dismissed")
+	}
+	return m, nil
 }
 
-func (m *Model) renderSetupWelcome() string {
+func (m *Model) renderResume() string {
+	holder := m.interrupted
 	var b strings.Builder
 
-	title := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(ui.Primary).
-		Render("🔄 Welcome to Dotsync!")
-
-	b.WriteString(title)
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("resume.title")))
 	b.WriteString("\n\n")
-	b.WriteString("Dotsync helps you sync your dotfiles between machines.\n\n")
-	b.WriteString("Features:\n")
-	b.WriteString("  • Auto-detect installed apps and their configs\n")
-	b.WriteString("  • Selective sync - choose which files to sync\n")
-	b.WriteString("  • Support for 960+ apps out of the box\n")
-	b.WriteString("  • Built-in git operations and branch switching\n")
-	b.WriteString("  • Discovers unknown apps in ~/.config\n")
+	b.WriteString(i18n.T("resume.body", holder.Command, holder.Started.Local().Format("2006-01-02 15:04"), holder.PID))
 	b.WriteString("\n\n")
-	b.WriteString(ui.HelpBarStyle.Render("Press ENTER to continue • q to quit"))
+	for _, id := range holder.Apps {
+		b.WriteString("  • " + id + "\n")
+	}
+	if m.crashReport != "" {
+		b.WriteString("\n")
+		b.WriteString(ui.MutedStyle.Render(i18n.T("resume.report", m.crashReport)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("resume.help", holder.Command)))
 
-	return b.String()
+	return ui.AppStyle.Render(b.String())
 }
 
-func (m *Model) renderSetupPath() string {
-	var b strings.Builder
+// appInfoCommits is how many commits the app details list
+const appInfoCommits = 8
 
-	title := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(ui.Primary).
-		Render("📁 Choose Dotfiles Location")
+// handleAppInfo opens the details of the app under the cursor
+func (m *Model) handleAppInfo() (tea.Model, tea.Cmd) {
+	app := m.appList.Current()
+	if app == nil {
+		m.status = i18n.T("appinfo.no_app")
+		return m, nil
+	}
+	m.infoApp = app
+	m.infoCommits = nil
+	if repo := git.NewRepo(m.config.DotfilesPath); repo.IsRepo() {
+		m.infoCommits, _ = repo.PathLog(m.appRepoPaths(app), appInfoCommits)
+	}
+	m.screen = ScreenAppInfo
+	return m, nil
+}
 
-	b.WriteString(title)
+// appRepoPaths matches the repo paths (relative, slash-separated) holding
+// app's files: its directory in the default layout, and the mapped path of
+// each scanned file in any layout
+func (m *Model) appRepoPaths(app *models.App) func(path string) bool {
+	var paths []string
+	for _, f := range app.Files {
+		rel, err := filepath.Rel(m.config.DotfilesPath, sync.DotfilePath(m.config.DotfilesPath, app.ID, f))
+		if err == nil {
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+	}
+	if layout.IsDefault(layout.For(m.config.DotfilesPath)) {
+		paths = append(paths, app.ID)
+	}
+	return func(path string) bool {
+		for _, p := range paths {
+			if path == p || strings.HasPrefix(path, p+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (m *Model) handleAppInfoKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Escape, m.keys.Quit, m.keys.AppInfo) {
+		m.screen = ScreenMain
+		m.infoApp = nil
+	}
+	return m, nil
+}
+
+func (m *Model) renderAppInfo() string {
+	var b strings.Builder
+	app := m.infoApp
+
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("appinfo.title", app.Icon, app.Name)))
 	b.WriteString("\n\n")
-	b.WriteString("Where do you want to store your dotfiles?\n\n")
 
-	paths := config.SuggestedPaths()
-	for i, path := range paths {
-		prefix := fmt.Sprintf("[%d] ", i+1)
-		exists := ""
-		if _, err := os.Stat(path); err == nil {
-			exists = " (exists)"
+	label := lipgloss.NewStyle().Bold(true).Width(14)
+	row := func(name, value string) {
+		b.WriteString("  " + label.Render(name) + value + "\n")
+	}
+	category := scanner.CategoryNames()[app.Category]
+	if category == "" {
+		category = app.Category
+	}
+	row(i18n.T("appinfo.id"), app.ID)
+	row(i18n.T("appinfo.category"), category)
+	for i, path := range app.ConfigPaths {
+		name := ""
+		if i == 0 {
+			name = i18n.T("appinfo.paths")
 		}
-		b.WriteString(ui.MutedStyle.Render(prefix))
-		b.WriteString(path)
-		b.WriteString(ui.MutedStyle.Render(exists))
-		b.WriteString("\n")
+		row(name, path)
 	}
 
-	b.WriteString("\nOr enter custom path:\n")
-	b.WriteString(m.textInput.View())
-	b.WriteString("\n\n")
-	b.WriteString(ui.HelpBarStyle.Render("1-3 quick select • ENTER confirm • ESC back"))
+	count, size := components.FileTotals(app.Files)
+	files := i18n.T("appinfo.files_value", count, components.FormatBytes(size))
+	if app.Truncated {
+		files += ui.MutedStyle.Render("  (" + i18n.T("files.truncated") + ")")
+	}
+	row(i18n.T("appinfo.files"), files)
+
+	if m.modesConfig != nil {
+		mode := i18n.T("appinfo.mode.backup")
+		if m.modesConfig.IsAppSynced(app.ID) {
+			mode = i18n.T("appinfo.mode.sync")
+		}
+		row(i18n.T("appinfo.mode"), fmt.Sprintf("[%s] %s", m.modesConfig.AppSyncLabel(app.ID), mode))
+	}
+
+	if m.stateManager != nil {
+		pushed, pulled := m.stateManager.AppSyncTimes(app.ID)
+		syncTime := func(t time.Time) string {
+			if t.IsZero() {
+				return i18n.T("time.never")
+			}
+			return components.FormatTimeAgo(t) + "  " + ui.MutedStyle.Render(t.Local().Format("2006-01-02 15:04"))
+		}
+		row(i18n.T("appinfo.last_push"), syncTime(pushed))
+		row(i18n.T("appinfo.last_pull"), syncTime(pulled))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("appinfo.history")))
+	b.WriteString("\n")
+	if len(m.infoCommits) == 0 {
+		b.WriteString("  " + ui.MutedStyle.Render(i18n.T("appinfo.no_history")) + "\n")
+	}
+	for _, c := range m.infoCommits {
+		b.WriteString(fmt.Sprintf("  %s  %s  %s\n", ui.CursorStyle.Render(c.Hash), ui.MutedStyle.Render(c.Date), c.Message))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("appinfo.help")))
+
+	return ui.AppStyle.Render(b.String())
+}
+
+// handleDiffStat counts the lines changed locally in the selected apps,
+// or the app under the cursor when none is selected
+func (m *Model) handleDiffStat() (tea.Model, tea.Cmd) {
+	apps := m.appList.SelectedApps()
+	if len(apps) == 0 {
+		app := m.appList.Current()
+		if app == nil {
+			m.status = i18n.T("appinfo.no_app")
+			return m, nil
+		}
+		// Stats cover selected files only, so use a selected copy
+		whole := *app
+		whole.Selected = true
+		whole.Files = slices.Clone(app.Files)
+		for i := range whole.Files {
+			whole.Files[i].Selected = true
+		}
+		apps = []*models.App{&whole}
+	}
+	m.status = i18n.T("diffstat.computing")
+	dotfilesPath := m.config.DotfilesPath
+	return m, func() tea.Msg {
+		return diffStatMsg{stats: sync.DiffStats(dotfilesPath, apps)}
+	}
+}
+
+func (m *Model) handleDiffStatKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit, m.keys.DiffStat):
+		m.screen = ScreenMain
+		m.diffFromStat = false
+		m.status = ""
+	case key.Matches(msg, m.keys.Up):
+		if m.diffStatCursor > 0 {
+			m.diffStatCursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.diffStatCursor < len(m.diffStats)-1 {
+			m.diffStatCursor++
+		}
+	case key.Matches(msg, m.keys.Home):
+		m.diffStatCursor = 0
+	case key.Matches(msg, m.keys.End):
+		m.diffStatCursor = max(0, len(m.diffStats)-1)
+	case key.Matches(msg, m.keys.Enter, m.keys.Diff):
+		if m.diffStatCursor < len(m.diffStats) {
+			return m.openStatDiff(m.diffStats[m.diffStatCursor])
+		}
+	}
+	return m, nil
+}
 
-	return b.String()
+// openStatDiff shows the diff of one file of the diffstat; Esc comes back
+func (m *Model) openStatDiff(s sync.DiffStat) (tea.Model, tea.Cmd) {
+	localPath := s.File.Path
+	dotfilePath := sync.DotfilePath(m.config.DotfilesPath, s.App.ID, *s.File)
+	diffResult, err := sync.ComputeDiff(localPath, dotfilePath)
+	if err != nil {
+		m.status = fmt.Sprintf("Diff error: %v", err)
+		return m, nil
+	}
+	m.currentDiffApp = s.App
+	m.currentDiffFile = s.File
+	m.resolvingConflict = false
+	m.diffFromQuickSync = false
+	m.diffFromStat = true
+	m.diffView.SetDiff(diffResult, localPath, dotfilePath)
+	m.diffView.Width = m.width - 4
+	m.diffView.Height = m.height - 6
+	m.screen = ScreenDiff
+	m.status = i18n.T("diffstat.back")
+	return m, nil
 }
 
-func (m *Model) renderSetupConfirm() string {
+// diffStatBarWidth is the widest +/- bar of the diffstat
+const diffStatBarWidth = 24
+
+func (m *Model) renderDiffStat() string {
 	var b strings.Builder
 
-	title := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(ui.Primary).
-		Render("✓ Confirm Setup")
+	b.WriteString(m.renderHeader())
+	b.WriteString("\n")
+	b.WriteString(ui.PanelTitleStyle.Render(i18n.T("diffstat.title")))
+	b.WriteString("\n")
 
-	b.WriteString(title)
-	b.WriteString("\n\n")
-	b.WriteString("Dotfiles will be stored at:\n")
-	b.WriteString(ui.SelectedItemStyle.Render("  " + m.config.DotfilesPath))
+	if len(m.diffStats) == 0 {
+		b.WriteString("\n")
+		b.WriteString(ui.SyncedStyle.Render(i18n.T("diffstat.none")))
+		b.WriteString("\n\n")
+		b.WriteString(ui.MutedStyle.Render(i18n.T("diffstat.help.empty")))
+		return ui.AppStyle.Render(b.String())
+	}
+
+	added, removed, most := 0, 0, 0
+	apps := make(map[string]bool)
+	for _, s := range m.diffStats {
+		added += s.Added
+		removed += s.Removed
+		most = max(most, s.Added+s.Removed)
+		apps[s.App.ID] = true
+	}
+	b.WriteString(ui.MutedStyle.Render(i18n.T("diffstat.summary", len(m.diffStats), len(apps))))
+	b.WriteString(" " + ui.SyncedStyle.Render(fmt.Sprintf("+%d", added)))
+	b.WriteString(" " + ui.ConflictStyle.Render(fmt.Sprintf("-%d", removed)))
 	b.WriteString("\n\n")
 
-	if _, err := os.Stat(m.config.DotfilesPath); err == nil {
-		b.WriteString(ui.SyncedStyle.Render("✓ Directory exists\n"))
-	} else {
-		b.WriteString(ui.MutedStyle.Render("  Directory will be created\n"))
+	// Keep the cursor visible; app headers take a line each
+	visible := max(m.height-12, 5)
+	start := 0
+	if m.diffStatCursor >= visible {
+		start = m.diffStatCursor - visible + 1
 	}
+	end := min(start+visible, len(m.diffStats))
 
-	b.WriteString("\n")
-	b.WriteString(ui.HelpBarStyle.Render("y/ENTER confirm • n/ESC go back • q quit"))
-
-	return b.String()
-}
-
-func (m *Model) renderConfirm() string {
-	width := 70
+	pathWidth := max(m.width-diffStatBarWidth-30, 20)
+	for i := start; i < end; i++ {
+		s := m.diffStats[i]
+		if i == start || m.diffStats[i-1].App != s.App {
+			b.WriteString(ui.CategoryStyle.Render(fmt.Sprintf("%s %s", s.App.Icon, s.App.Name)))
+			b.WriteString("\n")
+		}
 
-	// Different styling for push vs pull
-	borderColor := ui.Warning
-	var titleText string
-	var descText string
-	var filesLabel string
+		path := s.File.RelPath
+		if len(path) > pathWidth {
+			path = "..." + path[len(path)-pathWidth+3:]
+		}
+		var change string
+		switch {
+		case s.Err != nil:
+			change = ui.ConflictStyle.Render(s.Err.Error())
+		default:
+			// Scale the bar like git's diffstat when the largest change
+			// doesn't fit
+			plus, minus := s.Added, s.Removed
+			if most > diffStatBarWidth {
+				plus = (s.Added*diffStatBarWidth + most - 1) / most
+				minus = (s.Removed*diffStatBarWidth + most - 1) / most
+			}
+			change = fmt.Sprintf("%5d %s%s", s.Added+s.Removed,
+				ui.SyncedStyle.Render(strings.Repeat("+", plus)), ui.ConflictStyle.Render(strings.Repeat("-", minus)))
+			if s.LocalOnly {
+				change += " " + ui.MutedStyle.Render(i18n.T("diffstat.local_only"))
+			} else if s.DotfilesOnly {
+				change += " " + ui.MutedStyle.Render(i18n.T("diffstat.dotfiles_only"))
+			}
+		}
+		line := fmt.Sprintf("%-*s | %s", pathWidth, path, change)
 
-	if m.confirmAction == ActionPush {
-		borderColor = ui.Primary
-		titleText = "📤 Push to Dotfiles"
-		descText = "This will copy your local configs to your dotfiles repository."
-		filesLabel = "Files to push:"
-	} else {
-		titleText = "⚠️  Pull from Dotfiles"
-		descText = "This will replace your local configs with versions from dotfiles."
-		filesLabel = "Files to pull:"
+		if i == m.diffStatCursor {
+			b.WriteString(ui.CursorStyle.Render("  ▸ "))
+		} else {
+			b.WriteString("    ")
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
 	}
 
-	style := lipgloss.NewStyle().
-		Width(width).
-		Padding(1, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(borderColor)
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(i18n.T("diffstat.help", m.diffStatCursor+1, len(m.diffStats))))
 
-	var b strings.Builder
+	return ui.AppStyle.Render(b.String())
+}
 
-	title := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(borderColor).
-		Render(titleText)
+// quickSyncCompleteMsg is sent when quick sync completes
+type quickSyncCompleteMsg struct {
+	result *quicksync.Result
+}
 
-	b.WriteString(title)
-	b.WriteString("\n\n")
+// handleToggleMode toggles the mode for the selected app/file
+func (m *Model) handleToggleMode() (tea.Model, tea.Cmd) {
+	if m.modesConfig == nil {
+		m.status = "Modes not initialized"
+		return m, nil
+	}
 
-	b.WriteString(descText)
-	b.WriteString("\n\n")
+	if m.focusedPanel == PanelApps {
+		// Toggle app sync
+		currentApp := m.appList.Current()
+		if currentApp == nil {
+			m.status = "No app selected"
+			return m, nil
+		}
 
-	// Show files that will be affected
-	b.WriteString(ui.PanelTitleStyle.Render(filesLabel))
-	b.WriteString("\n")
+		synced := m.modesConfig.ToggleAppSync(currentApp.ID)
+		if err := m.modesConfig.Save(); err != nil {
+			m.status = fmt.Sprintf("Failed to save mode: %v", err)
+			return m, nil
+		}
 
-	maxShow := 8
-	for i, diff := range m.fileDiffs {
-		if i >= maxShow {
-			remaining := len(m.fileDiffs) - maxShow
-			b.WriteString(ui.MutedStyle.Render(fmt.Sprintf("  ... and %d more files\n", remaining)))
-			break
+		if synced {
+			m.status = fmt.Sprintf("%s: sync enabled", currentApp.Name)
+		} else {
+			m.status = fmt.Sprintf("%s: sync disabled", currentApp.Name)
+		}
+		m.appList.SetModesConfig(m.modesConfig)
+		m.updateFileList()
+	} else {
+		// Toggle file sync
+		currentApp := m.appList.Current()
+		currentFile := m.fileList.Current()
+		if currentApp == nil || currentFile == nil {
+			m.status = "No file selected"
+			return m, nil
 		}
 
-		icon := "📄"
-		if diff.File.IsDir {
-			icon = "📁"
+		synced := m.modesConfig.ToggleFileSync(currentApp.ID, currentFile.Path)
+		if err := m.modesConfig.Save(); err != nil {
+			m.status = fmt.Sprintf("Failed to save mode: %v", err)
+			return m, nil
 		}
 
-		statusStyle := ui.MutedStyle
-		switch diff.Status {
-		case "new (will create)":
-			statusStyle = ui.NewStyle
-		case "different", "will overwrite":
-			statusStyle = ui.ModifiedStyle
-		case "not in dotfiles", "missing locally":
-			statusStyle = ui.MissingStyle
-		case "same":
-			statusStyle = ui.SyncedStyle
+		if synced {
+			m.status = fmt.Sprintf("%s: sync enabled", currentFile.Name)
+		} else {
+			m.status = fmt.Sprintf("%s: sync disabled", currentFile.Name)
 		}
+		m.fileList.SetModesConfig(m.modesConfig)
+	}
 
-		b.WriteString(fmt.Sprintf("  %s %s %s\n",
-			icon,
-			diff.File.Name,
-			statusStyle.Render("("+diff.Status+")"),
-		))
+	return m, nil
+}
+
+// handleRestore opens the restore from machine dialog
+func (m *Model) handleRestore() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
+	if m.backupManager == nil {
+		m.status = "Backup manager not initialized"
+		return m, nil
 	}
 
-	b.WriteString("\n")
-	b.WriteString(ui.PanelTitleStyle.Render("Choose action:"))
-	b.WriteString("\n")
+	// Load available machines
+	machines, err := m.backupManager.ListMachines()
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to list machines: %v", err)
+		return m, nil
+	}
 
-	// Different options for push vs pull
-	var options []struct {
-		key   string
-		label string
-		desc  string
+	if len(machines) == 0 {
+		m.status = "No backup machines found"
+		return m, nil
 	}
 
-	if m.confirmAction == ActionPush {
-		options = []struct {
-			key   string
-			label string
-			desc  string
-		}{
-			{"1", "Push", "Copy local configs to dotfiles repository"},
-			{"2", "Cancel", "Go back without changes"},
+	m.restoreMachines = machines
+	m.restoreCursor = 0
+	m.status = "Select machine to restore from"
+	// TODO: Switch to restore screen when implemented
+	m.status = fmt.Sprintf("Found %d machines with backups. Restore screen coming soon.", len(machines))
+	return m, nil
+}
+
+// handleToggleSubtree toggles an exclude (or include-only) rule for the
+// current file panel entry and rescans so the tree reflects the new rules
+func (m *Model) handleToggleSubtree(include bool) (tea.Model, tea.Cmd) {
+	if m.focusedPanel != PanelFiles {
+		m.status = "Select a file first (Tab to switch panel)"
+		return m, nil
+	}
+
+	app := m.appList.Current()
+	node := m.fileList.CurrentNode()
+	if app == nil || node == nil || node.File == nil {
+		m.status = "No file selected"
+		return m, nil
+	}
+	relPath := node.File.RelPath
+
+	if m.config.SubtreeRules == nil {
+		m.config.SubtreeRules = make(map[string]subtree.Rules)
+	}
+	rules := m.config.SubtreeRules[app.ID]
+
+	var action string
+	if include {
+		rules.ToggleInclude(relPath)
+		action = "Only syncing"
+		if !rules.IsIncluded(relPath) {
+			action = "No longer include-only:"
 		}
 	} else {
-		options = []struct {
-			key   string
-			label string
-			desc  string
-		}{
-			{"1", "Pull", "Backup current configs and pull from dotfiles"},
-			{"2", "Cancel", "Go back without changes"},
+		rules.ToggleExclude(relPath)
+		action = "Excluded"
+		if !rules.IsExcluded(relPath) {
+			action = "Re-included"
 		}
 	}
 
-	for i, opt := range options {
-		cursor := "  "
-		optStyle := ui.ItemStyle
-		if i == m.confirmCursor {
-			cursor = ui.CursorStyle.Render("> ")
-			optStyle = ui.SelectedItemStyle
-		}
+	if rules.IsEmpty() {
+		delete(m.config.SubtreeRules, app.ID)
+	} else {
+		m.config.SubtreeRules[app.ID] = rules
+	}
 
-		b.WriteString(cursor)
-		b.WriteString(optStyle.Render(fmt.Sprintf("[%s] %s", opt.key, opt.label)))
-		b.WriteString("\n")
-		b.WriteString("      ")
-		b.WriteString(ui.MutedStyle.Render(opt.desc))
-		b.WriteString("\n")
+	if err := m.config.Save(); err != nil {
+		m.status = fmt.Sprintf("Error saving config: %v", err)
+		return m, nil
 	}
 
-	b.WriteString("\n")
-	b.WriteString(ui.HelpBarStyle.Render("↑↓ navigate • ENTER select • ESC cancel"))
+	model, cmd := m.handleRefresh()
+	m.status = fmt.Sprintf("%s %s, rescanning...", action, relPath)
+	return model, cmd
+}
 
-	box := style.Render(b.String())
+// handleMapPath opens the repo path of the current file for editing
+func (m *Model) handleMapPath() (tea.Model, tea.Cmd) {
+	if m.focusedPanel != PanelFiles {
+		m.status = "Select a file first (Tab to switch panel)"
+		return m, nil
+	}
+	app := m.appList.Current()
+	node := m.fileList.CurrentNode()
+	if app == nil || node == nil || node.File == nil {
+		m.status = "No file selected"
+		return m, nil
+	}
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
 
-	return lipgloss.Place(
-		m.width, m.height,
-		lipgloss.Center, lipgloss.Center,
-		box,
-	)
+	m.mapPathApp, m.mapPathFile = app, node.File
+	rel, err := filepath.Rel(m.config.DotfilesPath, sync.DotfilePath(m.config.DotfilesPath, app.ID, *node.File))
+	if err != nil {
+		rel = ""
+	}
+	m.textInput.SetValue(filepath.ToSlash(rel))
+	m.textInput.Placeholder = "vscode/settings.json"
+	m.textInput.Focus()
+	m.screen = ScreenMapPath
+	m.status = ""
+	return m, textinput.Blink
 }
 
-func (m *Model) renderMain() string {
-	var b strings.Builder
+func (m *Model) handleMapPathKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.textInput.Blur()
+		m.screen = ScreenMain
+		m.status = "Path mapping unchanged"
+		return m, nil
+	case "ctrl+r":
+		m.textInput.SetValue("")
+		return m.saveMapPath()
+	case "enter":
+		return m.saveMapPath()
+	}
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
 
-	header := m.renderHeader()
-	b.WriteString(header)
-	b.WriteString("\n")
+// saveMapPath stores the edited repo path of the file, moving its dotfiles
+// copy along, and rescans. An empty path or the layout's own path drops
+// the mapping.
+func (m *Model) saveMapPath() (tea.Model, tea.Cmd) {
+	app, file := m.mapPathApp, m.mapPathFile
+	repoPath := strings.TrimSpace(m.textInput.Value())
+	oldPath := sync.DotfilePath(m.config.DotfilesPath, app.ID, *file)
+
+	base, _ := layout.New(layout.For(m.config.DotfilesPath).Name())
+	auto := base.Path(m.config.DotfilesPath, app.ID, file.RelPath, file.Path)
+	if rel, err := filepath.Rel(m.config.DotfilesPath, auto); err == nil && filepath.ToSlash(rel) == repoPath {
+		repoPath = ""
+	}
+	if err := layout.SetMapping(m.config.DotfilesPath, file.Path, repoPath); err != nil {
+		m.status = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
 
-	switch m.screen {
-	case ScreenScanning:
-		// Nice loading screen with tips
-		var lines []string
+	// Keep the pushed copy where the file is now looked up
+	newPath := sync.DotfilePath(m.config.DotfilesPath, app.ID, *file)
+	moved := ""
+	if newPath != oldPath {
+		if _, err := os.Lstat(oldPath); err == nil {
+			if _, err := os.Lstat(newPath); os.IsNotExist(err) {
+				if err := os.MkdirAll(filepath.Dir(newPath), 0755); err == nil && os.Rename(oldPath, newPath) == nil {
+					moved = " (dotfiles copy moved)"
+				}
+			}
+		}
+	}
 
-		// Title with spinner
-		lines = append(lines, m.spinner.View()+" Scanning for apps...")
-		lines = append(lines, "")
+	m.textInput.Blur()
+	m.screen = ScreenMain
+	model, cmd := m.handleRefresh()
+	if repoPath == "" {
+		m.status = fmt.Sprintf("%s follows the repo layout again%s", file.Name, moved)
+	} else {
+		m.status = fmt.Sprintf("%s mapped to %s%s", file.Name, repoPath, moved)
+	}
+	return model, cmd
+}
 
-		// Scanning locations
-		lines = append(lines, "Looking for configurations in:")
-		lines = append(lines, "  • ~/.config/")
-		lines = append(lines, "  • ~/Library/Application Support/")
-		lines = append(lines, "  • Home directory dotfiles")
-		lines = append(lines, "")
+func (m *Model) renderMapPath() string {
+	var b strings.Builder
+	title := lipgloss.NewStyle().Bold(true).Foreground(ui.Primary)
 
-		// Show helpful tips with rotating animation
-		tips := []string{
-			"💡 Use / to search apps by name",
-			"💡 Press 1-9 to filter by category",
-			"💡 Press M to select modified, O for outdated",
-			"💡 Press d to view file differences",
-			"💡 Press g to access git operations",
-			"💡 Press s to rescan at any time",
-		}
-		tipIndex := int(time.Now().Unix()/3) % len(tips)
-		lines = append(lines, tips[tipIndex])
+	b.WriteString(title.Render(i18n.T("mappath.title")))
+	b.WriteString("\n\n")
+	b.WriteString(i18n.T("mappath.local") + "\n")
+	b.WriteString(ui.SelectedItemStyle.Render("  "+m.mapPathFile.Path) + "\n\n")
+	b.WriteString(i18n.T("mappath.repo") + "\n")
+	b.WriteString(m.textInput.View())
+	b.WriteString("\n\n")
+	if _, ok := layout.Mapping(m.config.DotfilesPath, m.mapPathFile.Path); ok {
+		b.WriteString(ui.MutedStyle.Render(i18n.T("mappath.mapped")) + "\n\n")
+	}
+	if strings.HasPrefix(m.status, "Error") {
+		b.WriteString(ui.ConflictStyle.Render(m.status) + "\n\n")
+	}
+	b.WriteString(ui.HelpBarStyle.Render(i18n.T("mappath.help")))
 
-		// Join all lines
-		scanContent := strings.Join(lines, "\n")
+	box := lipgloss.NewStyle().
+		Width(74).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.Primary).
+		Render(b.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
 
-		// Create a styled box for scan content
-		scanBox := lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(ui.Primary).
-			Padding(1, 3).
-			Render(scanContent)
+// handleCheckConflicts runs conflict detection and displays results
+func (m *Model) handleCheckConflicts() (tea.Model, tea.Cmd) {
+	if m.quickSync == nil {
+		m.status = "Quick backup not initialized"
+		return m, nil
+	}
 
-		// Get box dimensions
-		boxHeight := lipgloss.Height(scanBox)
-		boxWidth := lipgloss.Width(scanBox)
+	selectedApps := m.appList.SelectedApps()
+	if len(selectedApps) == 0 {
+		selectedApps = m.apps
+	}
 
-		// Calculate padding to center
-		availableHeight := m.height - 6 // header + status + help + newlines
-		availableWidth := m.width - 2   // AppStyle padding
+	m.status = "Checking for conflicts..."
 
-		topPad := (availableHeight - boxHeight) / 2
-		if topPad < 0 {
-			topPad = 0
-		}
-		leftPad := (availableWidth - boxWidth) / 2
-		if leftPad < 0 {
-			leftPad = 0
-		}
+	return m, func() tea.Msg {
+		detection := m.quickSync.DetectOnly(selectedApps)
+		return conflictCheckMsg{detection: detection}
+	}
+}
 
-		// Build centered content with explicit padding
-		var scanOutput strings.Builder
-		for i := 0; i < topPad; i++ {
-			scanOutput.WriteString("\n")
+// setConflictQueue replaces the conflict queue with files skipped by pull
+func (m *Model) setConflictQueue(results []sync.ImportResult) {
+	m.conflictQueue = nil
+	m.conflictCursor = 0
+
+	for _, r := range results {
+		if r.App == nil {
+			continue
 		}
-		// Add left padding to each line of the box
-		for _, line := range strings.Split(scanBox, "\n") {
-			scanOutput.WriteString(strings.Repeat(" ", leftPad))
-			scanOutput.WriteString(line)
-			scanOutput.WriteString("\n")
+		for i := range r.App.Files {
+			file := &r.App.Files[i]
+			if file.RelPath == r.File.RelPath {
+				file.ConflictType = models.ConflictBothModified
+				m.conflictQueue = append(m.conflictQueue, conflictItem{app: r.App, file: file})
+				break
+			}
 		}
+	}
+}
 
-		b.WriteString(scanOutput.String())
+// handleConflictQueue opens the queue of conflicts skipped by the last pull
+func (m *Model) handleConflictQueue() (tea.Model, tea.Cmd) {
+	if len(m.conflictQueue) == 0 {
+		m.status = "No pending conflicts"
+		return m, nil
+	}
+	m.screen = ScreenConflicts
+	m.status = fmt.Sprintf("%d conflicts to resolve", len(m.conflictQueue))
+	return m, nil
+}
 
-	case ScreenSyncing:
-		// Sync progress screen with progress bar
-		var syncContent strings.Builder
-		action := "Pushing"
-		if m.syncAction == "pull" {
-			action = "Pulling"
+func (m *Model) handleConflictKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.screen = ScreenMain
+		if len(m.conflictQueue) > 0 {
+			m.status = fmt.Sprintf("%d conflicts pending • Press 'C' to resume", len(m.conflictQueue))
+		} else {
+			m.status = "Ready"
 		}
-		syncContent.WriteString(fmt.Sprintf("%s %s files...\n\n", m.spinner.View(), action))
+		return m, nil
 
-		// Progress bar
-		var progressPercent float64
-		if m.syncTotal > 0 {
-			progressPercent = float64(m.syncCurrent) / float64(m.syncTotal)
+	case key.Matches(msg, m.keys.Up):
+		if m.conflictCursor > 0 {
+			m.conflictCursor--
 		}
-		syncContent.WriteString(m.progress.ViewAs(progressPercent) + "\n\n")
-		syncContent.WriteString(ui.MutedStyle.Render(fmt.Sprintf("  %d / %d files", m.syncCurrent, m.syncTotal)))
-		syncContent.WriteString("\n\n")
-		syncContent.WriteString(ui.MutedStyle.Render(m.status))
-
-		content := lipgloss.NewStyle().
-			Width(m.width).
-			Height(m.height-6).
-			Align(lipgloss.Center, lipgloss.Center).
-			Render(syncContent.String())
-		b.WriteString(content)
+		return m, nil
 
-	case ScreenHelp:
-		b.WriteString(m.helpVP.View())
+	case key.Matches(msg, m.keys.Down):
+		if m.conflictCursor < len(m.conflictQueue)-1 {
+			m.conflictCursor++
+		}
+		return m, nil
 
-	default:
-		panels := lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			m.appList.View(),
-			"  ",
-			m.fileList.View(),
-		)
-		b.WriteString(panels)
+	case key.Matches(msg, m.keys.Enter, m.keys.Diff):
+		return m.openConflictDiff()
 	}
 
-	b.WriteString("\n")
-	b.WriteString(m.renderStatusBar())
-	b.WriteString("\n")
-	b.WriteString(m.renderHelpBar())
-
-	return ui.AppStyle.Render(b.String())
+	return m, nil
 }
 
-func (m *Model) renderHeader() string {
-	title := ui.TitleStyle.Render("🔄 Dotsync")
-	ver := ui.VersionStyle.Render("v" + version)
-	path := ui.MutedStyle.Render("  " + m.config.DotfilesPath)
+// openConflictDiff shows the diff for the conflict under the cursor
+func (m *Model) openConflictDiff() (tea.Model, tea.Cmd) {
+	if m.conflictCursor >= len(m.conflictQueue) {
+		return m, nil
+	}
+	item := m.conflictQueue[m.conflictCursor]
 
-	// Show git branch if in a git repo (cached from gitPanel)
-	gitInfo := ""
-	if m.config.IsGitRepo() && m.gitPanel != nil && m.gitPanel.Status != nil && m.gitPanel.Status.Branch != "" {
-		gitInfo = ui.MutedStyle.Render(" [" + m.gitPanel.Status.Branch + "]")
+	localPath := item.file.Path
+	dotfilePath := sync.DotfilePath(m.config.DotfilesPath, item.app.ID, *item.file)
+
+	diffResult, err := sync.ComputeDiff(localPath, dotfilePath)
+	if err != nil {
+		m.status = fmt.Sprintf("Diff error: %v", err)
+		return m, nil
 	}
 
-	return ui.HeaderStyle.Render(title + "  " + ver + path + gitInfo)
-}
+	m.currentDiffFile = item.file
+	m.currentDiffApp = item.app
+	m.resolvingConflict = true
+	m.diffFromQuickSync = false
 
-func (m *Model) renderStatusBar() string {
-	selectedApps := m.appList.SelectedApps()
-	totalApps := len(m.apps)
+	m.diffView.SetDiff(diffResult, localPath, dotfilePath)
+	m.diffView.Width = m.width - 4
+	m.diffView.Height = m.height - 6
+	m.screen = ScreenDiff
+	m.status = "Resolve conflict: 1 keep local, 2 use dotfiles, m merge"
+	return m, nil
+}
 
-	// Count selected files across all selected apps
-	selectedFiles := 0
-	modifiedFiles := 0
-	conflictFiles := 0
-	for _, app := range selectedApps {
-		for _, file := range app.Files {
-			if file.Selected {
-				selectedFiles++
-			}
-			// Count modified and conflict files
-			switch file.ConflictType {
-			case models.ConflictLocalModified, models.ConflictLocalNew:
-				modifiedFiles++
-			case models.ConflictBothModified:
-				conflictFiles++
-			}
-		}
+// resolveConflict settles the current conflict by syncing one side over the
+// other, then drops it from the queue. keepLocal pushes local to dotfiles.
+func (m *Model) resolveConflict(keepLocal bool) (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
+	if m.currentDiffFile == nil || m.currentDiffApp == nil {
+		return m, nil
 	}
 
-	// Build stats string
-	var stats []string
-	stats = append(stats, fmt.Sprintf("Apps: %d/%d", len(selectedApps), totalApps))
-	if selectedFiles > 0 {
-		stats = append(stats, fmt.Sprintf("Files: %d", selectedFiles))
+	localPath := m.currentDiffFile.Path
+	dotfilePath := sync.DotfilePath(m.config.DotfilesPath, m.currentDiffApp.ID, *m.currentDiffFile)
+
+	// Both go through the push and pull filters; a pull backs up local
+	appID, relPath := m.currentDiffApp.ID, m.currentDiffFile.RelPath
+	var err error
+	if keepLocal {
+		err = sync.NewExporter(m.config).ExportFile(appID, relPath, localPath, dotfilePath)
+	} else {
+		err = sync.NewImporter(m.config).ImportFile(appID, relPath, dotfilePath, localPath)
 	}
-	if modifiedFiles > 0 {
-		stats = append(stats, fmt.Sprintf("Modified: %d", modifiedFiles))
+	// Merges from the queue were already logged by the merge screen
+	if m.screen != ScreenMerge {
+		detail := "use dotfiles"
+		if keepLocal {
+			detail = "keep local"
+		}
+		m.auditFile(audit.ActionResolve, m.currentDiffApp, m.currentDiffFile, err, detail)
 	}
-	if conflictFiles > 0 {
-		stats = append(stats, ui.ConflictStyle.Render(fmt.Sprintf("⚡Conflicts: %d", conflictFiles)))
+	if err != nil {
+		m.status = fmt.Sprintf("Error resolving conflict: %v", err)
+		return m, nil
 	}
 
-	// Show current panel indicator
-	panelIndicator := "📁"
-	if m.focusedPanel == PanelFiles {
-		panelIndicator = "📄"
+	sync.GetHashCache().InvalidatePath(localPath)
+	sync.GetHashCache().InvalidatePath(dotfilePath)
+	localHash, _ := sync.ComputeFileHash(localPath)
+	dotfilesHash, _ := sync.ComputeFileHash(dotfilePath)
+	m.currentDiffFile.LocalHash = localHash
+	m.currentDiffFile.DotfilesHash = dotfilesHash
+	m.currentDiffFile.ConflictType = models.ConflictNone
+	if m.stateManager != nil {
+		m.stateManager.SetFileState(appID, relPath, localHash, dotfilesHash)
+		_ = m.stateManager.Save()
 	}
 
-	// Style status message based on content
-	styledStatus := ui.StatusTextStyle.Render(m.status)
-	if strings.HasPrefix(m.status, "✓") {
-		styledStatus = ui.RenderNotification("success", strings.TrimPrefix(m.status, "✓ "))
-	} else if strings.HasPrefix(m.status, "Error") {
-		styledStatus = ui.RenderNotification("error", m.status)
-	} else if strings.Contains(m.status, "cancelled") || strings.Contains(m.status, "failed") {
-		styledStatus = ui.RenderNotification("warning", m.status)
+	m.dropConflict(m.currentDiffFile)
+	if len(m.conflictQueue) == 0 {
+		m.resolvingConflict = false
+		m.screen = ScreenMain
+		m.status = "✓ All conflicts resolved"
+		return m, nil
 	}
 
-	return ui.StatusBarStyle.Render(
-		panelIndicator + " " + styledStatus + "  •  " + strings.Join(stats, "  •  "),
-	)
+	m.screen = ScreenConflicts
+	m.status = fmt.Sprintf("✓ Resolved %s • %d conflicts remaining", m.currentDiffFile.RelPath, len(m.conflictQueue))
+	return m, nil
 }
 
-func (m *Model) renderHelpBar() string {
-	// Show different help bar based on current screen
-	switch m.screen {
-	case ScreenScanning:
-		items := []string{
-			ui.RenderHelpItem("q", "quit"),
-		}
-		return ui.HelpBarStyle.Render("⏳ Scanning... " + strings.Join(items, "  "))
+// reviewAppliedMsg is sent when the decisions of a review were applied
+type reviewAppliedMsg struct {
+	queue   *review.Queue
+	results []review.Result
+}
 
-	case ScreenSyncing:
-		items := []string{
-			ui.RenderHelpItem("q", "quit"),
-		}
-		return ui.HelpBarStyle.Render("🔄 Syncing... " + strings.Join(items, "  "))
+// handleReview starts stepping through the selected files that differ
+// between local and dotfiles
+func (m *Model) handleReview() (tea.Model, tea.Cmd) {
+	q := review.New(m.appList.SelectedApps())
+	if len(q.Items) == 0 {
+		m.status = "No selected changes to review - select modified files first (M/O)"
+		return m, nil
+	}
+	m.reviewQueue = q
+	m.resolvingConflict = false
+	m.diffFromQuickSync = false
+	return m.showReviewItem()
+}
 
-	case ScreenHelp:
-		scrollPct := fmt.Sprintf("%d%%", int(m.helpVP.ScrollPercent()*100))
-		items := []string{
-			ui.RenderHelpItem("↑↓/j/k", "scroll"),
-			ui.RenderHelpItem("PgUp/PgDn", "page"),
-			ui.RenderHelpItem("esc/?", "close"),
-			ui.RenderHelpItem(scrollPct, ""),
-		}
-		return ui.HelpBarStyle.Render(strings.Join(items, "  "))
-	case ScreenAddCustom:
-		items := []string{
-			ui.RenderHelpItem("Enter", "next/save"),
-			ui.RenderHelpItem("Tab", "mode"),
-			ui.RenderHelpItem("Esc", "cancel"),
-		}
-		return ui.HelpBarStyle.Render("➕ Add custom source  " + strings.Join(items, "  "))
+// showReviewItem shows the diff of the file under review, or the summary
+// once every file is decided
+func (m *Model) showReviewItem() (tea.Model, tea.Cmd) {
+	m.screen = ScreenReview
+	item := m.reviewQueue.Item()
+	if item == nil {
+		m.status = m.reviewQueue.Summary()
+		return m, nil
 	}
 
-	// Show different help bar when in search mode
-	if m.searchMode {
-		items := []string{
-			ui.RenderHelpItem("↑↓", "navigate"),
-			ui.RenderHelpItem("enter", "confirm"),
-			ui.RenderHelpItem("esc", "cancel"),
-		}
-		return ui.HelpBarStyle.Render("🔍 " + m.textInput.View() + "  " + strings.Join(items, "  "))
+	m.currentDiffApp = item.App
+	m.currentDiffFile = item.File
+	localPath := item.File.Path
+	dotfilePath := sync.DotfilePath(m.config.DotfilesPath, item.App.ID, *item.File)
+	diffResult, err := sync.ComputeDiff(localPath, dotfilePath)
+	if err != nil {
+		m.diffView.DiffResult = nil
+		m.status = fmt.Sprintf("Diff error: %v", err)
+		return m, nil
 	}
+	m.diffView.SetDiff(diffResult, localPath, dotfilePath)
+	m.diffView.Width = m.width - 4
+	m.diffView.Height = m.height - 7
+	m.status = fmt.Sprintf("%s • %s", item.File.ConflictType.ConflictString(), m.reviewQueue.Summary())
+	return m, nil
+}
 
-	// Show filter hint if category filter is active
-	if m.categoryFilter != "" {
-		items := []string{
-			ui.RenderHelpItem("esc", "clear"),
-			ui.RenderHelpItem("space", "select"),
-			ui.RenderHelpItem("Q", "backup"),
-			ui.RenderHelpItem("p", "push"),
-			ui.RenderHelpItem("l", "pull"),
-			ui.RenderHelpItem("?", "help"),
-		}
-		return ui.HelpBarStyle.Render("📁 " + m.categoryFilter + "  " + strings.Join(items, "  "))
+func (m *Model) handleReviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	q := m.reviewQueue
+	switch {
+	case key.Matches(msg, m.keys.Escape, m.keys.Quit):
+		m.reviewQueue = nil
+		m.screen = ScreenMain
+		m.status = "Review cancelled - nothing was applied"
+		return m, nil
+
+	case msg.String() == "b", msg.String() == "backspace":
+		q.Back()
+		return m.showReviewItem()
 	}
 
-	// Show search filter hint if search is active
-	if m.searchQuery != "" {
-		items := []string{
-			ui.RenderHelpItem("esc", "clear"),
-			ui.RenderHelpItem("space", "select"),
-			ui.RenderHelpItem("Q", "backup"),
-			ui.RenderHelpItem("p", "push"),
-			ui.RenderHelpItem("l", "pull"),
-			ui.RenderHelpItem("?", "help"),
+	if q.Done() {
+		if key.Matches(msg, m.keys.Enter) {
+			return m.applyReview()
 		}
-		return ui.HelpBarStyle.Render("🔍 \"" + m.searchQuery + "\"  " + strings.Join(items, "  "))
+		return m, nil
 	}
 
-	// Context-sensitive help based on panel and selection
-	var items []string
+	switch {
+	case key.Matches(msg, m.keys.KeepLocal):
+		q.Decide(review.Push)
+		return m.showReviewItem()
+
+	case key.Matches(msg, m.keys.UseDotfiles):
+		q.Decide(review.Pull)
+		return m.showReviewItem()
+
+	case msg.String() == "x":
+		q.Decide(review.Skip)
+		return m.showReviewItem()
+
+	case key.Matches(msg, m.keys.Merge):
+		return m.handleMerge()
+
+	case key.Matches(msg, m.keys.Up):
+		m.diffView.ScrollUp()
 
-	// Check if we have selected items
-	selectedApps := m.appList.SelectedApps()
-	hasSelection := len(selectedApps) > 0
+	case key.Matches(msg, m.keys.Down):
+		m.diffView.ScrollDown()
 
-	if m.focusedPanel == PanelApps {
-		if hasSelection {
-			// Show sync actions when items are selected
-			items = []string{
-				ui.RenderHelpItem("Q", "backup"),
-				ui.RenderHelpItem("p", "push"),
-				ui.RenderHelpItem("l", "pull"),
-				ui.RenderHelpItem("t", "mode"),
-				ui.RenderHelpItem("tab", "→files"),
-				ui.RenderHelpItem("?", "help"),
-			}
-		} else {
-			// Show selection actions when nothing selected
-			items = []string{
-				ui.RenderHelpItem("space", "select"),
-				ui.RenderHelpItem("a", "all"),
-				ui.RenderHelpItem("M", "mod"),
-				ui.RenderHelpItem("O", "outdated"),
-				ui.RenderHelpItem("+", "add custom"),
-				ui.RenderHelpItem("/", "search"),
-				ui.RenderHelpItem("1-9", "filter"),
-				ui.RenderHelpItem("?", "help"),
-			}
-		}
-	} else {
-		// Files panel - show file-specific actions
-		if hasSelection {
-			items = []string{
-				ui.RenderHelpItem("Q", "backup"),
-				ui.RenderHelpItem("p", "push"),
-				ui.RenderHelpItem("l", "pull"),
-				ui.RenderHelpItem("d", "diff"),
-				ui.RenderHelpItem("e", "edit"),
-				ui.RenderHelpItem("tab", "→apps"),
-				ui.RenderHelpItem("?", "help"),
-			}
-		} else {
-			items = []string{
-				ui.RenderHelpItem("space", "select"),
-				ui.RenderHelpItem("v", "preview"),
-				ui.RenderHelpItem("d", "diff"),
-				ui.RenderHelpItem("e", "edit"),
-				ui.RenderHelpItem("tab", "→apps"),
-				ui.RenderHelpItem("?", "help"),
-			}
-		}
-	}
+	case key.Matches(msg, m.keys.NextHunk):
+		m.diffView.NextHunk()
 
-	return ui.HelpBarStyle.Render(strings.Join(items, "  "))
-}
+	case key.Matches(msg, m.keys.PrevHunk):
+		m.diffView.PrevHunk()
 
-func (m *Model) renderHelp() string {
-	var b strings.Builder
+	case msg.String() == "h":
+		m.diffView.ToggleHighlight()
 
-	b.WriteString(ui.PanelTitleStyle.Render("⌨️  Keyboard Shortcuts Guide"))
-	b.WriteString("\n\n")
+	case msg.String() == "s":
+		m.diffView.ToggleSideBySide()
+		m.diffView.ScrollOffset = 0
 
-	// Quick Actions section (most important - at the top)
-	b.WriteString(ui.MutedStyle.Render("  ─── ⚡ Quick Actions ───"))
-	b.WriteString("\n")
-	quickBindings := []struct {
-		key  string
-		desc string
-	}{
-		{"Q", "Quick Backup: auto-backup files to dotfiles"},
-		{"P", "Push + Commit: push selected + git commit"},
-		{"p", "Push: copy local → dotfiles (manual)"},
-		{"l", "Pull: copy dotfiles → local"},
-		{"c", "Check conflicts"},
-		{"e", "Open in editor (VS Code/Cursor/Zed)"},
-	}
-	for _, bind := range quickBindings {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(14).Render(bind.key),
-			ui.HelpDescStyle.Render(bind.desc),
-		))
+	case msg.String() == "t":
+		m.diffView.ToggleStructural()
+		m.diffView.ScrollOffset = 0
 	}
+	return m, nil
+}
 
-	// Mode section - More detailed explanation
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  ─── 💾 Backup vs Sync ───"))
-	b.WriteString("\n")
-	b.WriteString(fmt.Sprintf("  %s  %s\n",
-		ui.HelpKeyStyle.Width(14).Render("[B] Backup"),
-		ui.HelpDescStyle.Render("Lưu riêng theo máy → Q tự động push"),
-	))
-	b.WriteString(fmt.Sprintf("  %s  %s\n",
-		ui.HelpKeyStyle.Width(14).Render("[B+S] Sync"),
-		ui.HelpDescStyle.Render("Giống nhau mọi máy → p/l thủ công"),
-	))
-	b.WriteString("\n")
-	modeBindings := []struct {
-		key  string
-		desc string
-	}{
-		{"t", "Toggle sync cho app/file đang chọn"},
-		{"R", "Restore config từ máy khác"},
-	}
-	for _, bind := range modeBindings {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(14).Render(bind.key),
-			ui.HelpDescStyle.Render(bind.desc),
-		))
+// applyReview carries out the accepted pushes, pulls and merges in one batch
+func (m *Model) applyReview() (tea.Model, tea.Cmd) {
+	q := m.reviewQueue
+	if q.Count(review.Push)+q.Count(review.Pull)+q.Count(review.Merged) == 0 {
+		m.reviewQueue = nil
+		m.screen = ScreenMain
+		m.status = "Nothing left to apply"
+		return m, nil
 	}
-
-	// Navigation section
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  ─── 🧭 Navigation ───"))
-	b.WriteString("\n")
-	navBindings := []struct {
-		key  string
-		desc string
-	}{
-		{"/", "Search/filter apps"},
-		{"1-9", "Filter by category"},
-		{"0", "Clear category filter"},
-		{"↑/k ↓/j", "Move cursor up/down"},
-		{"Tab", "Switch Apps ↔ Files panel"},
-		{"PgUp/PgDn", "Scroll page"},
-		{"Home/End", "Jump to first/last"},
-	}
-	for _, bind := range navBindings {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(14).Render(bind.key),
-			ui.HelpDescStyle.Render(bind.desc),
-		))
+	if !m.acquireSyncLock("review") {
+		return m, nil
 	}
-
-	// Selection section
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  ─── ✅ Selection ───"))
-	b.WriteString("\n")
-	selBindings := []struct {
-		key  string
-		desc string
-	}{
-		{"Space", "Toggle selection"},
-		{"a", "Select all"},
-		{"D", "Deselect all"},
-		{"M", "Select all modified (need push)"},
-		{"O", "Select all outdated (need pull)"},
-		{"+", "Add custom folder/app source"},
-		{"u", "Undo last selection"},
-	}
-	for _, bind := range selBindings {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(14).Render(bind.key),
-			ui.HelpDescStyle.Render(bind.desc),
-		))
+	m.screen = ScreenMain
+	m.status = fmt.Sprintf("Applying review: %s...", q.Summary())
+	cfg, state := m.config, m.stateManager
+	return m, func() tea.Msg {
+		return reviewAppliedMsg{queue: q, results: q.Apply(cfg, state)}
 	}
+}
 
-	// File Actions section
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  ─── 📄 File Actions ───"))
-	b.WriteString("\n")
-	fileBindings := []struct {
-		key  string
-		desc string
-	}{
-		{"v/Enter", "Preview file content"},
-		{"d", "View diff (local vs dotfiles)"},
-		{"m", "Merge conflicts"},
-		{"s", "Rescan all apps"},
-		{"b", "Export Brewfile"},
-		{"r", "Refresh current view"},
-	}
-	for _, bind := range fileBindings {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(14).Render(bind.key),
-			ui.HelpDescStyle.Render(bind.desc),
-		))
+// handleReviewApplied saves the sync state and audits each applied decision
+func (m *Model) handleReviewApplied(msg reviewAppliedMsg) (tea.Model, tea.Cmd) {
+	m.reviewQueue = nil
+	if m.stateManager != nil {
+		if err := m.stateManager.Save(); err != nil {
+			slog.Error("saving sync state failed", "err", err)
+		}
 	}
+	m.releaseSyncLock()
 
-	// Git Operations section
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  ─── 🔀 Git (press 'g') ───"))
-	b.WriteString("\n")
-	b.WriteString(fmt.Sprintf("  %s\n", ui.HelpDescStyle.Render("Tự động tạo git nếu chưa có")))
-	gitBindings := []struct {
-		key  string
-		desc string
-	}{
-		{"g", "Open git panel (auto git init)"},
-		{"a", "Stage all"},
-		{"c", "Commit"},
-		{"p", "Push"},
-		{"f", "Fetch"},
-		{"l", "Pull"},
-		{"b", "Switch branch"},
-		{"L", "Open lazygit (if installed)"},
-	}
-	for _, bind := range gitBindings {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(14).Render(bind.key),
-			ui.HelpDescStyle.Render(bind.desc),
-		))
+	failed := 0
+	for _, r := range msg.results {
+		action := audit.ActionPush
+		if r.Item.Decision == review.Pull {
+			action = audit.ActionPull
+		}
+		m.auditFile(action, r.Item.App, r.Item.File, r.Err, "review: "+r.Item.Decision.String())
+		if r.Err != nil {
+			failed++
+			slog.Warn("review failed", "decision", r.Item.Decision, "app", r.Item.App.ID, "file", r.Item.File.RelPath, "err", r.Err)
+		}
 	}
+	m.updateFileList()
 
-	// General section
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  ─── ⚙️ General ───"))
-	b.WriteString("\n")
-	generalBindings := []struct {
-		key  string
-		desc string
-	}{
-		{",", "Settings (dotfiles path, backup path)"},
-		{"?", "Toggle this help"},
-		{"Esc", "Go back / Cancel"},
-		{"q", "Quit"},
-	}
-	for _, bind := range generalBindings {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(14).Render(bind.key),
-			ui.HelpDescStyle.Render(bind.desc),
-		))
+	if failed > 0 {
+		m.status = fmt.Sprintf("⚠ Review applied with %d errors (%s) - see the audit log (H)", failed, msg.queue.Summary())
+	} else {
+		m.status = fmt.Sprintf("✓ Review applied: %s", msg.queue.Summary())
 	}
+	return m, nil
+}
 
-	// Status icons legend
-	b.WriteString("\n")
-	b.WriteString(ui.PanelTitleStyle.Render("📊 Status Icons"))
-	b.WriteString("\n\n")
-	statusIcons := []struct {
-		icon string
-		desc string
-	}{
-		{"✓", "Synced - Files are identical"},
-		{"●", "Modified - Local has changes (push)"},
-		{"○", "Outdated - Dotfiles has updates (pull)"},
-		{"⚡", "Conflict - Both sides changed"},
-		{"[B]", "Backup only - Per-machine storage"},
-		{"[B+S]", "Backup + Sync - Same on all machines"},
+// dropConflict removes a settled file from the conflict queue
+func (m *Model) dropConflict(file *models.File) {
+	for i, item := range m.conflictQueue {
+		if item.file == file {
+			m.conflictQueue = append(m.conflictQueue[:i], m.conflictQueue[i+1:]...)
+			break
+		}
 	}
-	for _, icon := range statusIcons {
-		b.WriteString(fmt.Sprintf("  %s  %s\n",
-			ui.HelpKeyStyle.Width(4).Render(icon.icon),
-			ui.HelpDescStyle.Render(icon.desc),
-		))
+	if m.conflictCursor >= len(m.conflictQueue) && m.conflictCursor > 0 {
+		m.conflictCursor--
 	}
-
-	// Quick reference - Backup explanation
-	b.WriteString("\n")
-	b.WriteString(ui.PanelTitleStyle.Render("💡 Cách hoạt động"))
-	b.WriteString("\n\n")
-	b.WriteString(ui.MutedStyle.Render("  Backup [B]:"))
-	b.WriteString("\n")
-	b.WriteString("    • Mỗi máy có folder riêng: dotfiles/app/{machine}/\n")
-	b.WriteString("    • Nhấn Q → tự động push lên folder của máy này\n")
-	b.WriteString("    • Dùng R để restore config từ máy khác\n")
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  Backup + Sync [B+S]:"))
-	b.WriteString("\n")
-	b.WriteString("    • Một bản duy nhất: dotfiles/app/file\n")
-	b.WriteString("    • Nhấn p để push, l để pull (thủ công)\n")
-	b.WriteString("    • Giống nhau trên mọi máy\n")
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("  Press any key to close"))
-
-	return b.String()
 }
 
-func (m *Model) renderDiff() string {
-	var b strings.Builder
-
-	header := m.renderHeader()
-	b.WriteString(header)
-	b.WriteString("\n")
+// conflictCheckMsg is sent when conflict check completes
+type conflictCheckMsg struct {
+	detection *quicksync.DetectionResult
+}
 
-	// Render diff view
-	b.WriteString(m.diffView.View())
+// handleOpenEditor opens the current file in a GUI editor: a local vs
+// dotfiles diff when compare is set (and the dotfiles copy exists),
+// otherwise just the local file. Both sides are re-hashed once it closes.
+func (m *Model) handleOpenEditor(compare bool) (tea.Model, tea.Cmd) {
+	if m.focusedPanel != PanelFiles {
+		m.status = "Select a file first (Tab to switch panel)"
+		return m, nil
+	}
 
-	return ui.AppStyle.Render(b.String())
-}
+	currentFile := m.fileList.Current()
+	currentApp := m.appList.Current()
+	if currentFile == nil || currentApp == nil {
+		m.status = "No file selected"
+		return m, nil
+	}
 
-func (m *Model) renderMerge() string {
-	var b strings.Builder
+	// Detect and open editor
+	ed, err := editor.Detect(nil)
+	if err != nil {
+		m.status = fmt.Sprintf("No editor found: %v", err)
+		return m, nil
+	}
 
-	header := m.renderHeader()
-	b.WriteString(header)
-	b.WriteString("\n")
+	localPath := currentFile.Path
+	dotfilePath := sync.DotfilePath(m.config.DotfilesPath, currentApp.ID, *currentFile)
+	if compare && !currentFile.IsDir {
+		if _, err := os.Stat(dotfilePath); err != nil {
+			m.status = fmt.Sprintf("%s is not in dotfiles yet", currentFile.RelPath)
+			return m, nil
+		}
+		m.status = fmt.Sprintf("Comparing %s in %s...", currentFile.Name, ed.Name())
+	} else {
+		compare = false
+		m.status = fmt.Sprintf("Opening %s in %s...", currentFile.Name, ed.Name())
+	}
 
-	// Render merge view
-	b.WriteString(m.mergeView.View())
+	return m, func() tea.Msg {
+		open := func() error { return ed.OpenFile(localPath) }
+		if compare {
+			open = func() error { return ed.OpenDiff(localPath, dotfilePath) }
+		}
+		if err := open(); err != nil {
+			return editorOpenedMsg{err: err}
+		}
+		return externalToolMsg{app: currentApp, file: currentFile, tool: ed.Name(), err: ed.Wait()}
+	}
+}
 
-	return ui.AppStyle.Render(b.String())
+// editorOpenedMsg is sent when editor operation completes
+type editorOpenedMsg struct {
+	err error
 }
 
-func (m *Model) renderPreview() string {
-	var b strings.Builder
+// handlePushAndCommit pushes changes and commits with auto-generated message
+func (m *Model) handlePushAndCommit() (tea.Model, tea.Cmd) {
+	if m.blockedByReadOnly() {
+		return m, nil
+	}
+	selectedApps := m.appList.SelectedApps()
+	if len(selectedApps) == 0 {
+		m.status = "No apps selected"
+		return m, nil
+	}
 
-	header := m.renderHeader()
-	b.WriteString(header)
-	b.WriteString("\n")
+	// Count selected files
+	fileCount := 0
+	var appNames []string
+	for _, app := range selectedApps {
+		hasFiles := false
+		for _, file := range app.Files {
+			if file.Selected {
+				fileCount++
+				hasFiles = true
+			}
+		}
+		if hasFiles {
+			appNames = append(appNames, app.ID)
+		}
+	}
 
-	// Render file preview
-	b.WriteString(m.filePreview.View())
-	b.WriteString("\n")
+	if fileCount == 0 {
+		m.status = "No files selected"
+		return m, nil
+	}
 
-	// Help bar
-	helpItems := []string{
-		ui.RenderHelpItem("j/k", "scroll"),
-		ui.RenderHelpItem("PgUp/Dn", "page"),
-		ui.RenderHelpItem("Home/End", "top/bottom"),
-		ui.RenderHelpItem("q/Esc", "close"),
+	if !m.acquireSyncLock("push") {
+		return m, nil
 	}
-	b.WriteString(ui.HelpBarStyle.Render(strings.Join(helpItems, "  ")))
+	m.status = "Pushing and committing..."
+	m.syncing = true
+	m.screen = ScreenSyncing
 
-	return ui.AppStyle.Render(b.String())
-}
+	// The backend is set up here: which files it holds depends on the
+	// app list, which the UI keeps changing while this runs
+	backend, backendErr := m.remoteBackend()
 
-func (m *Model) renderGit() string {
-	var b strings.Builder
+	return m, func() tea.Msg {
+		// Export files first
+		pluginResults := m.pluginExports()
+		exporter := sync.NewExporter(m.config)
+		results, err := exporter.ExportAll(selectedApps)
+		if err != nil {
+			return syncCompleteMsg{err: err, action: "push"}
+		}
 
-	header := m.renderHeader()
-	b.WriteString(header)
-	b.WriteString("\n")
+		// Generate commit message
+		var commitMsg string
+		if len(appNames) == 1 {
+			commitMsg = fmt.Sprintf("sync: update %s (%d files)", appNames[0], fileCount)
+		} else if len(appNames) <= 3 {
+			commitMsg = fmt.Sprintf("sync: update %s", strings.Join(appNames, ", "))
+		} else {
+			commitMsg = fmt.Sprintf("sync: update %d apps (%d files)", len(appNames), fileCount)
+		}
 
-	// Render git panel
-	b.WriteString(m.gitPanel.View())
+		// Commit and push through the configured backend
+		if backendErr != nil {
+			return syncCompleteMsg{results: results, err: backendErr, action: "push+commit"}
+		}
+		skipped, err := splitSkipped(backend.Push(commitMsg))
+		if err != nil {
+			return syncCompleteMsg{results: results, err: err, action: "push+commit"}
+		}
 
-	return ui.AppStyle.Render(b.String())
+		pluginResults = append(pluginResults, m.pluginPostSync("push", results)...)
+		return syncCompleteMsg{results: results, action: "push+commit", plugins: pluginResults, skipped: skipped}
+	}
 }
 
-func (m *Model) renderSettings() string {
-	width := 70
-	style := lipgloss.NewStyle().
-		Width(width).
-		Padding(1, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(ui.Primary)
-
-	var b strings.Builder
+// runReport scans every profile once and writes/emails one drift summary.
+// Meant to be run daily from cron or launchd, e.g. `0 9 * * * dotsync
+// report`, which --schedule adds to the crontab. With --profile only that
+// profile is reported.
+func runReport(args []string) int {
+	cfg, _ := config.Load()
 
-	// Title
-	title := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(ui.Primary).
-		Render("⚙️  Settings")
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	file := fs.String("file", cfg.ReportFile, "write report to this file")
+	email := fs.String("email", cfg.ReportEmail, "email report to this address via sendmail/msmtp")
+	schedule := fs.String("schedule", "", "add a cron job running this report on a schedule, e.g. \"0 9 * * *\"")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
-	b.WriteString(title)
-	b.WriteString("\n\n")
+	if *schedule != "" {
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return scheduleReport(fs, *schedule)
+	}
 
-	// Settings fields
-	fields := []struct {
-		name  string
-		value string
-		field SettingsField
-	}{
-		{"Dotfiles Path", m.config.DotfilesPath, SettingsDotfilesPath},
-		{"Backup Path", m.config.BackupPath, SettingsBackupPath},
+	machine := ""
+	if modesCfg, err := modes.Load(); err == nil {
+		machine = modesCfg.MachineName
 	}
+	summary := report.New(machine)
 
-	for _, f := range fields {
-		isSelected := m.settingsField == f.field
+	active := config.Profile()
+	profiles := []string{active}
+	if active == "" {
+		profiles = append(profiles, config.Profiles()...)
+	}
+	defer config.SetProfile(active)
+	for _, profile := range profiles {
+		config.SetProfile(profile)
+		profileCfg, _ := config.Load()
+		stateManager := sync.NewStateManager(config.ConfigDir())
+		_ = stateManager.Load()
 
-		// Label
-		labelStyle := lipgloss.NewStyle().Width(15)
-		if isSelected {
-			labelStyle = labelStyle.Bold(true).Foreground(ui.Primary)
-		} else {
-			labelStyle = labelStyle.Foreground(lipgloss.Color("#6c7086"))
+		apps, err := newScanner(profileCfg).Scan()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: scan failed: %v\n", err)
+			return 1
 		}
-		b.WriteString(labelStyle.Render(f.name + ":"))
-		b.WriteString(" ")
-
-		// Value or input
-		if isSelected && m.settingsEditing {
-			// Show text input
-			b.WriteString(m.textInput.View())
-		} else {
-			valueStyle := lipgloss.NewStyle()
-			if isSelected {
-				valueStyle = valueStyle.
-					Background(lipgloss.Color("#313244")).
-					Foreground(lipgloss.Color("#cdd6f4")).
-					Padding(0, 1)
-			} else {
-				valueStyle = valueStyle.Foreground(lipgloss.Color("#cdd6f4"))
-			}
-			b.WriteString(valueStyle.Render(f.value))
+		for _, app := range apps {
+			sync.UpdateSyncStatusWithHashes(app, profileCfg.DotfilesPath, stateManager)
 		}
-		b.WriteString("\n")
+		summary.Add(profile, apps)
 	}
 
-	b.WriteString("\n")
+	if *file == "" && *email == "" {
+		fmt.Print(summary.Text())
+		return 0
+	}
 
-	// Help text
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6c7086"))
-	if m.settingsEditing {
-		b.WriteString(helpStyle.Render("Enter: save  •  Esc: cancel"))
-	} else {
-		b.WriteString(helpStyle.Render("↑/↓: navigate  •  Enter: edit  •  Esc/q: back"))
+	status := 0
+	if *file != "" {
+		if err := summary.WriteFile(*file); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: write report: %v\n", err)
+			status = 1
+		}
 	}
+	if *email != "" {
+		if err := summary.SendMail(*email); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: send report: %v\n", err)
+			status = 1
+		}
+	}
+	return status
+}
 
-	// Current config file path
-	b.WriteString("\n\n")
-	b.WriteString(helpStyle.Render("Config file: " + config.ConfigPath()))
+// scheduleReport adds a cron job running the report with the flags given
+// on this command line, other than --schedule
+func scheduleReport(fs *flag.FlagSet, schedule string) int {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'" }
 
-	box := style.Render(b.String())
+	command := quote(exe)
+	if profile := config.Profile(); profile != "" {
+		command += " --profile " + quote(profile)
+	}
+	command += " report"
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "schedule" {
+			command += " --" + f.Name + " " + quote(f.Value.String())
+		}
+	})
 
-	return lipgloss.Place(
-		m.width, m.height,
-		lipgloss.Center, lipgloss.Center,
-		box,
-	)
+	added, err := scheduler.New(config.ConfigDir()).AddCronJob(schedule, command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: schedule report: %v\n", err)
+		return 1
+	}
+	if !added {
+		fmt.Println("The crontab already runs this report")
+		return 0
+	}
+	fmt.Printf("Added to crontab: %s %s\n", schedule, command)
+	return 0
 }
 
-func (m *Model) renderAddCustom() string {
-	width := 74
-	style := lipgloss.NewStyle().
-		Width(width).
-		Padding(1, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(ui.Primary)
-
-	var b strings.Builder
+// runSandbox materializes a full pull into a scratch directory that stands
+// in for $HOME, so the result can be inspected before restoring for real
+func runSandbox(args []string) int {
+	cfg, _ := config.Load()
 
-	title := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(ui.Primary).
-		Render("➕ Add Custom Source")
-	b.WriteString(title)
-	b.WriteString("\n\n")
+	fs := flag.NewFlagSet("sandbox", flag.ContinueOnError)
+	dir := fs.String("dir", "", "sandbox directory (default: new temp dir)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
-	mode := "[Folder]"
-	if m.addCustomMode == "app" {
-		mode = "[App]"
+	root := *dir
+	if root == "" {
+		tmp, err := os.MkdirTemp("", "dotsync-sandbox-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: create sandbox: %v\n", err)
+			return 1
+		}
+		root = tmp
+	} else if err := os.MkdirAll(root, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: create sandbox: %v\n", err)
+		return 1
 	}
-	b.WriteString("Mode: ")
-	b.WriteString(ui.SelectedItemStyle.Render(mode))
-	b.WriteString("  ")
-	b.WriteString(ui.MutedStyle.Render("(Tab to switch)"))
-	b.WriteString("\n\n")
 
-	b.WriteString("Name: ")
-	if m.addCustomStep == AddCustomStepName {
-		b.WriteString(m.textInput.View())
-	} else {
-		b.WriteString(ui.ItemStyle.Render(m.addCustomName))
+	apps, unmapped := newScanner(cfg).ScanDotfiles(cfg.DotfilesPath)
+	results, err := sync.NewImporter(cfg).WithSandbox(root).ImportAll(apps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: sandbox restore: %v\n", err)
+		return 1
 	}
-	b.WriteString("\n")
 
-	b.WriteString("Path(s): ")
-	if m.addCustomStep == AddCustomStepPaths {
-		b.WriteString(m.textInput.View())
-	} else {
-		if m.addCustomMode == "folder" {
-			b.WriteString(ui.MutedStyle.Render("~/.my-folder"))
+	modesCfg, _ := modes.Load()
+	entries := make([]audit.Entry, 0, len(results))
+	failed := 0
+	for _, r := range results {
+		result, message := audit.ResultFor(r.Error)
+		if !r.Success && r.Error == nil {
+			result = audit.ResultSkipped
+		}
+		entries = append(entries, audit.Entry{Action: audit.ActionRestore, AppID: r.App.ID, File: r.File.RelPath,
+			DotfilesHash: r.File.DotfilesHash, Result: result, Error: message, Detail: "sandbox " + root})
+
+		if r.Success {
+			fmt.Printf("✓ %-16s %s\n", r.App.ID, sync.SandboxPath(root, r.File.Path))
 		} else {
-			b.WriteString(ui.MutedStyle.Render("~/.config/app, ~/.appconfig"))
+			failed++
+			fmt.Printf("✗ %-16s %s: %v\n", r.App.ID, r.File.RelPath, r.Error)
 		}
 	}
-	b.WriteString("\n\n")
-
-	b.WriteString(ui.MutedStyle.Render("Notes:"))
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("• Folder mode expects exactly 1 path"))
-	b.WriteString("\n")
-	b.WriteString(ui.MutedStyle.Render("• App mode supports 1 or more comma-separated paths"))
-	b.WriteString("\n\n")
-	b.WriteString(ui.HelpBarStyle.Render("Enter: next/save  •  Tab: switch mode  •  Esc: cancel"))
+	for _, id := range unmapped {
+		if sync.IsReservedDir(id) {
+			continue
+		}
+		fmt.Printf("? %-16s no definition maps it to a local path, skipped\n", id)
+	}
 
-	box := style.Render(b.String())
+	if err := newAuditLog(modesCfg).Append(entries...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit log: %v\n", err)
+	}
 
-	return lipgloss.Place(
-		m.width, m.height,
-		lipgloss.Center, lipgloss.Center,
-		box,
-	)
+	fmt.Printf("\nRestored %d/%d entries from %d apps into %s\n", len(results)-failed, len(results), len(apps), root)
+	if failed > 0 {
+		return 1
+	}
+	return 0
 }
 
-func (m *Model) handleGitKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Handle branch mode separately
-	if m.gitPanel.Mode == components.ModeBranches {
-		return m.handleGitBranchKeys(msg)
+// holdSyncLock takes the sync lock for a CLI command that writes, refusing in
+// read-only mode; the caller releases it
+func holdSyncLock(cfg *config.Config, command string) (*lock.Lock, bool) {
+	if err := cfg.CheckWritable(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, false
+	}
+	l, err := lock.Acquire(lock.Path(config.ConfigDir()), command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, false
 	}
+	return l, true
+}
 
-	switch msg.String() {
-	case "esc", "q":
-		m.screen = ScreenMain
-		m.status = "Ready"
-		return m, nil
+// runPeerPush exports the selected apps and applies them on another machine
+// over SSH. Files changed in the dotfiles store since the last sync are
+// left out like in the TUI, so a push never overwrites changes not pulled
+// yet; the remote dotsync likewise skips files modified there.
+func runPeerPush(args []string) int {
+	cfg, _ := config.Load()
 
-	case "a":
-		// Add all changes
-		if err := m.gitPanel.AddAll(); err != nil {
-			m.status = fmt.Sprintf("Add failed: %v", err)
-		} else {
-			m.status = "All changes staged"
+	fs := flag.NewFlagSet("push", flag.ContinueOnError)
+	to := fs.String("to", "", "peer to push to, e.g. user@host")
+	appsFlag := fs.String("apps", "", "comma-separated app IDs to push (required)")
+	remoteBin := fs.String("remote-bin", "", "dotsync executable on the peer (default: dotsync)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "Error: --to USER@HOST is required")
+		return 2
+	}
+	wanted := make(map[string]bool)
+	for _, id := range strings.Split(*appsFlag, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
 		}
-		return m, nil
+	}
+	if len(wanted) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --apps is required, e.g. --apps zsh,git")
+		return 2
+	}
+	l, ok := holdSyncLock(cfg, "push --to "+*to)
+	if !ok {
+		return 1
+	}
+	defer l.Release()
 
-	case "c":
-		// Open commit message dialog
-		if !m.gitPanel.HasStagedChanges() {
-			m.status = "No staged changes to commit"
-			return m, nil
-		}
-		// Reset textarea for commit message
-		m.textArea.Reset()
-		m.textArea.Placeholder = "Enter commit message..."
-		m.textArea.Focus()
-		m.screen = ScreenCommit
-		return m, textarea.Blink
+	apps, err := newScanner(cfg).Scan()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: scan failed: %v\n", err)
+		return 1
+	}
+	stateManager := sync.NewStateManager(config.ConfigDir())
+	if err := stateManager.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 
-	case "p":
-		// Push
-		if err := m.gitPanel.Push(); err != nil {
-			m.status = fmt.Sprintf("Push failed: %v", err)
-		} else {
-			m.status = "Pushed successfully"
+	var selected []*models.App
+	for _, app := range apps {
+		app.Selected = wanted[app.ID]
+		if !app.Selected {
+			continue
 		}
-		return m, nil
-
-	case "f":
-		// Fetch
-		if err := m.gitPanel.Fetch(); err != nil {
-			m.status = fmt.Sprintf("Fetch failed: %v", err)
-		} else {
-			m.status = "Fetched from remote"
+		selected = append(selected, app)
+		sync.UpdateSyncStatusWithHashes(app, cfg.DotfilesPath, stateManager)
+		for i := range app.Files {
+			f := &app.Files[i]
+			if f.Selected && (f.ConflictType == models.ConflictDotfilesModified || f.ConflictType == models.ConflictBothModified) {
+				f.Selected = false
+				fmt.Printf("⚠ %-16s %s: changed in dotfiles since the last sync, pull or resolve it first\n", app.ID, f.RelPath)
+			}
 		}
-		return m, nil
+	}
+	if len(selected) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: none of the requested apps were found")
+		return 1
+	}
 
-	case "l":
-		// Pull
-		if err := m.gitPanel.Pull(); err != nil {
-			m.status = fmt.Sprintf("Pull failed: %v", err)
-		} else {
-			m.status = "Pulled from remote"
+	// Refresh the local store so the peer receives the current configs
+	results, err := sync.NewExporter(cfg).ExportAll(selected)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: export failed: %v\n", err)
+		return 1
+	}
+	exported := make(map[*models.App]map[string]bool)
+	for _, r := range results {
+		if !r.Success {
+			if r.Error != nil {
+				fmt.Printf("✗ %-16s %s: %v\n", r.App.ID, r.File.RelPath, r.Error)
+			}
+			continue
 		}
-		return m, nil
-
-	case "r":
-		// Refresh
-		m.gitPanel.Refresh()
-		m.status = "Git status refreshed"
-		return m, nil
-
-	case "s":
-		// Stash
-		if err := m.gitPanel.Stash(); err != nil {
-			m.status = fmt.Sprintf("Stash failed: %v", err)
-		} else {
-			m.status = "Changes stashed"
+		recordSyncState(cfg, stateManager, "push", r)
+		if exported[r.App] == nil {
+			exported[r.App] = make(map[string]bool)
 		}
-		return m, nil
+		exported[r.App][r.File.RelPath] = true
+	}
+	if err := stateManager.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: save sync state: %v\n", err)
+		return 1
+	}
 
-	case "S":
-		// Stash pop
-		if err := m.gitPanel.StashPop(); err != nil {
-			m.status = fmt.Sprintf("Stash pop failed: %v", err)
-		} else {
-			m.status = "Stash popped"
+	// Only what was just exported goes to the peer
+	var appIDs []string
+	for _, app := range selected {
+		for i := range app.Files {
+			app.Files[i].Selected = app.Files[i].Selected && exported[app][app.Files[i].RelPath]
 		}
-		return m, nil
-
-	case "b":
-		// Toggle branch mode
-		m.gitPanel.ToggleBranchMode()
-		if m.gitPanel.Mode == components.ModeBranches {
-			m.status = "Select branch to checkout"
-		} else {
-			m.status = "Git status"
+		if len(exported[app]) > 0 {
+			appIDs = append(appIDs, app.ID)
 		}
-		return m, nil
+	}
+	if len(appIDs) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: nothing to push")
+		return 1
+	}
 
-	case "L":
-		// Open lazygit
-		return m.handleLazygit()
+	fmt.Printf("Pushing %d apps to %s...\n", len(appIDs), *to)
+	result, err := peer.New(*to).WithBinary(*remoteBin).Push(cfg.DotfilesPath, selected)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
 
-	case "j", "down":
-		m.gitPanel.MoveDown()
-		return m, nil
+	for _, ev := range result.Applied {
+		fmt.Printf("✓ %-16s %s\n", ev.AppID, ev.File)
+	}
+	for _, ev := range result.Conflicts {
+		fmt.Printf("⚠ %-16s %s: modified on %s, skipped\n", ev.AppID, ev.File, *to)
+	}
+	for _, ev := range result.Failed {
+		fmt.Printf("✗ %-16s %s: %s\n", ev.AppID, ev.File, ev.Message)
+	}
+	fmt.Printf("\nApplied %d files • %d conflicts • %d failed\n", len(result.Applied), len(result.Conflicts), len(result.Failed))
 
-	case "k", "up":
-		m.gitPanel.MoveUp()
-		return m, nil
+	if len(result.Failed) > 0 {
+		return 1
 	}
+	return 0
+}
+
+// runFileCommand handles the single-file commands used by editor plugins.
+// With --porcelain the result is printed as one JSON object.
+func runFileCommand(name string, args []string) int {
+	cfg, _ := config.Load()
 
-	return m, nil
-}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	jsonOut := fs.Bool("porcelain", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	// Allow flags after the path, e.g. `diff-file init.lua --porcelain`
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: dotsync %s PATH [--porcelain]\n", name)
+		return 2
+	}
+	path := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return 2
+	}
 
-// handleGitBranchKeys handles keys in branch selection mode
-func (m *Model) handleGitBranchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "esc", "q", "b":
-		// Exit branch mode
-		m.gitPanel.Mode = components.ModeStatus
-		m.status = "Git status"
-		return m, nil
+	fail := func(err error) int {
+		if *jsonOut {
+			_ = json.NewEncoder(os.Stdout).Encode(porcelain.Event{Type: porcelain.EventError, Command: name, Message: err.Error()})
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return 1
+	}
 
-	case "j", "down":
-		m.gitPanel.MoveBranchDown()
-		return m, nil
+	stateManager := sync.NewStateManager(config.ConfigDir())
+	_ = stateManager.Load()
 
-	case "k", "up":
-		m.gitPanel.MoveBranchUp()
-		return m, nil
+	file, err := porcelain.ResolveFile(cfg, newScanner(cfg), stateManager, path)
+	if err != nil {
+		return fail(err)
+	}
 
-	case "enter":
-		// Checkout selected branch
-		branch := m.gitPanel.GetSelectedBranch()
-		if branch == "" {
-			m.status = "No branch selected"
-			return m, nil
+	var out interface{} = file
+	switch name {
+	case "push-file":
+		l, ok := holdSyncLock(cfg, name)
+		if !ok {
+			return 1
 		}
-		if err := m.gitPanel.CheckoutBranch(); err != nil {
-			m.status = fmt.Sprintf("Checkout failed: %v", err)
-		} else {
-			m.status = fmt.Sprintf("Switched to branch: %s", branch)
+		defer l.Release()
+		err := porcelain.PushFile(cfg, stateManager, file)
+		result, message := audit.ResultFor(err)
+		modesCfg, _ := modes.Load()
+		_ = newAuditLog(modesCfg).Append(audit.Entry{Action: audit.ActionPush, AppID: file.AppID, File: file.RelPath,
+			Result: result, Error: message, Detail: name})
+		if err != nil {
+			return fail(err)
 		}
-		return m, nil
+	case "diff-file":
+		diff, err := porcelain.DiffFile(file)
+		if err != nil {
+			return fail(err)
+		}
+		out = diff
 	}
 
-	return m, nil
+	if *jsonOut {
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			return 1
+		}
+		return 0
+	}
+
+	switch v := out.(type) {
+	case *porcelain.DiffReport:
+		fmt.Printf("--- %s\n+++ %s\n", v.Path, v.DotfilesPath)
+		for _, h := range v.Hunks {
+			fmt.Printf("@@ -%d +%d @@\n", h.StartOld, h.StartNew)
+			for _, line := range h.Lines {
+				fmt.Println(line)
+			}
+		}
+	case *porcelain.FileReport:
+		fmt.Printf("%s/%s: %s (%s)\n", v.AppID, v.RelPath, v.Status, v.Conflict)
+	}
+	return 0
 }
 
-// handleLazygit opens lazygit in the dotfiles directory
-func (m *Model) handleLazygit() (tea.Model, tea.Cmd) {
-	lazygitPath, err := exec.LookPath("lazygit")
+// runLog prints the audit log of sync operations, newest last
+func runLog(args []string) int {
+	fs := flag.NewFlagSet("log", flag.ContinueOnError)
+	action := fs.String("action", "", "only this action (push, pull, backup, merge, resolve, restore, delete)")
+	app := fs.String("app", "", "only this app ID")
+	file := fs.String("file", "", "only files whose path contains this")
+	since := fs.String("since", "", "only entries newer than this (12h, 7d or 2006-01-02)")
+	failed := fs.Bool("failed", false, "only failed or conflicted operations")
+	limit := fs.Int("limit", 50, "show at most this many entries (0 = all)")
+	jsonOut := fs.Bool("json", false, "print one JSON entry per line")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cutoff, err := audit.ParseSince(*since, time.Now())
 	if err != nil {
-		m.status = "lazygit not found — install: brew install lazygit"
-		return m, nil
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
 	}
 
-	c := exec.Command(lazygitPath, "-p", m.config.DotfilesPath)
-	return m, tea.ExecProcess(c, func(err error) tea.Msg {
-		return lazygitFinishedMsg{err: err}
+	entries, err := audit.New(audit.DefaultPath()).Read(audit.Filter{
+		Action: *action,
+		AppID:  *app,
+		File:   *file,
+		Since:  cutoff,
+		Failed: *failed,
+		Limit:  *limit,
 	})
-}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: read audit log: %v\n", err)
+		return 1
+	}
 
-// handleCommitKeys handles keys in the commit message dialog
-func (m *Model) handleCommitKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.Type {
-	case tea.KeyEsc:
-		// Cancel commit
-		m.screen = ScreenGit
-		m.textArea.Blur()
-		m.status = "Commit cancelled"
-		return m, nil
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return 1
+			}
+		}
+		return 0
+	}
 
-	case tea.KeyCtrlS:
-		// Ctrl+S to commit (since Enter is used for newline in textarea)
-		message := strings.TrimSpace(m.textArea.Value())
-		if message == "" {
-			m.status = "Commit message cannot be empty"
-			return m, nil
+	for _, e := range entries {
+		target := e.AppID
+		if e.File != "" {
+			target += "/" + e.File
 		}
-		if err := m.gitPanel.Commit(message); err != nil {
-			m.status = fmt.Sprintf("Commit failed: %v", err)
-		} else {
-			m.status = "Committed! Press 'p' to push to remote"
-			// Show a prompt to push after successful commit
-			m.gitPanel.Refresh()
+		note := e.Detail
+		if e.Error != "" {
+			note = e.Error
 		}
-		m.textArea.Blur()
-		m.textArea.Reset()
-		m.screen = ScreenGit
-		return m, nil
+		fmt.Printf("%s  %-8s %-8s %-12s %s  %s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05"), e.Action, e.Result, e.Machine, target, note)
 	}
-
-	// Pass other keys to textarea
-	var cmd tea.Cmd
-	m.textArea, cmd = m.textArea.Update(msg)
-	return m, cmd
+	if len(entries) == 0 {
+		fmt.Println("No logged operations")
+	}
+	return 0
 }
 
-// renderCommitDialog renders the commit message input dialog
-func (m *Model) renderCommitDialog() string {
-	var b strings.Builder
+// runChangelog prints or writes a markdown summary of what changed in the
+// dotfiles repo between two dates or commits, e.g. weekly from cron with
+// `dotsync changelog --out ~/notes/dotfiles-$(date +%F).md`
+func runChangelog(args []string) int {
+	cfg, _ := config.Load()
 
-	// Header
-	header := m.renderHeader()
-	b.WriteString(header)
-	b.WriteString("\n\n")
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	from := fs.String("from", "7d", "start: date (2006-01-02), age (12h, 7d) or git revision")
+	to := fs.String("to", "HEAD", "end: date, age or git revision")
+	out := fs.String("out", "", "write the markdown to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
-	// Dialog box
-	width := 60
-	style := lipgloss.NewStyle().
-		Width(width).
-		Padding(1, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(ui.Primary)
+	c, err := changelog.Build(cfg.DotfilesPath, *from, *to, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		fmt.Print(c.Markdown())
+		return 0
+	}
+	if err := c.WriteFile(*out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: write changelog: %v\n", err)
+		return 1
+	}
+	return 0
+}
 
-	var content strings.Builder
-	content.WriteString(ui.PanelTitleStyle.Render("📝 Commit Changes"))
-	content.WriteString("\n\n")
+// runMetrics scans once, writes the status file and prints it, or with
+// --listen serves it over HTTP (/metrics, /status.json), rescanning at most
+// once per interval
+func runMetrics(args []string) int {
+	cfg, _ := config.Load()
 
-	// Show staged files count
-	stagedCount := 0
-	if m.gitPanel.Status != nil {
-		stagedCount = len(m.gitPanel.Status.Staged)
+	fs := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	format := fs.String("format", "json", "output format: json or prometheus")
+	listen := fs.String("listen", "", "serve over HTTP on this address, e.g. 127.0.0.1:9105")
+	interval := fs.Duration("interval", time.Minute, "minimum time between rescans when serving")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "json" && *format != "prometheus" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use json or prometheus)\n", *format)
+		return 2
 	}
-	content.WriteString(fmt.Sprintf("Files to commit: %d\n\n", stagedCount))
 
-	// Input field - using textarea for multi-line messages
-	content.WriteString("Commit message:\n")
-	content.WriteString(m.textArea.View())
-	content.WriteString("\n\n")
+	machine := ""
+	if modesCfg, err := modes.Load(); err == nil {
+		machine = modesCfg.MachineName
+	}
+	build := func() (*metrics.Status, error) {
+		stateManager := sync.NewStateManager(config.ConfigDir())
+		_ = stateManager.Load()
+		apps, err := newScanner(cfg).Scan()
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range apps {
+			sync.UpdateSyncStatusWithHashes(app, cfg.DotfilesPath, stateManager)
+		}
+		status := metrics.Build(machine, apps, stateManager, time.Now())
+		if err := status.Write(metrics.DefaultPath()); err != nil {
+			return nil, fmt.Errorf("write status file: %w", err)
+		}
+		return status, nil
+	}
 
-	// Help text
-	content.WriteString(ui.MutedStyle.Render("Ctrl+S to commit • ESC to cancel"))
+	if *listen != "" {
+		cache := metrics.NewCache(build, *interval)
+		fmt.Fprintf(os.Stderr, "Serving dotsync metrics on http://%s/metrics\n", *listen)
+		if err := http.ListenAndServe(*listen, metrics.Handler(cache.Get)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
 
-	box := style.Render(content.String())
+	status, err := build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *format == "prometheus" {
+		fmt.Print(status.Prometheus())
+		return 0
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(status); err != nil {
+		return 1
+	}
+	return 0
+}
 
-	// Center the box
-	b.WriteString(box)
+// runStatus prints the drift summary kept in the status file by the TUI
+// and `dotsync metrics`, without scanning, so menu bars can poll it cheaply
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	short := fs.Bool("short", false, "one-line glyph summary for xbar/SketchyBar")
+	waybar := fs.Bool("waybar", false, "JSON for a waybar custom module")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
-	return ui.AppStyle.Render(b.String())
+	// A missing file leaves status nil; bars show a placeholder
+	status, err := metrics.Load(metrics.DefaultPath())
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case *waybar:
+		if status == nil {
+			fmt.Println(`{"text":"? dotsync","tooltip":"No status yet - run dotsync metrics","class":"unknown"}`)
+			return 0
+		}
+		data, err := status.Waybar()
+		if err != nil {
+			return 1
+		}
+		fmt.Println(string(data))
+	case *short:
+		if status == nil {
+			fmt.Println("? dotsync")
+			return 0
+		}
+		fmt.Println(status.Short())
+	case status == nil:
+		fmt.Println("No status yet - open dotsync or run `dotsync metrics` once")
+	default:
+		fmt.Println(status.Short())
+		fmt.Println(status.Tooltip())
+	}
+	return 0
 }
 
-// handleSearchKeys handles key input in search mode
-func (m *Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.Type {
-	case tea.KeyEsc:
-		// Cancel search, restore original app list
-		m.searchMode = false
-		m.searchQuery = ""
-		m.textInput.Blur()
-		m.appList.SetApps(m.apps)
-		m.filteredApps = nil
-		m.status = "Search cancelled"
-		m.updateFileList()
-		return m, nil
+// runRegistry fetches the community app-definition catalog, verifies its
+// signature and reports the apps it newly supports
+func runRegistry(args []string) int {
+	cfg, _ := config.Load()
 
-	case tea.KeyEnter:
-		// Confirm search
-		m.searchMode = false
-		m.textInput.Blur()
-		if m.searchQuery == "" {
-			m.appList.SetApps(m.apps)
-			m.filteredApps = nil
-			m.status = fmt.Sprintf("Showing all %d apps", len(m.apps))
-		} else {
-			m.status = fmt.Sprintf("Showing %d matching apps", len(m.filteredApps))
+	fs := flag.NewFlagSet("registry", flag.ContinueOnError)
+	url := fs.String("url", cfg.RegistryURL, "catalog URL (signature at URL.sig)")
+	key := fs.String("key", cfg.RegistryKey, "base64 ed25519 public key")
+	dryRun := fs.Bool("dry-run", false, "verify and report without saving")
+	if len(args) == 0 || args[0] != "update" {
+		fmt.Fprintln(os.Stderr, "Usage: dotsync registry update [--url URL] [--key KEY] [--dry-run]")
+		return 2
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if !*dryRun {
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
 		}
-		m.updateFileList()
-		return m, nil
-
-	case tea.KeyBackspace, tea.KeyDelete:
-		// Handle backspace in textinput
-		var cmd tea.Cmd
-		m.textInput, cmd = m.textInput.Update(msg)
-		m.searchQuery = m.textInput.Value()
-		m.filterApps()
-		return m, cmd
+	}
 
-	case tea.KeyUp:
-		// Navigate up in filtered results
-		m.appList.MoveUp()
-		m.updateFileList()
-		return m, nil
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	data, err := registry.NewFetcher(*url, *key).Fetch(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	catalog, err := registry.Parse(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid catalog: %v\n", err)
+		return 1
+	}
 
-	case tea.KeyDown:
-		// Navigate down in filtered results
-		m.appList.MoveDown()
-		m.updateFileList()
-		return m, nil
+	s := scanner.New(cfg.AppsConfig)
+	previous, _ := registry.Load(config.ConfigDir())
+	update := registry.Diff(catalog, append(s.BuiltinDefinitions(), previous...), s.CustomDefinitions())
+	if !*dryRun {
+		if err := registry.Save(config.ConfigDir(), data); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
 
-	default:
-		// Handle regular typing
-		var cmd tea.Cmd
-		m.textInput, cmd = m.textInput.Update(msg)
-		m.searchQuery = m.textInput.Value()
-		m.filterApps()
-		return m, cmd
+	fmt.Printf("Catalog verified: %d apps, %d new, %d changed\n", len(update.Apps), len(update.Added), len(update.Changed))
+	for _, def := range update.Added {
+		fmt.Printf("  + %-20s %s\n", def.ID, def.Name)
+	}
+	for _, def := range update.Changed {
+		fmt.Printf("  ~ %-20s %s\n", def.ID, strings.Join(def.ConfigPaths, ", "))
 	}
+	if len(update.Overridden) > 0 {
+		fmt.Printf("Kept your apps.yaml definitions for: %s\n", strings.Join(update.Overridden, ", "))
+	}
+	if *dryRun {
+		fmt.Println("Dry run - catalog not saved")
+	}
+	return 0
 }
 
-// filterApps filters the app list based on search query
-func (m *Model) filterApps() {
-	if m.searchQuery == "" {
-		m.appList.SetApps(m.apps)
-		m.filteredApps = nil
-		m.status = fmt.Sprintf("Type to search (%d apps)", len(m.apps))
-		return
+// runPlugins lists the installed plugins and, with --detect, the apps each
+// one contributes
+func runPlugins(args []string) int {
+	fs := flag.NewFlagSet("plugins", flag.ContinueOnError)
+	detect := fs.Bool("detect", false, "run each plugin's detect hook and list its apps")
+	if err := fs.Parse(args); err != nil {
+		return 2
 	}
 
-	query := strings.ToLower(m.searchQuery)
-	var filtered []*models.App
-
-	for _, app := range m.apps {
-		// Match against app name, ID, or category
-		nameLower := strings.ToLower(app.Name)
-		idLower := strings.ToLower(app.ID)
-		categoryLower := strings.ToLower(app.Category)
-
-		if strings.Contains(nameLower, query) ||
-			strings.Contains(idLower, query) ||
-			strings.Contains(categoryLower, query) {
-			filtered = append(filtered, app)
+	runner, err := plugin.NewRunner(config.ConfigDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(runner.Plugins) == 0 {
+		fmt.Printf("No plugins installed - add executables to %s\n", plugin.Dir(config.ConfigDir()))
+		return 0
+	}
+	if !*detect {
+		for _, p := range runner.Plugins {
+			fmt.Printf("%-20s %s\n", p.Name, p.Path)
 		}
+		return 0
 	}
 
-	m.filteredApps = filtered
-	m.appList.SetApps(filtered)
-	m.status = fmt.Sprintf("Found %d apps matching '%s'", len(filtered), m.searchQuery)
+	failed := false
+	for _, p := range runner.Plugins {
+		resp, err := runner.Call(context.Background(), p, plugin.Request{Hook: plugin.HookDetect})
+		if err != nil {
+			fmt.Printf("%-20s error: %v\n", p.Name, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%-20s %d apps\n", p.Name, len(resp.Apps))
+		for _, a := range resp.Apps {
+			fmt.Printf("  %-18s %s\n", a.ID, strings.Join(a.ConfigPaths, ", "))
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
 }
 
-// filterByCategory filters apps by category
-func (m *Model) filterByCategory(category string) (tea.Model, tea.Cmd) {
-	if m.categoryFilter == category {
-		// Toggle off if same category
-		return m.clearCategoryFilter()
-	}
+// runShell manages shell rc blocks shared through the dotfiles repo:
+// list, select/unselect, push (rc -> dotfiles), pull (dotfiles -> include)
+// and split (machine-specific lines -> rc.local)
+func runShell(args []string) int {
+	cfg, _ := config.Load()
 
-	m.categoryFilter = category
-	var filtered []*models.App
+	fs := flag.NewFlagSet("shell", flag.ContinueOnError)
+	rcFlag := fs.String("rc", "", "rc file to use (default: ~/.zshrc and ~/.bashrc)")
+	dryRun := fs.Bool("dry-run", false, "split: show what would move without writing")
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: dotsync shell list|select ID...|unselect ID...|push|pull|split [--rc PATH] [--dry-run]")
+		return 2
+	}
+	cmd := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
 
-	for _, app := range m.apps {
-		if strings.ToLower(app.Category) == category {
-			filtered = append(filtered, app)
+	rcFiles := []string{*rcFlag}
+	if *rcFlag == "" {
+		home, _ := os.UserHomeDir()
+		rcFiles = shellrc.DefaultRCFiles(home)
+	}
+	var local []shellrc.Block
+	for _, rc := range rcFiles {
+		blocks, err := shellrc.ParseFile(rc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: read %s: %v\n", rc, err)
+			return 1
 		}
+		local = append(local, blocks...)
 	}
 
-	m.filteredApps = filtered
-	m.appList.SetApps(filtered)
-	m.updateFileList()
-
-	categoryLabels := map[string]string{
-		"ai":           "AI Tools",
-		"shell":        "Shells",
-		"editor":       "Editors",
-		"terminal":     "Terminals",
-		"git":          "Git Tools",
-		"dev":          "Dev Tools",
-		"cli":          "CLI Tools",
-		"productivity": "Productivity",
-		"cloud":        "Cloud/Infra",
+	sharedPath := shellrc.SharedPath(cfg.DotfilesPath)
+	shared, err := shellrc.ParseFile(sharedPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: read %s: %v\n", sharedPath, err)
+		return 1
 	}
 
-	label := categoryLabels[category]
-	if label == "" {
-		label = category
+	selected := make(map[string]bool)
+	for _, id := range cfg.ShellBlocks {
+		selected[id] = true
 	}
-	m.status = fmt.Sprintf("Filtered: %s (%d apps) • Press 0 to clear", label, len(filtered))
-	return m, nil
-}
 
-// clearCategoryFilter clears the category filter
-func (m *Model) clearCategoryFilter() (tea.Model, tea.Cmd) {
-	m.categoryFilter = ""
-	m.searchQuery = ""
-	m.filteredApps = nil
-	m.appList.SetApps(m.apps)
-	m.updateFileList()
-	m.status = fmt.Sprintf("Showing all %d apps", len(m.apps))
-	return m, nil
-}
+	switch cmd {
+	case "list":
+		seen := make(map[string]bool)
+		for _, b := range append(local, shared...) {
+			if seen[b.ID()] {
+				continue
+			}
+			seen[b.ID()] = true
+			mark := "[ ]"
+			if selected[b.ID()] {
+				mark = "[x]"
+			}
+			where := "dotfiles only"
+			if shellrc.Find(local, b.ID()) != nil {
+				where = fmt.Sprintf("line %d", b.Line)
+			}
+			fmt.Printf("%s %-40s %s\n", mark, b.ID(), where)
+		}
+		if len(seen) == 0 {
+			fmt.Println("No aliases, exports, functions or PATH entries found")
+		}
+		return 0
 
-// clearAllFilters clears both search and category filters
-func (m *Model) clearAllFilters() (tea.Model, tea.Cmd) {
-	return m.clearCategoryFilter()
-}
+	case "select", "unselect":
+		for _, id := range fs.Args() {
+			if shellrc.Find(local, id) == nil && shellrc.Find(shared, id) == nil {
+				fmt.Fprintf(os.Stderr, "Warning: no block %s in rc files or dotfiles\n", id)
+			}
+			selected[id] = cmd == "select"
+		}
+		cfg.ShellBlocks = cfg.ShellBlocks[:0]
+		for id, on := range selected {
+			if on {
+				cfg.ShellBlocks = append(cfg.ShellBlocks, id)
+			}
+		}
+		sort.Strings(cfg.ShellBlocks)
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: save config: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✓ %d blocks selected\n", len(cfg.ShellBlocks))
+		return 0
 
-// handleSelectModified selects all apps/files with modifications
-func (m *Model) handleSelectModified() (tea.Model, tea.Cmd) {
-	m.saveSelectionState() // Save before changing
-	modifiedCount := 0
+	case "push":
+		l, ok := holdSyncLock(cfg, "shell push")
+		if !ok {
+			return 1
+		}
+		defer l.Release()
+		blocks := shellrc.Select(local, cfg.ShellBlocks)
+		for _, id := range cfg.ShellBlocks {
+			if shellrc.Find(blocks, id) == nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s is not defined in the rc files, skipped\n", id)
+			}
+		}
+		if err := shellrc.WriteFile(sharedPath, shellrc.Merge(shared, blocks)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: write %s: %v\n", sharedPath, err)
+			return 1
+		}
+		fmt.Printf("✓ Pushed %d blocks to %s\n", len(blocks), sharedPath)
+		return 0
 
-	if m.focusedPanel == PanelApps {
-		// Select all apps that have modified or conflicting files
-		for _, app := range m.apps {
-			hasModified := false
-			for _, file := range app.Files {
-				switch file.ConflictType {
-				case models.ConflictLocalModified, models.ConflictLocalNew,
-					models.ConflictDotfilesModified, models.ConflictDotfilesNew,
-					models.ConflictBothModified:
-					hasModified = true
-					break
-				}
-				if hasModified {
-					break
-				}
+	case "pull":
+		l, ok := holdSyncLock(cfg, "shell pull")
+		if !ok {
+			return 1
+		}
+		defer l.Release()
+		blocks := shellrc.Select(shared, cfg.ShellBlocks)
+		includePath := shellrc.IncludePath(config.ConfigDir())
+		if err := shellrc.WriteFile(includePath, blocks); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: write %s: %v\n", includePath, err)
+			return 1
+		}
+		for _, rc := range rcFiles {
+			data, err := os.ReadFile(rc)
+			if err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Error: read %s: %v\n", rc, err)
+				return 1
 			}
-			if hasModified {
-				app.Selected = true
-				modifiedCount++
+			updated := shellrc.EnsureSource(string(data), includePath)
+			if updated == string(data) {
+				continue
+			}
+			if _, err := sync.Backup(rc, cfg.BackupPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: backup %s: %v\n", rc, err)
+				return 1
 			}
+			if err := os.WriteFile(rc, []byte(updated), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: write %s: %v\n", rc, err)
+				return 1
+			}
+			fmt.Printf("✓ %s now sources %s\n", rc, includePath)
 		}
-		m.appList.SetApps(m.apps)
-		m.status = fmt.Sprintf("Selected %d apps with modifications", modifiedCount)
-	} else {
-		// Select all files that have modifications in current file list
-		for i := range m.fileList.Files {
-			switch m.fileList.Files[i].ConflictType {
-			case models.ConflictLocalModified, models.ConflictLocalNew,
-				models.ConflictDotfilesModified, models.ConflictDotfilesNew,
-				models.ConflictBothModified:
-				m.fileList.Files[i].Selected = true
-				modifiedCount++
+		fmt.Printf("✓ Pulled %d blocks into %s\n", len(blocks), includePath)
+		return 0
+
+	case "split":
+		if !*dryRun {
+			l, ok := holdSyncLock(cfg, "shell split")
+			if !ok {
+				return 1
 			}
+			defer l.Release()
 		}
-		m.syncFilesToApp()
-		m.status = fmt.Sprintf("Selected %d modified files", modifiedCount)
+		for _, rc := range rcFiles {
+			data, err := os.ReadFile(rc)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: read %s: %v\n", rc, err)
+				return 1
+			}
+			plan := shellrc.Split(string(data), rc)
+			if len(plan.Moved) == 0 {
+				fmt.Printf("✓ %s has nothing machine-specific\n", rc)
+				continue
+			}
+			fmt.Printf("%s → %s:\n", rc, shellrc.LocalPath(rc))
+			for _, m := range plan.Moved {
+				first, _, _ := strings.Cut(m.Text, "\n")
+				fmt.Printf("  line %-5d %-26s %s\n", m.Line, m.Reason, first)
+			}
+			if *dryRun {
+				continue
+			}
+			if _, err := sync.Backup(rc, cfg.BackupPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: backup %s: %v\n", rc, err)
+				return 1
+			}
+			if err := shellrc.WriteSplit(rc, plan); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: split %s: %v\n", rc, err)
+				return 1
+			}
+			fmt.Printf("✓ Moved %d pieces; %s now sources %s\n", len(plan.Moved), rc, shellrc.LocalPath(rc))
+		}
+		return 0
 	}
 
-	return m, nil
+	fmt.Fprintf(os.Stderr, "Error: unknown shell command %q\n", cmd)
+	return 2
 }
 
-// handleSelectOutdated selects all apps/files that need to be pulled (outdated)
-func (m *Model) handleSelectOutdated() (tea.Model, tea.Cmd) {
-	m.saveSelectionState() // Save before changing
-	outdatedCount := 0
+// runSSH lists the Host blocks of ~/.ssh/config and marks them shared or
+// machine-only. Machine-only blocks are never pushed and survive pulls.
+func runSSH(args []string) int {
+	cfg, _ := config.Load()
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: dotsync ssh list|local HOST...|share HOST...")
+		return 2
+	}
 
-	if m.focusedPanel == PanelApps {
-		// Select all apps that have outdated files (need pull)
-		for _, app := range m.apps {
-			hasOutdated := false
-			for _, file := range app.Files {
-				switch file.ConflictType {
-				case models.ConflictDotfilesModified, models.ConflictDotfilesNew:
-					hasOutdated = true
-					break
-				}
-				if hasOutdated {
-					break
-				}
-			}
-			if hasOutdated {
-				app.Selected = true
-				outdatedCount++
+	home, _ := os.UserHomeDir()
+	data, err := os.ReadFile(filepath.Join(home, ".ssh", "config"))
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: read ssh config: %v\n", err)
+		return 1
+	}
+	local := sshconfig.Parse(string(data))
+
+	switch args[0] {
+	case "list":
+		for _, b := range local.Blocks {
+			scope := "shared"
+			if sshconfig.MachineOnly(b, cfg.SSHLocalHosts) {
+				scope = "machine-only"
 			}
+			fmt.Printf("%-13s %s %s\n", scope, b.Keyword, b.Name())
 		}
-		m.appList.SetApps(m.apps)
-		m.status = fmt.Sprintf("Selected %d apps with outdated files (need pull)", outdatedCount)
-	} else {
-		// Select all files that are outdated in current file list
-		for i := range m.fileList.Files {
-			switch m.fileList.Files[i].ConflictType {
-			case models.ConflictDotfilesModified, models.ConflictDotfilesNew:
-				m.fileList.Files[i].Selected = true
-				outdatedCount++
+		if len(local.Blocks) == 0 {
+			fmt.Println("No Host or Match blocks in ~/.ssh/config")
+		}
+		return 0
+
+	case "local", "share":
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, host := range args[1:] {
+			cfg.SSHLocalHosts = slices.DeleteFunc(cfg.SSHLocalHosts, func(h string) bool { return h == host })
+			if args[0] == "local" {
+				cfg.SSHLocalHosts = append(cfg.SSHLocalHosts, host)
 			}
 		}
-		m.syncFilesToApp()
-		m.status = fmt.Sprintf("Selected %d outdated files (need pull)", outdatedCount)
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: save config: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✓ %d machine-only host patterns\n", len(cfg.SSHLocalHosts))
+		return 0
 	}
 
-	return m, nil
+	fmt.Fprintf(os.Stderr, "Error: unknown ssh command %q\n", args[0])
+	return 2
 }
 
-// handleRefresh refreshes the current view by rescanning
-func (m *Model) handleRefresh() (tea.Model, tea.Cmd) {
-	// If a category filter is active, preserve it after refresh
-	savedFilter := m.categoryFilter
+// runLayout shows or sets how the dotfiles repo is organized. The layout
+// is saved in the repo, so it applies on every machine syncing it.
+func runLayout(args []string) int {
+	cfg, _ := config.Load()
+	current, err := layout.Load(cfg.DotfilesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(args) == 0 {
+		fmt.Println(current.Name())
+		return 0
+	}
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "Usage: dotsync layout [%s]\n", strings.Join(layout.Names(), "|"))
+		return 2
+	}
 
-	m.screen = ScreenScanning
-	m.status = "Refreshing..."
+	l, err := layout.New(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if err := cfg.CheckWritable(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := layout.Save(cfg.DotfilesPath, l); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: save layout: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✓ Layout set to %s\n", l.Name())
+	if l.Name() != current.Name() && !onboarding.IsEmpty(cfg.DotfilesPath) {
+		fmt.Printf("  Push again to store files in the %s layout; copies in the %s layout stay until removed\n", l.Name(), current.Name())
+	}
+	return 0
+}
 
-	// Create a wrapped scan function that restores filter after scan
-	return m, func() tea.Msg {
-		s := scanner.New(m.config.AppsConfig)
-		apps, err := s.Scan()
+// runShare writes the stored configs of the chosen apps to the public repo,
+// sanitized, for sharing. The private dotfiles repo keeps the full files.
+func runShare(args []string) int {
+	cfg, _ := config.Load()
 
-		for _, app := range apps {
-			sync.UpdateSyncStatusWithHashes(app, m.config.DotfilesPath, m.stateManager)
+	fs := flag.NewFlagSet("share", flag.ContinueOnError)
+	to := fs.String("to", cfg.PublicRepo, "public repo to write to (default: public_repo)")
+	appsFlag := fs.String("apps", "", "comma-separated app IDs (default: every app with stored configs)")
+	dryRun := fs.Bool("dry-run", false, "only list what would be shared and redacted")
+	commit := fs.Bool("commit", false, "commit the public repo and push it if it has a remote")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "Error: set public_repo or pass --to PATH")
+		return 2
+	}
+	public := *to
+	if strings.HasPrefix(public, "~/") {
+		homeDir, _ := os.UserHomeDir()
+		public = filepath.Join(homeDir, public[2:])
+	}
+	if filepath.Clean(public) == filepath.Clean(cfg.DotfilesPath) {
+		fmt.Fprintln(os.Stderr, "Error: the public repo must not be the dotfiles repo")
+		return 2
+	}
+	if !*dryRun {
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
 		}
+	}
 
-		// Restore category filter state in the message
-		return refreshCompleteMsg{
-			apps:           apps,
-			err:            err,
-			categoryFilter: savedFilter,
+	apps, err := newScanner(cfg).Scan()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: scan failed: %v\n", err)
+		return 1
+	}
+	wanted := make(map[string]bool)
+	for _, id := range strings.Split(*appsFlag, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
 		}
 	}
-}
-
-// saveSelectionState saves the current selection state for undo
-func (m *Model) saveSelectionState() {
-	m.lastAppSelections = make(map[string]bool)
-	m.lastFileSelections = make(map[string]bool)
 
-	for _, app := range m.apps {
-		m.lastAppSelections[app.ID] = app.Selected
+	var entries []share.Entry
+	for _, app := range apps {
+		if len(wanted) > 0 && !wanted[app.ID] {
+			continue
+		}
 		for _, file := range app.Files {
-			m.lastFileSelections[file.Path] = file.Selected
+			// Files marked for encryption never go public
+			if file.Excluded || file.Encrypted {
+				continue
+			}
+			stored := sync.DotfilePath(cfg.DotfilesPath, app.ID, file)
+			rel, err := filepath.Rel(cfg.DotfilesPath, stored)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			if _, err := os.Stat(stored); err == nil {
+				entries = append(entries, share.Entry{AppID: app.ID, Path: rel})
+			}
 		}
 	}
-	m.canUndo = true
-}
-
-// handleUndo restores the previous selection state
-func (m *Model) handleUndo() (tea.Model, tea.Cmd) {
-	if !m.canUndo || m.lastAppSelections == nil {
-		m.status = "Nothing to undo"
-		return m, nil
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no stored configs to share; push them to dotfiles first")
+		return 1
 	}
 
-	// Restore app selections
-	for _, app := range m.apps {
-		if selected, ok := m.lastAppSelections[app.ID]; ok {
-			app.Selected = selected
-		}
-		// Restore file selections
-		for i := range app.Files {
-			if selected, ok := m.lastFileSelections[app.Files[i].Path]; ok {
-				app.Files[i].Selected = selected
+	files := share.Export(cfg.DotfilesPath, public, entries, *dryRun)
+	var written, redacted, failed int
+	for _, f := range files {
+		switch {
+		case f.Err != nil:
+			failed++
+			fmt.Printf("✗ %-16s %s: %v\n", f.AppID, f.Path, f.Err)
+		case f.Skipped != "":
+			fmt.Printf("- %-16s %s: skipped, %s\n", f.AppID, f.Path, f.Skipped)
+		default:
+			written++
+			secretCount, emails := f.Count()
+			note := ""
+			if secretCount > 0 || emails > 0 {
+				redacted++
+				note = fmt.Sprintf(" (%d secrets, %d emails redacted)", secretCount, emails)
 			}
+			fmt.Printf("✓ %-16s %s%s\n", f.AppID, f.Path, note)
 		}
 	}
+	verb := "Shared"
+	if *dryRun {
+		verb = "Would share"
+	}
+	fmt.Printf("\n%s %d files to %s • %d redacted • %d failed\n", verb, written, public, redacted, failed)
 
-	m.appList.SetApps(m.apps)
-	m.updateFileList()
-	m.canUndo = false
-	m.status = "Selection restored"
-	return m, nil
+	if *commit && !*dryRun && written > 0 {
+		backend, err := remote.New(remote.KindGit, public, "")
+		if err == nil {
+			err = backend.Push(fmt.Sprintf("share: update %d files", written))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println("✓ Committed the public repo")
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
 }
 
-// handleQuickSync runs the Quick Sync workflow
-func (m *Model) handleQuickSync() (tea.Model, tea.Cmd) {
-	if m.quickSync == nil {
-		m.status = "Quick backup not initialized"
-		return m, nil
+// runBranches manages the branch-per-machine workflow: status lists how
+// far each machine branch is from main, merge publishes this machine's
+// branch into main and update brings main's changes onto it
+func runBranches(args []string) int {
+	cfg, _ := config.Load()
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
 	}
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: dotsync branches [status|enable|disable|merge|update]")
+		return 2
+	}
+	if !cfg.IsGitRepo() {
+		fmt.Fprintf(os.Stderr, "Error: %s is not a git repository\n", cfg.DotfilesPath)
+		return 1
+	}
+	modesCfg, _ := modes.Load()
 
-	selectedApps := m.appList.SelectedApps()
-	if len(selectedApps) == 0 {
-		m.status = "No apps selected"
-		return m, nil
+	if command != "status" {
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
 	}
 
-	m.status = "Running quick backup..."
-	m.syncing = true
+	switch command {
+	case "enable", "disable":
+		cfg.MachineBranches = command == "enable"
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: save config: %v\n", err)
+			return 1
+		}
+		if !cfg.MachineBranches {
+			fmt.Println("✓ Branch per machine disabled: the repo stays on its current branch")
+			return 0
+		}
+		flow := newBranchFlow(cfg, modesCfg)
+		if err := flow.Enter(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✓ Branch per machine enabled: pushes go to %s, `dotsync branches merge` merges them into %s\n", flow.Branch, flow.Main)
+		return 0
+	case "status", "merge", "update":
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: dotsync branches [status|enable|disable|merge|update]")
+		return 2
+	}
 
-	return m, func() tea.Msg {
-		result := m.quickSync.Run(selectedApps)
-		return quickSyncCompleteMsg{result: result}
+	flow := newBranchFlow(cfg, modesCfg)
+	if flow == nil {
+		fmt.Fprintln(os.Stderr, "Branch per machine is off; run `dotsync branches enable`")
+		return 1
 	}
-}
 
-// quickSyncCompleteMsg is sent when quick sync completes
-type quickSyncCompleteMsg struct {
-	result *quicksync.Result
+	switch command {
+	case "merge":
+		if err := flow.MergeToMain(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✓ Merged %s into %s\n", flow.Branch, flow.Main)
+		return 0
+	case "update":
+		if err := flow.Update(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✓ Merged %s into %s\n", flow.Main, flow.Branch)
+		return 0
+	}
+
+	if flow.Repo.HasRemote() {
+		_ = flow.Repo.Fetch() // Offline: compare what was fetched last
+	}
+	drift, err := flow.Drift()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Machine branches vs %s (this machine: %s)\n", flow.Main, flow.Branch)
+	for _, d := range drift {
+		marker := " "
+		if d.Current {
+			marker = "*"
+		}
+		state := "✓ merged"
+		if d.Ahead > 0 || d.Behind > 0 {
+			state = fmt.Sprintf("%d to merge, %d behind", d.Ahead, d.Behind)
+		}
+		fmt.Printf("%s %-28s %s\n", marker, d.Branch, state)
+	}
+	return 0
 }
 
-// handleToggleMode toggles the mode for the selected app/file
-func (m *Model) handleToggleMode() (tea.Model, tea.Cmd) {
-	if m.modesConfig == nil {
-		m.status = "Modes not initialized"
-		return m, nil
+// runSystem syncs configs outside $HOME, like /etc/keyd, kept apart from
+// the user's apps: once enabled they leave the regular scan and are stored
+// under dotfiles/system/, pushed as the user where readable and pulled
+// with sudo
+func runSystem(args []string) int {
+	cfg, _ := config.Load()
+	command := "status"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	if m.focusedPanel == PanelApps {
-		// Toggle app sync
-		currentApp := m.appList.Current()
-		if currentApp == nil {
-			m.status = "No app selected"
-			return m, nil
+	switch command {
+	case "enable", "disable":
+		if err := cfg.CheckWritable(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		cfg.SystemConfigs = command == "enable"
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: save config: %v\n", err)
+			return 1
+		}
+		if cfg.SystemConfigs {
+			fmt.Println("✓ System configs enabled: paths outside $HOME sync with `dotsync system push|pull` only")
+		} else {
+			fmt.Println("✓ System configs disabled: paths outside $HOME are scanned with their apps again")
 		}
+		return 0
+	case "status", "push", "pull":
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: dotsync system [status|push|pull|enable|disable] [--dry-run] [APP...]")
+		return 2
+	}
 
-		synced := m.modesConfig.ToggleAppSync(currentApp.ID)
-		if err := m.modesConfig.Save(); err != nil {
-			m.status = fmt.Sprintf("Failed to save mode: %v", err)
-			return m, nil
+	fs := flag.NewFlagSet("system "+command, flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "list what would be copied without copying")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !cfg.SystemConfigs {
+		fmt.Fprintln(os.Stderr, "System configs are off; run `dotsync system enable` to sync paths outside $HOME separately")
+		if command != "status" {
+			return 1
 		}
+	}
 
-		if synced {
-			m.status = fmt.Sprintf("%s: sync enabled", currentApp.Name)
-		} else {
-			m.status = fmt.Sprintf("%s: sync disabled", currentApp.Name)
+	entries := system.Entries(cfg.DotfilesPath, newScanner(cfg).Definitions())
+	if fs.NArg() > 0 {
+		entries = slices.DeleteFunc(entries, func(e system.Entry) bool {
+			return !slices.Contains(fs.Args(), e.AppID)
+		})
+	}
+	if command == "status" {
+		if len(entries) == 0 {
+			fmt.Println("No system configs found")
 		}
-		m.appList.SetModesConfig(m.modesConfig)
-		m.updateFileList()
-	} else {
-		// Toggle file sync
-		currentApp := m.appList.Current()
-		currentFile := m.fileList.Current()
-		if currentApp == nil || currentFile == nil {
-			m.status = "No file selected"
-			return m, nil
+		for _, e := range entries {
+			fmt.Printf("%-12s %-20s %s\n", e.State, e.AppID, e.Path)
 		}
+		return 0
+	}
 
-		synced := m.modesConfig.ToggleFileSync(currentApp.ID, currentFile.Path)
-		if err := m.modesConfig.Save(); err != nil {
-			m.status = fmt.Sprintf("Failed to save mode: %v", err)
-			return m, nil
+	// Push copies what changed here, pull what changed in the store
+	var todo []system.Entry
+	for _, e := range entries {
+		switch {
+		case e.State == system.StateSynced:
+		case command == "push" && e.State != system.StateStoredOnly,
+			command == "pull" && e.State != system.StateLocalOnly:
+			todo = append(todo, e)
 		}
+	}
+	if len(todo) == 0 {
+		fmt.Println("Nothing to " + command)
+		return 0
+	}
+	for _, e := range todo {
+		from, to := e.Path, e.Stored
+		if command == "pull" {
+			from, to = to, from
+		}
+		fmt.Printf("  %s → %s\n", from, to)
+	}
+	if *dryRun {
+		return 0
+	}
 
-		if synced {
-			m.status = fmt.Sprintf("%s: sync enabled", currentFile.Name)
+	l, ok := holdSyncLock(cfg, "system "+command)
+	if !ok {
+		return 1
+	}
+	defer l.Release()
+
+	failed := 0
+	for _, e := range todo {
+		var err error
+		if command == "push" {
+			err = system.Push(e, func(src, dst string, isDir bool) error {
+				return runElevatedCopy(src, dst, isDir, true)
+			})
 		} else {
-			m.status = fmt.Sprintf("%s: sync disabled", currentFile.Name)
+			err = runElevatedCopy(e.Stored, e.Path, e.IsDir, false)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", e.Path, err)
+			failed++
 		}
-		m.fileList.SetModesConfig(m.modesConfig)
 	}
-
-	return m, nil
+	fmt.Printf("✓ %d of %d system configs %sed\n", len(todo)-failed, len(todo), command)
+	if failed > 0 {
+		return 1
+	}
+	return 0
 }
 
-// handleRestore opens the restore from machine dialog
-func (m *Model) handleRestore() (tea.Model, tea.Cmd) {
-	if m.backupManager == nil {
-		m.status = "Backup manager not initialized"
-		return m, nil
+// runElevatedCopy copies src to dst through sudo in the terminal, so sudo
+// can ask for the password
+func runElevatedCopy(src, dst string, isDir, own bool) error {
+	c, err := sync.ElevatedCopyCmd(src, dst, isDir, own)
+	if err != nil {
+		return err
 	}
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return c.Run()
+}
 
-	// Load available machines
-	machines, err := m.backupManager.ListMachines()
-	if err != nil {
-		m.status = fmt.Sprintf("Failed to list machines: %v", err)
-		return m, nil
+// runConfig checks the config file or opens it in the user's editor,
+// checking it again when the editor closes
+func runConfig(args []string) int {
+	command := "check"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "path":
+		fmt.Println(config.ConfigPath())
+		return 0
+	case "check":
+		if _, err := config.Load(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("✓ %s is valid\n", config.ConfigPath())
+		if overridden := config.Overrides(); len(overridden) > 0 {
+			fmt.Printf("  Overridden for this run: %s\n", strings.Join(overridden, ", "))
+		}
+		return 0
+	case "edit":
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: dotsync config [check|edit|path]")
+		return 2
+	}
+
+	// Write a commented file to edit: the defaults, or the JSON config of
+	// earlier versions converted
+	path := config.ConfigPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if cfg, err := config.Load(); err == nil {
+			if err := cfg.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: save config: %v\n", err)
+				return 1
+			}
+		}
 	}
 
-	if len(machines) == 0 {
-		m.status = "No backup machines found"
-		return m, nil
+	answer := bufio.NewReader(os.Stdin)
+	for {
+		if err := editorCommand(path).Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: editor: %v\n", err)
+			return 1
+		}
+		_, err := config.Load()
+		if err == nil {
+			fmt.Printf("✓ %s is valid\n", path)
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Print("Edit again? [Y/n] ")
+		line, err := answer.ReadString('\n')
+		if err != nil || strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "n") {
+			return 1
+		}
 	}
+}
 
-	m.restoreMachines = machines
-	m.restoreCursor = 0
-	m.status = "Select machine to restore from"
-	// TODO: Switch to restore screen when implemented
-	m.status = fmt.Sprintf("Found %d machines with backups. Restore screen coming soon.", len(machines))
-	return m, nil
+// editorCommand opens path in $VISUAL or $EDITOR, vi if neither is set
+func editorCommand(path string) *exec.Cmd {
+	editor := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	c := exec.Command(editor[0], append(editor[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return c
 }
 
-// handleCheckConflicts runs conflict detection and displays results
-func (m *Model) handleCheckConflicts() (tea.Model, tea.Cmd) {
-	if m.quickSync == nil {
-		m.status = "Quick backup not initialized"
-		return m, nil
+// runProvision sets up a new machine from a dotfiles repo URL: it clones
+// the repo, saves the config the setup wizard would, pulls every app with
+// a shared copy, installs the Brewfile and package lists, and runs the
+// apps' bootstrap scripts
+func runProvision(args []string) int {
+	cfg, _ := config.Load()
+
+	fs := flag.NewFlagSet("provision", flag.ContinueOnError)
+	dir := fs.String("dir", cfg.DotfilesPath, "where to clone the dotfiles repo")
+	skipPackages := fs.Bool("skip-packages", false, "don't install the Brewfile or package lists")
+	skipBootstrap := fs.Bool("skip-bootstrap", false, "don't run the apps' bootstrap scripts")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	// Allow flags after the URL, e.g. `provision URL --dir ~/dots`
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: dotsync provision URL [--dir PATH] [--skip-packages] [--skip-bootstrap]")
+		return 2
+	}
+	url := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return 2
 	}
 
-	selectedApps := m.appList.SelectedApps()
-	if len(selectedApps) == 0 {
-		selectedApps = m.apps
+	path := *dir
+	if strings.HasPrefix(path, "~/") {
+		homeDir, _ := os.UserHomeDir()
+		path = filepath.Join(homeDir, path[2:])
 	}
 
-	m.status = "Checking for conflicts..."
+	l, ok := holdSyncLock(cfg, "provision")
+	if !ok {
+		return 1
+	}
+	defer l.Release()
 
-	return m, func() tea.Msg {
-		detection := m.quickSync.DetectOnly(selectedApps)
-		return conflictCheckMsg{detection: detection}
+	fmt.Printf("==> Cloning %s into %s\n", url, path)
+	if _, err := git.Clone(url, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
 	}
-}
 
-// conflictCheckMsg is sent when conflict check completes
-type conflictCheckMsg struct {
-	detection *quicksync.DetectionResult
-}
+	// What the setup wizard does once a path is confirmed
+	cfg.DotfilesPath = path
+	cfg.FirstRun = false
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: save config: %v\n", err)
+		return 1
+	}
+	if err := cfg.EnsureDirectories(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
 
-// handleOpenEditor opens the current file in the configured editor
-func (m *Model) handleOpenEditor() (tea.Model, tea.Cmd) {
-	if m.focusedPanel != PanelFiles {
-		m.status = "Select a file first (Tab to switch panel)"
-		return m, nil
+	// Apps with a shared copy in dotfiles are the ones in sync mode
+	fmt.Println("==> Pulling synced apps")
+	apps, unmapped := newScanner(cfg).ScanDotfiles(cfg.DotfilesPath)
+	stateManager := sync.NewStateManager(config.ConfigDir())
+	_ = stateManager.Load()
+	results, err := sync.NewImporter(cfg).WithStateManager(stateManager).ImportAll(apps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: pull: %v\n", err)
+		return 1
 	}
 
-	currentFile := m.fileList.Current()
-	if currentFile == nil {
-		m.status = "No file selected"
-		return m, nil
+	modesCfg, _ := modes.Load()
+	if modesCfg == nil {
+		modesCfg = modes.Default()
+	}
+	entries := make([]audit.Entry, 0, len(results))
+	failed := 0
+	var pulled []string
+	for _, r := range results {
+		result, message := audit.ResultFor(r.Error)
+		entries = append(entries, audit.Entry{Action: audit.ActionPull, AppID: r.App.ID, File: r.File.RelPath,
+			Result: result, Error: message, Detail: "provision"})
+		if !r.Success {
+			failed++
+			fmt.Printf("✗ %-16s %s: %v\n", r.App.ID, r.File.RelPath, r.Error)
+			continue
+		}
+		fmt.Printf("✓ %-16s %s\n", r.App.ID, r.File.Path)
+		localHash, _ := sync.ComputeFileHashNoCache(r.File.Path)
+		dotfilesHash, _ := sync.ComputeFileHashNoCache(sync.DotfilePath(cfg.DotfilesPath, r.App.ID, r.File))
+		stateManager.SetFileState(r.App.ID, r.File.RelPath, localHash, dotfilesHash)
+		stateManager.RecordPull(r.App.ID, r.File.RelPath)
+		if !slices.Contains(pulled, r.App.ID) {
+			pulled = append(pulled, r.App.ID)
+			modesCfg.SyncedApps[r.App.ID] = true
+		}
+	}
+	for _, id := range unmapped {
+		if sync.IsReservedDir(id) {
+			continue // Package lists are installed below
+		}
+		fmt.Printf("? %-16s no definition maps it to a local path, skipped\n", id)
 	}
+	if err := stateManager.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: save sync state: %v\n", err)
+	}
+	if err := modesCfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: save modes: %v\n", err)
+	}
+	if err := newAuditLog(modesCfg).Append(entries...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit log: %v\n", err)
+	}
+	fmt.Printf("Pulled %d/%d entries from %d apps\n", len(results)-failed, len(results), len(pulled))
 
-	// Detect and open editor
-	ed, err := editor.Detect(nil)
-	if err != nil {
-		m.status = fmt.Sprintf("No editor found: %v", err)
-		return m, nil
+	if !*skipPackages {
+		brewfile := brew.BrewfilePath(cfg.DotfilesPath)
+		if _, err := os.Stat(brewfile); err == nil {
+			fmt.Println("==> Installing Brewfile")
+			if err := brew.ApplyBrewfile(brewfile, os.Stdout); err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}
+
+		usable, unavailable, err := provision.FindPackageLists(cfg.DotfilesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: package lists: %v\n", err)
+			failed++
+		}
+		for _, list := range unavailable {
+			fmt.Printf("? %-16s %s not installed, skipped\n", filepath.Base(list.Path), list.Manager.Binary)
+		}
+		for _, list := range usable {
+			command := list.Command()
+			fmt.Printf("==> Installing %d %s packages\n", len(list.Packages), list.Manager.Name)
+			cmd := exec.Command(command[0], command[1:]...)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := cmd.Run(); err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", list.Manager.Name, err)
+			}
+		}
 	}
 
-	m.status = fmt.Sprintf("Opening %s in %s...", currentFile.Name, ed.Name())
+	if !*skipBootstrap {
+		record, err := bootstrap.LoadRecord(config.ConfigDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: load bootstrap record: %v\n", err)
+			return 1
+		}
+		if scripts := record.Pending(cfg.DotfilesPath, pulled); len(scripts) > 0 {
+			fmt.Println("==> Running bootstrap scripts")
+			session := bootstrap.NewSession(scripts, record)
+			if err := session.Run(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: save bootstrap record: %v\n", err)
+			}
+			fmt.Println(bootstrap.Summary(session.Results))
+			if bootstrap.HasFailures(session.Results) {
+				failed++
+			}
+		}
+	}
 
-	return m, func() tea.Msg {
-		err := ed.OpenDiff(currentFile.Path, currentFile.Path)
-		return editorOpenedMsg{err: err}
+	if failed > 0 {
+		fmt.Println("\nProvisioning finished with errors; run `dotsync` to review")
+		return 1
 	}
+	fmt.Println("\n✓ Machine provisioned")
+	return 0
 }
 
-// editorOpenedMsg is sent when editor operation completes
-type editorOpenedMsg struct {
-	err error
-}
+// runBootstrap runs the install scripts kept in the dotfiles app
+// directories, for apps that haven't bootstrapped on this machine
+func runBootstrap(args []string) int {
+	fs := flag.NewFlagSet("bootstrap", flag.ExitOnError)
+	list := fs.Bool("list", false, "List scripts and whether they ran here, without running them")
+	force := fs.Bool("force", false, "Run scripts again even if they already ran here")
+	fs.Parse(args)
 
-// handlePushAndCommit pushes changes and commits with auto-generated message
-func (m *Model) handlePushAndCommit() (tea.Model, tea.Cmd) {
-	selectedApps := m.appList.SelectedApps()
-	if len(selectedApps) == 0 {
-		m.status = "No apps selected"
-		return m, nil
+	cfg, _ := config.Load()
+	if lay := layout.For(cfg.DotfilesPath); !layout.IsDefault(lay) {
+		fmt.Fprintf(os.Stderr, "Error: bootstrap scripts live in per-app directories, which the %s layout doesn't have\n", lay.Name())
+		return 1
+	}
+	record, err := bootstrap.LoadRecord(config.ConfigDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: load bootstrap record: %v\n", err)
+		return 1
 	}
 
-	// Count selected files
-	fileCount := 0
-	var appNames []string
-	for _, app := range selectedApps {
-		hasFiles := false
-		for _, file := range app.Files {
-			if file.Selected {
-				fileCount++
-				hasFiles = true
+	appIDs := fs.Args()
+	if len(appIDs) == 0 {
+		entries, err := os.ReadDir(cfg.DotfilesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: read dotfiles: %v\n", err)
+			return 1
+		}
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				appIDs = append(appIDs, e.Name())
 			}
 		}
-		if hasFiles {
-			appNames = append(appNames, app.ID)
+	}
+
+	var scripts []bootstrap.Script
+	for _, appID := range appIDs {
+		dir, _ := bootstrap.AppDir(cfg.DotfilesPath, appID)
+		s := bootstrap.Find(appID, dir)
+		if s == nil {
+			continue
 		}
+		if *list {
+			state := "pending"
+			if record.Done(appID) {
+				state = "ran " + record.Ran[appID].Format("2006-01-02")
+			}
+			fmt.Printf("%-16s %-14s %s\n", appID, state, s.Path)
+			continue
+		}
+		if *force || !record.Done(appID) {
+			scripts = append(scripts, *s)
+		}
+	}
+	if *list {
+		return 0
+	}
+	if len(scripts) == 0 {
+		fmt.Println("No bootstrap scripts to run")
+		return 0
+	}
+	if err := cfg.CheckWritable(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
 	}
 
-	if fileCount == 0 {
-		m.status = "No files selected"
-		return m, nil
+	session := bootstrap.NewSession(scripts, record)
+	if err := session.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: save bootstrap record: %v\n", err)
+		return 1
+	}
+	fmt.Println(bootstrap.Summary(session.Results))
+	if bootstrap.HasFailures(session.Results) {
+		return 1
 	}
+	return 0
+}
 
-	m.status = "Pushing and committing..."
-	m.syncing = true
-	m.screen = ScreenSyncing
+// runPorcelain serves the JSON event stream for alternative frontends
+func runPorcelain() int {
+	cfg, _ := config.Load()
+	stateManager := sync.NewStateManager(config.ConfigDir())
+	_ = stateManager.Load()
 
-	return m, func() tea.Msg {
-		// Export files first
-		exporter := sync.NewExporter(m.config)
-		results, err := exporter.ExportAll(selectedApps)
-		if err != nil {
-			return syncCompleteMsg{err: err, action: "push"}
+	scan := func() ([]*models.App, error) {
+		apps, err := newScanner(cfg).Scan()
+		for _, app := range apps {
+			sync.UpdateSyncStatusWithHashes(app, cfg.DotfilesPath, stateManager)
 		}
+		return apps, err
+	}
 
-		// Generate commit message
-		var commitMsg string
-		if len(appNames) == 1 {
-			commitMsg = fmt.Sprintf("sync: update %s (%d files)", appNames[0], fileCount)
-		} else if len(appNames) <= 3 {
-			commitMsg = fmt.Sprintf("sync: update %s", strings.Join(appNames, ", "))
-		} else {
-			commitMsg = fmt.Sprintf("sync: update %d apps (%d files)", len(appNames), fileCount)
-		}
+	modesCfg, _ := modes.Load()
+	server := porcelain.New(cfg, scan, stateManager, os.Stdout).WithAudit(newAuditLog(modesCfg)).WithLock(lock.Path(config.ConfigDir())).WithReport(reportPath).WithNotifier(notify.New(!cfg.NotificationsOff))
+	if err := server.Run(os.Stdin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
 
-		// Commit and push
-		gitRepo := git.NewRepo(m.config.DotfilesPath)
-		if gitRepo.IsRepo() {
-			if err := gitRepo.AddAll(); err != nil {
-				return syncCompleteMsg{results: results, err: fmt.Errorf("git add: %w", err), action: "push+commit"}
-			}
-			if err := gitRepo.Commit(commitMsg); err != nil {
-				return syncCompleteMsg{results: results, err: fmt.Errorf("git commit: %w", err), action: "push+commit"}
-			}
-			if gitRepo.HasRemote() {
-				if err := gitRepo.Push(); err != nil {
-					return syncCompleteMsg{results: results, err: fmt.Errorf("git push: %w", err), action: "push+commit"}
-				}
+// applyGlobalFlags handles --profile and --report, plus a flag for every
+// setting of the config file such as --dotfiles-path, --theme or
+// --read-only (anywhere on the command line, "--flag value" or
+// "--flag=value"; switches need no value), and returns the remaining
+// arguments. Environment variables fill in whatever the flags didn't set.
+// Invalid values end the program.
+func applyGlobalFlags(args []string) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name == "-d" || name == "--debug" || (i == 0 && name == "debug") {
+			name, value, hasValue = "--log-level", "debug", true
+		}
+		key, isSwitch, isSetting := config.SettingForFlag(name)
+		if name != "--profile" && name != "--report" && !isSetting {
+			rest = append(rest, args[i])
+			continue
+		}
+		if isSwitch && !hasValue {
+			value, hasValue = "true", true
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "--profile":
+			config.SetProfile(value)
+		case "--report":
+			reportPath = value
+		default:
+			if err := config.SetOverride(key, value, config.SourceFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+				os.Exit(2)
 			}
 		}
-
-		return syncCompleteMsg{results: results, action: "push+commit"}
 	}
+	if err := config.ApplyEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	return rest
 }
 
 func main() {
+	os.Args = append(os.Args[:1], applyGlobalFlags(os.Args[1:])...)
+
+	// Everything below needs a config that parses; `config edit` fixes it
+	if len(os.Args) < 2 || os.Args[1] != "config" {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\nRun `dotsync config edit` to fix it\n", err)
+			os.Exit(1)
+		}
+		if cfg.NoColor || cfg.Plain {
+			lipgloss.SetColorProfile(termenv.Ascii)
+		}
+		// The file stays open for the life of the process; records are
+		// not buffered, so nothing is lost on exit
+		level, _ := logging.ParseLevel(cfg.LogLevel)
+		if _, err := logging.Setup(level); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: no log file: %v\n", err)
+		}
+	}
+
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "report":
+			os.Exit(runReport(os.Args[2:]))
+		case "sandbox":
+			os.Exit(runSandbox(os.Args[2:]))
+		case "log":
+			os.Exit(runLog(os.Args[2:]))
+		case "changelog":
+			os.Exit(runChangelog(os.Args[2:]))
+		case "metrics":
+			os.Exit(runMetrics(os.Args[2:]))
+		case "status":
+			os.Exit(runStatus(os.Args[2:]))
+		case "push":
+			os.Exit(runPeerPush(os.Args[2:]))
+		case "shell":
+			os.Exit(runShell(os.Args[2:]))
+		case "plugins":
+			os.Exit(runPlugins(os.Args[2:]))
+		case "registry":
+			os.Exit(runRegistry(os.Args[2:]))
+		case "ssh":
+			os.Exit(runSSH(os.Args[2:]))
+		case "layout":
+			os.Exit(runLayout(os.Args[2:]))
+		case "share":
+			os.Exit(runShare(os.Args[2:]))
+		case "branches":
+			os.Exit(runBranches(os.Args[2:]))
+		case "system":
+			os.Exit(runSystem(os.Args[2:]))
+		case "bootstrap":
+			os.Exit(runBootstrap(os.Args[2:]))
+		case "provision":
+			os.Exit(runProvision(os.Args[2:]))
+		case "file-status", "push-file", "diff-file":
+			os.Exit(runFileCommand(os.Args[1], os.Args[2:]))
+		}
+	}
+
 	// Check for flags
+	porcelainMode := false
 	for _, arg := range os.Args[1:] {
 		switch arg {
 		case "-v", "--version", "version":
@@ -3515,24 +11478,113 @@ func main() {
 			fmt.Println("dotsync - A beautiful TUI for managing dotfiles")
 			fmt.Println()
 			fmt.Println("Usage: dotsync [options]")
+			fmt.Println("       dotsync <command> [flags]")
+			fmt.Println()
+			fmt.Println("Commands:")
+			fmt.Println("  report [--file PATH] [--email ADDR] [--schedule \"0 9 * * *\"]")
+			fmt.Println("                   Summarize pending pushes/pulls/conflicts (for cron)")
+			fmt.Println("  push --to USER@HOST --apps a,b")
+			fmt.Println("                   Copy app configs to another machine running dotsync over SSH")
+			fmt.Println("  file-status PATH [--porcelain]")
+			fmt.Println("  push-file PATH [--porcelain]")
+			fmt.Println("  diff-file PATH [--porcelain]")
+			fmt.Println("                   Per-file commands for editor plugins")
+			fmt.Println("  sandbox [--dir PATH]")
+			fmt.Println("                   Restore everything into a temp dir instead of $HOME")
+			fmt.Println("  shell list|select ID...|unselect ID...|push|pull [--rc PATH]")
+			fmt.Println("                   Sync chosen aliases, exports, functions and PATH entries via an include file")
+			fmt.Println("  shell split [--rc PATH] [--dry-run]")
+			fmt.Println("                   Move machine-specific lines of rc files into ~/.zshrc.local / ~/.bashrc.local")
+			fmt.Println("  plugins [--detect]")
+			fmt.Println("                   List plugins in ~/.config/dotsync/plugins and the apps they detect")
+			fmt.Println("  registry update [--url URL] [--key KEY] [--dry-run]")
+			fmt.Println("                   Fetch the signed community app catalog and list newly supported apps")
+			fmt.Println("  ssh list|local HOST...|share HOST...")
+			fmt.Println("                   Choose which ~/.ssh/config Host blocks stay machine-only")
+			fmt.Println("  layout [by-app|home|flat]")
+			fmt.Println("                   Show or set how the dotfiles repo is organized (per app, mirroring $HOME, or flat)")
+			fmt.Println("  share [--to PATH] [--apps a,b] [--dry-run] [--commit]")
+			fmt.Println("                   Copy stored configs to a public repo with secrets redacted and emails templated")
+			fmt.Println("  branches [status|enable|disable|merge|update]")
+			fmt.Println("                   Branch per machine: push to machine/<host>, merge into main, show drift")
+			fmt.Println("  config [check|edit|path]")
+			fmt.Println("                   Check the config file, or edit it in $EDITOR and check it on close")
+			fmt.Println("  system [status|push|pull|enable|disable] [--dry-run] [APP...]")
+			fmt.Println("                   Sync configs outside $HOME (/etc) separately, under dotfiles/system with sudo")
+			fmt.Println("  provision URL [--dir PATH] [--skip-packages] [--skip-bootstrap]")
+			fmt.Println("                   Set up a new machine: clone, configure, pull, install packages, bootstrap")
+			fmt.Println("  bootstrap [--list] [--force] [APP...]")
+			fmt.Println("                   Run the install.sh/bootstrap scripts from dotfiles not yet run here")
+			fmt.Println("  log [--action A] [--app ID] [--file S] [--since 7d] [--failed] [--limit N] [--json]")
+			fmt.Println("                   Show the audit log of push/pull/merge/restore operations")
+			fmt.Println("  changelog [--from 7d] [--to REV] [--out FILE]")
+			fmt.Println("                   Markdown summary of per-app changes between two dates or commits")
+			fmt.Println("  metrics [--format json|prometheus] [--listen ADDR] [--interval 1m]")
+			fmt.Println("                   Write the drift status file, print it, or serve it over HTTP")
+			fmt.Println("  status [--short] [--waybar]")
+			fmt.Println("                   Drift summary from the status file, without scanning (for menu bars)")
 			fmt.Println()
 			fmt.Println("Options:")
 			fmt.Println("  -v, --version    Show version")
 			fmt.Println("  -h, --help       Show this help")
-			fmt.Println("  -d, --debug      Enable debug mode (logs to stderr)")
+			fmt.Println("  -d, --debug      Same as --log-level debug")
+			fmt.Println("      --porcelain  JSON events on stdout, JSON commands on stdin (no TUI)")
+			fmt.Println("      --profile NAME        Use a separate config and sync state ($DOTSYNC_PROFILE)")
+			fmt.Println("      --dotfiles-path PATH  Use this dotfiles repo without saving it ($DOTSYNC_DOTFILES_PATH)")
+			fmt.Println("      --read-only           Only scan, diff and preview; no push, pull or git changes ($DOTSYNC_READ_ONLY)")
+			fmt.Println("      --report FILE         Write a report after each push/pull/quick sync (.json or markdown)")
+			fmt.Println("      --log-level LEVEL     debug, info, warn or error, written to ~/.local/state/dotsync/logs ($DOTSYNC_LOG_LEVEL)")
+			fmt.Println("      --SETTING VALUE       Override any config setting for this run ($DOTSYNC_SETTING),")
+			fmt.Println("                            e.g. --theme light, --scan-max-depth 3, --no-color ($NO_COLOR)")
+			fmt.Println("      --plain               ASCII only: no colors, emoji or box drawing, for minimal terminals and screen readers")
+			fmt.Println("      --accessible          Screen-reader mode: linear panels, status changes printed as lines, no full-screen redraw")
 			fmt.Println()
 			fmt.Println("Run without arguments to start the TUI.")
 			return
-		case "-d", "--debug", "debug":
-			debugMode = true
-			scanner.DebugMode = true
-			fmt.Fprintln(os.Stderr, "[DEBUG] Debug mode enabled")
+		case "--porcelain":
+			porcelainMode = true
 		}
 	}
 
-	p := tea.NewProgram(New(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	if porcelainMode {
+		os.Exit(runPorcelain())
+	}
+
+	// Accessible mode stays inline, keeping the printed status lines in
+	// the terminal's scrollback for screen readers
+	var opts []tea.ProgramOption
+	if cfg, err := config.Load(); err == nil && !cfg.Accessible {
+		// Mouse reports let the panel divider be dragged
+		opts = append(opts, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	}
+	model := New()
+	p := tea.NewProgram(model, opts...)
+	_, err := p.Run()
+	model.Close()
+	if panicked := crash.Recovered(); panicked != nil {
+		// Bubble Tea restored the terminal and printed the stack
+		os.Exit(reportCrash(panicked))
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// reportCrash writes a crash report for a panic in the TUI and returns the
+// exit code. A sync it interrupted keeps its lock, so the next start offers
+// to resume it.
+func reportCrash(p *crash.Panic) int {
+	slog.Error("crashed", "panic", fmt.Sprint(p.Value))
+	lines, _ := logging.Tail(logging.Path(), 50)
+	path, err := crash.WriteReport(logging.Dir(), version, p, lines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dotsync crashed: %v (writing the crash report failed: %v)\n", p.Value, err)
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "dotsync crashed: %v\nCrash report: %s\n", p.Value, path)
+	if holder, err := lock.Read(lock.Path(config.ConfigDir())); err == nil && holder.PID == os.Getpid() && resumable(holder) {
+		fmt.Fprintf(os.Stderr, "The interrupted %s is offered for resuming on the next start.\n", holder.Command)
+	}
+	return 2
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dotsync/internal/changelog"
+	"dotsync/internal/config"
+	"dotsync/internal/lock"
+	"dotsync/internal/logging"
+	"dotsync/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+)
+
+// testHome points HOME at a temp dir, so the model loads and saves its
+// config and state there, and pins the language to English
+func testHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	for _, env := range []string{"LANGUAGE", "LC_ALL", "LC_MESSAGES"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("LANG", "en_US.UTF-8")
+	return home
+}
+
+// saveTestConfig writes a config past the setup wizard, with a flat app
+// list, no desktop notifications and /etc left to `dotsync system`
+func saveTestConfig(t *testing.T, home string) *config.Config {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DotfilesPath = filepath.Join(home, "dotfiles")
+	cfg.BackupPath = filepath.Join(home, ".dotfiles-backup")
+	cfg.FirstRun = false
+	cfg.FlatAppList = true
+	cfg.NotificationsOff = true
+	cfg.SystemConfigs = true
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(cfg.DotfilesPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTestModel runs the model in a 120x40 terminal
+func startTestModel(t *testing.T) *teatest.TestModel {
+	t.Helper()
+	m := New()
+	t.Cleanup(m.Close)
+	return teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 40))
+}
+
+// showApp waits for the scan and narrows the app list to one app
+func showApp(t *testing.T, tm *teatest.TestModel, name string) {
+	t.Helper()
+	waitForText(t, tm, "Found")
+	sendKeys(tm, "/")
+	tm.Type(name)
+	sendKeys(tm, "enter")
+}
+
+// waitForText waits until the rendered output shows text
+func waitForText(t *testing.T, tm *teatest.TestModel, text string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(b []byte) bool {
+		return bytes.Contains(b, []byte(text))
+	}, teatest.WithDuration(10*time.Second), teatest.WithCheckInterval(20*time.Millisecond))
+}
+
+func sendKeys(tm *teatest.TestModel, keys ...string) {
+	for _, k := range keys {
+		switch k {
+		case "enter":
+			tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+		case "esc":
+			tm.Send(tea.KeyMsg{Type: tea.KeyEsc})
+		case "tab":
+			tm.Send(tea.KeyMsg{Type: tea.KeyTab})
+		case "space":
+			tm.Send(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		case "ctrl+u":
+			tm.Send(tea.KeyMsg{Type: tea.KeyCtrlU})
+		default:
+			tm.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+}
+
+// finalModel quits the program and returns the model it ended with
+func finalModel(t *testing.T, tm *teatest.TestModel) *Model {
+	t.Helper()
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	return tm.FinalModel(t, teatest.WithFinalTimeout(10*time.Second)).(*Model)
+}
+
+func TestTUI_SetupWizard(t *testing.T) {
+	home := testHome(t)
+	tm := startTestModel(t)
+
+	waitForText(t, tm, "Welcome")
+	sendKeys(tm, "enter")
+	waitForText(t, tm, "[1]")
+
+	// A custom path replaces the suggested one
+	sendKeys(tm, "ctrl+u")
+	tm.Type("~/my-dots")
+	sendKeys(tm, "enter")
+	waitForText(t, tm, filepath.Join(home, "my-dots"))
+	sendKeys(tm, "y")
+	waitForText(t, tm, "Scanning")
+
+	m := finalModel(t, tm)
+	if m.config.FirstRun || m.config.DotfilesPath != filepath.Join(home, "my-dots") {
+		t.Errorf("Expected setup to finish with ~/my-dots, got first run %v, path %s", m.config.FirstRun, m.config.DotfilesPath)
+	}
+	saved, err := config.Load()
+	if err != nil || saved.DotfilesPath != filepath.Join(home, "my-dots") {
+		t.Errorf("Expected the path saved, got %v, %v", saved, err)
+	}
+}
+
+// TestTUI_SetupWelcomeSnapshot compares the welcome screen with
+// testdata/TestTUI_SetupWelcomeSnapshot.golden; go test -update rewrites it
+func TestTUI_SetupWelcomeSnapshot(t *testing.T) {
+	testHome(t)
+	m := New()
+	t.Cleanup(m.Close)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	golden.RequireEqual(t, []byte(m.View()))
+}
+
+func TestTUI_PushConfirm(t *testing.T) {
+	home := testHome(t)
+	cfg := saveTestConfig(t, home)
+	writeTestFile(t, filepath.Join(home, ".tmux.conf"), "set -g mouse on\n")
+
+	tm := startTestModel(t)
+	showApp(t, tm, "tmux")
+	sendKeys(tm, "space", "p")
+	waitForText(t, tm, "Files to push:")
+
+	// Cancel leaves dotfiles alone
+	sendKeys(tm, "2", "enter")
+	waitForText(t, tm, "Push cancelled")
+	if _, err := os.Stat(filepath.Join(cfg.DotfilesPath, "tmux", ".tmux.conf")); !os.IsNotExist(err) {
+		t.Fatalf("Expected nothing pushed after cancel, got %v", err)
+	}
+
+	sendKeys(tm, "p")
+	waitForText(t, tm, "Files to push:")
+	sendKeys(tm, "enter")
+	waitForText(t, tm, "1/1 files")
+
+	m := finalModel(t, tm)
+	data, err := os.ReadFile(filepath.Join(cfg.DotfilesPath, "tmux", ".tmux.conf"))
+	if err != nil || string(data) != "set -g mouse on\n" {
+		t.Errorf("Expected .tmux.conf pushed, got %q, %v (status %q)", data, err, m.status)
+	}
+}
+
+func TestTUI_MergeFlow(t *testing.T) {
+	home := testHome(t)
+	cfg := saveTestConfig(t, home)
+	local := filepath.Join(home, ".tmux.conf")
+	writeTestFile(t, local, "set -g mouse on\nset -g status on\n")
+	writeTestFile(t, filepath.Join(cfg.DotfilesPath, "tmux", ".tmux.conf"), "set -g mouse off\nset -g status on\n")
+
+	tm := startTestModel(t)
+	showApp(t, tm, "tmux")
+	sendKeys(tm, "tab", "d")
+	waitForText(t, tm, "mouse off")
+	sendKeys(tm, "m")
+	waitForText(t, tm, "0/1")
+
+	// Take the dotfiles side of the only hunk and save
+	sendKeys(tm, "2")
+	waitForText(t, tm, "1/1")
+	sendKeys(tm, "enter")
+
+	m := finalModel(t, tm)
+	data, _ := os.ReadFile(local)
+	if !strings.Contains(string(data), "mouse off") {
+		t.Errorf("Expected the merged file saved with the dotfiles line, got %q (status %q)", data, m.status)
+	}
+}
+
+func TestTUI_LogView(t *testing.T) {
+	home := testHome(t)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".local", "state"))
+	saveTestConfig(t, home)
+	writeTestFile(t, logging.Path(), `time=2026-01-02T10:00:00Z level=WARN msg=push app=tmux err="permission denied"`+"\n")
+
+	tm := startTestModel(t)
+	waitForText(t, tm, "Found")
+	sendKeys(tm, "H", "l")
+	waitForText(t, tm, "permission denied")
+
+	m := finalModel(t, tm)
+	if m.screen != ScreenLogs || len(m.logLines) != 1 {
+		t.Errorf("Expected the log view with one line, got screen %v, %d lines", m.screen, len(m.logLines))
+	}
+}
+
+func TestTUI_ResumeInterruptedPush(t *testing.T) {
+	home := testHome(t)
+	saveTestConfig(t, home)
+	writeTestFile(t, filepath.Join(home, ".tmux.conf"), "set -g mouse on\n")
+
+	// A push whose process died holding the lock
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(lock.Holder{PID: 1 << 30, Host: host, Command: "push", Started: time.Now(), Apps: []string{"tmux"}})
+	writeTestFile(t, lock.Path(config.ConfigDir()), string(data))
+
+	tm := startTestModel(t)
+	waitForText(t, tm, "Interrupted Sync")
+	sendKeys(tm, "y")
+	waitForText(t, tm, "Files to push:")
+
+	m := finalModel(t, tm)
+	selected := m.appList.SelectedApps()
+	if len(selected) != 1 || selected[0].ID != "tmux" {
+		t.Errorf("Expected only tmux selected for the resumed push, got %v", selected)
+	}
+}
+
+func TestTUI_RehashAfterExternalEdit(t *testing.T) {
+	home := testHome(t)
+	cfg := saveTestConfig(t, home)
+	writeTestFile(t, filepath.Join(home, ".tmux.conf"), "set -g mouse on\n")
+	writeTestFile(t, filepath.Join(cfg.DotfilesPath, "tmux", ".tmux.conf"), "set -g mouse on\n")
+
+	tm := startTestModel(t)
+	waitForText(t, tm, "Found")
+	writeTestFile(t, filepath.Join(home, ".tmux.conf"), "set -g mouse off\nset -g history-limit 5000\n")
+	waitForText(t, tm, "changed outside dotsync")
+
+	m := finalModel(t, tm)
+	for _, app := range m.apps {
+		if app.ID != "tmux" {
+			continue
+		}
+		if f := app.Files[0]; f.ConflictType == models.ConflictNone || f.LocalHash == f.DotfilesHash {
+			t.Errorf("Expected the edit re-hashed, got %v with hashes %s, %s", f.ConflictType, f.LocalHash, f.DotfilesHash)
+		}
+		return
+	}
+	t.Error("Expected tmux in the app list")
+}
+
+func TestTUI_UpstreamBanner(t *testing.T) {
+	home := testHome(t)
+	cfg := saveTestConfig(t, home)
+	writeTestFile(t, filepath.Join(home, ".tmux.conf"), "set -g mouse on\n")
+	writeTestFile(t, filepath.Join(cfg.DotfilesPath, "tmux", ".tmux.conf"), "set -g mouse on\n")
+
+	tm := startTestModel(t)
+	waitForText(t, tm, "Found")
+
+	// A fetch found commits touching tmux and an app this machine lacks
+	tm.Send(upstreamMsg{changelog: &changelog.Changelog{Commits: 2, Apps: []changelog.App{
+		{ID: "kitty", Files: []changelog.File{{Path: "kitty.conf"}}},
+		{ID: "tmux", Files: []changelog.File{{Path: ".tmux.conf", Added: 1}}},
+	}}})
+	waitForText(t, tm, "1 apps updated upstream (tmux)")
+
+	sendKeys(tm, "U")
+	waitForText(t, tm, "l: pull into dotfiles")
+	sendKeys(tm, "esc")
+
+	m := finalModel(t, tm)
+	if m.screen != ScreenMain || m.upstreamReview {
+		t.Errorf("Expected Esc back on the main screen, got screen %v", m.screen)
+	}
+}
+
+func TestHandleRehashed_DropsStaleResults(t *testing.T) {
+	app := &models.App{ID: "tmux", Files: []models.File{{RelPath: ".tmux.conf"}, {RelPath: "plugins.conf"}}}
+	rescanned := &models.App{ID: "tmux", Files: []models.File{{RelPath: ".tmux.conf"}}}
+	m := &Model{apps: []*models.App{app}}
+
+	refs := []fileRef{{app, &app.Files[0]}, {app, &app.Files[1]}, {rescanned, &rescanned.Files[0]}}
+	before := []models.File{app.Files[0], app.Files[1], rescanned.Files[0]}
+	results := make([]models.File, len(refs))
+	for i := range results {
+		results[i] = before[i]
+		results[i].LocalHash = "new"
+		results[i].ConflictType = models.ConflictLocalModified
+	}
+	// A push settled plugins.conf while it was being hashed
+	app.Files[1].LocalHash = "pushed"
+
+	m.handleRehashed(rehashedMsg{refs: refs, before: before, results: results})
+	if app.Files[0].LocalHash != "new" {
+		t.Errorf("Expected the listed file updated, got hash %q", app.Files[0].LocalHash)
+	}
+	if app.Files[1].LocalHash != "pushed" {
+		t.Errorf("Expected the file changed since the rehash kept, got hash %q", app.Files[1].LocalHash)
+	}
+	if rescanned.Files[0].LocalHash != "" {
+		t.Errorf("Expected the file no longer listed left alone, got hash %q", rescanned.Files[0].LocalHash)
+	}
+}
+
+func TestRehashChanged_HeldBackDuringMerge(t *testing.T) {
+	m := &Model{screen: ScreenMerge, config: config.Default()}
+	if cmd := m.rehashChanged([]string{"/a"}); cmd == nil || !m.fsRetrying {
+		t.Fatal("Expected a retry scheduled while the merge screen is open")
+	}
+	if cmd := m.rehashChanged([]string{"/b"}); cmd != nil {
+		t.Error("Expected one retry at a time")
+	}
+	if len(m.fsPending) != 2 {
+		t.Fatalf("Expected both paths held back, got %v", m.fsPending)
+	}
+
+	m.screen = ScreenMain
+	m.Update(fsRetryMsg{})
+	if m.fsRetrying || len(m.fsPending) != 0 {
+		t.Errorf("Expected the held back paths re-hashed once the merge closed, got %v", m.fsPending)
+	}
+}